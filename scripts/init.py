@@ -42,6 +42,7 @@ def generate_config_file(
         auth_app_secret=auth_app_secret,
         auth_enabled=auth_enabled,
         auth_scheme=auth_scheme,
+        auth_type="local" if auth_scheme == "local" else "",
         auth_sync_workers=auth_sync_workers,
         auth_sync_interval_minutes=auth_sync_interval_minutes,
         full_text_search=full_text_search
@@ -52,6 +53,7 @@ def generate_config_file(
     # apiserver.conf
     apiserver_file_template_str = '''
 [auth]
+type = $auth_type
 address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
@@ -93,6 +95,14 @@ port = $redis_port
 pwd = $redis_pass
 database = 0
 
+[snapshot-ingest]
+enable = false
+tokens =
+minInterval = 60
+
+[snapshot-drift]
+retentionDays = 180
+
 [redis]
 host = $redis_host
 port = $redis_port
@@ -100,6 +110,7 @@ pwd = $redis_pass
 database = 0
 
 [auth]
+type = $auth_type
 address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
@@ -130,6 +141,31 @@ database = 0
 port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
+
+# stream all the events to a message bus, type is one of kafka, file, leave it empty to disable
+[sink]
+type =
+kafkaBrokers =
+kafkaTopic = bk_cmdb_event
+kafkaUser =
+kafkaPassword =
+filePath =
+
+# how many latest events are kept for the watch api
+[watch]
+logSize = 100000
+
+# the certificate files of the subscriptions' callback tls options must be in the directory,
+# the callback tls files are refused if it's empty
+[callback-tls]
+dir =
+
+# the full text search index is kept up to date by the event server if full_text_search is on
+[es]
+full_text_search = $full_text_search
+url = $es_url
+usr = $es_user
+pwd = $es_pass
 '''
 
     template = FileTemplate(eventserver_file_template_str)
@@ -152,6 +188,7 @@ port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
 [auth]
+type = $auth_type
 address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
@@ -189,6 +226,7 @@ res = conf/errors
 [language]
 res = conf/language
 [auth]
+type = $auth_type
 address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
@@ -234,6 +272,7 @@ maxIDleConns = 1000
     proc_file_template_str = '''
 
 [auth]
+type = $auth_type
 address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
@@ -264,9 +303,6 @@ port = $mongo_port
 maxOpenConns = 3000
 maxIDleConns = 1000
 enable = true
-
-[timer]
-spec = 00:30  # 00:00 - 23:59
 '''
     template = FileTemplate(operation_file_template_str)
     result = template.substitute(**context)
@@ -310,6 +346,7 @@ enable = true
 [level]
 businessTopoMax = 7
 [auth]
+type = $auth_type
 address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
@@ -381,6 +418,8 @@ database = 0
 port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
+[cron-operation-chart-refresh]
+spec = 30 0 * * *
 '''
     template = FileTemplate(taskserver_file_template_str)
     result = template.substitute(**context)
@@ -491,7 +530,7 @@ def main(argv):
       --blueking_cmdb_url  <blueking_cmdb_url>    the cmdb site url, eg: http://127.0.0.1:8088 or http://bk.tencent.com
       --blueking_paas_url  <blueking_paas_url>    the blueking paas url, eg: http://127.0.0.1:8088 or http://bk.tencent.com
       --listen_port        <listen_port>          the cmdb_webserver listen port, should be the port as same as -c <blueking_cmdb_url> specified, default:8083
-      --auth_scheme        <auth_scheme>          auth scheme, ex: internal, iam
+      --auth_scheme        <auth_scheme>          auth scheme, ex: internal, iam, local
       --auth_enabled       <auth_enabled>         iam auth enabled, true or false
       --auth_address       <auth_address>         iam address
       --auth_app_code      <auth_app_code>        app code for iam, default bk_cmdb
@@ -664,8 +703,8 @@ def main(argv):
             print('es url not start with http:// or https://')
             sys.exit()
 
-    if auth["auth_scheme"] not in ["internal", "iam"]:
-        print('auth_scheme can only be internal or iam')
+    if auth["auth_scheme"] not in ["internal", "iam", "local"]:
+        print('auth_scheme can only be internal, iam or local')
         sys.exit()
 
     if auth["auth_enabled"] not in ["true", "false"]:
@@ -0,0 +1,123 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apitoken
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+func (a *apiToken) CreateAPIToken(ctx context.Context, h http.Header, option metadata.APITokenOption) (*metadata.CreateAPITokenResult, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.CreateAPITokenResult `json:"data"`
+	}{}
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/create/auth/api_token").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("create api token failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *apiToken) ListAPIToken(ctx context.Context, h http.Header, option metadata.ListAPITokenOption) (*metadata.MultipleAPIToken, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.MultipleAPIToken `json:"data"`
+	}{}
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/findmany/auth/api_token").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("list api token failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *apiToken) DeleteAPIToken(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := new(metadata.BaseResp)
+
+	err := a.client.Delete().
+		WithContext(ctx).
+		SubResourcef("/delete/auth/api_token/%d", id).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("delete api token failed, http request failed, err: %+v, rid: %s", err, rid)
+		return errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return nil
+}
+
+func (a *apiToken) ValidateAPIToken(ctx context.Context, h http.Header, option metadata.ValidateAPITokenOption) (*metadata.APIToken, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.APIToken `json:"data"`
+	}{}
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/find/auth/api_token/validate").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("validate api token failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
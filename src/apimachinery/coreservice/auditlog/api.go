@@ -49,3 +49,17 @@ func (inst *auditlog) SearchAuditLog(ctx context.Context, h http.Header, param m
 		Into(resp)
 	return
 }
+
+func (inst *auditlog) VerifyAuditChain(ctx context.Context, h http.Header) (resp *metadata.AuditChainVerifyResponse, err error) {
+	resp = new(metadata.AuditChainVerifyResponse)
+	subPath := "/read/auditlog/chain/verify"
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -23,6 +23,7 @@ import (
 type AuditClientInterface interface {
 	SaveAuditLog(ctx context.Context, h http.Header, logs ...metadata.SaveAuditLogParams) (*metadata.Response, error)
 	SearchAuditLog(ctx context.Context, h http.Header, param metadata.QueryInput) (*metadata.AuditQueryResult, error)
+	VerifyAuditChain(ctx context.Context, h http.Header) (*metadata.AuditChainVerifyResponse, error)
 }
 
 func NewAuditClientInterface(client rest.ClientInterface) AuditClientInterface {
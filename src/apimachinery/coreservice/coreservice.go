@@ -15,9 +15,11 @@ package coreservice
 import (
 	"fmt"
 
+	"configcenter/src/apimachinery/coreservice/apitoken"
 	"configcenter/src/apimachinery/coreservice/association"
 	"configcenter/src/apimachinery/coreservice/auditlog"
 	"configcenter/src/apimachinery/coreservice/cloudsync"
+	"configcenter/src/apimachinery/coreservice/fulltext"
 	"configcenter/src/apimachinery/coreservice/host"
 	"configcenter/src/apimachinery/coreservice/hostapplyrule"
 	"configcenter/src/apimachinery/coreservice/instance"
@@ -26,6 +28,7 @@ import (
 	"configcenter/src/apimachinery/coreservice/model"
 	"configcenter/src/apimachinery/coreservice/operation"
 	"configcenter/src/apimachinery/coreservice/process"
+	"configcenter/src/apimachinery/coreservice/rbac"
 	"configcenter/src/apimachinery/coreservice/settemplate"
 	"configcenter/src/apimachinery/coreservice/synchronize"
 	ccSystem "configcenter/src/apimachinery/coreservice/system"
@@ -50,6 +53,9 @@ type CoreServiceClientInterface interface {
 	SetTemplate() settemplate.SetTemplateInterface
 	HostApplyRule() hostapplyrule.HostApplyRuleInterface
 	System() ccSystem.SystemClientInterface
+	FullText() fulltext.FullTextInterface
+	RBAC() rbac.RBACInterface
+	APIToken() apitoken.APITokenInterface
 }
 
 func NewCoreServiceClient(c *util.Capability, version string) CoreServiceClientInterface {
@@ -123,3 +129,15 @@ func (c *coreService) SetTemplate() settemplate.SetTemplateInterface {
 func (c *coreService) HostApplyRule() hostapplyrule.HostApplyRuleInterface {
 	return hostapplyrule.NewHostApplyRuleClient(c.restCli)
 }
+
+func (c *coreService) FullText() fulltext.FullTextInterface {
+	return fulltext.NewFullTextInterfaceClient(c.restCli)
+}
+
+func (c *coreService) RBAC() rbac.RBACInterface {
+	return rbac.NewRBACInterfaceClient(c.restCli)
+}
+
+func (c *coreService) APIToken() apitoken.APITokenInterface {
+	return apitoken.NewAPITokenInterfaceClient(c.restCli)
+}
@@ -468,3 +468,49 @@ func (h *host) UpdateHostCloudAreaField(ctx context.Context, header http.Header,
 	}
 	return nil
 }
+
+func (h *host) SearchHostDrift(ctx context.Context, header http.Header, option metadata.SearchHostDriftOption) (*metadata.MultipleHostDrift, errors.CCErrorCoder) {
+	rid := util.GetHTTPCCRequestID(header)
+
+	result := metadata.SearchHostDriftResult{}
+	subPath := "/findmany/host/drift/%d"
+
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath, option.HostID).
+		WithHeaders(header).
+		Do().
+		Into(&result)
+	if err != nil {
+		blog.Errorf("SearchHostDrift failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if result.Code > 0 || result.Result == false {
+		return nil, errors.New(result.Code, result.ErrMsg)
+	}
+	return &result.Data, nil
+}
+
+func (h *host) ListDriftedHosts(ctx context.Context, header http.Header, option metadata.ListDriftedHostsOption) (*metadata.MultipleDriftedHost, errors.CCErrorCoder) {
+	rid := util.GetHTTPCCRequestID(header)
+
+	result := metadata.ListDriftedHostsResult{}
+	subPath := "/findmany/hosts/drift/list_hosts"
+
+	err := h.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(&result)
+	if err != nil {
+		blog.Errorf("ListDriftedHosts failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if result.Code > 0 || result.Result == false {
+		return nil, errors.New(result.Code, result.ErrMsg)
+	}
+	return &result.Data, nil
+}
@@ -63,6 +63,10 @@ type HostClientInterface interface {
 
 	// update host's cloud area field
 	UpdateHostCloudAreaField(ctx context.Context, header http.Header, option metadata.UpdateHostCloudAreaFieldOption) errors.CCErrorCoder
+
+	// host drift history recorded from the snapshots
+	SearchHostDrift(ctx context.Context, header http.Header, option metadata.SearchHostDriftOption) (*metadata.MultipleHostDrift, errors.CCErrorCoder)
+	ListDriftedHosts(ctx context.Context, header http.Header, option metadata.ListDriftedHostsOption) (*metadata.MultipleDriftedHost, errors.CCErrorCoder)
 }
 
 func NewHostClientInterface(client rest.ClientInterface) HostClientInterface {
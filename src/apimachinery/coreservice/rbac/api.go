@@ -0,0 +1,349 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+func (r *rbac) CreateRole(ctx context.Context, h http.Header, option metadata.RBACRoleOption) (*metadata.RBACRole, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.RBACRole `json:"data"`
+	}{}
+
+	err := r.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/create/auth/role").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("create role failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (r *rbac) UpdateRole(ctx context.Context, h http.Header, id int64, option metadata.RBACRoleOption) (*metadata.RBACRole, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.RBACRole `json:"data"`
+	}{}
+
+	err := r.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/update/auth/role/%d", id).
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("update role failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (r *rbac) DeleteRole(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := new(metadata.BaseResp)
+
+	err := r.client.Delete().
+		WithContext(ctx).
+		SubResourcef("/delete/auth/role/%d", id).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("delete role failed, http request failed, err: %+v, rid: %s", err, rid)
+		return errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return nil
+}
+
+func (r *rbac) ListRole(ctx context.Context, h http.Header, option metadata.ListRBACOption) (*metadata.MultipleRBACRole, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.MultipleRBACRole `json:"data"`
+	}{}
+
+	err := r.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/findmany/auth/role").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("list role failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (r *rbac) CreateRoleBinding(ctx context.Context, h http.Header, option metadata.RBACRoleBindingOption) (*metadata.RBACRoleBinding, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.RBACRoleBinding `json:"data"`
+	}{}
+
+	err := r.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/create/auth/role_binding").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("create role binding failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (r *rbac) UpdateRoleBinding(ctx context.Context, h http.Header, id int64, option metadata.RBACRoleBindingOption) (*metadata.RBACRoleBinding, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.RBACRoleBinding `json:"data"`
+	}{}
+
+	err := r.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/update/auth/role_binding/%d", id).
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("update role binding failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (r *rbac) DeleteRoleBinding(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := new(metadata.BaseResp)
+
+	err := r.client.Delete().
+		WithContext(ctx).
+		SubResourcef("/delete/auth/role_binding/%d", id).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("delete role binding failed, http request failed, err: %+v, rid: %s", err, rid)
+		return errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return nil
+}
+
+func (r *rbac) ListRoleBinding(ctx context.Context, h http.Header, option metadata.ListRBACOption) (*metadata.MultipleRBACRoleBinding, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.MultipleRBACRoleBinding `json:"data"`
+	}{}
+
+	err := r.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/findmany/auth/role_binding").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("list role binding failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (r *rbac) CreateUserGroup(ctx context.Context, h http.Header, option metadata.RBACUserGroupOption) (*metadata.RBACUserGroup, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.RBACUserGroup `json:"data"`
+	}{}
+
+	err := r.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/create/auth/user_group").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("create user group failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (r *rbac) UpdateUserGroup(ctx context.Context, h http.Header, id int64, option metadata.RBACUserGroupOption) (*metadata.RBACUserGroup, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.RBACUserGroup `json:"data"`
+	}{}
+
+	err := r.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/update/auth/user_group/%d", id).
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("update user group failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (r *rbac) DeleteUserGroup(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := new(metadata.BaseResp)
+
+	err := r.client.Delete().
+		WithContext(ctx).
+		SubResourcef("/delete/auth/user_group/%d", id).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("delete user group failed, http request failed, err: %+v, rid: %s", err, rid)
+		return errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return nil
+}
+
+func (r *rbac) ListUserGroup(ctx context.Context, h http.Header, option metadata.ListRBACOption) (*metadata.MultipleRBACUserGroup, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.MultipleRBACUserGroup `json:"data"`
+	}{}
+
+	err := r.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/findmany/auth/user_group").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("list user group failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (r *rbac) GetUserPolicy(ctx context.Context, h http.Header, user string) (*metadata.RBACUserPolicy, errors.CCErrorCoder) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.RBACUserPolicy `json:"data"`
+	}{}
+
+	err := r.client.Post().
+		WithContext(ctx).
+		Body(metadata.RBACUserPolicyOption{User: user}).
+		SubResourcef("/find/auth/user_policy").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("get user policy failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
@@ -0,0 +1,46 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+type RBACInterface interface {
+	CreateRole(ctx context.Context, h http.Header, option metadata.RBACRoleOption) (*metadata.RBACRole, errors.CCErrorCoder)
+	UpdateRole(ctx context.Context, h http.Header, id int64, option metadata.RBACRoleOption) (*metadata.RBACRole, errors.CCErrorCoder)
+	DeleteRole(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder
+	ListRole(ctx context.Context, h http.Header, option metadata.ListRBACOption) (*metadata.MultipleRBACRole, errors.CCErrorCoder)
+	CreateRoleBinding(ctx context.Context, h http.Header, option metadata.RBACRoleBindingOption) (*metadata.RBACRoleBinding, errors.CCErrorCoder)
+	UpdateRoleBinding(ctx context.Context, h http.Header, id int64, option metadata.RBACRoleBindingOption) (*metadata.RBACRoleBinding, errors.CCErrorCoder)
+	DeleteRoleBinding(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder
+	ListRoleBinding(ctx context.Context, h http.Header, option metadata.ListRBACOption) (*metadata.MultipleRBACRoleBinding, errors.CCErrorCoder)
+	CreateUserGroup(ctx context.Context, h http.Header, option metadata.RBACUserGroupOption) (*metadata.RBACUserGroup, errors.CCErrorCoder)
+	UpdateUserGroup(ctx context.Context, h http.Header, id int64, option metadata.RBACUserGroupOption) (*metadata.RBACUserGroup, errors.CCErrorCoder)
+	DeleteUserGroup(ctx context.Context, h http.Header, id int64) errors.CCErrorCoder
+	ListUserGroup(ctx context.Context, h http.Header, option metadata.ListRBACOption) (*metadata.MultipleRBACUserGroup, errors.CCErrorCoder)
+	GetUserPolicy(ctx context.Context, h http.Header, user string) (*metadata.RBACUserPolicy, errors.CCErrorCoder)
+}
+
+func NewRBACInterfaceClient(client rest.ClientInterface) RBACInterface {
+	return &rbac{client: client}
+}
+
+type rbac struct {
+	client rest.ClientInterface
+}
@@ -102,3 +102,17 @@ func (e *eventServer) Rebook(ctx context.Context, ownerID string, appID string,
 		Into(resp)
 	return
 }
+
+func (e *eventServer) Watch(ctx context.Context, h http.Header, opt *metadata.ParamEventWatch) (resp *metadata.EventWatchResult, err error) {
+	resp = new(metadata.EventWatchResult)
+	subPath := "/watch"
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
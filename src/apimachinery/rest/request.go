@@ -252,6 +252,14 @@ func (r *Request) Do() *Result {
 		return result
 	}
 
+	// the timeout bounds the request with all of its retries, including reading the response body
+	var timeoutCtx context.Context
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		timeoutCtx, cancel = context.WithTimeout(context.Background(), r.timeout)
+		defer cancel()
+	}
+
 	maxRetryCycle := 3
 	var retries int
 	for try := 0; try < maxRetryCycle; try++ {
@@ -267,6 +275,9 @@ func (r *Request) Do() *Result {
 			if r.ctx != nil {
 				req.WithContext(r.ctx)
 			}
+			if timeoutCtx != nil {
+				req = req.WithContext(timeoutCtx)
+			}
 
 			req.Header = commonUtil.CloneHeader(r.headers)
 			if len(req.Header) == 0 {
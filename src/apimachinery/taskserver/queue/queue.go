@@ -14,6 +14,7 @@ package queue
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"configcenter/src/common/metadata"
 )
@@ -21,13 +22,21 @@ import (
 func (tq *taskQueue) Post(ctx context.Context, header http.Header, path string, data interface{}) (resp *metadata.Response, err error) {
 	resp = &metadata.Response{}
 
-	err = tq.client.Post().
+	req := tq.client.Post().
 		WithContext(ctx).
 		Body(data).
 		SubResourcef(path).
-		WithHeaders(header).
-		Do().
-		Into(resp)
+		WithHeaders(header)
 
+	// the context is not bound to the http request, its deadline is enforced with the request timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			return nil, context.DeadlineExceeded
+		}
+		req = req.WithTimeout(timeout)
+	}
+
+	err = req.Do().Into(resp)
 	return
 }
@@ -0,0 +1,62 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package queue
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"configcenter/src/apimachinery/flowctrl"
+	"configcenter/src/apimachinery/rest"
+	"configcenter/src/apimachinery/util"
+)
+
+type staticDiscovery []string
+
+func (d staticDiscovery) GetServers() ([]string, error) {
+	return d, nil
+}
+
+func TestPostTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the sub task hangs until the test is done
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewSychronizeClientInterface(rest.NewRESTClient(&util.Capability{
+		Client:   http.DefaultClient,
+		Discover: staticDiscovery{server.URL},
+		Throttle: flowctrl.NewMockRateLimiter(),
+	}, "/"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.Post(ctx, http.Header{}, "/task/sync", map[string]interface{}{})
+	if err == nil {
+		t.Fatalf("expect the hanging sub task to time out")
+	}
+	if cost := time.Since(start); cost > 2*time.Second {
+		t.Fatalf("expect the sub task to time out in 200ms, but it took %v", cost)
+	}
+
+	if _, err := client.Post(ctx, http.Header{}, "/task/sync", map[string]interface{}{}); err != context.DeadlineExceeded {
+		t.Fatalf("expect the expired context to fail the request directly, got %v", err)
+	}
+}
@@ -28,6 +28,26 @@ type TaskClientInterface interface {
 
 	TaskDetail(ctx context.Context, header http.Header, taskID string) (resp *metadata.TaskDetailResponse, err error)
 
+	// CancelTask 取消任务，未执行的子任务不再执行
+	CancelTask(ctx context.Context, header http.Header, taskID string) (resp *metadata.Response, err error)
+	// PauseTask 暂停任务，恢复前不再执行
+	PauseTask(ctx context.Context, header http.Header, taskID string) (resp *metadata.Response, err error)
+	// ResumeTask 恢复暂停的任务
+	ResumeTask(ctx context.Context, header http.Header, taskID string) (resp *metadata.Response, err error)
+	// UpdateTaskPriority 修改未完成任务的优先级，同一队列中优先级高的任务先执行
+	UpdateTaskPriority(ctx context.Context, header http.Header, taskID string, priority int64) (resp *metadata.Response, err error)
+
+	// UpsertCronJob 创建定时任务，已存在时替换
+	UpsertCronJob(ctx context.Context, header http.Header, data *metadata.UpsertCronJobRequest) (resp *metadata.UpsertCronJobResponse, err error)
+	// UpdateCronJob 修改定时任务的周期、启停状态和错过执行的处理策略，内置定时任务也可以修改
+	UpdateCronJob(ctx context.Context, header http.Header, name string, data *metadata.UpdateCronJobRequest) (resp *metadata.Response, err error)
+	// DeleteCronJob 删除定时任务及其执行历史
+	DeleteCronJob(ctx context.Context, header http.Header, name string) (resp *metadata.Response, err error)
+	// TriggerCronJob 立即执行一次定时任务
+	TriggerCronJob(ctx context.Context, header http.Header, name string) (resp *metadata.Response, err error)
+	ListCronJob(ctx context.Context, header http.Header, data *metadata.ListCronJobRequest) (resp *metadata.ListCronJobResponse, err error)
+	ListCronJobRun(ctx context.Context, header http.Header, name string, data *metadata.ListCronJobRunRequest) (resp *metadata.ListCronJobRunResponse, err error)
+
 	// TaskStatusToSuccess(ctx context.Context, header http.Header, taskID, subTaskID string) (resp *metadata.Response, err error)
 	// TaskStatusToFailure(ctx context.Context, header http.Header, taskID, subTaskID string, errResponse *metadata.Response) (resp *metadata.Response, err error)
 }
@@ -0,0 +1,104 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package task
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/metadata"
+)
+
+func (t *task) UpsertCronJob(ctx context.Context, header http.Header, data *metadata.UpsertCronJobRequest) (resp *metadata.UpsertCronJobResponse, err error) {
+	resp = new(metadata.UpsertCronJobResponse)
+	subPath := "/task/createorupdate/cron_job"
+
+	err = t.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (t *task) UpdateCronJob(ctx context.Context, header http.Header, name string, data *metadata.UpdateCronJobRequest) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/task/update/cron_job/name/%s"
+
+	err = t.client.Put().
+		WithContext(ctx).
+		Body(data).
+		SubResourcef(subPath, name).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (t *task) DeleteCronJob(ctx context.Context, header http.Header, name string) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/task/delete/cron_job/name/%s"
+
+	err = t.client.Delete().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath, name).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (t *task) TriggerCronJob(ctx context.Context, header http.Header, name string) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/task/trigger/cron_job/name/%s"
+
+	err = t.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath, name).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (t *task) ListCronJob(ctx context.Context, header http.Header, data *metadata.ListCronJobRequest) (resp *metadata.ListCronJobResponse, err error) {
+	resp = new(metadata.ListCronJobResponse)
+	subPath := "/task/findmany/cron_job"
+
+	err = t.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (t *task) ListCronJobRun(ctx context.Context, header http.Header, name string, data *metadata.ListCronJobRunRequest) (resp *metadata.ListCronJobRunResponse, err error) {
+	resp = new(metadata.ListCronJobRunResponse)
+	subPath := "/task/findmany/cron_job/name/%s/history"
+
+	err = t.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResourcef(subPath, name).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
@@ -95,6 +95,36 @@ func (t *task) TaskStatusToFailure(ctx context.Context, header http.Header, task
 	return
 }
 
+func (t *task) CancelTask(ctx context.Context, header http.Header, taskID string) (resp *metadata.Response, err error) {
+	return t.setTask(ctx, header, "/task/set/cancel/id/%s", taskID, nil)
+}
+
+func (t *task) PauseTask(ctx context.Context, header http.Header, taskID string) (resp *metadata.Response, err error) {
+	return t.setTask(ctx, header, "/task/set/pause/id/%s", taskID, nil)
+}
+
+func (t *task) ResumeTask(ctx context.Context, header http.Header, taskID string) (resp *metadata.Response, err error) {
+	return t.setTask(ctx, header, "/task/set/resume/id/%s", taskID, nil)
+}
+
+func (t *task) UpdateTaskPriority(ctx context.Context, header http.Header, taskID string, priority int64) (resp *metadata.Response, err error) {
+	body := metadata.UpdateTaskPriorityRequest{Priority: priority}
+	return t.setTask(ctx, header, "/task/set/priority/id/%s", taskID, body)
+}
+
+func (t *task) setTask(ctx context.Context, header http.Header, subPath, taskID string, body interface{}) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+
+	err = t.client.Put().
+		WithContext(ctx).
+		Body(body).
+		SubResourcef(subPath, taskID).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
 /*
 
 
@@ -103,5 +133,9 @@ func (t *task) TaskStatusToFailure(ctx context.Context, header http.Header, task
  http.MethodPost, Path: "/task/findone/detail/{task_id}", Handler: s.DetailTask})
  http.MethodPut, Path: "/task/set/status/sucess/id/{task_id}/sub_id/{sub_task_id}", Handler: s.StatusToSuccess})
  http.MethodPut, Path: "/task/set/status/failure/id/{task_id}/sub_id/{sub_task_id}", Handler: s.StatusToFailure})
+ http.MethodPut, Path: "/task/set/cancel/id/{task_id}", Handler: s.CancelTask})
+ http.MethodPut, Path: "/task/set/pause/id/{task_id}", Handler: s.PauseTask})
+ http.MethodPut, Path: "/task/set/resume/id/{task_id}", Handler: s.ResumeTask})
+ http.MethodPut, Path: "/task/set/priority/id/{task_id}", Handler: s.UpdateTaskPriority})
 
 */
@@ -48,6 +48,20 @@ func (t *instanceClient) DeleteInst(ctx context.Context, ownerID string, objID s
 	return
 }
 
+func (t *instanceClient) PreviewDeleteInsts(ctx context.Context, objID string, h http.Header, dat interface{}) (resp *metadata.DeleteInstPreviewResult, err error) {
+	resp = new(metadata.DeleteInstPreviewResult)
+	subPath := "/deletemany/instance/object/%s/preview"
+
+	err = t.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResourcef(subPath, objID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
 func (t *instanceClient) UpdateInst(ctx context.Context, ownerID string, objID string, instID int64, h http.Header, dat map[string]interface{}) (resp *metadata.Response, err error) {
 	resp = new(metadata.Response)
 	subPath := "/inst/%s/%s/%d"
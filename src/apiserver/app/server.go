@@ -68,7 +68,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	if err != nil {
 		return err
 	}
-	authorize, err := auth.NewAuthorize(nil, authConf, engine.Metric().Registry())
+	authorize, err := auth.NewAuthorize(nil, authConf, engine.CoreAPI, engine.Metric().Registry())
 	if err != nil {
 		return fmt.Errorf("new authorize failed, err: %v", err)
 	}
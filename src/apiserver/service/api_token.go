@@ -0,0 +1,172 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/auth/parser"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+const bearerPrefix = "Bearer "
+
+// apiTokenPathKeyword matches the apis to manage the api tokens, which can not be called with a token,
+// so that a token can not be used to create the tokens that live longer or have more permissions.
+const apiTokenPathKeyword = "/topo/api_token"
+
+// readActions are the actions allowed to the tokens of the read scope
+var readActions = map[meta.Action]bool{
+	meta.Find:              true,
+	meta.FindMany:          true,
+	meta.ModelTopologyView: true,
+}
+
+// globalReadTypes are the resources not belonging to any business that the tokens limited
+// to the businesses can find, they are the models which are needed to use the instances.
+var globalReadTypes = map[meta.ResourceType]bool{
+	meta.Business:              true,
+	meta.Model:                 true,
+	meta.ModelModule:           true,
+	meta.ModelSet:              true,
+	meta.MainlineModel:         true,
+	meta.MainlineModelTopology: true,
+	meta.AssociationType:       true,
+	meta.ModelAssociation:      true,
+	meta.ModelTopology:         true,
+	meta.ModelClassification:   true,
+	meta.ModelAttributeGroup:   true,
+	meta.ModelAttribute:        true,
+	meta.ModelUnique:           true,
+	meta.UserCustom:            true,
+}
+
+// apiTokenFilter authenticates the requests with the "Authorization: Bearer <token>" header,
+// the user and supplier account headers are set from the token instead of the request,
+// and the request is checked against the businesses and the scope of the token.
+// the requests without the header are passed through.
+func (s *service) apiTokenFilter(errFunc func() errors.CCErrorIf) func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
+	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
+		authorization := req.Request.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, bearerPrefix) {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
+		rid := util.GetHTTPCCRequestID(req.Request.Header)
+		if len(rid) == 0 {
+			rid = util.GenerateRID()
+			req.Request.Header.Set(common.BKHTTPCCRequestID, rid)
+		}
+		defErr := errFunc().CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+
+		header := make(http.Header)
+		header.Set(common.BKHTTPCCRequestID, rid)
+		header.Set(common.BKHTTPHeaderUser, common.CCSystemOperatorUserName)
+		header.Set(common.BKHTTPOwnerID, common.BKDefaultOwnerID)
+		header.Set(common.BKHTTPLanguage, util.GetLanguage(req.Request.Header))
+		clientIP, _, err := net.SplitHostPort(req.Request.RemoteAddr)
+		if err != nil {
+			clientIP = req.Request.RemoteAddr
+		}
+		option := metadata.ValidateAPITokenOption{
+			Token:    strings.TrimSpace(strings.TrimPrefix(authorization, bearerPrefix)),
+			ClientIP: clientIP,
+		}
+
+		token, ccErr := s.engine.CoreAPI.CoreService().APIToken().ValidateAPIToken(req.Request.Context(), header, option)
+		if ccErr != nil {
+			blog.Errorf("apiTokenFilter failed, validate token failed, url: %s, err: %v, rid: %s", req.Request.URL.Path, ccErr, rid)
+			status, code := http.StatusInternalServerError, ccErr.GetCode()
+			if code == common.CCErrAPITokenInvalid {
+				status = http.StatusUnauthorized
+			}
+			resp.WriteHeaderAndJson(status, metadata.BaseResp{Code: code, ErrMsg: defErr.Error(code).Error()}, restful.MIME_JSON)
+			return
+		}
+
+		// the token decides who the request is from, the headers of the request are ignored
+		req.Request.Header.Del("Authorization")
+		req.Request.Header.Del(common.BKHTTPOwner)
+		req.Request.Header.Set(common.BKHTTPHeaderUser, token.User)
+		req.Request.Header.Set(common.BKHTTPOwnerID, token.SupplierAccount)
+
+		if strings.Contains(req.Request.URL.Path, apiTokenPathKeyword) {
+			blog.Errorf("apiTokenFilter failed, token %d can not manage the tokens, rid: %s", token.ID, rid)
+			writeAPITokenNoPermission(resp, defErr)
+			return
+		}
+
+		attribute, err := parser.ParseAttribute(req, s.engine)
+		if err != nil {
+			blog.Errorf("apiTokenFilter failed, parse attribute for %s %s failed, err: %v, rid: %s", req.Request.Method, req.Request.URL.Path, err, rid)
+			writeAPITokenNoPermission(resp, defErr)
+			return
+		}
+		if err := checkAPITokenScope(token, req.Request.Method, attribute); err != nil {
+			blog.Errorf("apiTokenFilter failed, token %d of user %s, %s %s, err: %v, rid: %s", token.ID, token.User, req.Request.Method, req.Request.URL.Path, err, rid)
+			writeAPITokenNoPermission(resp, defErr)
+			return
+		}
+
+		fchain.ProcessFilter(req, resp)
+	}
+}
+
+func writeAPITokenNoPermission(resp *restful.Response, defErr errors.DefaultCCErrorIf) {
+	rsp := metadata.BaseResp{
+		Code:   common.CCErrAPITokenNoPermission,
+		ErrMsg: defErr.Error(common.CCErrAPITokenNoPermission).Error(),
+		Result: false,
+	}
+	resp.WriteHeaderAndJson(http.StatusForbidden, rsp, restful.MIME_JSON)
+}
+
+// checkAPITokenScope checks the resources of the request against the businesses and the scope of the token,
+// the skipped resources are taken as changes unless the request is a get, as they are not classified.
+func checkAPITokenScope(token *metadata.APIToken, method string, attribute *meta.AuthAttribute) error {
+	resources := attribute.Resources
+	if len(resources) == 0 {
+		resources = []meta.ResourceAttribute{{Basic: meta.Basic{Action: meta.SkipAction}}}
+	}
+
+	for _, resource := range resources {
+		isRead := readActions[resource.Action] || (resource.Action == meta.SkipAction && method == http.MethodGet)
+		if token.Scope != metadata.APITokenScopeWrite && !isRead {
+			return fmt.Errorf("%s %s is not allowed to the token of %s scope", resource.Action, resource.Type, token.Scope)
+		}
+		if len(token.BizIDs) == 0 {
+			continue
+		}
+		if resource.BusinessID == 0 {
+			if !isRead || !globalReadTypes[resource.Type] {
+				return fmt.Errorf("%s %s out of the businesses is not allowed to the token", resource.Action, resource.Type)
+			}
+			continue
+		}
+		if !token.AllowBiz(resource.BusinessID) {
+			return fmt.Errorf("business %d is not allowed to the token", resource.BusinessID)
+		}
+	}
+	return nil
+}
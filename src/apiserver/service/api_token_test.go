@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAPITokenScope(t *testing.T) {
+	resource := func(typ meta.ResourceType, action meta.Action, bizID int64) *meta.AuthAttribute {
+		return &meta.AuthAttribute{Resources: []meta.ResourceAttribute{{
+			Basic:      meta.Basic{Type: typ, Action: action},
+			BusinessID: bizID,
+		}}}
+	}
+	readAll := &metadata.APIToken{Scope: metadata.APITokenScopeRead}
+	writeBiz := &metadata.APIToken{Scope: metadata.APITokenScopeWrite, BizIDs: []int64{2}}
+
+	tests := []struct {
+		name      string
+		token     *metadata.APIToken
+		method    string
+		attribute *meta.AuthAttribute
+		allowed   bool
+	}{
+		{"read token finds hosts", readAll, http.MethodPost, resource(meta.HostInstance, meta.FindMany, 3), true},
+		{"read token updates hosts", readAll, http.MethodPut, resource(meta.HostInstance, meta.Update, 3), false},
+		{"read token gets skipped api", readAll, http.MethodGet, resource(meta.UserCustom, meta.SkipAction, 0), true},
+		{"read token posts skipped api", readAll, http.MethodPost, resource(meta.UserCustom, meta.SkipAction, 0), false},
+		{"read token posts unknown api", readAll, http.MethodPost, &meta.AuthAttribute{}, false},
+		{"biz token updates its business", writeBiz, http.MethodPut, resource(meta.HostInstance, meta.Update, 2), true},
+		{"biz token updates other business", writeBiz, http.MethodPut, resource(meta.HostInstance, meta.Update, 3), false},
+		{"biz token finds models", writeBiz, http.MethodPost, resource(meta.ModelAttribute, meta.FindMany, 0), true},
+		{"biz token updates models", writeBiz, http.MethodPut, resource(meta.ModelAttribute, meta.Update, 0), false},
+		{"biz token finds resource pool hosts", writeBiz, http.MethodPost, resource(meta.HostInstance, meta.FindMany, 0), false},
+	}
+	for _, test := range tests {
+		err := checkAPITokenScope(test.token, test.method, test.attribute)
+		require.Equal(t, test.allowed, err == nil, test.name)
+	}
+}
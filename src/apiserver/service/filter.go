@@ -43,6 +43,8 @@ const (
 
 func (s *service) URLFilterChan(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
 	rid := util.GetHTTPCCRequestID(req.Request.Header)
+	// the reverted audit log is only set by the audit log revert of topo server, never accepted from the callers
+	req.Request.Header.Del(common.BKHTTPCCRevertAuditID)
 
 	var kind RequestType
 	var err error
@@ -56,6 +56,8 @@ func (s *service) WebServices(auth authcenter.AuthConfig) []*restful.WebService
 	ws := &restful.WebService{}
 	ws.Path(rootPath)
 	ws.Filter(s.engine.Metric().RestfulMiddleWare)
+	// the api token sets the user and supplier account headers the global filter checks
+	ws.Filter(s.apiTokenFilter(getErrFun))
 	ws.Filter(rdapi.AllGlobalFilter(getErrFun))
 	ws.Produces(restful.MIME_JSON)
 	if s.authorizer.Enabled() == true {
@@ -17,8 +17,10 @@ import (
 	"errors"
 	"net/http"
 
+	"configcenter/src/apimachinery"
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/local"
 	"configcenter/src/auth/meta"
 	"configcenter/src/common/metadata"
 
@@ -78,6 +80,11 @@ type ResourceHandler interface {
 // This allows bk-cmdb to support other kind of auth center.
 // tls can be nil if it is not care.
 // authConfig is a way to parse configuration info for the connection to a auth center.
-func NewAuthorize(tls *util.TLSClientConfig, authConfig authcenter.AuthConfig, reg prometheus.Registerer) (Authorize, error) {
+// clientSet is used by the local authorizer to read the roles from core service.
+func NewAuthorize(tls *util.TLSClientConfig, authConfig authcenter.AuthConfig, clientSet apimachinery.ClientSetInterface,
+	reg prometheus.Registerer) (Authorize, error) {
+	if authConfig.IsLocal() {
+		return local.New(clientSet), nil
+	}
 	return authcenter.NewAuthCenter(tls, authConfig, reg)
 }
@@ -49,6 +49,16 @@ func ParseConfigFromKV(prefix string, configmap map[string]string) (AuthConfig,
 	if !auth.IsAuthed() {
 		return AuthConfig{}, nil
 	}
+
+	cfg.Type = configmap[prefix+".type"]
+	switch cfg.Type {
+	case "":
+	case AuthTypeLocal:
+		return cfg, nil
+	default:
+		return cfg, fmt.Errorf(`invalid auth "type" value %s`, cfg.Type)
+	}
+
 	enableSync, exist := configmap[prefix+".enableSync"]
 	if exist && len(enableSync) > 0 {
 		cfg.EnableSync, err = strconv.ParseBool(enableSync)
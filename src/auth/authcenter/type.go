@@ -21,7 +21,12 @@ const (
 	ScopeTypeIDBizName = "业务"
 )
 
+// AuthTypeLocal authorizes with the roles stored in cmdb itself instead of the auth center
+const AuthTypeLocal = "local"
+
 type AuthConfig struct {
+	// the type of the authorizer, empty for the blueking's auth center, or AuthTypeLocal
+	Type string
 	// blueking's auth center addresses
 	Address []string
 	// app code is used for authorize used.
@@ -36,6 +41,11 @@ type AuthConfig struct {
 	SyncIntervalMinutes int
 }
 
+// IsLocal returns if the local authorizer is used, the auth center configurations are ignored then.
+func (c AuthConfig) IsLocal() bool {
+	return c.Type == AuthTypeLocal
+}
+
 type RegisterInfo struct {
 	CreatorType string           `json:"creator_type"`
 	CreatorID   string           `json:"creator_id"`
@@ -22,6 +22,7 @@ import (
 	"configcenter/src/apimachinery/coreservice"
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/auth/meta"
+	"configcenter/src/auth/parser"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/condition"
@@ -487,6 +488,49 @@ func (am *AuthManager) AuthorizeByHostsIDs(ctx context.Context, header http.Head
 	return am.AuthorizeByHosts(ctx, header, action, hosts...)
 }
 
+// FilterAuthorizedHostIDs returns the hosts in hostIDs which the user is allowed to do the action on,
+// the unauthorized hosts are dropped instead of failing the whole check.
+func (am *AuthManager) FilterAuthorizedHostIDs(ctx context.Context, header http.Header, action meta.Action, hostIDs ...int64) ([]int64, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+
+	if !am.Enabled() {
+		return hostIDs, nil
+	}
+	if am.SkipReadAuthorization && (action == meta.Find || action == meta.FindMany) {
+		blog.V(4).Infof("skip authorization for reading, hosts: %+v, rid: %s", hostIDs, rid)
+		return hostIDs, nil
+	}
+
+	if len(hostIDs) == 0 {
+		return make([]int64, 0), nil
+	}
+	hosts, err := am.collectHostByHostIDs(ctx, header, hostIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("filter authorized hosts failed, get hosts by id failed, err: %+v, rid: %s", err, rid)
+	}
+	resources, err := am.MakeResourcesByHosts(ctx, header, action, hosts...)
+	if err != nil {
+		return nil, fmt.Errorf("make host resources failed, err: %+v", err)
+	}
+
+	commonInfo, err := parser.ParseCommonInfo(&header)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed, parse user info from header failed, err: %+v", err)
+	}
+	decisions, err := am.Authorize.AuthorizeBatch(ctx, commonInfo.User, resources...)
+	if err != nil {
+		return nil, fmt.Errorf("authorize failed, err: %+v", err)
+	}
+
+	authorizedIDs := make([]int64, 0)
+	for idx, decision := range decisions {
+		if decision.Authorized {
+			authorizedIDs = append(authorizedIDs, resources[idx].InstanceID)
+		}
+	}
+	return authorizedIDs, nil
+}
+
 func (am *AuthManager) AuthorizeByHostsIDsNoPermissionsResponse(businessID int64) metadata.BaseResp {
 
 	return metadata.BaseResp{}
@@ -0,0 +1,139 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"fmt"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common/metadata"
+)
+
+// resourceTypes is all the resource types the auth parser generates
+var resourceTypes = map[meta.ResourceType]bool{
+	meta.Business:                 true,
+	meta.Model:                    true,
+	meta.ModelModule:              true,
+	meta.ModelSet:                 true,
+	meta.MainlineModel:            true,
+	meta.MainlineModelTopology:    true,
+	meta.MainlineInstanceTopology: true,
+	meta.MainlineInstance:         true,
+	meta.AssociationType:          true,
+	meta.ModelAssociation:         true,
+	meta.ModelInstanceAssociation: true,
+	meta.ModelInstance:            true,
+	meta.ModelInstanceTopology:    true,
+	meta.ModelTopology:            true,
+	meta.ModelClassification:      true,
+	meta.ModelAttributeGroup:      true,
+	meta.ModelAttribute:           true,
+	meta.ModelUnique:              true,
+	meta.HostFavorite:             true,
+	meta.Process:                  true,
+	meta.ProcessServiceCategory:   true,
+	meta.ProcessServiceTemplate:   true,
+	meta.ProcessTemplate:          true,
+	meta.ProcessServiceInstance:   true,
+	meta.BizTopology:              true,
+	meta.HostInstance:             true,
+	meta.NetDataCollector:         true,
+	meta.DynamicGrouping:          true,
+	meta.EventPushing:             true,
+	meta.Plat:                     true,
+	meta.AuditLog:                 true,
+	meta.ResourceSync:             true,
+	meta.UserCustom:               true,
+	meta.SystemBase:               true,
+	meta.InstallBK:                true,
+	meta.SystemConfig:             true,
+	meta.SetTemplate:              true,
+	meta.OperationStatistic:       true,
+	meta.HostApply:                true,
+}
+
+// actions is all the actions the auth parser generates
+var actions = map[meta.Action]bool{
+	meta.Create:                         true,
+	meta.CreateMany:                     true,
+	meta.Update:                         true,
+	meta.UpdateMany:                     true,
+	meta.Delete:                         true,
+	meta.DeleteMany:                     true,
+	meta.Archive:                        true,
+	meta.Find:                           true,
+	meta.FindMany:                       true,
+	meta.Execute:                        true,
+	meta.MoveResPoolHostToBizIdleModule: true,
+	meta.AddHostToResourcePool:          true,
+	meta.MoveHostFromModuleToResPool:    true,
+	meta.MoveHostToBizFaultModule:       true,
+	meta.MoveHostToBizIdleModule:        true,
+	meta.MoveHostToBizRecycleModule:     true,
+	meta.MoveHostToAnotherBizModule:     true,
+	meta.CleanHostInSetOrModule:         true,
+	meta.MoveHostsToBusinessOrModule:    true,
+	meta.MoveBizHostToModule:            true,
+	meta.TransferHost:                   true,
+	meta.BoundModuleToProcess:           true,
+	meta.UnboundModuleToProcess:         true,
+	meta.ModelTopologyView:              true,
+	meta.ModelTopologyOperation:         true,
+	meta.AdminEntrance:                  true,
+}
+
+// ValidateGrants checks the resource types and actions of the grants are the ones
+// defined in auth/meta, or the wildcard "*".
+func ValidateGrants(grants []metadata.RBACGrant) error {
+	for _, grant := range grants {
+		if grant.ResourceType != metadata.RBACAny && !resourceTypes[meta.ResourceType(grant.ResourceType)] {
+			return fmt.Errorf("unknown resource type %s", grant.ResourceType)
+		}
+		for _, action := range grant.Actions {
+			if action != metadata.RBACAny && !actions[meta.Action(action)] {
+				return fmt.Errorf("unknown action %s of resource type %s", action, grant.ResourceType)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeAction maps the batch actions to the single ones, so that a grant
+// of an action covers both of them.
+func normalizeAction(action meta.Action) meta.Action {
+	switch action {
+	case meta.CreateMany:
+		return meta.Create
+	case meta.UpdateMany:
+		return meta.Update
+	case meta.DeleteMany:
+		return meta.Delete
+	case meta.FindMany:
+		return meta.Find
+	}
+	return action
+}
+
+// grantAllows checks if the grant allows the action on the resource type
+func grantAllows(grant metadata.RBACGrant, resourceType meta.ResourceType, action meta.Action) bool {
+	if grant.ResourceType != metadata.RBACAny && grant.ResourceType != string(resourceType) {
+		return false
+	}
+	action = normalizeAction(action)
+	for _, granted := range grant.Actions {
+		if granted == metadata.RBACAny || normalizeAction(meta.Action(granted)) == action {
+			return true
+		}
+	}
+	return false
+}
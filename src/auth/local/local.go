@@ -0,0 +1,360 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/authcenter/permit"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/auth"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// Authorizer authorizes the requests with the roles and role bindings stored in cmdb itself,
+// it's used when cmdb runs standalone without the blueking auth center.
+type Authorizer struct {
+	clientSet apimachinery.ClientSetInterface
+	// getPolicy gets the roles bound to the user directly or by the user groups
+	getPolicy func(ctx context.Context, user meta.UserInfo) (*metadata.RBACUserPolicy, error)
+	cache     *policyCache
+}
+
+// New creates a local authorizer which reads the policies through the core service
+func New(clientSet apimachinery.ClientSetInterface) *Authorizer {
+	a := &Authorizer{
+		clientSet: clientSet,
+		cache:     newPolicyCache(),
+	}
+	a.getPolicy = a.getUserPolicy
+	return a
+}
+
+func (a *Authorizer) Enabled() bool {
+	return auth.IsAuthed()
+}
+
+func (a *Authorizer) Authorize(ctx context.Context, attribute *meta.AuthAttribute) (decision meta.Decision, err error) {
+	if !auth.IsAuthed() {
+		return meta.Decision{Authorized: true}, nil
+	}
+	// filter out SkipAction, which set by api server to skip authorization
+	noSkipResources := make([]meta.ResourceAttribute, 0)
+	for _, resource := range attribute.Resources {
+		if resource.Action == meta.SkipAction {
+			continue
+		}
+		noSkipResources = append(noSkipResources, resource)
+	}
+	attribute.Resources = noSkipResources
+	if len(noSkipResources) == 0 {
+		blog.V(5).Infof("Authorize skip. auth attribute: %+v", attribute)
+		return meta.Decision{Authorized: true}, nil
+	}
+	batchResult, err := a.AuthorizeBatch(ctx, attribute.User, attribute.Resources...)
+	if err != nil {
+		blog.Errorf("AuthorizeBatch error. err:%s", err.Error())
+		return meta.Decision{}, err
+	}
+	noAuth := make([]string, 0)
+	for i, item := range batchResult {
+		if !item.Authorized {
+			noAuth = append(noAuth, fmt.Sprintf("resource [%v] permission deny by reason: %s", attribute.Resources[i].Type, item.Reason))
+		}
+	}
+
+	if len(noAuth) > 0 {
+		return meta.Decision{
+			Authorized: false,
+			Reason:     fmt.Sprintf("%v", noAuth),
+		}, nil
+	}
+
+	return meta.Decision{Authorized: true}, nil
+}
+
+func (a *Authorizer) AuthorizeBatch(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) (decisions []meta.Decision, err error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	decisions = make([]meta.Decision, len(resources))
+	if !auth.IsAuthed() {
+		for i := range decisions {
+			decisions[i].Authorized = true
+		}
+		return decisions, nil
+	}
+
+	p, err := a.policy(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, rsc := range resources {
+		if permit.ShouldSkipAuthorize(&rsc) {
+			decisions[index].Authorized = true
+			blog.V(5).Infof("skip authorization for resource: %+v, rid: %s", rsc, rid)
+			continue
+		}
+
+		// these resources are global, whatever the business is.
+		if rsc.Type == meta.MainlineModel || rsc.Type == meta.ModelTopology {
+			rsc.BusinessID = 0
+		}
+
+		if p.allows(rsc.Type, rsc.Action, rsc.BusinessID) {
+			decisions[index].Authorized = true
+			continue
+		}
+		decisions[index].Reason = fmt.Sprintf("no role of user %s grants action %s", user.UserName, rsc.Action)
+		if rsc.BusinessID > 0 {
+			decisions[index].Reason += fmt.Sprintf(" in business %d", rsc.BusinessID)
+		}
+	}
+
+	return decisions, nil
+}
+
+// GetAnyAuthorizedBusinessList returns the businesses the user has any role in,
+// all the businesses if the user has any role globally.
+func (a *Authorizer) GetAnyAuthorizedBusinessList(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	if !auth.IsAuthed() {
+		return make([]int64, 0), nil
+	}
+
+	p, err := a.policy(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if p.hasGlobal() {
+		return a.listBusinesses(ctx, user)
+	}
+	return p.businesses("", ""), nil
+}
+
+// GetExactAuthorizedBusinessList returns the businesses the user is allowed to find.
+func (a *Authorizer) GetExactAuthorizedBusinessList(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	if !auth.IsAuthed() {
+		return make([]int64, 0), nil
+	}
+
+	p, err := a.policy(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if p.allowsGlobally(meta.Business, meta.Find) {
+		return a.listBusinesses(ctx, user)
+	}
+	return p.businesses(meta.Business, meta.Find), nil
+}
+
+// ListAuthorizedResources lists the resources of the resource type the user is allowed to do the action,
+// the resource ids are in the same format with the auth center's, only cloud areas are supported for now.
+func (a *Authorizer) ListAuthorizedResources(ctx context.Context, username string, bizID int64, resourceType meta.ResourceType, action meta.Action) ([]authcenter.IamResource, error) {
+	if resourceType != meta.Plat {
+		return nil, fmt.Errorf("list authorized resources of resource type %s is not supported", resourceType)
+	}
+
+	user := meta.UserInfo{UserName: username, SupplierAccount: util.ExtractOwnerFromContext(ctx)}
+	p, err := a.policy(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	iamResources := make([]authcenter.IamResource, 0)
+	if !p.allows(resourceType, action, bizID) {
+		return iamResources, nil
+	}
+
+	query := &metadata.QueryCondition{
+		Fields: []string{common.BKCloudIDField},
+		Limit:  metadata.SearchLimit{Limit: common.BKNoLimit},
+	}
+	result, err := a.clientSet.CoreService().Instance().ReadInstance(ctx, a.header(ctx, user), common.BKInnerObjIDPlat, query)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Result {
+		return nil, errors.New(result.ErrMsg)
+	}
+	for _, plat := range result.Data.Info {
+		platID, err := plat.Int64(common.BKCloudIDField)
+		if err != nil {
+			return nil, fmt.Errorf("parse plat id failed, plat: %+v, err: %v", plat, err)
+		}
+		iamResources = append(iamResources, authcenter.IamResource{{
+			ResourceType: authcenter.SysInstance,
+			ResourceID:   fmt.Sprintf("plat:%d", platID),
+		}})
+	}
+	return iamResources, nil
+}
+
+// AdminEntrance returns the cmdb system if any role is bound to the user globally
+func (a *Authorizer) AdminEntrance(ctx context.Context, user meta.UserInfo) ([]string, error) {
+	systemList := make([]string, 0)
+	if !auth.IsAuthed() {
+		return systemList, nil
+	}
+
+	p, err := a.policy(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if p.hasGlobal() {
+		systemList = append(systemList, authcenter.SystemIDCMDB)
+	}
+	return systemList, nil
+}
+
+// GetAuthorizedAuditList returns the models whose audit logs the user is allowed to find in the business,
+// the grants are on the audit log resource type as a whole, so it's all the models or none of them.
+func (a *Authorizer) GetAuthorizedAuditList(ctx context.Context, user meta.UserInfo, businessID int64) ([]authcenter.AuthorizedResource, error) {
+	if !auth.IsAuthed() {
+		return nil, nil
+	}
+
+	p, err := a.policy(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if !p.allows(meta.AuditLog, meta.Find, businessID) {
+		return nil, nil
+	}
+
+	query := &metadata.QueryCondition{
+		Fields: []string{common.BKObjIDField},
+		Limit:  metadata.SearchLimit{Limit: common.BKNoLimit},
+	}
+	result, err := a.clientSet.CoreService().Model().ReadModel(ctx, a.header(ctx, user), query)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Result {
+		return nil, errors.New(result.ErrMsg)
+	}
+
+	resourceType := authcenter.SysAuditLog
+	if businessID > 0 {
+		resourceType = authcenter.BizAuditLog
+	}
+	authorized := authcenter.AuthorizedResource{
+		ActionID:     authcenter.Get,
+		ResourceType: resourceType,
+		ResourceIDs:  make([]authcenter.IamResource, 0),
+	}
+	for _, model := range result.Data.Info {
+		authorized.ResourceIDs = append(authorized.ResourceIDs, authcenter.IamResource{{
+			ResourceType: resourceType,
+			ResourceID:   model.Spec.ObjectID,
+		}})
+	}
+	return []authcenter.AuthorizedResource{authorized}, nil
+}
+
+// GetNoAuthSkipUrl has no permission apply page to skip to, the permissions are
+// granted by the administrators through the role binding apis.
+func (a *Authorizer) GetNoAuthSkipUrl(ctx context.Context, header http.Header, p []metadata.Permission) (url string, err error) {
+	return "", nil
+}
+
+// GetUserGroupMembers returns the members of the local user groups, user groups are not scoped to businesses.
+func (a *Authorizer) GetUserGroupMembers(ctx context.Context, header http.Header, bizID int64, groups []string) ([]authcenter.UserGroupMembers, error) {
+	if !auth.IsAuthed() {
+		return nil, errors.New("auth not enabled")
+	}
+
+	option := metadata.ListRBACOption{
+		Names: groups,
+		Page:  metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	result, err := a.clientSet.CoreService().RBAC().ListUserGroup(ctx, header, option)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]authcenter.UserGroupMembers, 0)
+	for _, group := range result.Info {
+		members = append(members, authcenter.UserGroupMembers{
+			ID:    group.ID,
+			Name:  group.Name,
+			Users: group.Members,
+		})
+	}
+	return members, nil
+}
+
+func (a *Authorizer) policy(ctx context.Context, user meta.UserInfo) (*policy, error) {
+	if p, exist := a.cache.get(user); exist {
+		return p, nil
+	}
+	userPolicy, err := a.getPolicy(ctx, user)
+	if err != nil {
+		blog.Errorf("get policy of user %s failed, err: %v, rid: %s", user.UserName, err, util.ExtractRequestIDFromContext(ctx))
+		return nil, err
+	}
+	p := newPolicy(userPolicy)
+	a.cache.set(user, p)
+	return p, nil
+}
+
+func (a *Authorizer) getUserPolicy(ctx context.Context, user meta.UserInfo) (*metadata.RBACUserPolicy, error) {
+	userPolicy, err := a.clientSet.CoreService().RBAC().GetUserPolicy(ctx, a.header(ctx, user), user.UserName)
+	if err != nil {
+		return nil, err
+	}
+	return userPolicy, nil
+}
+
+func (a *Authorizer) listBusinesses(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	query := &metadata.QueryCondition{
+		Fields: []string{common.BKAppIDField},
+		Limit:  metadata.SearchLimit{Limit: common.BKNoLimit},
+		Condition: mapstr.MapStr{
+			common.BKDataStatusField: mapstr.MapStr{common.BKDBNE: common.DataStatusDisabled},
+		},
+	}
+	result, err := a.clientSet.CoreService().Instance().ReadInstance(ctx, a.header(ctx, user), common.BKInnerObjIDApp, query)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Result {
+		return nil, errors.New(result.ErrMsg)
+	}
+
+	businessIDs := make([]int64, 0)
+	for _, biz := range result.Data.Info {
+		bizID, err := biz.Int64(common.BKAppIDField)
+		if err != nil {
+			return nil, fmt.Errorf("parse business id failed, business: %+v, err: %v", biz, err)
+		}
+		businessIDs = append(businessIDs, bizID)
+	}
+	return businessIDs, nil
+}
+
+func (a *Authorizer) header(ctx context.Context, user meta.UserInfo) http.Header {
+	header := http.Header{}
+	header.Set(common.BKHTTPHeaderUser, user.UserName)
+	header.Set(common.BKHTTPOwnerID, user.SupplierAccount)
+	header.Set(common.BKHTTPCCRequestID, util.ExtractRequestIDFromContext(ctx))
+	header.Set("Content-Type", "application/json")
+	return header
+}
@@ -0,0 +1,136 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common/metadata"
+)
+
+func newTestAuthorizer(items ...metadata.RBACPolicyItem) *Authorizer {
+	a := New(nil)
+	a.getPolicy = func(ctx context.Context, user meta.UserInfo) (*metadata.RBACUserPolicy, error) {
+		return &metadata.RBACUserPolicy{User: user.UserName, Items: items}, nil
+	}
+	return a
+}
+
+func TestAuthorizeBatch(t *testing.T) {
+	a := newTestAuthorizer(
+		metadata.RBACPolicyItem{
+			BizID:  0,
+			Grants: []metadata.RBACGrant{{ResourceType: string(meta.Model), Actions: []string{string(meta.Find)}}},
+		},
+		metadata.RBACPolicyItem{
+			BizID:  2,
+			Grants: []metadata.RBACGrant{{ResourceType: metadata.RBACAny, Actions: []string{string(meta.Update)}}},
+		},
+	)
+	user := meta.UserInfo{UserName: "tom", SupplierAccount: "0"}
+
+	cases := []struct {
+		name       string
+		resource   meta.ResourceAttribute
+		authorized bool
+	}{
+		{"global grant in system", resource(meta.Model, meta.Find, 0), true},
+		{"global grant in business", resource(meta.Model, meta.Find, 3), true},
+		{"batch action of granted action", resource(meta.Model, meta.FindMany, 0), true},
+		{"action not granted", resource(meta.Model, meta.Delete, 0), false},
+		{"business grant in the business", resource(meta.HostInstance, meta.UpdateMany, 2), true},
+		{"business grant in other business", resource(meta.HostInstance, meta.Update, 3), false},
+		{"business grant in system", resource(meta.HostInstance, meta.Update, 0), false},
+		{"global resource in business", resource(meta.ModelTopology, meta.Update, 2), false},
+	}
+
+	resources := make([]meta.ResourceAttribute, 0)
+	for _, c := range cases {
+		resources = append(resources, c.resource)
+	}
+	decisions, err := a.AuthorizeBatch(context.Background(), user, resources...)
+	if err != nil {
+		t.Fatalf("authorize batch failed, err: %v", err)
+	}
+	for i, c := range cases {
+		if decisions[i].Authorized != c.authorized {
+			t.Errorf("%s: got authorized %v, want %v, reason: %s", c.name, decisions[i].Authorized, c.authorized, decisions[i].Reason)
+		}
+	}
+}
+
+func TestAuthorizeWithoutRoles(t *testing.T) {
+	a := newTestAuthorizer()
+	attribute := &meta.AuthAttribute{
+		User:      meta.UserInfo{UserName: "tom", SupplierAccount: "0"},
+		Resources: []meta.ResourceAttribute{resource(meta.Business, meta.Find, 1)},
+	}
+	decision, err := a.Authorize(context.Background(), attribute)
+	if err != nil {
+		t.Fatalf("authorize failed, err: %v", err)
+	}
+	if decision.Authorized {
+		t.Errorf("user without roles should not be authorized")
+	}
+
+	attribute.Resources = []meta.ResourceAttribute{resource(meta.Business, meta.SkipAction, 1)}
+	decision, err = a.Authorize(context.Background(), attribute)
+	if err != nil {
+		t.Fatalf("authorize failed, err: %v", err)
+	}
+	if !decision.Authorized {
+		t.Errorf("skip action should be authorized")
+	}
+}
+
+func TestPolicyBusinesses(t *testing.T) {
+	p := newPolicy(&metadata.RBACUserPolicy{Items: []metadata.RBACPolicyItem{
+		{BizID: 1, Grants: []metadata.RBACGrant{{ResourceType: string(meta.Business), Actions: []string{metadata.RBACAny}}}},
+		{BizID: 2, Grants: []metadata.RBACGrant{{ResourceType: string(meta.HostInstance), Actions: []string{string(meta.Find)}}}},
+		{BizID: 2, Grants: []metadata.RBACGrant{{ResourceType: string(meta.Process), Actions: []string{string(meta.Find)}}}},
+	}})
+	if p.hasGlobal() {
+		t.Errorf("policy without global bindings should not be global")
+	}
+	if got := p.businesses("", ""); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("got any businesses %v, want [1 2]", got)
+	}
+	if got := p.businesses(meta.Business, meta.Find); len(got) != 1 || got[0] != 1 {
+		t.Errorf("got exact businesses %v, want [1]", got)
+	}
+}
+
+func TestValidateGrants(t *testing.T) {
+	valid := []metadata.RBACGrant{
+		{ResourceType: metadata.RBACAny, Actions: []string{metadata.RBACAny}},
+		{ResourceType: string(meta.HostInstance), Actions: []string{string(meta.Find), string(meta.TransferHost)}},
+	}
+	if err := ValidateGrants(valid); err != nil {
+		t.Errorf("validate grants failed, err: %v", err)
+	}
+	if err := ValidateGrants([]metadata.RBACGrant{{ResourceType: "host", Actions: []string{string(meta.Find)}}}); err == nil {
+		t.Errorf("unknown resource type should be invalid")
+	}
+	if err := ValidateGrants([]metadata.RBACGrant{{ResourceType: string(meta.HostInstance), Actions: []string{"read"}}}); err == nil {
+		t.Errorf("unknown action should be invalid")
+	}
+}
+
+func resource(resourceType meta.ResourceType, action meta.Action, bizID int64) meta.ResourceAttribute {
+	return meta.ResourceAttribute{
+		Basic:      meta.Basic{Type: resourceType, Action: action},
+		BusinessID: bizID,
+	}
+}
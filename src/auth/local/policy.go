@@ -0,0 +1,127 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"sync"
+	"time"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common/metadata"
+)
+
+// policyCacheTTL is how long the policy of a user is cached, the changes of the roles
+// and role bindings take effect after it at most.
+const policyCacheTTL = 10 * time.Second
+
+type policy struct {
+	items []metadata.RBACPolicyItem
+}
+
+func newPolicy(userPolicy *metadata.RBACUserPolicy) *policy {
+	if userPolicy == nil {
+		return &policy{}
+	}
+	return &policy{items: userPolicy.Items}
+}
+
+// allows checks if the user is allowed to do the action on the resource type in the business,
+// the grants of the global bindings are effective in all the businesses.
+func (p *policy) allows(resourceType meta.ResourceType, action meta.Action, bizID int64) bool {
+	for _, item := range p.items {
+		if item.BizID != 0 && item.BizID != bizID {
+			continue
+		}
+		for _, grant := range item.Grants {
+			if grantAllows(grant, resourceType, action) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allowsGlobally checks if the user is allowed to do the action on the resource type by a global binding
+func (p *policy) allowsGlobally(resourceType meta.ResourceType, action meta.Action) bool {
+	return p.allows(resourceType, action, 0)
+}
+
+// hasGlobal returns if any role is bound to the user globally
+func (p *policy) hasGlobal() bool {
+	for _, item := range p.items {
+		if item.BizID == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// businesses returns the businesses the user has roles bound in, if the resource type is not empty,
+// only the businesses in which the action on the resource type is allowed are returned.
+func (p *policy) businesses(resourceType meta.ResourceType, action meta.Action) []int64 {
+	bizIDs := make([]int64, 0)
+	seen := make(map[int64]bool)
+	for _, item := range p.items {
+		if item.BizID == 0 || seen[item.BizID] {
+			continue
+		}
+		if len(resourceType) > 0 && !p.allows(resourceType, action, item.BizID) {
+			continue
+		}
+		seen[item.BizID] = true
+		bizIDs = append(bizIDs, item.BizID)
+	}
+	return bizIDs
+}
+
+type cachedPolicy struct {
+	policy   *policy
+	expireAt time.Time
+}
+
+// policyCache caches the policies by the supplier account and the user name
+type policyCache struct {
+	lock     sync.RWMutex
+	policies map[string]cachedPolicy
+}
+
+func newPolicyCache() *policyCache {
+	return &policyCache{policies: make(map[string]cachedPolicy)}
+}
+
+func (c *policyCache) key(user meta.UserInfo) string {
+	return user.SupplierAccount + "/" + user.UserName
+}
+
+func (c *policyCache) get(user meta.UserInfo) (*policy, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	cached, exist := c.policies[c.key(user)]
+	if !exist || time.Now().After(cached.expireAt) {
+		return nil, false
+	}
+	return cached.policy, true
+}
+
+func (c *policyCache) set(user meta.UserInfo, p *policy) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := time.Now()
+	// drop the expired ones, so that the users left do not stay in memory
+	for key, cached := range c.policies {
+		if now.After(cached.expireAt) {
+			delete(c.policies, key)
+		}
+	}
+	c.policies[c.key(user)] = cachedPolicy{policy: p, expireAt: now.Add(policyCacheTTL)}
+}
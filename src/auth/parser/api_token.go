@@ -0,0 +1,50 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"net/http"
+	"regexp"
+
+	"configcenter/src/auth/meta"
+)
+
+// the api tokens belong to the current user, the tokens are managed without authorization.
+var APITokenAuthConfigs = []AuthConfig{
+	{
+		Name:           "CreateAPITokenPattern",
+		Description:    "创建API令牌",
+		Pattern:        "/api/v3/create/topo/api_token",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.UserCustom,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "ListAPITokenPattern",
+		Description:    "查询API令牌",
+		Pattern:        "/api/v3/findmany/topo/api_token",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.UserCustom,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "DeleteAPITokenRegex",
+		Description:    "删除API令牌",
+		Regex:          regexp.MustCompile(`^/api/v3/delete/topo/api_token/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.UserCustom,
+		ResourceAction: meta.SkipAction,
+	},
+}
+
+func (ps *parseStream) apiToken() *parseStream {
+	return ParseStreamWithFramework(ps, APITokenAuthConfigs)
+}
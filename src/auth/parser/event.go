@@ -36,11 +36,15 @@ var (
 	createSubscribeRegexp = regexp.MustCompile(`^/api/v3/event/subscribe/\S+/\d+/?$`)
 	updateSubscribeRegexp = regexp.MustCompile(`^/api/v3/event/subscribe/\S+/\d+/\d+/?$`)
 	deleteSubscribeRegexp = regexp.MustCompile(`^/api/v3/event/subscribe/\S+/\d+/\d+/?$`)
+
+	findDeadLetterRegexp    = regexp.MustCompile(`^/api/v3/event/subscribe/deadletter/search/[^\s/]+/\d+/\d+/?$`)
+	operateDeadLetterRegexp = regexp.MustCompile(`^/api/v3/event/subscribe/deadletter/(replay|purge)/[^\s/]+/\d+/\d+/?$`)
 )
 
 const (
 	telnetEventTestPattern = "/api/v3/event/subscribe/telnet"
 	pingEventTestPattern   = "/api/v3/event/subscribe/ping"
+	watchEventPattern      = "/api/v3/event/watch"
 )
 
 func (ps *parseStream) subscribe() *parseStream {
@@ -48,6 +52,46 @@ func (ps *parseStream) subscribe() *parseStream {
 		return ps
 	}
 
+	// find the dead letters of a subscription.
+	// the dead letter apis should be parsed before the others, because the subscription
+	// regexps can also match them.
+	if ps.hitRegexp(findDeadLetterRegexp, http.MethodPost) {
+		subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[8], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("find dead letters, but got invalid subscription id: %s", ps.RequestCtx.Elements[8])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:       meta.EventPushing,
+					Action:     meta.Find,
+					InstanceID: subscribeID,
+				},
+			},
+		}
+		return ps
+	}
+
+	// replay or purge the dead letters of a subscription
+	if ps.hitRegexp(operateDeadLetterRegexp, http.MethodPost) {
+		subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[8], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("%s dead letters, but got invalid subscription id: %s", ps.RequestCtx.Elements[5], ps.RequestCtx.Elements[8])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:       meta.EventPushing,
+					Action:     meta.Update,
+					InstanceID: subscribeID,
+				},
+			},
+		}
+		return ps
+	}
+
 	// find all the subscription
 	if ps.hitRegexp(findSubscribeRegexp, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
@@ -125,6 +169,19 @@ func (ps *parseStream) subscribe() *parseStream {
 		return ps
 	}
 
+	// watch the events after a cursor
+	if ps.hitPattern(watchEventPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
 	// ping event for testing.
 	if ps.hitPattern(pingEventTestPattern, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
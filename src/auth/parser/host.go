@@ -28,6 +28,7 @@ func (ps *parseStream) hostRelated() *parseStream {
 		hostFavorite().
 		cloudResourceSync().
 		hostSnapshot().
+		hostDrift().
 		findObjectIdentifier().
 		HostApply()
 	return ps
@@ -959,6 +960,48 @@ func (ps *parseStream) hostSnapshot() *parseStream {
 	return ps
 }
 
+var (
+	searchHostDriftAPIRegexp  = regexp.MustCompile(`^/api/v3/hosts/drift/[0-9]+/?$`)
+	listDriftedHostsAPIRegexp = regexp.MustCompile(`^/api/v3/hosts/drift/search/?$`)
+)
+
+// hostDrift the drift history apis authorize the hosts or the business themselves
+func (ps *parseStream) hostDrift() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitRegexp(searchHostDriftAPIRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 5 {
+			ps.err = errors.New("search host drift, but got invalid uri")
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.HostInstance,
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
+	if ps.hitRegexp(listDriftedHostsAPIRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.HostInstance,
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+	return ps
+}
+
 var (
 	findIdentifierAPIRegexp = regexp.MustCompile(`^/api/v3/identifier/[^\s/]+/search/?$`)
 )
@@ -0,0 +1,120 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"net/http"
+	"regexp"
+
+	"configcenter/src/auth/meta"
+)
+
+// the roles, role bindings and user groups of the local authorizer are managed as the system base.
+var RBACAuthConfigs = []AuthConfig{
+	{
+		Name:           "CreateRBACRoleRegex",
+		Description:    "创建角色",
+		Pattern:        "/api/v3/create/topo/auth/role",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.Create,
+	}, {
+		Name:           "UpdateRBACRoleRegex",
+		Description:    "更新角色",
+		Regex:          regexp.MustCompile(`^/api/v3/update/topo/auth/role/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "DeleteRBACRoleRegex",
+		Description:    "删除角色",
+		Regex:          regexp.MustCompile(`^/api/v3/delete/topo/auth/role/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.Delete,
+	}, {
+		Name:           "ListRBACRoleRegex",
+		Description:    "查询角色",
+		Pattern:        "/api/v3/findmany/topo/auth/role",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.FindMany,
+	}, {
+		Name:           "CreateRBACRoleBindingRegex",
+		Description:    "创建角色授权",
+		Pattern:        "/api/v3/create/topo/auth/role_binding",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.Create,
+	}, {
+		Name:           "UpdateRBACRoleBindingRegex",
+		Description:    "更新角色授权",
+		Regex:          regexp.MustCompile(`^/api/v3/update/topo/auth/role_binding/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "DeleteRBACRoleBindingRegex",
+		Description:    "删除角色授权",
+		Regex:          regexp.MustCompile(`^/api/v3/delete/topo/auth/role_binding/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.Delete,
+	}, {
+		Name:           "ListRBACRoleBindingRegex",
+		Description:    "查询角色授权",
+		Pattern:        "/api/v3/findmany/topo/auth/role_binding",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.FindMany,
+	}, {
+		Name:           "CreateRBACUserGroupRegex",
+		Description:    "创建用户组",
+		Pattern:        "/api/v3/create/topo/auth/user_group",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.Create,
+	}, {
+		Name:           "UpdateRBACUserGroupRegex",
+		Description:    "更新用户组",
+		Regex:          regexp.MustCompile(`^/api/v3/update/topo/auth/user_group/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "DeleteRBACUserGroupRegex",
+		Description:    "删除用户组",
+		Regex:          regexp.MustCompile(`^/api/v3/delete/topo/auth/user_group/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.Delete,
+	}, {
+		Name:           "ListRBACUserGroupRegex",
+		Description:    "查询用户组",
+		Pattern:        "/api/v3/findmany/topo/auth/user_group",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.FindMany,
+	}, {
+		Name:           "GetRBACUserPolicyRegex",
+		Description:    "查询用户权限",
+		Pattern:        "/api/v3/find/topo/auth/user_policy",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.SystemBase,
+		ResourceAction: meta.Find,
+	},
+}
+
+func (ps *parseStream) rbac() *parseStream {
+	return ParseStreamWithFramework(ps, RBACAuthConfigs)
+}
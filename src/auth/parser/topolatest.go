@@ -48,7 +48,9 @@ func (ps *parseStream) topologyLatest() *parseStream {
 		objectAttributeGroupLatest().
 		objectAttributeLatest().
 		mainlineLatest().
-		SetTemplate()
+		SetTemplate().
+		rbac().
+		apiToken()
 
 	return ps
 }
@@ -739,6 +741,7 @@ var (
 	updateObjectInstanceLatestRegexp          = regexp.MustCompile(`^/api/v3/update/instance/object/[^\s/]+/inst/[0-9]+/?$`)
 	updateObjectInstanceBatchLatestRegexp     = regexp.MustCompile(`^/api/v3/updatemany/instance/object/[^\s/]+/?$`)
 	deleteObjectInstanceBatchLatestRegexp     = regexp.MustCompile(`^/api/v3/deletemany/instance/object/[^\s/]+/?$`)
+	previewDeleteObjectInstanceLatestRegexp   = regexp.MustCompile(`^/api/v3/deletemany/instance/object/[^\s/]+/preview/?$`)
 	deleteObjectInstanceLatestRegexp          = regexp.MustCompile(`^/api/v3/delete/instance/object/[^\s/]+/inst/[0-9]+/?$`)
 	// TODO remove it
 	findObjectInstanceSubTopologyLatestRegexp = regexp.MustCompile(`^/api/v3/find/insttopo/object/[^\s/]+/inst/[0-9]+/?$`)
@@ -976,6 +979,42 @@ func (ps *parseStream) objectInstanceLatest() *parseStream {
 		return ps
 	}
 
+	// preview what will be deleted with the instances, which is a read only operation
+	if ps.hitRegexp(previewDeleteObjectInstanceLatestRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 7 {
+			ps.err = errors.New("preview delete object instance, but got invalid url")
+			return ps
+		}
+
+		bizID, err := metadata.BizIDFromMetadata(ps.RequestCtx.Metadata)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		filter := mapstr.MapStr{
+			common.BKObjIDField: ps.RequestCtx.Elements[5],
+		}
+		model, err := ps.getOneModel(filter)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.ModelInstance,
+					Action: meta.FindMany,
+				},
+				Layers: []meta.Item{{Type: meta.Model, InstanceID: model.ID}},
+			},
+		}
+
+		return ps
+	}
+
 	// delete instance operation.
 	if ps.hitRegexp(deleteObjectInstanceLatestRegexp, http.MethodDelete) {
 		if len(ps.RequestCtx.Elements) != 8 {
@@ -2413,7 +2413,11 @@ func (ps *parseStream) objectUnique() *parseStream {
 
 var (
 	searchAuditLog               = `/api/v3/audit/search`
+	verifyAuditLogChain          = `/api/v3/find/audit/chain/verify`
 	searchInstanceAuditLogRegexp = regexp.MustCompile(`^/api/v3/object/[^\s/]+/audit/search/?$`)
+	findInstanceHistoryRegexp    = regexp.MustCompile(`^/api/v3/find/audit/history/(diff/)?object/[^\s/]+/inst/[0-9]+/?$`)
+	findBizTopoHistoryRegexp     = regexp.MustCompile(`^/api/v3/find/audit/history/topo/biz/[0-9]+/?$`)
+	revertAuditLogRegexp         = regexp.MustCompile(`^/api/v3/update/audit/[0-9]+/revert/?$`)
 )
 
 func (ps *parseStream) audit() *parseStream {
@@ -2421,7 +2425,7 @@ func (ps *parseStream) audit() *parseStream {
 		return ps
 	}
 
-	if ps.hitPattern(searchAuditLog, http.MethodPost) {
+	if ps.hitPattern(searchAuditLog, http.MethodPost) || ps.hitPattern(verifyAuditLogChain, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
 			{
 				Basic: meta.Basic{
@@ -2443,7 +2447,10 @@ func (ps *parseStream) instanceAudit() *parseStream {
 	}
 
 	// add object unique operation.
-	if ps.hitRegexp(searchInstanceAuditLogRegexp, http.MethodPost) {
+	if ps.hitRegexp(searchInstanceAuditLogRegexp, http.MethodPost) ||
+		ps.hitRegexp(findInstanceHistoryRegexp, http.MethodPost) ||
+		ps.hitRegexp(findBizTopoHistoryRegexp, http.MethodPost) ||
+		ps.hitRegexp(revertAuditLogRegexp, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
 			{
 				Basic: meta.Basic{
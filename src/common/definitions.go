@@ -261,6 +261,12 @@ const (
 	// BKAttrChangedHost the cloud sync attr changed hosts
 	BKAttrChangedHost = "attr_changed"
 
+	// BKRemovedHost the cloud sync hosts which are removed from the cloud
+	BKRemovedHost = "removed"
+
+	// BKCloudInstIDField the host's instance id in the cloud
+	BKCloudInstIDField = "bk_cloud_inst_id"
+
 	// BKCloudConfirm whether new add cloud hosts need confirm
 	BKCloudConfirm = "bk_confirm"
 
@@ -310,12 +316,21 @@ const (
 	// BKSubscriptionNameField the subscription name field
 	BKSubscriptionNameField = "subscription_name"
 
+	// BKEventIDField the event id field
+	BKEventIDField = "event_id"
+
 	// BKOSTypeField the os type field
 	BKOSTypeField = "bk_os_type"
 
 	// BKOSNameField the os name field
 	BKOSNameField = "bk_os_name"
 
+	// BKHostCPUField the host cpu logical cores field
+	BKHostCPUField = "bk_cpu"
+
+	// BKHostMemField the host memory field, in MB
+	BKHostMemField = "bk_mem"
+
 	// BKHttpGet the http get
 	BKHttpGet = "GET"
 
@@ -889,6 +904,9 @@ const (
 	BKHTTPCCRequestTime     = "Cc_Request_Time"
 	BKHTTPCCTransactionID   = "Cc_Txn_Id"
 	BKHTTPCCTxnTMServerAddr = "Cc_Txn_Tm_addr-Ip"
+	// BKHTTPCCRevertAuditID the id of the audit log which is being reverted by the request,
+	// the audit logs recorded with this header are linked to the reverted one.
+	BKHTTPCCRevertAuditID = "Cc_Revert_Audit_Id"
 )
 
 type CCContextKey string
@@ -965,6 +983,8 @@ const (
 
 const (
 	BKDefaultLoginUserPluginVersion = "self"
+	BKOIDCLoginUserPluginVersion    = "oidc"
+	BKLDAPLoginUserPluginVersion    = "ldap"
 	HTTPCookieBKToken               = "bk_token"
 
 	WEBSessionUinKey           = "username"
@@ -985,6 +1005,11 @@ const (
 	LogoutHTTPSchemeCookieKey = "http_scheme"
 	LogoutHTTPSchemeHTTP      = "http"
 	LogoutHTTPSchemeHTTPS     = "https"
+
+	// WebLoginPagePath is the login page of the login plugins which authenticate with the user name and password
+	WebLoginPagePath = "/login"
+	// WebLoginRedirectKey is the query key of the url redirected to after login
+	WebLoginRedirectKey = "c_url"
 )
 
 const (
@@ -1021,9 +1046,8 @@ const (
 	RedisProcSrvHostInstanceRefreshModuleKey  = BKCacheKeyV3Prefix + "prochostinstancerefresh:set"
 	RedisProcSrvHostInstanceAllRefreshLockKey = BKCacheKeyV3Prefix + "lock:prochostinstancerefresh"
 	RedisProcSrvQueryProcOPResultKey          = BKCacheKeyV3Prefix + "procsrv:query:opresult:set"
-	RedisCloudSyncInstancePendingStart        = BKCacheKeyV3Prefix + "cloudsyncinstancependingstart:list"
-	RedisCloudSyncInstanceStarted             = BKCacheKeyV3Prefix + "cloudsyncinstancestarted:list"
-	RedisCloudSyncInstancePendingStop         = BKCacheKeyV3Prefix + "cloudsyncinstancependingstop:list"
+	// RedisCloudSyncTaskInstancesPrefix the prefix of the set which saves the cloud instance ids a task synced last time
+	RedisCloudSyncTaskInstancesPrefix = BKCacheKeyV3Prefix + "cloudsynctaskinstances:"
 )
 
 // association fields
@@ -233,6 +233,10 @@ const (
 	CCErrAPIGetAuthorizedAppListFromAuthFailed = 1100001
 	CCErrAPIGetUserResourceAuthStatusFailed    = 1100002
 	CCErrAPINoObjectInstancesIsFound           = 1100003
+	// CCErrAPITokenInvalid the api token is invalid or expired
+	CCErrAPITokenInvalid = 1100004
+	// CCErrAPITokenNoPermission the api token is not allowed to do the operation
+	CCErrAPITokenNoPermission = 1100005
 
 	// toposerver 1101XXX
 	// CCErrTopoInstCreateFailed unable to create the instance
@@ -415,6 +419,8 @@ const (
 	CCErrTopoObjectUniqueKeyKindInvalid                       = 1101166
 	CCErrTopoObjectUniquePresetCouldNotDelOrEdit              = 1101167
 	CCErrTopoObjectUniqueCanNotHasMultipleMustCheck           = 1101168
+	CCErrTopoObjectUniqueAssociationInvalid                   = 1101169
+	CCErrTopoAssociationUsedByUnique                          = 1101170
 	CCErrTopoObjectUniqueShouldHaveMoreThanOne                = 1101069
 	// association kind has been apply to object
 	CCErrorTopoAssKindHasApplyToObject = 1101070
@@ -497,6 +503,16 @@ const (
 	CCErrEventSubscribeTelnetFailed = 1103005
 	// CCErrEventOperateSuccessBUtSentEventFailed failed to sent event
 	CCErrEventPushEventFailed = 1103006
+	// CCErrEventDeadLetterSelectFailed failed to query the dead letters
+	CCErrEventDeadLetterSelectFailed = 1103007
+	// CCErrEventDeadLetterReplayFailed failed to replay the dead letters
+	CCErrEventDeadLetterReplayFailed = 1103008
+	// CCErrEventDeadLetterPurgeFailed failed to purge the dead letters
+	CCErrEventDeadLetterPurgeFailed = 1103009
+	// CCErrEventWatchFailed failed to watch the events
+	CCErrEventWatchFailed = 1103010
+	// CCErrEventWatchCursorExpired the events after the cursor have been removed from the watch log
+	CCErrEventWatchCursorExpired = 1103011
 
 	// host 1104XXX
 	CCErrHostModuleRelationAddFailed = 1104000
@@ -598,6 +614,14 @@ const (
 	// audit log 1109XXX
 	CCErrAuditSaveLogFailed      = 1109001
 	CCErrAuditTakeSnapshotFailed = 1109001
+	// CCErrAuditLogNotFound the audit log is not exist
+	CCErrAuditLogNotFound = 1109002
+	// CCErrAuditRevertNotSupported the audit log can not be reverted
+	CCErrAuditRevertNotSupported = 1109003
+	// CCErrAuditRevertConflict the instance is changed after the audit log
+	CCErrAuditRevertConflict = 1109004
+	// CCErrAuditRevertCascade the revert deletes other instances with cascade
+	CCErrAuditRevertCascade = 1109005
 
 	// host server
 	CCErrHostGetFail              = 1110001
@@ -707,6 +731,12 @@ const (
 	CCErrCollectNetHistorySearchFail           = 1112016
 	CCErrCollectNetDeviceUpdateFail            = 1112017
 	CCErrCollectNetPropertyUpdateFail          = 1112018
+	CCErrCollectSnapMappingRuleInvalid         = 1112019
+	CCErrCollectSnapMappingRuleNotExist        = 1112020
+	CCErrCollectSnapIngestUnauthorized         = 1112021
+	CCErrCollectSnapIngestInvalid              = 1112022
+	CCErrCollectSnapIngestTooFrequent          = 1112023
+	CCErrCollectSnapIngestFailed               = 1112024
 
 	// coreservice 1113xxx
 	// CCErrorModelAttributeGroupHasSomeAttributes the group has some attributes
@@ -798,6 +828,12 @@ const (
 	CCErrTaskLockedTaskFail       = 1117005
 	CCErrTaskUnLockedTaskFail     = 1117006
 	CCErrTaskListTaskFail         = 1117007
+	// CCErrTaskCronJobNotFound cron job not found
+	CCErrTaskCronJobNotFound = 1117008
+	// CCErrTaskCronJobSpecInvalid cron job spec is not a valid cron expression
+	CCErrTaskCronJobSpecInvalid = 1117009
+	// CCErrTaskCronJobBuiltinNotDeletable the builtin cron job can only be disabled
+	CCErrTaskCronJobBuiltinNotDeletable = 1117010
 
 	/** TODO: 以下错误码需要改造 **/
 
@@ -11,6 +11,9 @@ const (
 
 	// CreateModuleAttrFormat create model  attribute format
 	CreateModuleAttrFormat = "coreservice:create:model:%s:attr:%s"
+
+	// SealAuditChainFormat seal the audit log hash chain
+	SealAuditChainFormat = "coreservice:seal:auditlog:chain"
 )
 
 // StrFormat  build  lock key format
@@ -0,0 +1,115 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// APITokenScopeRead allows the token to find the resources only
+	APITokenScopeRead = "read"
+	// APITokenScopeWrite allows the token to find and change the resources
+	APITokenScopeWrite = "write"
+
+	// APITokenDefaultExpireDays is the lifetime of the token if the expire days is not set
+	APITokenDefaultExpireDays = 30
+	// APITokenMaxExpireDays is the max lifetime of a token
+	APITokenMaxExpireDays = 365
+)
+
+// APIToken is a personal token to call the api server as the user, only the hash of
+// the token is kept, the token itself is returned once when it is created.
+type APIToken struct {
+	ID        int64  `field:"id" json:"id" bson:"id" mapstructure:"id"`
+	Name      string `field:"name" json:"name" bson:"name" mapstructure:"name"`
+	User      string `field:"bk_username" json:"bk_username" bson:"bk_username" mapstructure:"bk_username"`
+	TokenHash string `field:"token_hash" json:"-" bson:"token_hash" mapstructure:"-"`
+	// TokenPrefix is the beginning of the token to tell the tokens apart
+	TokenPrefix string `field:"token_prefix" json:"token_prefix" bson:"token_prefix" mapstructure:"token_prefix"`
+	// BizIDs limits the token to the businesses, the token can be used in all the businesses if it is empty
+	BizIDs    []int64   `field:"bk_biz_ids" json:"bk_biz_ids" bson:"bk_biz_ids" mapstructure:"bk_biz_ids"`
+	Scope     string    `field:"scope" json:"scope" bson:"scope" mapstructure:"scope"`
+	ExpiredAt time.Time `field:"expired_at" json:"expired_at" bson:"expired_at" mapstructure:"expired_at"`
+
+	LastUsedTime *time.Time `field:"last_used_time" json:"last_used_time" bson:"last_used_time" mapstructure:"last_used_time"`
+	LastUsedIP   string     `field:"last_used_ip" json:"last_used_ip" bson:"last_used_ip" mapstructure:"last_used_ip"`
+	UseCount     int64      `field:"use_count" json:"use_count" bson:"use_count" mapstructure:"use_count"`
+
+	CreateTime      time.Time `field:"create_time" json:"create_time" bson:"create_time" mapstructure:"create_time"`
+	SupplierAccount string    `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account" mapstructure:"bk_supplier_account"`
+}
+
+// AllowBiz checks whether the token can be used in the business
+func (t *APIToken) AllowBiz(bizID int64) bool {
+	if len(t.BizIDs) == 0 {
+		return true
+	}
+	for _, id := range t.BizIDs {
+		if id == bizID {
+			return true
+		}
+	}
+	return false
+}
+
+type APITokenOption struct {
+	Name       string  `json:"name" mapstructure:"name"`
+	BizIDs     []int64 `json:"bk_biz_ids" mapstructure:"bk_biz_ids"`
+	Scope      string  `json:"scope" mapstructure:"scope"`
+	ExpireDays int64   `json:"expire_days" mapstructure:"expire_days"`
+}
+
+// Validate checks the api token option, returns the invalid key with the error
+func (o *APITokenOption) Validate() (string, error) {
+	if len(o.Name) == 0 {
+		return "name", fmt.Errorf("token name should not be empty")
+	}
+	if o.Scope != APITokenScopeRead && o.Scope != APITokenScopeWrite {
+		return "scope", fmt.Errorf("scope should be %s or %s", APITokenScopeRead, APITokenScopeWrite)
+	}
+	for _, bizID := range o.BizIDs {
+		if bizID <= 0 {
+			return "bk_biz_ids", fmt.Errorf("invalid business id %d", bizID)
+		}
+	}
+	if o.ExpireDays < 0 || o.ExpireDays > APITokenMaxExpireDays {
+		return "expire_days", fmt.Errorf("expire days should be between 0 and %d, 0 means never expire", APITokenMaxExpireDays)
+	}
+	return "", nil
+}
+
+// CreateAPITokenResult is the created token with the token itself
+type CreateAPITokenResult struct {
+	APIToken `json:",inline" mapstructure:",squash"`
+	Token    string `json:"token" mapstructure:"token"`
+}
+
+// ListAPITokenOption filters the tokens of the user, all the tokens of the user if the ids are empty
+type ListAPITokenOption struct {
+	IDs  []int64  `json:"ids" mapstructure:"ids"`
+	Page BasePage `json:"page" mapstructure:"page"`
+}
+
+type MultipleAPIToken struct {
+	Count int64      `json:"count" mapstructure:"count"`
+	Info  []APIToken `json:"info" mapstructure:"info"`
+}
+
+// ValidateAPITokenOption is used by the api server to check the token of a request
+type ValidateAPITokenOption struct {
+	Token string `json:"token" mapstructure:"token"`
+	// ClientIP is recorded as the last used ip of the token
+	ClientIP string `json:"client_ip" mapstructure:"client_ip"`
+}
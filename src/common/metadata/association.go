@@ -204,7 +204,8 @@ type AssociationOnDeleteAction string
 type AssociationMapping string
 
 const (
-	// this is a default action, which is do nothing when a association between object is deleted.
+	// this is a default action, which is do nothing when a association between object is deleted,
+	// so an instance can not be deleted while it's still associated with other instances.
 	NoAction AssociationOnDeleteAction = "none"
 	// delete related source object instances when the association is deleted.
 	DeleteSource AssociationOnDeleteAction = "delete_src"
@@ -219,6 +220,57 @@ const (
 	ManyToManyMapping AssociationMapping = "n:n"
 )
 
+// IsValid check whether the on delete action is a known action
+func (a AssociationOnDeleteAction) IsValid() bool {
+	switch a {
+	case NoAction, DeleteSource, DeleteDestinatioin:
+		return true
+	}
+	return false
+}
+
+// ShouldCascade returns whether the instance on the other side of an instance association
+// should be deleted together, isSource describe whether the deleted instance is the source.
+func (a AssociationOnDeleteAction) ShouldCascade(isSource bool) bool {
+	return (a == DeleteDestinatioin && isSource) || (a == DeleteSource && !isSource)
+}
+
+// the reasons why an instance is deleted in an instance deletion
+const (
+	DeleteInstReasonRequest  = "request"
+	DeleteInstReasonMainline = "mainline"
+	DeleteInstReasonCascade  = "cascade"
+)
+
+// DeleteInstPreviewItem an instance which will be deleted by an instance deletion
+type DeleteInstPreviewItem struct {
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id"`
+	InstName string `json:"bk_inst_name"`
+	Reason   string `json:"reason"`
+	// the association and the instance which cause this instance to be deleted with cascade
+	AssociationID string `json:"bk_obj_asst_id,omitempty"`
+	CauseObjectID string `json:"cause_obj_id,omitempty"`
+	CauseInstID   int64  `json:"cause_inst_id,omitempty"`
+}
+
+// DeleteInstPreview describe what will be removed by an instance deletion
+type DeleteInstPreview struct {
+	// Deletable is false if there is any restricted instance association
+	Deletable bool                    `json:"deletable"`
+	Instances []DeleteInstPreviewItem `json:"instances"`
+	// Associations are the instance associations to be removed with the instances
+	Associations []InstAsst `json:"associations"`
+	// Restricted are the instance associations which prevent the deletion
+	Restricted []InstAsst `json:"restricted"`
+}
+
+// DeleteInstPreviewResult the result of the instance deletion preview
+type DeleteInstPreviewResult struct {
+	BaseResp `json:",inline"`
+	Data     DeleteInstPreview `json:"data"`
+}
+
 // Association defines the association between two objects.
 type Association struct {
 	ID      int64  `field:"id" json:"id" bson:"id"`
@@ -253,6 +305,23 @@ type Association struct {
 	Metadata `field:"metadata" json:"metadata" bson:"metadata"`
 }
 
+// IsSingleSide returns whether every instance of the model is associated with at most one instance through
+// the association, which is required to use the association as a unique key of the model.
+func (a Association) IsSingleSide(objID string) bool {
+	if a.ObjectID == a.AsstObjID {
+		return false
+	}
+	switch a.Mapping {
+	case OneToOneMapping:
+		return objID == a.ObjectID || objID == a.AsstObjID
+	case OneToManyMapping:
+		// one source instance is associated with many destination instances
+		return objID == a.AsstObjID
+	default:
+		return false
+	}
+}
+
 // return field means which filed is set but is forbidden to update.
 func (a *Association) CanUpdate() (field string, can bool) {
 	if a.ID != 0 {
@@ -13,7 +13,10 @@
 package metadata
 
 import (
+	"time"
+
 	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/mapstr"
 )
 
 type SaveAuditLogParams struct {
@@ -34,3 +37,136 @@ type AuditQueryResult struct {
 		Info  []OperationLog `json:"info"`
 	} `json:"data"`
 }
+
+// AuditHistoryRequest the request to rebuild the instance or topology at the time from the audit log
+type AuditHistoryRequest struct {
+	Time Time `json:"time"`
+}
+
+// AuditHistoryDiffRequest the request to compare the instance at two times
+type AuditHistoryDiffRequest struct {
+	StartTime Time `json:"start_time"`
+	EndTime   Time `json:"end_time"`
+}
+
+// InstanceHistory the instance rebuilt from the audit log
+type InstanceHistory struct {
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id"`
+	Time     Time   `json:"time"`
+	// Exist is false if the instance is not created yet or has been deleted at the time
+	Exist bool          `json:"exist"`
+	Data  mapstr.MapStr `json:"data"`
+	// LastOpTime is the time of the last audit log which the data is rebuilt from
+	LastOpTime *Time `json:"last_op_time,omitempty"`
+}
+
+// InstanceFieldDiff a field changed between two times
+type InstanceFieldDiff struct {
+	PropertyID string      `json:"bk_property_id"`
+	Before     interface{} `json:"before"`
+	After      interface{} `json:"after"`
+}
+
+// InstanceHistoryDiff the instance at two times and the fields changed between them
+type InstanceHistoryDiff struct {
+	Start InstanceHistory     `json:"start"`
+	End   InstanceHistory     `json:"end"`
+	Diff  []InstanceFieldDiff `json:"diff"`
+}
+
+const (
+	// AuditRevertTargetInstance the reverted audit log is about a model instance
+	AuditRevertTargetInstance = "instance"
+	// AuditRevertTargetHost the reverted audit log is about the attributes of a host
+	AuditRevertTargetHost = "host"
+	// AuditRevertTargetAssociation the reverted audit log is about an instance association
+	AuditRevertTargetAssociation = "association"
+
+	AuditRevertActionCreate = "create"
+	AuditRevertActionUpdate = "update"
+	AuditRevertActionDelete = "delete"
+)
+
+// AuditRevertRequest revert the change recorded by an audit log
+type AuditRevertRequest struct {
+	// DryRun only return what the revert will do, without changing anything
+	DryRun bool `json:"dry_run"`
+}
+
+// AuditRevertResult describe the revert of an audit log
+type AuditRevertResult struct {
+	AuditID int64  `json:"audit_id"`
+	DryRun  bool   `json:"dry_run"`
+	Target  string `json:"target"`
+	Action  string `json:"action"`
+	// BizID the business of the audit log
+	BizID    int64  `json:"bk_biz_id"`
+	ObjectID string `json:"bk_obj_id"`
+	// InstID the instance id, for association it is the id of the source instance
+	InstID int64 `json:"bk_inst_id"`
+	// NewInstID the id of the instance or association created by the revert
+	NewInstID int64 `json:"new_inst_id,omitempty"`
+	// Data the data to be created, or the fields to be updated
+	Data mapstr.MapStr       `json:"data"`
+	Diff []InstanceFieldDiff `json:"diff"`
+	// Conflicts the fields which have been changed after the audit log
+	Conflicts []InstanceFieldDiff `json:"conflicts"`
+	// Cascade is what will be removed by reverting the creation of an instance
+	Cascade *DeleteInstPreview `json:"cascade,omitempty"`
+}
+
+// AuditChainHead the end of the audit log hash chain of a supplier account
+type AuditChainHead struct {
+	OwnerID  string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	LastID   int64     `json:"last_id" bson:"last_id"`
+	LastHash string    `json:"last_hash" bson:"last_hash"`
+	LastTime time.Time `json:"last_time" bson:"last_time"`
+	// ArchivedID and ArchivedHash are the last audit log exported and purged, the chain starts after it
+	ArchivedID   int64  `json:"archived_id" bson:"archived_id"`
+	ArchivedHash string `json:"archived_hash" bson:"archived_hash"`
+}
+
+const (
+	// AuditChainModified the audit log is changed after it was sealed
+	AuditChainModified = "modified"
+	// AuditChainPreviousMissing the audit log is not chained to the previous one, which means the audit logs between them are deleted
+	AuditChainPreviousMissing = "previous_missing"
+	// AuditChainInserted the audit log is not sealed, but it's inside the sealed chain
+	AuditChainInserted = "inserted"
+	// AuditChainTailMissing the last sealed audit log is deleted or changed
+	AuditChainTailMissing = "tail_missing"
+)
+
+// AuditChainProblem an audit log which breaks the hash chain
+type AuditChainProblem struct {
+	ID     int64  `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// AuditChainVerifyResult the result of verifying the audit log hash chain of a supplier account
+type AuditChainVerifyResult struct {
+	OwnerID string `json:"bk_supplier_account"`
+	Valid   bool   `json:"valid"`
+	// Checked the count of the sealed audit logs checked
+	Checked int64 `json:"checked"`
+	// Unsealed the count of the audit logs not sealed yet
+	Unsealed int64               `json:"unsealed"`
+	LastID   int64               `json:"last_id"`
+	Problems []AuditChainProblem `json:"problems"`
+}
+
+// AuditChainVerifyResponse verify audit log hash chain response
+type AuditChainVerifyResponse struct {
+	BaseResp `json:",inline"`
+	Data     AuditChainVerifyResult `json:"data"`
+}
+
+// AuditArchiveResult describe the audit logs exported to an archive
+type AuditArchiveResult struct {
+	OwnerID  string `json:"bk_supplier_account"`
+	Count    int64  `json:"count"`
+	FirstID  int64  `json:"first_id"`
+	LastID   int64  `json:"last_id"`
+	LastHash string `json:"last_hash"`
+}
@@ -13,12 +13,18 @@
 package metadata
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"configcenter/src/common/querybuilder"
 )
 
 type RspSubscriptionCreate struct {
@@ -45,11 +51,29 @@ type ParamSubscriptionTelnet struct {
 type ParamSubscriptionTestCallback struct {
 	ParamSubscriptionTelnet `json:",inline"`
 	Data                    string `json:"data"`
+	// SubscriptionID test with the callback url, secret, headers and tls options of the subscription if set
+	SubscriptionID int64             `json:"subscription_id"`
+	Secret         string            `json:"secret"`
+	Headers        map[string]string `json:"headers"`
+	TLS            *CallbackTLS      `json:"tls"`
+	// CheckSignature checks whether the receiver verifies the signature, a signed request and a request
+	// with forged signature are sent, the receiver should accept the former and reject the latter.
+	CheckSignature bool `json:"check_signature"`
 }
 
 type RspSubscriptionTestCallback struct {
-	HttpStatus   int    `json:"http_status"`
-	ResponseBody string `json:"response_body"`
+	HttpStatus     int                     `json:"http_status"`
+	ResponseBody   string                  `json:"response_body"`
+	SignatureCheck *CallbackSignatureCheck `json:"signature_check,omitempty"`
+}
+
+// CallbackSignatureCheck is the result of checking whether the receiver verifies the callback signature
+type CallbackSignatureCheck struct {
+	Passed bool `json:"passed"`
+	// ForgedHttpStatus the http status the receiver returns for the request with forged signature
+	ForgedHttpStatus   int    `json:"forged_http_status"`
+	ForgedResponseBody string `json:"forged_response_body"`
+	Message            string `json:"message"`
 }
 
 // Subscription define
@@ -67,6 +91,284 @@ type Subscription struct {
 	OwnerID          string      `bson:"bk_supplier_account" json:"bk_supplier_account"`
 	LastTime         Time        `bson:"last_time" json:"last_time"`
 	Statistics       *Statistics `bson:"-" json:"operation"`
+	// RetryPolicy defines how to retry the failed callbacks, the default retry policy is used if not set
+	RetryPolicy *RetryPolicy `bson:"retry_policy" json:"retry_policy"`
+	// Secret is used to sign the callback body, the callbacks are not signed if it's empty
+	Secret string `bson:"secret" json:"secret"`
+	// Headers are the custom headers sent with each callback
+	Headers map[string]string `bson:"headers" json:"headers"`
+	// TLS is the tls options used to send callbacks to a https receiver
+	TLS *CallbackTLS `bson:"tls" json:"tls"`
+	// Filter is a querybuilder rule evaluated on the event data, the fields of the rule should
+	// start with cur_data or pre_data, such as cur_data.bk_host_innerip. only the matched events are sent.
+	Filter map[string]interface{} `bson:"filter" json:"filter"`
+	// ChangedFields only sends the update events which change at least one of the fields
+	ChangedFields []string `bson:"changed_fields" json:"changed_fields"`
+	// Batch sends the events in batches if set
+	Batch *EventBatch `bson:"batch" json:"batch"`
+}
+
+// EventBatch defines how the events are sent in batches, the callback body is a json array of the
+// events in a batch. a batch is sent when it has MaxEvents events, or MaxWait milliseconds elapsed
+// since the first event of the batch is received.
+type EventBatch struct {
+	MaxEvents int   `bson:"max_events" json:"max_events"`
+	MaxWait   int64 `bson:"max_wait" json:"max_wait"`
+}
+
+const (
+	// MaxEventBatchSize the max events a batch can hold
+	MaxEventBatchSize = 500
+	// MaxEventBatchWait the max milliseconds a batch can wait
+	MaxEventBatchWait = 60 * 1000
+)
+
+// Validate validates the batch options, returns the invalid field name if it's invalid
+func (b *EventBatch) Validate() (string, bool) {
+	if b.MaxEvents <= 0 || b.MaxEvents > MaxEventBatchSize {
+		return "batch.max_events", false
+	}
+	if b.MaxWait < 0 || b.MaxWait > MaxEventBatchWait {
+		return "batch.max_wait", false
+	}
+	return "", true
+}
+
+// GetFilter parses the event filter of the subscription, returns nil if it's not set
+func (s Subscription) GetFilter() (querybuilder.Rule, string, error) {
+	if len(s.Filter) == 0 {
+		return nil, "", nil
+	}
+	rule, key, err := querybuilder.ParseRule(s.Filter)
+	if err != nil {
+		return nil, key, err
+	}
+	if key, err := rule.Validate(); err != nil {
+		return nil, key, err
+	}
+	return rule, "", nil
+}
+
+// CallbackTLS is the tls options of the event callbacks, the files are relative to the callback
+// tls directory configured on the event server, the files out of the directory are refused.
+type CallbackTLS struct {
+	InsecureSkipVerify bool `bson:"insecure_skip_verify" json:"insecure_skip_verify"`
+	// CAFile is used to verify the receiver's certificate, the system root cas are used if not set
+	CAFile string `bson:"ca_file" json:"ca_file"`
+	// CertFile and KeyFile are the client certificate used for mutual tls
+	CertFile string `bson:"cert_file" json:"cert_file"`
+	KeyFile  string `bson:"key_file" json:"key_file"`
+	Password string `bson:"password" json:"password"`
+}
+
+const (
+	// EventCallbackTimestampHeader is the unix timestamp in seconds when the callback is signed
+	EventCallbackTimestampHeader = "X-Bkcmdb-Timestamp"
+	// EventCallbackSignatureHeader is the signature of the callback, in the format of sha256=<hex of hmac>,
+	// the hmac is calculated with sha256 on "<timestamp>.<body>" with the subscription's secret.
+	EventCallbackSignatureHeader = "X-Bkcmdb-Signature"
+)
+
+// SignEventCallback returns the signature of the callback body signed at the timestamp
+func SignEventCallback(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyEventCallback checks the signature of the callback body, it's for the receivers written in go
+func VerifyEventCallback(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignEventCallback(secret, timestamp, body)), []byte(signature))
+}
+
+// RetryPolicy defines how a failed event callback is retried with exponential backoff
+type RetryPolicy struct {
+	// MaxRetries the max retry times after the first delivery failed, 0 means never retry
+	MaxRetries int `bson:"max_retries" json:"max_retries"`
+	// InitialInterval the interval in milliseconds before the first retry
+	InitialInterval int64 `bson:"initial_interval" json:"initial_interval"`
+	// MaxInterval the max interval in milliseconds between two retries
+	MaxInterval int64 `bson:"max_interval" json:"max_interval"`
+	// Multiplier the interval is multiplied by after each retry
+	Multiplier float64 `bson:"multiplier" json:"multiplier"`
+}
+
+const (
+	// MaxRetryPolicyRetries the max retry times a subscription can set
+	MaxRetryPolicyRetries = 10
+	// MaxRetryPolicyInterval the max interval in milliseconds a subscription can set
+	MaxRetryPolicyInterval = 5 * 60 * 1000
+)
+
+// DefaultRetryPolicy the retry policy of the subscriptions which do not set one
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:      3,
+	InitialInterval: 1000,
+	MaxInterval:     30 * 1000,
+	Multiplier:      2,
+}
+
+// Validate validates the retry policy, returns the invalid field name if it's invalid
+func (p *RetryPolicy) Validate() (string, bool) {
+	if p.MaxRetries < 0 || p.MaxRetries > MaxRetryPolicyRetries {
+		return "retry_policy.max_retries", false
+	}
+	if p.InitialInterval < 0 || p.InitialInterval > MaxRetryPolicyInterval {
+		return "retry_policy.initial_interval", false
+	}
+	if p.MaxInterval < 0 || p.MaxInterval > MaxRetryPolicyInterval {
+		return "retry_policy.max_interval", false
+	}
+	if p.Multiplier < 0 {
+		return "retry_policy.multiplier", false
+	}
+	return "", true
+}
+
+// Backoff returns how long to wait before the retry, retry starts from 0
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	interval := float64(p.InitialInterval)
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	for i := 0; i < retry; i++ {
+		interval *= multiplier
+		if p.MaxInterval > 0 && interval >= float64(p.MaxInterval) {
+			interval = float64(p.MaxInterval)
+			break
+		}
+	}
+	return time.Duration(interval) * time.Millisecond
+}
+
+// MaxElapsed returns how long it waits at most for all the retries
+func (p RetryPolicy) MaxElapsed() time.Duration {
+	var elapsed time.Duration
+	for retry := 0; retry < p.MaxRetries; retry++ {
+		elapsed += p.Backoff(retry)
+	}
+	return elapsed
+}
+
+// DeadLetter is an event which is still failed to be delivered to the subscriber after all the retries
+type DeadLetter struct {
+	ID             int64  `bson:"id" json:"id"`
+	SubscriptionID int64  `bson:"subscription_id" json:"subscription_id"`
+	OwnerID        string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	DistID         int64  `bson:"distribution_id" json:"distribution_id"`
+	EventID        int64  `bson:"event_id" json:"event_id"`
+	EventType      string `bson:"event_type" json:"event_type"`
+	ObjType        string `bson:"obj_type" json:"obj_type"`
+	Action         string `bson:"action" json:"action"`
+	// Event is the raw distributed event sent to the subscriber
+	Event      string `bson:"event" json:"event"`
+	Attempts   int    `bson:"attempts" json:"attempts"`
+	LastError  string `bson:"last_error" json:"last_error"`
+	CreateTime Time   `bson:"create_time" json:"create_time"`
+	LastTime   Time   `bson:"last_time" json:"last_time"`
+}
+
+type ParamDeadLetterSearch struct {
+	Condition map[string]interface{} `json:"condition"`
+	Page      BasePage               `json:"page"`
+}
+
+type RspDeadLetterSearch struct {
+	Count uint64       `json:"count"`
+	Info  []DeadLetter `json:"info"`
+}
+
+// ParamDeadLetterOperate selects the dead letters of a subscription to replay or purge,
+// all the dead letters of the subscription are selected if ids is empty.
+type ParamDeadLetterOperate struct {
+	IDs []int64 `json:"ids"`
+}
+
+type RspDeadLetterReplay struct {
+	Succeeded []int64               `json:"succeeded"`
+	Failed    []DeadLetterReplayErr `json:"failed"`
+}
+
+type DeadLetterReplayErr struct {
+	ID    int64  `json:"id"`
+	Error string `json:"error"`
+}
+
+// EventWatchLog is an event kept in the watch log, the consumers watch the events from it by the event id.
+type EventWatchLog struct {
+	EventID   int64  `bson:"event_id" json:"event_id"`
+	OwnerID   string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	EventType string `bson:"event_type" json:"event_type"`
+	ObjType   string `bson:"obj_type" json:"obj_type"`
+	Action    string `bson:"action" json:"action"`
+	// Event is the raw event, whose object type is resolved the same way as the subscriptions
+	Event      string `bson:"event" json:"event"`
+	CreateTime Time   `bson:"create_time" json:"create_time"`
+}
+
+const (
+	// DefaultEventWatchLimit the default max events returned by a watch request
+	DefaultEventWatchLimit = 100
+	// MaxEventWatchLimit the max events can be returned by a watch request
+	MaxEventWatchLimit = 1000
+	// DefaultEventWatchTimeout the default seconds a watch request waits for the events
+	DefaultEventWatchTimeout = 20
+	// MaxEventWatchTimeout the max seconds a watch request can wait for the events
+	MaxEventWatchTimeout = 60
+)
+
+// ParamEventWatch watches the events after the cursor, the request waits until there are
+// matched events or timeout.
+type ParamEventWatch struct {
+	// Cursor is the last event id the consumer has seen, 0 means from the earliest event kept.
+	Cursor int64 `json:"cursor"`
+	// ObjTypes filters the events by object type, all the object types if it's empty
+	ObjTypes []string `json:"obj_types"`
+	// Actions filters the events by action, all the actions if it's empty
+	Actions []string `json:"actions"`
+	Limit   int64    `json:"limit"`
+	// Timeout is the seconds to wait for the events
+	Timeout int64 `json:"timeout"`
+}
+
+// Validate validates the watch options and fills the default values
+func (p *ParamEventWatch) Validate() (string, bool) {
+	if p.Cursor < 0 {
+		return "cursor", false
+	}
+	for _, action := range p.Actions {
+		if action != EventActionCreate && action != EventActionUpdate && action != EventActionDelete {
+			return "actions", false
+		}
+	}
+	if p.Limit < 0 || p.Limit > MaxEventWatchLimit {
+		return "limit", false
+	}
+	if p.Limit == 0 {
+		p.Limit = DefaultEventWatchLimit
+	}
+	if p.Timeout < 0 || p.Timeout > MaxEventWatchTimeout {
+		return "timeout", false
+	}
+	if p.Timeout == 0 {
+		p.Timeout = DefaultEventWatchTimeout
+	}
+	return "", true
+}
+
+// RspEventWatch is the result of a watch request, the consumer should watch with
+// the returned cursor next time, even if no events are returned.
+type RspEventWatch struct {
+	Cursor int64        `json:"cursor"`
+	Events []*EventInst `json:"events"`
+}
+
+type EventWatchResult struct {
+	BaseResp `json:",inline"`
+	Data     RspEventWatch `json:"data"`
 }
 
 // Report define sending statistic
@@ -90,15 +392,42 @@ func (s Subscription) GetCacheKey() string {
 		ConfirmPattern:   s.ConfirmPattern,
 		SubscriptionForm: s.SubscriptionForm,
 		TimeOutSeconds:   s.TimeOutSeconds,
+		RetryPolicy:      s.RetryPolicy,
+		Filter:           s.Filter,
+		ChangedFields:    s.ChangedFields,
+		Batch:            s.Batch,
 	}
-	b, _ := json.Marshal(ns)
+	// the secret, headers and tls options may hold credentials, only their digest is a part of the key
+	key := struct {
+		*Subscription
+		CredentialDigest string `json:"credential_digest,omitempty"`
+	}{Subscription: ns, CredentialDigest: s.credentialDigest()}
+	b, _ := json.Marshal(key)
 	return string(b)
 }
 
+// credentialDigest returns the sha256 digest of the secret, headers and tls options, or empty if none is set
+func (s Subscription) credentialDigest() string {
+	if s.Secret == "" && len(s.Headers) == 0 && s.TLS == nil {
+		return ""
+	}
+	b, _ := json.Marshal([]interface{}{s.Secret, s.Headers, s.TLS})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
 func (s Subscription) GetTimeout() time.Duration {
 	return time.Second * time.Duration(s.TimeOutSeconds)
 }
 
+// GetRetryPolicy returns the retry policy of the subscription, or the default one if not set
+func (s Subscription) GetRetryPolicy() RetryPolicy {
+	if s.RetryPolicy == nil {
+		return DefaultRetryPolicy
+	}
+	return *s.RetryPolicy
+}
+
 type EventInst struct {
 	ID          int64       `json:"event_id,omitempty"`
 	TxnID       string      `json:"txn_id"`
@@ -0,0 +1,53 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+// FullTextSearchOption is the option of searching the instances by the full text indexes of db,
+// which is used when elasticsearch is not deployed.
+type FullTextSearchOption struct {
+	// QueryString is the words to search, separated by spaces
+	QueryString string `json:"query_string"`
+	// Tables are the tables to search, which are the same with the types of the elasticsearch index
+	Tables   []string `json:"tables"`
+	ObjectID string   `json:"bk_obj_id"`
+	BizID    string   `json:"bk_biz_id"`
+	Start    int      `json:"start"`
+	Limit    int      `json:"limit"`
+}
+
+// FullTextSearchHit is a document matched
+type FullTextSearchHit struct {
+	Source    map[string]interface{} `json:"source"`
+	Highlight map[string][]string    `json:"highlight"`
+	// Table is the table the document is in
+	Table string  `json:"table"`
+	Score float64 `json:"score"`
+}
+
+// FullTextSearchAggregation is how many documents of the object or the table are matched
+type FullTextSearchAggregation struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// FullTextSearchResult is the result of the full text search
+type FullTextSearchResult struct {
+	Total        int64                       `json:"total"`
+	Aggregations []FullTextSearchAggregation `json:"aggregations"`
+	Hits         []FullTextSearchHit         `json:"hits"`
+}
+
+type FullTextSearchResponse struct {
+	BaseResp `json:",inline"`
+	Data     FullTextSearchResult `json:"data"`
+}
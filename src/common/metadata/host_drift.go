@@ -0,0 +1,120 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+)
+
+// HostDriftMaxTimeRange is the max time range of the drifted hosts search
+const HostDriftMaxTimeRange = 366 * 24 * time.Hour
+
+// HostDrift is the host properties changed by a snapshot report
+type HostDrift struct {
+	HostID     int64             `json:"bk_host_id" bson:"bk_host_id"`
+	OwnerID    string            `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Changes    []HostDriftChange `json:"changes" bson:"changes"`
+	CreateTime time.Time         `json:"create_time" bson:"create_time"`
+}
+
+type HostDriftChange struct {
+	PropertyID string      `json:"bk_property_id" bson:"bk_property_id"`
+	PreValue   interface{} `json:"pre_value" bson:"pre_value"`
+	CurValue   interface{} `json:"cur_value" bson:"cur_value"`
+}
+
+// HostDriftTimeRange limits the drifts by the create time, both are optional
+type HostDriftTimeRange struct {
+	StartTime *time.Time `json:"start_time,omitempty"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
+}
+
+func (r HostDriftTimeRange) Validate() (string, error) {
+	if r.StartTime != nil && r.EndTime != nil && r.EndTime.Before(*r.StartTime) {
+		return "end_time", fmt.Errorf("end time is before start time")
+	}
+	return "", nil
+}
+
+// Condition returns the condition of the create time
+func (r HostDriftTimeRange) Condition() map[string]interface{} {
+	cond := make(map[string]interface{})
+	if r.StartTime != nil {
+		cond[common.BKDBGTE] = *r.StartTime
+	}
+	if r.EndTime != nil {
+		cond[common.BKDBLT] = *r.EndTime
+	}
+	return cond
+}
+
+// SearchHostDriftOption searches the drift timeline of a host, the drifts are in reverse order of the create time
+type SearchHostDriftOption struct {
+	HostID             int64    `json:"bk_host_id"`
+	Fields             []string `json:"fields,omitempty"`
+	HostDriftTimeRange `json:",inline"`
+	Page               BasePage `json:"page"`
+}
+
+type MultipleHostDrift struct {
+	Count uint64      `json:"count"`
+	Info  []HostDrift `json:"info"`
+}
+
+// ListDriftedHostsOption lists the hosts whose fields changed in the time range
+type ListDriftedHostsOption struct {
+	HostIDs            []int64  `json:"bk_host_ids,omitempty"`
+	Fields             []string `json:"fields,omitempty"`
+	HostDriftTimeRange `json:",inline"`
+	Page               BasePage `json:"page"`
+}
+
+func (o ListDriftedHostsOption) Validate() (string, error) {
+	if o.StartTime == nil {
+		return "start_time", fmt.Errorf("start time is required")
+	}
+	end := time.Now()
+	if o.EndTime != nil {
+		end = *o.EndTime
+	}
+	if end.Sub(*o.StartTime) > HostDriftMaxTimeRange {
+		return "start_time", fmt.Errorf("time range exceeds %v", HostDriftMaxTimeRange)
+	}
+	return o.HostDriftTimeRange.Validate()
+}
+
+// DriftedHost is a host whose fields changed in the time range
+type DriftedHost struct {
+	HostID      int64     `json:"bk_host_id" bson:"_id"`
+	ChangeCount int64     `json:"change_count" bson:"change_count"`
+	LastTime    time.Time `json:"last_time" bson:"last_time"`
+	Fields      []string  `json:"fields" bson:"fields"`
+}
+
+type MultipleDriftedHost struct {
+	Count uint64        `json:"count"`
+	Info  []DriftedHost `json:"info"`
+}
+
+type SearchHostDriftResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleHostDrift `json:"data"`
+}
+
+type ListDriftedHostsResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleDriftedHost `json:"data"`
+}
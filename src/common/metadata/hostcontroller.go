@@ -232,9 +232,11 @@ type CloudTaskInfo struct {
 	AttrConfirm     bool   `json:"bk_attr_confirm" bson:"bk_attr_confirm"`
 	SecretID        string `json:"bk_secret_id" bson:"bk_secret_id"`
 	SecretKey       string `json:"bk_secret_key" bson:"bk_secret_key"`
+	Endpoint        string `json:"bk_endpoint" bson:"bk_endpoint"`
 	SyncStatus      string `json:"bk_sync_status" bson:"bk_sync_status"`
 	NewAdd          int64  `json:"new_add" bson:"new_add"`
 	AttrChanged     int64  `json:"attr_changed" bson:"attr_changed"`
+	Removed         int64  `json:"removed" bson:"removed"`
 	OwnerID         string `json:"bk_supplier_account" bson:"bk_supplier_account"`
 }
 
@@ -15,7 +15,6 @@ package metadata
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"time"
 
 	"configcenter/src/common"
@@ -324,6 +323,7 @@ type CloudTaskList struct {
 	AttrConfirm     bool   `json:"bk_attr_confirm" bson:"bk_attr_confirm"`
 	SecretID        string `json:"bk_secret_id" bson:"bk_secret_id"`
 	SecretKey       string `json:"bk_secret_key" bson:"bk_secret_key"`
+	Endpoint        string `json:"bk_endpoint" bson:"bk_endpoint"`
 	OwnerID         string `json:"bk_supplier_account" bson:"bk_supplier_account"`
 }
 
@@ -346,6 +346,7 @@ type CloudHistory struct {
 	TimeConsume string `json:"bk_time_consume" bson:"bk_time_consume"`
 	NewAdd      int    `json:"new_add" bson:"new_add"`
 	AttrChanged int    `json:"attr_changed" bson:"attr_changed"`
+	Removed     int    `json:"removed" bson:"removed"`
 	StartTime   string `json:"bk_start_time" bson:"bk_start_time"`
 	TaskID      int64  `json:"bk_task_id" bson:"bk_task_id"`
 	HistoryID   int64  `json:"bk_history_id" bson:"bk_history_id"`
@@ -383,26 +384,9 @@ type CloudHostInfo struct {
 	OsName             string   `json:"OsName"`
 }
 
-type TaskInfo struct {
-	Args        CloudTaskInfo
-	Method      string
-	NextTrigger int64
-}
-
-type CloudSyncRedisPendingStart struct {
-	NewHeader    http.Header `json:"new_header"`
-	TaskID       int64       `json:"bk_task_id"`
-	TaskItemInfo TaskInfo    `json:"task_item_info"`
-	OwnerID      string      `json:"bk_supplier_account"`
-	Update       bool        `json:"update"`
-}
-
-type CloudSyncRedisAlreadyStarted struct {
-	LastSyncTime time.Time   `json:"last_sync_time"`
-	NewHeader    http.Header `json:"new_header"`
-	TaskID       int64       `json:"bk_task_id"`
-	TaskItemInfo TaskInfo    `json:"task_item_info"`
-	OwnerID      string      `json:"bk_supplier_account"`
+// CloudSyncRunRequest the request the cron job of the cloud sync task sends to run the task
+type CloudSyncRunRequest struct {
+	TaskID int64 `json:"bk_task_id"`
 }
 
 // TransferHostAcrossBusinessParameter Transfer host across business request parameter
@@ -0,0 +1,131 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+const (
+	// SnapTransformNone uses the value of the path as it is
+	SnapTransformNone = ""
+	// SnapTransformUnit converts the numeric value by dividing it by the divisor, an array is summed first
+	SnapTransformUnit = "unit"
+	// SnapTransformJoin joins the values of an array with the separator
+	SnapTransformJoin = "join"
+	// SnapTransformRegex extracts the first sub match of the pattern, or the whole match if it has no group
+	SnapTransformRegex = "regex"
+
+	// SnapConditionExists matches when the path exists in the snapshot
+	SnapConditionExists = "$exists"
+	// SnapConditionEqual matches when the value of the path equals the condition value
+	SnapConditionEqual = "$eq"
+	// SnapConditionNotEqual matches when the value of the path does not equal the condition value
+	SnapConditionNotEqual = "$ne"
+	// SnapConditionRegex matches when the value of the path matches the condition value pattern
+	SnapConditionRegex = "$regex"
+)
+
+// HostSnapMappingRule maps a field of the host snapshot reported by the agent to a host property
+type HostSnapMappingRule struct {
+	ID         uint64             `json:"id" bson:"id"`
+	Name       string             `json:"name" bson:"name"`
+	Path       string             `json:"path" bson:"path"`
+	PropertyID string             `json:"bk_property_id" bson:"bk_property_id"`
+	Transform  HostSnapTransform  `json:"transform" bson:"transform"`
+	Condition  *HostSnapCondition `json:"condition,omitempty" bson:"condition,omitempty"`
+	Enable     bool               `json:"enable" bson:"enable"`
+	OwnerID    string             `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime *time.Time         `json:"create_time,omitempty" bson:"create_time,omitempty"`
+	LastTime   *time.Time         `json:"last_time,omitempty" bson:"last_time,omitempty"`
+}
+
+// HostSnapTransform converts the value of the snapshot path before it is set to the host property
+type HostSnapTransform struct {
+	Type      string  `json:"type" bson:"type"`
+	Divisor   float64 `json:"divisor,omitempty" bson:"divisor,omitempty"`
+	Separator string  `json:"separator,omitempty" bson:"separator,omitempty"`
+	Pattern   string  `json:"pattern,omitempty" bson:"pattern,omitempty"`
+}
+
+// HostSnapCondition decides whether the rule applies to a snapshot by another path of it
+type HostSnapCondition struct {
+	Path     string `json:"path" bson:"path"`
+	Operator string `json:"operator" bson:"operator"`
+	Value    string `json:"value,omitempty" bson:"value,omitempty"`
+}
+
+// Validate checks the fields of the rule except the host property, which is checked with the host model
+func (r *HostSnapMappingRule) Validate() error {
+	if len(r.Name) == 0 {
+		return fmt.Errorf("name is empty")
+	}
+	if len(r.Path) == 0 {
+		return fmt.Errorf("path is empty")
+	}
+	if len(r.PropertyID) == 0 {
+		return fmt.Errorf("bk_property_id is empty")
+	}
+
+	switch r.Transform.Type {
+	case SnapTransformNone, SnapTransformJoin:
+	case SnapTransformUnit:
+		if r.Transform.Divisor <= 0 {
+			return fmt.Errorf("transform divisor must be positive")
+		}
+	case SnapTransformRegex:
+		if _, err := regexp.Compile(r.Transform.Pattern); err != nil {
+			return fmt.Errorf("transform pattern is invalid, %v", err)
+		}
+	default:
+		return fmt.Errorf("transform type %s is not supported", r.Transform.Type)
+	}
+
+	if r.Condition == nil {
+		return nil
+	}
+	if len(r.Condition.Path) == 0 {
+		return fmt.Errorf("condition path is empty")
+	}
+	switch r.Condition.Operator {
+	case SnapConditionExists, SnapConditionEqual, SnapConditionNotEqual:
+	case SnapConditionRegex:
+		if _, err := regexp.Compile(r.Condition.Value); err != nil {
+			return fmt.Errorf("condition value is invalid, %v", err)
+		}
+	default:
+		return fmt.Errorf("condition operator %s is not supported", r.Condition.Operator)
+	}
+	return nil
+}
+
+type SearchHostSnapMappingRuleOption struct {
+	Condition map[string]interface{} `json:"condition"`
+	Page      BasePage               `json:"page"`
+}
+
+type SearchHostSnapMappingRule struct {
+	Count uint64                `json:"count"`
+	Info  []HostSnapMappingRule `json:"info"`
+}
+
+type SearchHostSnapMappingRuleResult struct {
+	BaseResp `json:",inline"`
+	Data     SearchHostSnapMappingRule `json:"data"`
+}
+
+type DeleteHostSnapMappingRuleOption struct {
+	IDs []uint64 `json:"ids"`
+}
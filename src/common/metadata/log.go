@@ -16,6 +16,7 @@ import "time"
 
 // OperationLog opeartion log item definition
 type OperationLog struct {
+	ID            int64       `bson:"id"                  json:"id"`
 	OwnerID       string      `bson:"bk_supplier_account"    json:"bk_supplier_account"`
 	ApplicationID int64       `bson:"bk_biz_id"              json:"bk_biz_id"`
 	ExtKey        string      `bson:"ext_key"             json:"ext_key"`
@@ -28,6 +29,11 @@ type OperationLog struct {
 	ExtInfo       string      `bson:"ext_info"            json:"ext_info"`
 	CreateTime    time.Time   `bson:"op_time"         json:"op_time"`
 	InstID        int64       `bson:"inst_id"             json:"inst_id"`
+	// RevertOf the id of the audit log reverted by this operation
+	RevertOf int64 `bson:"revert_of,omitempty" json:"revert_of,omitempty"`
+	// PrevHash and Hash chain the audit logs of a supplier account, they are set when the log is sealed
+	PrevHash string `bson:"prev_hash,omitempty" json:"prev_hash,omitempty"`
+	Hash     string `bson:"hash,omitempty" json:"hash,omitempty"`
 }
 
 // TableName return the table name
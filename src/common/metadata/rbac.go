@@ -0,0 +1,182 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// RBACSubjectUser binds a role to a single user
+	RBACSubjectUser = "user"
+	// RBACSubjectGroup binds a role to all the members of a local user group
+	RBACSubjectGroup = "group"
+
+	// RBACAny matches any resource type or action in a grant
+	RBACAny = "*"
+)
+
+// RBACGrant allows the actions on a resource type, the resource types and actions
+// are the ones defined in auth/meta.
+type RBACGrant struct {
+	ResourceType string   `field:"resource_type" json:"resource_type" bson:"resource_type" mapstructure:"resource_type"`
+	Actions      []string `field:"actions" json:"actions" bson:"actions" mapstructure:"actions"`
+}
+
+// RBACRole is a named set of grants
+type RBACRole struct {
+	ID          int64       `field:"id" json:"id" bson:"id" mapstructure:"id"`
+	Name        string      `field:"name" json:"name" bson:"name" mapstructure:"name"`
+	Description string      `field:"description" json:"description" bson:"description" mapstructure:"description"`
+	Grants      []RBACGrant `field:"grants" json:"grants" bson:"grants" mapstructure:"grants"`
+
+	Creator         string    `field:"creator" json:"creator" bson:"creator" mapstructure:"creator"`
+	Modifier        string    `field:"modifier" json:"modifier" bson:"modifier" mapstructure:"modifier"`
+	CreateTime      time.Time `field:"create_time" json:"create_time" bson:"create_time" mapstructure:"create_time"`
+	LastTime        time.Time `field:"last_time" json:"last_time" bson:"last_time" mapstructure:"last_time"`
+	SupplierAccount string    `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account" mapstructure:"bk_supplier_account"`
+}
+
+// RBACRoleBinding binds a role to a user or a user group, in a business or globally when bk_biz_id is 0
+type RBACRoleBinding struct {
+	ID          int64  `field:"id" json:"id" bson:"id" mapstructure:"id"`
+	RoleID      int64  `field:"role_id" json:"role_id" bson:"role_id" mapstructure:"role_id"`
+	SubjectType string `field:"subject_type" json:"subject_type" bson:"subject_type" mapstructure:"subject_type"`
+	Subject     string `field:"subject" json:"subject" bson:"subject" mapstructure:"subject"`
+	BizID       int64  `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id" mapstructure:"bk_biz_id"`
+
+	Creator         string    `field:"creator" json:"creator" bson:"creator" mapstructure:"creator"`
+	Modifier        string    `field:"modifier" json:"modifier" bson:"modifier" mapstructure:"modifier"`
+	CreateTime      time.Time `field:"create_time" json:"create_time" bson:"create_time" mapstructure:"create_time"`
+	LastTime        time.Time `field:"last_time" json:"last_time" bson:"last_time" mapstructure:"last_time"`
+	SupplierAccount string    `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account" mapstructure:"bk_supplier_account"`
+}
+
+// RBACUserGroup is a local group of users which roles can be bound to
+type RBACUserGroup struct {
+	ID          int64    `field:"id" json:"id" bson:"id" mapstructure:"id"`
+	Name        string   `field:"name" json:"name" bson:"name" mapstructure:"name"`
+	Description string   `field:"description" json:"description" bson:"description" mapstructure:"description"`
+	Members     []string `field:"members" json:"members" bson:"members" mapstructure:"members"`
+
+	Creator         string    `field:"creator" json:"creator" bson:"creator" mapstructure:"creator"`
+	Modifier        string    `field:"modifier" json:"modifier" bson:"modifier" mapstructure:"modifier"`
+	CreateTime      time.Time `field:"create_time" json:"create_time" bson:"create_time" mapstructure:"create_time"`
+	LastTime        time.Time `field:"last_time" json:"last_time" bson:"last_time" mapstructure:"last_time"`
+	SupplierAccount string    `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account" mapstructure:"bk_supplier_account"`
+}
+
+type RBACRoleOption struct {
+	Name        string      `json:"name" mapstructure:"name"`
+	Description string      `json:"description" mapstructure:"description"`
+	Grants      []RBACGrant `json:"grants" mapstructure:"grants"`
+}
+
+// Validate checks the role option, returns the invalid key with the error
+func (o *RBACRoleOption) Validate() (string, error) {
+	if len(o.Name) == 0 {
+		return "name", fmt.Errorf("role name should not be empty")
+	}
+	for _, grant := range o.Grants {
+		if len(grant.ResourceType) == 0 {
+			return "resource_type", fmt.Errorf("grant resource type should not be empty")
+		}
+		if len(grant.Actions) == 0 {
+			return "actions", fmt.Errorf("grant of resource type %s has no action", grant.ResourceType)
+		}
+	}
+	return "", nil
+}
+
+type RBACRoleBindingOption struct {
+	RoleID      int64  `json:"role_id" mapstructure:"role_id"`
+	SubjectType string `json:"subject_type" mapstructure:"subject_type"`
+	Subject     string `json:"subject" mapstructure:"subject"`
+	BizID       int64  `json:"bk_biz_id" mapstructure:"bk_biz_id"`
+}
+
+// Validate checks the role binding option, returns the invalid key with the error
+func (o *RBACRoleBindingOption) Validate() (string, error) {
+	if o.RoleID <= 0 {
+		return "role_id", fmt.Errorf("invalid role id %d", o.RoleID)
+	}
+	if o.SubjectType != RBACSubjectUser && o.SubjectType != RBACSubjectGroup {
+		return "subject_type", fmt.Errorf("subject type should be %s or %s", RBACSubjectUser, RBACSubjectGroup)
+	}
+	if len(o.Subject) == 0 {
+		return "subject", fmt.Errorf("subject should not be empty")
+	}
+	if o.BizID < 0 {
+		return "bk_biz_id", fmt.Errorf("invalid business id %d", o.BizID)
+	}
+	return "", nil
+}
+
+type RBACUserGroupOption struct {
+	Name        string   `json:"name" mapstructure:"name"`
+	Description string   `json:"description" mapstructure:"description"`
+	Members     []string `json:"members" mapstructure:"members"`
+}
+
+// Validate checks the user group option, returns the invalid key with the error
+func (o *RBACUserGroupOption) Validate() (string, error) {
+	if len(o.Name) == 0 {
+		return "name", fmt.Errorf("user group name should not be empty")
+	}
+	return "", nil
+}
+
+// ListRBACOption filters the roles, role bindings or user groups, the empty fields are ignored.
+type ListRBACOption struct {
+	IDs         []int64  `json:"ids" mapstructure:"ids"`
+	Names       []string `json:"names" mapstructure:"names"`
+	RoleIDs     []int64  `json:"role_ids" mapstructure:"role_ids"`
+	SubjectType string   `json:"subject_type" mapstructure:"subject_type"`
+	Subjects    []string `json:"subjects" mapstructure:"subjects"`
+	BizIDs      []int64  `json:"bk_biz_ids" mapstructure:"bk_biz_ids"`
+	Page        BasePage `json:"page" mapstructure:"page"`
+}
+
+type MultipleRBACRole struct {
+	Count int64      `json:"count" mapstructure:"count"`
+	Info  []RBACRole `json:"info" mapstructure:"info"`
+}
+
+type MultipleRBACRoleBinding struct {
+	Count int64             `json:"count" mapstructure:"count"`
+	Info  []RBACRoleBinding `json:"info" mapstructure:"info"`
+}
+
+type MultipleRBACUserGroup struct {
+	Count int64           `json:"count" mapstructure:"count"`
+	Info  []RBACUserGroup `json:"info" mapstructure:"info"`
+}
+
+type RBACUserPolicyOption struct {
+	User string `json:"bk_username" mapstructure:"bk_username"`
+}
+
+// RBACPolicyItem is the grants a user holds in a business, or globally when bk_biz_id is 0
+type RBACPolicyItem struct {
+	BizID  int64       `json:"bk_biz_id" mapstructure:"bk_biz_id"`
+	RoleID int64       `json:"role_id" mapstructure:"role_id"`
+	Grants []RBACGrant `json:"grants" mapstructure:"grants"`
+}
+
+// RBACUserPolicy is all the grants a user holds through the roles bound to him or his groups
+type RBACUserPolicy struct {
+	User   string           `json:"bk_username" mapstructure:"bk_username"`
+	Groups []string         `json:"groups" mapstructure:"groups"`
+	Items  []RBACPolicyItem `json:"items" mapstructure:"items"`
+}
@@ -26,9 +26,24 @@ type CreateTaskRequest struct {
 	// flag task 任务标识，留给业务方做识别任务
 	Flag string `json:"flag"`
 
+	// Priority the task with higher priority in the same queue is executed first
+	Priority int64 `json:"priority"`
+
 	Data []interface{} `json:"data"`
 }
 
+const (
+	// MinAPITaskPriority the min priority of a task
+	MinAPITaskPriority int64 = -100
+	// MaxAPITaskPriority the max priority of a task
+	MaxAPITaskPriority int64 = 100
+)
+
+// UpdateTaskPriorityRequest changes the priority of a task that is not finished
+type UpdateTaskPriorityRequest struct {
+	Priority int64 `json:"priority"`
+}
+
 // APITaskDetail task info detaill
 type APITaskDetail struct {
 	// task id
@@ -43,6 +58,8 @@ type APITaskDetail struct {
 	Header http.Header `json:"header" bson:"header"`
 	// task status
 	Status APITaskStatus `json:"status" bson:"status"`
+	// Priority the task with higher priority in the same queue is executed first
+	Priority int64 `json:"priority" bson:"priority"`
 	// sub task detail
 	Detail []APISubTaskDetail `json:"detail" bson:"detail"`
 
@@ -56,13 +73,15 @@ type APISubTaskDetail struct {
 	Data      interface{}   `json:"data" bson:"data"`
 	Status    APITaskStatus `json:"status" bson:"status"`
 	Response  *Response     `json:"response" bson:"response"`
+	// Attempts is how many times the sub task has been sent
+	Attempts int64 `json:"attempts" bson:"attempts"`
 }
 
 // APITaskStatus task status type
 type APITaskStatus int64
 
 func (s APITaskStatus) IsFinished() bool {
-	if s == 200 || s == 500 {
+	if s == 200 || s == 500 || s == 400 {
 		return true
 	}
 	return false
@@ -81,6 +100,13 @@ func (s APITaskStatus) IsFailure() bool {
 	return false
 }
 
+func (s APITaskStatus) IsCanceled() bool {
+	if s == 400 {
+		return true
+	}
+	return false
+}
+
 const (
 	// APITaskStatusNew new task ,waiting execute
 	APITaskStatusNew APITaskStatus = 0
@@ -93,6 +119,12 @@ const (
 	// APITaskStatusSuccess task execute success
 	APITaskStatusSuccess APITaskStatus = 200
 
+	// APITaskStatusPaused task is paused, it's not executed until it's resumed
+	APITaskStatusPaused APITaskStatus = 300
+
+	// APITaskStatusCanceled task is canceled, the sub tasks not executed are abandoned
+	APITaskStatusCanceled APITaskStatus = 400
+
 	// APITAskStatusFail task execute failure
 	APITAskStatusFail APITaskStatus = 500
 )
@@ -124,3 +156,124 @@ type TaskDetailResponse struct {
 		Info APITaskDetail `json:"info"`
 	} `json:"data"`
 }
+
+// CronJobMissedPolicy decides what to do with the runs missed while there is no master task server
+type CronJobMissedPolicy string
+
+const (
+	// CronJobMissedSkip the missed runs are skipped, the job runs at its next schedule time
+	CronJobMissedSkip CronJobMissedPolicy = "skip"
+	// CronJobMissedRunOnce the job runs once at once for all the missed runs
+	CronJobMissedRunOnce CronJobMissedPolicy = "run_once"
+)
+
+// CronJobRunStatus the result of a cron job run
+type CronJobRunStatus string
+
+const (
+	CronJobRunSuccess CronJobRunStatus = "success"
+	CronJobRunFailure CronJobRunStatus = "failure"
+	// CronJobRunSkipped the run is missed or the former run is not finished
+	CronJobRunSkipped CronJobRunStatus = "skipped"
+)
+
+// CronJob a job the task server sends to the path of a service periodically
+type CronJob struct {
+	// Name unique name of the job
+	Name string `json:"name" bson:"name"`
+	// Spec standard cron expression, like "30 0 * * *"
+	Spec string `json:"spec" bson:"spec"`
+	// SvrType the service the job is sent to, host, topo, coreservice etc
+	SvrType string `json:"svr_type" bson:"svr_type"`
+	// Path the full url path the job is sent to
+	Path string `json:"path" bson:"path"`
+	// Data the request body sent with the job
+	Data interface{} `json:"data" bson:"data"`
+	// the job is sent with the supplier account and user
+	OwnerID string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	User    string `json:"user" bson:"user"`
+	Enabled bool   `json:"enabled" bson:"enabled"`
+
+	MissedPolicy CronJobMissedPolicy `json:"missed_policy" bson:"missed_policy"`
+	// Timeout the max seconds a run can take
+	Timeout int64 `json:"timeout" bson:"timeout"`
+	// Builtin the job is registered in code, it can be disabled but can't be deleted
+	Builtin bool `json:"builtin" bson:"builtin"`
+	// Triggered the job is triggered manually and waits to run
+	Triggered bool `json:"triggered" bson:"triggered"`
+
+	NextRunTime time.Time        `json:"next_run_time" bson:"next_run_time"`
+	LastRunTime time.Time        `json:"last_run_time" bson:"last_run_time"`
+	LastStatus  CronJobRunStatus `json:"last_status" bson:"last_status"`
+	CreateTime  time.Time        `json:"create_time" bson:"create_time"`
+	LastTime    time.Time        `json:"last_time" bson:"last_time"`
+}
+
+// CronJobRun the history of a cron job run
+type CronJobRun struct {
+	Name string `json:"name" bson:"name"`
+	// ScheduledTime the time the run is scheduled at
+	ScheduledTime time.Time        `json:"scheduled_time" bson:"scheduled_time"`
+	StartTime     time.Time        `json:"start_time" bson:"start_time"`
+	EndTime       time.Time        `json:"end_time" bson:"end_time"`
+	Status        CronJobRunStatus `json:"status" bson:"status"`
+	// Manual the run is triggered manually
+	Manual bool `json:"manual" bson:"manual"`
+	// Server the task server which runs the job
+	Server   string    `json:"server" bson:"server"`
+	Message  string    `json:"message" bson:"message"`
+	Response *Response `json:"response" bson:"response"`
+}
+
+// UpsertCronJobRequest creates the cron job, or replaces it if the job exists
+type UpsertCronJobRequest struct {
+	Name         string              `json:"name"`
+	Spec         string              `json:"spec"`
+	SvrType      string              `json:"svr_type"`
+	Path         string              `json:"path"`
+	Data         interface{}         `json:"data"`
+	Enabled      bool                `json:"enabled"`
+	MissedPolicy CronJobMissedPolicy `json:"missed_policy"`
+	Timeout      int64               `json:"timeout"`
+}
+
+type UpsertCronJobResponse struct {
+	BaseResp
+	Data CronJob `json:"data"`
+}
+
+// UpdateCronJobRequest changes the schedule of a cron job, the fields not set are not changed
+type UpdateCronJobRequest struct {
+	Spec         *string              `json:"spec"`
+	Enabled      *bool                `json:"enabled"`
+	MissedPolicy *CronJobMissedPolicy `json:"missed_policy"`
+}
+
+type ListCronJobRequest struct {
+	Condition mapstr.MapStr `json:"condition"`
+	Page      BasePage      `json:"page"`
+}
+
+type ListCronJobData struct {
+	Info  []CronJob `json:"info"`
+	Count int64     `json:"count"`
+}
+
+type ListCronJobResponse struct {
+	BaseResp
+	Data ListCronJobData `json:"data"`
+}
+
+type ListCronJobRunRequest struct {
+	Page BasePage `json:"page"`
+}
+
+type ListCronJobRunData struct {
+	Info  []CronJobRun `json:"info"`
+	Count int64        `json:"count"`
+}
+
+type ListCronJobRunResponse struct {
+	BaseResp
+	Data ListCronJobRunData `json:"data"`
+}
@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"configcenter/src/common"
+)
+
+// UniqueIndexNamePrefix is the prefix of the names of the db indexes backing the unique rules
+const UniqueIndexNamePrefix = "bkcc_unique_"
+
+// UniqueIndexTableLimit the unique rules are not backed by index any more when the instance table has so many
+// indexes, as mongodb allows 64 indexes on a collection at most, the rest are left for the other indexes.
+// the rules of all the custom models share the same table, the rules not backed are checked by the application.
+const UniqueIndexTableLimit = 48
+
+// uniqueIndexKeyBizID scopes the instances of the business private models by business
+const uniqueIndexKeyBizID = BKMetadata + "." + BKLabel + "." + common.BKAppIDField
+
+var uniqueIndexNameRegexp = regexp.MustCompile(`index: ` + UniqueIndexNamePrefix + `(\d+)`)
+
+// UniqueIndexName returns the name of the db index backing the unique rule
+func UniqueIndexName(id uint64) string {
+	return UniqueIndexNamePrefix + strconv.FormatUint(id, 10)
+}
+
+// ParseUniqueIDFromDupError returns the id of the unique rule whose db index the duplicate key error comes from
+func ParseUniqueIDFromDupError(err error) (uint64, bool) {
+	if err == nil {
+		return 0, false
+	}
+	match := uniqueIndexNameRegexp.FindStringSubmatch(err.Error())
+	if len(match) != 2 {
+		return 0, false
+	}
+	id, parseErr := strconv.ParseUint(match[1], 10, 64)
+	if parseErr != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// IndexKeysAndFilter returns the keys and the partial filter of the db unique index which backs the unique rule on
+// the instance table, properties are the attributes of the keys of the rule.
+// the index is scoped by the supplier account, the model and the business of the instance. when the rule is not
+// must check, the index only covers the instances whose unique values are all set, as the application check does.
+// an error is returned if the rule can not be backed by an index, then it is only checked by the application.
+func (u ObjectUnique) IndexKeysAndFilter(properties []Attribute) (map[string]int32, map[string]interface{}, error) {
+	if u.ObjID == common.BKInnerObjIDApp {
+		// the archived businesses keep their names, which are excluded from the check by their status
+		return nil, nil, fmt.Errorf("the unique rules of model %s can not be backed by index", u.ObjID)
+	}
+
+	propertyMap := make(map[uint64]Attribute, len(properties))
+	for _, property := range properties {
+		propertyMap[uint64(property.ID)] = property
+	}
+
+	keys := map[string]int32{
+		common.BKOwnerIDField: 1,
+		uniqueIndexKeyBizID:   1,
+	}
+	filter := make(map[string]interface{})
+	if common.GetObjByType(u.ObjID) == common.BKInnerObjIDObject {
+		keys[common.BKObjIDField] = 1
+		filter[common.BKObjIDField] = u.ObjID
+	}
+
+	for _, key := range u.Keys {
+		if key.Kind != UniqueKeyKindProperty {
+			return nil, nil, fmt.Errorf("unique key kind %s can not be backed by index", key.Kind)
+		}
+		property, ok := propertyMap[key.ID]
+		if !ok {
+			return nil, nil, fmt.Errorf("property %d of unique key not found", key.ID)
+		}
+		keys[property.PropertyID] = 1
+		if u.MustCheck {
+			continue
+		}
+
+		// the empty and zero values are excluded from the index, as they are not checked. the partial filter
+		// can not express "not zero", so the negative numbers are left to the application check
+		switch property.PropertyType {
+		case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeEnum, common.FieldTypeDate,
+			common.FieldTypeTime, common.FieldTypeTimeZone, common.FieldTypeUser:
+			filter[property.PropertyID] = map[string]interface{}{"$type": "string", common.BKDBGT: ""}
+		case common.FieldTypeInt, common.FieldTypeFloat:
+			filter[property.PropertyID] = map[string]interface{}{"$type": "number", common.BKDBGT: 0}
+		case common.FieldTypeBool:
+			filter[property.PropertyID] = true
+		default:
+			return nil, nil, fmt.Errorf("property %s of type %s can not be backed by index", property.PropertyID, property.PropertyType)
+		}
+	}
+	return keys, filter, nil
+}
@@ -62,6 +62,12 @@ type LoginUserPluginInerface interface {
 	GetLoginUrl(c *gin.Context, config map[string]string, input *LogoutRequestParams) string
 }
 
+// LoginUserPluginLogout is implemented by the login plugins which log the user out of the login system
+// with a url other than the login url.
+type LoginUserPluginLogout interface {
+	GetLogoutUrl(c *gin.Context, config map[string]string, input *LogoutRequestParams) string
+}
+
 type LoginSystemUserInfo struct {
 	CnName string `json:"chinese_name"`
 	EnName string `json:"english_name"`
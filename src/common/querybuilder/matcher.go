@@ -0,0 +1,240 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querybuilder
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MapMatcher returns a matcher which evaluates the atom rules against the data, the field of
+// a rule is a dot separated path in the data, for example: cur_data.bk_host_innerip
+func MapMatcher(data map[string]interface{}) Matcher {
+	return func(r AtomRule) bool {
+		value, exist := lookupField(data, r.Field)
+		return r.MatchValue(value, exist)
+	}
+}
+
+func lookupField(data map[string]interface{}, field string) (interface{}, bool) {
+	var current interface{} = data
+	for _, key := range strings.Split(field, ".") {
+		object, ok := toMap(current)
+		if !ok {
+			return nil, false
+		}
+		current, ok = object[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func toMap(value interface{}) (map[string]interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	if m, ok := value.(map[string]interface{}); ok {
+		return m, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	m := make(map[string]interface{}, rv.Len())
+	for _, key := range rv.MapKeys() {
+		m[key.String()] = rv.MapIndex(key).Interface()
+	}
+	return m, true
+}
+
+// MatchValue evaluates the rule against the value of the rule's field, exist is false if
+// there is no such field.
+func (r AtomRule) MatchValue(value interface{}, exist bool) bool {
+	switch r.Operator {
+	case OperatorExist:
+		return exist
+	case OperatorNotExist:
+		return !exist
+	case OperatorIsNull:
+		return !exist || value == nil
+	case OperatorIsNotNull:
+		return exist && value != nil
+	case OperatorIsEmpty:
+		return exist && isEmptySlice(value)
+	case OperatorIsNotEmpty:
+		return exist && isSlice(value) && !isEmptySlice(value)
+	}
+
+	if !exist {
+		// a field that does not exist equals to nothing, same as mongodb
+		switch r.Operator {
+		case OperatorNotEqual, OperatorNotIn, OperatorNotBeginsWith, OperatorNotContains, OperatorNotEndsWith:
+			return true
+		default:
+			return false
+		}
+	}
+
+	switch r.Operator {
+	case OperatorEqual:
+		return valueEqual(value, r.Value)
+	case OperatorNotEqual:
+		return !valueEqual(value, r.Value)
+	case OperatorIn:
+		return valueIn(value, r.Value)
+	case OperatorNotIn:
+		return !valueIn(value, r.Value)
+	case OperatorLess, OperatorLessOrEqual, OperatorGreater, OperatorGreaterOrEqual:
+		left, ok := parseFloat(value)
+		if !ok {
+			return false
+		}
+		right, ok := parseFloat(r.Value)
+		if !ok {
+			return false
+		}
+		return compare(r.Operator, left, right)
+	case OperatorDatetimeLess, OperatorDatetimeLessOrEqual, OperatorDatetimeGreater, OperatorDatetimeGreaterOrEqual:
+		left, ok := toTime(value)
+		if !ok {
+			return false
+		}
+		right, ok := toTime(r.Value)
+		if !ok {
+			return false
+		}
+		return compare(r.Operator, float64(left.UnixNano()), float64(right.UnixNano()))
+	case OperatorBeginsWith:
+		return strings.HasPrefix(fmt.Sprint(value), fmt.Sprint(r.Value))
+	case OperatorNotBeginsWith:
+		return !strings.HasPrefix(fmt.Sprint(value), fmt.Sprint(r.Value))
+	case OperatorContains:
+		return strings.Contains(fmt.Sprint(value), fmt.Sprint(r.Value))
+	case OperatorNotContains:
+		return !strings.Contains(fmt.Sprint(value), fmt.Sprint(r.Value))
+	case OperatorsEndsWith:
+		return strings.HasSuffix(fmt.Sprint(value), fmt.Sprint(r.Value))
+	case OperatorNotEndsWith:
+		return !strings.HasSuffix(fmt.Sprint(value), fmt.Sprint(r.Value))
+	default:
+		return false
+	}
+}
+
+func compare(op Operator, left, right float64) bool {
+	switch op {
+	case OperatorLess, OperatorDatetimeLess:
+		return left < right
+	case OperatorLessOrEqual, OperatorDatetimeLessOrEqual:
+		return left <= right
+	case OperatorGreater, OperatorDatetimeGreater:
+		return left > right
+	case OperatorGreaterOrEqual, OperatorDatetimeGreaterOrEqual:
+		return left >= right
+	default:
+		return false
+	}
+}
+
+func valueEqual(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	_, leftIsNum := toFloat(left)
+	_, rightIsNum := toFloat(right)
+	if leftIsNum || rightIsNum {
+		// the rule value may be decoded as a string, compare it as a number with numeric values
+		leftNum, ok := parseFloat(left)
+		if !ok {
+			return false
+		}
+		rightNum, ok := parseFloat(right)
+		if !ok {
+			return false
+		}
+		return leftNum == rightNum
+	}
+	leftValue, rightValue := reflect.ValueOf(left), reflect.ValueOf(right)
+	if leftValue.Kind() == reflect.String && rightValue.Kind() == reflect.String {
+		return leftValue.String() == rightValue.String()
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func valueIn(value, set interface{}) bool {
+	rv := reflect.ValueOf(set)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return false
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if valueEqual(value, rv.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSlice(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	kind := reflect.ValueOf(value).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+func isEmptySlice(value interface{}) bool {
+	return isSlice(value) && reflect.ValueOf(value).Len() == 0
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// parseFloat converts the numeric value or the numeric string to float
+func parseFloat(value interface{}) (float64, bool) {
+	if value != nil && reflect.TypeOf(value).Kind() == reflect.String {
+		f, err := strconv.ParseFloat(reflect.ValueOf(value).String(), 64)
+		return f, err == nil
+	}
+	return toFloat(value)
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch t := value.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		parsed, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return parsed, true
+	default:
+		return time.Time{}, false
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querybuilder_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common/querybuilder"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapMatcher(t *testing.T) {
+	data := make(map[string]interface{})
+	err := json.Unmarshal([]byte(`{
+		"cur_data": {"bk_host_innerip": "10.0.0.1", "bk_cpu": 8, "bk_os_name": "centos 7", "tags": [], "create_time": "2020-05-01T00:00:00Z"},
+		"pre_data": {"bk_host_innerip": "10.0.0.1", "bk_cpu": 4}
+	}`), &data)
+	assert.Nil(t, err)
+	matcher := querybuilder.MapMatcher(data)
+
+	cases := []struct {
+		rule   querybuilder.AtomRule
+		expect bool
+	}{
+		{querybuilder.AtomRule{Field: "cur_data.bk_cpu", Operator: querybuilder.OperatorEqual, Value: 8}, true},
+		{querybuilder.AtomRule{Field: "cur_data.bk_cpu", Operator: querybuilder.OperatorGreater, Value: 4}, true},
+		{querybuilder.AtomRule{Field: "pre_data.bk_cpu", Operator: querybuilder.OperatorGreaterOrEqual, Value: 8}, false},
+		{querybuilder.AtomRule{Field: "cur_data.bk_host_innerip", Operator: querybuilder.OperatorIn, Value: []interface{}{"10.0.0.1", "10.0.0.2"}}, true},
+		{querybuilder.AtomRule{Field: "cur_data.bk_host_innerip", Operator: querybuilder.OperatorNotIn, Value: []string{"10.0.0.1"}}, false},
+		{querybuilder.AtomRule{Field: "cur_data.bk_os_name", Operator: querybuilder.OperatorBeginsWith, Value: "centos"}, true},
+		{querybuilder.AtomRule{Field: "cur_data.bk_os_name", Operator: querybuilder.OperatorNotContains, Value: "7"}, false},
+		{querybuilder.AtomRule{Field: "cur_data.tags", Operator: querybuilder.OperatorIsEmpty}, true},
+		{querybuilder.AtomRule{Field: "cur_data.create_time", Operator: querybuilder.OperatorDatetimeLess, Value: "2020-06-01T00:00:00Z"}, true},
+		{querybuilder.AtomRule{Field: "pre_data.bk_os_name", Operator: querybuilder.OperatorExist}, false},
+		{querybuilder.AtomRule{Field: "pre_data.bk_os_name", Operator: querybuilder.OperatorNotEqual, Value: "centos 7"}, true},
+		{querybuilder.AtomRule{Field: "pre_data.bk_cpu.value", Operator: querybuilder.OperatorEqual, Value: 4}, false},
+	}
+	for idx, c := range cases {
+		assert.Equal(t, c.expect, c.rule.Match(matcher), "case %d: %+v", idx, c.rule)
+	}
+
+	combined := querybuilder.CombinedRule{
+		Condition: querybuilder.ConditionAnd,
+		Rules: []querybuilder.Rule{
+			cases[0].rule,
+			querybuilder.CombinedRule{
+				Condition: querybuilder.ConditionOr,
+				Rules:     []querybuilder.Rule{cases[2].rule, cases[5].rule},
+			},
+		},
+	}
+	assert.True(t, combined.Match(matcher))
+}
@@ -106,6 +106,29 @@ const (
 
 	// rule for host property auto apply
 	BKTableNameHostApplyRule = "cc_HostApplyRule"
+
+	// BKTableNameEventDeadLetter the events failed to be delivered to the subscribers after all the retries
+	BKTableNameEventDeadLetter = "cc_EventDeadLetter"
+
+	// BKTableNameEventWatchLog the latest events kept for the consumers watching the events
+	BKTableNameEventWatchLog = "cc_EventWatchLog"
+
+	// BKTableNameCronJob the periodic jobs scheduled by the task server
+	BKTableNameCronJob = "cc_CronJob"
+	// BKTableNameCronJobHistory the run history of the cron jobs
+	BKTableNameCronJobHistory = "cc_CronJobHistory"
+
+	// BKTableNameAuditChain the end of the audit log hash chain of each supplier account
+	BKTableNameAuditChain = "cc_AuditChain"
+
+	// the roles, role bindings and user groups of the local rbac authorizer
+	BKTableNameAuthRole        = "cc_AuthRole"
+	BKTableNameAuthRoleBinding = "cc_AuthRoleBinding"
+	BKTableNameAuthUserGroup   = "cc_AuthUserGroup"
+	BKTableNameAPIToken        = "cc_APIToken"
+
+	BKTableNameHostSnapMappingRule = "cc_HostSnapMappingRule"
+	BKTableNameHostDrift           = "cc_HostDrift"
 )
 
 // AllTables alltables
@@ -162,6 +185,17 @@ var AllTables = []string{
 	BKTableNameChartPosition,
 	BKTableNameChartData,
 	BKTableNameHostApplyRule,
+	BKTableNameEventDeadLetter,
+	BKTableNameEventWatchLog,
+	BKTableNameCronJob,
+	BKTableNameCronJobHistory,
+	BKTableNameAuditChain,
+	BKTableNameAuthRole,
+	BKTableNameAuthRoleBinding,
+	BKTableNameAuthUserGroup,
+	BKTableNameAPIToken,
+	BKTableNameHostSnapMappingRule,
+	BKTableNameHostDrift,
 }
 
 // GetInstTableName returns inst data table name
@@ -97,7 +97,9 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		process.Service.SetDB(db)
 		process.Service.SetApiSrvAddr(process.Config.ProcSrvConfig.CCApiSrvAddr)
 
-		if auth.IsAuthed() {
+		if auth.IsAuthed() && process.Config.AuthCenter.IsLocal() {
+			blog.Info("enable local authorization, auth center access is not needed.")
+		} else if auth.IsAuthed() {
 			blog.Info("enable auth center access.")
 			authCli, err := authcenter.NewAuthCenter(nil, process.Config.AuthCenter, engine.Metric().Registry())
 			if err != nil {
@@ -73,7 +73,7 @@ func (d *AuthSynchronizer) Run() error {
 
 	// make fake handler
 	blog.Infof("new auth client with config: %+v", d.AuthConfig)
-	authorize, err := auth.NewAuthorize(nil, d.AuthConfig, d.reg)
+	authorize, err := auth.NewAuthorize(nil, d.AuthConfig, d.clientSet, d.reg)
 	if err != nil {
 		blog.Errorf("new auth client failed, err: %+v", err)
 		return fmt.Errorf("new auth client failed, err: %+v", err)
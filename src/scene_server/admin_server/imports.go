@@ -83,4 +83,16 @@ import (
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.201912171427"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202002231026"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202004141131"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202005151041"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202005201630"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202005221100"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202005231500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202005251500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202005261500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202005271500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202005281500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202005291500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202006011500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202006021500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.7.202006031500"
 )
@@ -0,0 +1,76 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_7_202005151041
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	com "configcenter/src/scene_server/admin_server/common"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+func addHostCloudInstIDProperty(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	filter := map[string]interface{}{
+		common.BKObjIDField:      common.BKInnerObjIDHost,
+		common.BKPropertyIDField: common.BKCloudInstIDField,
+	}
+	count, err := db.Table(common.BKTableNameObjAttDes).Find(filter).Count(ctx)
+	if err != nil {
+		blog.Errorf("count host attribute %s failed, err: %s", common.BKCloudInstIDField, err.Error())
+		return fmt.Errorf("count host attribute %s failed, err: %s", common.BKCloudInstIDField, err.Error())
+	}
+	if count > 0 {
+		return nil
+	}
+
+	attrID, err := db.NextSequence(ctx, common.BKTableNameObjAttDes)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	attribute := map[string]interface{}{
+		"id":                  attrID,
+		"bk_obj_id":           common.BKInnerObjIDHost,
+		"editable":            false,
+		"bk_supplier_account": conf.OwnerID,
+		"ispre":               true,
+		"isreadonly":          true,
+		"bk_issystem":         false,
+		"bk_property_index":   0,
+		"unit":                "",
+		"isrequired":          false,
+		"isonly":              false,
+		"bk_property_type":    common.FieldTypeSingleChar,
+		"option":              "",
+		"bk_property_id":      common.BKCloudInstIDField,
+		"bk_property_name":    "云实例ID",
+		"bk_property_group":   com.BaseInfo,
+		"placeholder":         "主机在云厂商的实例ID，由云同步任务写入",
+		"bk_isapi":            false,
+		"creator":             conf.User,
+		"create_time":         now,
+		"last_time":           now,
+	}
+	if err := db.Table(common.BKTableNameObjAttDes).Insert(ctx, attribute); err != nil {
+		blog.Errorf("insert host attribute %s failed, err: %s", common.BKCloudInstIDField, err.Error())
+		return fmt.Errorf("insert host attribute %s failed, err: %s", common.BKCloudInstIDField, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_7_202005221100
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+func createEventWatchLogTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	tableName := common.BKTableNameEventWatchLog
+	exists, err := db.HasTable(tableName)
+	if err != nil {
+		return fmt.Errorf("check table %s exist failed, err: %v", tableName, err)
+	}
+	if !exists {
+		if err = db.CreateTable(tableName); err != nil && !db.IsDuplicatedError(err) {
+			return fmt.Errorf("create table %s failed, err: %v", tableName, err)
+		}
+	}
+
+	indexes := []dal.Index{
+		{Name: "event_id", Keys: map[string]int32{common.BKEventIDField: 1}, Unique: true, Background: true},
+		{Name: "bk_supplier_account_event_id", Keys: map[string]int32{common.BKOwnerIDField: 1, common.BKEventIDField: 1}, Background: true},
+	}
+
+	existIndexes, err := db.Table(tableName).Indexes(ctx)
+	if err != nil {
+		return fmt.Errorf("get table %s indexes failed, err: %v", tableName, err)
+	}
+	existIndexMap := make(map[string]bool)
+	for _, index := range existIndexes {
+		existIndexMap[index.Name] = true
+	}
+	for _, index := range indexes {
+		if existIndexMap[index.Name] {
+			continue
+		}
+		if err = db.Table(tableName).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+			return fmt.Errorf("create index %s of table %s failed, err: %v", index.Name, tableName, err)
+		}
+	}
+	return nil
+}
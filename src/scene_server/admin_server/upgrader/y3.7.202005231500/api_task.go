@@ -0,0 +1,50 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_7_202005231500
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+func addAPITaskPriority(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	tableName := common.BKTableNameAPITask
+	cond := mapstr.MapStr{"priority": mapstr.MapStr{common.BKDBExists: false}}
+	if err := db.Table(tableName).Update(ctx, cond, mapstr.MapStr{"priority": 0}); err != nil {
+		return fmt.Errorf("set default priority of table %s failed, err: %v", tableName, err)
+	}
+
+	index := dal.Index{
+		Keys:       map[string]int32{"name": 1, "status": 1, "priority": -1},
+		Name:       "idx_name_status_priority",
+		Background: true,
+	}
+	existIndexes, err := db.Table(tableName).Indexes(ctx)
+	if err != nil {
+		return fmt.Errorf("get table %s indexes failed, err: %v", tableName, err)
+	}
+	for _, existIndex := range existIndexes {
+		if existIndex.Name == index.Name {
+			return nil
+		}
+	}
+	if err = db.Table(tableName).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+		return fmt.Errorf("create index %s of table %s failed, err: %v", index.Name, tableName, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_7_202005261500
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+const operationLogIDIndex = "id"
+
+// addOperationLogID assign an id to the audit logs in the order they were recorded, and index it.
+func addOperationLogID(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	cond := map[string]interface{}{
+		common.BKFieldID: map[string]interface{}{common.BKDBExists: false},
+	}
+	for {
+		logs := make([]map[string]interface{}, 0)
+		err := db.Table(common.BKTableNameOperationLog).Find(cond).Fields("_id").Sort("op_time").
+			Limit(common.BKMaxPageSize).All(ctx, &logs)
+		if err != nil {
+			return fmt.Errorf("find audit logs without id failed, err: %v", err)
+		}
+		if len(logs) == 0 {
+			break
+		}
+
+		for _, log := range logs {
+			id, err := db.NextSequence(ctx, common.BKTableNameOperationLog)
+			if err != nil {
+				return fmt.Errorf("generate audit log id failed, err: %v", err)
+			}
+			filter := map[string]interface{}{"_id": log["_id"]}
+			doc := map[string]interface{}{common.BKFieldID: int64(id)}
+			if err := db.Table(common.BKTableNameOperationLog).Update(ctx, filter, doc); err != nil {
+				return fmt.Errorf("update audit log %v id failed, err: %v", log["_id"], err)
+			}
+		}
+	}
+
+	indexes, err := db.Table(common.BKTableNameOperationLog).Indexes(ctx)
+	if err != nil {
+		return fmt.Errorf("get audit log indexes failed, err: %v", err)
+	}
+	for _, index := range indexes {
+		if index.Name == operationLogIDIndex {
+			return nil
+		}
+	}
+
+	index := dal.Index{
+		Name:       operationLogIDIndex,
+		Keys:       map[string]int32{common.BKFieldID: 1},
+		Background: true,
+	}
+	if err := db.Table(common.BKTableNameOperationLog).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+		return fmt.Errorf("create audit log index %s failed, err: %v", operationLogIDIndex, err)
+	}
+	return nil
+}
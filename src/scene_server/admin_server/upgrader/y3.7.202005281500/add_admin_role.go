@@ -0,0 +1,82 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_7_202005281500
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	adminRoleName = "admin"
+	adminUser     = "admin"
+)
+
+// addAdminRole adds a role with all the permissions and binds it to the admin user globally,
+// so that the administrator can manage the roles when the local authorizer is enabled.
+func addAdminRole(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	roleFilter := map[string]interface{}{
+		common.BKOwnerIDField: conf.OwnerID,
+		"name":                adminRoleName,
+	}
+	count, err := db.Table(common.BKTableNameAuthRole).Find(roleFilter).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	now := time.Now()
+	roleID, err := db.NextSequence(ctx, common.BKTableNameAuthRole)
+	if err != nil {
+		return err
+	}
+	role := metadata.RBACRole{
+		ID:              int64(roleID),
+		Name:            adminRoleName,
+		Description:     "all the permissions of cmdb",
+		Grants:          []metadata.RBACGrant{{ResourceType: metadata.RBACAny, Actions: []string{metadata.RBACAny}}},
+		Creator:         conf.User,
+		Modifier:        conf.User,
+		CreateTime:      now,
+		LastTime:        now,
+		SupplierAccount: conf.OwnerID,
+	}
+	if err := db.Table(common.BKTableNameAuthRole).Insert(ctx, role); err != nil {
+		return err
+	}
+
+	bindingID, err := db.NextSequence(ctx, common.BKTableNameAuthRoleBinding)
+	if err != nil {
+		return err
+	}
+	binding := metadata.RBACRoleBinding{
+		ID:              int64(bindingID),
+		RoleID:          role.ID,
+		SubjectType:     metadata.RBACSubjectUser,
+		Subject:         adminUser,
+		BizID:           0,
+		Creator:         conf.User,
+		Modifier:        conf.User,
+		CreateTime:      now,
+		LastTime:        now,
+		SupplierAccount: conf.OwnerID,
+	}
+	return db.Table(common.BKTableNameAuthRoleBinding).Insert(ctx, binding)
+}
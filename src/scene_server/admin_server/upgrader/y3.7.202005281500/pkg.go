@@ -0,0 +1,45 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_7_202005281500
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+/*
+添加本地鉴权的角色、角色授权及用户组表，并创建默认的管理员角色
+*/
+func init() {
+	upgrader.RegistUpgrader("y3.7.202005281500", upgrade)
+}
+
+func upgrade(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	blog.Infof("start execute y3.7.202005281500")
+
+	if err := createRBACTables(ctx, db, conf); err != nil {
+		blog.Errorf("[upgrade y3.7.202005281500] createRBACTables failed, error %s", err.Error())
+		return fmt.Errorf("createRBACTables failed, error %s", err.Error())
+	}
+
+	if err := addAdminRole(ctx, db, conf); err != nil {
+		blog.Errorf("[upgrade y3.7.202005281500] addAdminRole failed, error %s", err.Error())
+		return fmt.Errorf("addAdminRole failed, error %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_7_202006011500
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// createUniqueIndexes creates the db unique indexes backing the existing unique rules, the rule whose instances
+// are already duplicated, or whose table has too many indexes, is skipped with a log, as it is still checked
+// by the application.
+func createUniqueIndexes(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	uniques := make([]metadata.ObjectUnique, 0)
+	if err := db.Table(common.BKTableNameObjUnique).Find(nil).All(ctx, &uniques); err != nil {
+		return fmt.Errorf("find unique rules failed, err: %v", err)
+	}
+
+	for _, unique := range uniques {
+		propertyIDs := make([]uint64, 0, len(unique.Keys))
+		for _, key := range unique.Keys {
+			propertyIDs = append(propertyIDs, key.ID)
+		}
+		cond := map[string]interface{}{
+			common.BKObjIDField:   unique.ObjID,
+			common.BKOwnerIDField: unique.OwnerID,
+			common.BKFieldID:      map[string]interface{}{common.BKDBIN: propertyIDs},
+		}
+		properties := make([]metadata.Attribute, 0)
+		if err := db.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &properties); err != nil {
+			return fmt.Errorf("find properties of unique %d failed, err: %v", unique.ID, err)
+		}
+
+		keys, filter, err := unique.IndexKeysAndFilter(properties)
+		if err != nil {
+			blog.Warnf("unique %d of %s is not backed by index, reason: %v", unique.ID, unique.ObjID, err)
+			continue
+		}
+
+		tableName := common.GetInstTableName(unique.ObjID)
+		index := dal.Index{
+			Name:                    metadata.UniqueIndexName(unique.ID),
+			Keys:                    keys,
+			Unique:                  true,
+			Background:              true,
+			PartialFilterExpression: filter,
+		}
+		existIndexes, err := db.Table(tableName).Indexes(ctx)
+		if err != nil {
+			return fmt.Errorf("get table %s indexes failed, err: %v", tableName, err)
+		}
+		exists := false
+		for _, existIndex := range existIndexes {
+			if existIndex.Name == index.Name {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+		if len(existIndexes) >= metadata.UniqueIndexTableLimit {
+			blog.Warnf("unique %d of %s is not backed by index, table %s has %d indexes", unique.ID, unique.ObjID,
+				tableName, len(existIndexes))
+			continue
+		}
+
+		if err := db.Table(tableName).CreateIndex(ctx, index); err != nil {
+			blog.Errorf("create index %s of table %s failed, the unique is checked by the application, err: %v",
+				index.Name, tableName, err)
+			continue
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_7_202006021500
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+/*
+添加主机快照映射规则表
+*/
+func init() {
+	upgrader.RegistUpgrader("y3.7.202006021500", upgrade)
+}
+
+func upgrade(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	blog.Infof("start execute y3.7.202006021500")
+
+	if err := createHostSnapMappingRuleTable(ctx, db, conf); err != nil {
+		blog.Errorf("[upgrade y3.7.202006021500] createHostSnapMappingRuleTable failed, error %s", err.Error())
+		return fmt.Errorf("createHostSnapMappingRuleTable failed, error %s", err.Error())
+	}
+
+	return nil
+}
@@ -51,9 +51,23 @@ type Config struct {
 	NetCollectRedis SnapRedis
 	Esb             esbutil.EsbConfig
 	AuthConfig      authcenter.AuthConfig
+	SnapIngest      SnapIngest
+	SnapDrift       SnapDrift
 }
 
 type SnapRedis struct {
 	redis.Config
 	Enable string
 }
+
+type SnapIngest struct {
+	Enable bool
+	Tokens []string
+	// MinInterval is the min interval in seconds between two snapshots of a host
+	MinInterval int
+}
+
+type SnapDrift struct {
+	// RetentionDays is the days to keep the host drift history, 0 to disable the history
+	RetentionDays int
+}
@@ -17,6 +17,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,7 +32,7 @@ import (
 	"configcenter/src/common/types"
 	"configcenter/src/common/version"
 	"configcenter/src/scene_server/datacollection/app/options"
-	"configcenter/src/scene_server/datacollection/datacollection"
+	dc "configcenter/src/scene_server/datacollection/datacollection"
 	"configcenter/src/scene_server/datacollection/logics"
 	svc "configcenter/src/scene_server/datacollection/service"
 	"configcenter/src/storage/dal"
@@ -93,7 +95,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 
 		process.Service.SetDB(mgoCli)
 		process.Service.Logics = logics.NewLogics(ctx, service.Engine, mgoCli, esb)
-		datacollection := datacollection.NewDataCollection(ctx, process.Core, mgoCli, engine.Metric().Registry())
+		datacollection := dc.NewDataCollection(ctx, process.Core, mgoCli, engine.Metric().Registry())
 
 		blog.Infof("[data-collection][RUN]connecting to cc redis %+v", process.Config.CCRedis)
 		redisCli, err := redis.NewFromConfig(process.Config.CCRedis)
@@ -136,17 +138,24 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		}
 		if enableauth.IsAuthed() {
 			blog.Info("[data-collection] auth enabled")
-			authorize, err := auth.NewAuthorize(nil, process.Config.AuthConfig, engine.Metric().Registry())
+			authorize, err := auth.NewAuthorize(nil, process.Config.AuthConfig, engine.CoreAPI, engine.Metric().Registry())
 			if err != nil {
 				return fmt.Errorf("[data-collection] new authorize failed, err: %v", err)
 			}
 			datacollection.AuthManager = *extensions.NewAuthManager(engine.CoreAPI, authorize)
 		}
 
+		datacollection.IngestConfig = dc.IngestConfig{
+			Enable:      process.Config.SnapIngest.Enable,
+			Tokens:      process.Config.SnapIngest.Tokens,
+			MinInterval: time.Duration(process.Config.SnapIngest.MinInterval) * time.Second,
+		}
+		datacollection.DriftRetention = time.Duration(process.Config.SnapDrift.RetentionDays) * 24 * time.Hour
 		err = datacollection.Run(redisCli, snapcli, disCli, netCli)
 		if err != nil {
 			return fmt.Errorf("run datacollection routine failed %s", err.Error())
 		}
+		process.Service.SetSnapIngester(datacollection.SnapIngester())
 		break
 	}
 
@@ -169,6 +178,12 @@ type DCServer struct {
 
 var configLock sync.Mutex
 
+// defaultSnapIngestMinInterval is the default min interval in seconds between two snapshots of a host
+const defaultSnapIngestMinInterval = 60
+
+// defaultSnapDriftRetentionDays is the default days to keep the host drift history
+const defaultSnapDriftRetentionDays = 180
+
 func (h *DCServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 	configLock.Lock()
 	defer configLock.Unlock()
@@ -208,6 +223,25 @@ func (h *DCServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 		h.Config.Esb.AppCode = current.ConfigMap[esbPrefix+".appCode"]
 		h.Config.Esb.AppSecret = current.ConfigMap[esbPrefix+".appSecret"]
 
+		ingestPrefix := "snapshot-ingest"
+		h.Config.SnapIngest.Enable = current.ConfigMap[ingestPrefix+".enable"] == "true"
+		h.Config.SnapIngest.Tokens = make([]string, 0)
+		for _, token := range strings.Split(current.ConfigMap[ingestPrefix+".tokens"], ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				h.Config.SnapIngest.Tokens = append(h.Config.SnapIngest.Tokens, token)
+			}
+		}
+		h.Config.SnapIngest.MinInterval = defaultSnapIngestMinInterval
+		if interval, err := strconv.Atoi(current.ConfigMap[ingestPrefix+".minInterval"]); err == nil && interval >= 0 {
+			h.Config.SnapIngest.MinInterval = interval
+		}
+
+		driftPrefix := "snapshot-drift"
+		h.Config.SnapDrift.RetentionDays = defaultSnapDriftRetentionDays
+		if days, err := strconv.Atoi(current.ConfigMap[driftPrefix+".retentionDays"]); err == nil && days >= 0 {
+			h.Config.SnapDrift.RetentionDays = days
+		}
+
 		var err error
 		authPrefix := "auth"
 		h.Config.AuthConfig, err = authcenter.ParseConfigFromKV(authPrefix, current.ConfigMap)
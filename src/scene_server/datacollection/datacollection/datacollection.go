@@ -38,6 +38,11 @@ type DataCollection struct {
 	ctx         context.Context
 	registry    prometheus.Registerer
 	AuthManager extensions.AuthManager
+
+	IngestConfig IngestConfig
+	ingester     *SnapIngester
+	// DriftRetention the retention of the host drift history recorded from the snapshots, 0 to disable the history
+	DriftRetention time.Duration
 }
 
 func NewDataCollection(ctx context.Context, backbone *backbone.Engine, db dal.RDB, registry prometheus.Registerer) *DataCollection {
@@ -60,9 +65,20 @@ func (d *DataCollection) Run(redisCli, snapCli, disCli, netCli *redis.Client) er
 
 	manager := NewManager()
 
+	if d.IngestConfig.Enable && len(d.IngestConfig.Tokens) == 0 {
+		blog.Errorf("host snapshot ingestion is disabled for no token is configured")
+		d.IngestConfig.Enable = false
+	}
+	var hostsnapCollector *hostsnap.HostSnap
+	if snapCli != nil || d.IngestConfig.Enable {
+		hostsnapCollector = hostsnap.NewHostSnap(d.ctx, redisCli, d.db, d.Engine, d.AuthManager, d.DriftRetention)
+	}
+	if d.IngestConfig.Enable {
+		d.ingester = NewSnapIngester(hostsnapCollector, d.IngestConfig)
+		blog.Infof("host snapshot ingestion enabled, min interval: %v", d.IngestConfig.MinInterval)
+	}
 	if snapCli != nil {
 		snapChanName := d.getSnapChanName(defaultAppID)
-		hostsnapCollector := hostsnap.NewHostSnap(d.ctx, redisCli, d.db, d.Engine, d.AuthManager)
 		snapPorter := BuildChanPorter("hostsnap", hostsnapCollector, redisCli, snapCli, snapChanName, hostsnap.MockMessage, d.registry, d.Engine)
 		manager.AddPorter(snapPorter)
 	}
@@ -83,6 +99,11 @@ func (d *DataCollection) Run(redisCli, snapCli, disCli, netCli *redis.Client) er
 	return nil
 }
 
+// SnapIngester returns the host snapshot ingester, nil if the ingestion is disabled
+func (d *DataCollection) SnapIngester() *SnapIngester {
+	return d.ingester
+}
+
 func (d *DataCollection) getNetcollectChanName(defaultAppID string) []string {
 	return []string{"netdevice2"}
 }
@@ -0,0 +1,328 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// SnapFormatNative is the snapshot json reported by the gse agent
+	SnapFormatNative = "native"
+	// SnapFormatAnsible is the output of the ansible setup module
+	SnapFormatAnsible = "ansible"
+	// SnapFormatNodeExporter is the text exposition of the prometheus node_exporter
+	SnapFormatNodeExporter = "node_exporter"
+)
+
+// SnapSource is where the converted snapshot comes from, the hosts are always matched in the supplier account
+// of the source, ip is used when the report does not contain it
+type SnapSource struct {
+	OwnerID string
+	CloudID int64
+	IP      string
+}
+
+// ConvertSnapshot converts the report of the format to the native snapshot which is analyzed by HostSnap
+func ConvertSnapshot(format string, body []byte, source SnapSource) (string, error) {
+	switch format {
+	case SnapFormatNative, "":
+		snapshot := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(body, &snapshot); err != nil {
+			return "", fmt.Errorf("snapshot is not a valid json object")
+		}
+		val := gjson.ParseBytes(body)
+		if !val.Get("cloudid").Exists() || !val.Get("data").IsObject() {
+			return "", fmt.Errorf("snapshot has no cloudid or data")
+		}
+		// the reported host belongs to the supplier account of the source, whatever the snapshot says
+		ownerID, err := json.Marshal(source.OwnerID)
+		if err != nil {
+			return "", err
+		}
+		snapshot["bizid"] = ownerID
+		js, err := json.Marshal(snapshot)
+		if err != nil {
+			return "", err
+		}
+		return string(js), nil
+	case SnapFormatAnsible:
+		return convertAnsibleFacts(body, source)
+	case SnapFormatNodeExporter:
+		return convertNodeExporter(body, source)
+	default:
+		return "", fmt.Errorf("snapshot format %s is not supported", format)
+	}
+}
+
+func buildSnapshot(source SnapSource, ip string, data map[string]interface{}) (string, error) {
+	snapshot := map[string]interface{}{
+		"ip":      ip,
+		"bizid":   source.OwnerID,
+		"cloudid": source.CloudID,
+		"data":    data,
+	}
+	js, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+	return string(js), nil
+}
+
+// osBit converts the machine architecture to the value of bk_os_bit
+func osBit(machine string) string {
+	switch strings.ToLower(machine) {
+	case "x86_64", "amd64", "aarch64", "arm64", "ppc64", "ppc64le", "s390x":
+		return "64-bit"
+	case "i386", "i686", "x86", "armv7l":
+		return "32-bit"
+	default:
+		return machine
+	}
+}
+
+// convertAnsibleFacts converts the facts of the ansible setup module, which are either wrapped in
+// "ansible_facts" or not.
+func convertAnsibleFacts(body []byte, source SnapSource) (string, error) {
+	if !json.Valid(body) {
+		return "", fmt.Errorf("ansible facts is not valid json")
+	}
+	facts := gjson.ParseBytes(body)
+	if wrapped := facts.Get("ansible_facts"); wrapped.IsObject() {
+		facts = wrapped
+	}
+	if !facts.Get("ansible_hostname").Exists() && !facts.Get("ansible_default_ipv4").Exists() {
+		return "", fmt.Errorf("ansible facts has no ansible_hostname or ansible_default_ipv4")
+	}
+
+	ip := facts.Get("ansible_default_ipv4.address").String()
+	if ip == "" {
+		ip = source.IP
+	}
+
+	modelName := ""
+	processors := facts.Get("ansible_processor").Array()
+	if len(processors) > 0 {
+		modelName = processors[len(processors)-1].String()
+	}
+	cores := facts.Get("ansible_processor_vcpus").Int()
+	if cores == 0 {
+		cores = facts.Get("ansible_processor_count").Int() * facts.Get("ansible_processor_cores").Int()
+	}
+
+	usage := make([]map[string]interface{}, 0)
+	partition := make([]map[string]interface{}, 0)
+	for _, mount := range facts.Get("ansible_mounts").Array() {
+		usage = append(usage, map[string]interface{}{
+			"path":   mount.Get("mount").String(),
+			"fstype": mount.Get("fstype").String(),
+			"total":  mount.Get("size_total").Int(),
+			"free":   mount.Get("size_available").Int(),
+		})
+		partition = append(partition, map[string]interface{}{
+			"device":     mount.Get("device").String(),
+			"mountpoint": mount.Get("mount").String(),
+			"fstype":     mount.Get("fstype").String(),
+		})
+	}
+
+	interfaces := make([]map[string]interface{}, 0)
+	for _, name := range facts.Get("ansible_interfaces").Array() {
+		// the fact names of the interfaces have "-" replaced by "_"
+		iface := facts.Get("ansible_" + strings.Replace(name.String(), "-", "_", -1))
+		if !iface.Exists() {
+			continue
+		}
+		addrs := make([]map[string]interface{}, 0)
+		ipv4s := append([]gjson.Result{iface.Get("ipv4")}, iface.Get("ipv4_secondaries").Array()...)
+		for _, ipv4 := range ipv4s {
+			if !ipv4.Get("address").Exists() {
+				continue
+			}
+			addrs = append(addrs, map[string]interface{}{"addr": cidr(ipv4.Get("address").String(), ipv4.Get("netmask").String())})
+		}
+		for _, ipv6 := range iface.Get("ipv6").Array() {
+			addrs = append(addrs, map[string]interface{}{"addr": ipv6.Get("address").String() + "/" + ipv6.Get("prefix").String()})
+		}
+		interfaces = append(interfaces, map[string]interface{}{
+			"name":         name.String(),
+			"mtu":          iface.Get("mtu").Int(),
+			"hardwareaddr": iface.Get("macaddress").String(),
+			"addrs":        addrs,
+		})
+	}
+
+	data := map[string]interface{}{
+		"cpu": map[string]interface{}{
+			"cpuinfo": []map[string]interface{}{{"cores": cores, "modelName": modelName}},
+		},
+		"mem": map[string]interface{}{
+			"meminfo": map[string]interface{}{"total": facts.Get("ansible_memtotal_mb").Int() * 1024 * 1024},
+		},
+		"disk": map[string]interface{}{"usage": usage, "partition": partition},
+		"net":  map[string]interface{}{"interface": interfaces},
+		"system": map[string]interface{}{
+			"info": map[string]interface{}{
+				"hostname":        facts.Get("ansible_hostname").String(),
+				"os":              strings.ToLower(facts.Get("ansible_system").String()),
+				"platform":        strings.ToLower(facts.Get("ansible_distribution").String()),
+				"platformFamily":  strings.ToLower(facts.Get("ansible_os_family").String()),
+				"platformVersion": facts.Get("ansible_distribution_version").String(),
+				"kernelVersion":   facts.Get("ansible_kernel").String(),
+				"systemtype":      osBit(facts.Get("ansible_architecture").String()),
+			},
+		},
+	}
+	return buildSnapshot(source, ip, data)
+}
+
+func cidr(ip, netmask string) string {
+	mask := net.ParseIP(netmask)
+	if mask == nil || mask.To4() == nil {
+		return ip
+	}
+	ones, _ := net.IPMask(mask.To4()).Size()
+	return ip + "/" + strconv.Itoa(ones)
+}
+
+// the pseudo file systems are not counted in the disk of the host
+var pseudoFSTypes = map[string]bool{
+	"tmpfs":    true,
+	"devtmpfs": true,
+	"overlay":  true,
+	"squashfs": true,
+	"ramfs":    true,
+	"nsfs":     true,
+	"rootfs":   true,
+}
+
+// convertNodeExporter converts the text exposition of the node_exporter, which does not contain the ip
+// of the host, so the ip of the source is used.
+func convertNodeExporter(body []byte, source SnapSource) (string, error) {
+	if source.IP == "" {
+		return "", fmt.Errorf("ip is required for node_exporter metrics")
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("parse node_exporter metrics failed, %v", err)
+	}
+	if _, ok := families["node_uname_info"]; !ok {
+		return "", fmt.Errorf("node_exporter metrics has no node_uname_info")
+	}
+
+	labels := func(name string) []map[string]string {
+		result := make([]map[string]string, 0)
+		family, ok := families[name]
+		if !ok {
+			return result
+		}
+		for _, metric := range family.Metric {
+			pairs := make(map[string]string)
+			for _, label := range metric.Label {
+				pairs[label.GetName()] = label.GetValue()
+			}
+			if metric.Gauge != nil {
+				pairs["__value__"] = strconv.FormatFloat(metric.Gauge.GetValue(), 'f', -1, 64)
+			} else if metric.Counter != nil {
+				pairs["__value__"] = strconv.FormatFloat(metric.Counter.GetValue(), 'f', -1, 64)
+			} else if metric.Untyped != nil {
+				pairs["__value__"] = strconv.FormatFloat(metric.Untyped.GetValue(), 'f', -1, 64)
+			}
+			result = append(result, pairs)
+		}
+		return result
+	}
+	value := func(pairs map[string]string) int64 {
+		num, _ := strconv.ParseFloat(pairs["__value__"], 64)
+		return int64(num)
+	}
+
+	uname := labels("node_uname_info")[0]
+	info := map[string]interface{}{
+		"hostname":      uname["nodename"],
+		"os":            strings.ToLower(uname["sysname"]),
+		"kernelVersion": uname["release"],
+		"systemtype":    osBit(uname["machine"]),
+	}
+	if osInfo := labels("node_os_info"); len(osInfo) > 0 {
+		info["platform"] = osInfo[0]["id"]
+		info["platformVersion"] = osInfo[0]["version_id"]
+	}
+
+	cpus := make(map[string]bool)
+	for _, pairs := range labels("node_cpu_seconds_total") {
+		cpus[pairs["cpu"]] = true
+	}
+	modelName := ""
+	if cpuInfo := labels("node_cpu_info"); len(cpuInfo) > 0 {
+		modelName = cpuInfo[0]["model_name"]
+	}
+
+	var memTotal int64
+	if mem := labels("node_memory_MemTotal_bytes"); len(mem) > 0 {
+		memTotal = value(mem[0])
+	}
+
+	usage := make([]map[string]interface{}, 0)
+	partition := make([]map[string]interface{}, 0)
+	devices := make(map[string]bool)
+	for _, pairs := range labels("node_filesystem_size_bytes") {
+		// a device mounted on multiple points is counted once
+		if pseudoFSTypes[pairs["fstype"]] || devices[pairs["device"]] {
+			continue
+		}
+		devices[pairs["device"]] = true
+		usage = append(usage, map[string]interface{}{
+			"path":   pairs["mountpoint"],
+			"fstype": pairs["fstype"],
+			"total":  value(pairs),
+		})
+		partition = append(partition, map[string]interface{}{
+			"device":     pairs["device"],
+			"mountpoint": pairs["mountpoint"],
+			"fstype":     pairs["fstype"],
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i]["path"].(string) < usage[j]["path"].(string) })
+	sort.Slice(partition, func(i, j int) bool { return partition[i]["mountpoint"].(string) < partition[j]["mountpoint"].(string) })
+
+	interfaces := make([]map[string]interface{}, 0)
+	for _, pairs := range labels("node_network_info") {
+		interfaces = append(interfaces, map[string]interface{}{
+			"name":         pairs["device"],
+			"hardwareaddr": pairs["address"],
+			"addrs":        []map[string]interface{}{},
+		})
+	}
+
+	data := map[string]interface{}{
+		"cpu": map[string]interface{}{
+			"cpuinfo": []map[string]interface{}{{"cores": len(cpus), "modelName": modelName}},
+		},
+		"mem":    map[string]interface{}{"meminfo": map[string]interface{}{"total": memTotal}},
+		"disk":   map[string]interface{}{"usage": usage, "partition": partition},
+		"net":    map[string]interface{}{"interface": interfaces},
+		"system": map[string]interface{}{"info": info},
+	}
+	return buildSnapshot(source, source.IP, data)
+}
@@ -0,0 +1,140 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+
+	"github.com/tidwall/gjson"
+)
+
+const ansibleFacts = `{
+    "ansible_facts": {
+        "ansible_hostname": "web-01",
+        "ansible_system": "Linux",
+        "ansible_distribution": "CentOS",
+        "ansible_os_family": "RedHat",
+        "ansible_distribution_version": "7.6",
+        "ansible_kernel": "3.10.0-957.el7.x86_64",
+        "ansible_architecture": "x86_64",
+        "ansible_processor": ["0", "GenuineIntel", "Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz"],
+        "ansible_processor_vcpus": 4,
+        "ansible_memtotal_mb": 7821,
+        "ansible_default_ipv4": {"address": "10.0.0.8", "interface": "eth0"},
+        "ansible_interfaces": ["lo", "eth0"],
+        "ansible_lo": {"device": "lo", "mtu": 65536, "ipv4": {"address": "127.0.0.1", "netmask": "255.0.0.0"}},
+        "ansible_eth0": {"device": "eth0", "mtu": 1500, "macaddress": "52:54:00:19:2e:e8",
+            "ipv4": {"address": "10.0.0.8", "netmask": "255.255.255.0"}},
+        "ansible_mounts": [
+            {"mount": "/", "device": "/dev/vda1", "fstype": "xfs", "size_total": 53660876800, "size_available": 40000000000}
+        ]
+    }
+}`
+
+const nodeExporterMetrics = `# HELP node_uname_info Labeled system information as provided by the uname system call.
+# TYPE node_uname_info gauge
+node_uname_info{domainname="(none)",machine="x86_64",nodename="web-02",release="4.18.0-80.el8.x86_64",sysname="Linux",version="#1 SMP"} 1
+# TYPE node_os_info gauge
+node_os_info{id="rocky",name="Rocky Linux",version_id="8.6"} 1
+# TYPE node_memory_MemTotal_bytes gauge
+node_memory_MemTotal_bytes 8.201158656e+09
+# TYPE node_cpu_seconds_total counter
+node_cpu_seconds_total{cpu="0",mode="idle"} 1000
+node_cpu_seconds_total{cpu="0",mode="user"} 10
+node_cpu_seconds_total{cpu="1",mode="idle"} 1000
+# TYPE node_filesystem_size_bytes gauge
+node_filesystem_size_bytes{device="/dev/vda1",fstype="xfs",mountpoint="/"} 5.36608768e+10
+node_filesystem_size_bytes{device="/dev/vda1",fstype="xfs",mountpoint="/var/lib/docker"} 5.36608768e+10
+node_filesystem_size_bytes{device="tmpfs",fstype="tmpfs",mountpoint="/run"} 4.100579328e+09
+# TYPE node_network_info gauge
+node_network_info{address="52:54:00:aa:bb:cc",broadcast="ff:ff:ff:ff:ff:ff",device="eth0",duplex="",ifalias="",operstate="up"} 1
+`
+
+func TestConvertAnsibleFacts(t *testing.T) {
+	snapshot, err := ConvertSnapshot(SnapFormatAnsible, []byte(ansibleFacts), SnapSource{OwnerID: common.BKDefaultOwnerID, CloudID: 2})
+	if err != nil {
+		t.Fatalf("convert ansible facts failed, err: %v", err)
+	}
+	val := gjson.Parse(snapshot)
+	setter := parseSetter(&val, "10.0.0.8", "")
+
+	expected := map[string]interface{}{
+		"bk_cpu":        int64(4),
+		"bk_cpu_module": "Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz",
+		"bk_disk":       int64(49),
+		"bk_mem":        int64(7821),
+		"bk_os_type":    common.HostOSTypeEnumLinux,
+		"bk_os_name":    "linux centos",
+		"bk_os_version": "7.6",
+		"bk_host_name":  "web-01",
+		"bk_mac":        "52:54:00:19:2e:e8",
+		"bk_os_bit":     "64-bit",
+	}
+	for key, value := range expected {
+		if setter[key] != value {
+			t.Errorf("expect %s to be %#v, got %#v", key, value, setter[key])
+		}
+	}
+	if val.Get("cloudid").Int() != 2 || val.Get("ip").String() != "10.0.0.8" || val.Get("bizid").String() != common.BKDefaultOwnerID {
+		t.Errorf("unexpected snapshot source: %s", snapshot)
+	}
+	if ips := getIPS(&val); len(ips) != 2 || ips[1] != "10.0.0.8" {
+		t.Errorf("unexpected ips %v", ips)
+	}
+}
+
+func TestConvertNodeExporter(t *testing.T) {
+	if _, err := ConvertSnapshot(SnapFormatNodeExporter, []byte(nodeExporterMetrics), SnapSource{}); err == nil {
+		t.Errorf("expect node_exporter metrics without ip to be invalid")
+	}
+
+	snapshot, err := ConvertSnapshot(SnapFormatNodeExporter, []byte(nodeExporterMetrics), SnapSource{OwnerID: common.BKDefaultOwnerID, IP: "10.0.0.9"})
+	if err != nil {
+		t.Fatalf("convert node_exporter metrics failed, err: %v", err)
+	}
+	val := gjson.Parse(snapshot)
+	setter := parseSetter(&val, "10.0.0.9", "")
+
+	expected := map[string]interface{}{
+		"bk_cpu":        int64(2),
+		"bk_disk":       int64(49),
+		"bk_mem":        int64(7821),
+		"bk_os_type":    common.HostOSTypeEnumLinux,
+		"bk_os_name":    "linux rocky",
+		"bk_os_version": "8.6",
+		"bk_host_name":  "web-02",
+		"bk_os_bit":     "64-bit",
+	}
+	for key, value := range expected {
+		if setter[key] != value {
+			t.Errorf("expect %s to be %#v, got %#v", key, value, setter[key])
+		}
+	}
+	if kernel := val.Get("data.system.info.kernelVersion").String(); kernel != "4.18.0-80.el8.x86_64" {
+		t.Errorf("unexpected kernel version %s", kernel)
+	}
+}
+
+func TestConvertNative(t *testing.T) {
+	if _, err := ConvertSnapshot(SnapFormatNative, []byte(MockMessageData), SnapSource{}); err != nil {
+		t.Errorf("convert native snapshot failed, err: %v", err)
+	}
+	if _, err := ConvertSnapshot(SnapFormatNative, []byte(`{"ip": "10.0.0.1"}`), SnapSource{}); err == nil {
+		t.Errorf("expect snapshot without cloudid to be invalid")
+	}
+	if _, err := ConvertSnapshot("collectd", []byte(MockMessageData), SnapSource{}); err == nil {
+		t.Errorf("expect unknown format to be invalid")
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+)
+
+var (
+	// cleanDriftInterval the interval to remove the drift history out of the retention
+	cleanDriftInterval = time.Hour
+)
+
+// diffHost returns the fields in setter changed from the host data before the update,
+// the values are compared by their text so that the number types stored in db won't be a drift.
+func diffHost(setter map[string]interface{}, preData map[string]interface{}) []metadata.HostDriftChange {
+	changes := make([]metadata.HostDriftChange, 0)
+	for key, cur := range setter {
+		pre := preData[key]
+		if pre == nil && cur == "" {
+			continue
+		}
+		if pre != nil && fmt.Sprint(pre) == fmt.Sprint(cur) {
+			continue
+		}
+		changes = append(changes, metadata.HostDriftChange{
+			PropertyID: key,
+			PreValue:   pre,
+			CurValue:   cur,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].PropertyID < changes[j].PropertyID
+	})
+	return changes
+}
+
+// recordDrift saves the changed fields of the host updated by a snapshot to the drift history,
+// the history is only a record, so the failure won't fail the snapshot.
+func (h *HostSnap) recordDrift(hostID int64, ownerID string, setter, preData map[string]interface{}) {
+	if h.driftRetention <= 0 {
+		return
+	}
+	changes := diffHost(setter, preData)
+	if len(changes) == 0 {
+		return
+	}
+	drift := metadata.HostDrift{
+		HostID:     hostID,
+		OwnerID:    ownerID,
+		Changes:    changes,
+		CreateTime: time.Now(),
+	}
+	if err := h.db.Table(common.BKTableNameHostDrift).Insert(h.ctx, drift); err != nil {
+		blog.Errorf("[data-collection][hostsnap] save drift of host %d failed, err: %v, drift: %+v", hostID, err, drift)
+	}
+}
+
+func (h *HostSnap) cleanDriftLoop() {
+	for range time.Tick(cleanDriftInterval) {
+		h.cleanDrift()
+	}
+}
+
+func (h *HostSnap) cleanDrift() {
+	cond := map[string]interface{}{
+		common.CreateTimeField: map[string]interface{}{common.BKDBLT: time.Now().Add(-h.driftRetention)},
+	}
+	if err := h.db.Table(common.BKTableNameHostDrift).Delete(h.ctx, cond); err != nil {
+		blog.Errorf("[data-collection][hostsnap] remove expired host drift failed, err: %v", err)
+	}
+}
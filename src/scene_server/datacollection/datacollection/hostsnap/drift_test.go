@@ -0,0 +1,51 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestDiffHost(t *testing.T) {
+	preData := map[string]interface{}{
+		"bk_cpu":       int32(8),
+		"bk_mem":       int64(16384),
+		"bk_os_name":   "linux centos",
+		"bk_host_name": "host-a",
+	}
+	setter := map[string]interface{}{
+		"bk_cpu":       int64(8),
+		"bk_mem":       int64(32768),
+		"bk_os_name":   "linux centos",
+		"bk_host_name": "host-b",
+		"bk_mac":       "",
+		"bk_outer_mac": "52:54:00:12:34:56",
+	}
+
+	expected := []metadata.HostDriftChange{
+		{PropertyID: "bk_host_name", PreValue: "host-a", CurValue: "host-b"},
+		{PropertyID: "bk_mem", PreValue: int64(16384), CurValue: int64(32768)},
+		{PropertyID: "bk_outer_mac", PreValue: nil, CurValue: "52:54:00:12:34:56"},
+	}
+	changes := diffHost(setter, preData)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("diff host failed, expected: %+v, got: %+v", expected, changes)
+	}
+
+	if changes := diffHost(preData, preData); len(changes) != 0 {
+		t.Fatalf("diff host with itself should have no change, got: %+v", changes)
+	}
+}
@@ -49,6 +49,9 @@ type HostSnap struct {
 	cachelock sync.RWMutex
 	ctx       context.Context
 	db        dal.RDB
+	mapping   mappingRules
+	// driftRetention the retention of the host drift history, no history is recorded if it's not positive
+	driftRetention time.Duration
 }
 
 type Cache struct {
@@ -56,7 +59,8 @@ type Cache struct {
 	flag  bool
 }
 
-func NewHostSnap(ctx context.Context, redisCli *redis.Client, db dal.RDB, engine *backbone.Engine, authManager extensions.AuthManager) *HostSnap {
+func NewHostSnap(ctx context.Context, redisCli *redis.Client, db dal.RDB, engine *backbone.Engine, authManager extensions.AuthManager,
+	driftRetention time.Duration) *HostSnap {
 	header := http.Header{}
 	header.Add(common.BKHTTPOwnerID, common.BKDefaultOwnerID)
 	header.Add(common.BKHTTPHeaderUser, common.CCSystemCollectorUserName)
@@ -69,10 +73,15 @@ func NewHostSnap(ctx context.Context, redisCli *redis.Client, db dal.RDB, engine
 			cache: map[bool]*HostCache{},
 			flag:  false,
 		},
-		authManager: authManager,
-		Engine:      engine,
+		authManager:    authManager,
+		Engine:         engine,
+		driftRetention: driftRetention,
 	}
 	go h.fetchDBLoop()
+	go h.reloadMappingLoop()
+	if driftRetention > 0 {
+		go h.cleanDriftLoop()
+	}
 	return h
 }
 
@@ -113,6 +122,8 @@ func (h *HostSnap) Analyze(mesg string) error {
 		blog.Warnf("[data-collection][hostsnap] outerip is not string, %s", val.String())
 	}
 	setter := parseSetter(&val, innerIp, outIp)
+	ownerID, _ := host.get(common.BKOwnerIDField).(string)
+	h.applyMappingRules(&val, ownerID, setter)
 	// no need to update
 	if !needToUpdate(setter, host) {
 		return nil
@@ -136,6 +147,7 @@ func (h *HostSnap) Analyze(mesg string) error {
 
 	preData := host.clone()
 	copyVal(setter, host)
+	h.recordDrift(hostIdInt64, ownerID, setter, preData)
 
 	// add auditLog
 	curData, err := h.CoreAPI.CoreService().Host().GetHostByID(h.ctx, h.httpHeader, hostIdStr)
@@ -324,7 +336,7 @@ func (h *HostSnap) getHostByVal(val *gjson.Result) *HostInst {
 	if len(ips) > 0 {
 		blog.Infof("[data-collection][hostsnap] handle clouid: %s ips: %v", cloudid, ips)
 		for _, ip := range ips {
-			if host := h.getCache().get(cloudid + "::" + ip); host != nil {
+			if host := h.getCache().get(hostCacheKey(ownerID, cloudid, ip)); host != nil {
 				return host
 			}
 		}
@@ -348,10 +360,9 @@ func (h *HostSnap) getHostByVal(val *gjson.Result) *HostInst {
 			blog.Errorf("[data-collection][hostsnap] fetch db error %v", err)
 		}
 		for index := range result {
-			cloudID := fmt.Sprint(result[index][common.BKCloudIDField])
-			innerIP := fmt.Sprint(result[index][common.BKHostInnerIPField])
 			inst := &HostInst{data: result[index]}
-			h.setCache(cloudID+"::"+innerIP, inst)
+			h.setCache(hostCacheKey(fmt.Sprint(result[index][common.BKOwnerIDField]),
+				fmt.Sprint(result[index][common.BKCloudIDField]), fmt.Sprint(result[index][common.BKHostInnerIPField])), inst)
 			return inst
 		}
 		blog.Infof("[data-collection][hostsnap] ips not in cache and db, clouid: %v, ip: %v", cloudid, ips)
@@ -361,6 +372,12 @@ func (h *HostSnap) getHostByVal(val *gjson.Result) *HostInst {
 	return nil
 }
 
+// hostCacheKey returns the key of the host in the cache, the hosts of different supplier accounts
+// may have the same cloud id and ip.
+func hostCacheKey(ownerID, cloudID, innerIP string) string {
+	return ownerID + "::" + cloudID + "::" + innerIP
+}
+
 func getIPS(val *gjson.Result) (ips []string) {
 	if !strings.HasPrefix(val.Get("ip").String(), "127.0.0.") {
 		ips = append(ips, val.Get("ip").String())
@@ -416,9 +433,10 @@ func (h *HostSnap) fetch() *HostCache {
 			blog.Errorf("[data-collection][hostsnap] fetch db error %v", err)
 		}
 		for index := range result {
+			ownerID := fmt.Sprint(result[index][common.BKOwnerIDField])
 			cloudid := fmt.Sprint(result[index][common.BKCloudIDField])
 			innerip := fmt.Sprint(result[index][common.BKHostInnerIPField])
-			hostcache.data[cloudid+"::"+innerip] = &HostInst{data: result[index]}
+			hostcache.data[hostCacheKey(ownerID, cloudid, innerip)] = &HostInst{data: result[index]}
 		}
 		if uint64(len(result)) < limit {
 			break
@@ -0,0 +1,80 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/storage/dal/mongo/local"
+
+	"github.com/tidwall/gjson"
+)
+
+func newTestHostSnap(t *testing.T, hosts ...map[string]interface{}) *HostSnap {
+	db := local.NewMemory()
+	if err := db.Table(common.BKTableNameBaseHost).Insert(context.Background(), hosts); err != nil {
+		t.Fatalf("insert hosts failed, err: %v", err)
+	}
+	return &HostSnap{
+		ctx:   context.Background(),
+		db:    db,
+		cache: &Cache{cache: map[bool]*HostCache{false: {data: map[string]*HostInst{}}}},
+	}
+}
+
+func testHost(hostID int64, ownerID string) map[string]interface{} {
+	return map[string]interface{}{
+		common.BKHostIDField:      hostID,
+		common.BKCloudIDField:     int64(0),
+		common.BKHostInnerIPField: "192.168.1.7",
+		common.BKOwnerIDField:     ownerID,
+	}
+}
+
+func TestGetHostByValOwner(t *testing.T) {
+	// the reported snapshot claims to be of tenant-b, the token is bound to tenant-a
+	body := strings.Replace(MockMessageData, `"bizid": 0`, `"bizid": "tenant-b"`, 1)
+	snapshot, err := ConvertSnapshot(SnapFormatNative, []byte(body), SnapSource{OwnerID: "tenant-a"})
+	if err != nil {
+		t.Fatalf("convert native snapshot failed, err: %v", err)
+	}
+	val := gjson.Parse(snapshot)
+	if owner := val.Get("bizid").String(); owner != "tenant-a" {
+		t.Fatalf("expect bizid to be overwritten by the source owner, got %s", owner)
+	}
+
+	// only the other owner has the host, it's found neither in the db nor in the cache
+	h := newTestHostSnap(t, testHost(2, "tenant-b"))
+	if host := h.getHostByVal(&val); host != nil {
+		t.Errorf("expect the host of tenant-b not to be matched, got %v", host.get(common.BKHostIDField))
+	}
+	h.cache.cache[h.cache.flag] = h.fetch()
+	if host := h.getHostByVal(&val); host != nil {
+		t.Errorf("expect the cached host of tenant-b not to be matched, got %v", host.get(common.BKHostIDField))
+	}
+
+	// both owners have the host, the one of the source owner is matched
+	h = newTestHostSnap(t, testHost(2, "tenant-b"), testHost(1, "tenant-a"))
+	host := h.getHostByVal(&val)
+	if host == nil || host.get(common.BKHostIDField) != int64(1) {
+		t.Fatalf("expect host 1 of tenant-a to be matched from db, got %v", host)
+	}
+	h.cache.cache[h.cache.flag] = h.fetch()
+	host = h.getHostByVal(&val)
+	if host == nil || host.get(common.BKHostIDField) != int64(1) {
+		t.Fatalf("expect host 1 of tenant-a to be matched from cache, got %v", host)
+	}
+}
@@ -0,0 +1,247 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	reloadMappingInterval = time.Second * 30
+)
+
+// mappingRule is an enabled mapping rule with its host property and the compiled patterns
+type mappingRule struct {
+	metadata.HostSnapMappingRule
+	attribute        metadata.Attribute
+	transformPattern *regexp.Regexp
+	conditionPattern *regexp.Regexp
+}
+
+// mappingRules are the mapping rules of each supplier account, which are reloaded periodically
+// so that the changes of the rules take effect without a restart.
+type mappingRules struct {
+	sync.RWMutex
+	rules map[string][]*mappingRule
+}
+
+func (h *HostSnap) reloadMappingLoop() {
+	h.reloadMapping()
+	for range time.Tick(reloadMappingInterval) {
+		h.reloadMapping()
+	}
+}
+
+func (h *HostSnap) reloadMapping() {
+	rules := make([]metadata.HostSnapMappingRule, 0)
+	cond := map[string]interface{}{"enable": true}
+	if err := h.db.Table(common.BKTableNameHostSnapMappingRule).Find(cond).Sort(common.BKFieldID).All(h.ctx, &rules); err != nil {
+		blog.Errorf("[data-collection][hostsnap] reload mapping rules failed, keep the old rules, err: %v", err)
+		return
+	}
+
+	propertyIDs := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		propertyIDs = append(propertyIDs, rule.PropertyID)
+	}
+	attrs := make([]metadata.Attribute, 0)
+	attrCond := map[string]interface{}{
+		common.BKObjIDField:      common.BKInnerObjIDHost,
+		common.BKPropertyIDField: map[string]interface{}{common.BKDBIN: propertyIDs},
+	}
+	if len(rules) > 0 {
+		if err := h.db.Table(common.BKTableNameObjAttDes).Find(attrCond).All(h.ctx, &attrs); err != nil {
+			blog.Errorf("[data-collection][hostsnap] reload mapping rules, get host properties failed, keep the old rules, err: %v", err)
+			return
+		}
+	}
+	properties := make(map[string]*metadata.Attribute, len(attrs))
+	for index := range attrs {
+		properties[attrs[index].OwnerID+"::"+attrs[index].PropertyID] = &attrs[index]
+	}
+
+	compiled := make(map[string][]*mappingRule)
+	for index := range rules {
+		rule, err := compileMappingRule(rules[index], properties[rules[index].OwnerID+"::"+rules[index].PropertyID])
+		if err != nil {
+			blog.Warnf("[data-collection][hostsnap] skip invalid mapping rule %d, err: %v", rules[index].ID, err)
+			continue
+		}
+		compiled[rule.OwnerID] = append(compiled[rule.OwnerID], rule)
+	}
+
+	h.mapping.Lock()
+	h.mapping.rules = compiled
+	h.mapping.Unlock()
+	blog.V(4).Infof("[data-collection][hostsnap] reload %d mapping rules", len(rules))
+}
+
+func compileMappingRule(rule metadata.HostSnapMappingRule, attribute *metadata.Attribute) (*mappingRule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	if attribute == nil {
+		return nil, fmt.Errorf("host property %s not exist", rule.PropertyID)
+	}
+
+	compiled := &mappingRule{HostSnapMappingRule: rule, attribute: *attribute}
+	if rule.Transform.Type == metadata.SnapTransformRegex {
+		compiled.transformPattern = regexp.MustCompile(rule.Transform.Pattern)
+	}
+	if rule.Condition != nil && rule.Condition.Operator == metadata.SnapConditionRegex {
+		compiled.conditionPattern = regexp.MustCompile(rule.Condition.Value)
+	}
+	return compiled, nil
+}
+
+// applyMappingRules sets the host properties of the mapping rules of the supplier account to the setter,
+// the rules are applied in the order of id, so a later rule of the same property wins. the value which
+// is not valid for the host property is dropped, so that it doesn't fail the update of the others.
+func (h *HostSnap) applyMappingRules(val *gjson.Result, ownerID string, setter map[string]interface{}) {
+	h.mapping.RLock()
+	rules := h.mapping.rules[ownerID]
+	h.mapping.RUnlock()
+
+	for _, rule := range rules {
+		if !rule.match(val) {
+			continue
+		}
+		result := val.Get(rule.Path)
+		if !result.Exists() {
+			continue
+		}
+		value, err := rule.convert(result)
+		if err != nil {
+			blog.V(4).Infof("[data-collection][hostsnap] mapping rule %d not applied, err: %v", rule.ID, err)
+			continue
+		}
+		if rawErr := rule.attribute.Validate(h.ctx, value, rule.PropertyID); rawErr.ErrCode != 0 {
+			blog.V(4).Infof("[data-collection][hostsnap] mapping rule %d not applied, value %#v of %s is invalid, code: %d",
+				rule.ID, value, rule.PropertyID, rawErr.ErrCode)
+			continue
+		}
+		setter[rule.PropertyID] = value
+	}
+}
+
+func (r *mappingRule) match(val *gjson.Result) bool {
+	if r.Condition == nil {
+		return true
+	}
+
+	result := val.Get(r.Condition.Path)
+	switch r.Condition.Operator {
+	case metadata.SnapConditionExists:
+		return result.Exists()
+	case metadata.SnapConditionEqual:
+		return result.Exists() && result.String() == r.Condition.Value
+	case metadata.SnapConditionNotEqual:
+		return result.String() != r.Condition.Value
+	case metadata.SnapConditionRegex:
+		return result.Exists() && r.conditionPattern.MatchString(result.String())
+	default:
+		return false
+	}
+}
+
+// convert transforms the value of the path, and converts it to the type of the host property
+func (r *mappingRule) convert(result gjson.Result) (interface{}, error) {
+	var value interface{}
+	switch r.Transform.Type {
+	case metadata.SnapTransformUnit:
+		var sum float64
+		for _, item := range resultItems(result) {
+			num, err := strconv.ParseFloat(strings.TrimSpace(item.String()), 64)
+			if err != nil {
+				return nil, fmt.Errorf("value %s is not numeric", item.String())
+			}
+			sum += num
+		}
+		value = sum / r.Transform.Divisor
+	case metadata.SnapTransformJoin:
+		value = joinResult(result, r.Transform.Separator)
+	case metadata.SnapTransformRegex:
+		matches := r.transformPattern.FindStringSubmatch(joinResult(result, ","))
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("value %s does not match the pattern", result.String())
+		}
+		value = matches[0]
+		if len(matches) > 1 {
+			value = matches[1]
+		}
+	default:
+		if result.Type == gjson.Number {
+			value = result.Float()
+		} else {
+			value = joinResult(result, ",")
+		}
+	}
+
+	switch r.attribute.PropertyType {
+	case common.FieldTypeInt:
+		num, err := toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		return int64(num), nil
+	case common.FieldTypeFloat:
+		return toFloat(value)
+	case common.FieldTypeBool:
+		return strconv.ParseBool(fmt.Sprint(value))
+	default:
+		if num, ok := value.(float64); ok {
+			return strconv.FormatFloat(num, 'f', -1, 64), nil
+		}
+		return value, nil
+	}
+}
+
+func resultItems(result gjson.Result) []gjson.Result {
+	if result.IsArray() {
+		return result.Array()
+	}
+	return []gjson.Result{result}
+}
+
+func joinResult(result gjson.Result, separator string) string {
+	items := resultItems(result)
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		values = append(values, item.String())
+	}
+	return strings.Join(values, separator)
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	default:
+		num, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(v)), 64)
+		if err != nil {
+			return 0, fmt.Errorf("value %v is not numeric", v)
+		}
+		return num, nil
+	}
+}
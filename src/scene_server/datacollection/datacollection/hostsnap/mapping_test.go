@@ -0,0 +1,163 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyMappingRules(t *testing.T) {
+	rules := []struct {
+		rule         metadata.HostSnapMappingRule
+		propertyType string
+	}{
+		{
+			rule: metadata.HostSnapMappingRule{ID: 1, Name: "kernel", Path: "data.system.info.kernelVersion",
+				PropertyID: "kernel_version", Transform: metadata.HostSnapTransform{Type: metadata.SnapTransformRegex, Pattern: `^([\d.]+-\d+)`}},
+			propertyType: common.FieldTypeSingleChar,
+		},
+		{
+			rule: metadata.HostSnapMappingRule{ID: 2, Name: "disk", Path: "data.disk.usage.#.total",
+				PropertyID: "disk_gb", Transform: metadata.HostSnapTransform{Type: metadata.SnapTransformUnit, Divisor: 1 << 30}},
+			propertyType: common.FieldTypeInt,
+		},
+		{
+			rule: metadata.HostSnapMappingRule{ID: 3, Name: "mounts", Path: "data.disk.partition.#.mountpoint",
+				PropertyID: "mounts", Transform: metadata.HostSnapTransform{Type: metadata.SnapTransformJoin, Separator: ";"}},
+			propertyType: common.FieldTypeLongChar,
+		},
+		{
+			rule: metadata.HostSnapMappingRule{ID: 4, Name: "windows only", Path: "data.system.info.platform",
+				PropertyID: "win_platform", Condition: &metadata.HostSnapCondition{Path: "data.system.info.os",
+					Operator: metadata.SnapConditionEqual, Value: "windows"}},
+			propertyType: common.FieldTypeSingleChar,
+		},
+		{
+			rule: metadata.HostSnapMappingRule{ID: 5, Name: "linux procs", Path: "data.system.info.procs",
+				PropertyID: "procs", Condition: &metadata.HostSnapCondition{Path: "data.system.info.os",
+					Operator: metadata.SnapConditionRegex, Value: "^lin"}},
+			propertyType: common.FieldTypeFloat,
+		},
+	}
+
+	h := &HostSnap{ctx: context.Background(), mapping: mappingRules{rules: map[string][]*mappingRule{}}}
+	for _, item := range rules {
+		attr := &metadata.Attribute{PropertyID: item.rule.PropertyID, PropertyType: item.propertyType}
+		compiled, err := compileMappingRule(item.rule, attr)
+		if err != nil {
+			t.Fatalf("compile rule %d failed, err: %v", item.rule.ID, err)
+		}
+		h.mapping.rules[common.BKDefaultOwnerID] = append(h.mapping.rules[common.BKDefaultOwnerID], compiled)
+	}
+
+	val := gjson.Parse(MockMessageData)
+	setter := map[string]interface{}{}
+	h.applyMappingRules(&val, common.BKDefaultOwnerID, setter)
+
+	expected := map[string]interface{}{
+		"kernel_version": "2.6.32-504",
+		"disk_gb":        int64(49),
+		"mounts":         "/",
+		"procs":          float64(142),
+	}
+	if len(setter) != len(expected) {
+		t.Fatalf("expect %v, got %v", expected, setter)
+	}
+	for key, value := range expected {
+		if setter[key] != value {
+			t.Errorf("expect %s to be %#v, got %#v", key, value, setter[key])
+		}
+	}
+
+	setter = map[string]interface{}{}
+	h.applyMappingRules(&val, "other", setter)
+	if len(setter) != 0 {
+		t.Errorf("expect no rules of other supplier account applied, got %v", setter)
+	}
+}
+
+func TestApplyMappingRulesInvalidValue(t *testing.T) {
+	rules := []struct {
+		rule metadata.HostSnapMappingRule
+		attr metadata.Attribute
+	}{
+		{
+			rule: metadata.HostSnapMappingRule{ID: 1, Name: "os type", Path: "data.system.info.os", PropertyID: "os_type"},
+			attr: metadata.Attribute{PropertyType: common.FieldTypeEnum, Option: []metadata.EnumVal{
+				{ID: "1", Name: "Linux", Type: "text"}, {ID: "2", Name: "Windows", Type: "text"}}},
+		},
+		{
+			rule: metadata.HostSnapMappingRule{ID: 2, Name: "disk", Path: "data.disk.usage.#.total",
+				PropertyID: "disk_gb", Transform: metadata.HostSnapTransform{Type: metadata.SnapTransformUnit, Divisor: 1 << 30}},
+			attr: metadata.Attribute{PropertyType: common.FieldTypeInt, Option: map[string]interface{}{"min": "0", "max": "10"}},
+		},
+		{
+			rule: metadata.HostSnapMappingRule{ID: 3, Name: "kernel", Path: "data.system.info.kernelVersion", PropertyID: "kernel_version"},
+			attr: metadata.Attribute{PropertyType: common.FieldTypeSingleChar, Option: `^\d+$`},
+		},
+		{
+			rule: metadata.HostSnapMappingRule{ID: 4, Name: "platform", Path: "data.system.info.platform", PropertyID: "platform"},
+			attr: metadata.Attribute{PropertyType: common.FieldTypeSingleChar, Option: `^[a-z]+$`},
+		},
+	}
+
+	h := &HostSnap{ctx: context.Background(), mapping: mappingRules{rules: map[string][]*mappingRule{}}}
+	for index := range rules {
+		rules[index].attr.PropertyID = rules[index].rule.PropertyID
+		compiled, err := compileMappingRule(rules[index].rule, &rules[index].attr)
+		if err != nil {
+			t.Fatalf("compile rule %d failed, err: %v", rules[index].rule.ID, err)
+		}
+		h.mapping.rules[common.BKDefaultOwnerID] = append(h.mapping.rules[common.BKDefaultOwnerID], compiled)
+	}
+
+	// the enum id not in the options, the int out of range and the char not matching the regex are dropped,
+	// the other properties are still set.
+	val := gjson.Parse(MockMessageData)
+	setter := map[string]interface{}{common.BKHostNameField: "host"}
+	h.applyMappingRules(&val, common.BKDefaultOwnerID, setter)
+	expected := map[string]interface{}{common.BKHostNameField: "host", "platform": "centos"}
+	if len(setter) != len(expected) {
+		t.Fatalf("expect %v, got %v", expected, setter)
+	}
+	for key, value := range expected {
+		if setter[key] != value {
+			t.Errorf("expect %s to be %#v, got %#v", key, value, setter[key])
+		}
+	}
+}
+
+func TestCompileMappingRule(t *testing.T) {
+	invalid := []metadata.HostSnapMappingRule{
+		{Name: "no path", PropertyID: "a"},
+		{Name: "bad divisor", Path: "a", PropertyID: "a", Transform: metadata.HostSnapTransform{Type: metadata.SnapTransformUnit}},
+		{Name: "bad pattern", Path: "a", PropertyID: "a", Transform: metadata.HostSnapTransform{Type: metadata.SnapTransformRegex, Pattern: "("}},
+		{Name: "bad operator", Path: "a", PropertyID: "a", Condition: &metadata.HostSnapCondition{Path: "b", Operator: "$gt"}},
+	}
+	for _, rule := range invalid {
+		if _, err := compileMappingRule(rule, &metadata.Attribute{PropertyType: common.FieldTypeSingleChar}); err == nil {
+			t.Errorf("expect rule %s to be invalid", rule.Name)
+		}
+	}
+
+	rule := metadata.HostSnapMappingRule{Name: "no property", Path: "a", PropertyID: "a"}
+	if _, err := compileMappingRule(rule, nil); err == nil {
+		t.Errorf("expect rule of not exist property to be invalid")
+	}
+}
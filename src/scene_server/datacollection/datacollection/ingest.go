@@ -0,0 +1,167 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datacollection
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/datacollection/datacollection/hostsnap"
+
+	"github.com/tidwall/gjson"
+)
+
+// ErrSnapTooFrequent the host reports snapshots more often than the min interval
+var ErrSnapTooFrequent = errors.New("host snapshot is reported too frequently")
+
+// SnapInvalidError the report can not be converted to a snapshot
+type SnapInvalidError struct {
+	Err error
+}
+
+func (e *SnapInvalidError) Error() string {
+	return e.Err.Error()
+}
+
+// IngestConfig is the config of the host snapshot ingestion over http
+type IngestConfig struct {
+	Enable bool
+	// Tokens are the bearer tokens accepted by the ingestion, a token of the form "<supplier account>:<token>"
+	// can only report the hosts of the supplier account, while the other tokens can report the hosts of any
+	// supplier account by the header.
+	Tokens []string
+	// MinInterval is the min interval between two snapshots of a host
+	MinInterval time.Duration
+}
+
+// SnapIngester analyzes the host snapshots received over http with the same analyzer
+// as the snapshots from the redis channels.
+type SnapIngester struct {
+	analyzer    Analyzer
+	tokens      []ingestToken
+	minInterval time.Duration
+
+	lock     sync.Mutex
+	lastTime map[string]time.Time
+}
+
+// ingestToken is a bearer token, which is bound to the supplier account if ownerID is set
+type ingestToken struct {
+	token   []byte
+	ownerID string
+}
+
+func NewSnapIngester(analyzer Analyzer, conf IngestConfig) *SnapIngester {
+	tokens := make([]ingestToken, 0, len(conf.Tokens))
+	for _, token := range conf.Tokens {
+		item := ingestToken{token: []byte(token)}
+		if index := strings.Index(token, ":"); index >= 0 {
+			item = ingestToken{ownerID: token[:index], token: []byte(token[index+1:])}
+		}
+		if len(item.token) == 0 {
+			continue
+		}
+		tokens = append(tokens, item)
+	}
+	s := &SnapIngester{
+		analyzer:    analyzer,
+		tokens:      tokens,
+		minInterval: conf.MinInterval,
+		lastTime:    make(map[string]time.Time),
+	}
+	if s.minInterval > 0 {
+		go s.cleanLoop()
+	}
+	return s
+}
+
+// Authorize checks the bearer token of the request, and returns the supplier account the hosts reported belong to.
+// a token bound to a supplier account can not report the hosts of the others, an unbound token reports the hosts
+// of the supplier account of the request, which can be any one.
+func (s *SnapIngester) Authorize(token, ownerID string) (string, bool) {
+	if len(token) == 0 {
+		return "", false
+	}
+	matched := -1
+	for index, t := range s.tokens {
+		if subtle.ConstantTimeCompare(t.token, []byte(token)) == 1 {
+			matched = index
+		}
+	}
+	if matched < 0 {
+		return "", false
+	}
+
+	bound := s.tokens[matched].ownerID
+	if bound == "" {
+		return ownerID, true
+	}
+	if ownerID != "" && ownerID != bound {
+		return "", false
+	}
+	return bound, true
+}
+
+// Ingest converts the report of the format to the snapshot and analyzes it
+func (s *SnapIngester) Ingest(format string, body []byte, source hostsnap.SnapSource) error {
+	snapshot, err := hostsnap.ConvertSnapshot(format, body, source)
+	if err != nil {
+		return &SnapInvalidError{Err: err}
+	}
+
+	val := gjson.Parse(snapshot)
+	key := fmt.Sprintf("%s::%s::%s", source.OwnerID, val.Get("cloudid").String(), val.Get("ip").String())
+	if !s.allow(key, time.Now()) {
+		return ErrSnapTooFrequent
+	}
+
+	return s.analyzer.Analyze(snapshot)
+}
+
+// allow limits the snapshots of each host to one in the min interval
+func (s *SnapIngester) allow(key string, now time.Time) bool {
+	if s.minInterval <= 0 {
+		return true
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if last, ok := s.lastTime[key]; ok && now.Sub(last) < s.minInterval {
+		return false
+	}
+	s.lastTime[key] = now
+	return true
+}
+
+func (s *SnapIngester) cleanLoop() {
+	for now := range time.Tick(s.minInterval) {
+		size := s.clean(now)
+		blog.V(5).Infof("[data-collection][ingest] %d hosts reported in the last %v", size, s.minInterval)
+	}
+}
+
+// clean removes the hosts which can report again, returns the count of the hosts left
+func (s *SnapIngester) clean(now time.Time) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for key, last := range s.lastTime {
+		if now.Sub(last) >= s.minInterval {
+			delete(s.lastTime, key)
+		}
+	}
+	return len(s.lastTime)
+}
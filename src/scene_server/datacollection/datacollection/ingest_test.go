@@ -0,0 +1,108 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datacollection
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnapIngesterAuthorize(t *testing.T) {
+	ingester := NewSnapIngester(nil, IngestConfig{Tokens: []string{"global", "0:bound0", "1:bound1", "", "2:"}})
+	cases := []struct {
+		name       string
+		token      string
+		ownerID    string
+		authorized bool
+		expected   string
+	}{
+		{name: "empty token", token: "", authorized: false},
+		{name: "empty token of the empty bound token", token: "", ownerID: "2", authorized: false},
+		{name: "wrong token", token: "wrong", authorized: false},
+		{name: "prefix of a token", token: "glob", authorized: false},
+		{name: "token with the supplier account", token: "0:bound0", authorized: false},
+		{name: "global token", token: "global", authorized: true, expected: ""},
+		{name: "global token of any supplier account", token: "global", ownerID: "1", authorized: true, expected: "1"},
+		{name: "bound token", token: "bound0", authorized: true, expected: "0"},
+		{name: "bound token of its supplier account", token: "bound0", ownerID: "0", authorized: true, expected: "0"},
+		{name: "bound token of other supplier account", token: "bound0", ownerID: "1", authorized: false},
+		{name: "another bound token", token: "bound1", authorized: true, expected: "1"},
+	}
+	for _, c := range cases {
+		ownerID, authorized := ingester.Authorize(c.token, c.ownerID)
+		if authorized != c.authorized || ownerID != c.expected {
+			t.Errorf("%s: expect %v of supplier account %q, got %v of %q", c.name, c.authorized, c.expected, authorized, ownerID)
+		}
+	}
+
+	empty := NewSnapIngester(nil, IngestConfig{})
+	for _, token := range []string{"", "global"} {
+		if _, authorized := empty.Authorize(token, ""); authorized {
+			t.Errorf("expect token %q not authorized without tokens", token)
+		}
+	}
+}
+
+func TestSnapIngesterAllow(t *testing.T) {
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	ingester := &SnapIngester{minInterval: time.Minute, lastTime: make(map[string]time.Time)}
+	cases := []struct {
+		name    string
+		key     string
+		elapsed time.Duration
+		allowed bool
+	}{
+		{name: "first report", key: "0::0::10.0.0.1", elapsed: 0, allowed: true},
+		{name: "within the interval", key: "0::0::10.0.0.1", elapsed: 59 * time.Second, allowed: false},
+		{name: "another host", key: "0::0::10.0.0.2", elapsed: 59 * time.Second, allowed: true},
+		{name: "same ip of another supplier account", key: "1::0::10.0.0.1", elapsed: 59 * time.Second, allowed: true},
+		{name: "at the interval", key: "0::0::10.0.0.1", elapsed: time.Minute, allowed: true},
+		{name: "within the interval after the last allowed", key: "0::0::10.0.0.1", elapsed: 119 * time.Second, allowed: false},
+	}
+	for _, c := range cases {
+		if allowed := ingester.allow(c.key, start.Add(c.elapsed)); allowed != c.allowed {
+			t.Errorf("%s: expect allowed %v, got %v", c.name, c.allowed, allowed)
+		}
+	}
+
+	unlimited := &SnapIngester{lastTime: make(map[string]time.Time)}
+	for i := 0; i < 3; i++ {
+		if !unlimited.allow("0::0::10.0.0.1", start) {
+			t.Errorf("expect always allowed without the min interval")
+		}
+	}
+}
+
+func TestSnapIngesterClean(t *testing.T) {
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	ingester := &SnapIngester{minInterval: time.Minute, lastTime: make(map[string]time.Time)}
+	ingester.allow("0::0::10.0.0.1", start)
+	ingester.allow("0::0::10.0.0.2", start.Add(30*time.Second))
+
+	if size := ingester.clean(start.Add(59 * time.Second)); size != 2 {
+		t.Errorf("expect no host cleaned within the interval, got %d left", size)
+	}
+	// the host reported exactly the interval ago can report again, so it is cleaned
+	if size := ingester.clean(start.Add(time.Minute)); size != 1 {
+		t.Errorf("expect 1 host left, got %d", size)
+	}
+	if _, ok := ingester.lastTime["0::0::10.0.0.2"]; !ok {
+		t.Errorf("expect the host reported within the interval kept")
+	}
+	if !ingester.allow("0::0::10.0.0.1", start.Add(time.Minute)) {
+		t.Errorf("expect the cleaned host allowed")
+	}
+	if size := ingester.clean(start.Add(90 * time.Second)); size != 1 {
+		t.Errorf("expect 1 host left, got %d", size)
+	}
+}
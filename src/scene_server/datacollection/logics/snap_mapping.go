@@ -0,0 +1,189 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// the host fields which locate the host or are maintained by the system can not be set by the mapping rules
+var snapMappingForbiddenProperties = map[string]bool{
+	common.BKHostIDField:      true,
+	common.BKHostInnerIPField: true,
+	common.BKHostOuterIPField: true,
+	common.BKCloudIDField:     true,
+	common.BKOwnerIDField:     true,
+	common.CreateTimeField:    true,
+	common.LastTimeField:      true,
+}
+
+// CreateSnapMappingRule create a new host snapshot mapping rule
+func (lgc *Logics) CreateSnapMappingRule(pHeader http.Header, rule meta.HostSnapMappingRule) (uint64, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+
+	if err := lgc.checkSnapMappingRule(pHeader, &rule); err != nil {
+		return INVALIDID, err
+	}
+
+	id, err := lgc.db.NextSequence(lgc.ctx, common.BKTableNameHostSnapMappingRule)
+	if err != nil {
+		blog.Errorf("[SnapMapping] create mapping rule, get next sequence failed, err: %v, rid: %s", err, rid)
+		return INVALIDID, defErr.Error(common.CCErrCommDBInsertFailed)
+	}
+
+	now := time.Now()
+	rule.ID = id
+	rule.OwnerID = util.GetOwnerID(pHeader)
+	rule.CreateTime = &now
+	rule.LastTime = &now
+	if err := lgc.db.Table(common.BKTableNameHostSnapMappingRule).Insert(lgc.ctx, rule); err != nil {
+		blog.Errorf("[SnapMapping] create mapping rule %#v failed, err: %v, rid: %s", rule, err, rid)
+		return INVALIDID, defErr.Error(common.CCErrCommDBInsertFailed)
+	}
+
+	return id, nil
+}
+
+// UpdateSnapMappingRule update the host snapshot mapping rule by id
+func (lgc *Logics) UpdateSnapMappingRule(pHeader http.Header, id uint64, rule meta.HostSnapMappingRule) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+
+	cond := map[string]interface{}{
+		common.BKOwnerIDField: util.GetOwnerID(pHeader),
+		common.BKFieldID:      id,
+	}
+	cnt, err := lgc.db.Table(common.BKTableNameHostSnapMappingRule).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[SnapMapping] update mapping rule, count rule %d failed, err: %v, rid: %s", id, err, rid)
+		return defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if cnt == 0 {
+		blog.Errorf("[SnapMapping] update mapping rule, rule %d not exist, rid: %s", id, rid)
+		return defErr.Error(common.CCErrCollectSnapMappingRuleNotExist)
+	}
+
+	if err := lgc.checkSnapMappingRule(pHeader, &rule); err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"name":                   rule.Name,
+		"path":                   rule.Path,
+		common.BKPropertyIDField: rule.PropertyID,
+		"transform":              rule.Transform,
+		"condition":              rule.Condition,
+		"enable":                 rule.Enable,
+		common.LastTimeField:     time.Now(),
+	}
+	if err := lgc.db.Table(common.BKTableNameHostSnapMappingRule).Update(lgc.ctx, cond, data); err != nil {
+		blog.Errorf("[SnapMapping] update mapping rule %d with %#v failed, err: %v, rid: %s", id, data, err, rid)
+		return defErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+
+	return nil
+}
+
+// SearchSnapMappingRule search the host snapshot mapping rules by the condition
+func (lgc *Logics) SearchSnapMappingRule(pHeader http.Header, opt meta.SearchHostSnapMappingRuleOption) (*meta.SearchHostSnapMappingRule, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+
+	cond := opt.Condition
+	if cond == nil {
+		cond = make(map[string]interface{})
+	}
+	cond[common.BKOwnerIDField] = util.GetOwnerID(pHeader)
+
+	result := &meta.SearchHostSnapMappingRule{Info: make([]meta.HostSnapMappingRule, 0)}
+	count, err := lgc.db.Table(common.BKTableNameHostSnapMappingRule).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[SnapMapping] count mapping rules by condition %#v failed, err: %v, rid: %s", cond, err, rid)
+		return nil, defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	result.Count = count
+	if count == 0 {
+		return result, nil
+	}
+
+	sort := opt.Page.Sort
+	if sort == "" {
+		sort = common.BKFieldID
+	}
+	find := lgc.db.Table(common.BKTableNameHostSnapMappingRule).Find(cond).Sort(sort).Start(uint64(opt.Page.Start))
+	if opt.Page.Limit > 0 {
+		find = find.Limit(uint64(opt.Page.Limit))
+	}
+	if err := find.All(lgc.ctx, &result.Info); err != nil {
+		blog.Errorf("[SnapMapping] search mapping rules by condition %#v failed, err: %v, rid: %s", cond, err, rid)
+		return nil, defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	return result, nil
+}
+
+// DeleteSnapMappingRule delete the host snapshot mapping rules by ids
+func (lgc *Logics) DeleteSnapMappingRule(pHeader http.Header, ids []uint64) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+
+	cond := map[string]interface{}{
+		common.BKOwnerIDField: util.GetOwnerID(pHeader),
+		common.BKFieldID:      map[string]interface{}{common.BKDBIN: ids},
+	}
+	if err := lgc.db.Table(common.BKTableNameHostSnapMappingRule).Delete(lgc.ctx, cond); err != nil {
+		blog.Errorf("[SnapMapping] delete mapping rules %v failed, err: %v, rid: %s", ids, err, rid)
+		return defErr.Error(common.CCErrCommDBDeleteFailed)
+	}
+
+	return nil
+}
+
+// checkSnapMappingRule checks the rule, and the target must be a property of the host model
+func (lgc *Logics) checkSnapMappingRule(pHeader http.Header, rule *meta.HostSnapMappingRule) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+
+	if err := rule.Validate(); err != nil {
+		blog.Errorf("[SnapMapping] mapping rule %#v is invalid, err: %v, rid: %s", rule, err, rid)
+		return defErr.Errorf(common.CCErrCollectSnapMappingRuleInvalid, err.Error())
+	}
+	if snapMappingForbiddenProperties[rule.PropertyID] {
+		blog.Errorf("[SnapMapping] mapping rule can not set host property %s, rid: %s", rule.PropertyID, rid)
+		return defErr.Errorf(common.CCErrCollectSnapMappingRuleInvalid, common.BKPropertyIDField)
+	}
+
+	attrCond := map[string]interface{}{
+		common.BKObjIDField:      common.BKInnerObjIDHost,
+		common.BKPropertyIDField: rule.PropertyID,
+		common.BKOwnerIDField:    util.GetOwnerID(pHeader),
+	}
+	cnt, err := lgc.db.Table(common.BKTableNameObjAttDes).Find(attrCond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[SnapMapping] count host property %s failed, err: %v, rid: %s", rule.PropertyID, err, rid)
+		return defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if cnt == 0 {
+		blog.Errorf("[SnapMapping] host property %s of mapping rule not exist, rid: %s", rule.PropertyID, rid)
+		return defErr.Errorf(common.CCErrCollectSnapMappingRuleInvalid, common.BKPropertyIDField)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/datacollection/datacollection"
+	"configcenter/src/scene_server/datacollection/datacollection/hostsnap"
+)
+
+// maxSnapBodySize is the max size of a reported snapshot
+const maxSnapBodySize = 4 << 20
+
+// IngestHostSnap receives the host snapshot of the native, ansible or node_exporter format,
+// and analyzes it the same as the snapshots from the redis channels.
+func (s *Service) IngestHostSnap(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+
+	token := strings.TrimSpace(strings.TrimPrefix(pHeader.Get("Authorization"), "Bearer "))
+	ownerID, authorized := s.ingester.Authorize(token, util.GetOwnerID(pHeader))
+	if !authorized {
+		blog.Errorf("[SnapIngest] unauthorized request from %s", req.Request.RemoteAddr)
+		_ = resp.WriteError(http.StatusUnauthorized, &meta.RespError{Msg: defErr.Error(common.CCErrCollectSnapIngestUnauthorized)})
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(resp.ResponseWriter, req.Request.Body, maxSnapBodySize))
+	if err != nil {
+		blog.Errorf("[SnapIngest] read body from %s failed, err: %v", req.Request.RemoteAddr, err)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCollectSnapIngestInvalid, err.Error())})
+		return
+	}
+
+	source := hostsnap.SnapSource{
+		OwnerID: ownerID,
+		IP:      req.QueryParameter("ip"),
+	}
+	if source.OwnerID == "" {
+		source.OwnerID = common.BKDefaultOwnerID
+	}
+	if source.IP == "" {
+		source.IP, _, _ = net.SplitHostPort(req.Request.RemoteAddr)
+	}
+	if cloudID := req.QueryParameter(common.BKCloudIDField); cloudID != "" {
+		source.CloudID, err = strconv.ParseInt(cloudID, 10, 64)
+		if err != nil {
+			_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, common.BKCloudIDField)})
+			return
+		}
+	}
+
+	format := req.QueryParameter("format")
+	err = s.ingester.Ingest(format, body, source)
+	switch e := err.(type) {
+	case nil:
+		_ = resp.WriteEntity(meta.NewSuccessResp(nil))
+	case *datacollection.SnapInvalidError:
+		blog.Errorf("[SnapIngest] invalid %s snapshot from %s, err: %v", format, req.Request.RemoteAddr, e)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCollectSnapIngestInvalid, e.Error())})
+	default:
+		if err == datacollection.ErrSnapTooFrequent {
+			_ = resp.WriteError(http.StatusTooManyRequests, &meta.RespError{Msg: defErr.Error(common.CCErrCollectSnapIngestTooFrequent)})
+			return
+		}
+		blog.Errorf("[SnapIngest] analyze %s snapshot from %s failed, err: %v", format, req.Request.RemoteAddr, err)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCollectSnapIngestFailed, err.Error())})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"configcenter/src/common/metric"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
+	"configcenter/src/scene_server/datacollection/datacollection"
 	"configcenter/src/scene_server/datacollection/logics"
 	"configcenter/src/storage/dal"
 
@@ -35,6 +36,7 @@ type Service struct {
 	disCli  *redis.Client
 	netCli  *redis.Client
 	*logics.Logics
+	ingester *datacollection.SnapIngester
 }
 
 func (s *Service) SetDB(db dal.RDB) {
@@ -57,6 +59,10 @@ func (s *Service) SetNetCli(db *redis.Client) {
 	s.netCli = db
 }
 
+func (s *Service) SetSnapIngester(ingester *datacollection.SnapIngester) {
+	s.ingester = ingester
+}
+
 func (s *Service) WebService() *restful.Container {
 
 	container := restful.NewContainer()
@@ -89,8 +95,21 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/netcollect/collector/action/update").To(s.UpdateCollector))
 	api.Route(api.POST("/netcollect/collector/action/discover").To(s.DiscoverNetDevice))
 
+	api.Route(api.POST("/hostsnap/mapping_rule/action/create").To(s.CreateSnapMappingRule))
+	api.Route(api.POST("/hostsnap/mapping_rule/{id}/action/update").To(s.UpdateSnapMappingRule))
+	api.Route(api.POST("/hostsnap/mapping_rule/action/search").To(s.SearchSnapMappingRule))
+	api.Route(api.DELETE("/hostsnap/mapping_rule/action/delete").To(s.DeleteSnapMappingRule))
+
 	container.Add(api)
 
+	// the host snapshot ingestion is authorized by its own tokens
+	if s.ingester != nil {
+		ingestAPI := new(restful.WebService)
+		ingestAPI.Path("/ingest/v3").Filter(s.Engine.Metric().RestfulMiddleWare).Produces(restful.MIME_JSON)
+		ingestAPI.Route(ingestAPI.POST("/hostsnap").To(s.IngestHostSnap))
+		container.Add(ingestAPI)
+	}
+
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
 	healthzAPI.Route(healthzAPI.GET("/healthz").To(s.Healthz))
 	container.Add(healthzAPI)
@@ -0,0 +1,122 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateSnapMappingRule create host snapshot mapping rule
+func (s *Service) CreateSnapMappingRule(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+
+	rule := meta.HostSnapMappingRule{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&rule); nil != err {
+		blog.Errorf("[SnapMapping] add mapping rule failed with decode body err: %v", err)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	id, err := s.Logics.CreateSnapMappingRule(pHeader, rule)
+	if nil != err {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(meta.RspID{ID: int64(id)}))
+}
+
+// UpdateSnapMappingRule update host snapshot mapping rule
+func (s *Service) UpdateSnapMappingRule(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+
+	id, err := strconv.ParseUint(req.PathParameter("id"), 10, 64)
+	if nil != err || id == 0 {
+		blog.Errorf("[SnapMapping] update mapping rule with invalid id %s", req.PathParameter("id"))
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, common.BKFieldID)})
+		return
+	}
+
+	rule := meta.HostSnapMappingRule{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&rule); nil != err {
+		blog.Errorf("[SnapMapping] update mapping rule failed with decode body err: %v", err)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := s.Logics.UpdateSnapMappingRule(pHeader, id, rule); nil != err {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// SearchSnapMappingRule search host snapshot mapping rules
+func (s *Service) SearchSnapMappingRule(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+
+	opt := meta.SearchHostSnapMappingRuleOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&opt); nil != err {
+		blog.Errorf("[SnapMapping] search mapping rule failed with decode body err: %v", err)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.Logics.SearchSnapMappingRule(pHeader, opt)
+	if nil != err {
+		_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.SearchHostSnapMappingRuleResult{
+		BaseResp: meta.SuccessBaseResp,
+		Data:     *result,
+	})
+}
+
+// DeleteSnapMappingRule delete host snapshot mapping rules
+func (s *Service) DeleteSnapMappingRule(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+
+	opt := meta.DeleteHostSnapMappingRuleOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&opt); nil != err {
+		blog.Errorf("[SnapMapping] delete mapping rule failed with decode body err: %v", err)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if len(opt.IDs) == 0 {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, "ids")})
+		return
+	}
+
+	if err := s.Logics.DeleteSnapMappingRule(pHeader, opt.IDs); nil != err {
+		_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
+}
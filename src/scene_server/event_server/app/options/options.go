@@ -16,9 +16,11 @@ import (
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/core/cc/config"
+	"configcenter/src/scene_server/event_server/sink"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
 	"configcenter/src/storage/rpc"
+	"configcenter/src/thirdpartyclient/elasticsearch"
 
 	"github.com/spf13/pflag"
 )
@@ -46,9 +48,19 @@ func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.Var(auth.EnableAuthFlag, "enable-auth", "The auth center enable status, true for enabled, false for disabled")
 }
 
+// DefaultWatchLogSize the default count of the latest events kept for watching
+const DefaultWatchLogSize int64 = 100000
+
 type Config struct {
 	MongoDB mongo.Config
 	Redis   redis.Config
 	RPC     rpc.ClientConfig
 	Auth    authcenter.AuthConfig
+	Sink    sink.Config
+	// Es is the elasticsearch the full text search index is written to if full text search is on
+	Es elasticsearch.EsConfig
+	// WatchLogSize is how many latest events are kept for watching
+	WatchLogSize int64
+	// CallbackTLSDir is the directory the certificate files of the subscriptions' callback tls options are in
+	CallbackTLSDir string
 }
@@ -17,11 +17,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
+	"configcenter/src/auth"
 	"configcenter/src/auth/authcenter"
-	"configcenter/src/common/auth"
+	enableauth "configcenter/src/common/auth"
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
@@ -29,13 +31,16 @@ import (
 	"configcenter/src/common/version"
 	"configcenter/src/scene_server/event_server/app/options"
 	"configcenter/src/scene_server/event_server/distribution"
+	"configcenter/src/scene_server/event_server/indexer"
 	svc "configcenter/src/scene_server/event_server/service"
+	"configcenter/src/scene_server/event_server/sink"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/mongo/remote"
 	"configcenter/src/storage/dal/redis"
 	"configcenter/src/storage/rpc"
+	"configcenter/src/thirdpartyclient/elasticsearch"
 )
 
 func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOption) error {
@@ -100,19 +105,38 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 			return fmt.Errorf("connect subcli redis server failed, err: %s", err.Error())
 		}
 
-		authCli, err := authcenter.NewAuthCenter(nil, process.Config.Auth, engine.Metric().Registry())
+		authCli, err := auth.NewAuthorize(nil, process.Config.Auth, engine.CoreAPI, engine.Metric().Registry())
 		if err != nil {
 			return fmt.Errorf("new authcenter failed: %v, config: %+v", err, process.Config.Auth)
 		}
 		process.Service.SetAuth(authCli)
-		blog.Infof("enable auth center: %v", auth.IsAuthed())
+		blog.Infof("enable auth center: %v", enableauth.IsAuthed())
 
 		go func() {
 			errCh <- distribution.SubscribeChannel(subCli)
 		}()
 
+		eventSink, err := sink.New(process.Config.Sink)
+		if err != nil {
+			return fmt.Errorf("create event sink failed, err: %v, config: %+v", err, process.Config.Sink)
+		}
+		if eventSink != nil {
+			blog.Infof("event sink %s enabled", eventSink.Name())
+		}
+
+		var esIndexer *indexer.Indexer
+		if process.Config.Es.FullTextSearch == "on" {
+			esClient, err := elasticsearch.NewEsClient(process.Config.Es)
+			if err != nil {
+				return fmt.Errorf("create es client failed, err: %v", err)
+			}
+			esIndexer = indexer.New(esClient, db)
+			blog.Infof("full text search indexer enabled")
+		}
+
+		distribution.SetCallbackTLSDir(process.Config.CallbackTLSDir)
 		go func() {
-			errCh <- distribution.Start(ctx, cache, db, rpcCli)
+			errCh <- distribution.Start(ctx, cache, db, rpcCli, eventSink, esIndexer, process.Config.WatchLogSize)
 		}()
 
 		break
@@ -158,6 +182,20 @@ func (h *EventServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 
 		h.Config.RPC.Address = current.ConfigMap["rpc.address"]
 
+		h.Config.Sink = sink.ParseConfigFromKV("sink", current.ConfigMap)
+
+		h.Config.Es, err = elasticsearch.ParseConfigFromKV("es", current.ConfigMap)
+		if err != nil {
+			blog.Errorf("parse es config failed: %v", err)
+		}
+
+		h.Config.WatchLogSize = options.DefaultWatchLogSize
+		if size, err := strconv.ParseInt(current.ConfigMap["watch.logSize"], 10, 64); err == nil && size > 0 {
+			h.Config.WatchLogSize = size
+		}
+
+		h.Config.CallbackTLSDir = current.ConfigMap["callback-tls.dir"]
+
 		h.Config.Auth, err = authcenter.ParseConfigFromKV("auth", current.ConfigMap)
 		if err != nil {
 			blog.Errorf("parse auth center config failed: %v", err)
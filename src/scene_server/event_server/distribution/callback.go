@@ -14,11 +14,15 @@ package distribution
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/redis.v5"
@@ -26,25 +30,30 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/http/httpclient"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/ssl"
 	"configcenter/src/scene_server/event_server/types"
 )
 
 func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event string) (err error) {
 	increaseTotal(dh.cache, receiver.SubscriptionID)
 
-	body := bytes.NewBufferString(event)
-	req, err := http.NewRequest("POST", receiver.CallbackURL, body)
+	req, err := NewCallbackRequest(receiver, event)
 	if err != nil {
 		increaseFailure(dh.cache, receiver.SubscriptionID)
 		return fmt.Errorf("event distribute fail, build request error: %v, data=[%s]", err, event)
 	}
+	client, err := CallbackClient(receiver.TLS)
+	if err != nil {
+		increaseFailure(dh.cache, receiver.SubscriptionID)
+		return fmt.Errorf("event distribute fail, build tls client error: %v, data=[%s]", err, event)
+	}
 	var duration time.Duration
 	if receiver.TimeOutSeconds == 0 {
 		duration = timeout
 	} else {
 		duration = receiver.GetTimeout()
 	}
-	resp, err := httpCli.DoWithTimeout(duration, req)
+	resp, err := client.DoWithTimeout(duration, req)
 	if err != nil {
 		increaseFailure(dh.cache, receiver.SubscriptionID)
 		return fmt.Errorf("event distribute fail, send request error: %v, data=[%s]", err, event)
@@ -75,8 +84,147 @@ func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event strin
 	return
 }
 
+// NewCallbackRequest builds the callback request of the event body with the receiver's custom headers,
+// the body is signed with the receiver's secret if it's set.
+func NewCallbackRequest(receiver *metadata.Subscription, event string) (*http.Request, error) {
+	return newSignedRequest(receiver, event, receiver.Secret, time.Now().Unix())
+}
+
+func newSignedRequest(receiver *metadata.Subscription, event, secret string, timestamp int64) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, receiver.CallbackURL, bytes.NewBufferString(event))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range receiver.Headers {
+		req.Header.Set(key, value)
+	}
+
+	if receiver.Secret != "" {
+		req.Header.Set(metadata.EventCallbackTimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(metadata.EventCallbackSignatureHeader, metadata.SignEventCallback(secret, timestamp, []byte(event)))
+	}
+	return req, nil
+}
+
 var httpCli = httpclient.NewHttpClient()
 
+var (
+	tlsClientsLock sync.Mutex
+	// tlsClients are the http clients of the receivers' tls options, keyed by the options
+	tlsClients = make(map[metadata.CallbackTLS]*httpclient.HttpClient)
+)
+
+// CallbackClient returns the http client to send callbacks with the tls options,
+// the clients are shared by the receivers with the same tls options.
+func CallbackClient(opt *metadata.CallbackTLS) (*httpclient.HttpClient, error) {
+	if opt == nil {
+		return httpCli, nil
+	}
+
+	tlsClientsLock.Lock()
+	defer tlsClientsLock.Unlock()
+	if client, exist := tlsClients[*opt]; exist {
+		return client, nil
+	}
+
+	tlsConf, err := NewCallbackTLSConfig(opt)
+	if err != nil {
+		return nil, err
+	}
+	client := httpclient.NewHttpClient()
+	client.SetTlsVerityConfig(tlsConf)
+	tlsClients[*opt] = client
+	return client, nil
+}
+
+var (
+	callbackTLSDirLock sync.RWMutex
+	// callbackTLSDir the directory the files of the callback tls options must be in
+	callbackTLSDir string
+)
+
+// SetCallbackTLSDir sets the directory the files of the callback tls options must be in,
+// the tls options with files are refused if it's not set.
+func SetCallbackTLSDir(dir string) {
+	callbackTLSDirLock.Lock()
+	callbackTLSDir = dir
+	callbackTLSDirLock.Unlock()
+}
+
+// callbackTLSFile resolves the file of the callback tls options, the file is relative to the callback
+// tls directory, it's refused if it's out of the directory, so that the callers can't read the other files.
+func callbackTLSFile(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	callbackTLSDirLock.RLock()
+	dir := callbackTLSDir
+	callbackTLSDirLock.RUnlock()
+	if dir == "" {
+		return "", fmt.Errorf("the callback tls files are not allowed, for the callback tls directory is not configured")
+	}
+
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid callback tls directory, err: %v", err)
+	}
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("callback tls file %s not found", name)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("callback tls file %s is out of the callback tls directory", name)
+	}
+	return path, nil
+}
+
+// NewCallbackTLSConfig loads the certificates of the tls options
+func NewCallbackTLSConfig(opt *metadata.CallbackTLS) (*tls.Config, error) {
+	caFile, err := callbackTLSFile(opt.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	certFile, err := callbackTLSFile(opt.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	keyFile, err := callbackTLSFile(opt.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsConf *tls.Config
+	switch {
+	case certFile != "" && caFile != "":
+		tlsConf, err = ssl.ClientTLSConfVerity(caFile, certFile, keyFile, opt.Password)
+	case certFile != "":
+		if opt.Password != "" {
+			return nil, fmt.Errorf("the ca file is required when the client key is encrypted")
+		}
+		cert, loadErr := tls.LoadX509KeyPair(certFile, keyFile)
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		tlsConf = &tls.Config{Certificates: []tls.Certificate{cert}}
+	case caFile != "":
+		tlsConf, err = ssl.ClientTslConfVerityServer(caFile)
+	default:
+		tlsConf = &tls.Config{}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConf.InsecureSkipVerify = opt.InsecureSkipVerify
+	return tlsConf, nil
+}
+
 func increaseTotal(cache *redis.Client, subscriptionID int64) error {
 	return increase(cache, subscriptionID, "total")
 }
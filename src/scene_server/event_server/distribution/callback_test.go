@@ -0,0 +1,193 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+const testSecret = "s3cret"
+
+// newVerifyingReceiver returns a receiver which rejects the callbacks with invalid signatures if verify is true
+func newVerifyingReceiver(t *testing.T, verify bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Custom") != "custom" {
+			t.Errorf("custom header is not sent, headers: %v", r.Header)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(metadata.EventCallbackTimestampHeader), 10, 64)
+		signature := r.Header.Get(metadata.EventCallbackSignatureHeader)
+		if verify && !metadata.VerifyEventCallback(testSecret, timestamp, body, signature) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+}
+
+func TestNewCallbackRequest(t *testing.T) {
+	receiver := &metadata.Subscription{
+		CallbackURL: "http://127.0.0.1/callback",
+		Secret:      testSecret,
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+	}
+	req, err := NewCallbackRequest(receiver, `{"event_id":1}`)
+	if err != nil {
+		t.Fatalf("new callback request failed, err: %v", err)
+	}
+	if req.Header.Get("Authorization") != "Bearer token" {
+		t.Fatalf("custom header is not set, headers: %v", req.Header)
+	}
+
+	timestamp, err := strconv.ParseInt(req.Header.Get(metadata.EventCallbackTimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid timestamp header, err: %v", err)
+	}
+	signature := req.Header.Get(metadata.EventCallbackSignatureHeader)
+	if !metadata.VerifyEventCallback(testSecret, timestamp, []byte(`{"event_id":1}`), signature) {
+		t.Fatalf("invalid signature %s", signature)
+	}
+	if metadata.VerifyEventCallback(testSecret, timestamp+1, []byte(`{"event_id":1}`), signature) {
+		t.Fatal("signature should be bound to the timestamp")
+	}
+
+	receiver.Secret = ""
+	req, err = NewCallbackRequest(receiver, `{"event_id":1}`)
+	if err != nil {
+		t.Fatalf("new callback request failed, err: %v", err)
+	}
+	if req.Header.Get(metadata.EventCallbackSignatureHeader) != "" {
+		t.Fatal("callback should not be signed without secret")
+	}
+}
+
+func TestPingCallbackCheckSignature(t *testing.T) {
+	verifying := newVerifyingReceiver(t, true)
+	defer verifying.Close()
+	receiver := &metadata.Subscription{
+		CallbackURL: verifying.URL,
+		Secret:      testSecret,
+		Headers:     map[string]string{"X-Custom": "custom"},
+	}
+	result, err := PingCallback(receiver, `{"ping":true}`, true)
+	if err != nil {
+		t.Fatalf("ping callback failed, err: %v", err)
+	}
+	if result.HttpStatus != http.StatusOK || result.SignatureCheck == nil || !result.SignatureCheck.Passed {
+		t.Fatalf("receiver verifies the signature, but got: %+v, %+v", result, result.SignatureCheck)
+	}
+	if result.SignatureCheck.ForgedHttpStatus != http.StatusUnauthorized {
+		t.Fatalf("forged request should be rejected, but got status %d", result.SignatureCheck.ForgedHttpStatus)
+	}
+
+	careless := newVerifyingReceiver(t, false)
+	defer careless.Close()
+	receiver.CallbackURL = careless.URL
+	result, err = PingCallback(receiver, `{"ping":true}`, true)
+	if err != nil {
+		t.Fatalf("ping callback failed, err: %v", err)
+	}
+	if result.SignatureCheck == nil || result.SignatureCheck.Passed {
+		t.Fatalf("receiver does not verify the signature, but got: %+v", result.SignatureCheck)
+	}
+
+	receiver.Secret = ""
+	if _, err := PingCallback(receiver, `{"ping":true}`, true); err != ErrNoSecret {
+		t.Fatalf("expect no secret error, but got: %v", err)
+	}
+}
+
+func TestCallbackTLSFile(t *testing.T) {
+	base, err := ioutil.TempDir("", "callback-tls")
+	if err != nil {
+		t.Fatalf("create temp dir failed, err: %v", err)
+	}
+	defer os.RemoveAll(base)
+	dir := filepath.Join(base, "certs")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatalf("create tls dir failed, err: %v", err)
+	}
+	for _, file := range []string{filepath.Join(dir, "ca.pem"), filepath.Join(dir, "sub", "cert.pem"), filepath.Join(base, "secret")} {
+		if err := ioutil.WriteFile(file, []byte("x"), 0644); err != nil {
+			t.Fatalf("write file failed, err: %v", err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(base, "secret"), filepath.Join(dir, "link.pem")); err != nil {
+		t.Fatalf("create symlink failed, err: %v", err)
+	}
+
+	SetCallbackTLSDir("")
+	if _, err := callbackTLSFile("ca.pem"); err == nil {
+		t.Fatalf("the tls files should be refused if the tls directory is not configured")
+	}
+
+	SetCallbackTLSDir(dir)
+	defer SetCallbackTLSDir("")
+	cases := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "", valid: true},
+		{name: "ca.pem", valid: true},
+		{name: "sub/cert.pem", valid: true},
+		{name: filepath.Join(dir, "ca.pem"), valid: true},
+		{name: "../secret", valid: false},
+		{name: "sub/../../secret", valid: false},
+		{name: filepath.Join(base, "secret"), valid: false},
+		{name: "/etc/passwd", valid: false},
+		{name: "link.pem", valid: false},
+		{name: "not-exist.pem", valid: false},
+	}
+	for _, c := range cases {
+		_, err := callbackTLSFile(c.name)
+		if (err == nil) != c.valid {
+			t.Errorf("callback tls file %q, expect valid: %v, got err: %v", c.name, c.valid, err)
+		}
+	}
+}
+
+func TestSubscriptionCacheKey(t *testing.T) {
+	sub := metadata.Subscription{
+		SubscriptionID: 1,
+		CallbackURL:    "http://127.0.0.1/callback",
+		Secret:         testSecret,
+		Headers:        map[string]string{"Authorization": "Bearer token"},
+		TLS:            &metadata.CallbackTLS{CAFile: "ca.pem", Password: "key-password"},
+	}
+	key := sub.GetCacheKey()
+	for _, credential := range []string{testSecret, "Bearer token", "key-password"} {
+		if strings.Contains(key, credential) {
+			t.Fatalf("cache key should not contain the credential %s, key: %s", credential, key)
+		}
+	}
+
+	changed := sub
+	changed.TLS = &metadata.CallbackTLS{CAFile: "ca.pem", Password: "another"}
+	if changed.GetCacheKey() == key {
+		t.Fatalf("cache key should change with the tls password")
+	}
+	changed = sub
+	changed.Secret = "another"
+	if changed.GetCacheKey() == key {
+		t.Fatalf("cache key should change with the secret")
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+)
+
+// retrySignal interrupts the callback retries of a subscriber when it's deleted or changed,
+// so that the subscriber routine keeps receiving them during the backoff.
+type retrySignal struct {
+	chNew <-chan metadata.Subscription
+	done  <-chan struct{}
+	// renewed is the changed subscription received during the backoff, it's applied by the routine after the retries
+	renewed *metadata.Subscription
+}
+
+// sendCallbackWithRetry sends the body of the events to the subscriber, and retries with the subscriber's
+// retry policy if failed. the events are saved as dead letters if all the retries failed, or the retries
+// are stopped early because the subscriber is changed, nothing is saved if the subscriber is deleted.
+func (dh *DistHandler) sendCallbackWithRetry(sub *metadata.Subscription, dists []*metadata.DistInstCtx, body string,
+	signal *retrySignal) error {
+	policy := sub.GetRetryPolicy()
+
+	var err error
+	attempts := 0
+	for retry := 0; ; retry++ {
+		attempts++
+		if err = dh.SendCallback(sub, body); err == nil {
+			return nil
+		}
+		if retry >= policy.MaxRetries {
+			break
+		}
+
+		backoff := policy.Backoff(retry)
+		blog.Warnf("send callback to subscriber %d failed, retry %d after %s, err: %v", sub.SubscriptionID, retry+1, backoff, err)
+		if !dh.waitRetry(sub, backoff, signal) {
+			break
+		}
+	}
+
+	select {
+	case <-signal.done:
+		// the subscriber is deleted, its dead letters would never be replayed
+		return err
+	default:
+	}
+
+	for _, dist := range dists {
+		if saveErr := dh.saveDeadLetter(dist, attempts, err); saveErr != nil {
+			blog.Errorf("save dead letter of subscriber %d dist %d failed, err: %v", dist.SubscriptionID, dist.DstbID, saveErr)
+		}
+	}
+	return err
+}
+
+// waitRetry waits the backoff before the next retry, it returns false if the retries should stop, which
+// happens when the event server is stopping, or the subscriber is deleted or changed during the backoff.
+func (dh *DistHandler) waitRetry(sub *metadata.Subscription, backoff time.Duration, signal *retrySignal) bool {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return true
+		case <-dh.ctx.Done():
+			// keep the event as a dead letter so that it can be replayed later
+			blog.Warnf("stop retrying send callback to subscriber %d, event server is stopping", sub.SubscriptionID)
+			return false
+		case <-signal.done:
+			blog.Warnf("stop retrying send callback to subscriber %d, subscriber is deleted", sub.SubscriptionID)
+			return false
+		case nsub := <-signal.chNew:
+			if nsub.GetCacheKey() == sub.GetCacheKey() {
+				continue
+			}
+			// the events are kept as dead letters, and replayed to the changed subscriber later
+			blog.Warnf("stop retrying send callback to subscriber %d, subscriber is changed", sub.SubscriptionID)
+			signal.renewed = &nsub
+			return false
+		}
+	}
+}
+
+// saveDeadLetter saves the event which failed to be delivered after all the retries
+func (dh *DistHandler) saveDeadLetter(dist *metadata.DistInstCtx, attempts int, sendErr error) error {
+	id, err := dh.db.NextSequence(dh.ctx, common.BKTableNameEventDeadLetter)
+	if err != nil {
+		return err
+	}
+
+	now := metadata.Now()
+	letter := metadata.DeadLetter{
+		ID:             int64(id),
+		SubscriptionID: dist.SubscriptionID,
+		OwnerID:        dist.OwnerID,
+		DistID:         dist.DstbID,
+		EventID:        dist.ID,
+		EventType:      dist.EventType,
+		ObjType:        dist.ObjType,
+		Action:         dist.Action,
+		Event:          dist.Raw,
+		Attempts:       attempts,
+		CreateTime:     now,
+		LastTime:       now,
+	}
+	if sendErr != nil {
+		letter.LastError = sendErr.Error()
+	}
+	return dh.db.Table(common.BKTableNameEventDeadLetter).Insert(dh.ctx, letter)
+}
+
+// ReplayDeadLetter sends the dead letter to the subscriber once again, the dead letter is removed
+// if it's delivered successfully, otherwise the attempts and last error of it are updated.
+func (dh *DistHandler) ReplayDeadLetter(sub *metadata.Subscription, letter *metadata.DeadLetter) error {
+	filter := map[string]interface{}{
+		common.BKFieldID: letter.ID,
+	}
+
+	sendErr := dh.SendCallback(sub, letter.Event)
+	if sendErr == nil {
+		return dh.db.Table(common.BKTableNameEventDeadLetter).Delete(dh.ctx, filter)
+	}
+
+	doc := map[string]interface{}{
+		"attempts":   letter.Attempts + 1,
+		"last_error": sendErr.Error(),
+		"last_time":  metadata.Now(),
+	}
+	if err := dh.db.Table(common.BKTableNameEventDeadLetter).Update(dh.ctx, filter, doc); err != nil {
+		blog.Errorf("update dead letter %d failed, err: %v", letter.ID, err)
+	}
+	return sendErr
+}
@@ -17,8 +17,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"runtime/debug"
+	"strings"
 	"time"
 
+	"gopkg.in/redis.v5"
+
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
@@ -53,7 +56,7 @@ func (dh *DistHandler) StartDistribute() (err error) {
 		}
 
 		done := make(chan struct{})
-		renewCh := make(chan metadata.Subscription)
+		renewCh := make(chan metadata.Subscription, 1)
 		go func() {
 			err := dh.distToSubscribe(subscriber, renewCh, done)
 			if err != nil {
@@ -84,20 +87,20 @@ func (dh *DistHandler) StartDistribute() (err error) {
 			msgBody := extractChangeBody(msg)
 
 			subscriber := metadata.Subscription{}
-			blog.Infof("msg: action:%s, body:%s", msgAction, msgBody)
 			if err := json.Unmarshal([]byte(msgBody), &subscriber); err != nil {
 				chErr <- err
 				return
 			}
+			blog.V(4).Infof("msg: action: %s, subscription: %d", msgAction, subscriber.SubscriptionID)
 			switch msgAction {
 			case "create":
 				blog.Infof("starting subscribers process %d", subscriber.SubscriptionID)
 				if renewCh, ok := renewMaps[subscriber.SubscriptionID]; ok {
-					renewCh <- subscriber
+					renewSubscriber(renewCh, subscriber)
 					continue
 				}
 				done := make(chan struct{})
-				renewCh := make(chan metadata.Subscription)
+				renewCh := make(chan metadata.Subscription, 1)
 				go func() {
 					err := dh.distToSubscribe(subscriber, renewCh, done)
 					if err != nil {
@@ -109,7 +112,7 @@ func (dh *DistHandler) StartDistribute() (err error) {
 			case "update":
 				blog.Infof("renew subscribers process %d", subscriber.SubscriptionID)
 				if renewCh, exist := renewMaps[subscriber.SubscriptionID]; exist == true {
-					renewCh <- subscriber
+					renewSubscriber(renewCh, subscriber)
 				} else {
 					MsgChan <- "create" + msgBody
 				}
@@ -128,6 +131,20 @@ func (dh *DistHandler) StartDistribute() (err error) {
 
 }
 
+// renewSubscriber hands the subscription to its routine without blocking the subscriber change loop,
+// the renewal which is not received by the routine yet is replaced, so that the latest one wins.
+func renewSubscriber(renewCh chan metadata.Subscription, sub metadata.Subscription) {
+	select {
+	case <-renewCh:
+	default:
+	}
+	// only the change loop sends to the channel, it's empty now
+	select {
+	case renewCh <- sub:
+	default:
+	}
+}
+
 func (dh *DistHandler) distToSubscribe(param metadata.Subscription, chNew chan metadata.Subscription, done chan struct{}) (err error) {
 	blog.Infof("start handle dist %v", param.SubscriptionID)
 	defer func() {
@@ -140,22 +157,33 @@ func (dh *DistHandler) distToSubscribe(param metadata.Subscription, chNew chan m
 		}
 	}()
 	sub := param
+	filter := newEventFilter(&sub)
+	signal := &retrySignal{chNew: chNew, done: done}
 	ticker := time.NewTicker(time.Minute)
 	defer blog.Infof("ended handle dist %v", sub.SubscriptionID)
 	for {
+		// the subscriber is changed while retrying the callback
+		if signal.renewed != nil {
+			sub = *signal.renewed
+			filter = newEventFilter(&sub)
+			signal.renewed = nil
+			blog.Infof("refreshed subscriber %d", sub.SubscriptionID)
+		}
+
 		select {
 		case nsub := <-chNew:
 			if nsub.GetCacheKey() != sub.GetCacheKey() {
 				sub = nsub
-				blog.Infof("refreshed subscriber %v", sub.GetCacheKey())
+				filter = newEventFilter(&sub)
+				blog.Infof("refreshed subscriber %d", sub.SubscriptionID)
 			} else {
-				blog.Infof("refresh ignore, subscriber cache key not change\nold:%s\nnew:%s ", sub.GetCacheKey(), nsub.GetCacheKey())
+				blog.V(4).Infof("refresh ignore, subscriber %d not changed", sub.SubscriptionID)
 			}
 		case <-ticker.C:
-			filter := map[string]interface{}{
+			cond := map[string]interface{}{
 				common.BKSubscriptionIDField: sub.SubscriptionID,
 			}
-			count, countErr := dh.db.Table(common.BKTableNameSubscription).Find(filter).Count(context.Background())
+			count, countErr := dh.db.Table(common.BKTableNameSubscription).Find(cond).Count(context.Background())
 			if countErr != nil {
 				blog.Errorf("get subscription count error %v", countErr)
 				continue
@@ -171,19 +199,25 @@ func (dh *DistHandler) distToSubscribe(param metadata.Subscription, chNew chan m
 			if dist == nil {
 				continue
 			}
-			if err = dh.handleDist(&sub, dist); err != nil {
-				blog.Errorf("error handle dist: %v, %v", err, dist)
+			if sub.Batch == nil {
+				if err = dh.handleDist(&sub, filter, dist, signal); err != nil {
+					blog.Errorf("error handle dist: %v, %v", err, dist)
+				}
+				continue
+			}
+
+			dists := dh.fillBatch(sub.SubscriptionID, dist, *sub.Batch)
+			if err = dh.handleDistBatch(&sub, filter, dists, signal); err != nil {
+				blog.Errorf("error handle dist batch of subscriber %d: %v", sub.SubscriptionID, err)
 			}
 		}
 	}
 }
 
-func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.DistInstCtx) (err error) {
+func (dh *DistHandler) handleDist(sub *metadata.Subscription, filter *eventFilter, dist *metadata.DistInstCtx,
+	signal *retrySignal) (err error) {
 	blog.Infof("handling dist %s", dist.Raw)
-	distID := fmt.Sprint(dist.DstbID - 1)
-	subscriberID := fmt.Sprint(dist.SubscriptionID)
-	runningKey := types.EventCacheDistRunningPrefix + subscriberID + "_" + distID
-	if err = saveRunning(dh.cache, runningKey, timeout+sub.GetTimeout()); err != nil {
+	if err = dh.saveDistRunning(sub, dist); err != nil {
 		if ErrProcessExists == err {
 			blog.Infof("process exist, continue")
 			return nil
@@ -191,6 +225,95 @@ func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.Dis
 		return err
 	}
 
+	if err = dh.waitPreviousDist(sub, dist); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err = dh.saveDistDone(dist); err != nil {
+			return
+		}
+		blog.Infof("done event dist : %v", dist.DstbID)
+	}()
+
+	if !filter.match(dist) {
+		blog.V(4).Infof("event dist %d is filtered by subscriber %d", dist.DstbID, dist.SubscriptionID)
+		return nil
+	}
+
+	if err = dh.sendCallbackWithRetry(sub, []*metadata.DistInstCtx{dist}, dist.Raw, signal); err != nil {
+		blog.Errorf("send callback error: %v", err)
+		return
+	}
+
+	return
+}
+
+// handleDistBatch sends the matched events of the batch to the subscriber in one callback
+func (dh *DistHandler) handleDistBatch(sub *metadata.Subscription, filter *eventFilter, dists []*metadata.DistInstCtx,
+	signal *retrySignal) (err error) {
+	running := make([]*metadata.DistInstCtx, 0, len(dists))
+	for _, dist := range dists {
+		if err = dh.saveDistRunning(sub, dist); err != nil {
+			if ErrProcessExists == err {
+				blog.Infof("process of dist %d exist, continue", dist.DstbID)
+				continue
+			}
+			return err
+		}
+		running = append(running, dist)
+	}
+	if len(running) == 0 {
+		return nil
+	}
+
+	if err = dh.waitPreviousDist(sub, running[0]); err != nil {
+		return err
+	}
+
+	defer func() {
+		for _, dist := range running {
+			if err = dh.saveDistDone(dist); err != nil {
+				return
+			}
+		}
+		blog.Infof("done event dist batch: %v - %v", running[0].DstbID, running[len(running)-1].DstbID)
+	}()
+
+	matched := make([]*metadata.DistInstCtx, 0, len(running))
+	raws := make([]string, 0, len(running))
+	for _, dist := range running {
+		if !filter.match(dist) {
+			continue
+		}
+		matched = append(matched, dist)
+		raws = append(raws, dist.Raw)
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	body := "[" + strings.Join(raws, ",") + "]"
+	if err = dh.sendCallbackWithRetry(sub, matched, body, signal); err != nil {
+		blog.Errorf("send batch callback error: %v", err)
+		return
+	}
+	return
+}
+
+func (dh *DistHandler) saveDistRunning(sub *metadata.Subscription, dist *metadata.DistInstCtx) error {
+	distID := fmt.Sprint(dist.DstbID - 1)
+	subscriberID := fmt.Sprint(dist.SubscriptionID)
+	runningKey := types.EventCacheDistRunningPrefix + subscriberID + "_" + distID
+	// the running key should cover all the retries of the callback
+	policy := sub.GetRetryPolicy()
+	runningTTL := (timeout+sub.GetTimeout())*time.Duration(policy.MaxRetries+1) + policy.MaxElapsed()
+	return saveRunning(dh.cache, runningKey, runningTTL)
+}
+
+// waitPreviousDist waits the previous dist of the subscriber done, so that the events are sent in order
+func (dh *DistHandler) waitPreviousDist(sub *metadata.Subscription, dist *metadata.DistInstCtx) error {
+	subscriberID := fmt.Sprint(dist.SubscriptionID)
 	previousID := fmt.Sprint(dist.DstbID - 1)
 	previousRunningKey := types.EventCacheDistRunningPrefix + subscriberID + "_" + previousID
 	done, err := checkFromDone(dh.cache, types.EventCacheDistDonePrefix+subscriberID, previousID)
@@ -222,20 +345,7 @@ func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.Dis
 			}
 		}
 	}
-
-	defer func() {
-		if err = dh.saveDistDone(dist); err != nil {
-			return
-		}
-		blog.Infof("done event dist : %v", dist.DstbID)
-	}()
-
-	if err = dh.SendCallback(sub, dist.Raw); err != nil {
-		blog.Errorf("send callback error: %v", err)
-		return
-	}
-
-	return
+	return nil
 }
 
 func (dh *DistHandler) popDistInst(subID int64) *metadata.DistInstCtx {
@@ -245,14 +355,49 @@ func (dh *DistHandler) popDistInst(subID int64) *metadata.DistInstCtx {
 		return nil
 	}
 
-	eventBytes := []byte(eventSlice[1])
+	return decodeDistInst(eventSlice[1])
+}
+
+// batchPollInterval is the interval to check the subscriber's queue when filling a batch
+var batchPollInterval = 50 * time.Millisecond
+
+// fillBatch pops the events of the subscriber until the batch is full or the max wait time elapsed
+func (dh *DistHandler) fillBatch(subID int64, first *metadata.DistInstCtx, batch metadata.EventBatch) []*metadata.DistInstCtx {
+	dists := []*metadata.DistInstCtx{first}
+	deadline := time.Now().Add(time.Duration(batch.MaxWait) * time.Millisecond)
+	for len(dists) < batch.MaxEvents {
+		raw, err := dh.cache.LPop(types.EventCacheDistQueuePrefix + fmt.Sprint(subID)).Result()
+		if err == nil {
+			if dist := decodeDistInst(raw); dist != nil {
+				dists = append(dists, dist)
+			}
+			continue
+		}
+		if err != redis.Nil {
+			blog.Errorf("pop event of subscriber %d failed, err: %v", subID, err)
+			break
+		}
+
+		wait := deadline.Sub(time.Now())
+		if wait <= 0 {
+			break
+		}
+		if wait > batchPollInterval {
+			wait = batchPollInterval
+		}
+		time.Sleep(wait)
+	}
+	return dists
+}
+
+func decodeDistInst(raw string) *metadata.DistInstCtx {
 	event := metadata.DistInst{}
-	if err := json.Unmarshal(eventBytes, &event); err != nil {
-		blog.Errorf("event distribute fail, unmarshal error: %v, data=[%s]", err, eventBytes)
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		blog.Errorf("event distribute fail, unmarshal error: %v, data=[%s]", err, raw)
 		return nil
 	}
 
-	return &metadata.DistInstCtx{DistInst: event, Raw: eventSlice[1]}
+	return &metadata.DistInstCtx{DistInst: event, Raw: raw}
 }
 
 func (dh *DistHandler) saveDistDone(dist *metadata.DistInstCtx) (err error) {
@@ -0,0 +1,195 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := metadata.RetryPolicy{MaxRetries: 5, InitialInterval: 100, MaxInterval: 500, Multiplier: 2}
+	expects := []time.Duration{100, 200, 400, 500, 500}
+	for retry, expect := range expects {
+		if backoff := policy.Backoff(retry); backoff != expect*time.Millisecond {
+			t.Fatalf("retry %d expect backoff %v, but got %v", retry, expect*time.Millisecond, backoff)
+		}
+	}
+	if elapsed := policy.MaxElapsed(); elapsed != 1700*time.Millisecond {
+		t.Fatalf("expect max elapsed 1.7s, but got %v", elapsed)
+	}
+
+	constant := metadata.RetryPolicy{MaxRetries: 2, InitialInterval: 100}
+	if backoff := constant.Backoff(1); backoff != 100*time.Millisecond {
+		t.Fatalf("expect constant backoff 100ms, but got %v", backoff)
+	}
+}
+
+func TestSubscriptionRetryPolicy(t *testing.T) {
+	sub := metadata.Subscription{}
+	if sub.GetRetryPolicy() != metadata.DefaultRetryPolicy {
+		t.Fatalf("expect default retry policy, but got %+v", sub.GetRetryPolicy())
+	}
+
+	sub.RetryPolicy = &metadata.RetryPolicy{MaxRetries: metadata.MaxRetryPolicyRetries + 1}
+	if field, ok := sub.RetryPolicy.Validate(); ok || field != "retry_policy.max_retries" {
+		t.Fatalf("expect max retries invalid, but got %s, %v", field, ok)
+	}
+
+	key := sub.GetCacheKey()
+	sub.RetryPolicy = &metadata.RetryPolicy{MaxRetries: 1}
+	if key == sub.GetCacheKey() {
+		t.Fatal("subscription cache key should change with the retry policy")
+	}
+}
+
+func TestWaitRetry(t *testing.T) {
+	dh := &DistHandler{ctx: context.Background()}
+	sub := &metadata.Subscription{SubscriptionID: 1, CallbackURL: "http://127.0.0.1/callback"}
+
+	chNew := make(chan metadata.Subscription, 1)
+	done := make(chan struct{})
+	signal := &retrySignal{chNew: chNew, done: done}
+	if !dh.waitRetry(sub, 10*time.Millisecond, signal) {
+		t.Fatal("expect to retry after the backoff")
+	}
+
+	// the unchanged renewal doesn't stop the retries
+	chNew <- *sub
+	if !dh.waitRetry(sub, 10*time.Millisecond, signal) || signal.renewed != nil {
+		t.Fatalf("expect to retry if the subscriber is not changed, renewed: %v", signal.renewed)
+	}
+
+	// the changed renewal stops the retries, and it's handed back to the routine
+	changed := *sub
+	changed.CallbackURL = "http://127.0.0.1/changed"
+	chNew <- changed
+	start := time.Now()
+	if dh.waitRetry(sub, time.Minute, signal) {
+		t.Fatal("expect to stop retrying if the subscriber is changed")
+	}
+	if signal.renewed == nil || signal.renewed.CallbackURL != changed.CallbackURL {
+		t.Fatalf("expect the changed subscriber to be handed back, got %v", signal.renewed)
+	}
+	if cost := time.Since(start); cost > time.Second {
+		t.Fatalf("expect to stop retrying at once, but it took %v", cost)
+	}
+
+	// the deleted subscriber stops the retries
+	close(done)
+	start = time.Now()
+	if dh.waitRetry(sub, time.Minute, signal) {
+		t.Fatal("expect to stop retrying if the subscriber is deleted")
+	}
+	if cost := time.Since(start); cost > time.Second {
+		t.Fatalf("expect to stop retrying at once, but it took %v", cost)
+	}
+}
+
+func TestRenewSubscriber(t *testing.T) {
+	renewCh := make(chan metadata.Subscription, 1)
+	// nobody receives the renewals, the change loop is not blocked and the latest one wins
+	for id := int64(1); id <= 3; id++ {
+		renewSubscriber(renewCh, metadata.Subscription{SubscriptionID: id})
+	}
+	if sub := <-renewCh; sub.SubscriptionID != 3 {
+		t.Fatalf("expect the latest renewal 3, but got %d", sub.SubscriptionID)
+	}
+}
+
+func newTestDist(t *testing.T, action string, cur, pre string) *metadata.DistInstCtx {
+	raw := `{"action":"` + action + `","data":[{"cur_data":` + cur + `,"pre_data":` + pre + `}]}`
+	dist := decodeDistInst(raw)
+	if dist == nil {
+		t.Fatalf("decode dist failed, raw: %s", raw)
+	}
+	return dist
+}
+
+func TestEventFilter(t *testing.T) {
+	sub := &metadata.Subscription{}
+	if filter := newEventFilter(sub); filter != nil || !filter.match(newTestDist(t, "create", `{}`, `null`)) {
+		t.Fatal("subscription without filter should receive all the events")
+	}
+
+	rule := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(`{"condition":"AND","rules":[
+		{"field":"cur_data.bk_os_type","operator":"equal","value":"1"},
+		{"field":"cur_data.bk_cpu","operator":"greater","value":4}
+	]}`), &rule); err != nil {
+		t.Fatalf("unmarshal rule failed, err: %v", err)
+	}
+	sub.Filter = rule
+	sub.ChangedFields = []string{"bk_cpu"}
+	filter := newEventFilter(sub)
+
+	cases := []struct {
+		dist   *metadata.DistInstCtx
+		expect bool
+	}{
+		{newTestDist(t, "create", `{"bk_os_type":"1","bk_cpu":8}`, `null`), true},
+		{newTestDist(t, "create", `{"bk_os_type":"2","bk_cpu":8}`, `null`), false},
+		{newTestDist(t, "update", `{"bk_os_type":"1","bk_cpu":8}`, `{"bk_os_type":"1","bk_cpu":6}`), true},
+		// only fields not cared about are changed
+		{newTestDist(t, "update", `{"bk_os_type":"1","bk_cpu":8,"bk_mem":2}`, `{"bk_os_type":"1","bk_cpu":8,"bk_mem":1}`), false},
+		{newTestDist(t, "update", `{"bk_os_type":"1","bk_cpu":2}`, `{"bk_os_type":"1","bk_cpu":8}`), false},
+	}
+	for idx, c := range cases {
+		if filter.match(c.dist) != c.expect {
+			t.Fatalf("case %d expect match %v, dist: %s", idx, c.expect, c.dist.Raw)
+		}
+	}
+}
+
+func TestWatchCondition(t *testing.T) {
+	opt := metadata.ParamEventWatch{ObjTypes: []string{"host"}, Actions: []string{metadata.EventActionCreate}}
+	if field, ok := opt.Validate(); !ok {
+		t.Fatalf("validate watch options failed, field: %s", field)
+	}
+	if opt.Limit != metadata.DefaultEventWatchLimit || opt.Timeout != metadata.DefaultEventWatchTimeout {
+		t.Fatalf("default watch options are not filled: %+v", opt)
+	}
+	if _, ok := (&metadata.ParamEventWatch{Actions: []string{"not_exist"}}).Validate(); ok {
+		t.Fatal("expect invalid action")
+	}
+
+	cond := watchCondition("0", opt, 10, 20)
+	eventID, ok := cond[common.BKEventIDField].(mapstr.MapStr)
+	if !ok || eventID[common.BKDBGT] != int64(10) || eventID[common.BKDBLTE] != int64(20) {
+		t.Fatalf("unexpected event id condition: %v", cond)
+	}
+	if cond[common.BKOwnerIDField] != "0" || cond["obj_type"] == nil || cond["action"] == nil {
+		t.Fatalf("unexpected watch condition: %v", cond)
+	}
+}
+
+func TestNewWatchResult(t *testing.T) {
+	logs := []metadata.EventWatchLog{
+		{EventID: 11, Event: `{"event_type":"instdata","action":"create","obj_type":"host"}`},
+		{EventID: 15, Event: `{"event_type":"instdata","action":"delete","obj_type":"host"}`},
+	}
+	result, err := newWatchResult(logs)
+	if err != nil {
+		t.Fatalf("new watch result failed, err: %v", err)
+	}
+	if result.Cursor != 15 || len(result.Events) != 2 || result.Events[0].ID != 11 ||
+		result.Events[1].Action != metadata.EventActionDelete {
+		t.Fatalf("unexpected watch result: %+v", result)
+	}
+}
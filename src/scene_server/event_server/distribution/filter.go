@@ -0,0 +1,78 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"reflect"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
+)
+
+// eventFilter decides which events are sent to the subscriber besides the event types
+type eventFilter struct {
+	rule          querybuilder.Rule
+	changedFields []string
+}
+
+// newEventFilter returns the filter of the subscriber, nil means all the events are sent
+func newEventFilter(sub *metadata.Subscription) *eventFilter {
+	rule, key, err := sub.GetFilter()
+	if err != nil {
+		// the filter is validated when subscribe, ignore it to keep sending events to the subscriber
+		blog.Errorf("invalid filter of subscriber %d, key: %s, err: %v", sub.SubscriptionID, key, err)
+	}
+	if rule == nil && len(sub.ChangedFields) == 0 {
+		return nil
+	}
+	return &eventFilter{rule: rule, changedFields: sub.ChangedFields}
+}
+
+// match returns true if any data of the event matches the filter
+func (f *eventFilter) match(dist *metadata.DistInstCtx) bool {
+	if f == nil {
+		return true
+	}
+	for _, data := range dist.Data {
+		if f.matchData(dist.Action, data) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *eventFilter) matchData(action string, data metadata.EventData) bool {
+	if action == metadata.EventActionUpdate && len(f.changedFields) > 0 && !changed(data, f.changedFields) {
+		return false
+	}
+	if f.rule == nil {
+		return true
+	}
+	return f.rule.Match(querybuilder.MapMatcher(map[string]interface{}{
+		"cur_data": data.CurData,
+		"pre_data": data.PreData,
+	}))
+}
+
+// changed returns true if any of the fields is changed from the pre data to the cur data
+func changed(data metadata.EventData, fields []string) bool {
+	cur, _ := data.CurData.(map[string]interface{})
+	pre, _ := data.PreData.(map[string]interface{})
+	for _, field := range fields {
+		if !reflect.DeepEqual(cur[field], pre[field]) {
+			return true
+		}
+	}
+	return false
+}
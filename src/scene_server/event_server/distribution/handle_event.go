@@ -113,11 +113,22 @@ func (eh *EventHandler) handleEvent(event *metadata.EventInstCtx) (err error) {
 		err = eh.SaveEventDone(event)
 	}()
 
+	if err := eh.saveWatchLog(event); err != nil {
+		blog.Errorf("save event %d to watch log failed, err: %v", event.ID, err)
+	}
+
 	originDists := eh.GetDistInst(&event.EventInst)
 
 	for _, originDist := range originDists {
 		subscribers := eh.findEventTypeSubscribers(originDist.GetType(), event.OwnerID)
-		if len(subscribers) <= 0 || nilStr == subscribers[0] {
+		if len(subscribers) > 0 && nilStr == subscribers[0] {
+			subscribers = subscribers[:0]
+		}
+		// the sinks receive all the events as subscribers
+		for _, sinkID := range eh.sinkSubscribers {
+			subscribers = append(subscribers, strconv.FormatInt(sinkID, 10))
+		}
+		if len(subscribers) <= 0 {
 			blog.Infof("%v no subscriber，continue", originDist.GetType())
 			return eh.SaveEventDone(event)
 		}
@@ -0,0 +1,78 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/event_server/indexer"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+var (
+	// indexerSyncInterval is how often the models and the mapping of the attributes are synchronized
+	// to the full text search index, which have no events.
+	indexerSyncInterval = time.Minute
+	// indexerLockTTL is how long the lock of building the index is held at most
+	indexerLockTTL = time.Hour
+)
+
+// StartIndexer keeps the full text search index up to date with the events, the index is built
+// from mongo by one of the event servers if it's not built yet.
+func (dh *DistHandler) StartIndexer(esIndexer *indexer.Indexer) error {
+	go dh.syncIndex(esIndexer)
+	return dh.StartSink(types.EventIndexerSubscriptionID, esIndexer)
+}
+
+// syncIndex builds the index if it's not built, which is retried until elasticsearch is available,
+// and synchronizes the models periodically.
+func (dh *DistHandler) syncIndex(esIndexer *indexer.Indexer) {
+	ticker := time.NewTicker(indexerSyncInterval)
+	defer ticker.Stop()
+	for {
+		dh.ensureIndex(esIndexer)
+		if err := esIndexer.SyncModels(dh.ctx); err != nil {
+			blog.Errorf("sync models to full text index failed, err: %v", err)
+		}
+
+		select {
+		case <-dh.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ensureIndex builds the index if it's not built, the other event servers skip building it when
+// the lock is held.
+func (dh *DistHandler) ensureIndex(esIndexer *indexer.Indexer) {
+	locked, err := dh.cache.SetNX(types.EventCacheIndexerLockKey, time.Now().Unix(), indexerLockTTL).Result()
+	if err != nil {
+		blog.Errorf("lock to build full text index failed, err: %v", err)
+		return
+	}
+	if !locked {
+		blog.V(4).Infof("full text index is being built by another event server")
+		return
+	}
+	defer func() {
+		if err := dh.cache.Del(types.EventCacheIndexerLockKey).Err(); err != nil {
+			blog.Errorf("unlock building full text index failed, err: %v", err)
+		}
+	}()
+
+	if err := esIndexer.EnsureIndex(dh.ctx); err != nil {
+		blog.Errorf("build full text index failed, err: %v", err)
+	}
+}
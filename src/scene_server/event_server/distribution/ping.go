@@ -0,0 +1,98 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+// ErrNoSecret the receiver can not be checked for signature verification without a secret
+var ErrNoSecret = errors.New("secret is not set, can not check signature verification")
+
+// PingCallback sends the test data to the receiver the same way as the events are sent.
+// if checkSignature is true, a request with forged signature is sent too, the receiver
+// should accept the signed request and reject the forged one.
+func PingCallback(receiver *metadata.Subscription, data string, checkSignature bool) (*metadata.RspSubscriptionTestCallback, error) {
+	if checkSignature && receiver.Secret == "" {
+		return nil, ErrNoSecret
+	}
+
+	now := time.Now().Unix()
+	req, err := newSignedRequest(receiver, data, receiver.Secret, now)
+	if err != nil {
+		return nil, err
+	}
+	status, body, err := doPing(receiver, req)
+	if err != nil {
+		return nil, err
+	}
+	result := &metadata.RspSubscriptionTestCallback{HttpStatus: status, ResponseBody: body}
+	if !checkSignature {
+		return result, nil
+	}
+
+	forgedReq, err := newSignedRequest(receiver, data, receiver.Secret+"-forged", now)
+	if err != nil {
+		return nil, err
+	}
+	forgedStatus, forgedBody, err := doPing(receiver, forgedReq)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &metadata.CallbackSignatureCheck{ForgedHttpStatus: forgedStatus, ForgedResponseBody: forgedBody}
+	switch {
+	case !isAccepted(status):
+		check.Message = fmt.Sprintf("the receiver rejects the signed request with http status %d", status)
+	case isAccepted(forgedStatus):
+		check.Message = fmt.Sprintf("the receiver accepts the request with forged signature with http status %d", forgedStatus)
+	default:
+		check.Passed = true
+		check.Message = "the receiver verifies the signature"
+	}
+	result.SignatureCheck = check
+	return result, nil
+}
+
+func doPing(receiver *metadata.Subscription, req *http.Request) (int, string, error) {
+	client, err := CallbackClient(receiver.TLS)
+	if err != nil {
+		return 0, "", err
+	}
+
+	duration := timeout
+	if receiver.TimeOutSeconds > 0 {
+		duration = receiver.GetTimeout()
+	}
+	resp, err := client.DoWithTimeout(duration, req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", err
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+func isAccepted(status int) bool {
+	return status >= http.StatusOK && status < http.StatusMultipleChoices
+}
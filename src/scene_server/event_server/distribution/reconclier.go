@@ -14,6 +14,7 @@ package distribution
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
@@ -82,7 +83,13 @@ func (r *reconciler) loadAllPersisted() {
 	blog.Infof("loaded %v subscriptions from persistent", len(subscriptions))
 	for _, sub := range subscriptions {
 		eventNames := strings.Split(sub.SubscriptionForm, ",")
-		r.persistedSubscribers = append(r.persistedSubscribers, sub.GetCacheKey())
+		// the subscriber is sent to the distributor with the credentials, never log it
+		subscriber, err := json.Marshal(&sub)
+		if err != nil {
+			blog.Errorf("reconcile marshal subscription %d failed, err: %v", sub.SubscriptionID, err)
+			continue
+		}
+		r.persistedSubscribers = append(r.persistedSubscribers, string(subscriber))
 		for _, eventName := range eventNames {
 			eventName = sub.OwnerID + ":" + eventName
 			r.persisted[eventName] = append(r.persisted[eventName], fmt.Sprint(sub.SubscriptionID))
@@ -0,0 +1,136 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/sink"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+var (
+	// sinkBatch is how many events are written to the sink at a time
+	sinkBatch = metadata.EventBatch{MaxEvents: 200, MaxWait: 100}
+	// sinkRetryPolicy is used to retry writing the sink, the sink is retried until it succeeds
+	// so that the events are never lost or out of order.
+	sinkRetryPolicy = metadata.RetryPolicy{InitialInterval: 1000, MaxInterval: 60 * 1000, Multiplier: 2}
+)
+
+// StartSink writes all the events dispatched to the sink subscriber in order, the events written are
+// recorded as done the same way as the subscriptions, which is the offset of the sink.
+func (dh *DistHandler) StartSink(subID int64, eventSink sink.Sink) (err error) {
+	defer func() {
+		sysError := recover()
+		if sysError != nil {
+			err = fmt.Errorf("system error: %v", sysError)
+		}
+		if err != nil {
+			blog.Errorf("event sink %s stopped with error: %v, stack:%s", eventSink.Name(), err, debug.Stack())
+		}
+		if closeErr := eventSink.Close(); closeErr != nil {
+			blog.Errorf("close event sink %s failed, err: %v", eventSink.Name(), closeErr)
+		}
+	}()
+
+	blog.Infof("event sink %s started", eventSink.Name())
+	sub := &metadata.Subscription{SubscriptionID: subID, TimeOutSeconds: 10}
+	for {
+		select {
+		case <-dh.ctx.Done():
+			blog.Infof("event sink %s stopped", eventSink.Name())
+			return nil
+		default:
+		}
+
+		dist := dh.popDistInst(sub.SubscriptionID)
+		if dist == nil {
+			continue
+		}
+		dists := dh.fillBatch(sub.SubscriptionID, dist, sinkBatch)
+		if err := dh.writeSink(eventSink, sub, dists); err != nil {
+			blog.Errorf("write events to sink %s failed, err: %v", eventSink.Name(), err)
+		}
+	}
+}
+
+func (dh *DistHandler) writeSink(eventSink sink.Sink, sub *metadata.Subscription, dists []*metadata.DistInstCtx) error {
+	running := make([]*metadata.DistInstCtx, 0, len(dists))
+	for _, dist := range dists {
+		if err := dh.saveDistRunning(sub, dist); err != nil {
+			if ErrProcessExists == err {
+				blog.Infof("process of sink dist %d exist, continue", dist.DstbID)
+				continue
+			}
+			dh.requeueDists(sub.SubscriptionID, dists)
+			return err
+		}
+		running = append(running, dist)
+	}
+	if len(running) == 0 {
+		return nil
+	}
+
+	if err := dh.waitPreviousDist(sub, running[0]); err != nil {
+		dh.requeueDists(sub.SubscriptionID, running)
+		return err
+	}
+
+	events := make([]*metadata.DistInst, 0, len(running))
+	for _, dist := range running {
+		events = append(events, &dist.DistInst)
+	}
+
+	for retry := 0; ; retry++ {
+		err := eventSink.Write(dh.ctx, events)
+		if err == nil {
+			break
+		}
+
+		backoff := sinkRetryPolicy.Backoff(retry)
+		blog.Errorf("write %d events to sink %s failed, retry after %s, err: %v", len(events), eventSink.Name(), backoff, err)
+		select {
+		case <-dh.ctx.Done():
+			// put the events back, so that they are written after the event server restarts
+			dh.requeueDists(sub.SubscriptionID, running)
+			return err
+		case <-time.After(backoff):
+		}
+	}
+
+	for _, dist := range running {
+		if err := dh.saveDistDone(dist); err != nil {
+			return err
+		}
+	}
+	blog.V(4).Infof("wrote event dist %d - %d to sink %s", running[0].DstbID, running[len(running)-1].DstbID, eventSink.Name())
+	return nil
+}
+
+// requeueDists puts the dists back to the head of the subscriber's queue in order
+func (dh *DistHandler) requeueDists(subID int64, dists []*metadata.DistInstCtx) {
+	if len(dists) == 0 {
+		return
+	}
+	raws := make([]interface{}, 0, len(dists))
+	for i := len(dists) - 1; i >= 0; i-- {
+		raws = append(raws, dists[i].Raw)
+	}
+	if err := dh.cache.LPush(types.EventCacheDistQueuePrefix+fmt.Sprint(subID), raws...).Err(); err != nil {
+		blog.Errorf("requeue %d dists of subscriber %d failed, err: %v", len(dists), subID, err)
+	}
+}
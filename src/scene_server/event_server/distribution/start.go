@@ -25,33 +25,56 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/event_server/identifier"
+	"configcenter/src/scene_server/event_server/indexer"
+	"configcenter/src/scene_server/event_server/sink"
+	"configcenter/src/scene_server/event_server/types"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/rpc"
 )
 
-func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client) error {
+func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client, eventSink sink.Sink, esIndexer *indexer.Indexer,
+	watchLogSize int64) error {
 	chErr := make(chan error, 1)
 	err := migrateIDToMongo(ctx, cache, db)
 	if err != nil {
 		return fmt.Errorf("migrateIDToMongo failed: %v", err)
 	}
 
-	eh := &EventHandler{cache: cache}
+	eh := &EventHandler{cache: cache, db: db}
+	if eventSink != nil {
+		eh.sinkSubscribers = append(eh.sinkSubscribers, types.EventSinkSubscriptionID)
+	}
+	if esIndexer != nil {
+		eh.sinkSubscribers = append(eh.sinkSubscribers, types.EventIndexerSubscriptionID)
+	}
 	go func() {
 		chErr <- eh.Run()
 	}()
 
-	dh := &DistHandler{cache: cache, db: db, ctx: ctx}
+	dh := NewDistHandler(ctx, cache, db)
 	go func() {
 		chErr <- dh.StartDistribute()
 	}()
 
+	if eventSink != nil {
+		go func() {
+			chErr <- dh.StartSink(types.EventSinkSubscriptionID, eventSink)
+		}()
+	}
+
+	if esIndexer != nil {
+		go func() {
+			chErr <- dh.StartIndexer(esIndexer)
+		}()
+	}
+
 	ih := identifier.NewIdentifierHandler(ctx, cache, db)
 	go func() {
 		chErr <- ih.Run()
 	}()
 
 	go cleanExpiredEvents(cache)
+	go trimWatchLog(ctx, db, watchLogSize)
 
 	if rc != nil {
 		th := &TxnHandler{cache: cache, db: db, ctx: ctx, rc: rc, committed: make(chan string, 100), shouldClose: util.NewBool(false)}
@@ -98,13 +121,22 @@ func migrateIDToMongo(ctx context.Context, cache *redis.Client, db dal.RDB) erro
 	return cache.Del(common.EventCacheEventIDKey).Err()
 }
 
-type EventHandler struct{ cache *redis.Client }
+type EventHandler struct {
+	cache *redis.Client
+	db    dal.RDB
+	// sinkSubscribers are the subscription ids of the sinks, which all the events are dispatched to
+	sinkSubscribers []int64
+}
 type DistHandler struct {
 	cache *redis.Client
 	db    dal.RDB
 	ctx   context.Context
 }
 
+func NewDistHandler(ctx context.Context, cache *redis.Client, db dal.RDB) *DistHandler {
+	return &DistHandler{cache: cache, db: db, ctx: ctx}
+}
+
 type TxnHandler struct {
 	rc          rpc.Client
 	cache       *redis.Client
@@ -0,0 +1,177 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+)
+
+var (
+	// watchPollInterval is how often a watch request checks the new events
+	watchPollInterval = 500 * time.Millisecond
+	// watchLogTrimInterval is how often the watch log is trimmed to its size
+	watchLogTrimInterval = time.Minute
+)
+
+// ErrCursorExpired the events right after the cursor have been trimmed from the watch log
+var ErrCursorExpired = errors.New("event cursor expired")
+
+// saveWatchLog appends the event to the watch log, so that the consumers can watch it by the event id.
+func (eh *EventHandler) saveWatchLog(event *metadata.EventInstCtx) error {
+	inst := event.EventInst
+	if dists := eh.GetDistInst(&event.EventInst); len(dists) > 0 {
+		inst.ObjType = dists[0].ObjType
+	}
+	raw, err := json.Marshal(inst)
+	if err != nil {
+		return err
+	}
+
+	log := metadata.EventWatchLog{
+		EventID:    event.ID,
+		OwnerID:    event.OwnerID,
+		EventType:  event.EventType,
+		ObjType:    inst.ObjType,
+		Action:     event.Action,
+		Event:      string(raw),
+		CreateTime: metadata.Now(),
+	}
+	err = eh.db.Table(common.BKTableNameEventWatchLog).Insert(context.Background(), log)
+	if err != nil && !eh.db.IsDuplicatedError(err) {
+		return err
+	}
+	return nil
+}
+
+// trimWatchLog keeps the latest size events in the watch log
+func trimWatchLog(ctx context.Context, db dal.RDB, size int64) {
+	ticker := time.NewTicker(watchLogTrimInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		last, err := watchLogEdge(ctx, db, "-"+common.BKEventIDField)
+		if err != nil {
+			blog.Errorf("trim watch log, but get the last event failed, err: %v", err)
+			continue
+		}
+		if last <= size {
+			continue
+		}
+		cond := mapstr.MapStr{common.BKEventIDField: mapstr.MapStr{common.BKDBLTE: last - size}}
+		if err := db.Table(common.BKTableNameEventWatchLog).Delete(ctx, cond); err != nil {
+			blog.Errorf("trim watch log failed, cond: %v, err: %v", cond, err)
+		}
+	}
+}
+
+// watchLogEdge returns the event id of the first event in the sort order, 0 if the log is empty
+func watchLogEdge(ctx context.Context, db dal.RDB, sort string) (int64, error) {
+	log := metadata.EventWatchLog{}
+	err := db.Table(common.BKTableNameEventWatchLog).Find(mapstr.MapStr{}).Fields(common.BKEventIDField).
+		Sort(sort).One(ctx, &log)
+	if err != nil {
+		if db.IsNotFoundError(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return log.EventID, nil
+}
+
+// WatchEvents returns the events after the cursor, it waits until there are matched events or timeout.
+func WatchEvents(ctx context.Context, db dal.RDB, ownerID string, opt metadata.ParamEventWatch) (*metadata.RspEventWatch, error) {
+	if opt.Cursor > 0 {
+		first, err := watchLogEdge(ctx, db, common.BKEventIDField)
+		if err != nil {
+			return nil, err
+		}
+		if first > opt.Cursor+1 {
+			return nil, ErrCursorExpired
+		}
+	}
+
+	result := &metadata.RspEventWatch{Cursor: opt.Cursor, Events: make([]*metadata.EventInst, 0)}
+	deadline := time.NewTimer(time.Duration(opt.Timeout) * time.Second)
+	defer deadline.Stop()
+	for {
+		last, err := watchLogEdge(ctx, db, "-"+common.BKEventIDField)
+		if err != nil {
+			return nil, err
+		}
+		if last > result.Cursor {
+			logs := make([]metadata.EventWatchLog, 0)
+			cond := watchCondition(ownerID, opt, result.Cursor, last)
+			err := db.Table(common.BKTableNameEventWatchLog).Find(cond).Sort(common.BKEventIDField).
+				Limit(uint64(opt.Limit)).All(ctx, &logs)
+			if err != nil {
+				return nil, err
+			}
+			if len(logs) > 0 {
+				return newWatchResult(logs)
+			}
+			// none of the events until the last one matches, skip them
+			result.Cursor = last
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return result, nil
+		case <-time.After(watchPollInterval):
+		}
+	}
+}
+
+// watchCondition returns the condition of the events in (cursor, last] that the consumer watches
+func watchCondition(ownerID string, opt metadata.ParamEventWatch, cursor, last int64) mapstr.MapStr {
+	cond := mapstr.MapStr{
+		common.BKOwnerIDField: ownerID,
+		common.BKEventIDField: mapstr.MapStr{common.BKDBGT: cursor, common.BKDBLTE: last},
+	}
+	if len(opt.ObjTypes) > 0 {
+		cond["obj_type"] = mapstr.MapStr{common.BKDBIN: opt.ObjTypes}
+	}
+	if len(opt.Actions) > 0 {
+		cond["action"] = mapstr.MapStr{common.BKDBIN: opt.Actions}
+	}
+	return cond
+}
+
+func newWatchResult(logs []metadata.EventWatchLog) (*metadata.RspEventWatch, error) {
+	result := &metadata.RspEventWatch{Events: make([]*metadata.EventInst, 0, len(logs))}
+	for _, log := range logs {
+		event := new(metadata.EventInst)
+		if err := json.Unmarshal([]byte(log.Event), event); err != nil {
+			return nil, err
+		}
+		event.ID = log.EventID
+		result.Events = append(result.Events, event)
+	}
+	result.Cursor = logs[len(logs)-1].EventID
+	return result, nil
+}
@@ -0,0 +1,217 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package indexer
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+
+	"github.com/olivere/elastic"
+)
+
+const (
+	// SinkName is the name of the indexer as an event sink
+	SinkName = "elasticsearch"
+	// rebuildAlias is the alias of the index being rebuilt, the events are written to it too
+	rebuildAlias = common.CMDBINDEX + "_rebuilding"
+	// bulkSize is how many documents are loaded from mongo and indexed at a time
+	bulkSize = 500
+)
+
+// Indexer keeps the full text search index up to date with the events, so that the mongo data
+// doesn't need to be synchronized to elasticsearch by mongo-connector. the index searched is an
+// alias of the index the indexer built, so that the index can be rebuilt without downtime.
+type Indexer struct {
+	client *elastic.Client
+	db     dal.RDB
+
+	lock    sync.RWMutex
+	mapping *fieldMapping
+}
+
+// New creates an indexer
+func New(client *elastic.Client, db dal.RDB) *Indexer {
+	return &Indexer{client: client, db: db}
+}
+
+// Name returns the sink name of the indexer
+func (idx *Indexer) Name() string {
+	return SinkName
+}
+
+// Write indexes or deletes the documents of the events in all the indices being searched or rebuilt,
+// the events are skipped if the index is not built yet, which is built from mongo later.
+func (idx *Indexer) Write(ctx context.Context, events []*metadata.DistInst) error {
+	indices, err := idx.writeIndices(ctx)
+	if err != nil {
+		return err
+	}
+	if len(indices) == 0 {
+		blog.V(4).Infof("full text index is not built yet, skip %d events", len(events))
+		return nil
+	}
+
+	mapping, err := idx.getMapping(ctx)
+	if err != nil {
+		return err
+	}
+
+	bulk := idx.client.Bulk()
+	for _, event := range events {
+		ops, err := mapping.eventOperations(event)
+		if err != nil {
+			blog.Errorf("event %d can not be indexed, skip it, err: %v", event.ID, err)
+			continue
+		}
+		for _, op := range ops {
+			for _, index := range indices {
+				if op.delete {
+					bulk.Add(elastic.NewBulkDeleteRequest().Index(index).Type(op.typ).Id(op.id))
+					continue
+				}
+				bulk.Add(elastic.NewBulkIndexRequest().Index(index).Type(op.typ).Id(op.id).Doc(op.doc))
+			}
+		}
+	}
+	if bulk.NumberOfActions() == 0 {
+		return nil
+	}
+
+	resp, err := bulk.Do(ctx)
+	if err != nil {
+		return err
+	}
+	logFailedItems(resp)
+	return nil
+}
+
+// Close stops the es client
+func (idx *Indexer) Close() error {
+	idx.client.Stop()
+	return nil
+}
+
+// writeIndices returns the indices the documents are written to, which are the index searched
+// and the index being rebuilt, the index created by mongo-connector is not written any more.
+func (idx *Indexer) writeIndices(ctx context.Context) ([]string, error) {
+	state, err := idx.indexState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(state.current, state.rebuilding...), nil
+}
+
+// indexState is the indices of the full text search
+type indexState struct {
+	// current is the index searched
+	current []string
+	// rebuilding is the index being rebuilt
+	rebuilding []string
+	// legacy is whether the index searched is the index created by mongo-connector instead of an alias
+	legacy bool
+}
+
+func (idx *Indexer) indexState(ctx context.Context) (*indexState, error) {
+	aliases, err := idx.client.Aliases().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state := new(indexState)
+	for _, index := range aliases.IndicesByAlias(common.CMDBINDEX) {
+		if isManagedIndex(index) {
+			state.current = append(state.current, index)
+		}
+	}
+	for _, index := range aliases.IndicesByAlias(rebuildAlias) {
+		if isManagedIndex(index) {
+			state.rebuilding = append(state.rebuilding, index)
+		}
+	}
+	if len(state.current) == 0 {
+		// the index name is not an alias of the indexer, it's created by mongo-connector if exists
+		state.legacy, err = idx.client.IndexExists(common.CMDBINDEX).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// getMapping returns the mapping of the attributes, which is loaded at the first time
+func (idx *Indexer) getMapping(ctx context.Context) (*fieldMapping, error) {
+	idx.lock.RLock()
+	mapping := idx.mapping
+	idx.lock.RUnlock()
+	if mapping != nil {
+		return mapping, nil
+	}
+
+	mapping, err := idx.loadMapping(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idx.setMapping(mapping)
+	return mapping, nil
+}
+
+func (idx *Indexer) setMapping(mapping *fieldMapping) {
+	idx.lock.Lock()
+	idx.mapping = mapping
+	idx.lock.Unlock()
+}
+
+// loadMapping builds the mapping from the attributes of all the models
+func (idx *Indexer) loadMapping(ctx context.Context) (*fieldMapping, error) {
+	attrs := make([]metadata.Attribute, 0)
+	err := idx.db.Table(common.BKTableNameObjAttDes).Find(nil).
+		Fields(common.BKObjIDField, common.BKPropertyIDField, common.BKPropertyTypeField).All(ctx, &attrs)
+	if err != nil {
+		blog.Errorf("find attributes for full text index mapping failed, err: %v", err)
+		return nil, err
+	}
+	return newFieldMapping(attrs), nil
+}
+
+// logFailedItems logs the documents failed to index, the documents already deleted or already
+// created by the events when the index is being rebuilt are not failures.
+func logFailedItems(resp *elastic.BulkResponse) int {
+	failed := 0
+	for _, item := range resp.Items {
+		for action, result := range item {
+			if result.Status < http.StatusMultipleChoices {
+				continue
+			}
+			if action == "delete" && result.Status == http.StatusNotFound {
+				continue
+			}
+			if action == "create" && result.Status == http.StatusConflict {
+				continue
+			}
+			failed++
+			reason := ""
+			if result.Error != nil {
+				reason = result.Error.Reason
+			}
+			blog.Errorf("%s document %s/%s/%s failed, status: %d, reason: %s", action, result.Index, result.Type, result.Id,
+				result.Status, reason)
+		}
+	}
+	return failed
+}
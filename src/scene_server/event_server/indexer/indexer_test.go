@@ -0,0 +1,134 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package indexer
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldMapping(t *testing.T) {
+	attrs := []metadata.Attribute{
+		{ObjectID: "host", PropertyID: "bk_cpu", PropertyType: common.FieldTypeInt},
+		{ObjectID: "host", PropertyID: "bk_host_name", PropertyType: common.FieldTypeSingleChar},
+		{ObjectID: "switch", PropertyID: "price", PropertyType: common.FieldTypeFloat},
+		{ObjectID: "switch", PropertyID: "buy_time", PropertyType: common.FieldTypeTime},
+		{ObjectID: "switch", PropertyID: "size", PropertyType: common.FieldTypeInt},
+		{ObjectID: "router", PropertyID: "size", PropertyType: common.FieldTypeSingleChar},
+		{ObjectID: "router", PropertyID: "online", PropertyType: common.FieldTypeBool},
+	}
+	mapping := newFieldMapping(attrs)
+
+	require.Equal(t, "long", mapping.properties["bk_cpu"]["type"])
+	require.Equal(t, "double", mapping.properties["price"]["type"])
+	require.Equal(t, "boolean", mapping.properties["online"]["type"])
+	require.Equal(t, "date", mapping.properties["buy_time"]["type"])
+	require.Equal(t, esDateFormat, mapping.properties["buy_time"]["format"])
+	require.Equal(t, "date", mapping.properties[common.CreateTimeField]["type"])
+	// strings and the fields of different types are mapped dynamically
+	require.NotContains(t, mapping.properties, "bk_host_name")
+	require.NotContains(t, mapping.properties, "size")
+	require.True(t, mapping.dateFields["buy_time"])
+	require.True(t, mapping.dateFields[common.LastTimeField])
+
+	body := mapping.indexBody(rebuildAlias)
+	require.Len(t, body["mappings"], len(indexTypes))
+	require.Contains(t, body["aliases"], rebuildAlias)
+
+	added := newFieldMapping(append(attrs, metadata.Attribute{ObjectID: "router", PropertyID: "port", PropertyType: common.FieldTypeInt}))
+	require.Equal(t, []string{"port"}, keys(added.newFields(mapping)))
+}
+
+func TestNormalizeDoc(t *testing.T) {
+	mapping := newFieldMapping(nil)
+	local := time.Date(2020, 6, 1, 10, 30, 0, 0, time.Local)
+
+	doc := mapping.normalizeDoc(map[string]interface{}{
+		"_id":                  "5ed4b3",
+		common.CreateTimeField: local,
+		common.LastTimeField:   "2020-06-01 10:30:00",
+		"bk_host_innerip":      "2020-06-01 10:30:00",
+	})
+	require.NotContains(t, doc, "_id")
+	require.Equal(t, local.Format(time.RFC3339Nano), doc[common.CreateTimeField])
+	require.Equal(t, local.Format(time.RFC3339Nano), doc[common.LastTimeField])
+	require.Equal(t, "2020-06-01 10:30:00", doc["bk_host_innerip"])
+}
+
+func TestEventOperations(t *testing.T) {
+	mapping := newFieldMapping(nil)
+
+	create := &metadata.DistInst{EventInst: metadata.EventInst{
+		EventType: metadata.EventTypeInstData,
+		Action:    metadata.EventActionCreate,
+		ObjType:   "switch",
+		Data: []metadata.EventData{{CurData: map[string]interface{}{
+			common.BKInstIDField: float64(12), common.BKObjIDField: "switch", "_id": "5ed4b3",
+		}}},
+	}}
+	ops, err := mapping.eventOperations(create)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	require.Equal(t, common.BKTableNameBaseInst, ops[0].typ)
+	require.Equal(t, "12", ops[0].id)
+	require.False(t, ops[0].delete)
+	require.NotContains(t, ops[0].doc, "_id")
+
+	remove := &metadata.DistInst{EventInst: metadata.EventInst{
+		EventType: metadata.EventTypeInstData,
+		Action:    metadata.EventActionDelete,
+		ObjType:   common.BKInnerObjIDHost,
+		Data:      []metadata.EventData{{PreData: map[string]interface{}{common.BKHostIDField: float64(3)}}},
+	}}
+	ops, err = mapping.eventOperations(remove)
+	require.NoError(t, err)
+	require.Equal(t, []docOperation{{typ: common.BKTableNameBaseHost, id: "3", delete: true}}, ops)
+
+	// sets are not searched, and the relation events have no document
+	set := &metadata.DistInst{EventInst: metadata.EventInst{
+		EventType: metadata.EventTypeInstData,
+		Action:    metadata.EventActionCreate,
+		ObjType:   common.BKInnerObjIDSet,
+		Data:      []metadata.EventData{{CurData: map[string]interface{}{common.BKSetIDField: float64(5)}}},
+	}}
+	ops, err = mapping.eventOperations(set)
+	require.NoError(t, err)
+	require.Empty(t, ops)
+
+	relation := &metadata.DistInst{EventInst: metadata.EventInst{EventType: metadata.EventTypeRelation, ObjType: "moduletransfer"}}
+	ops, err = mapping.eventOperations(relation)
+	require.NoError(t, err)
+	require.Empty(t, ops)
+
+	invalid := &metadata.DistInst{EventInst: metadata.EventInst{
+		EventType: metadata.EventTypeInstData,
+		Action:    metadata.EventActionUpdate,
+		ObjType:   common.BKInnerObjIDApp,
+		Data:      []metadata.EventData{{CurData: map[string]interface{}{common.BKAppNameField: "demo"}}},
+	}}
+	_, err = mapping.eventOperations(invalid)
+	require.Error(t, err)
+}
+
+func keys(fields map[string]map[string]interface{}) []string {
+	result := make([]string, 0, len(fields))
+	for field := range fields {
+		result = append(result, field)
+	}
+	return result
+}
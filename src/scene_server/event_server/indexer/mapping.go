@@ -0,0 +1,270 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package indexer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// esDateFormat accepts the formats the time values are saved or marshaled in
+const esDateFormat = "yyyy-MM-dd HH:mm:ss||yyyy-MM-dd||strict_date_optional_time||epoch_millis"
+
+// localTimeLayout is the layout metadata.Time is marshaled in, which is the local time without zone
+const localTimeLayout = "2006-01-02 15:04:05"
+
+// indexTypes are the mongo tables indexed, the es type of a document is the table name and
+// the document id is the instance id, which is what the full text search api searches.
+var indexTypes = []string{
+	common.BKTableNameBaseInst,
+	common.BKTableNameBaseHost,
+	common.BKTableNameObjDes,
+	common.BKTableNameBaseApp,
+}
+
+// builtinDateFields are the date fields that all the documents have but are not model attributes
+var builtinDateFields = []string{common.CreateTimeField, common.LastTimeField}
+
+// docIDField returns the field which is used as the document id of the type
+func docIDField(typ string) string {
+	switch typ {
+	case common.BKTableNameBaseHost:
+		return common.BKHostIDField
+	case common.BKTableNameBaseApp:
+		return common.BKAppIDField
+	case common.BKTableNameObjDes:
+		return common.BKFieldID
+	default:
+		return common.BKInstIDField
+	}
+}
+
+// esFieldType returns the es field type of the attribute type, empty if the field is left to
+// the dynamic mapping, which is text with a keyword sub field for the strings.
+func esFieldType(propertyType string) string {
+	switch propertyType {
+	case common.FieldTypeInt:
+		return "long"
+	case common.FieldTypeFloat:
+		return "double"
+	case common.FieldTypeDate, common.FieldTypeTime:
+		return "date"
+	case common.FieldTypeBool:
+		return "boolean"
+	default:
+		return ""
+	}
+}
+
+// fieldMapping is the typed es mapping of the model attributes
+type fieldMapping struct {
+	// properties is the es property of the typed fields
+	properties map[string]map[string]interface{}
+	// dateFields are the fields mapped as date
+	dateFields map[string]bool
+}
+
+// newFieldMapping builds the mapping of the attributes, all the types in an index share the same
+// mapping of a field, so a field is left to the dynamic mapping if the attributes of it have
+// different types in different models.
+func newFieldMapping(attrs []metadata.Attribute) *fieldMapping {
+	fieldTypes := make(map[string]string)
+	conflicts := make(map[string]bool)
+	for _, field := range builtinDateFields {
+		fieldTypes[field] = "date"
+	}
+	for _, attr := range attrs {
+		esType := esFieldType(attr.PropertyType)
+		if conflicts[attr.PropertyID] {
+			continue
+		}
+		if exist, ok := fieldTypes[attr.PropertyID]; ok && exist != esType {
+			blog.V(3).Infof("attribute %s of object %s has different types in the models, use dynamic mapping", attr.PropertyID, attr.ObjectID)
+			delete(fieldTypes, attr.PropertyID)
+			conflicts[attr.PropertyID] = true
+			continue
+		}
+		fieldTypes[attr.PropertyID] = esType
+	}
+
+	mapping := &fieldMapping{
+		properties: make(map[string]map[string]interface{}),
+		dateFields: make(map[string]bool),
+	}
+	for field, esType := range fieldTypes {
+		if esType == "" {
+			continue
+		}
+		property := map[string]interface{}{"type": esType}
+		switch esType {
+		case "date":
+			property["format"] = esDateFormat
+			property["ignore_malformed"] = true
+			mapping.dateFields[field] = true
+		case "long", "double":
+			property["ignore_malformed"] = true
+		}
+		mapping.properties[field] = property
+	}
+	return mapping
+}
+
+// typeMapping returns the mapping of an index type, the dates are not detected from the strings,
+// so that a text field is never mapped as a date by the first document.
+func typeMapping(fields map[string]map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"date_detection": false,
+		"properties":     fields,
+	}
+}
+
+// indexBody returns the body to create the index with
+func (m *fieldMapping) indexBody(aliases ...string) map[string]interface{} {
+	mappings := make(map[string]interface{})
+	for _, typ := range indexTypes {
+		mappings[typ] = typeMapping(m.properties)
+	}
+	aliasBody := make(map[string]interface{})
+	for _, alias := range aliases {
+		aliasBody[alias] = map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"mappings": mappings,
+		"aliases":  aliasBody,
+	}
+}
+
+// newFields returns the fields of m that are not in the previous mapping
+func (m *fieldMapping) newFields(previous *fieldMapping) map[string]map[string]interface{} {
+	fields := make(map[string]map[string]interface{})
+	for field, property := range m.properties {
+		if previous != nil {
+			if _, exist := previous.properties[field]; exist {
+				continue
+			}
+		}
+		fields[field] = property
+	}
+	return fields
+}
+
+// normalizeDoc returns the document to index, the mongo _id is removed and the date fields are
+// converted to the time with zone, so that the times from mongo and from the events are the same.
+func (m *fieldMapping) normalizeDoc(data map[string]interface{}) map[string]interface{} {
+	doc := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if key == "_id" {
+			continue
+		}
+		if m.dateFields[key] {
+			value = normalizeTime(value)
+		}
+		doc[key] = value
+	}
+	return doc
+}
+
+func normalizeTime(value interface{}) interface{} {
+	switch t := value.(type) {
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case *time.Time:
+		if t == nil {
+			return nil
+		}
+		return t.Format(time.RFC3339Nano)
+	case metadata.Time:
+		return t.Time.Format(time.RFC3339Nano)
+	case string:
+		if parsed, err := time.ParseInLocation(localTimeLayout, t, time.Local); err == nil {
+			return parsed.Format(time.RFC3339Nano)
+		}
+	}
+	return value
+}
+
+// docOperation is an index or a delete of a document
+type docOperation struct {
+	typ    string
+	id     string
+	delete bool
+	doc    map[string]interface{}
+}
+
+// eventOperations returns the document operations of an event, the events not indexed are ignored
+func (m *fieldMapping) eventOperations(event *metadata.DistInst) ([]docOperation, error) {
+	if event.EventType != metadata.EventTypeInstData {
+		return nil, nil
+	}
+
+	typ := common.GetInstTableName(event.ObjType)
+	if !isIndexType(typ) {
+		return nil, nil
+	}
+
+	ops := make([]docOperation, 0, len(event.Data))
+	for _, data := range event.Data {
+		isDelete := event.Action == metadata.EventActionDelete
+		raw := data.CurData
+		if isDelete {
+			raw = data.PreData
+		}
+		doc, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("event %d data is not an object", event.ID)
+		}
+		id, err := docID(typ, doc)
+		if err != nil {
+			return nil, fmt.Errorf("event %d data has no valid id, err: %v", event.ID, err)
+		}
+
+		op := docOperation{typ: typ, id: id, delete: isDelete}
+		if !isDelete {
+			op.doc = m.normalizeDoc(doc)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// docID returns the document id of the data
+func docID(typ string, data map[string]interface{}) (string, error) {
+	id, err := util.GetInt64ByInterface(data[docIDField(typ)])
+	if err != nil {
+		return "", err
+	}
+	if id <= 0 {
+		return "", fmt.Errorf("invalid %s: %v", docIDField(typ), data[docIDField(typ)])
+	}
+	return fmt.Sprint(id), nil
+}
+
+func isIndexType(typ string) bool {
+	for _, t := range indexTypes {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// isManagedIndex returns if the index is one of the indices built by the indexer
+func isManagedIndex(index string) bool {
+	return strings.HasPrefix(index, common.CMDBINDEX+"_") && index != rebuildAlias
+}
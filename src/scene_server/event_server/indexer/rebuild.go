@@ -0,0 +1,287 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/util"
+
+	"github.com/olivere/elastic"
+)
+
+// RebuildResult is the result of rebuilding the index
+type RebuildResult struct {
+	// Index is the index built
+	Index string
+	// Counts is how many documents of each type are indexed
+	Counts map[string]int64
+	// Failed is how many documents failed to index
+	Failed int64
+	// Replaced are the indices replaced by the index built
+	Replaced []string
+}
+
+// EnsureIndex builds the index from mongo if it's not built by the indexer yet, which is either
+// the index does not exist or it's the index synchronized by mongo-connector before.
+func (idx *Indexer) EnsureIndex(ctx context.Context) error {
+	state, err := idx.indexState(ctx)
+	if err != nil {
+		return err
+	}
+	if len(state.current) > 0 {
+		return nil
+	}
+	if len(state.rebuilding) > 0 {
+		blog.Infof("full text index %v is being built, skip building it", state.rebuilding)
+		return nil
+	}
+
+	if state.legacy {
+		blog.Infof("full text index %s is not built by the indexer, rebuild it", common.CMDBINDEX)
+	} else {
+		blog.Infof("full text index %s does not exist, build it", common.CMDBINDEX)
+	}
+	result, err := idx.Rebuild(ctx)
+	if err != nil {
+		return err
+	}
+	blog.Infof("full text index %s built, documents: %v, failed: %d", result.Index, result.Counts, result.Failed)
+	return nil
+}
+
+// Rebuild builds a new index from mongo and switches the searched index to it. the events are
+// written to the new index while it's being built, and the documents loaded from mongo are only
+// created if they are not written by the events, which are newer.
+func (idx *Indexer) Rebuild(ctx context.Context) (*RebuildResult, error) {
+	state, err := idx.indexState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.rebuilding) > 0 {
+		return nil, fmt.Errorf("full text index %v is being rebuilt", state.rebuilding)
+	}
+
+	mapping, err := idx.loadMapping(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s_%s", common.CMDBINDEX, time.Now().Format("20060102150405"))
+	if _, err := idx.client.CreateIndex(name).BodyJson(mapping.indexBody(rebuildAlias)).Do(ctx); err != nil {
+		blog.Errorf("create full text index %s failed, err: %v", name, err)
+		return nil, err
+	}
+	idx.setMapping(mapping)
+
+	result := &RebuildResult{Index: name, Counts: make(map[string]int64)}
+	for _, typ := range indexTypes {
+		if err := idx.loadType(ctx, name, typ, mapping, result); err != nil {
+			idx.dropIndex(name)
+			return nil, err
+		}
+	}
+
+	if _, err := idx.client.Refresh(name).Do(ctx); err != nil {
+		idx.dropIndex(name)
+		return nil, err
+	}
+
+	if err := idx.switchIndex(ctx, name); err != nil {
+		idx.dropIndex(name)
+		return nil, err
+	}
+
+	result.Replaced = state.current
+	if len(state.current) > 0 {
+		if _, err := idx.client.DeleteIndex(state.current...).Do(ctx); err != nil {
+			blog.Errorf("delete replaced full text index %v failed, err: %v", state.current, err)
+		}
+	}
+	return result, nil
+}
+
+// ClearRebuild deletes the indices left by the rebuilding which is interrupted
+func (idx *Indexer) ClearRebuild(ctx context.Context) ([]string, error) {
+	state, err := idx.indexState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.rebuilding) == 0 {
+		return nil, nil
+	}
+	if _, err := idx.client.DeleteIndex(state.rebuilding...).Do(ctx); err != nil {
+		return nil, err
+	}
+	return state.rebuilding, nil
+}
+
+// loadType indexes all the documents of a type from mongo, ordered by the document id
+func (idx *Indexer) loadType(ctx context.Context, index, typ string, mapping *fieldMapping, result *RebuildResult) error {
+	idField := docIDField(typ)
+	var lastID int64
+	for {
+		docs := make([]map[string]interface{}, 0)
+		cond := map[string]interface{}{idField: map[string]interface{}{common.BKDBGT: lastID}}
+		err := idx.db.Table(typ).Find(cond).Sort(idField).Limit(bulkSize).All(ctx, &docs)
+		if err != nil {
+			blog.Errorf("find %s to build full text index failed, err: %v", typ, err)
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+
+		bulk := idx.client.Bulk()
+		for _, doc := range docs {
+			id, err := docID(typ, doc)
+			if err != nil {
+				blog.Warnf("%s document has no valid %s, skip it, err: %v", typ, idField, err)
+				continue
+			}
+			bulk.Add(elastic.NewBulkIndexRequest().Index(index).Type(typ).Id(id).OpType("create").Doc(mapping.normalizeDoc(doc)))
+		}
+		if bulk.NumberOfActions() > 0 {
+			resp, err := bulk.Do(ctx)
+			if err != nil {
+				blog.Errorf("index %d %s documents failed, err: %v", bulk.NumberOfActions(), typ, err)
+				return err
+			}
+			failed := logFailedItems(resp)
+			result.Failed += int64(failed)
+			result.Counts[typ] += int64(len(resp.Items) - failed)
+		}
+
+		lastID, err = util.GetInt64ByInterface(docs[len(docs)-1][idField])
+		if err != nil {
+			return fmt.Errorf("invalid %s %s: %v", typ, idField, docs[len(docs)-1][idField])
+		}
+	}
+}
+
+// switchIndex points the searched alias to the index built, the index created by mongo-connector
+// is deleted first, since an alias can not have the same name with an index.
+func (idx *Indexer) switchIndex(ctx context.Context, index string) error {
+	state, err := idx.indexState(ctx)
+	if err != nil {
+		return err
+	}
+	if state.legacy {
+		if _, err := idx.client.DeleteIndex(common.CMDBINDEX).Do(ctx); err != nil {
+			blog.Errorf("delete full text index %s created by mongo-connector failed, err: %v", common.CMDBINDEX, err)
+			return err
+		}
+	}
+
+	alias := idx.client.Alias().Action(
+		elastic.NewAliasAddAction(common.CMDBINDEX).Index(index),
+		elastic.NewAliasRemoveAction(rebuildAlias).Index(index),
+	)
+	for _, current := range state.current {
+		alias.Action(elastic.NewAliasRemoveAction(common.CMDBINDEX).Index(current))
+	}
+	if _, err := alias.Do(ctx); err != nil {
+		blog.Errorf("switch full text index to %s failed, err: %v", index, err)
+		return err
+	}
+	return nil
+}
+
+// dropIndex deletes the index failed to build
+func (idx *Indexer) dropIndex(index string) {
+	if _, err := idx.client.DeleteIndex(index).Do(context.Background()); err != nil {
+		blog.Errorf("delete full text index %s failed to build failed, err: %v", index, err)
+	}
+}
+
+// SyncModels puts the mapping of the attributes added to the indices, and indexes the models,
+// which are not sent as events.
+func (idx *Indexer) SyncModels(ctx context.Context) error {
+	indices, err := idx.writeIndices(ctx)
+	if err != nil {
+		return err
+	}
+	if len(indices) == 0 {
+		return nil
+	}
+
+	mapping, err := idx.loadMapping(ctx)
+	if err != nil {
+		return err
+	}
+	idx.lock.RLock()
+	previous := idx.mapping
+	idx.lock.RUnlock()
+	idx.putFields(ctx, indices, mapping.newFields(previous))
+	idx.setMapping(mapping)
+
+	models := make([]map[string]interface{}, 0)
+	if err := idx.db.Table(common.BKTableNameObjDes).Find(nil).All(ctx, &models); err != nil {
+		blog.Errorf("find models to index failed, err: %v", err)
+		return err
+	}
+
+	typ := common.BKTableNameObjDes
+	ids := make([]string, 0, len(models))
+	bulk := idx.client.Bulk()
+	for _, model := range models {
+		id, err := docID(typ, model)
+		if err != nil {
+			blog.Warnf("model %v has no valid id, skip it, err: %v", model[common.BKObjIDField], err)
+			continue
+		}
+		ids = append(ids, id)
+		doc := mapping.normalizeDoc(model)
+		for _, index := range indices {
+			bulk.Add(elastic.NewBulkIndexRequest().Index(index).Type(typ).Id(id).Doc(doc))
+		}
+	}
+	if bulk.NumberOfActions() > 0 {
+		resp, err := bulk.Do(ctx)
+		if err != nil {
+			return err
+		}
+		logFailedItems(resp)
+	}
+
+	// delete the models that are deleted
+	query := elastic.NewBoolQuery().MustNot(elastic.NewIdsQuery(typ).Ids(ids...))
+	_, err = idx.client.DeleteByQuery(indices...).Type(typ).Query(query).Conflicts("proceed").Do(ctx)
+	return err
+}
+
+// putFields adds the mapping of the fields to the indices, the fields already mapped as another
+// type are kept as they are.
+func (idx *Indexer) putFields(ctx context.Context, indices []string, fields map[string]map[string]interface{}) {
+	if len(fields) == 0 {
+		return
+	}
+	for _, typ := range indexTypes {
+		_, err := idx.client.PutMapping().Index(indices...).Type(typ).BodyJson(typeMapping(fields)).Do(ctx)
+		if err == nil {
+			continue
+		}
+
+		// put the fields one by one to skip the conflict ones
+		for field, property := range fields {
+			one := map[string]map[string]interface{}{field: property}
+			if _, err := idx.client.PutMapping().Index(indices...).Type(typ).BodyJson(typeMapping(one)).Do(ctx); err != nil {
+				blog.Warnf("put full text index mapping of %s field %s failed, err: %v", typ, field, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/distribution"
+
+	"github.com/emicklei/go-restful"
+)
+
+// ListDeadLetters lists the events of the subscription which failed to be delivered after all the retries
+func (s *Service) ListDeadLetters(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	rid := util.GetHTTPCCRequestID(header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	ownerID := util.GetOwnerID(header)
+
+	id, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "subscribeID")})
+		return
+	}
+
+	data := metadata.ParamDeadLetterSearch{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&data); err != nil {
+		blog.Errorf("search dead letters, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	condition := data.Condition
+	if condition == nil {
+		condition = make(map[string]interface{})
+	}
+	condition[common.BKSubscriptionIDField] = id
+	condition = util.SetModOwner(condition, ownerID)
+
+	limit := data.Page.Limit
+	if limit <= 0 {
+		limit = common.BKNoLimit
+	}
+	sortOption := data.Page.Sort
+	if sortOption == "" {
+		sortOption = common.BKFieldID
+	}
+
+	count, err := s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("count dead letters failed, condition: %v, err: %v, rid: %s", condition, err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterSelectFailed)})
+		return
+	}
+
+	letters := make([]metadata.DeadLetter, 0)
+	err = s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Sort(sortOption).
+		Start(uint64(data.Page.Start)).Limit(uint64(limit)).All(s.ctx, &letters)
+	if err != nil {
+		blog.Errorf("search dead letters failed, condition: %v, err: %v, rid: %s", condition, err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspDeadLetterSearch{Count: count, Info: letters}))
+}
+
+// ReplayDeadLetters sends the dead letters to the subscriber once again in the order they are distributed,
+// the dead letters delivered successfully are removed.
+func (s *Service) ReplayDeadLetters(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	rid := util.GetHTTPCCRequestID(header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	ownerID := util.GetOwnerID(header)
+
+	id, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "subscribeID")})
+		return
+	}
+
+	data := metadata.ParamDeadLetterOperate{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&data); err != nil {
+		blog.Errorf("replay dead letters, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	sub := metadata.Subscription{}
+	subCond := util.NewMapBuilder(common.BKSubscriptionIDField, id, common.BKOwnerIDField, ownerID).Build()
+	if err := s.db.Table(common.BKTableNameSubscription).Find(subCond).One(s.ctx, &sub); err != nil {
+		blog.Errorf("replay dead letters, but get subscription %d failed, err: %v, rid: %s", id, err, rid)
+		if s.db.IsNotFoundError(err) {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+			return
+		}
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeSelectFailed)})
+		return
+	}
+
+	letters := make([]metadata.DeadLetter, 0)
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Find(s.deadLetterCondition(id, ownerID, data.IDs)).
+		Sort(common.BKFieldID).All(s.ctx, &letters); err != nil {
+		blog.Errorf("replay dead letters, but search dead letters failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+		return
+	}
+
+	result := metadata.RspDeadLetterReplay{
+		Succeeded: make([]int64, 0),
+		Failed:    make([]metadata.DeadLetterReplayErr, 0),
+	}
+	dh := distribution.NewDistHandler(s.ctx, s.cache, s.db)
+	for index := range letters {
+		if err := dh.ReplayDeadLetter(&sub, &letters[index]); err != nil {
+			blog.Warnf("replay dead letter %d failed, err: %v, rid: %s", letters[index].ID, err, rid)
+			result.Failed = append(result.Failed, metadata.DeadLetterReplayErr{ID: letters[index].ID, Error: err.Error()})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, letters[index].ID)
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// PurgeDeadLetters removes the dead letters of the subscription
+func (s *Service) PurgeDeadLetters(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	rid := util.GetHTTPCCRequestID(header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	ownerID := util.GetOwnerID(header)
+
+	id, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "subscribeID")})
+		return
+	}
+
+	data := metadata.ParamDeadLetterOperate{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&data); err != nil {
+		blog.Errorf("purge dead letters, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, s.deadLetterCondition(id, ownerID, data.IDs)); err != nil {
+		blog.Errorf("purge dead letters of subscription %d failed, err: %v, rid: %s", id, err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterPurgeFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+func (s *Service) deadLetterCondition(subscriptionID int64, ownerID string, ids []int64) map[string]interface{} {
+	condition := util.NewMapBuilder(common.BKSubscriptionIDField, subscriptionID, common.BKOwnerIDField, ownerID).Build()
+	if len(ids) > 0 {
+		condition[common.BKFieldID] = map[string]interface{}{common.BKDBIN: ids}
+	}
+	return condition
+}
@@ -73,9 +73,15 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.DELETE("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.UnSubscribe))
 	api.Route(api.PUT("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.UpdateSubscription))
 
+	api.Route(api.POST("/subscribe/deadletter/search/{ownerID}/{appID}/{subscribeID}").To(s.ListDeadLetters))
+	api.Route(api.POST("/subscribe/deadletter/replay/{ownerID}/{appID}/{subscribeID}").To(s.ReplayDeadLetters))
+	api.Route(api.POST("/subscribe/deadletter/purge/{ownerID}/{appID}/{subscribeID}").To(s.PurgeDeadLetters))
+
 	api.Route(api.POST("/subscribe/ping").To(s.Ping))
 	api.Route(api.POST("/subscribe/telnet").To(s.Telnet))
 
+	api.Route(api.POST("/watch").To(s.WatchEvents))
+
 	container.Add(api)
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
@@ -13,10 +13,8 @@
 package service
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net"
 	"net/http"
 	"sort"
@@ -27,7 +25,9 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/distribution"
 	"configcenter/src/scene_server/event_server/types"
 
 	"github.com/emicklei/go-restful"
@@ -70,6 +70,10 @@ func (s *Service) Subscribe(req *restful.Request, resp *restful.Response) {
 	if sub.ConfirmMode == metadata.ConfirmModeHTTPStatus && sub.ConfirmPattern == "" {
 		sub.ConfirmPattern = strconv.FormatInt(http.StatusOK, 10)
 	}
+	if field, ok := validateCallbackOptions(sub); !ok {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, field)})
+		return
+	}
 	now := metadata.Now()
 	sub.LastTime = now
 	sub.OwnerID = ownerID
@@ -219,6 +223,10 @@ func (s *Service) UnSubscribe(req *restful.Request, resp *restful.Response) {
 		types.EventCacheDistQueuePrefix+subID,
 		types.EventCacheDistDonePrefix+subID)
 
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, condition); err != nil {
+		blog.Errorf("delete dead letters of subscription %d failed, err: %v, rid: %s", id, err, rid)
+	}
+
 	msg, _ := json.Marshal(&sub)
 	s.cache.Publish(types.EventCacheProcessChannel, "delete"+string(msg))
 
@@ -282,6 +290,10 @@ func (s *Service) UpdateSubscription(req *restful.Request, resp *restful.Respons
 	if sub.ConfirmMode == metadata.ConfirmModeHTTPStatus && sub.ConfirmPattern == "" {
 		sub.ConfirmPattern = strconv.FormatInt(http.StatusOK, 10)
 	}
+	if field, ok := validateCallbackOptions(sub); !ok {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, field)})
+		return
+	}
 	sub.Operator = util.GetUser(req.Request.Header)
 	if err = s.updateSubscription(header, id, ownerID, sub); err != nil {
 		result := &metadata.RespError{
@@ -335,6 +347,13 @@ func (s *Service) updateSubscription(header http.Header, id int64, ownerID strin
 	}
 
 	sub.SubscriptionID = oldSub.SubscriptionID
+	// the secret is not returned when list subscriptions, keep it if it's not changed
+	if sub.Secret == "" {
+		sub.Secret = oldSub.Secret
+	}
+	if sub.TLS != nil && oldSub.TLS != nil && sub.TLS.Password == "" {
+		sub.TLS.Password = oldSub.TLS.Password
+	}
 	if sub.TimeOutSeconds <= 0 {
 		sub.TimeOutSeconds = 10
 	}
@@ -446,6 +465,11 @@ func (s *Service) ListSubscriptions(req *restful.Request, resp *restful.Response
 			Total:   total,
 			Failure: failure,
 		}
+		// never return the secrets of the subscription
+		results[index].Secret = ""
+		if results[index].TLS != nil {
+			results[index].TLS.Password = ""
+		}
 	}
 
 	info := make(map[string]interface{})
@@ -473,12 +497,32 @@ func (s *Service) Ping(req *restful.Request, resp *restful.Response) {
 		return
 	}
 
-	callbackUrl := data.CallbackUrl
-	callbackBody := data.Data
+	receiver := &metadata.Subscription{
+		CallbackURL: data.CallbackUrl,
+		Secret:      data.Secret,
+		Headers:     data.Headers,
+		TLS:         data.TLS,
+	}
+	if data.SubscriptionID > 0 {
+		// test with the options of the subscription
+		condition := util.NewMapBuilder(common.BKSubscriptionIDField, data.SubscriptionID, common.BKOwnerIDField, util.GetOwnerID(header)).Build()
+		if err := s.db.Table(common.BKTableNameSubscription).Find(condition).One(s.ctx, receiver); err != nil {
+			blog.Errorf("ping subscription failed, get subscription %d failed, err: %v, rid: %s", data.SubscriptionID, err, rid)
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeSelectFailed)})
+			return
+		}
+	}
+	if field, ok := validateCallbackOptions(receiver); !ok {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, field)})
+		return
+	}
+	if data.CheckSignature && receiver.Secret == "" {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, "secret")})
+		return
+	}
 
-	blog.Infof("requesting callback url: %s, data: %s, rid: %s", callbackUrl, callbackBody, rid)
-	callbackReq, _ := http.NewRequest(http.MethodPost, callbackUrl, bytes.NewBufferString(callbackBody))
-	callbackResp, err := http.DefaultClient.Do(callbackReq)
+	blog.Infof("requesting callback url: %s, data: %s, rid: %s", receiver.CallbackURL, data.Data, rid)
+	result, err := distribution.PingCallback(receiver, data.Data, data.CheckSignature)
 	if err != nil {
 		blog.Errorf("test distribute failed, do http request failed, err: %v, rid: %s", err, rid)
 		result := &metadata.RespError{
@@ -487,17 +531,51 @@ func (s *Service) Ping(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusBadRequest, result)
 		return
 	}
-	defer callbackResp.Body.Close()
 
-	callbackRespBody, err := ioutil.ReadAll(callbackResp.Body)
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// validateCallbackOptions validates the retry, header, tls, filter and batch options of the subscription,
+// returns the invalid field name if it's invalid.
+func validateCallbackOptions(sub *metadata.Subscription) (string, bool) {
+	if sub.RetryPolicy != nil {
+		if field, ok := sub.RetryPolicy.Validate(); !ok {
+			return field, false
+		}
+	}
+	if sub.Batch != nil {
+		if field, ok := sub.Batch.Validate(); !ok {
+			return field, false
+		}
+	}
+	rule, key, err := sub.GetFilter()
 	if err != nil {
-		blog.Errorf("test distribute failed, read response body failed, err:%v, rid: %s", err, rid)
+		blog.Errorf("invalid subscription filter, key: %s, err: %v", key, err)
+		return "filter", false
 	}
-	result := metadata.RspSubscriptionTestCallback{}
-	result.HttpStatus = callbackResp.StatusCode
-	result.ResponseBody = string(callbackRespBody)
-
-	resp.WriteEntity(metadata.NewSuccessResp(result))
+	if rule != nil && !validFilterFields(rule) {
+		return "filter", false
+	}
+	for _, field := range sub.ChangedFields {
+		if strings.TrimSpace(field) == "" {
+			return "changed_fields", false
+		}
+	}
+	for key := range sub.Headers {
+		if strings.TrimSpace(key) == "" {
+			return "headers", false
+		}
+	}
+	if sub.TLS != nil {
+		if sub.TLS.CertFile == "" && sub.TLS.KeyFile != "" || sub.TLS.CertFile != "" && sub.TLS.KeyFile == "" {
+			return "tls.cert_file", false
+		}
+		if _, err := distribution.NewCallbackTLSConfig(sub.TLS); err != nil {
+			blog.Errorf("invalid callback tls options, err: %v", err)
+			return "tls", false
+		}
+	}
+	return "", true
 }
 
 func (s *Service) Telnet(req *restful.Request, resp *restful.Response) {
@@ -537,3 +615,20 @@ func (s *Service) Telnet(req *restful.Request, resp *restful.Response) {
 
 	resp.WriteEntity(metadata.NewSuccessResp(nil))
 }
+
+// validFilterFields checks that the rules are all applied on the cur_data or pre_data of the event
+func validFilterFields(rule querybuilder.Rule) bool {
+	switch r := rule.(type) {
+	case querybuilder.AtomRule:
+		return strings.HasPrefix(r.Field, "cur_data.") || strings.HasPrefix(r.Field, "pre_data.")
+	case querybuilder.CombinedRule:
+		for _, child := range r.Rules {
+			if !validFilterFields(child) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/distribution"
+
+	"github.com/emicklei/go-restful"
+)
+
+// WatchEvents returns the events after the cursor in the watch log, it waits until there are
+// matched events or timeout, so that the consumers can long poll the events and resume from
+// the last event they have seen.
+func (s *Service) WatchEvents(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	rid := util.GetHTTPCCRequestID(header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	ownerID := util.GetOwnerID(header)
+
+	opt := metadata.ParamEventWatch{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&opt); err != nil {
+		blog.Errorf("watch events, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if field, ok := opt.Validate(); !ok {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, field)})
+		return
+	}
+
+	result, err := distribution.WatchEvents(req.Request.Context(), s.db, ownerID, opt)
+	if err != nil {
+		if err == distribution.ErrCursorExpired {
+			blog.Errorf("watch events, but cursor %d expired, rid: %s", opt.Cursor, rid)
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventWatchCursorExpired)})
+			return
+		}
+		blog.Errorf("watch events failed, opt: %+v, err: %v, rid: %s", opt, err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventWatchFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
@@ -0,0 +1,82 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"configcenter/src/common/metadata"
+)
+
+// FileConfig is the config of the file sink
+type FileConfig struct {
+	// Path is the file the events are appended to
+	Path string
+}
+
+// file appends the events to a local file in ndjson format, one event per line.
+type file struct {
+	lock sync.Mutex
+	f    *os.File
+}
+
+// NewFile creates a file sink, the file is created if it does not exist
+func NewFile(conf FileConfig) (Sink, error) {
+	if conf.Path == "" {
+		return nil, errors.New("event file sink path is not set")
+	}
+	if err := os.MkdirAll(filepath.Dir(conf.Path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(conf.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &file{f: f}, nil
+}
+
+func (s *file) Name() string {
+	return FileSink
+}
+
+func (s *file) Write(ctx context.Context, events []*metadata.DistInst) error {
+	// encode all the events before writing, so that a batch is never partially written
+	// because of an invalid event
+	buf := make([]byte, 0)
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, err := s.f.Write(buf); err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+func (s *file) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.f.Close()
+}
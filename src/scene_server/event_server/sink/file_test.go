@@ -0,0 +1,107 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event_sink")
+	if err != nil {
+		t.Fatalf("create temp dir failed, err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "events", "events.log")
+	s, err := New(Config{Type: FileSink, File: FileConfig{Path: path}})
+	if err != nil {
+		t.Fatalf("new file sink failed, err: %v", err)
+	}
+
+	events := make([]*metadata.DistInst, 0)
+	for i := 1; i <= 3; i++ {
+		event := new(metadata.DistInst)
+		event.ID = int64(i)
+		event.DstbID = int64(i)
+		event.ObjType = "host"
+		event.Action = metadata.EventActionUpdate
+		events = append(events, event)
+	}
+	if err := s.Write(context.Background(), events[:2]); err != nil {
+		t.Fatalf("write events failed, err: %v", err)
+	}
+	if err := s.Write(context.Background(), events[2:]); err != nil {
+		t.Fatalf("write events failed, err: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("close file sink failed, err: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open sink file failed, err: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lines := 0
+	for scanner.Scan() {
+		event := new(metadata.DistInst)
+		if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+			t.Fatalf("line %d is not a json event, err: %v", lines, err)
+		}
+		lines++
+		if event.ID != int64(lines) || event.ObjType != "host" {
+			t.Fatalf("unexpected event at line %d: %+v", lines, event)
+		}
+	}
+	if lines != 3 {
+		t.Fatalf("expect 3 events, but got %d", lines)
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	if s, err := New(Config{}); s != nil || err != nil {
+		t.Fatalf("sink should be disabled without type, sink: %v, err: %v", s, err)
+	}
+	if _, err := New(Config{Type: "not_exist"}); err == nil {
+		t.Fatal("expect error for unknown sink type")
+	}
+	if _, err := New(Config{Type: KafkaSink}); err == nil {
+		t.Fatal("expect error for kafka sink without brokers")
+	}
+	if _, err := New(Config{Type: FileSink}); err == nil {
+		t.Fatal("expect error for file sink without path")
+	}
+}
+
+func TestParseConfigFromKV(t *testing.T) {
+	conf := ParseConfigFromKV("sink", map[string]string{
+		"sink.type":         KafkaSink,
+		"sink.kafkaBrokers": "127.0.0.1:9092, 127.0.0.2:9092,",
+		"sink.kafkaTopic":   "events",
+	})
+	if conf.Type != KafkaSink || conf.Kafka.Topic != "events" || len(conf.Kafka.Brokers) != 2 ||
+		conf.Kafka.Brokers[1] != "127.0.0.2:9092" {
+		t.Fatalf("unexpected sink config: %+v", conf)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"configcenter/src/common/metadata"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// KafkaConfig is the config of the kafka sink
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+	// User and Password are used for sasl plain authentication if set
+	User     string
+	Password string
+}
+
+// kafkaSink produces the events to a kafka topic, the events are keyed by the object type,
+// so that the events of an object type are in the same partition and keep in order.
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafka creates a kafka sink
+func NewKafka(conf KafkaConfig) (Sink, error) {
+	if len(conf.Brokers) == 0 {
+		return nil, errors.New("event kafka sink brokers are not set")
+	}
+	if conf.Topic == "" {
+		return nil, errors.New("event kafka sink topic is not set")
+	}
+
+	dialer := &kafka.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+	}
+	if conf.User != "" {
+		dialer.SASLMechanism = plain.Mechanism{Username: conf.User, Password: conf.Password}
+	}
+
+	writer := kafka.NewWriter(kafka.WriterConfig{
+		Brokers:  conf.Brokers,
+		Topic:    conf.Topic,
+		Dialer:   dialer,
+		Balancer: &kafka.Hash{},
+		// the events are retried by the event server, so that the order is kept
+		MaxAttempts:  1,
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: -1,
+	})
+	return &kafkaSink{writer: writer}, nil
+}
+
+func (s *kafkaSink) Name() string {
+	return KafkaSink
+}
+
+func (s *kafkaSink) Write(ctx context.Context, events []*metadata.DistInst) error {
+	msgs := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, kafka.Message{
+			Key:   []byte(partitionKey(event)),
+			Value: value,
+			Time:  event.ActionTime.Time,
+		})
+	}
+	return s.writer.WriteMessages(ctx, msgs...)
+}
+
+func (s *kafkaSink) Close() error {
+	return s.writer.Close()
+}
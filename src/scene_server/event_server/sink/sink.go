@@ -0,0 +1,86 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"configcenter/src/common/metadata"
+)
+
+const (
+	// KafkaSink streams the events to a kafka topic
+	KafkaSink = "kafka"
+	// FileSink appends the events to a local file, one json event per line
+	FileSink = "file"
+)
+
+// Sink streams all the events to a message bus besides the http callbacks of the subscriptions.
+// the events of the same object type are written in the order they happened.
+type Sink interface {
+	// Name returns the sink type
+	Name() string
+	// Write writes the events, the events are either all written or failed
+	Write(ctx context.Context, events []*metadata.DistInst) error
+	// Close flushes and closes the sink
+	Close() error
+}
+
+// Config is the config of the event sink, the sink is disabled if the type is empty.
+type Config struct {
+	Type  string
+	Kafka KafkaConfig
+	File  FileConfig
+}
+
+// ParseConfigFromKV parses the sink config from the config map
+func ParseConfigFromKV(prefix string, configMap map[string]string) Config {
+	conf := Config{
+		Type: configMap[prefix+".type"],
+		Kafka: KafkaConfig{
+			Topic:    configMap[prefix+".kafkaTopic"],
+			User:     configMap[prefix+".kafkaUser"],
+			Password: configMap[prefix+".kafkaPassword"],
+		},
+		File: FileConfig{
+			Path: configMap[prefix+".filePath"],
+		},
+	}
+	for _, broker := range strings.Split(configMap[prefix+".kafkaBrokers"], ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			conf.Kafka.Brokers = append(conf.Kafka.Brokers, broker)
+		}
+	}
+	return conf
+}
+
+// New creates the sink of the config, returns nil if the sink is disabled
+func New(conf Config) (Sink, error) {
+	switch conf.Type {
+	case "":
+		return nil, nil
+	case KafkaSink:
+		return NewKafka(conf.Kafka)
+	case FileSink:
+		return NewFile(conf.File)
+	default:
+		return nil, fmt.Errorf("unsupported event sink type: %s", conf.Type)
+	}
+}
+
+// partitionKey returns the key which the events are ordered by
+func partitionKey(event *metadata.DistInst) string {
+	return event.ObjType
+}
@@ -45,6 +45,16 @@ const (
 	EventCacheIdentInstPrefix = common.BKCacheKeyV3Prefix + "ident:inst_"
 )
 
+// EventSinkSubscriptionID is the subscription id the event sink is dispatched as, so that the sink
+// shares the dist queue and the done records of the subscriptions.
+const EventSinkSubscriptionID int64 = -1
+
+// EventIndexerSubscriptionID is the subscription id the full text search indexer is dispatched as
+const EventIndexerSubscriptionID int64 = -2
+
+// EventCacheIndexerLockKey is the lock key of building the full text search index
+const EventCacheIndexerLockKey = common.BKCacheKeyV3Prefix + "event:indexer_lock"
+
 // EventSubscriberCacheKey returns EventSubscriberCacheKey
 func EventSubscriberCacheKey(ownerID, eventType string) string {
 	return EventCacheSubscribeFormKey + ownerID + ":" + eventType
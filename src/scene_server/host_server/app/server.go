@@ -77,7 +77,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	}
 
 	blog.Info("host server auth config is: %+v", hostSrv.Config.Auth)
-	authorizer, err := auth.NewAuthorize(nil, hostSrv.Config.Auth, engine.Metric().Registry())
+	authorizer, err := auth.NewAuthorize(nil, hostSrv.Config.Auth, engine.CoreAPI, engine.Metric().Registry())
 	if err != nil {
 		blog.Errorf("new host authorizer failed, err: %+v", err)
 		return fmt.Errorf("new host authorizer failed, err: %+v", err)
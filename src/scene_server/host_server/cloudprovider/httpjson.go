@@ -0,0 +1,168 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+func init() {
+	Register(HTTPJson, newHTTPJson)
+}
+
+// httpJson pulls instances from a generic http json inventory service, which is usually
+// a thin adapter in front of a private cloud such as openstack. the service should offer:
+//
+//   GET {endpoint}/regions
+//       => {"regions": ["region-a", "region-b"]}
+//   GET {endpoint}/instances?region=region-a&offset=0&limit=100
+//       => {"total": 1, "instances": [{"instance_id": "i-1", "instance_name": "vm1",
+//           "private_ips": ["10.0.0.1"], "public_ips": [], "os_name": "centos 7",
+//           "attributes": {"bk_cpu": 8}}]}
+//
+// the secret id and key are sent with http basic auth if configured.
+type httpJson struct {
+	endpoint  string
+	secretID  string
+	secretKey string
+	client    *http.Client
+}
+
+type httpJsonRegions struct {
+	Regions []string `json:"regions"`
+}
+
+type httpJsonInstances struct {
+	Total     int64              `json:"total"`
+	Instances []httpJsonInstance `json:"instances"`
+}
+
+type httpJsonInstance struct {
+	InstanceID   string                 `json:"instance_id"`
+	InstanceName string                 `json:"instance_name"`
+	PrivateIPs   []string               `json:"private_ips"`
+	PublicIPs    []string               `json:"public_ips"`
+	OSName       string                 `json:"os_name"`
+	Attributes   map[string]interface{} `json:"attributes"`
+}
+
+func newHTTPJson(cred Credential) (Provider, error) {
+	if cred.Endpoint == "" {
+		return nil, errors.New("http json cloud provider endpoint is not set")
+	}
+	if _, err := url.ParseRequestURI(cred.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid http json cloud provider endpoint %s, err: %v", cred.Endpoint, err)
+	}
+
+	return &httpJson{
+		endpoint:  strings.TrimRight(cred.Endpoint, "/"),
+		secretID:  cred.SecretID,
+		secretKey: cred.SecretKey,
+		client:    &http.Client{Timeout: common.BKTencentCloudTimeOut * time.Second},
+	}, nil
+}
+
+func (h *httpJson) Name() string {
+	return HTTPJson
+}
+
+func (h *httpJson) ListRegions(ctx context.Context) ([]string, error) {
+	result := new(httpJsonRegions)
+	if err := h.get(ctx, "/regions", nil, result); err != nil {
+		return nil, err
+	}
+	return result.Regions, nil
+}
+
+func (h *httpJson) ListInstances(ctx context.Context, region string, page Page) (*InstancePage, error) {
+	params := url.Values{}
+	params.Set("region", region)
+	params.Set("offset", strconv.FormatInt(page.Offset, 10))
+	if page.Limit > 0 {
+		params.Set("limit", strconv.FormatInt(page.Limit, 10))
+	}
+
+	result := new(httpJsonInstances)
+	if err := h.get(ctx, "/instances", params, result); err != nil {
+		return nil, err
+	}
+
+	instPage := &InstancePage{Total: result.Total, Instances: make([]*Instance, 0, len(result.Instances))}
+	for _, obj := range result.Instances {
+		instPage.Instances = append(instPage.Instances, &Instance{
+			InstanceID:   obj.InstanceID,
+			InstanceName: obj.InstanceName,
+			Region:       region,
+			PrivateIPs:   obj.PrivateIPs,
+			PublicIPs:    obj.PublicIPs,
+			OSName:       obj.OSName,
+			Attributes:   obj.Attributes,
+		})
+	}
+	return instPage, nil
+}
+
+func (h *httpJson) ToHost(inst *Instance) mapstr.MapStr {
+	// the attributes reported by the inventory are already host attributes
+	return InstanceToHost(inst)
+}
+
+func (h *httpJson) get(ctx context.Context, path string, params url.Values, result interface{}) error {
+	rawURL := h.endpoint + path
+	if len(params) > 0 {
+		rawURL += "?" + params.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if h.secretID != "" || h.secretKey != "" {
+		req.SetBasicAuth(h.secretID, h.secretKey)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("AuthFailure, get %s returns status %d", path, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s returns status %d, body: %s", path, resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("decode %s response failed, err: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"configcenter/src/common"
+)
+
+func newInventoryStub(t *testing.T, total int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pwd, ok := r.BasicAuth()
+		if !ok || user != "id" || pwd != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/regions":
+			_ = json.NewEncoder(w).Encode(httpJsonRegions{Regions: []string{"region-a"}})
+		case "/instances":
+			if r.URL.Query().Get("region") != "region-a" {
+				t.Errorf("unexpected region %s", r.URL.Query().Get("region"))
+			}
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			result := httpJsonInstances{Total: int64(total)}
+			for i := offset; i < offset+limit && i < total; i++ {
+				result.Instances = append(result.Instances, httpJsonInstance{
+					InstanceID: "i-" + strconv.Itoa(i),
+					PrivateIPs: []string{"10.0.0." + strconv.Itoa(i)},
+					OSName:     "centos",
+					Attributes: map[string]interface{}{common.BKHostCPUField: 4, common.BKOSNameField: "overwritten"},
+				})
+			}
+			_ = json.NewEncoder(w).Encode(result)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestHTTPJsonProvider(t *testing.T) {
+	server := newInventoryStub(t, 3)
+	defer server.Close()
+
+	provider, err := New(HTTPJson, Credential{SecretID: "id", SecretKey: "key", Endpoint: server.URL + "/"})
+	if err != nil {
+		t.Fatalf("new provider failed, err: %v", err)
+	}
+
+	regions, err := provider.ListRegions(context.Background())
+	if err != nil {
+		t.Fatalf("list regions failed, err: %v", err)
+	}
+	if len(regions) != 1 || regions[0] != "region-a" {
+		t.Fatalf("unexpected regions: %v", regions)
+	}
+
+	page, err := provider.ListInstances(context.Background(), "region-a", Page{Offset: 2, Limit: 2})
+	if err != nil {
+		t.Fatalf("list instances failed, err: %v", err)
+	}
+	if page.Total != 3 || len(page.Instances) != 1 || page.Instances[0].InstanceID != "i-2" {
+		t.Fatalf("unexpected instance page: %+v", page)
+	}
+
+	host := provider.ToHost(page.Instances[0])
+	if host[common.BKHostInnerIPField] != "10.0.0.2" || host[common.BKOSNameField] != "centos" ||
+		host[common.BKHostCloudRegionField] != "region-a" {
+		t.Fatalf("unexpected host attributes: %v", host)
+	}
+	if cpu, _ := host[common.BKHostCPUField].(float64); cpu != 4 {
+		t.Fatalf("extra attribute bk_cpu is not mapped, host: %v", host)
+	}
+}
+
+func TestListAllInstances(t *testing.T) {
+	server := newInventoryStub(t, 5)
+	defer server.Close()
+
+	provider, err := New(HTTPJson, Credential{SecretID: "id", SecretKey: "key", Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("new provider failed, err: %v", err)
+	}
+
+	instances, err := ListAllInstances(context.Background(), provider, "region-a", 2)
+	if err != nil {
+		t.Fatalf("list all instances failed, err: %v", err)
+	}
+	if len(instances) != 5 {
+		t.Fatalf("expect 5 instances, but got %d", len(instances))
+	}
+	for i, inst := range instances {
+		if inst.InstanceID != "i-"+strconv.Itoa(i) {
+			t.Fatalf("unexpected instance %d: %+v", i, inst)
+		}
+	}
+}
+
+func TestHTTPJsonProviderAuthFailure(t *testing.T) {
+	server := newInventoryStub(t, 1)
+	defer server.Close()
+
+	provider, err := New(HTTPJson, Credential{SecretID: "id", SecretKey: "wrong", Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("new provider failed, err: %v", err)
+	}
+	if _, err := provider.ListRegions(context.Background()); err == nil || !strings.Contains(err.Error(), "AuthFailure") {
+		t.Fatalf("expect auth failure, but got: %v", err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	if _, err := New("not_exist", Credential{}); err == nil {
+		t.Fatal("expect error for unknown provider type")
+	}
+	if _, err := New(HTTPJson, Credential{}); err == nil {
+		t.Fatal("expect error for http json provider without endpoint")
+	}
+	types := Types()
+	if len(types) != 2 || types[0] != HTTPJson || types[1] != TencentCloud {
+		t.Fatalf("unexpected provider types: %v", types)
+	}
+}
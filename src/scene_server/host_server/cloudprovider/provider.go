@@ -0,0 +1,162 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+const (
+	// TencentCloud the tencent cloud cvm provider type
+	TencentCloud = "tencent_cloud"
+	// HTTPJson the generic http json inventory provider type
+	HTTPJson = "http_json"
+
+	// DefaultPageLimit the default page size used to list instances
+	DefaultPageLimit int64 = 100
+)
+
+// Provider is a cloud vendor that a cloud sync task can pull host inventory from.
+type Provider interface {
+	// Name returns the provider type this provider registered with.
+	Name() string
+	// ListRegions returns all the regions the account can see.
+	ListRegions(ctx context.Context) ([]string, error)
+	// ListInstances returns one page of instances in the region, with the total count of the region.
+	ListInstances(ctx context.Context, region string, page Page) (*InstancePage, error)
+	// ToHost maps a cloud instance to host attributes.
+	ToHost(inst *Instance) mapstr.MapStr
+}
+
+// Credential is what a provider needs to access the cloud account.
+type Credential struct {
+	SecretID  string
+	SecretKey string
+	// Endpoint overwrites the provider's default api address.
+	Endpoint string
+}
+
+// Page the paging option when list instances.
+type Page struct {
+	Offset int64
+	Limit  int64
+}
+
+// InstancePage one page of instances returned by a provider.
+type InstancePage struct {
+	Total     int64
+	Instances []*Instance
+}
+
+// Instance is a cloud virtual machine described in a vendor neutral way.
+type Instance struct {
+	InstanceID   string
+	InstanceName string
+	Region       string
+	PrivateIPs   []string
+	PublicIPs    []string
+	OSName       string
+	// Attributes are extra host attributes reported by the provider, keyed by host property id.
+	Attributes map[string]interface{}
+}
+
+// Factory creates a provider with the credential.
+type Factory func(cred Credential) (Provider, error)
+
+var (
+	lock      sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register registers a provider factory with the provider type.
+// it's usually called in the provider's init function.
+func Register(providerType string, factory Factory) {
+	lock.Lock()
+	defer lock.Unlock()
+	if _, exist := factories[providerType]; exist {
+		panic(fmt.Sprintf("cloud provider %s registered twice", providerType))
+	}
+	factories[providerType] = factory
+}
+
+// New creates a provider of the provider type.
+func New(providerType string, cred Credential) (Provider, error) {
+	lock.RLock()
+	factory, exist := factories[providerType]
+	lock.RUnlock()
+	if !exist {
+		return nil, fmt.Errorf("unsupported cloud provider type: %s", providerType)
+	}
+	return factory(cred)
+}
+
+// Types returns all the registered provider types.
+func Types() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	types := make([]string, 0, len(factories))
+	for providerType := range factories {
+		types = append(types, providerType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ListAllInstances lists the instances in the region page by page until all of them are fetched.
+func ListAllInstances(ctx context.Context, provider Provider, region string, limit int64) ([]*Instance, error) {
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+
+	instances := make([]*Instance, 0)
+	for offset := int64(0); ; {
+		page, err := provider.ListInstances(ctx, region, Page{Offset: offset, Limit: limit})
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, page.Instances...)
+
+		offset += int64(len(page.Instances))
+		if len(page.Instances) == 0 || offset >= page.Total {
+			return instances, nil
+		}
+	}
+}
+
+// InstanceToHost maps the instance to host attributes, the extra attributes are copied first,
+// so the well known fields always win over the attributes with the same property id.
+func InstanceToHost(inst *Instance) mapstr.MapStr {
+	host := mapstr.MapStr{}
+	for key, value := range inst.Attributes {
+		host[key] = value
+	}
+	host[common.BKHostCloudRegionField] = inst.Region
+	host[common.BKHostInnerIPField] = FirstIP(inst.PrivateIPs)
+	host[common.BKHostOuterIPField] = FirstIP(inst.PublicIPs)
+	host[common.BKOSNameField] = inst.OSName
+	return host
+}
+
+// FirstIP returns the first ip of the ip list, or empty string if there is none.
+func FirstIP(ips []string) string {
+	if len(ips) == 0 {
+		return ""
+	}
+	return ips[0]
+}
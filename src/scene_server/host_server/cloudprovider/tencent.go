@@ -0,0 +1,140 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudprovider
+
+import (
+	"context"
+
+	com "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/regions"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+func init() {
+	Register(TencentCloud, newTencentCloud)
+}
+
+// tencentCloud pulls instances with the tencent cloud cvm api.
+type tencentCloud struct {
+	credential *com.Credential
+	profile    *profile.ClientProfile
+}
+
+func newTencentCloud(cred Credential) (Provider, error) {
+	cpf := profile.NewClientProfile()
+	cpf.HttpProfile.ReqMethod = common.BKHttpGet
+	cpf.HttpProfile.ReqTimeout = common.BKTencentCloudTimeOut
+	cpf.HttpProfile.Endpoint = common.TencentCloudUrl
+	if cred.Endpoint != "" {
+		cpf.HttpProfile.Endpoint = cred.Endpoint
+	}
+	cpf.SignMethod = common.TencentCloudSignMethod
+
+	return &tencentCloud{
+		credential: com.NewCredential(cred.SecretID, cred.SecretKey),
+		profile:    cpf,
+	}, nil
+}
+
+func (t *tencentCloud) Name() string {
+	return TencentCloud
+}
+
+func (t *tencentCloud) ListRegions(ctx context.Context) ([]string, error) {
+	client, err := cvm.NewClient(t.credential, regions.Guangzhou, t.profile)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.DescribeRegions(cvm.NewDescribeRegionsRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	regionList := make([]string, 0)
+	for _, region := range resp.Response.RegionSet {
+		if region.Region == nil {
+			continue
+		}
+		regionList = append(regionList, *region.Region)
+	}
+	return regionList, nil
+}
+
+func (t *tencentCloud) ListInstances(ctx context.Context, region string, page Page) (*InstancePage, error) {
+	client, err := cvm.NewClient(t.credential, region, t.profile)
+	if err != nil {
+		return nil, err
+	}
+
+	request := cvm.NewDescribeInstancesRequest()
+	request.Offset = com.Int64Ptr(page.Offset)
+	if page.Limit > 0 {
+		request.Limit = com.Int64Ptr(page.Limit)
+	}
+	resp, err := client.DescribeInstances(request)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &InstancePage{Instances: make([]*Instance, 0)}
+	if resp.Response.TotalCount != nil {
+		result.Total = *resp.Response.TotalCount
+	}
+	for _, obj := range resp.Response.InstanceSet {
+		inst := &Instance{
+			InstanceID:   stringValue(obj.InstanceId),
+			InstanceName: stringValue(obj.InstanceName),
+			Region:       region,
+			PrivateIPs:   stringValues(obj.PrivateIpAddresses),
+			PublicIPs:    stringValues(obj.PublicIpAddresses),
+			OSName:       stringValue(obj.OsName),
+			Attributes:   make(map[string]interface{}),
+		}
+		if obj.CPU != nil {
+			inst.Attributes[common.BKHostCPUField] = *obj.CPU
+		}
+		if obj.Memory != nil {
+			// tencent cloud reports memory in GB, host stores it in MB
+			inst.Attributes[common.BKHostMemField] = *obj.Memory * 1024
+		}
+		result.Instances = append(result.Instances, inst)
+	}
+	return result, nil
+}
+
+func (t *tencentCloud) ToHost(inst *Instance) mapstr.MapStr {
+	return InstanceToHost(inst)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func stringValues(list []*string) []string {
+	values := make([]string, 0, len(list))
+	for _, s := range list {
+		if s == nil || *s == "" {
+			continue
+		}
+		values = append(values, *s)
+	}
+	return values
+}
@@ -0,0 +1,177 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/types"
+)
+
+// cloudSyncRunPath the host server path the task server calls to run a cloud sync task
+const cloudSyncRunPath = "/host/v3/hosts/cloud/sync/run"
+
+// CloudSyncCronJobName the name of the task server cron job that runs the cloud sync task
+func CloudSyncCronJobName(taskID int64) string {
+	return "cloud-sync-" + strconv.FormatInt(taskID, 10)
+}
+
+// CloudSyncCronSpec converts the period of the cloud sync task to the cron expression.
+// the task runs at "HH:MM" every day, at "MM" every hour, or every five minutes.
+func CloudSyncCronSpec(periodType, period string) (string, error) {
+	switch periodType {
+	case "day":
+		parts := strings.Split(period, ":")
+		if len(parts) != 2 {
+			return "", fmt.Errorf("invalid day period %s, should be HH:MM", period)
+		}
+		hour, err := strconv.Atoi(parts[0])
+		if err != nil || hour < 0 || hour > 23 {
+			return "", fmt.Errorf("invalid hour of day period %s", period)
+		}
+		minute, err := strconv.Atoi(parts[1])
+		if err != nil || minute < 0 || minute > 59 {
+			return "", fmt.Errorf("invalid minute of day period %s", period)
+		}
+		return fmt.Sprintf("%d %d * * *", minute, hour), nil
+	case "hour":
+		minute, err := strconv.Atoi(period)
+		if err != nil || minute < 0 || minute > 59 {
+			return "", fmt.Errorf("invalid hour period %s, should be MM", period)
+		}
+		return fmt.Sprintf("%d * * * *", minute), nil
+	case "minute":
+		return "*/5 * * * *", nil
+	}
+	return "", fmt.Errorf("unsupported period type %s", periodType)
+}
+
+// ScheduleCloudTask makes the task server cron job of the cloud sync task consistent with the task,
+// the job is enabled only if the task is started.
+func (lgc *Logics) ScheduleCloudTask(ctx context.Context, taskInfo meta.CloudTaskInfo) error {
+	spec, err := CloudSyncCronSpec(taskInfo.PeriodType, taskInfo.Period)
+	if err != nil {
+		blog.Errorf("schedule cloud task %d failed, err: %v, rid: %s", taskInfo.TaskID, err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "bk_period")
+	}
+
+	// the task is synced with the account of the user who creates it
+	header := make(http.Header)
+	for key, values := range lgc.header {
+		header[key] = values
+	}
+	header.Set(common.BKHTTPOwnerID, taskInfo.OwnerID)
+	if taskInfo.User != "" {
+		header.Set(common.BKHTTPHeaderUser, taskInfo.User)
+	}
+
+	job := &meta.UpsertCronJobRequest{
+		Name:         CloudSyncCronJobName(taskInfo.TaskID),
+		Spec:         spec,
+		SvrType:      types.CC_MODULE_HOST,
+		Path:         cloudSyncRunPath,
+		Data:         meta.CloudSyncRunRequest{TaskID: taskInfo.TaskID},
+		Enabled:      taskInfo.Status,
+		MissedPolicy: meta.CronJobMissedSkip,
+	}
+	resp, err := lgc.CoreAPI.TaskServer().Task().UpsertCronJob(ctx, header, job)
+	if err != nil {
+		blog.Errorf("schedule cloud task %d failed, err: %v, rid: %s", taskInfo.TaskID, err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("schedule cloud task %d failed, err: %s, rid: %s", taskInfo.TaskID, resp.ErrMsg, lgc.rid)
+		return resp.CCError()
+	}
+	return nil
+}
+
+// ScheduleCloudTasks schedules the cloud sync tasks found by the condition
+func (lgc *Logics) ScheduleCloudTasks(ctx context.Context, opt map[string]interface{}) error {
+	response, err := lgc.CoreAPI.CoreService().Cloud().SearchCloudSyncTask(ctx, lgc.header, opt)
+	if err != nil {
+		blog.Errorf("search cloud task instance failed, err: %v, rid: %s", err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCloudGetTaskFail)
+	}
+
+	for _, taskInfo := range response.Info {
+		if err := lgc.ScheduleCloudTask(ctx, taskInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnscheduleCloudTask removes the cron job of the deleted cloud sync task
+func (lgc *Logics) UnscheduleCloudTask(ctx context.Context, taskID int64) error {
+	resp, err := lgc.CoreAPI.TaskServer().Task().DeleteCronJob(ctx, lgc.header, CloudSyncCronJobName(taskID))
+	if err != nil {
+		blog.Errorf("delete cron job of cloud task %d failed, err: %v, rid: %s", taskID, err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("delete cron job of cloud task %d failed, err: %s, rid: %s", taskID, resp.ErrMsg, lgc.rid)
+		return resp.CCError()
+	}
+	return nil
+}
+
+// InitCloudTaskSchedule creates the cron jobs of the cloud sync tasks when the host server becomes master,
+// so the tasks created before the cron jobs are introduced keep running.
+func (lgc *Logics) InitCloudTaskSchedule(ctx context.Context) {
+	for {
+		if lgc.Engine.ServiceManageInterface.IsMaster() {
+			err := lgc.ScheduleCloudTasks(ctx, make(map[string]interface{}))
+			if err == nil {
+				return
+			}
+			blog.Errorf("init cloud task schedule failed, retry later, err: %v, rid: %s", err, lgc.rid)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Minute):
+		}
+	}
+}
+
+// RunCloudSync is called by the cron job of the cloud sync task to sync the hosts
+func (lgc *Logics) RunCloudSync(ctx context.Context, taskID int64) error {
+	opt := map[string]interface{}{common.BKCloudTaskID: taskID}
+	response, err := lgc.CoreAPI.CoreService().Cloud().SearchCloudSyncTask(ctx, lgc.header, opt)
+	if err != nil {
+		blog.Errorf("search cloud task %d failed, err: %v, rid: %s", taskID, err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCloudGetTaskFail)
+	}
+	if len(response.Info) == 0 {
+		blog.Errorf("cloud task %d not found, rid: %s", taskID, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCloudGetTaskFail)
+	}
+
+	taskInfo := response.Info[0]
+	if !taskInfo.Status {
+		blog.Infof("cloud task %d is stopped, skip syncing, rid: %s", taskID, lgc.rid)
+		return nil
+	}
+	lgc.ExecSync(ctx, taskInfo)
+	return nil
+}
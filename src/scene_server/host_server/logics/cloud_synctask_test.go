@@ -0,0 +1,119 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+func cloudHost(instID, innerIP, outerIP, osName string) mapstr.MapStr {
+	return mapstr.MapStr{
+		common.BKCloudInstIDField: instID,
+		common.BKHostInnerIPField: innerIP,
+		common.BKHostOuterIPField: outerIP,
+		common.BKOSNameField:      osName,
+	}
+}
+
+func TestDiffCloudHosts(t *testing.T) {
+	existHosts := []mapstr.MapStr{
+		// unchanged
+		cloudHost("ins-1", "10.0.0.1", "", "centos"),
+		// os name changed
+		cloudHost("ins-2", "10.0.0.2", "", "centos"),
+		// synced before the instance id is recorded
+		cloudHost("", "10.0.0.3", "", "centos"),
+		// removed from the cloud
+		cloudHost("ins-4", "10.0.0.4", "", "centos"),
+		// not synced by this task
+		cloudHost("ins-5", "10.0.0.5", "", "centos"),
+		// cpu changed
+		cloudHost("ins-7", "10.0.0.7", "", "centos"),
+	}
+	for i, host := range existHosts {
+		host[common.BKHostIDField] = int64(i + 1)
+	}
+	// the numbers decoded from the response of the host search
+	existHosts[0][common.BKHostCPUField] = float64(8)
+	existHosts[5][common.BKHostCPUField] = float64(4)
+
+	cloudHosts := []mapstr.MapStr{
+		cloudHost("ins-1", "10.0.0.1", "", "centos"),
+		cloudHost("ins-2", "10.0.0.2", "", "ubuntu"),
+		cloudHost("ins-3", "10.0.0.3", "", "centos"),
+		cloudHost("ins-6", "10.0.0.6", "1.1.1.1", "centos"),
+		cloudHost("ins-7", "10.0.0.7", "", "centos"),
+	}
+	cloudHosts[0][common.BKHostCPUField] = int64(8)
+	cloudHosts[4][common.BKHostCPUField] = int64(8)
+
+	diff := diffCloudHosts(existHosts, cloudHosts, []string{"ins-1", "ins-2", "ins-4"})
+
+	if len(diff.added) != 1 || diff.added[0][common.BKCloudInstIDField] != "ins-6" {
+		t.Fatalf("unexpected added hosts: %v", diff.added)
+	}
+
+	if len(diff.changed) != 3 {
+		t.Fatalf("unexpected changed hosts: %v", diff.changed)
+	}
+	if diff.changed[0][common.BKHostIDField] != int64(2) || diff.changed[0][common.BKOSNameField] != "ubuntu" {
+		t.Fatalf("unexpected changed host: %v", diff.changed[0])
+	}
+	if diff.changed[1][common.BKHostIDField] != int64(3) || diff.changed[1][common.BKCloudInstIDField] != "ins-3" {
+		t.Fatalf("unexpected changed host: %v", diff.changed[1])
+	}
+	if diff.changed[2][common.BKHostIDField] != int64(6) || diff.changed[2][common.BKHostCPUField] != int64(8) {
+		t.Fatalf("unexpected changed host: %v", diff.changed[2])
+	}
+	if _, exist := cloudHosts[1][common.BKHostIDField]; exist {
+		t.Fatalf("the cloud host should not be modified: %v", cloudHosts[1])
+	}
+
+	if len(diff.removed) != 1 || diff.removed[0][common.BKHostIDField] != int64(4) {
+		t.Fatalf("unexpected removed hosts: %v", diff.removed)
+	}
+}
+
+func TestCloudSyncCronSpec(t *testing.T) {
+	cases := []struct {
+		periodType string
+		period     string
+		expect     string
+	}{
+		{"day", "08:05", "5 8 * * *"},
+		{"day", "23:59", "59 23 * * *"},
+		{"hour", "07", "7 * * * *"},
+		{"minute", "", "*/5 * * * *"},
+	}
+	for _, c := range cases {
+		spec, err := CloudSyncCronSpec(c.periodType, c.period)
+		if err != nil {
+			t.Fatalf("convert period %s %s failed, err: %v", c.periodType, c.period, err)
+		}
+		if spec != c.expect {
+			t.Fatalf("period %s %s expect spec %s, but got %s", c.periodType, c.period, c.expect, spec)
+		}
+	}
+
+	for _, period := range []string{"24:00", "8", "08:60"} {
+		if _, err := CloudSyncCronSpec("day", period); err == nil {
+			t.Fatalf("expect error for day period %s", period)
+		}
+	}
+	if _, err := CloudSyncCronSpec("week", "1"); err == nil {
+		t.Fatal("expect error for unsupported period type")
+	}
+}
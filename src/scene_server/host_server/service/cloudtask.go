@@ -64,6 +64,8 @@ func (s *Service) DeleteCloudTask(req *restful.Request, resp *restful.Response)
 	retData := make(map[string]interface{})
 	if err != nil {
 		retData["errors:"] = err
+	} else if err := srvData.lgc.UnscheduleCloudTask(srvData.ctx, int64ID); err != nil {
+		retData["errors:"] = err
 	}
 
 	_ = resp.WriteEntity(meta.NewSuccessResp(retData))
@@ -118,31 +120,24 @@ func (s *Service) UpdateCloudTask(req *restful.Request, resp *restful.Response)
 		return
 	}
 
-	status, err := data.Bool("bk_status")
-	if err != nil {
+	if _, err := data.Bool("bk_status"); err != nil {
 		blog.Errorf("UpdateCloudTask fail with interface convert to bool fail, err: %v, rid: %s", err, srvData.rid)
 		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCloudSyncUpdateSyncTaskFail)})
 		return
 	}
 
-	if status {
-		// 开启同步状态下，update:先关闭同步，更新数据后，再开启同步
-		if _, err := s.CoreAPI.CoreService().Cloud().UpdateCloudSyncTask(srvData.ctx, srvData.header, data); err != nil {
-			blog.Errorf("update task failed with decode body err: %v, rid: %s", err, srvData.rid)
-			_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCloudSyncUpdateSyncTaskFail)})
-			return
-		}
-
-		if err := srvData.lgc.FrontEndSyncSwitch(srvData.ctx, data, true); err != nil {
-			blog.Errorf("stop cloud sync fail, err: %v, rid: %s", err, srvData.rid)
-			return
-		}
-	} else {
-		if _, err := s.CoreAPI.CoreService().Cloud().UpdateCloudSyncTask(srvData.ctx, srvData.header, data); err != nil {
-			blog.Errorf("update task failed with decode body err: %v, rid: %s", err, srvData.rid)
-			_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCloudSyncUpdateSyncTaskFail)})
-			return
-		}
+	// the period or status may be changed, reschedule the task
+	taskID, err := data.Int64(common.BKCloudTaskID)
+	if err != nil {
+		blog.Errorf("UpdateCloudTask fail with invalid task id, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCloudSyncUpdateSyncTaskFail)})
+		return
+	}
+	opt := map[string]interface{}{common.BKCloudTaskID: taskID}
+	if err := srvData.lgc.ScheduleCloudTasks(srvData.ctx, opt); err != nil {
+		blog.Errorf("schedule cloud task %d fail, err: %v, rid: %s", taskID, err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
 	}
 
 	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
@@ -183,7 +178,7 @@ func (s *Service) StartCloudSync(req *restful.Request, resp *restful.Response) {
 
 	delete(opt, "bk_task_name")
 
-	if err := srvData.lgc.FrontEndSyncSwitch(srvData.ctx, opt, false); err != nil {
+	if err := srvData.lgc.ScheduleCloudTasks(srvData.ctx, opt); err != nil {
 		blog.Errorf("StartCloudSync fail, err: %v, rid: %s", err, srvData.rid)
 		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCloudSyncStartFail)})
 		return
@@ -192,6 +187,26 @@ func (s *Service) StartCloudSync(req *restful.Request, resp *restful.Response) {
 	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
 }
 
+// RunCloudSync runs the cloud sync task, it's called by the cron job of the task
+func (s *Service) RunCloudSync(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	input := new(meta.CloudSyncRunRequest)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("RunCloudSync failed, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := srvData.lgc.RunCloudSync(srvData.ctx, input.TaskID); err != nil {
+		blog.Errorf("RunCloudSync failed, task id: %d, err: %v, rid: %s", input.TaskID, err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
 func (s *Service) CreateResourceConfirm(req *restful.Request, resp *restful.Response) {
 	srvData := s.newSrvComm(req.Request.Header)
 
@@ -0,0 +1,197 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	authmeta "configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// ListDriftedHostsParameter lists the drifted hosts in the business if bk_biz_id is set
+type ListDriftedHostsParameter struct {
+	BizID                       int64 `json:"bk_biz_id"`
+	meta.ListDriftedHostsOption `json:",inline"`
+}
+
+// SearchHostDrift returns the attribute changes of a host made by the snapshots
+func (s *Service) SearchHostDrift(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	hostID, err := strconv.ParseInt(req.PathParameter(common.BKHostIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("SearchHostDrift failed, parse host id failed, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedInt, common.BKHostIDField)})
+		return
+	}
+
+	option := meta.SearchHostDriftOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&option); err != nil {
+		blog.Errorf("SearchHostDrift failed, decode body failed, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	option.HostID = hostID
+	if option.Page.Limit == 0 {
+		option.Page.Limit = common.BKMaxPageSize
+	}
+	if key, err := option.Page.Validate(false); err != nil {
+		blog.Errorf("SearchHostDrift failed, page invalid, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, key)})
+		return
+	}
+	if key, err := option.HostDriftTimeRange.Validate(); err != nil {
+		blog.Errorf("SearchHostDrift failed, time range invalid, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, key)})
+		return
+	}
+
+	if err := s.AuthManager.AuthorizeByHostsIDs(srvData.ctx, srvData.header, authmeta.Find, hostID); err != nil {
+		blog.Errorf("check host authorization failed, hosts: %+v, err: %v, rid: %s", hostID, err, srvData.rid)
+		_ = resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	drifts, ccErr := s.CoreAPI.CoreService().Host().SearchHostDrift(srvData.ctx, srvData.header, option)
+	if ccErr != nil {
+		blog.Errorf("SearchHostDrift failed, search drift failed, err: %v, option: %+v, rid: %s", ccErr, option, srvData.rid)
+		_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: ccErr})
+		return
+	}
+	_ = resp.WriteEntity(meta.NewSuccessResp(drifts))
+}
+
+// ListDriftedHosts returns the hosts whose attributes are changed by the snapshots in the time range
+func (s *Service) ListDriftedHosts(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	input := ListDriftedHostsParameter{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&input); err != nil {
+		blog.Errorf("ListDriftedHosts failed, decode body failed, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	option := input.ListDriftedHostsOption
+	if option.Page.Limit == 0 {
+		option.Page.Limit = common.BKMaxPageSize
+	}
+	if key, err := option.Page.Validate(false); err != nil {
+		blog.Errorf("ListDriftedHosts failed, page invalid, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, key)})
+		return
+	}
+	if key, err := option.Validate(); err != nil {
+		blog.Errorf("ListDriftedHosts failed, option invalid, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, key)})
+		return
+	}
+
+	// limit the hosts to the business, or to the authorized hosts before paging so that the page
+	// and the count only cover the hosts the user is allowed to find
+	if input.BizID != 0 {
+		if err := s.AuthManager.AuthorizeByBusinessID(srvData.ctx, srvData.header, authmeta.Find, input.BizID); err != nil {
+			blog.Errorf("check business authorization failed, business: %d, err: %v, rid: %s", input.BizID, err, srvData.rid)
+			_ = resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+			return
+		}
+		hostIDs, err := srvData.lgc.GetHostIDByCond(srvData.ctx, meta.HostModuleRelationRequest{
+			ApplicationID: input.BizID,
+			HostIDArr:     option.HostIDs,
+		})
+		if err != nil {
+			blog.Errorf("ListDriftedHosts failed, get hosts of business %d failed, err: %v, rid: %s", input.BizID, err, srvData.rid)
+			_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+			return
+		}
+		if len(hostIDs) == 0 {
+			_ = resp.WriteEntity(meta.NewSuccessResp(meta.MultipleDriftedHost{Info: make([]meta.DriftedHost, 0)}))
+			return
+		}
+		option.HostIDs = hostIDs
+	} else if s.AuthManager.Enabled() && !s.AuthManager.SkipReadAuthorization {
+		hostIDs, err := s.listAuthorizedHostIDs(srvData, option.HostIDs)
+		if err != nil {
+			blog.Errorf("ListDriftedHosts failed, list authorized hosts failed, err: %v, rid: %s", err, srvData.rid)
+			_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+			return
+		}
+		if len(hostIDs) == 0 {
+			_ = resp.WriteEntity(meta.NewSuccessResp(meta.MultipleDriftedHost{Info: make([]meta.DriftedHost, 0)}))
+			return
+		}
+		option.HostIDs = hostIDs
+	}
+
+	hosts, ccErr := s.CoreAPI.CoreService().Host().ListDriftedHosts(srvData.ctx, srvData.header, option)
+	if ccErr != nil {
+		blog.Errorf("ListDriftedHosts failed, list drifted hosts failed, err: %v, option: %+v, rid: %s", ccErr, option, srvData.rid)
+		_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: ccErr})
+		return
+	}
+	_ = resp.WriteEntity(meta.NewSuccessResp(hosts))
+}
+
+// listAuthorizedHostIDs returns the hosts the user is allowed to find, limited to hostIDs if it's not empty.
+// the hosts in the businesses the user is allowed to find are authorized by the business as a whole,
+// the same as listing with bk_biz_id, the resource pool hosts are authorized one by one.
+func (s *Service) listAuthorizedHostIDs(srvData *srvComm, hostIDs []int64) ([]int64, errors.CCError) {
+	user := authmeta.UserInfo{UserName: srvData.user, SupplierAccount: srvData.ownerID}
+	bizIDs, err := s.AuthManager.Authorize.GetExactAuthorizedBusinessList(srvData.ctx, user)
+	if err != nil {
+		blog.Errorf("get authorized businesses failed, user: %s, err: %v, rid: %s", srvData.user, err, srvData.rid)
+		return nil, srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)
+	}
+	resPoolBizID, ccErr := srvData.lgc.GetDefaultAppID(srvData.ctx)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+
+	authorizedIDs := make([]int64, 0)
+	for _, bizID := range bizIDs {
+		if bizID == resPoolBizID {
+			continue
+		}
+		bizHostIDs, ccErr := srvData.lgc.GetHostIDByCond(srvData.ctx, meta.HostModuleRelationRequest{
+			ApplicationID: bizID,
+			HostIDArr:     hostIDs,
+		})
+		if ccErr != nil {
+			return nil, ccErr
+		}
+		authorizedIDs = append(authorizedIDs, bizHostIDs...)
+	}
+
+	resPoolHostIDs, ccErr := srvData.lgc.GetHostIDByCond(srvData.ctx, meta.HostModuleRelationRequest{
+		ApplicationID: resPoolBizID,
+		HostIDArr:     hostIDs,
+	})
+	if ccErr != nil {
+		return nil, ccErr
+	}
+	resPoolHostIDs, err = s.AuthManager.FilterAuthorizedHostIDs(srvData.ctx, srvData.header, authmeta.Find, util.IntArrayUnique(resPoolHostIDs)...)
+	if err != nil {
+		blog.Errorf("filter authorized resource pool hosts failed, err: %v, rid: %s", err, srvData.rid)
+		return nil, srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)
+	}
+	authorizedIDs = append(authorizedIDs, resPoolHostIDs...)
+	return util.IntArrayUnique(authorizedIDs), nil
+}
@@ -88,6 +88,8 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.DELETE("/hosts/batch").To(s.DeleteHostBatchFromResourcePool))
 	api.Route(api.GET("/hosts/{bk_supplier_account}/{bk_host_id}").To(s.GetHostInstanceProperties))
 	api.Route(api.GET("/hosts/snapshot/{bk_host_id}").To(s.HostSnapInfo))
+	api.Route(api.POST("/hosts/drift/{bk_host_id}").To(s.SearchHostDrift))
+	api.Route(api.POST("/hosts/drift/search").To(s.ListDriftedHosts))
 	api.Route(api.POST("/hosts/add").To(s.AddHost))
 	// api.Route(api.POST("/host/add/agent").To(s.AddHostFromAgent))
 	api.Route(api.POST("/hosts/sync/new/host").To(s.NewHostSyncAppTopo))
@@ -152,6 +154,7 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/hosts/cloud/search").To(s.SearchCloudTask))
 	api.Route(api.PUT("/hosts/cloud/update").To(s.UpdateCloudTask))
 	api.Route(api.POST("/hosts/cloud/startSync").To(s.StartCloudSync))
+	api.Route(api.POST("/hosts/cloud/sync/run").To(s.RunCloudSync))
 	api.Route(api.POST("/hosts/cloud/resourceConfirm").To(s.CreateResourceConfirm))
 	api.Route(api.POST("/hosts/cloud/searchConfirm").To(s.SearchConfirm))
 	api.Route(api.POST("/hosts/cloud/confirmHistory/add").To(s.AddConfirmHistory))
@@ -239,8 +242,7 @@ func (s *Service) InitBackground() {
 		header.Set(common.BKHTTPOwnerID, common.BKSuperOwnerID)
 		header.Set(common.BKHTTPHeaderUser, common.BKProcInstanceOpUser)
 	}
-	s.CacheDB.FlushDb()
 
 	srvData := s.newSrvComm(header)
-	go srvData.lgc.TimerTriggerCheckStatus(srvData.ctx)
+	go srvData.lgc.InitCloudTaskSchedule(srvData.ctx)
 }
@@ -31,14 +31,13 @@ type Config struct {
 	Mongo     mongo.Config
 	Redis     redis.Config
 	Auth      authcenter.AuthConfig
-	Timer     string
 }
 
 func (c *Config) Ready() bool {
 	if c == nil {
 		return false
 	}
-	if len(c.Timer) == 0 {
+	if len(c.Mongo.Address) == 0 {
 		return false
 	}
 	return true
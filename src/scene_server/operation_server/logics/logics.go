@@ -21,8 +21,6 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
-
-	"github.com/robfig/cron"
 )
 
 func (lgc *Logics) GetBizHostCount(kit *rest.Kit) ([]metadata.StringIDCount, error) {
@@ -130,36 +128,8 @@ func (lgc *Logics) InnerChartData(kit *rest.Kit, chartInfo metadata.ChartConfig)
 	}
 }
 
-func (lgc *Logics) TimerFreshData(ctx context.Context) {
-	lgc.CheckTableExist(ctx)
-
-	c := cron.New()
-	spec := lgc.timerSpec // 从配置文件读取的时间
-	_, err := c.AddFunc(spec, func() {
-		blog.V(3).Infof("begin statistic chart data, time: %v", time.Now())
-		// 主服务器跑定时
-		opt := mapstr.MapStr{}
-		isMaster := lgc.Engine.ServiceManageInterface.IsMaster()
-		if isMaster {
-			if _, err := lgc.CoreAPI.CoreService().Operation().TimerFreshData(ctx, lgc.header, opt); err != nil {
-				blog.Error("statistic chart data fail, err: %v", err)
-			}
-		}
-	})
-
-	if err != nil {
-		blog.Errorf("new cron failed, please contact developer, err: %v", err)
-		return
-	}
-	c.Start()
-
-	select {
-	case <-ctx.Done():
-		return
-	}
-}
-
-// CheckTableExist 检测cc_chartData集合是否存在
+// CheckTableExist 检测cc_chartData集合是否存在，存在时会刷新一次统计数据。
+// 之后的定时刷新由task server的内置定时任务operation-chart-refresh执行
 func (lgc *Logics) CheckTableExist(ctx context.Context) {
 	opt := mapstr.MapStr{}
 	for {
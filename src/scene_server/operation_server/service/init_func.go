@@ -27,5 +27,5 @@ func (o *OperationServer) InitFunc() {
 	}
 
 	srvData := o.newSrvComm(header)
-	go srvData.lgc.TimerFreshData(srvData.ctx)
+	go srvData.lgc.CheckTableExist(srvData.ctx)
 }
@@ -13,12 +13,7 @@ package service
 
 import (
 	"context"
-	goErr "errors"
-	"fmt"
 	"net/http"
-	"regexp"
-	"strconv"
-	"strings"
 
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/auth/extensions"
@@ -74,7 +69,7 @@ func (o *OperationServer) newSrvComm(header http.Header) *srvComm {
 		ctxCancelFunc: cancel,
 		user:          util.GetUser(header),
 		ownerID:       util.GetOwnerID(header),
-		lgc:           logics.NewLogics(o.Engine, header, o.AuthManager, o.Config.Timer),
+		lgc:           logics.NewLogics(o.Engine, header, o.AuthManager),
 	}
 }
 
@@ -166,12 +161,6 @@ func (o *OperationServer) OnOperationConfigUpdate(previous, current cc.ProcessCo
 
 	o.Config = &options.Config{}
 	o.Config.ConfigMap = current.ConfigMap
-	o.Config.Timer, err = o.ParseTimerConfigFromKV("timer", current.ConfigMap)
-	if err != nil {
-		blog.Errorf("parse timer config failed, err: %v", err)
-		return
-	}
-
 	cfg := mongo.ParseConfigFromKV("mongodb", current.ConfigMap)
 	o.Config.Mongo = cfg
 
@@ -182,55 +171,3 @@ func (o *OperationServer) OnOperationConfigUpdate(previous, current cc.ProcessCo
 	}
 
 }
-
-func (o *OperationServer) ParseTimerConfigFromKV(prefix string, configMap map[string]string) (string, error) {
-	// 若是timer没配置，或者解析失败，给一个默认的定时时间
-	defaultSpec := "30 0 * * *"
-
-	specStr, ok := configMap[prefix+".spec"]
-	if !ok {
-		blog.Errorf("parse timer config failed, missing 'spec' configuration for timer, set timer-spec default value: 00:30")
-		return defaultSpec, nil
-	}
-
-	spec, err := parseTimerConfig(specStr)
-	if err != nil || spec == "" {
-		blog.Errorf("parse timer config failed, set timer-spec default value: 00:30, err: %v", err)
-		return defaultSpec, nil
-	}
-
-	return spec, nil
-}
-
-func parseTimerConfig(spec string) (string, error) {
-	matched, err := regexp.MatchString(common.TimerPattern, spec)
-	if err != nil || !matched {
-		blog.Errorf("parse timer config failed, 'spec' not match required rules, err: %v", err)
-		return "", goErr.New("'spec' not match required rules")
-	}
-
-	numArray := strings.Split(spec, ":")
-	hour := numArray[0]
-	intHour, err := strconv.Atoi(hour)
-	if err != nil {
-		blog.Errorf("parse timer config failed, got invalid hour data, err: %v", err)
-		return "", goErr.New("parse time config failed, got invalid hour data")
-	}
-	if intHour < 0 || intHour > 23 {
-		blog.Errorf("parse timer config failed, got invalid hour data, err: %v", err)
-		return "", goErr.New("'parse time config failed, got invalid hour data, should between 0-23")
-	}
-	minute := numArray[1]
-	intMinute, err := strconv.Atoi(minute)
-	if err != nil {
-		blog.Errorf("parse timer config failed, got invalid minute data, err: %v", err)
-		return "", goErr.New("parse time config failed, got invalid minute data")
-	}
-	if intMinute < 0 || intMinute > 59 {
-		blog.Errorf("parse timer config failed, got invalid minute data, err: %v", err)
-		return "", goErr.New("parse time config failed, got invalid minute data, should between 0-59")
-	}
-
-	spec = fmt.Sprintf("%d %d * * *", intMinute, intHour)
-	return spec, nil
-}
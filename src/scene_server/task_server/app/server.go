@@ -95,6 +95,10 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 
 	queue := service.NewQueue(taskSrv.taskQueue)
 	queue.Start()
+
+	server := fmt.Sprintf("%s:%d", svrInfo.IP, svrInfo.Port)
+	scheduler := service.NewCronScheduler(server, taskSrv.cronJobSpecs)
+	scheduler.Start(ctx)
 	select {
 	case <-ctx.Done():
 	}
@@ -106,6 +110,8 @@ type TaskServer struct {
 	Config    options.Config
 	Service   *tasksvc.Service
 	taskQueue map[string]tasksvc.TaskInfo
+	// cronJobSpecs the cron expressions of the builtin cron jobs set in the config
+	cronJobSpecs map[string]string
 }
 
 func (h *TaskServer) WebService() *restful.Container {
@@ -141,15 +147,26 @@ func (h *TaskServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 			}
 		}
 
+		concurrency, err := parseTaskInt(current.ConfigMap, prefix+".concurrency")
+		if err != nil {
+			blog.Errorf(" parse task name %s concurrency to int error. err:%s", name, err.Error())
+		}
+		timeout, err := parseTaskInt(current.ConfigMap, prefix+".timeout")
+		if err != nil {
+			blog.Errorf(" parse task name %s timeout to int error. err:%s", name, err.Error())
+		}
+
 		f := func() ([]string, error) {
 			addrs := strings.Split(current.ConfigMap[prefix+".addrs"], ",")
 			return addrs, nil
 		}
 		task := tasksvc.TaskInfo{
-			Name:  name,
-			Addr:  f,
-			Path:  current.ConfigMap[prefix+".path"],
-			Retry: retry,
+			Name:        name,
+			Addr:        f,
+			Path:        current.ConfigMap[prefix+".path"],
+			Retry:       retry,
+			Concurrency: concurrency,
+			Timeout:     time.Duration(timeout) * time.Second,
 		}
 		if h.taskQueue == nil {
 			h.taskQueue = make(map[string]tasksvc.TaskInfo, 0)
@@ -157,6 +174,29 @@ func (h *TaskServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 		h.taskQueue[name] = task
 	}
 
+	h.cronJobSpecs = parseCronJobSpecs(current.ConfigMap)
+}
+
+// parseCronJobSpecs gets the cron expressions of the builtin cron jobs, which is set as cron-<name>.spec
+func parseCronJobSpecs(configMap map[string]string) map[string]string {
+	specs := make(map[string]string)
+	for key, value := range configMap {
+		if !strings.HasPrefix(key, "cron-") || !strings.HasSuffix(key, ".spec") || value == "" {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "cron-"), ".spec")
+		specs[name] = value
+	}
+	return specs
+}
+
+// parseTaskInt parses an optional int config of the task, returns 0 if it's not set
+func parseTaskInt(configMap map[string]string, key string) (int64, error) {
+	value := configMap[key]
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
 }
 
 func newServerInfo(op *options.ServerOption) (*types.ServerInfo, error) {
@@ -0,0 +1,307 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/types"
+
+	"github.com/robfig/cron"
+)
+
+// ParseCronSpec parses the standard cron expression of the cron job
+func ParseCronSpec(spec string) (cron.Schedule, error) {
+	return cron.ParseStandard(strings.TrimSpace(spec))
+}
+
+// NextRunTime returns the first time after from the cron expression activates
+func NextRunTime(spec string, from time.Time) (time.Time, error) {
+	schedule, err := ParseCronSpec(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(from), nil
+}
+
+// CronJobSvrTypes the services a cron job can be sent to
+var CronJobSvrTypes = []string{
+	types.CC_MODULE_APISERVER,
+	types.CC_MODULE_HOST,
+	types.CC_MODULE_PROC,
+	types.CC_MODULE_TOPO,
+	types.CC_MODULE_EVENTSERVER,
+	types.CC_MODULE_DATACOLLECTION,
+	types.CC_MODULE_CORESERVICE,
+	types.CC_MODULE_OPERATION,
+	types.CC_MODULE_TASK,
+}
+
+// CronJobServers returns the servers of the service the cron job is sent to, nil if the service is not supported
+func CronJobServers(disc discovery.DiscoveryInterface, svrType string) discovery.Interface {
+	switch svrType {
+	case types.CC_MODULE_APISERVER:
+		return disc.ApiServer()
+	case types.CC_MODULE_HOST:
+		return disc.HostServer()
+	case types.CC_MODULE_PROC:
+		return disc.ProcServer()
+	case types.CC_MODULE_TOPO:
+		return disc.TopoServer()
+	case types.CC_MODULE_EVENTSERVER:
+		return disc.EventServer()
+	case types.CC_MODULE_DATACOLLECTION:
+		return disc.DataCollect()
+	case types.CC_MODULE_CORESERVICE:
+		return disc.CoreService()
+	case types.CC_MODULE_OPERATION:
+		return disc.OperationServer()
+	case types.CC_MODULE_TASK:
+		return disc.TaskServer()
+	}
+	return nil
+}
+
+func validMissedPolicy(policy metadata.CronJobMissedPolicy) bool {
+	return policy == metadata.CronJobMissedSkip || policy == metadata.CronJobMissedRunOnce
+}
+
+// UpsertCronJob creates the cron job, or replaces it if the job exists
+func (lgc *Logics) UpsertCronJob(ctx context.Context, input *metadata.UpsertCronJobRequest) (*metadata.CronJob, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsNeedString, "name")
+	}
+	if input.Path == "" {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsNeedString, "path")
+	}
+	if CronJobServers(lgc.Engine.Discovery(), input.SvrType) == nil {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "svr_type")
+	}
+	if input.MissedPolicy == "" {
+		input.MissedPolicy = metadata.CronJobMissedSkip
+	}
+	if !validMissedPolicy(input.MissedPolicy) {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "missed_policy")
+	}
+	if input.Timeout < 0 {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "timeout")
+	}
+	now := time.Now()
+	nextRunTime, err := NextRunTime(input.Spec, now)
+	if err != nil {
+		blog.Errorf("parse cron job %s spec %s failed, err: %v, rid: %s", input.Name, input.Spec, err, lgc.rid)
+		return nil, lgc.ccErr.Errorf(common.CCErrTaskCronJobSpecInvalid, input.Spec)
+	}
+
+	job, err := lgc.findCronJob(ctx, input.Name)
+	if err != nil {
+		return nil, err
+	}
+	if job != nil && job.Builtin {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "name")
+	}
+
+	if job == nil {
+		job = &metadata.CronJob{
+			Name:        input.Name,
+			NextRunTime: nextRunTime,
+			CreateTime:  now,
+		}
+	} else if job.Spec != input.Spec || !job.Enabled {
+		job.NextRunTime = nextRunTime
+	}
+	job.Spec = input.Spec
+	job.SvrType = input.SvrType
+	job.Path = input.Path
+	job.Data = input.Data
+	job.OwnerID = lgc.ownerID
+	job.User = lgc.user
+	job.Enabled = input.Enabled
+	job.MissedPolicy = input.MissedPolicy
+	job.Timeout = input.Timeout
+	job.LastTime = now
+
+	cond := mapstr.MapStr{"name": job.Name}
+	if err := lgc.db.Table(common.BKTableNameCronJob).Upsert(ctx, cond, job); err != nil {
+		blog.ErrorJSON("upsert cron job table:%s, data:%s, err:%s, rid:%s", common.BKTableNameCronJob, job, err.Error(), lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return job, nil
+}
+
+// UpdateCronJob changes the schedule of the cron job, including the builtin one
+func (lgc *Logics) UpdateCronJob(ctx context.Context, name string, input *metadata.UpdateCronJobRequest) error {
+	job, err := lgc.findCronJob(ctx, name)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return lgc.ccErr.Error(common.CCErrTaskCronJobNotFound)
+	}
+
+	data := mapstr.MapStr{common.LastTimeField: time.Now()}
+	spec := job.Spec
+	if input.Spec != nil {
+		spec = *input.Spec
+		data.Set("spec", spec)
+	}
+	enabled := job.Enabled
+	if input.Enabled != nil {
+		enabled = *input.Enabled
+		data.Set("enabled", enabled)
+	}
+	if input.MissedPolicy != nil {
+		if !validMissedPolicy(*input.MissedPolicy) {
+			return lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "missed_policy")
+		}
+		data.Set("missed_policy", *input.MissedPolicy)
+	}
+
+	// the next run is rescheduled when the spec is changed or the job is enabled again
+	if spec != job.Spec || (enabled && !job.Enabled) {
+		nextRunTime, err := NextRunTime(spec, time.Now())
+		if err != nil {
+			blog.Errorf("parse cron job %s spec %s failed, err: %v, rid: %s", name, spec, err, lgc.rid)
+			return lgc.ccErr.Errorf(common.CCErrTaskCronJobSpecInvalid, spec)
+		}
+		data.Set("next_run_time", nextRunTime)
+	}
+
+	cond := mapstr.MapStr{"name": name}
+	if err := lgc.db.Table(common.BKTableNameCronJob).Update(ctx, cond, data); err != nil {
+		blog.ErrorJSON("update cron job table:%s, cond:%s, data:%s, err:%s, rid:%s", common.BKTableNameCronJob, cond, data, err.Error(), lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// DeleteCronJob deletes the cron job and its run history, the builtin job can only be disabled
+func (lgc *Logics) DeleteCronJob(ctx context.Context, name string) error {
+	job, err := lgc.findCronJob(ctx, name)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return nil
+	}
+	if job.Builtin {
+		return lgc.ccErr.Error(common.CCErrTaskCronJobBuiltinNotDeletable)
+	}
+
+	cond := mapstr.MapStr{"name": name}
+	if err := lgc.db.Table(common.BKTableNameCronJob).Delete(ctx, cond); err != nil {
+		blog.ErrorJSON("delete cron job table:%s, cond:%s, err:%s, rid:%s", common.BKTableNameCronJob, cond, err.Error(), lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommDBDeleteFailed)
+	}
+	if err := lgc.db.Table(common.BKTableNameCronJobHistory).Delete(ctx, cond); err != nil {
+		blog.ErrorJSON("delete cron job history table:%s, cond:%s, err:%s, rid:%s", common.BKTableNameCronJobHistory, cond, err.Error(), lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+// TriggerCronJob makes the cron job run at once, the master task server runs it in a few seconds
+func (lgc *Logics) TriggerCronJob(ctx context.Context, name string) error {
+	job, err := lgc.findCronJob(ctx, name)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return lgc.ccErr.Error(common.CCErrTaskCronJobNotFound)
+	}
+
+	cond := mapstr.MapStr{"name": name}
+	data := mapstr.MapStr{"triggered": true, common.LastTimeField: time.Now()}
+	if err := lgc.db.Table(common.BKTableNameCronJob).Update(ctx, cond, data); err != nil {
+		blog.ErrorJSON("trigger cron job table:%s, cond:%s, err:%s, rid:%s", common.BKTableNameCronJob, cond, err.Error(), lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// ListCronJob list the cron jobs
+func (lgc *Logics) ListCronJob(ctx context.Context, input *metadata.ListCronJobRequest) ([]metadata.CronJob, uint64, error) {
+	if input.Condition == nil {
+		input.Condition = mapstr.New()
+	}
+	if input.Page.IsIllegal() {
+		return nil, 0, lgc.ccErr.Errorf(common.CCErrCommPageLimitIsExceeded)
+	}
+	if input.Page.Sort == "" {
+		input.Page.Sort = "name"
+	}
+
+	cnt, err := lgc.db.Table(common.BKTableNameCronJob).Find(input.Condition).Count(ctx)
+	if err != nil {
+		blog.ErrorJSON("list cron job table:%s, input:%s, err:%s, rid:%s", common.BKTableNameCronJob, input, err.Error(), lgc.rid)
+		return nil, 0, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	rows := make([]metadata.CronJob, 0)
+	err = lgc.db.Table(common.BKTableNameCronJob).Find(input.Condition).
+		Start(uint64(input.Page.Start)).Limit(uint64(input.Page.Limit)).
+		Sort(input.Page.Sort).All(ctx, &rows)
+	if err != nil {
+		blog.ErrorJSON("list cron job table:%s, input:%s, err:%s, rid:%s", common.BKTableNameCronJob, input, err.Error(), lgc.rid)
+		return nil, 0, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	return rows, cnt, nil
+}
+
+// ListCronJobRun list the run history of the cron job, the latest run first
+func (lgc *Logics) ListCronJobRun(ctx context.Context, name string, input *metadata.ListCronJobRunRequest) ([]metadata.CronJobRun, uint64, error) {
+	if input.Page.IsIllegal() {
+		return nil, 0, lgc.ccErr.Errorf(common.CCErrCommPageLimitIsExceeded)
+	}
+	if input.Page.Sort == "" {
+		input.Page.Sort = "-scheduled_time"
+	}
+
+	cond := mapstr.MapStr{"name": name}
+	cnt, err := lgc.db.Table(common.BKTableNameCronJobHistory).Find(cond).Count(ctx)
+	if err != nil {
+		blog.ErrorJSON("list cron job history table:%s, cond:%s, err:%s, rid:%s", common.BKTableNameCronJobHistory, cond, err.Error(), lgc.rid)
+		return nil, 0, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	rows := make([]metadata.CronJobRun, 0)
+	err = lgc.db.Table(common.BKTableNameCronJobHistory).Find(cond).
+		Start(uint64(input.Page.Start)).Limit(uint64(input.Page.Limit)).
+		Sort(input.Page.Sort).All(ctx, &rows)
+	if err != nil {
+		blog.ErrorJSON("list cron job history table:%s, cond:%s, err:%s, rid:%s", common.BKTableNameCronJobHistory, cond, err.Error(), lgc.rid)
+		return nil, 0, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	return rows, cnt, nil
+}
+
+func (lgc *Logics) findCronJob(ctx context.Context, name string) (*metadata.CronJob, error) {
+	rows := make([]metadata.CronJob, 0)
+	cond := mapstr.MapStr{"name": name}
+	if err := lgc.db.Table(common.BKTableNameCronJob).Find(cond).All(ctx, &rows); err != nil {
+		blog.ErrorJSON("find cron job table:%s, cond:%s, err:%s, rid:%s", common.BKTableNameCronJob, cond, err.Error(), lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
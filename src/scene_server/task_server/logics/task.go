@@ -40,12 +40,17 @@ func (lgc *Logics) Create(ctx context.Context, input *metadata.CreateTaskRequest
 		return dbTask, lgc.ccErr.Errorf(common.CCErrCommParamsNeedString, "data")
 	}
 
+	if !validPriority(input.Priority) {
+		return dbTask, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "priority")
+	}
+
 	dbTask.TaskID = getStrTaskID("id")
 	dbTask.Name = input.Name
 	dbTask.User = lgc.user
 	dbTask.Flag = input.Flag
 	dbTask.Header = getDBHTTPHeader(lgc.header)
 	dbTask.Status = metadata.APITaskStatusNew
+	dbTask.Priority = input.Priority
 	dbTask.CreateTime = time.Now()
 	dbTask.LastTime = time.Now()
 	for _, taskItem := range input.Data {
@@ -217,6 +222,112 @@ func (lgc *Logics) changeStatus(ctx context.Context, taskID, subTaskID string, s
 	return nil
 }
 
+// taskStatusTransitions are the statuses a task can be changed to by the users, and the statuses
+// it can be changed from. an executing task stops before its next sub task when it's canceled or paused.
+var taskStatusTransitions = map[metadata.APITaskStatus][]metadata.APITaskStatus{
+	metadata.APITaskStatusCanceled: {metadata.APITaskStatusNew, metadata.APITaskStatusWaitExecute,
+		metadata.APITaskStatuExecute, metadata.APITaskStatusPaused},
+	metadata.APITaskStatusPaused: {metadata.APITaskStatusNew, metadata.APITaskStatusWaitExecute,
+		metadata.APITaskStatuExecute},
+	// resume a paused task
+	metadata.APITaskStatusWaitExecute: {metadata.APITaskStatusPaused},
+}
+
+func canChangeTaskStatus(from, to metadata.APITaskStatus) bool {
+	for _, status := range taskStatusTransitions[to] {
+		if status == from {
+			return true
+		}
+	}
+	return false
+}
+
+func validPriority(priority int64) bool {
+	return priority >= metadata.MinAPITaskPriority && priority <= metadata.MaxAPITaskPriority
+}
+
+// Cancel cancels a task, the sub tasks not executed are abandoned
+func (lgc *Logics) Cancel(ctx context.Context, taskID string) (*metadata.APITaskDetail, error) {
+	return lgc.changeTaskStatus(ctx, taskID, metadata.APITaskStatusCanceled)
+}
+
+// Pause pauses a task, it's not executed until it's resumed
+func (lgc *Logics) Pause(ctx context.Context, taskID string) (*metadata.APITaskDetail, error) {
+	return lgc.changeTaskStatus(ctx, taskID, metadata.APITaskStatusPaused)
+}
+
+// Resume resumes a paused task, the sub tasks not executed are executed again
+func (lgc *Logics) Resume(ctx context.Context, taskID string) (*metadata.APITaskDetail, error) {
+	return lgc.changeTaskStatus(ctx, taskID, metadata.APITaskStatusWaitExecute)
+}
+
+func (lgc *Logics) changeTaskStatus(ctx context.Context, taskID string, status metadata.APITaskStatus) (*metadata.APITaskDetail, error) {
+	if taskID == "" {
+		return nil, lgc.ccErr.CCErrorf(common.CCErrCommParamsNeedSet, "task_id")
+	}
+
+	task, err := lgc.Detail(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, lgc.ccErr.CCError(common.CCErrTaskNotFound)
+	}
+	if !canChangeTaskStatus(task.Status, status) {
+		blog.Errorf("change task %s status from %d to %d is not allowed, rid:%s", taskID, task.Status, status, lgc.rid)
+		return nil, lgc.ccErr.CCErrorf(common.CCErrTaskStatusNotAllowChangeTo, status)
+	}
+
+	// only change the task in the statuses allowed, in case the task is finished just now
+	condition := mapstr.New()
+	condition.Set("task_id", taskID)
+	condition.Set("status", mapstr.MapStr{common.BKDBIN: taskStatusTransitions[status]})
+	updateData := mapstr.New()
+	updateData.Set("status", status)
+	updateData.Set(common.LastTimeField, time.Now())
+	err = lgc.db.Table(common.BKTableNameAPITask).Update(ctx, condition, updateData)
+	if err != nil {
+		blog.ErrorJSON("change task status, table:%s, condition:%s, err:%s, rid:%s", common.BKTableNameAPITask, condition, err.Error(), lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+
+	task.Status = status
+	return task, nil
+}
+
+// UpdatePriority changes the priority of a task which is not finished
+func (lgc *Logics) UpdatePriority(ctx context.Context, taskID string, priority int64) error {
+	if taskID == "" {
+		return lgc.ccErr.CCErrorf(common.CCErrCommParamsNeedSet, "task_id")
+	}
+	if !validPriority(priority) {
+		return lgc.ccErr.CCErrorf(common.CCErrCommParamsInvalid, "priority")
+	}
+
+	task, err := lgc.Detail(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if task == nil {
+		return lgc.ccErr.CCError(common.CCErrTaskNotFound)
+	}
+	if task.Status.IsFinished() {
+		return lgc.ccErr.CCErrorf(common.CCErrTaskStatusNotAllowChangeTo, task.Status)
+	}
+
+	condition := mapstr.New()
+	condition.Set("task_id", taskID)
+	updateData := mapstr.New()
+	updateData.Set("priority", priority)
+	updateData.Set(common.LastTimeField, time.Now())
+	err = lgc.db.Table(common.BKTableNameAPITask).Update(ctx, condition, updateData)
+	if err != nil {
+		blog.ErrorJSON("update task priority, table:%s, condition:%s, err:%s, rid:%s", common.BKTableNameAPITask, condition, err.Error(), lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
 func getDBHTTPHeader(header http.Header) http.Header {
 
 	header.Del("Cookie")
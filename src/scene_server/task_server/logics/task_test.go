@@ -0,0 +1,51 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestCanChangeTaskStatus(t *testing.T) {
+	cases := []struct {
+		from   metadata.APITaskStatus
+		to     metadata.APITaskStatus
+		expect bool
+	}{
+		{metadata.APITaskStatusNew, metadata.APITaskStatusCanceled, true},
+		{metadata.APITaskStatuExecute, metadata.APITaskStatusCanceled, true},
+		{metadata.APITaskStatusPaused, metadata.APITaskStatusCanceled, true},
+		{metadata.APITaskStatusSuccess, metadata.APITaskStatusCanceled, false},
+		{metadata.APITaskStatusWaitExecute, metadata.APITaskStatusPaused, true},
+		{metadata.APITaskStatusCanceled, metadata.APITaskStatusPaused, false},
+		{metadata.APITaskStatusPaused, metadata.APITaskStatusWaitExecute, true},
+		{metadata.APITaskStatuExecute, metadata.APITaskStatusWaitExecute, false},
+		{metadata.APITaskStatusNew, metadata.APITaskStatusSuccess, false},
+	}
+	for _, c := range cases {
+		if canChangeTaskStatus(c.from, c.to) != c.expect {
+			t.Fatalf("change task status from %d to %d, expect %v", c.from, c.to, c.expect)
+		}
+	}
+}
+
+func TestValidPriority(t *testing.T) {
+	if !validPriority(0) || !validPriority(metadata.MaxAPITaskPriority) || !validPriority(metadata.MinAPITaskPriority) {
+		t.Fatal("priority in range should be valid")
+	}
+	if validPriority(metadata.MaxAPITaskPriority+1) || validPriority(metadata.MinAPITaskPriority-1) {
+		t.Fatal("priority out of range should be invalid")
+	}
+}
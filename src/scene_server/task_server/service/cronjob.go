@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+func (s *Service) UpsertCronJob(ctx *rest.Contexts) {
+	input := new(metadata.UpsertCronJobRequest)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	job, err := srvData.lgc.UpsertCronJob(srvData.ctx, input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(job)
+}
+
+func (s *Service) UpdateCronJob(ctx *rest.Contexts) {
+	input := new(metadata.UpdateCronJobRequest)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	if err := srvData.lgc.UpdateCronJob(srvData.ctx, ctx.Request.PathParameter("name"), input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *Service) DeleteCronJob(ctx *rest.Contexts) {
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	if err := srvData.lgc.DeleteCronJob(srvData.ctx, ctx.Request.PathParameter("name")); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *Service) TriggerCronJob(ctx *rest.Contexts) {
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	if err := srvData.lgc.TriggerCronJob(srvData.ctx, ctx.Request.PathParameter("name")); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *Service) ListCronJob(ctx *rest.Contexts) {
+	input := new(metadata.ListCronJobRequest)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	infos, cnt, err := srvData.lgc.ListCronJob(srvData.ctx, input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(metadata.ListCronJobData{
+		Info:  infos,
+		Count: int64(cnt),
+	})
+}
+
+func (s *Service) ListCronJobRun(ctx *rest.Contexts) {
+	input := new(metadata.ListCronJobRunRequest)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	infos, cnt, err := srvData.lgc.ListCronJobRun(srvData.ctx, ctx.Request.PathParameter("name"), input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(metadata.ListCronJobRunData{
+		Info:  infos,
+		Count: int64(cnt),
+	})
+}
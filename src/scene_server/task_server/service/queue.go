@@ -28,10 +28,26 @@ import (
 	"configcenter/src/common/types"
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/task_server/taskconfig"
+
+	"gopkg.in/redis.v5"
 )
 
 var (
 	dbMaxRetry = 20
+
+	// pollInterval is the max time a queue waits before it checks the tasks again
+	pollInterval = 5 * time.Second
+	// heartbeatInterval is how often an executing task refreshes its last time
+	heartbeatInterval = 30 * time.Second
+	// heartbeatTimeout an executing task without heartbeat for this long is considered interrupted
+	heartbeatTimeout = 3 * heartbeatInterval
+
+	// the sub task is retried with exponential backoff from retryInitialInterval to retryMaxInterval
+	retryInitialInterval = time.Second
+	retryMaxInterval     = time.Minute
+
+	defaultConcurrency    int64 = 1
+	defaultSubTaskTimeout       = 10 * time.Minute
 )
 
 type TaskInfo struct {
@@ -39,6 +55,10 @@ type TaskInfo struct {
 	Addr  func() ([]string, error)
 	Path  string
 	Retry int64
+	// Concurrency is the max tasks of the queue executed at the same time by all the task servers
+	Concurrency int64
+	// Timeout is the max time a sub task request can take
+	Timeout time.Duration
 }
 
 type TaskQueue struct {
@@ -55,10 +75,25 @@ func (s *Service) NewQueue(taskMap map[string]TaskInfo) *TaskQueue {
 		taskMap = make(map[string]TaskInfo)
 	}
 	for name, taskInfo := range codeTaskInfoMap {
+		// the limits of the code task can be tuned by the config
+		if confTaskInfo, exist := taskMap[name]; exist {
+			if confTaskInfo.Concurrency > 0 {
+				taskInfo.Concurrency = confTaskInfo.Concurrency
+			}
+			if confTaskInfo.Timeout > 0 {
+				taskInfo.Timeout = confTaskInfo.Timeout
+			}
+		}
 		taskMap[name] = taskInfo
 	}
 
 	for _, taskItem := range taskMap {
+		if taskItem.Concurrency <= 0 {
+			taskItem.Concurrency = defaultConcurrency
+		}
+		if taskItem.Timeout <= 0 {
+			taskItem.Timeout = defaultSubTaskTimeout
+		}
 		taskUtil.UpdateTaskServerConfigServ(taskItem.Name, taskItem.Addr)
 		taskArr = append(taskArr, taskItem)
 	}
@@ -80,8 +115,8 @@ func (tq *TaskQueue) Start() {
 	go tq.compensate(context.Background())
 	for _, taskInfo := range tq.task {
 
+		tq.Add(1)
 		go func(taskInfo TaskInfo) {
-			tq.Add(1)
 			defer tq.Done()
 			tq.executeWrap(context.Background(), taskInfo)
 		}(taskInfo)
@@ -112,69 +147,83 @@ func (tq *TaskQueue) execute(ctx context.Context, task TaskInfo) {
 		if tq.close {
 			return
 		}
-		canSleep := true
-		taskQueueInfoArr, err := tq.getWaitExectue(ctx, task.Name)
+		taskQueueInfo, err := tq.next(ctx, task)
 		if err != nil {
 			blog.Errorf("execute get wait execute task error. task name:%s, err:%s", task.Name, err.Error())
 			// select db error. sleep 10s
 			time.Sleep(time.Second * 10)
 			continue
 		}
-		if len(taskQueueInfoArr) == 0 {
-			// not task. sleep 5s
-			time.Sleep(time.Second * 5)
+		if taskQueueInfo == nil {
+			// no task or the queue is full, wait until a task is added or finished
+			tq.wait(task.Name)
 			continue
 		}
-		for _, taskQueueInfo := range taskQueueInfoArr {
-			if tq.close {
-				return
-			}
-			execute := tq.executeTaskQueueItem(ctx, task, taskQueueInfo)
-			if execute {
-				canSleep = false
-			}
-
-		}
 
-		if canSleep {
-			time.Sleep(time.Second * 10)
-		}
+		tq.Add(1)
+		go func() {
+			defer tq.Done()
+			tq.executeTaskQueueItem(ctx, task, taskQueueInfo)
+		}()
 	}
 }
 
-// executeTaskQueueItem  返回是否执行任务
-func (tq *TaskQueue) executeTaskQueueItem(ctx context.Context, taskInfo TaskInfo, taskQueueInfo metadata.APITaskDetail) (execute bool) {
-
-	locked, err := tq.lockTask(ctx, taskQueueInfo.TaskID)
-	blog.Infof("start task %s", taskQueueInfo.TaskID)
+// next picks the task with the highest priority of the queue and changes it to executing, it returns
+// nil if there is no task to execute or the queue has reached its concurrency limit.
+func (tq *TaskQueue) next(ctx context.Context, taskInfo TaskInfo) (*metadata.APITaskDetail, error) {
+	locked, err := tq.lockQueue(ctx, taskInfo.Name)
 	if err != nil {
-		blog.Errorf("exceute task. lock error. task name:%s, taskID:%s, err:%s", taskInfo.Name, taskQueueInfo.TaskID, err.Error())
-		time.Sleep(time.Second)
-		return
+		return nil, err
 	}
 	if !locked {
-		return
+		return nil, nil
+	}
+	defer tq.unLockQueue(ctx, taskInfo.Name)
+
+	cond := condition.CreateCondition()
+	cond.Field("name").Eq(taskInfo.Name)
+	cond.Field("status").Eq(metadata.APITaskStatuExecute)
+	executing, err := tq.service.DB.Table(common.BKTableNameAPITask).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		blog.ErrorJSON("count executing task error:%s, task queue task:%s, cond:%s", err.Error(), taskInfo.Name, cond.ToMapStr())
+		return nil, tq.service.CCErr.Error("zh-cn", common.CCErrCommDBSelectFailed)
+	}
+	if int64(executing) >= taskInfo.Concurrency {
+		return nil, nil
+	}
+
+	taskQueueInfo, err := tq.getWaitExectue(ctx, taskInfo.Name)
+	if err != nil || taskQueueInfo == nil {
+		return nil, err
 	}
 	canExecute, err := tq.changeTaskToExecuting(ctx, taskQueueInfo.TaskID)
 	blog.Infof("change task %s to executing, can execute %v", taskQueueInfo.TaskID, canExecute)
-	if err != nil {
-		tq.unLockTask(ctx, taskQueueInfo.TaskID)
-		time.Sleep(time.Second)
-		return
+	if err != nil || !canExecute {
+		return nil, err
 	}
-	if !canExecute {
-		if err := tq.unLockTask(ctx, taskQueueInfo.TaskID); err != nil {
-			blog.Errorf("exceute task. task cann't execute. unlock error. task name:%s, taskID:%s, err:%s", taskInfo.Name, taskQueueInfo.TaskID, err.Error())
+	taskQueueInfo.Status = metadata.APITaskStatuExecute
+	return taskQueueInfo, nil
+}
+
+// executeTaskQueueItem executes a task which has been changed to executing
+func (tq *TaskQueue) executeTaskQueueItem(ctx context.Context, taskInfo TaskInfo, taskQueueInfo *metadata.APITaskDetail) {
+	defer func() {
+		if fetalErr := recover(); fetalErr != nil {
+			blog.Errorf("execute task %s, err:%s, panic:%s", taskQueueInfo.TaskID, fetalErr, debug.Stack())
 		}
-		return
-	}
-	tq.executePush(ctx, taskInfo, &taskQueueInfo)
-	return true
+		// the queue may wait for the task to finish
+		tq.service.notifyQueue(taskInfo.Name)
+	}()
+
+	blog.Infof("start task %s", taskQueueInfo.TaskID)
+	stop := make(chan struct{})
+	defer close(stop)
+	go tq.heartbeat(ctx, taskQueueInfo.TaskID, stop)
+
+	tq.executePush(ctx, taskInfo, taskQueueInfo)
 }
 
 func (tq *TaskQueue) executePush(ctx context.Context, taskInfo TaskInfo, taskQueue *metadata.APITaskDetail) {
-	var resp *metadata.Response
-	var err error
 	blog.InfoJSON("task execute task id:%s", taskQueue.TaskID)
 
 	allSucc := true
@@ -190,16 +239,18 @@ func (tq *TaskQueue) executePush(ctx context.Context, taskInfo TaskInfo, taskQue
 			allSucc = false
 			break
 		}
-		for retry := int64(0); retry < taskInfo.Retry; retry++ {
-			resp, err = tq.service.CoreAPI.TaskServer().Queue(taskInfo.Name).Post(ctx, taskQueue.Header, taskInfo.Path, subTask.Data)
-			if err != nil {
-				time.Sleep(time.Millisecond * 100)
-				blog.ErrorJSON("task execute http do error. taskID:%s, path:%s, taskName:%s, err:%s", taskQueue.TaskID, taskInfo.Path, taskInfo.Name, err.Error())
-				continue
-			}
-			break
+
+		// the task may be canceled or paused while the former sub task is executing
+		status, err := tq.getTaskStatus(ctx, taskQueue.TaskID)
+		if err != nil {
+			blog.Errorf("get task %s status failed, err: %v", taskQueue.TaskID, err)
+		} else if status != metadata.APITaskStatuExecute {
+			blog.Infof("task %s status changed to %d, stop executing it", taskQueue.TaskID, status)
+			return
 		}
 
+		resp, attempts, err := tq.pushSubTask(ctx, taskInfo, taskQueue, subTask)
+
 		updateConditon := mapstr.New()
 		updateConditon.Set("task_id", taskQueue.TaskID)
 		updateConditon.Set("detail.sub_task_id", subTask.SubTaskID)
@@ -218,28 +269,20 @@ func (tq *TaskQueue) executePush(ctx context.Context, taskInfo TaskInfo, taskQue
 		if err != nil || !resp.Result {
 			allSucc = false
 			updateData.Set("detail.$.status", metadata.APITAskStatusFail)
-			updateData.Set("status", metadata.APITAskStatusFail)
 		} else {
 			updateData.Set("detail.$.status", metadata.APITaskStatusSuccess)
 		}
 		updateData.Set("detail.$.response", errResponse)
+		updateData.Set("detail.$.attempts", subTask.Attempts+attempts)
 		updateData.Set(common.LastTimeField, time.Now())
 
-		for dbRetry := 0; dbRetry < dbMaxRetry; dbRetry++ {
-			dbErr := tq.service.DB.Table(common.BKTableNameAPITask).Update(ctx, updateConditon, updateData)
-			if dbErr != nil {
-				blog.ErrorJSON("task execute http do error. taskID:%s, err:%s", taskQueue.TaskID, dbErr)
-				time.Sleep(time.Second * 3)
-				continue
-			}
-			break
-		}
-
+		tq.updateTask(ctx, taskQueue.TaskID, updateConditon, updateData)
 	}
 
-	// 所有任务执行完成，修改整个任务状态
+	// 所有任务执行完成，修改整个任务状态。任务执行过程中被取消或暂停的，保留取消或暂停状态
 	updateConditon := mapstr.New()
 	updateConditon.Set("task_id", taskQueue.TaskID)
+	updateConditon.Set("status", metadata.APITaskStatuExecute)
 	updateData := mapstr.New()
 	if allSucc {
 		updateData.Set("status", metadata.APITaskStatusSuccess)
@@ -248,55 +291,156 @@ func (tq *TaskQueue) executePush(ctx context.Context, taskInfo TaskInfo, taskQue
 	}
 	updateData.Set(common.LastTimeField, time.Now())
 
+	tq.updateTask(ctx, taskQueue.TaskID, updateConditon, updateData)
+	return
+}
+
+// pushSubTask sends the sub task to the queue's service, the request is retried with
+// exponential backoff if it fails. it returns the response and how many times it's sent.
+func (tq *TaskQueue) pushSubTask(ctx context.Context, taskInfo TaskInfo, taskQueue *metadata.APITaskDetail,
+	subTask metadata.APISubTaskDetail) (resp *metadata.Response, attempts int64, err error) {
+
+	for attempts < taskInfo.Retry {
+		if attempts > 0 {
+			time.Sleep(retryBackoff(attempts - 1))
+		}
+		attempts++
+
+		subCtx, cancel := context.WithTimeout(ctx, taskInfo.Timeout)
+		resp, err = tq.service.CoreAPI.TaskServer().Queue(taskInfo.Name).Post(subCtx, taskQueue.Header, taskInfo.Path, subTask.Data)
+		cancel()
+		if err == nil {
+			return resp, attempts, nil
+		}
+		blog.ErrorJSON("task execute http do error. taskID:%s, subTaskID:%s, path:%s, taskName:%s, attempts:%s, err:%s",
+			taskQueue.TaskID, subTask.SubTaskID, taskInfo.Path, taskInfo.Name, attempts, err.Error())
+	}
+	return resp, attempts, err
+}
+
+// retryBackoff returns the time to wait before the retry, which doubles every retry
+func retryBackoff(retry int64) time.Duration {
+	backoff := retryInitialInterval
+	for i := int64(0); i < retry && backoff < retryMaxInterval; i++ {
+		backoff *= 2
+	}
+	if backoff > retryMaxInterval {
+		backoff = retryMaxInterval
+	}
+	return backoff
+}
+
+func (tq *TaskQueue) updateTask(ctx context.Context, taskID string, updateConditon, updateData mapstr.MapStr) {
 	for dbRetry := 0; dbRetry < dbMaxRetry; dbRetry++ {
 		dbErr := tq.service.DB.Table(common.BKTableNameAPITask).Update(ctx, updateConditon, updateData)
 		if dbErr != nil {
-			blog.ErrorJSON("task execute http do error. taskID:%s, err:%s", taskQueue.TaskID, dbErr)
+			blog.ErrorJSON("task execute http do error. taskID:%s, err:%s", taskID, dbErr)
 			time.Sleep(time.Second * 3)
 			continue
 		}
 		break
 	}
+}
 
-	return
+// heartbeat refreshes the last time of the executing task until it's stopped, so that the
+// task is not compensated as an interrupted one.
+func (tq *TaskQueue) heartbeat(ctx context.Context, taskID string, stop <-chan struct{}) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		cond := condition.CreateCondition()
+		cond.Field("task_id").Eq(taskID)
+		cond.Field("status").Eq(metadata.APITaskStatuExecute)
+		data := mapstr.MapStr{common.LastTimeField: time.Now()}
+		if err := tq.service.DB.Table(common.BKTableNameAPITask).Update(ctx, cond.ToMapStr(), data); err != nil {
+			blog.ErrorJSON("refresh executing task last time error:%s, cond:%s", err.Error(), cond.ToMapStr())
+		}
+	}
+}
+
+func (tq *TaskQueue) getTaskStatus(ctx context.Context, taskID string) (metadata.APITaskStatus, error) {
+	cond := condition.CreateCondition()
+	cond.Field("task_id").Eq(taskID)
+
+	task := metadata.APITaskDetail{}
+	err := tq.service.DB.Table(common.BKTableNameAPITask).Find(cond.ToMapStr()).Fields("status").One(ctx, &task)
+	if err != nil {
+		return 0, err
+	}
+	return task.Status, nil
 }
 
-func (tq *TaskQueue) lockTask(ctx context.Context, taskID string) (locked bool, err error) {
+func (tq *TaskQueue) lockQueue(ctx context.Context, name string) (locked bool, err error) {
 
-	key := fmt.Sprintf("%s:apiTask:%s", common.BKCacheKeyV3Prefix, taskID)
-	locked, err = tq.service.CacheDB.SetNX(key, time.Now(), time.Minute*2).Result()
+	key := fmt.Sprintf("%s:apiTaskQueue:%s", common.BKCacheKeyV3Prefix, name)
+	locked, err = tq.service.CacheDB.SetNX(key, time.Now(), time.Second*30).Result()
 	if err != nil {
-		blog.Errorf("lock task error. err:%s, taskID:%s", err.Error(), taskID)
+		blog.Errorf("lock task queue error. err:%s, name:%s", err.Error(), name)
 		return false, tq.service.CCErr.Error("zh-cn", common.CCErrTaskLockedTaskFail)
 	}
 	return locked, nil
 }
 
-func (tq *TaskQueue) unLockTask(ctx context.Context, taskID string) (err error) {
+func (tq *TaskQueue) unLockQueue(ctx context.Context, name string) (err error) {
 
-	key := fmt.Sprintf("%s:apiTask:%s", common.BKCacheKeyV3Prefix, taskID)
+	key := fmt.Sprintf("%s:apiTaskQueue:%s", common.BKCacheKeyV3Prefix, name)
 	_, err = tq.service.CacheDB.Del(key).Result()
 	if err != nil {
-		blog.Errorf("unlock task error. err:%s, taskID:%s", err.Error(), taskID)
+		blog.Errorf("unlock task queue error. err:%s, name:%s", err.Error(), name)
 		return tq.service.CCErr.Error("zh-cn", common.CCErrTaskUnLockedTaskFail)
 	}
 	return nil
 }
 
-func (tq *TaskQueue) getWaitExectue(ctx context.Context, name string) ([]metadata.APITaskDetail, error) {
+// wait blocks until the queue is notified or the poll interval passes
+func (tq *TaskQueue) wait(name string) {
+	_, err := tq.service.CacheDB.BRPop(pollInterval, queueNotifyKey(name)).Result()
+	if err != nil && err != redis.Nil {
+		blog.Errorf("wait task queue %s error. err:%s", name, err.Error())
+		time.Sleep(pollInterval)
+	}
+}
+
+// notifyQueue wakes up a task server waiting on the queue, when a task is added or finished.
+func (s *Service) notifyQueue(name string) {
+	key := queueNotifyKey(name)
+	if err := s.CacheDB.LPush(key, time.Now().Unix()).Err(); err != nil {
+		blog.Errorf("notify task queue %s error. err:%s", name, err.Error())
+		return
+	}
+	// the notifications are only wake up signals, keep a few of them
+	if err := s.CacheDB.LTrim(key, 0, 9).Err(); err != nil {
+		blog.Errorf("trim task queue %s notifications error. err:%s", name, err.Error())
+	}
+}
+
+func queueNotifyKey(name string) string {
+	return fmt.Sprintf("%s:apiTaskQueue:notify:%s", common.BKCacheKeyV3Prefix, name)
+}
+
+func (tq *TaskQueue) getWaitExectue(ctx context.Context, name string) (*metadata.APITaskDetail, error) {
 
 	cond := condition.CreateCondition()
 	cond.Field("name").Eq(name)
 	cond.Field("status").In([]metadata.APITaskStatus{metadata.APITaskStatusNew, metadata.APITaskStatusWaitExecute})
 
 	rows := make([]metadata.APITaskDetail, 0)
-	err := tq.service.DB.Table(common.BKTableNameAPITask).Find(cond.ToMapStr()).Sort("create_time").Limit(20).All(ctx, &rows)
+	err := tq.service.DB.Table(common.BKTableNameAPITask).Find(cond.ToMapStr()).Sort("-priority,create_time").Limit(1).All(ctx, &rows)
 	if err != nil {
 		blog.ErrorJSON("query wait execute error:%s, task queue task:%s, cond:%s", err.Error(), name, cond.ToMapStr())
 		return nil, tq.service.CCErr.Error("zh-cn", common.CCErrCommDBSelectFailed)
 	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
 
-	return rows, nil
+	return &rows[0], nil
 }
 
 func (tq *TaskQueue) changeTaskToExecuting(ctx context.Context, taskID string) (bool, error) {
@@ -328,7 +472,7 @@ func (tq *TaskQueue) changeTaskToExecuting(ctx context.Context, taskID string) (
 func (tq *TaskQueue) compensate(ctx context.Context) {
 	go func() {
 		tq.compensateDBExecute(ctx)
-		timer := time.NewTicker(time.Minute * 10)
+		timer := time.NewTicker(time.Minute)
 		for range timer.C {
 			if tq.close {
 				return
@@ -339,10 +483,12 @@ func (tq *TaskQueue) compensate(ctx context.Context) {
 	}()
 }
 
+// compensateDBExecute changes the executing tasks without heartbeat back to wait execute,
+// their task server is probably down.
 func (tq *TaskQueue) compensateDBExecute(ctx context.Context) {
 	cond := condition.CreateCondition()
 	cond.Field("status").In([]metadata.APITaskStatus{metadata.APITaskStatuExecute})
-	cond.Field(common.LastTimeField).Lt(time.Now().Add(-time.Minute * 20))
+	cond.Field(common.LastTimeField).Lt(time.Now().Add(-heartbeatTimeout))
 	data := mapstr.MapStr{
 		"status":             metadata.APITaskStatusWaitExecute,
 		common.LastTimeField: time.Now(),
@@ -359,9 +505,11 @@ func (s *Service) initCodeTaskConfig() map[string]TaskInfo {
 
 	for _, codeTaskConfig := range codeTaskConfigArr {
 		ti := TaskInfo{
-			Name:  codeTaskConfig.Name,
-			Retry: codeTaskConfig.Retry,
-			Path:  codeTaskConfig.Path,
+			Name:        codeTaskConfig.Name,
+			Retry:       codeTaskConfig.Retry,
+			Path:        codeTaskConfig.Path,
+			Concurrency: codeTaskConfig.Concurrency,
+			Timeout:     codeTaskConfig.Timeout,
 		}
 		switch codeTaskConfig.SvrType {
 		case types.CC_MODULE_APISERVER:
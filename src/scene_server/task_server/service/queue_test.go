@@ -0,0 +1,30 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	expects := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}
+	for retry, expect := range expects {
+		if backoff := retryBackoff(int64(retry)); backoff != expect {
+			t.Fatalf("retry %d expect backoff %v, but got %v", retry, expect, backoff)
+		}
+	}
+	if backoff := retryBackoff(100); backoff != retryMaxInterval {
+		t.Fatalf("backoff should not exceed %v, but got %v", retryMaxInterval, backoff)
+	}
+}
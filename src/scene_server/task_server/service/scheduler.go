@@ -0,0 +1,371 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	taskUtil "configcenter/src/apimachinery/taskserver/util"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/task_server/logics"
+	"configcenter/src/scene_server/task_server/taskconfig"
+)
+
+var (
+	// cronCheckInterval is how often the master task server checks the jobs to run
+	cronCheckInterval = 10 * time.Second
+	// cronMissedThreshold a run which is late for this long is considered missed
+	cronMissedThreshold = time.Minute
+	// cronHistoryRetention the run history older than this is removed
+	cronHistoryRetention = 30 * 24 * time.Hour
+
+	defaultCronJobTimeout = 10 * time.Minute
+)
+
+// CronScheduler runs the cron jobs on the master task server
+type CronScheduler struct {
+	service *Service
+	// server the address of this task server, which is saved in the run history
+	server string
+	// confSpecs the cron expressions of the builtin jobs set in the config
+	confSpecs map[string]string
+
+	lock    sync.Mutex
+	running map[string]bool
+}
+
+// NewCronScheduler creates the scheduler, confSpecs overwrites the cron expressions of the builtin jobs.
+func (s *Service) NewCronScheduler(server string, confSpecs map[string]string) *CronScheduler {
+	if confSpecs == nil {
+		confSpecs = make(map[string]string)
+	}
+	return &CronScheduler{
+		service:   s,
+		server:    server,
+		confSpecs: confSpecs,
+		running:   make(map[string]bool),
+	}
+}
+
+// Start checks the jobs periodically, only the master task server runs them.
+func (cs *CronScheduler) Start(ctx context.Context) {
+	for _, svrType := range logics.CronJobSvrTypes {
+		servers := logics.CronJobServers(cs.service.Engine.Discovery(), svrType)
+		taskUtil.UpdateTaskServerConfigServ(cronJobQueueName(svrType), servers.GetServers)
+	}
+
+	go func() {
+		isMaster := false
+		ticker := time.NewTicker(cronCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if !cs.service.Engine.ServiceManageInterface.IsMaster() {
+				isMaster = false
+				continue
+			}
+			if !isMaster {
+				// the builtin jobs are registered every time the task server becomes master
+				if err := cs.initBuiltinJobs(ctx); err != nil {
+					blog.Errorf("init builtin cron jobs failed, err: %v", err)
+					continue
+				}
+				isMaster = true
+			}
+			cs.check(ctx)
+		}
+	}()
+}
+
+// initBuiltinJobs adds the builtin jobs that not exist, the changes made by api are kept,
+// but the cron expressions set in the config have higher priority.
+func (cs *CronScheduler) initBuiltinJobs(ctx context.Context) error {
+	for _, conf := range taskconfig.GetCodeCronJobConfig() {
+		cond := mapstr.MapStr{"name": conf.Name}
+		jobs := make([]metadata.CronJob, 0)
+		if err := cs.service.DB.Table(common.BKTableNameCronJob).Find(cond).All(ctx, &jobs); err != nil {
+			return err
+		}
+
+		spec := conf.Spec
+		if confSpec, exist := cs.confSpecs[conf.Name]; exist {
+			spec = confSpec
+		}
+		now := time.Now()
+		nextRunTime, err := logics.NextRunTime(spec, now)
+		if err != nil {
+			blog.Errorf("parse builtin cron job %s spec %s failed, use the default spec, err: %v", conf.Name, spec, err)
+			spec = conf.Spec
+			if nextRunTime, err = logics.NextRunTime(spec, now); err != nil {
+				return fmt.Errorf("parse builtin cron job %s spec %s failed, err: %v", conf.Name, spec, err)
+			}
+		}
+
+		if len(jobs) == 0 {
+			job := metadata.CronJob{
+				Name:         conf.Name,
+				Spec:         spec,
+				SvrType:      conf.SvrType,
+				Path:         conf.Path,
+				OwnerID:      common.BKSuperOwnerID,
+				User:         common.BKProcInstanceOpUser,
+				Enabled:      true,
+				MissedPolicy: conf.MissedPolicy,
+				Builtin:      true,
+				NextRunTime:  nextRunTime,
+				CreateTime:   now,
+				LastTime:     now,
+			}
+			if err := cs.service.DB.Table(common.BKTableNameCronJob).Insert(ctx, job); err != nil {
+				return err
+			}
+			continue
+		}
+
+		data := mapstr.MapStr{
+			"svr_type": conf.SvrType,
+			"path":     conf.Path,
+			"builtin":  true,
+		}
+		if spec != jobs[0].Spec && cs.confSpecs[conf.Name] != "" {
+			data.Set("spec", spec)
+			data.Set("next_run_time", nextRunTime)
+		}
+		if err := cs.service.DB.Table(common.BKTableNameCronJob).Update(ctx, cond, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check runs the enabled jobs which are due or triggered manually
+func (cs *CronScheduler) check(ctx context.Context) {
+	now := time.Now()
+	cond := mapstr.MapStr{
+		"enabled": true,
+		common.BKDBOR: []mapstr.MapStr{
+			{"next_run_time": mapstr.MapStr{common.BKDBLTE: now}},
+			{"triggered": true},
+		},
+	}
+	jobs := make([]metadata.CronJob, 0)
+	if err := cs.service.DB.Table(common.BKTableNameCronJob).Find(cond).All(ctx, &jobs); err != nil {
+		blog.ErrorJSON("find cron jobs to run error:%s, cond:%s", err.Error(), cond)
+		return
+	}
+
+	for _, job := range jobs {
+		cs.schedule(ctx, job, now)
+	}
+}
+
+// cronJobAction what the scheduler does with a job when it's checked
+type cronJobAction int
+
+const (
+	cronJobWait cronJobAction = iota
+	cronJobRun
+	cronJobSkip
+)
+
+// decideCronJobAction decides whether the job should run now, a due run that is late for longer than
+// the missed threshold is skipped unless the job's missed policy is run once.
+func decideCronJobAction(job *metadata.CronJob, now time.Time) cronJobAction {
+	if job.Triggered {
+		return cronJobRun
+	}
+	if job.NextRunTime.After(now) {
+		return cronJobWait
+	}
+	if now.Sub(job.NextRunTime) > cronMissedThreshold && job.MissedPolicy != metadata.CronJobMissedRunOnce {
+		return cronJobSkip
+	}
+	return cronJobRun
+}
+
+func (cs *CronScheduler) schedule(ctx context.Context, job metadata.CronJob, now time.Time) {
+	action := decideCronJobAction(&job, now)
+	if action == cronJobWait {
+		return
+	}
+
+	// the lock makes sure a run is only scheduled once when the master is changing
+	scheduled := job.NextRunTime
+	if job.Triggered {
+		scheduled = now
+	}
+	locked, err := cs.lockRun(job.Name, scheduled, job.Triggered)
+	if err != nil || !locked {
+		return
+	}
+
+	data := mapstr.MapStr{}
+	if job.Triggered {
+		data.Set("triggered", false)
+	}
+	if !job.NextRunTime.After(now) {
+		nextRunTime, err := logics.NextRunTime(job.Spec, now)
+		if err != nil {
+			blog.Errorf("parse cron job %s spec %s failed, disable it, err: %v", job.Name, job.Spec, err)
+			data.Set("enabled", false)
+		} else {
+			data.Set("next_run_time", nextRunTime)
+		}
+	}
+	cond := mapstr.MapStr{"name": job.Name}
+	if err := cs.service.DB.Table(common.BKTableNameCronJob).Update(ctx, cond, data); err != nil {
+		blog.ErrorJSON("update cron job next run time error:%s, cond:%s, data:%s", err.Error(), cond, data)
+		return
+	}
+
+	run := &metadata.CronJobRun{
+		Name:          job.Name,
+		ScheduledTime: scheduled,
+		StartTime:     now,
+		Manual:        job.Triggered,
+		Server:        cs.server,
+	}
+	if action == cronJobSkip {
+		run.Status = metadata.CronJobRunSkipped
+		run.Message = fmt.Sprintf("the run is missed, the task server is not master at %s", scheduled.Format(time.RFC3339))
+		cs.finishRun(ctx, &job, run)
+		return
+	}
+
+	cs.lock.Lock()
+	if cs.running[job.Name] {
+		cs.lock.Unlock()
+		run.Status = metadata.CronJobRunSkipped
+		run.Message = "the former run is not finished"
+		cs.finishRun(ctx, &job, run)
+		return
+	}
+	cs.running[job.Name] = true
+	cs.lock.Unlock()
+
+	go func() {
+		defer func() {
+			if fetalErr := recover(); fetalErr != nil {
+				blog.Errorf("run cron job %s, err:%s, panic:%s", job.Name, fetalErr, debug.Stack())
+			}
+			cs.lock.Lock()
+			delete(cs.running, job.Name)
+			cs.lock.Unlock()
+		}()
+		cs.run(ctx, &job, run)
+	}()
+}
+
+// run sends the job to its service and saves the result
+func (cs *CronScheduler) run(ctx context.Context, job *metadata.CronJob, run *metadata.CronJobRun) {
+	if logics.CronJobServers(cs.service.Engine.Discovery(), job.SvrType) == nil {
+		run.Status = metadata.CronJobRunFailure
+		run.Message = fmt.Sprintf("service %s is not supported", job.SvrType)
+		cs.finishRun(ctx, job, run)
+		return
+	}
+
+	timeout := defaultCronJobTimeout
+	if job.Timeout > 0 {
+		timeout = time.Duration(job.Timeout) * time.Second
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	blog.Infof("start cron job %s, scheduled at %s", job.Name, run.ScheduledTime)
+	resp, err := cs.service.CoreAPI.TaskServer().Queue(cronJobQueueName(job.SvrType)).Post(runCtx, cronJobHeader(job), job.Path, job.Data)
+	switch {
+	case err != nil:
+		blog.Errorf("run cron job %s failed, path: %s, err: %v", job.Name, job.Path, err)
+		run.Status = metadata.CronJobRunFailure
+		run.Message = err.Error()
+	case !resp.Result:
+		run.Status = metadata.CronJobRunFailure
+		run.Response = resp
+	default:
+		run.Status = metadata.CronJobRunSuccess
+		run.Response = resp
+	}
+	cs.finishRun(ctx, job, run)
+}
+
+// cronJobQueueName the name the servers of the service are registered with to send the jobs
+func cronJobQueueName(svrType string) string {
+	return "cron-" + svrType
+}
+
+func cronJobHeader(job *metadata.CronJob) http.Header {
+	header := make(http.Header)
+	ownerID := job.OwnerID
+	if ownerID == "" {
+		ownerID = common.BKSuperOwnerID
+	}
+	user := job.User
+	if user == "" {
+		user = common.BKProcInstanceOpUser
+	}
+	header.Set(common.BKHTTPOwnerID, ownerID)
+	header.Set(common.BKHTTPHeaderUser, user)
+	header.Set(common.BKHTTPCCRequestID, util.GenerateRID())
+	header.Set("Content-Type", "application/json")
+	return header
+}
+
+// finishRun saves the run history and the last status of the job
+func (cs *CronScheduler) finishRun(ctx context.Context, job *metadata.CronJob, run *metadata.CronJobRun) {
+	run.EndTime = time.Now()
+	if err := cs.service.DB.Table(common.BKTableNameCronJobHistory).Insert(ctx, run); err != nil {
+		blog.ErrorJSON("save cron job run history error:%s, run:%s", err.Error(), run)
+	}
+
+	cond := mapstr.MapStr{"name": job.Name}
+	data := mapstr.MapStr{
+		"last_run_time": run.StartTime,
+		"last_status":   run.Status,
+	}
+	if err := cs.service.DB.Table(common.BKTableNameCronJob).Update(ctx, cond, data); err != nil {
+		blog.ErrorJSON("update cron job last status error:%s, cond:%s", err.Error(), cond)
+	}
+
+	expiredCond := mapstr.MapStr{
+		"name":           job.Name,
+		"scheduled_time": mapstr.MapStr{common.BKDBLT: time.Now().Add(-cronHistoryRetention)},
+	}
+	if err := cs.service.DB.Table(common.BKTableNameCronJobHistory).Delete(ctx, expiredCond); err != nil {
+		blog.ErrorJSON("remove expired cron job run history error:%s, cond:%s", err.Error(), expiredCond)
+	}
+}
+
+func (cs *CronScheduler) lockRun(name string, scheduled time.Time, manual bool) (bool, error) {
+	key := fmt.Sprintf("%s:cronJob:%s:%d:%v", common.BKCacheKeyV3Prefix, name, scheduled.Unix(), manual)
+	locked, err := cs.service.CacheDB.SetNX(key, cs.server, time.Hour).Result()
+	if err != nil {
+		blog.Errorf("lock cron job %s run error. err:%s", name, err.Error())
+		return false, err
+	}
+	return locked, nil
+}
@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/task_server/logics"
+)
+
+func TestDecideCronJobAction(t *testing.T) {
+	now := time.Date(2020, 5, 25, 0, 30, 5, 0, time.Local)
+	cases := []struct {
+		name   string
+		job    metadata.CronJob
+		expect cronJobAction
+	}{
+		{"not due", metadata.CronJob{NextRunTime: now.Add(time.Second)}, cronJobWait},
+		{"due", metadata.CronJob{NextRunTime: now.Add(-5 * time.Second)}, cronJobRun},
+		{"missed and skip", metadata.CronJob{NextRunTime: now.Add(-time.Hour)}, cronJobSkip},
+		{"missed and run once", metadata.CronJob{NextRunTime: now.Add(-time.Hour),
+			MissedPolicy: metadata.CronJobMissedRunOnce}, cronJobRun},
+		{"triggered", metadata.CronJob{NextRunTime: now.Add(time.Hour), Triggered: true}, cronJobRun},
+	}
+	for _, c := range cases {
+		if action := decideCronJobAction(&c.job, now); action != c.expect {
+			t.Fatalf("%s: expect action %d, but got %d", c.name, c.expect, action)
+		}
+	}
+}
+
+func TestNextRunTime(t *testing.T) {
+	from := time.Date(2020, 5, 25, 0, 30, 0, 0, time.Local)
+	next, err := logics.NextRunTime("30 0 * * *", from)
+	if err != nil {
+		t.Fatalf("parse spec failed, err: %v", err)
+	}
+	if expect := from.AddDate(0, 0, 1); !next.Equal(expect) {
+		t.Fatalf("expect next run time %v, but got %v", expect, next)
+	}
+
+	if _, err := logics.NextRunTime("every day", from); err == nil {
+		t.Fatal("expect error for invalid spec")
+	}
+}
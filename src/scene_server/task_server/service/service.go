@@ -108,6 +108,18 @@ func (s *Service) addAPIService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/findone/detail/{task_id}", Handler: s.DetailTask})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/task/set/status/sucess/id/{task_id}/sub_id/{sub_task_id}", Handler: s.StatusToSuccess})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/task/set/status/failure/id/{task_id}/sub_id/{sub_task_id}", Handler: s.StatusToFailure})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/task/set/cancel/id/{task_id}", Handler: s.CancelTask})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/task/set/pause/id/{task_id}", Handler: s.PauseTask})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/task/set/resume/id/{task_id}", Handler: s.ResumeTask})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/task/set/priority/id/{task_id}", Handler: s.UpdateTaskPriority})
+
+	// cron job
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/createorupdate/cron_job", Handler: s.UpsertCronJob})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/task/update/cron_job/name/{name}", Handler: s.UpdateCronJob})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/task/delete/cron_job/name/{name}", Handler: s.DeleteCronJob})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/trigger/cron_job/name/{name}", Handler: s.TriggerCronJob})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/findmany/cron_job", Handler: s.ListCronJob})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/task/findmany/cron_job/name/{name}/history", Handler: s.ListCronJobRun})
 
 	utility.AddToRestfulWebService(web)
 
@@ -29,6 +29,7 @@ func (s *Service) CreateTask(ctx *rest.Contexts) {
 		ctx.RespAutoError(err)
 		return
 	}
+	s.notifyQueue(taskInfo.Name)
 
 	ctx.RespEntity(taskInfo)
 }
@@ -95,3 +96,50 @@ func (s *Service) StatusToFailure(ctx *rest.Contexts) {
 	}
 	ctx.RespEntity(nil)
 }
+
+func (s *Service) CancelTask(ctx *rest.Contexts) {
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	_, err := srvData.lgc.Cancel(srvData.ctx, ctx.Request.PathParameter("task_id"))
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *Service) PauseTask(ctx *rest.Contexts) {
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	_, err := srvData.lgc.Pause(srvData.ctx, ctx.Request.PathParameter("task_id"))
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *Service) ResumeTask(ctx *rest.Contexts) {
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	taskInfo, err := srvData.lgc.Resume(srvData.ctx, ctx.Request.PathParameter("task_id"))
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	s.notifyQueue(taskInfo.Name)
+	ctx.RespEntity(nil)
+}
+
+func (s *Service) UpdateTaskPriority(ctx *rest.Contexts) {
+	input := new(metadata.UpdateTaskPriorityRequest)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	err := srvData.lgc.UpdatePriority(srvData.ctx, ctx.Request.PathParameter("task_id"), input.Priority)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
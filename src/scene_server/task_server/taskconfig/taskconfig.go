@@ -13,7 +13,10 @@
 package taskconfig
 
 import (
+	"time"
+
 	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
 	"configcenter/src/common/types"
 )
 
@@ -26,16 +29,48 @@ type CodeTaskConfig struct {
 	Path string
 	// http request error. max retry
 	Retry int64
+	// max tasks executed at the same time, use the default value if it's 0
+	Concurrency int64
+	// max time a sub task request can take, use the default value if it's 0
+	Timeout time.Duration
+}
+
+// CodeCronJobConfig the builtin cron job registered in code
+type CodeCronJobConfig struct {
+	// job name
+	Name string
+	// service name, host, topo, coreservice etc
+	SvrType string
+	// url path
+	Path string
+	// default cron expression, it can be changed by the config or api
+	Spec string
+	// what to do with the runs missed while there is no master task server
+	MissedPolicy metadata.CronJobMissedPolicy
 }
 
 var (
 	// 在代码中配置任务的任务
 	codeTaskConfigArr = []CodeTaskConfig{}
+	// 在代码中配置的定时任务
+	codeCronJobConfigArr = []CodeCronJobConfig{}
 )
 
 // init for auto task
 func init() {
 	AddCodeTaskConfig("sync-settemplate2set", types.CC_MODULE_TOPO, "/topo/v3/internal/task", 1)
+
+	// 运营统计图表数据每天刷新一次
+	AddCodeCronJobConfig("operation-chart-refresh", types.CC_MODULE_CORESERVICE, "/api/v3/start/operation/chart/timer",
+		"30 0 * * *", metadata.CronJobMissedRunOnce)
+
+	// 审计日志每分钟加入哈希链一次
+	AddCodeCronJobConfig("audit-log-chain-seal", types.CC_MODULE_CORESERVICE, "/api/v3/update/auditlog/chain/seal",
+		"* * * * *", metadata.CronJobMissedSkip)
+
+	// 全文检索的文本索引每分钟按可检索的模型属性重建一次
+	AddCodeCronJobConfig("fulltext-index-sync", types.CC_MODULE_CORESERVICE, "/api/v3/update/fulltext/index",
+		"* * * * *", metadata.CronJobMissedSkip)
 }
 
 // AddCodeTaskConfig add task
@@ -53,3 +88,20 @@ func AddCodeTaskConfig(name, srvType, path string, retry int64) {
 func GetCodeTaskConfig() []CodeTaskConfig {
 	return codeTaskConfigArr
 }
+
+// AddCodeCronJobConfig add builtin cron job
+func AddCodeCronJobConfig(name, srvType, path, spec string, missedPolicy metadata.CronJobMissedPolicy) {
+	blog.Infof("add cron job. name:%s, service type:%s, path:%s, spec:%s", name, srvType, path, spec)
+	codeCronJobConfigArr = append(codeCronJobConfigArr, CodeCronJobConfig{
+		Name:         name,
+		SvrType:      srvType,
+		Path:         path,
+		Spec:         spec,
+		MissedPolicy: missedPolicy,
+	})
+}
+
+// GetCodeCronJobConfig return code cron job config
+func GetCodeCronJobConfig() []CodeCronJobConfig {
+	return codeCronJobConfigArr
+}
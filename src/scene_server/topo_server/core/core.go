@@ -69,7 +69,7 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 	classificationOperation := operation.NewClassificationOperation(client, authManager)
 	groupOperation := operation.NewGroupOperation(client)
 	objectOperation := operation.NewObjectOperation(client, authManager)
-	instOperation := operation.NewInstOperation(client)
+	instOperation := operation.NewInstOperation(client, authManager)
 	moduleOperation := operation.NewModuleOperation(client, authManager)
 	setOperation := operation.NewSetOperation(client)
 	businessOperation := operation.NewBusinessOperation(client, authManager)
@@ -96,6 +96,7 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 	businessOperation.SetProxy(setOperation, moduleOperation, instOperation, objectOperation)
 
 	graphics.SetProxy(objectOperation, associationOperation)
+	audit.SetProxy(objectOperation, instOperation, associationOperation)
 
 	return &core{
 		set:            setOperation,
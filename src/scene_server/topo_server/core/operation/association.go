@@ -222,6 +222,10 @@ func (assoc *association) CreateCommonAssociation(params types.ContextParams, da
 	if len(data.OnDelete) == 0 {
 		data.OnDelete = metadata.NoAction
 	}
+	if !data.OnDelete.IsValid() {
+		blog.Errorf("[operation-asst] failed to create the association, invalid on delete action %s, rid: %s", data.OnDelete, params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsInvalid, "on_delete")
+	}
 
 	// check if this association has already exist,
 	// if yes, it's not allowed to create this association
@@ -437,6 +441,11 @@ func (assoc *association) UpdateAssociation(params types.ContextParams, data map
 		return params.Err.Error(common.CCErrorTopoObjectAssociationUpdateForbiddenFields)
 	}
 
+	if len(asst.OnDelete) != 0 && !asst.OnDelete.IsValid() {
+		blog.Errorf("[operation-asst] update association[%d] with invalid on delete action %s, rid: %s", assoID, asst.OnDelete, params.ReqID)
+		return params.Err.Errorf(common.CCErrCommParamsInvalid, "on_delete")
+	}
+
 	cond := condition.CreateCondition()
 	cond.Field(metadata.AssociationFieldAssociationId).Eq(assoID)
 
@@ -14,6 +14,7 @@ package operation
 
 import (
 	"context"
+	"time"
 
 	"configcenter/src/apimachinery"
 	"configcenter/src/common"
@@ -24,6 +25,13 @@ import (
 
 type AuditOperationInterface interface {
 	Query(params types.ContextParams, query metadata.QueryInput) (interface{}, error)
+	InstanceHistory(params types.ContextParams, objID string, instID int64, at time.Time) (*metadata.InstanceHistory, error)
+	InstanceHistoryDiff(params types.ContextParams, objID string, instID int64, start, end time.Time) (*metadata.InstanceHistoryDiff, error)
+	BizTopoHistory(params types.ContextParams, bizID int64, at time.Time) (*metadata.TopoInstRst, error)
+	PlanRevert(params types.ContextParams, auditID int64, authorize func(plan *metadata.AuditRevertResult) error) (*metadata.AuditRevertResult, error)
+	Revert(params types.ContextParams, plan *metadata.AuditRevertResult) error
+
+	SetProxy(obj ObjectOperationInterface, inst InstOperationInterface, asst AssociationOperationInterface)
 }
 
 // NewAuditOperation create a new inst operation instance
@@ -35,6 +43,15 @@ func NewAuditOperation(client apimachinery.ClientSetInterface) AuditOperationInt
 
 type audit struct {
 	clientSet apimachinery.ClientSetInterface
+	obj       ObjectOperationInterface
+	inst      InstOperationInterface
+	asst      AssociationOperationInterface
+}
+
+func (a *audit) SetProxy(obj ObjectOperationInterface, inst InstOperationInterface, asst AssociationOperationInterface) {
+	a.obj = obj
+	a.inst = inst
+	a.asst = asst
 }
 
 func (a *audit) Query(params types.ContextParams, query metadata.QueryInput) (interface{}, error) {
@@ -0,0 +1,375 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// historyAuditOpTypes are the audit logs which record the changes of the instance data
+var historyAuditOpTypes = []auditoplog.AuditOpType{
+	auditoplog.AuditOpTypeAdd,
+	auditoplog.AuditOpTypeModify,
+	auditoplog.AuditOpTypeDel,
+}
+
+// InstanceHistory rebuild the instance at the time by replaying its audit logs
+func (a *audit) InstanceHistory(params types.ContextParams, objID string, instID int64, at time.Time) (*metadata.InstanceHistory, error) {
+	cond := map[string]interface{}{
+		common.BKOpTargetField: objID,
+		"inst_id":              instID,
+	}
+	logs, err := a.searchHistoryLogs(params, cond)
+	if nil != err {
+		return nil, err
+	}
+
+	history := &metadata.InstanceHistory{
+		ObjectID: objID,
+		InstID:   instID,
+		Time:     metadata.Time{Time: at},
+	}
+	if len(logs) > 0 {
+		history.Data, history.Exist, history.LastOpTime = replayAuditLogs(logs, at)
+		return history, nil
+	}
+
+	// the instance without any audit log exists since before the audit log is recorded
+	insts, err := a.readCurrentInsts(params, objID, mapstr.MapStr{common.GetInstIDField(objID): instID})
+	if nil != err {
+		return nil, err
+	}
+	if len(insts) > 0 {
+		history.Exist = true
+		history.Data = insts[0]
+	}
+	return history, nil
+}
+
+// InstanceHistoryDiff rebuild the instance at two times and compare them field by field
+func (a *audit) InstanceHistoryDiff(params types.ContextParams, objID string, instID int64, start, end time.Time) (*metadata.InstanceHistoryDiff, error) {
+	startHistory, err := a.InstanceHistory(params, objID, instID, start)
+	if nil != err {
+		return nil, err
+	}
+	endHistory, err := a.InstanceHistory(params, objID, instID, end)
+	if nil != err {
+		return nil, err
+	}
+
+	return &metadata.InstanceHistoryDiff{
+		Start: *startHistory,
+		End:   *endHistory,
+		Diff:  diffInstanceData(startHistory.Data, endHistory.Data),
+	}, nil
+}
+
+// BizTopoHistory rebuild the mainline topology of the business at the time by replaying the audit logs
+func (a *audit) BizTopoHistory(params types.ContextParams, bizID int64, at time.Time) (*metadata.TopoInstRst, error) {
+	objIDs, objNames, err := a.searchMainlineObjects(params)
+	if nil != err {
+		return nil, err
+	}
+
+	levels := make([][]mapstr.MapStr, len(objIDs))
+	for idx, objID := range objIDs {
+		cond := map[string]interface{}{common.BKOpTargetField: objID, common.BKAppIDField: bizID}
+		if objID == common.BKInnerObjIDApp {
+			cond = map[string]interface{}{common.BKOpTargetField: objID, "inst_id": bizID}
+		}
+		logs, err := a.searchHistoryLogs(params, cond)
+		if nil != err {
+			return nil, err
+		}
+
+		instLogs := make(map[int64][]metadata.OperationLog)
+		for _, log := range logs {
+			instLogs[log.InstID] = append(instLogs[log.InstID], log)
+		}
+		for _, logs := range instLogs {
+			if data, exist, _ := replayAuditLogs(logs, at); exist {
+				levels[idx] = append(levels[idx], data)
+			}
+		}
+
+		// the instances without any audit log exist since before the audit log is recorded
+		current, err := a.readCurrentInsts(params, objID, mapstr.MapStr{common.BKAppIDField: bizID})
+		if nil != err {
+			return nil, err
+		}
+		for _, inst := range current {
+			instID, err := inst.Int64(common.GetInstIDField(objID))
+			if nil != err {
+				blog.Errorf("[audit] the %s instance(%#v) has no valid id, rid: %s", objID, inst, params.ReqID)
+				continue
+			}
+			if _, ok := instLogs[instID]; !ok {
+				levels[idx] = append(levels[idx], inst)
+			}
+		}
+	}
+
+	topo := buildTopoHistory(objIDs, objNames, levels)
+	if nil == topo {
+		blog.Errorf("[audit] the business %d does not exist at %s, rid: %s", bizID, at, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommNotFound)
+	}
+	return topo, nil
+}
+
+// searchHistoryLogs returns all the audit logs matched the condition which record the data changes, sorted by the operation time
+func (a *audit) searchHistoryLogs(params types.ContextParams, cond map[string]interface{}) ([]metadata.OperationLog, error) {
+	cond[common.BKOpTypeField] = map[string]interface{}{common.BKDBIN: historyAuditOpTypes}
+
+	logs := make([]metadata.OperationLog, 0)
+	for start := 0; ; start += common.BKMaxPageSize {
+		query := metadata.QueryInput{
+			Condition: cond,
+			Start:     start,
+			Limit:     common.BKMaxPageSize,
+			Sort:      common.BKOpTimeField,
+		}
+		rsp, err := a.clientSet.CoreService().Audit().SearchAuditLog(params.Context, params.Header, query)
+		if nil != err {
+			blog.Errorf("[audit] failed request audit controller, error info is %s, rid: %s", err.Error(), params.ReqID)
+			return nil, params.Err.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+		}
+		if !rsp.Result {
+			blog.Errorf("[audit] failed request audit controller, error info is %s, rid: %s", rsp.ErrMsg, params.ReqID)
+			return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+		}
+
+		logs = append(logs, rsp.Data.Info...)
+		if len(rsp.Data.Info) < common.BKMaxPageSize {
+			return logs, nil
+		}
+	}
+}
+
+func (a *audit) readCurrentInsts(params types.ContextParams, objID string, cond mapstr.MapStr) ([]mapstr.MapStr, error) {
+	query := &metadata.QueryCondition{
+		Condition: cond,
+		Limit:     metadata.SearchLimit{Limit: common.BKNoLimit},
+	}
+	rsp, err := a.clientSet.CoreService().Instance().ReadInstance(params.Context, params.Header, objID, query)
+	if nil != err {
+		blog.Errorf("[audit] failed to request object controller, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[audit] failed to search the %s instances by the condition(%#v), err: %s, rid: %s", objID, cond, rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	return rsp.Data.Info, nil
+}
+
+// searchMainlineObjects returns the mainline objects from the business to the module and their names
+func (a *audit) searchMainlineObjects(params types.ContextParams) ([]string, map[string]string, error) {
+	cond := mapstr.MapStr{common.AssociationKindIDField: common.AssociationKindMainline}
+	asstRsp, err := a.clientSet.CoreService().Association().ReadModelAssociation(params.Context, params.Header, &metadata.QueryCondition{Condition: cond})
+	if nil != err {
+		blog.Errorf("[audit] failed to request object controller, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !asstRsp.Result {
+		blog.Errorf("[audit] failed to search the mainline associations, err: %s, rid: %s", asstRsp.ErrMsg, params.ReqID)
+		return nil, nil, params.Err.New(asstRsp.Code, asstRsp.ErrMsg)
+	}
+
+	children := make(map[string]string)
+	for _, asst := range asstRsp.Data.Info {
+		children[asst.AsstObjID] = asst.ObjectID
+	}
+	objIDs := []string{common.BKInnerObjIDApp}
+	// the host is associated to the module as a mainline object too, but it is not a topology level
+	for child, ok := children[common.BKInnerObjIDApp]; ok && len(objIDs) <= len(children); child, ok = children[child] {
+		objIDs = append(objIDs, child)
+		if child == common.BKInnerObjIDModule {
+			break
+		}
+	}
+
+	cond = mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objIDs}}
+	modelRsp, err := a.clientSet.CoreService().Model().ReadModel(params.Context, params.Header, &metadata.QueryCondition{Condition: cond})
+	if nil != err {
+		blog.Errorf("[audit] failed to request object controller, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !modelRsp.Result {
+		blog.Errorf("[audit] failed to search the mainline objects, err: %s, rid: %s", modelRsp.ErrMsg, params.ReqID)
+		return nil, nil, params.Err.New(modelRsp.Code, modelRsp.ErrMsg)
+	}
+	objNames := make(map[string]string)
+	for _, model := range modelRsp.Data.Info {
+		objNames[model.Spec.ObjectID] = model.Spec.ObjectName
+	}
+	return objIDs, objNames, nil
+}
+
+// historyAuditContent is the content of the audit logs which record the data changes
+type historyAuditContent struct {
+	PreData map[string]interface{} `json:"pre_data"`
+	CurData map[string]interface{} `json:"cur_data"`
+}
+
+func parseHistoryAuditContent(content interface{}) (*historyAuditContent, error) {
+	js, err := json.Marshal(content)
+	if nil != err {
+		return nil, err
+	}
+	result := new(historyAuditContent)
+	if err := json.Unmarshal(js, result); nil != err {
+		return nil, err
+	}
+	return result, nil
+}
+
+// replayAuditLogs rebuild the instance data at the time from its audit logs sorted by the operation time,
+// the logs after the time are only used to find out whether the instance exists before its first audit log.
+func replayAuditLogs(logs []metadata.OperationLog, at time.Time) (mapstr.MapStr, bool, *metadata.Time) {
+	var data mapstr.MapStr
+	var lastOpTime *metadata.Time
+	exist := false
+	for _, log := range logs {
+		content, err := parseHistoryAuditContent(log.Content)
+		if nil != err {
+			blog.Warnf("[audit] skip the audit log of %s instance %d with invalid content, err: %v", log.OpTarget, log.InstID, err)
+			continue
+		}
+
+		opType := auditoplog.AuditOpType(log.OpType)
+		if log.CreateTime.After(at) {
+			// the instance is changed for the first time after the time, it exists with the pre data at the time.
+			if nil == lastOpTime && opType != auditoplog.AuditOpTypeAdd && len(content.PreData) > 0 {
+				return mapstr.MapStr(content.PreData), true, nil
+			}
+			break
+		}
+
+		lastOpTime = &metadata.Time{Time: log.CreateTime}
+		switch opType {
+		case auditoplog.AuditOpTypeAdd:
+			data = mapstr.MapStr(content.CurData).Clone()
+			exist = true
+		case auditoplog.AuditOpTypeModify:
+			if nil == data {
+				data = mapstr.MapStr(content.PreData).Clone()
+			}
+			for key, value := range content.CurData {
+				data[key] = value
+			}
+			exist = true
+		case auditoplog.AuditOpTypeDel:
+			data = nil
+			exist = false
+		}
+	}
+	return data, exist, lastOpTime
+}
+
+// diffInstanceData compare the instance data field by field, the last time field is ignored
+func diffInstanceData(before, after mapstr.MapStr) []metadata.InstanceFieldDiff {
+	fields := make([]string, 0)
+	for field := range before {
+		fields = append(fields, field)
+	}
+	for field := range after {
+		if _, ok := before[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	diffs := make([]metadata.InstanceFieldDiff, 0)
+	for _, field := range fields {
+		if field == common.LastTimeField {
+			continue
+		}
+		if reflect.DeepEqual(before[field], after[field]) {
+			continue
+		}
+		diffs = append(diffs, metadata.InstanceFieldDiff{
+			PropertyID: field,
+			Before:     before[field],
+			After:      after[field],
+		})
+	}
+	return diffs
+}
+
+// buildTopoHistory build the topology with the instances of each mainline level, the first level is the business.
+func buildTopoHistory(objIDs []string, objNames map[string]string, levels [][]mapstr.MapStr) *metadata.TopoInstRst {
+	if len(levels) == 0 || len(levels[0]) == 0 {
+		return nil
+	}
+
+	newNode := func(objID string, data mapstr.MapStr) (*metadata.TopoInstRst, error) {
+		instID, err := util.GetInt64ByInterface(data[common.GetInstIDField(objID)])
+		if nil != err {
+			return nil, err
+		}
+		defaultValue, _ := util.GetIntByInterface(data[common.BKDefaultField])
+		return &metadata.TopoInstRst{
+			TopoInst: metadata.TopoInst{
+				InstID:   instID,
+				InstName: util.GetStrByInterface(data[common.GetInstNameField(objID)]),
+				ObjID:    objID,
+				ObjName:  objNames[objID],
+				Default:  defaultValue,
+			},
+			Child: make([]*metadata.TopoInstRst, 0),
+		}, nil
+	}
+
+	root, err := newNode(objIDs[0], levels[0][0])
+	if nil != err {
+		return nil
+	}
+
+	parents := map[int64]*metadata.TopoInstRst{root.InstID: root}
+	for idx := 1; idx < len(levels); idx++ {
+		nodes := make(map[int64]*metadata.TopoInstRst)
+		for _, data := range levels[idx] {
+			node, err := newNode(objIDs[idx], data)
+			if nil != err {
+				continue
+			}
+			parentID, err := util.GetInt64ByInterface(data[common.BKInstParentStr])
+			if nil != err {
+				continue
+			}
+			parent, ok := parents[parentID]
+			if !ok {
+				continue
+			}
+			parent.Child = append(parent.Child, node)
+			nodes[node.InstID] = node
+		}
+		for _, parent := range parents {
+			sort.Slice(parent.Child, func(i, j int) bool { return parent.Child[i].InstID < parent.Child[j].InstID })
+		}
+		parents = nodes
+	}
+	return root
+}
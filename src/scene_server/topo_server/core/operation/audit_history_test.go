@@ -0,0 +1,108 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func historyLog(opType auditoplog.AuditOpType, at time.Time, pre, cur map[string]interface{}) metadata.OperationLog {
+	return metadata.OperationLog{
+		OpType:     int(opType),
+		OpTarget:   "host",
+		InstID:     1,
+		CreateTime: at,
+		Content:    map[string]interface{}{"pre_data": pre, "cur_data": cur},
+	}
+}
+
+func TestReplayAuditLogs(t *testing.T) {
+	base := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	logs := []metadata.OperationLog{
+		historyLog(auditoplog.AuditOpTypeAdd, base, nil, map[string]interface{}{"name": "a", "ip": "1.1.1.1"}),
+		historyLog(auditoplog.AuditOpTypeModify, base.Add(time.Hour), map[string]interface{}{"name": "a", "ip": "1.1.1.1"}, map[string]interface{}{"name": "b"}),
+		historyLog(auditoplog.AuditOpTypeDel, base.Add(2*time.Hour), map[string]interface{}{"name": "b", "ip": "1.1.1.1"}, nil),
+	}
+
+	data, exist, _ := replayAuditLogs(logs, base.Add(-time.Minute))
+	require.False(t, exist)
+	require.Nil(t, data)
+
+	data, exist, last := replayAuditLogs(logs, base.Add(30*time.Minute))
+	require.True(t, exist)
+	require.Equal(t, "a", data["name"])
+	require.Equal(t, base, last.Time)
+
+	data, exist, _ = replayAuditLogs(logs, base.Add(90*time.Minute))
+	require.True(t, exist)
+	require.Equal(t, "b", data["name"])
+	require.Equal(t, "1.1.1.1", data["ip"])
+
+	_, exist, _ = replayAuditLogs(logs, base.Add(3*time.Hour))
+	require.False(t, exist)
+
+	// the create log is missing, the pre data of the first change is the state before it.
+	data, exist, last = replayAuditLogs(logs[1:], base.Add(30*time.Minute))
+	require.True(t, exist)
+	require.Nil(t, last)
+	require.Equal(t, "a", data["name"])
+}
+
+func TestDiffInstanceData(t *testing.T) {
+	before := mapstr.MapStr{"name": "a", "ip": "1.1.1.1", common.LastTimeField: "t1"}
+	after := mapstr.MapStr{"name": "b", "ip": "1.1.1.1", "os": "linux", common.LastTimeField: "t2"}
+
+	diffs := diffInstanceData(before, after)
+	require.Equal(t, []metadata.InstanceFieldDiff{
+		{PropertyID: "name", Before: "a", After: "b"},
+		{PropertyID: "os", Before: nil, After: "linux"},
+	}, diffs)
+	require.Empty(t, diffInstanceData(before, before))
+}
+
+func TestBuildTopoHistory(t *testing.T) {
+	objIDs := []string{common.BKInnerObjIDApp, common.BKInnerObjIDSet, common.BKInnerObjIDModule}
+	objNames := map[string]string{common.BKInnerObjIDApp: "biz", common.BKInnerObjIDSet: "set", common.BKInnerObjIDModule: "module"}
+	levels := [][]mapstr.MapStr{
+		{{common.BKAppIDField: 2, common.BKAppNameField: "app"}},
+		{
+			{common.BKSetIDField: 4, common.BKSetNameField: "set2", common.BKInstParentStr: 2},
+			{common.BKSetIDField: 3, common.BKSetNameField: "set1", common.BKInstParentStr: 2},
+		},
+		{
+			{common.BKModuleIDField: 5, common.BKModuleNameField: "m1", common.BKInstParentStr: 3},
+			{common.BKModuleIDField: 6, common.BKModuleNameField: "orphan", common.BKInstParentStr: 9},
+		},
+	}
+
+	root := buildTopoHistory(objIDs, objNames, levels)
+	require.NotNil(t, root)
+	require.Equal(t, int64(2), root.InstID)
+	require.Equal(t, "app", root.InstName)
+	require.Len(t, root.Child, 2)
+	require.Equal(t, "set1", root.Child[0].InstName)
+	require.Equal(t, "set2", root.Child[1].InstName)
+	require.Len(t, root.Child[0].Child, 1)
+	require.Equal(t, "m1", root.Child[0].Child[0].InstName)
+	require.Empty(t, root.Child[1].Child)
+
+	require.Nil(t, buildTopoHistory(objIDs, objNames, nil))
+}
@@ -0,0 +1,395 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// revertIgnoredFields are maintained by the system, they are never restored by a revert
+var revertIgnoredFields = map[string]bool{
+	"_id":                  true,
+	common.BKOwnerIDField:  true,
+	common.BKObjIDField:    true,
+	common.CreateTimeField: true,
+	common.LastTimeField:   true,
+	common.BKParentIDField: true,
+	common.BKAppIDField:    true,
+	metadata.BKMetadata:    true,
+	common.BKFieldID:       true,
+	common.BKInstIDField:   true,
+	common.BKHostIDField:   true,
+	common.BKDefaultField:  true,
+}
+
+// PlanRevert returns what will be done to revert the change recorded by the audit log, authorize is called
+// with the target and action of the revert before the current data is read.
+func (a *audit) PlanRevert(params types.ContextParams, auditID int64, authorize func(plan *metadata.AuditRevertResult) error) (*metadata.AuditRevertResult, error) {
+	log, err := a.findAuditLog(params, auditID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := parseHistoryAuditContent(log.Content)
+	if nil != err {
+		blog.Errorf("[audit] the content of audit log %d is invalid, err: %v, rid: %s", auditID, err, params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrAuditRevertNotSupported, "content")
+	}
+
+	result := &metadata.AuditRevertResult{
+		AuditID:   auditID,
+		BizID:     log.ApplicationID,
+		ObjectID:  log.OpTarget,
+		InstID:    log.InstID,
+		Data:      mapstr.New(),
+		Diff:      make([]metadata.InstanceFieldDiff, 0),
+		Conflicts: make([]metadata.InstanceFieldDiff, 0),
+	}
+	opType := auditoplog.AuditOpType(log.OpType)
+
+	switch opType {
+	case auditoplog.AuditOpTypeAdd:
+		result.Action = metadata.AuditRevertActionDelete
+	case auditoplog.AuditOpTypeDel:
+		result.Action = metadata.AuditRevertActionCreate
+	case auditoplog.AuditOpTypeModify:
+		result.Action = metadata.AuditRevertActionUpdate
+	default:
+		return nil, params.Err.Errorf(common.CCErrAuditRevertNotSupported, common.BKOpTypeField)
+	}
+
+	isInstAsst := isInstAsstAuditData(content.PreData) || isInstAsstAuditData(content.CurData)
+	switch {
+	case isInstAsst:
+		result.Target = metadata.AuditRevertTargetAssociation
+		if opType == auditoplog.AuditOpTypeModify {
+			return nil, params.Err.Errorf(common.CCErrAuditRevertNotSupported, common.BKOpTypeField)
+		}
+	case log.OpTarget == common.BKInnerObjIDHost:
+		result.Target = metadata.AuditRevertTargetHost
+		// only the attributes of the host can be reverted, the host is added and deleted with its topology
+		if opType != auditoplog.AuditOpTypeModify {
+			return nil, params.Err.Errorf(common.CCErrAuditRevertNotSupported, common.BKOpTypeField)
+		}
+	case util.IsInnerObject(log.OpTarget) || len(log.OpTarget) == 0:
+		return nil, params.Err.Errorf(common.CCErrAuditRevertNotSupported, common.BKOpTargetField)
+	default:
+		result.Target = metadata.AuditRevertTargetInstance
+	}
+
+	// the current instance and the cascade are only read after the revert is authorized
+	if err := authorize(result); err != nil {
+		return nil, err
+	}
+
+	if isInstAsst {
+		return result, a.planRevertInstAsst(params, opType, content, result)
+	}
+	return result, a.planRevertInst(params, opType, content, result)
+}
+
+func (a *audit) planRevertInst(params types.ContextParams, opType auditoplog.AuditOpType, content *historyAuditContent,
+	result *metadata.AuditRevertResult) error {
+
+	var current mapstr.MapStr
+	if opType != auditoplog.AuditOpTypeDel {
+		cond := mapstr.MapStr{common.GetInstIDField(result.ObjectID): result.InstID}
+		insts, err := a.readCurrentInsts(params, result.ObjectID, cond)
+		if err != nil {
+			return err
+		}
+		if len(insts) == 0 {
+			blog.Errorf("[audit] revert audit log %d failed, instance %d of %s not exist, rid: %s", result.AuditID, result.InstID, result.ObjectID, params.ReqID)
+			return params.Err.Errorf(common.CCErrAuditRevertConflict, common.GetInstIDField(result.ObjectID))
+		}
+		current = normalizeRevertData(insts[0])
+	}
+
+	switch opType {
+	case auditoplog.AuditOpTypeModify:
+		for _, diff := range diffInstanceData(content.PreData, content.CurData) {
+			if revertIgnoredFields[diff.PropertyID] {
+				continue
+			}
+			result.Data[diff.PropertyID] = diff.Before
+			result.Diff = append(result.Diff, metadata.InstanceFieldDiff{
+				PropertyID: diff.PropertyID,
+				Before:     current[diff.PropertyID],
+				After:      diff.Before,
+			})
+			if !reflect.DeepEqual(current[diff.PropertyID], diff.After) {
+				result.Conflicts = append(result.Conflicts, metadata.InstanceFieldDiff{
+					PropertyID: diff.PropertyID,
+					Before:     diff.After,
+					After:      current[diff.PropertyID],
+				})
+			}
+		}
+		if len(result.Data) == 0 {
+			return params.Err.Errorf(common.CCErrAuditRevertNotSupported, "cur_data")
+		}
+
+	case auditoplog.AuditOpTypeAdd:
+		// the instance is deleted, the changes after it was created are conflicts as they will be lost
+		result.Data = current
+		for _, diff := range diffInstanceData(current, nil) {
+			if !revertIgnoredFields[diff.PropertyID] {
+				result.Diff = append(result.Diff, diff)
+			}
+		}
+		for _, diff := range diffInstanceData(content.CurData, current) {
+			if !revertIgnoredFields[diff.PropertyID] {
+				result.Conflicts = append(result.Conflicts, diff)
+			}
+		}
+
+		// the deletion may remove other instances with cascade, they are previewed with the plan
+		obj, err := a.obj.FindSingleObject(params, result.ObjectID)
+		if err != nil {
+			blog.Errorf("[audit] revert audit log %d failed, find object %s failed, err: %v, rid: %s", result.AuditID, result.ObjectID, err, params.ReqID)
+			return err
+		}
+		result.Cascade, err = a.inst.PreviewDeleteInst(params, obj, []int64{result.InstID}, true)
+		if err != nil {
+			blog.Errorf("[audit] revert audit log %d failed, preview the deletion failed, err: %v, rid: %s", result.AuditID, err, params.ReqID)
+			return err
+		}
+
+	case auditoplog.AuditOpTypeDel:
+		// the instance is created again with a new id, uniqueness and required fields are checked as usual
+		for field, value := range content.PreData {
+			if revertIgnoredFields[field] || field == common.GetInstIDField(result.ObjectID) {
+				continue
+			}
+			result.Data[field] = value
+		}
+		result.Diff = diffInstanceData(nil, result.Data)
+
+	default:
+		return params.Err.Errorf(common.CCErrAuditRevertNotSupported, common.BKOpTypeField)
+	}
+	return nil
+}
+
+func (a *audit) planRevertInstAsst(params types.ContextParams, opType auditoplog.AuditOpType, content *historyAuditContent,
+	result *metadata.AuditRevertResult) error {
+
+	var asstData mapstr.MapStr
+	switch opType {
+	case auditoplog.AuditOpTypeAdd:
+		asstData = content.CurData
+	case auditoplog.AuditOpTypeDel:
+		asstData = content.PreData
+	default:
+		return params.Err.Errorf(common.CCErrAuditRevertNotSupported, common.BKOpTypeField)
+	}
+
+	asst := metadata.InstAsst{}
+	if err := asstData.MarshalJSONInto(&asst); err != nil {
+		blog.Errorf("[audit] the association data %#v of audit log %d is invalid, err: %v, rid: %s", asstData, result.AuditID, err, params.ReqID)
+		return params.Err.Errorf(common.CCErrAuditRevertNotSupported, common.AssociationObjAsstIDField)
+	}
+	result.Data = mapstr.MapStr{
+		common.AssociationObjAsstIDField: asst.ObjectAsstID,
+		common.BKObjIDField:              asst.ObjectID,
+		common.BKInstIDField:             asst.InstID,
+		common.BKAsstObjIDField:          asst.AsstObjectID,
+		common.BKAsstInstIDField:         asst.AsstInstID,
+	}
+
+	query := &metadata.QueryInput{
+		Condition: mapstr.MapStr{
+			common.AssociationObjAsstIDField: asst.ObjectAsstID,
+			common.BKInstIDField:             asst.InstID,
+			common.BKAsstInstIDField:         asst.AsstInstID,
+		},
+	}
+	exists, err := a.asst.SearchInstAssociation(params, query)
+	if err != nil {
+		blog.Errorf("[audit] revert audit log %d failed, search instance association failed, err: %v, rid: %s", result.AuditID, err, params.ReqID)
+		return err
+	}
+
+	switch {
+	case result.Action == metadata.AuditRevertActionDelete && len(exists) == 0:
+		result.Conflicts = append(result.Conflicts, metadata.InstanceFieldDiff{PropertyID: common.BKFieldID, Before: asstData})
+	case result.Action == metadata.AuditRevertActionDelete:
+		result.Data[common.BKFieldID] = exists[0].ID
+	case len(exists) != 0:
+		result.Conflicts = append(result.Conflicts, metadata.InstanceFieldDiff{PropertyID: common.BKFieldID, After: exists[0].ID})
+	}
+	return nil
+}
+
+// Revert execute the revert planned by PlanRevert with the normal operations, so the validations still apply.
+// all the audit logs recorded by the revert are linked to the reverted audit log.
+func (a *audit) Revert(params types.ContextParams, plan *metadata.AuditRevertResult) error {
+	if len(plan.Conflicts) != 0 {
+		fields := make([]string, 0)
+		for _, conflict := range plan.Conflicts {
+			fields = append(fields, conflict.PropertyID)
+		}
+		blog.Errorf("[audit] revert audit log %d failed, fields %v are conflict, rid: %s", plan.AuditID, fields, params.ReqID)
+		return params.Err.Errorf(common.CCErrAuditRevertConflict, strings.Join(fields, ","))
+	}
+
+	// the revert only checks and reverts the instance of the audit log, it never deletes others with cascade
+	if plan.Cascade != nil {
+		others := make([]string, 0)
+		for _, inst := range plan.Cascade.Instances {
+			if inst.ObjectID != plan.ObjectID || inst.InstID != plan.InstID {
+				others = append(others, fmt.Sprintf("%s:%d", inst.ObjectID, inst.InstID))
+			}
+		}
+		if len(others) != 0 {
+			blog.Errorf("[audit] revert audit log %d failed, instances %v are deleted with it, rid: %s", plan.AuditID, others, params.ReqID)
+			return params.Err.Errorf(common.CCErrAuditRevertCascade, strings.Join(others, ","))
+		}
+	}
+
+	params.Header = util.CloneHeader(params.Header)
+	params.Header.Set(common.BKHTTPCCRevertAuditID, strconv.FormatInt(plan.AuditID, 10))
+
+	switch plan.Target {
+	case metadata.AuditRevertTargetHost:
+		return a.revertHost(params, plan)
+	case metadata.AuditRevertTargetAssociation:
+		return a.revertInstAsst(params, plan)
+	}
+
+	obj, err := a.obj.FindSingleObject(params, plan.ObjectID)
+	if err != nil {
+		blog.Errorf("[audit] revert audit log %d failed, find object %s failed, err: %v, rid: %s", plan.AuditID, plan.ObjectID, err, params.ReqID)
+		return err
+	}
+
+	switch plan.Action {
+	case metadata.AuditRevertActionUpdate:
+		cond := condition.CreateCondition()
+		cond.Field(obj.GetInstIDFieldName()).Eq(plan.InstID)
+		return a.inst.UpdateInst(params, plan.Data.Clone(), obj, cond, plan.InstID)
+	case metadata.AuditRevertActionDelete:
+		return a.inst.DeleteInstByInstID(params, obj, []int64{plan.InstID}, true)
+	case metadata.AuditRevertActionCreate:
+		inst, err := a.inst.CreateInst(params, obj, plan.Data.Clone())
+		if err != nil {
+			return err
+		}
+		plan.NewInstID, err = inst.GetInstID()
+		if err != nil {
+			blog.Errorf("[audit] revert audit log %d, but get the created instance id failed, err: %v, rid: %s", plan.AuditID, err, params.ReqID)
+			return params.Err.Error(common.CCErrTopoInstCreateFailed)
+		}
+	}
+	return nil
+}
+
+func (a *audit) revertHost(params types.ContextParams, plan *metadata.AuditRevertResult) error {
+	data := plan.Data.Clone()
+	data[common.BKHostIDField] = strconv.FormatInt(plan.InstID, 10)
+	rsp, err := a.clientSet.HostServer().UpdateHostBatch(params.Context, params.Header, data)
+	if nil != err {
+		blog.Errorf("[audit] revert audit log %d failed, update host failed, err: %v, rid: %s", plan.AuditID, err, params.ReqID)
+		return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[audit] revert audit log %d failed, update host failed, err: %s, rid: %s", plan.AuditID, rsp.ErrMsg, params.ReqID)
+		return params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	return nil
+}
+
+func (a *audit) revertInstAsst(params types.ContextParams, plan *metadata.AuditRevertResult) error {
+	if plan.Action == metadata.AuditRevertActionDelete {
+		id, err := plan.Data.Int64(common.BKFieldID)
+		if err != nil {
+			return params.Err.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+		}
+		rsp, err := a.asst.DeleteInst(params, id)
+		if err != nil {
+			return err
+		}
+		if !rsp.Result {
+			return params.Err.New(rsp.Code, rsp.ErrMsg)
+		}
+		return nil
+	}
+
+	request := &metadata.CreateAssociationInstRequest{}
+	if err := plan.Data.MarshalJSONInto(request); err != nil {
+		return params.Err.Errorf(common.CCErrCommParamsInvalid, common.AssociationObjAsstIDField)
+	}
+	rsp, err := a.asst.CreateInst(params, request)
+	if err != nil {
+		return err
+	}
+	if !rsp.Result {
+		return params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	plan.NewInstID = rsp.Data.ID
+	return nil
+}
+
+func (a *audit) findAuditLog(params types.ContextParams, auditID int64) (*metadata.OperationLog, error) {
+	query := metadata.QueryInput{
+		Condition: map[string]interface{}{common.BKFieldID: auditID},
+		Limit:     1,
+	}
+	rsp, err := a.clientSet.CoreService().Audit().SearchAuditLog(params.Context, params.Header, query)
+	if nil != err {
+		blog.Errorf("[audit] failed request audit controller, error info is %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+	}
+	if !rsp.Result {
+		blog.Errorf("[audit] failed request audit controller, error info is %s, rid: %s", rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	if len(rsp.Data.Info) == 0 {
+		blog.Errorf("[audit] audit log %d not found, rid: %s", auditID, params.ReqID)
+		return nil, params.Err.Error(common.CCErrAuditLogNotFound)
+	}
+	return &rsp.Data.Info[0], nil
+}
+
+// isInstAsstAuditData check whether the audit data is an instance association
+func isInstAsstAuditData(data map[string]interface{}) bool {
+	_, hasAsstID := data[common.AssociationObjAsstIDField]
+	_, hasAsstInst := data[common.BKAsstInstIDField]
+	return hasAsstID && hasAsstInst
+}
+
+// normalizeRevertData convert the data to the same types as the audit log content, so they can be compared
+func normalizeRevertData(data mapstr.MapStr) mapstr.MapStr {
+	js, err := json.Marshal(data)
+	if nil != err {
+		return data
+	}
+	result := mapstr.New()
+	if err := json.Unmarshal(js, &result); nil != err {
+		return data
+	}
+	return result
+}
@@ -252,6 +252,9 @@ func (ia *importAssociation) getAssociationObjProperty() error {
 			continue
 		}
 		for _, property := range unique.Keys {
+			if property.Kind != metadata.UniqueKeyKindProperty {
+				continue
+			}
 			propertyIDArr = append(propertyIDArr, property.ID)
 		}
 	}
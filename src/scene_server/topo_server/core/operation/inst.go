@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	"configcenter/src/apimachinery"
+	"configcenter/src/auth/extensions"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/condition"
@@ -37,6 +38,7 @@ type InstOperationInterface interface {
 	DeleteInst(params types.ContextParams, obj model.Object, cond condition.Condition, needCheckHost bool) error
 	DeleteMainlineInstWithID(params types.ContextParams, obj model.Object, instID int64) error
 	DeleteInstByInstID(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool) error
+	PreviewDeleteInst(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool) (*metadata.DeleteInstPreview, error)
 	FindOriginInst(params types.ContextParams, obj model.Object, cond *metadata.QueryInput) (*metadata.InstResult, error)
 	FindInst(params types.ContextParams, obj model.Object, cond *metadata.QueryInput, needAsstDetail bool) (count int, results []inst.Inst, err error)
 	FindInstByAssociationInst(params types.ContextParams, obj model.Object, data mapstr.MapStr) (cont int, results []inst.Inst, err error)
@@ -49,9 +51,10 @@ type InstOperationInterface interface {
 }
 
 // NewInstOperation create a new inst operation instance
-func NewInstOperation(client apimachinery.ClientSetInterface) InstOperationInterface {
+func NewInstOperation(client apimachinery.ClientSetInterface, authManager *extensions.AuthManager) InstOperationInterface {
 	return &commonInst{
-		clientSet: client,
+		clientSet:   client,
+		authManager: authManager,
 	}
 }
 
@@ -71,6 +74,7 @@ type BatchResult struct {
 
 type commonInst struct {
 	clientSet    apimachinery.ClientSetInterface
+	authManager  *extensions.AuthManager
 	modelFactory model.Factory
 	instFactory  inst.Factory
 	asst         AssociationOperationInterface
@@ -378,8 +382,9 @@ func (c *commonInst) hasHost(params types.ContextParams, targetInst inst.Inst, c
 		}
 	}
 
+	instName, _ := targetInst.GetInstName()
 	instIDS := make([]deletedInst, 0)
-	instIDS = append(instIDS, deletedInst{instID: id, bizID: bizID, obj: targetObj})
+	instIDS = append(instIDS, deletedInst{instID: id, bizID: bizID, instName: instName, obj: targetObj, reason: metadata.DeleteInstReasonMainline})
 	childInsts, err := targetInst.GetMainlineChildInst()
 	if nil != err {
 		return nil, false, err
@@ -401,56 +406,43 @@ func (c *commonInst) hasHost(params types.ContextParams, targetInst inst.Inst, c
 }
 
 func (c *commonInst) DeleteInstByInstID(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool) error {
-	object := obj.Object()
-	objectID := object.ObjectID
-
-	cond := condition.CreateCondition()
-	cond.Field(obj.GetInstIDFieldName()).In(instID)
-	if obj.IsCommon() {
-		cond.Field(common.BKObjIDField).Eq(objectID)
-	}
-
-	query := &metadata.QueryInput{}
-	query.Condition = cond.ToMapStr()
-
-	_, insts, err := c.FindInst(params, obj, query, false)
+	plan, err := c.planDeleteInst(params, obj, instID, needCheckHost)
 	if nil != err {
 		return err
 	}
 
-	deleteIDS := make([]deletedInst, 0)
-	for _, inst := range insts {
-		ids, exists, err := c.hasHost(params, inst, needCheckHost)
-		if nil != err {
-			return params.Err.Error(common.CCErrTopoHasHostCheckFailed)
-		}
-
-		if exists {
-			return params.Err.Error(common.CCErrTopoHasHostCheckFailed)
+	// if an instance has been bind to a instance by the association which doesn't delete with cascade, then it should not be deleted.
+	if len(plan.restricted) > 0 {
+		asst := plan.restricted[0]
+		blog.Errorf("[operation-inst] the instance is restricted to delete by the association(%#v), rid: %s", asst, params.ReqID)
+		if plan.isDeleted(asst.ObjectID, asst.InstID) {
+			return params.Err.CCErrorf(common.CCErrTopoInstHasBeenAssociation, asst.InstID)
 		}
+		return params.Err.CCErrorf(common.CCErrTopoInstHasBeenAssociation, asst.AsstInstID)
+	}
 
-		deleteIDS = append(deleteIDS, ids...)
+	if err := c.authorizeDeletePlan(params, plan); nil != err {
+		return err
 	}
 
+	deleteIDS := plan.insts
 	for _, delInst := range deleteIDS {
 		auditFilter := condition.CreateCondition().ToMapStr()
 		preAudit := NewSupplementary().Audit(params, c.clientSet, delInst.obj, c).CreateSnapshot(delInst.instID, auditFilter)
 
-		// if this instance has been bind to a instance by the association, then this instance should not be deleted.
-		err := c.asst.CheckAssociation(params, obj, objectID, delInst.instID)
-		if nil != err {
+		// clear association
+		if err := c.deleteInstAssociations(params, delInst, plan); nil != err {
 			return err
 		}
 
 		// delete this instance now.
+		instObjID := delInst.obj.GetObjectID()
 		delCond := condition.CreateCondition()
 		delCond.Field(delInst.obj.GetInstIDFieldName()).In(delInst.instID)
 		if delInst.obj.IsCommon() {
-			delCond.Field(common.BKObjIDField).Eq(objectID)
+			delCond.Field(common.BKObjIDField).Eq(instObjID)
 		}
-		// clear association
 		dc := &metadata.DeleteOption{Condition: delCond.ToMapStr()}
-		instObjID := delInst.obj.GetObjectID()
 		rsp, err := c.clientSet.CoreService().Instance().DeleteInstance(params.Context, params.Header, instObjID, dc)
 		if nil != err {
 			blog.Errorf("[operation-inst] failed to request object controller, err: %s, rid: %s", err.Error(), params.ReqID)
@@ -458,11 +450,16 @@ func (c *commonInst) DeleteInstByInstID(params types.ContextParams, obj model.Ob
 		}
 
 		if !rsp.Result {
-			blog.Errorf("[operation-inst] failed to delete the object(%s) inst by the condition(%#v), err: %s, rid: %s", objectID, delCond.ToMapStr(), rsp.ErrMsg, params.ReqID)
+			blog.Errorf("[operation-inst] failed to delete the object(%s) inst by the condition(%#v), err: %s, rid: %s", instObjID, delCond.ToMapStr(), rsp.ErrMsg, params.ReqID)
 			return params.Err.New(rsp.Code, rsp.ErrMsg)
 		}
 
-		NewSupplementary().Audit(params, c.clientSet, delInst.obj, c).CommitDeleteLog(preAudit, nil, nil)
+		audit := NewSupplementary().Audit(params, c.clientSet, delInst.obj, c)
+		if delInst.reason == metadata.DeleteInstReasonCascade {
+			audit.CommitCascadeDeleteLog(preAudit, delInst.asstID, delInst.causeObjID, delInst.causeInstID)
+			continue
+		}
+		audit.CommitDeleteLog(preAudit, nil, nil)
 	}
 
 	// clear set template sync status for set instances
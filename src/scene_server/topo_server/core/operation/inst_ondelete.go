@@ -0,0 +1,368 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"fmt"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/model"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// deletePlan describe the instances and instance associations to be removed by an instance deletion,
+// it's the closure of the requested instances with their mainline children and the instances deleted
+// with cascade by the on_delete action of the associations.
+type deletePlan struct {
+	insts []deletedInst
+	// assts are the instance associations to be removed with the instances
+	assts []metadata.InstAsst
+	// restricted are the instance associations which prevent the deletion
+	restricted []metadata.InstAsst
+	deleted    map[string]bool
+}
+
+func deletedInstKey(objID string, instID int64) string {
+	return fmt.Sprintf("%s:%d", objID, instID)
+}
+
+func (p *deletePlan) isDeleted(objID string, instID int64) bool {
+	return p.deleted[deletedInstKey(objID, instID)]
+}
+
+func (p *deletePlan) add(insts []deletedInst) {
+	for _, delInst := range insts {
+		key := deletedInstKey(delInst.obj.GetObjectID(), delInst.instID)
+		if p.deleted[key] {
+			continue
+		}
+		p.deleted[key] = true
+		p.insts = append(p.insts, delInst)
+	}
+}
+
+// PreviewDeleteInst returns what will be removed if the instances are deleted, nothing is deleted.
+func (c *commonInst) PreviewDeleteInst(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool) (*metadata.DeleteInstPreview, error) {
+	plan, err := c.planDeleteInst(params, obj, instID, needCheckHost)
+	if nil != err {
+		return nil, err
+	}
+
+	preview := &metadata.DeleteInstPreview{
+		Deletable:    len(plan.restricted) == 0,
+		Instances:    make([]metadata.DeleteInstPreviewItem, 0),
+		Associations: plan.assts,
+		Restricted:   plan.restricted,
+	}
+	for _, delInst := range plan.insts {
+		preview.Instances = append(preview.Instances, metadata.DeleteInstPreviewItem{
+			ObjectID:      delInst.obj.GetObjectID(),
+			InstID:        delInst.instID,
+			InstName:      delInst.instName,
+			Reason:        delInst.reason,
+			AssociationID: delInst.asstID,
+			CauseObjectID: delInst.causeObjID,
+			CauseInstID:   delInst.causeInstID,
+		})
+	}
+	return preview, nil
+}
+
+// planDeleteInst walk through the instance associations of the instances to be deleted, and decide
+// what to do with the instance on the other side by the on_delete action of the association:
+// the instance is deleted too if the association deletes it with cascade, the deletion is
+// restricted if the association has no action, otherwise only the instance association is removed.
+func (c *commonInst) planDeleteInst(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool) (*deletePlan, error) {
+	plan := &deletePlan{
+		insts:      make([]deletedInst, 0),
+		assts:      make([]metadata.InstAsst, 0),
+		restricted: make([]metadata.InstAsst, 0),
+		deleted:    make(map[string]bool),
+	}
+
+	roots, err := c.findDeletedInsts(params, obj, instID, needCheckHost)
+	if nil != err {
+		return nil, err
+	}
+	plan.add(roots)
+
+	objects := map[string]model.Object{obj.GetObjectID(): obj}
+	onDeletes := make(map[string]metadata.AssociationOnDeleteAction)
+	visitedAssts := make(map[int64]bool)
+	candidates := make([]metadata.InstAsst, 0)
+	// the plan grows while walking through it, until no more instance is deleted with cascade
+	for idx := 0; idx < len(plan.insts); idx++ {
+		delInst := plan.insts[idx]
+		objID := delInst.obj.GetObjectID()
+
+		cond := condition.CreateCondition()
+		or := cond.NewOR()
+		or.Item(mapstr.MapStr{common.BKObjIDField: objID, common.BKInstIDField: delInst.instID})
+		or.Item(mapstr.MapStr{common.BKAsstObjIDField: objID, common.BKAsstInstIDField: delInst.instID})
+		assts, err := c.asst.SearchInstAssociation(params, &metadata.QueryInput{Condition: cond.ToMapStr()})
+		if nil != err {
+			return nil, err
+		}
+
+		for _, asst := range assts {
+			if visitedAssts[asst.ID] {
+				continue
+			}
+			visitedAssts[asst.ID] = true
+
+			isSource := asst.ObjectID == objID && asst.InstID == delInst.instID
+			peerObjID, peerInstID := asst.AsstObjectID, asst.AsstInstID
+			if !isSource {
+				peerObjID, peerInstID = asst.ObjectID, asst.InstID
+			}
+			if plan.isDeleted(peerObjID, peerInstID) {
+				plan.assts = append(plan.assts, asst)
+				continue
+			}
+
+			// the association with a not exist instance is dirty data, which is removed directly.
+			exist, err := c.isInstExist(params, peerObjID, peerInstID)
+			if nil != err {
+				return nil, err
+			}
+			if !exist {
+				plan.assts = append(plan.assts, asst)
+				continue
+			}
+
+			onDelete, err := c.getAssociationOnDelete(params, asst.ObjectAsstID, onDeletes)
+			if nil != err {
+				return nil, err
+			}
+
+			cascade := onDelete.ShouldCascade(isSource)
+			var peerObj model.Object
+			if cascade {
+				peerObj, err = c.findPlanObject(params, peerObjID, objects)
+				if nil != err {
+					return nil, err
+				}
+				// the inner and mainline instances are deleted by their own delete logic, such as archiving
+				// the business and cleaning up the service instances, they are never deleted with cascade.
+				isMainline, err := peerObj.IsMainlineObject()
+				if nil != err {
+					blog.Errorf("[operation-inst] failed to check whether the object(%s) is mainline, err: %s, rid: %s", peerObjID, err.Error(), params.ReqID)
+					return nil, err
+				}
+				cascade = !common.IsInnerModel(peerObjID) && !isMainline
+			}
+
+			if cascade {
+				peers, err := c.findDeletedInsts(params, peerObj, []int64{peerInstID}, needCheckHost)
+				if nil != err {
+					return nil, err
+				}
+				if len(peers) > 0 {
+					peers[0].reason = metadata.DeleteInstReasonCascade
+					peers[0].asstID = asst.ObjectAsstID
+					peers[0].causeObjID = objID
+					peers[0].causeInstID = delInst.instID
+				}
+				plan.add(peers)
+				plan.assts = append(plan.assts, asst)
+				continue
+			}
+
+			if onDelete == metadata.NoAction || onDelete.ShouldCascade(isSource) {
+				candidates = append(candidates, asst)
+				continue
+			}
+			plan.assts = append(plan.assts, asst)
+		}
+	}
+
+	// the restricted association doesn't prevent the deletion if the instances on both sides are deleted.
+	for _, asst := range candidates {
+		if plan.isDeleted(asst.ObjectID, asst.InstID) && plan.isDeleted(asst.AsstObjectID, asst.AsstInstID) {
+			plan.assts = append(plan.assts, asst)
+			continue
+		}
+		plan.restricted = append(plan.restricted, asst)
+	}
+
+	return plan, nil
+}
+
+// findPlanObject returns the object of the object id, the result is cached in objects.
+func (c *commonInst) findPlanObject(params types.ContextParams, objID string, objects map[string]model.Object) (model.Object, error) {
+	if obj, ok := objects[objID]; ok {
+		return obj, nil
+	}
+	obj, err := c.obj.FindSingleObject(params, objID)
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to find the object(%s), err: %s, rid: %s", objID, err.Error(), params.ReqID)
+		return nil, err
+	}
+	objects[objID] = obj
+	return obj, nil
+}
+
+// authorizeDeletePlan checks the delete permission of every instance in the plan, not only the requested ones,
+// so that the cascade deletion never removes the instances which the user is not allowed to delete.
+func (c *commonInst) authorizeDeletePlan(params types.ContextParams, plan *deletePlan) error {
+	objIDs := make([]string, 0)
+	instIDs := make(map[string][]int64)
+	for _, delInst := range plan.insts {
+		objID := delInst.obj.GetObjectID()
+		if _, ok := instIDs[objID]; !ok {
+			objIDs = append(objIDs, objID)
+		}
+		instIDs[objID] = append(instIDs[objID], delInst.instID)
+	}
+
+	for _, objID := range objIDs {
+		if err := c.authManager.AuthorizeByInstanceID(params.Context, params.Header, meta.Delete, objID, instIDs[objID]...); err != nil {
+			blog.Errorf("[operation-inst] authorize to delete the object(%s) insts(%v) failed, err: %v, rid: %s", objID, instIDs[objID], err, params.ReqID)
+			return params.Err.New(common.CCErrCommAuthorizeFailed, err.Error())
+		}
+	}
+	return nil
+}
+
+// findDeletedInsts returns the instances with their mainline children, the first one is the requested instance.
+func (c *commonInst) findDeletedInsts(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool) ([]deletedInst, error) {
+	cond := condition.CreateCondition()
+	cond.Field(obj.GetInstIDFieldName()).In(instID)
+	if obj.IsCommon() {
+		cond.Field(common.BKObjIDField).Eq(obj.GetObjectID())
+	}
+
+	query := &metadata.QueryInput{}
+	query.Condition = cond.ToMapStr()
+	query.Limit = common.BKNoLimit
+
+	_, insts, err := c.FindInst(params, obj, query, false)
+	if nil != err {
+		return nil, err
+	}
+
+	deleteIDS := make([]deletedInst, 0)
+	for _, inst := range insts {
+		ids, exists, err := c.hasHost(params, inst, needCheckHost)
+		if nil != err {
+			return nil, params.Err.Error(common.CCErrTopoHasHostCheckFailed)
+		}
+
+		if exists {
+			return nil, params.Err.Error(common.CCErrTopoHasHostCheckFailed)
+		}
+
+		if len(ids) > 0 {
+			ids[0].reason = metadata.DeleteInstReasonRequest
+		}
+		deleteIDS = append(deleteIDS, ids...)
+	}
+	return deleteIDS, nil
+}
+
+func (c *commonInst) isInstExist(params types.ContextParams, objID string, instID int64) (bool, error) {
+	cond := mapstr.MapStr{common.GetInstIDField(objID): instID}
+	rsp, err := c.clientSet.CoreService().Instance().ReadInstance(params.Context, params.Header, objID, &metadata.QueryCondition{Condition: cond})
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to request object controller, err: %s, rid: %s", err.Error(), params.ReqID)
+		return false, params.Err.Error(common.CCErrObjectSelectInstFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[operation-inst] failed to search the object(%s) inst(%d), err: %s, rid: %s", objID, instID, rsp.ErrMsg, params.ReqID)
+		return false, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	return len(rsp.Data.Info) > 0, nil
+}
+
+// getAssociationOnDelete returns the on delete action of the model association, the result is cached in onDeletes.
+func (c *commonInst) getAssociationOnDelete(params types.ContextParams, asstID string, onDeletes map[string]metadata.AssociationOnDeleteAction) (metadata.AssociationOnDeleteAction, error) {
+	if onDelete, ok := onDeletes[asstID]; ok {
+		return onDelete, nil
+	}
+
+	cond := mapstr.MapStr{common.AssociationObjAsstIDField: asstID}
+	rsp, err := c.clientSet.CoreService().Association().ReadModelAssociation(params.Context, params.Header, &metadata.QueryCondition{Condition: cond})
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to request object controller, err: %s, rid: %s", err.Error(), params.ReqID)
+		return "", params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("[operation-inst] failed to search the association(%s), err: %s, rid: %s", asstID, rsp.ErrMsg, params.ReqID)
+		return "", params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	onDelete := metadata.NoAction
+	if len(rsp.Data.Info) > 0 && len(rsp.Data.Info[0].OnDelete) > 0 {
+		onDelete = rsp.Data.Info[0].OnDelete
+	}
+	onDeletes[asstID] = onDelete
+	return onDelete, nil
+}
+
+// deleteInstAssociations remove all the instance associations of the deleted instance,
+// the ones removed for the cascade deletion are audited.
+func (c *commonInst) deleteInstAssociations(params types.ContextParams, delInst deletedInst, plan *deletePlan) error {
+	objID := delInst.obj.GetObjectID()
+	cond := condition.CreateCondition()
+	or := cond.NewOR()
+	or.Item(mapstr.MapStr{common.BKObjIDField: objID, common.BKInstIDField: delInst.instID})
+	or.Item(mapstr.MapStr{common.BKAsstObjIDField: objID, common.BKAsstInstIDField: delInst.instID})
+	if err := c.asst.DeleteInstAssociation(params, cond); nil != err {
+		blog.Errorf("[operation-inst] failed to delete the associations of the object(%s) inst(%d), err: %s, rid: %s", objID, delInst.instID, err.Error(), params.ReqID)
+		return err
+	}
+
+	if delInst.reason != metadata.DeleteInstReasonCascade {
+		return nil
+	}
+	for _, asst := range plan.assts {
+		if asst.ObjectAsstID != delInst.asstID {
+			continue
+		}
+		isSource := asst.ObjectID == objID && asst.InstID == delInst.instID &&
+			asst.AsstObjectID == delInst.causeObjID && asst.AsstInstID == delInst.causeInstID
+		isDest := asst.AsstObjectID == objID && asst.AsstInstID == delInst.instID &&
+			asst.ObjectID == delInst.causeObjID && asst.InstID == delInst.causeInstID
+		if !isSource && !isDest {
+			continue
+		}
+
+		auditlog := metadata.SaveAuditLogParams{
+			ID:    asst.InstID,
+			Model: asst.ObjectID,
+			Content: metadata.Content{
+				PreData: mapstr.NewFromStruct(asst, "json"),
+				Headers: InstanceAssociationAuditHeaders,
+			},
+			OpDesc: "cascade delete instance association",
+			OpType: auditoplog.AuditOpTypeDel,
+			BizID:  delInst.bizID,
+		}
+		auditresp, err := c.clientSet.CoreService().Audit().SaveAuditLog(params.Context, params.Header, auditlog)
+		if nil != err {
+			blog.Errorf("[operation-inst] failed to save the audit log(%#v), err: %s, rid: %s", auditlog, err.Error(), params.ReqID)
+			return params.Err.Error(common.CCErrAuditSaveLogFailed)
+		}
+		if !auditresp.Result {
+			blog.Errorf("[operation-inst] failed to save the audit log(%#v), err: %s, rid: %s", auditlog, auditresp.ErrMsg, params.ReqID)
+			return params.Err.New(auditresp.Code, auditresp.ErrMsg)
+		}
+	}
+	return nil
+}
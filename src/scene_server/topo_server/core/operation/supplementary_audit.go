@@ -14,6 +14,7 @@ package operation
 
 import (
 	"context"
+	"fmt"
 
 	"configcenter/src/apimachinery"
 	"configcenter/src/common"
@@ -37,6 +38,7 @@ type AuditInterface interface {
 	CreateSnapshot(instID int64, cond mapstr.MapStr) *WrapperResult
 	CommitCreateLog(preData, currData *WrapperResult, inst inst.Inst, nonInnerAttributes []model.AttributeInterface)
 	CommitDeleteLog(preData, currData *WrapperResult, inst inst.Inst)
+	CommitCascadeDeleteLog(preData *WrapperResult, asstID string, causeObjID string, causeInstID int64)
 	CommitUpdateLog(preData, currData *WrapperResult, inst inst.Inst, nonInnerAttributes []model.AttributeInterface)
 }
 
@@ -45,6 +47,8 @@ type auditLog struct {
 	inst   InstOperationInterface
 	params types.ContextParams
 	obj    model.Object
+	// desc replace the default operation description if it's not empty
+	desc string
 }
 
 // nonInnerAttributes 用于加速，避免不必要的数据查询(批量创建实例时，每次创建实例都会执行nonInnerAttributes)
@@ -119,6 +123,9 @@ func (a *auditLog) commitSnapshot(preData, currData *WrapperResult, action audit
 			}
 
 		}
+		if len(a.desc) != 0 {
+			desc = a.desc
+		}
 
 		headers := []Header{}
 		for _, attr := range nonInnerAttributes {
@@ -200,6 +207,12 @@ func (a *auditLog) CommitDeleteLog(preData, currData *WrapperResult, inst inst.I
 	a.commitSnapshot(preData, currData, auditoplog.AuditOpTypeDel, nil)
 }
 
+// CommitCascadeDeleteLog commit the delete log of the instance which is deleted with cascade by an association
+func (a *auditLog) CommitCascadeDeleteLog(preData *WrapperResult, asstID string, causeObjID string, causeInstID int64) {
+	a.desc = fmt.Sprintf("cascade delete %s by association %s of %s %d", a.obj.GetObjectType(), asstID, causeObjID, causeInstID)
+	a.commitSnapshot(preData, nil, auditoplog.AuditOpTypeDel, nil)
+}
+
 func (a *auditLog) CommitUpdateLog(preData, currData *WrapperResult, inst inst.Inst, nonInnerAttributes []model.AttributeInterface) {
 	a.commitSnapshot(preData, currData, auditoplog.AuditOpTypeModify, nonInnerAttributes)
 }
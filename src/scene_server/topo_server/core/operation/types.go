@@ -74,9 +74,16 @@ type CommonInstTopoV2 struct {
 }
 
 type deletedInst struct {
-	instID int64
-	bizID  int64
-	obj    model.Object
+	instID   int64
+	bizID    int64
+	instName string
+	obj      model.Object
+	// reason is why the instance is deleted, and the following fields
+	// are the association and instance which cause the cascade deletion
+	reason      string
+	asstID      string
+	causeObjID  string
+	causeInstID int64
 }
 
 // OperationLog opeartion log item definition
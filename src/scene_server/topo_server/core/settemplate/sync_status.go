@@ -120,7 +120,7 @@ func (st *setTemplate) UpdateSetSyncStatus(params types.ContextParams, setID int
 		}
 	} else if !detail.Status.IsFinished() {
 		syncStatus = metadata.SyncStatusSyncing
-	} else if detail.Status.IsSuccessful() {
+	} else if detail.Status.IsSuccessful() || detail.Status.IsCanceled() {
 		if setDiff.NeedSync {
 			syncStatus = metadata.SyncStatusWaiting
 		} else {
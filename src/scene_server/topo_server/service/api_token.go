@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// the api tokens belong to the current user, a token calls the api server as the user
+// with the user's permissions narrowed by the token's businesses and scope, so any
+// user can manage their own tokens.
+
+// CreateAPIToken creates a token of the current user, the token is returned only this time
+func (s *Service) CreateAPIToken(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.APITokenOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	token, err := s.Engine.CoreAPI.CoreService().APIToken().CreateAPIToken(params.Context, params.Header, option)
+	if err != nil {
+		blog.Errorf("CreateAPIToken failed, core service create failed, option: %+v, err: %v, rid: %s", option, err, params.ReqID)
+		return nil, err
+	}
+	return token, nil
+}
+
+// ListAPIToken lists the tokens of the current user
+func (s *Service) ListAPIToken(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ListAPITokenOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	tokens, err := s.Engine.CoreAPI.CoreService().APIToken().ListAPIToken(params.Context, params.Header, option)
+	if err != nil {
+		blog.Errorf("ListAPIToken failed, core service list failed, option: %+v, err: %v, rid: %s", option, err, params.ReqID)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// DeleteAPIToken revokes a token of the current user
+func (s *Service) DeleteAPIToken(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		return nil, params.Err.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+	}
+
+	if ccErr := s.Engine.CoreAPI.CoreService().APIToken().DeleteAPIToken(params.Context, params.Header, id); ccErr != nil {
+		blog.Errorf("DeleteAPIToken failed, core service delete failed, id: %d, err: %v, rid: %s", id, ccErr, params.ReqID)
+		return nil, ccErr
+	}
+	return nil, nil
+}
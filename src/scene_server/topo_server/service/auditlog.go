@@ -15,6 +15,7 @@ package service
 import (
 	"configcenter/src/auth"
 	"fmt"
+	"strconv"
 
 	"configcenter/src/auth/meta"
 	"configcenter/src/common"
@@ -182,6 +183,18 @@ func (s *Service) InstanceAuditQuery(params types.ContextParams, pathParams, que
 		}
 	}
 
+	if resp, err := s.authorizeInstanceAudit(params, objectID, instanceID, businessID); err != nil {
+		return resp, err
+	}
+
+	blog.V(4).Infof("InstanceAuditQuery failed, AuditOperation parameter: %+v, rid: %s", query, params.ReqID)
+	return s.Core.AuditOperation().Query(params, query)
+}
+
+// authorizeInstanceAudit check the authorization on the instance whose audit log is read,
+// the response is not nil if there is a no permission response for the web.
+func (s *Service) authorizeInstanceAudit(params types.ContextParams, objectID string, instanceID, businessID int64) (interface{}, error) {
+	var err error
 	action := meta.Find
 	switch objectID {
 	case common.BKInnerObjIDHost:
@@ -191,7 +204,7 @@ func (s *Service) InstanceAuditQuery(params types.ContextParams, pathParams, que
 		if err != nil && err == auth.NoAuthorizeError {
 			resp, err := s.AuthManager.GenProcessNoPermissionResp(params.Context, params.Header, businessID)
 			if err != nil {
-				return nil, params.Err.Errorf(common.CCErrTopoGetAppFailed, bizID)
+				return nil, params.Err.Errorf(common.CCErrTopoGetAppFailed, businessID)
 			}
 			return resp, auth.NoAuthorizeError
 		}
@@ -218,10 +231,189 @@ func (s *Service) InstanceAuditQuery(params types.ContextParams, pathParams, que
 		err = s.AuthManager.AuthorizeByInstanceID(params.Context, params.Header, action, objectID, instanceID)
 	}
 	if err != nil {
-		blog.Errorf("InstanceAuditQuery failed, query instance audit log failed, authorization on instance of model %s failed, err: %+v, rid: %s", objectID, err, params.ReqID)
+		blog.Errorf("authorize instance audit failed, authorization on instance of model %s failed, err: %+v, rid: %s", objectID, err, params.ReqID)
 		return nil, params.Err.Error(common.CCErrCommAuthorizeFailed)
 	}
 
-	blog.V(4).Infof("InstanceAuditQuery failed, AuditOperation parameter: %+v, rid: %s", query, params.ReqID)
-	return s.Core.AuditOperation().Query(params, query)
+	return nil, nil
+}
+
+// InstanceHistory rebuild the instance at the time from the audit log
+func (s *Service) InstanceHistory(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objectID := pathParams("bk_obj_id")
+	instanceID, err := strconv.ParseInt(pathParams("inst_id"), 10, 64)
+	if err != nil {
+		blog.Errorf("InstanceHistory failed, parse inst id %s failed, err: %v, rid: %s", pathParams("inst_id"), err, params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "inst_id")
+	}
+
+	input := metadata.AuditHistoryRequest{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("InstanceHistory failed, failed to parse the input (%#v), err: %s, rid: %s", data, err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+	if input.Time.IsZero() {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedSet, "time")
+	}
+
+	if resp, err := s.authorizeInstanceAudit(params, objectID, instanceID, 0); err != nil {
+		return resp, err
+	}
+
+	return s.Core.AuditOperation().InstanceHistory(params, objectID, instanceID, input.Time.Time)
+}
+
+// InstanceHistoryDiff compare the instance at two times field by field from the audit log
+func (s *Service) InstanceHistoryDiff(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objectID := pathParams("bk_obj_id")
+	instanceID, err := strconv.ParseInt(pathParams("inst_id"), 10, 64)
+	if err != nil {
+		blog.Errorf("InstanceHistoryDiff failed, parse inst id %s failed, err: %v, rid: %s", pathParams("inst_id"), err, params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "inst_id")
+	}
+
+	input := metadata.AuditHistoryDiffRequest{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("InstanceHistoryDiff failed, failed to parse the input (%#v), err: %s, rid: %s", data, err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+	if input.StartTime.IsZero() {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedSet, "start_time")
+	}
+	if input.EndTime.IsZero() {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedSet, "end_time")
+	}
+	if input.EndTime.Before(input.StartTime.Time) {
+		return nil, params.Err.Errorf(common.CCErrCommParamsInvalid, "end_time")
+	}
+
+	if resp, err := s.authorizeInstanceAudit(params, objectID, instanceID, 0); err != nil {
+		return resp, err
+	}
+
+	return s.Core.AuditOperation().InstanceHistoryDiff(params, objectID, instanceID, input.StartTime.Time, input.EndTime.Time)
+}
+
+// BizTopoHistory rebuild the mainline topology of the business at the time from the audit log
+func (s *Service) BizTopoHistory(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	bizID, err := strconv.ParseInt(pathParams("bk_biz_id"), 10, 64)
+	if err != nil {
+		blog.Errorf("BizTopoHistory failed, parse biz id %s failed, err: %v, rid: %s", pathParams("bk_biz_id"), err, params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, common.BKAppIDField)
+	}
+
+	input := metadata.AuditHistoryRequest{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("BizTopoHistory failed, failed to parse the input (%#v), err: %s, rid: %s", data, err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+	if input.Time.IsZero() {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedSet, "time")
+	}
+
+	if resp, err := s.authorizeInstanceAudit(params, common.BKInnerObjIDApp, bizID, bizID); err != nil {
+		return resp, err
+	}
+
+	return s.Core.AuditOperation().BizTopoHistory(params, bizID, input.Time.Time)
+}
+
+// RevertAuditLog revert the instance, host attributes or instance association changed by the audit log
+// to the state before the change, or only return what will be done if it's a dry run.
+func (s *Service) RevertAuditLog(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	auditID, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("RevertAuditLog failed, parse audit log id %s failed, err: %v, rid: %s", pathParams("id"), err, params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, common.BKFieldID)
+	}
+
+	input := metadata.AuditRevertRequest{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("RevertAuditLog failed, failed to parse the input (%#v), err: %s, rid: %s", data, err.Error(), params.ReqID)
+		return nil, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+
+	authorize := func(plan *metadata.AuditRevertResult) error {
+		return s.authorizeAuditRevert(params, plan)
+	}
+	plan, err := s.Core.AuditOperation().PlanRevert(params, auditID, authorize)
+	if err != nil {
+		blog.Errorf("RevertAuditLog failed, plan the revert of audit log %d failed, err: %v, rid: %s", auditID, err, params.ReqID)
+		return nil, err
+	}
+	plan.DryRun = input.DryRun
+	if plan.DryRun {
+		return plan, nil
+	}
+
+	if err := s.Core.AuditOperation().Revert(params, plan); err != nil {
+		blog.Errorf("RevertAuditLog failed, revert audit log %d failed, err: %v, rid: %s", auditID, err, params.ReqID)
+		return nil, err
+	}
+
+	// auth: sync the reverted instance to iam
+	if plan.Target != metadata.AuditRevertTargetInstance {
+		return plan, nil
+	}
+	switch plan.Action {
+	case metadata.AuditRevertActionCreate:
+		if err := s.AuthManager.RegisterInstancesByID(params.Context, params.Header, plan.ObjectID, plan.NewInstID); err != nil {
+			blog.Errorf("RevertAuditLog success, but register instance %d to iam failed, err: %v, rid: %s", plan.NewInstID, err, params.ReqID)
+			return nil, params.Err.Error(common.CCErrCommRegistResourceToIAMFailed)
+		}
+	case metadata.AuditRevertActionDelete:
+		if err := s.AuthManager.DeregisterInstanceByRawID(params.Context, params.Header, plan.ObjectID, plan.InstID); err != nil {
+			blog.Errorf("RevertAuditLog success, but deregister instance %d from iam failed, err: %v, rid: %s", plan.InstID, err, params.ReqID)
+			return nil, params.Err.Error(common.CCErrCommUnRegistResourceToIAMFailed)
+		}
+	case metadata.AuditRevertActionUpdate:
+		if err := s.AuthManager.UpdateRegisteredInstanceByID(params.Context, params.Header, plan.ObjectID, plan.InstID); err != nil {
+			blog.Errorf("RevertAuditLog success, but update registered instance %d failed, err: %v, rid: %s", plan.InstID, err, params.ReqID)
+			return nil, params.Err.Error(common.CCErrCommRegistResourceToIAMFailed)
+		}
+	}
+	return plan, nil
+}
+
+// authorizeAuditRevert check the authorization to change the instance as the revert will do,
+// only the target and action of the plan is set when it's called.
+func (s *Service) authorizeAuditRevert(params types.ContextParams, plan *metadata.AuditRevertResult) error {
+	var err error
+	switch {
+	case plan.Target == metadata.AuditRevertTargetInstance && plan.Action == metadata.AuditRevertActionCreate:
+		err = s.AuthManager.AuthorizeResourceCreate(params.Context, params.Header, plan.BizID, meta.ModelInstance)
+	case plan.Target == metadata.AuditRevertTargetInstance && plan.Action == metadata.AuditRevertActionDelete:
+		err = s.AuthManager.AuthorizeByInstanceID(params.Context, params.Header, meta.Delete, plan.ObjectID, plan.InstID)
+	default:
+		// changing the association is to update the source instance
+		err = s.AuthManager.AuthorizeByInstanceID(params.Context, params.Header, meta.Update, plan.ObjectID, plan.InstID)
+	}
+	if err != nil {
+		blog.Errorf("authorize audit revert failed, authorization on instance %d of model %s failed, err: %+v, rid: %s", plan.InstID, plan.ObjectID, err, params.ReqID)
+		return params.Err.Error(common.CCErrCommAuthorizeFailed)
+	}
+	return nil
+}
+
+// VerifyAuditChain verify the audit log hash chain of the supplier account to find the tampered audit logs
+func (s *Service) VerifyAuditChain(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	if err := s.AuthManager.AuthorizeAuditRead(params.Context, params.Header, 0); err != nil {
+		blog.Errorf("VerifyAuditChain failed, authorize failed, AuthorizeAuditRead failed, err: %+v, rid: %s", err, params.ReqID)
+		resp, err := s.AuthManager.GenAuthorizeAuditReadNoPermissionsResponse(params.Context, params.Header, 0)
+		if err != nil {
+			return nil, fmt.Errorf("try authorize failed, err: %v", err)
+		}
+		return resp, auth.NoAuthorizeError
+	}
+
+	rsp, err := s.Engine.CoreAPI.CoreService().Audit().VerifyAuditChain(params.Context, params.Header)
+	if err != nil {
+		blog.Errorf("VerifyAuditChain failed, request core service failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("VerifyAuditChain failed, err: %s, rid: %s", rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	return rsp.Data, nil
 }
@@ -9,6 +9,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/topo_server/core/types"
 
@@ -57,11 +58,6 @@ func NewQuery() *Query {
 }
 
 func (s *Service) FullTextFind(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
-	if s.Es.Client == nil {
-		blog.Errorf("FullTextFind failed, es client is nil, rid: %s", params.ReqID)
-		return nil, params.Err.Error(common.CCErrorTopoFullTextClientNotInitialized)
-	}
-
 	if _, exist := data["query_string"]; exist == false {
 		return nil, params.Err.Error(common.CCErrCommParamsIsInvalid)
 	}
@@ -81,6 +77,11 @@ func (s *Service) FullTextFind(params types.ContextParams, pathParams, queryPara
 	// get query and search types
 	esQuery, searchTypes := query.toEsQueryAndSearchTypes()
 
+	// search the text indexes of db if elasticsearch is not deployed
+	if s.Es.Client == nil {
+		return s.dbFullTextFind(params, query, rawString, searchTypes)
+	}
+
 	result, err := s.Es.Search(params.Context, esQuery, searchTypes, query.Paging.Start, query.Paging.Limit)
 	if err != nil {
 		blog.Errorf("full_text_find failed, es search failed, err: %+v, rid: %s", err, params.ReqID)
@@ -94,7 +95,7 @@ func (s *Service) FullTextFind(params types.ContextParams, pathParams, queryPara
 	// set hits
 	for _, hit := range result.Hits.Hits {
 		// ignore not correct cmdb table data
-		if hit.Index == common.CMDBINDEX && hit.Id != common.INDICES {
+		if isCmdbIndex(hit.Index) && hit.Id != common.INDICES {
 			sr := SearchResult{}
 			sr.setHit(params.Context, hit, query.BkBizId, rawString)
 			searchResults.Hits = append(searchResults.Hits, sr)
@@ -240,6 +241,12 @@ func getEsIndexTypes(typesFilter []string) []string {
 	return indexTypes
 }
 
+// isCmdbIndex returns if the index is the cmdb index, which is either the index synchronized by
+// mongo-connector or the index built by the event server, which is searched by its alias.
+func isCmdbIndex(index string) bool {
+	return index == common.CMDBINDEX || strings.HasPrefix(index, common.CMDBINDEX+"_")
+}
+
 func inTypes(val string, types []string) bool {
 	for _, v := range types {
 		if v == val {
@@ -264,7 +271,21 @@ func (agg *Aggregation) setAgg(bucket *elastic.AggregationBucketKeyItem) {
 func (sr *SearchResult) setHit(ctx context.Context, searchHit *elastic.SearchHit, bkBizId, rawString string) {
 	rid := util.ExtractRequestIDFromContext(ctx)
 	sr.Score = *searchHit.Score
-	switch searchHit.Type {
+	sr.setType(searchHit.Type)
+
+	// sr.Highlight = searchHit.Highlight
+	err := json.Unmarshal(*searchHit.Source, &(sr.Source))
+	if err != nil {
+		blog.Warnf("full_text_find unmarshal search result source err: %+v, rid: %s", err, rid)
+		sr.Source = nil
+	}
+
+	sr.dealHighlight(sr.Source, searchHit.Highlight, bkBizId, rawString)
+}
+
+// setType sets the search type of the table the hit is in
+func (sr *SearchResult) setType(table string) {
+	switch table {
 	case common.BKTableNameBaseInst:
 		sr.Type = common.TypeObject
 	case common.BKTableNameBaseHost:
@@ -276,15 +297,39 @@ func (sr *SearchResult) setHit(ctx context.Context, searchHit *elastic.SearchHit
 	case common.BKTableNameObjDes:
 		sr.Type = common.TypeModel
 	}
+}
 
-	// sr.Highlight = searchHit.Highlight
-	err := json.Unmarshal(*searchHit.Source, &(sr.Source))
+// dbFullTextFind searches the text indexes of db, the result is the same with the elasticsearch search
+func (s *Service) dbFullTextFind(params types.ContextParams, query *Query, rawString string, searchTypes []string) (*SearchResults, error) {
+	option := metadata.FullTextSearchOption{
+		QueryString: rawString,
+		Tables:      searchTypes,
+		BizID:       query.BkBizId,
+		Start:       query.Paging.Start,
+		Limit:       query.Paging.Limit,
+	}
+	// the hosts and the businesses are searched by the types, the others are searched by the bk_obj_id
+	if query.BkObjId != common.TypeHost && query.BkObjId != common.TypeApplication {
+		option.ObjectID = query.BkObjId
+	}
+
+	result, err := s.Engine.CoreAPI.CoreService().FullText().Search(params.Context, params.Header, option)
 	if err != nil {
-		blog.Warnf("full_text_find unmarshal search result source err: %+v, rid: %s", err, rid)
-		sr.Source = nil
+		blog.Errorf("full_text_find failed, db search failed, option: %+v, err: %v, rid: %s", option, err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrorTopoFullTextFindErr)
 	}
 
-	sr.dealHighlight(sr.Source, searchHit.Highlight, bkBizId, rawString)
+	searchResults := &SearchResults{Total: result.Total}
+	for _, agg := range result.Aggregations {
+		searchResults.Aggregations = append(searchResults.Aggregations, Aggregation{Key: agg.Key, Count: agg.Count})
+	}
+	for _, hit := range result.Hits {
+		sr := SearchResult{Source: hit.Source, Score: hit.Score}
+		sr.setType(hit.Table)
+		sr.dealHighlight(sr.Source, hit.Highlight, query.BkBizId, rawString)
+		searchResults.Hits = append(searchResults.Hits, sr)
+	}
+	return searchResults, nil
 }
 
 func (sr *SearchResult) dealHighlight(source map[string]interface{}, highlight elastic.SearchHitHighlight, bkBizId, rawString string) {
@@ -167,6 +167,33 @@ func (s *Service) DeleteInsts(params types.ContextParams, pathParams, queryParam
 	return nil, nil
 }
 
+// PreviewDeleteInsts returns the instances and instance associations which will be removed
+// if the instances are deleted, including the ones deleted with cascade by the associations.
+func (s *Service) PreviewDeleteInsts(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objID := pathParams("bk_obj_id")
+
+	obj, err := s.Core.ObjectOperation().FindSingleObject(params, objID)
+	if nil != err {
+		blog.Errorf("[api-inst] failed to find the objects(%s), error info is %s, rid: %s", objID, err.Error(), params.ReqID)
+		return nil, err
+	}
+
+	deleteCondition := &operation.OpCondition{}
+	if err := data.MarshalJSONInto(deleteCondition); nil != err {
+		return nil, err
+	}
+	if len(deleteCondition.Delete.InstID) == 0 {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedSet, "inst_ids")
+	}
+
+	preview, err := s.Core.InstOperation().PreviewDeleteInst(params, obj, deleteCondition.Delete.InstID, true)
+	if err != nil {
+		blog.Errorf("PreviewDeleteInsts failed, err: %s, objID: %s, instIDs: %+v, rid: %s", err.Error(), objID, deleteCondition.Delete.InstID, params.ReqID)
+		return nil, err
+	}
+	return preview, nil
+}
+
 // DeleteInst delete the inst
 func (s *Service) DeleteInst(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
 	objID := pathParams("bk_obj_id")
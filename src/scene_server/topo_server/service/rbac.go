@@ -0,0 +1,238 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/auth/local"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// the roles, role bindings and user groups are used by the local authorizer, the apis are
+// authorized as the system base resource, so only the administrators can manage them.
+
+func parseRBACID(params types.ContextParams, pathParams ParamsGetter) (int64, error) {
+	id, err := strconv.ParseInt(pathParams(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, params.Err.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+	}
+	return id, nil
+}
+
+func (s *Service) CreateRBACRole(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.RBACRoleOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+	if err := local.ValidateGrants(option.Grants); err != nil {
+		blog.Errorf("CreateRBACRole failed, invalid grants: %+v, err: %v, rid: %s", option.Grants, err, params.ReqID)
+		return nil, params.Err.CCErrorf(common.CCErrCommParamsInvalid, "grants")
+	}
+
+	role, err := s.Engine.CoreAPI.CoreService().RBAC().CreateRole(params.Context, params.Header, option)
+	if err != nil {
+		blog.Errorf("CreateRBACRole failed, core service create failed, option: %+v, err: %v, rid: %s", option, err, params.ReqID)
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *Service) UpdateRBACRole(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(params, pathParams)
+	if err != nil {
+		return nil, err
+	}
+	option := metadata.RBACRoleOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+	if err := local.ValidateGrants(option.Grants); err != nil {
+		blog.Errorf("UpdateRBACRole failed, invalid grants: %+v, err: %v, rid: %s", option.Grants, err, params.ReqID)
+		return nil, params.Err.CCErrorf(common.CCErrCommParamsInvalid, "grants")
+	}
+
+	role, ccErr := s.Engine.CoreAPI.CoreService().RBAC().UpdateRole(params.Context, params.Header, id, option)
+	if ccErr != nil {
+		blog.Errorf("UpdateRBACRole failed, core service update failed, id: %d, option: %+v, err: %v, rid: %s", id, option, ccErr, params.ReqID)
+		return nil, ccErr
+	}
+	return role, nil
+}
+
+func (s *Service) DeleteRBACRole(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(params, pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if ccErr := s.Engine.CoreAPI.CoreService().RBAC().DeleteRole(params.Context, params.Header, id); ccErr != nil {
+		blog.Errorf("DeleteRBACRole failed, core service delete failed, id: %d, err: %v, rid: %s", id, ccErr, params.ReqID)
+		return nil, ccErr
+	}
+	return nil, nil
+}
+
+func (s *Service) ListRBACRole(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ListRBACOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	roles, err := s.Engine.CoreAPI.CoreService().RBAC().ListRole(params.Context, params.Header, option)
+	if err != nil {
+		blog.Errorf("ListRBACRole failed, core service list failed, option: %+v, err: %v, rid: %s", option, err, params.ReqID)
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (s *Service) CreateRBACRoleBinding(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.RBACRoleBindingOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	binding, err := s.Engine.CoreAPI.CoreService().RBAC().CreateRoleBinding(params.Context, params.Header, option)
+	if err != nil {
+		blog.Errorf("CreateRBACRoleBinding failed, core service create failed, option: %+v, err: %v, rid: %s", option, err, params.ReqID)
+		return nil, err
+	}
+	return binding, nil
+}
+
+func (s *Service) UpdateRBACRoleBinding(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(params, pathParams)
+	if err != nil {
+		return nil, err
+	}
+	option := metadata.RBACRoleBindingOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	binding, ccErr := s.Engine.CoreAPI.CoreService().RBAC().UpdateRoleBinding(params.Context, params.Header, id, option)
+	if ccErr != nil {
+		blog.Errorf("UpdateRBACRoleBinding failed, core service update failed, id: %d, option: %+v, err: %v, rid: %s", id, option, ccErr, params.ReqID)
+		return nil, ccErr
+	}
+	return binding, nil
+}
+
+func (s *Service) DeleteRBACRoleBinding(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(params, pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if ccErr := s.Engine.CoreAPI.CoreService().RBAC().DeleteRoleBinding(params.Context, params.Header, id); ccErr != nil {
+		blog.Errorf("DeleteRBACRoleBinding failed, core service delete failed, id: %d, err: %v, rid: %s", id, ccErr, params.ReqID)
+		return nil, ccErr
+	}
+	return nil, nil
+}
+
+func (s *Service) ListRBACRoleBinding(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ListRBACOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	bindings, err := s.Engine.CoreAPI.CoreService().RBAC().ListRoleBinding(params.Context, params.Header, option)
+	if err != nil {
+		blog.Errorf("ListRBACRoleBinding failed, core service list failed, option: %+v, err: %v, rid: %s", option, err, params.ReqID)
+		return nil, err
+	}
+	return bindings, nil
+}
+
+func (s *Service) CreateRBACUserGroup(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.RBACUserGroupOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	group, err := s.Engine.CoreAPI.CoreService().RBAC().CreateUserGroup(params.Context, params.Header, option)
+	if err != nil {
+		blog.Errorf("CreateRBACUserGroup failed, core service create failed, option: %+v, err: %v, rid: %s", option, err, params.ReqID)
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *Service) UpdateRBACUserGroup(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(params, pathParams)
+	if err != nil {
+		return nil, err
+	}
+	option := metadata.RBACUserGroupOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	group, ccErr := s.Engine.CoreAPI.CoreService().RBAC().UpdateUserGroup(params.Context, params.Header, id, option)
+	if ccErr != nil {
+		blog.Errorf("UpdateRBACUserGroup failed, core service update failed, id: %d, option: %+v, err: %v, rid: %s", id, option, ccErr, params.ReqID)
+		return nil, ccErr
+	}
+	return group, nil
+}
+
+func (s *Service) DeleteRBACUserGroup(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(params, pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if ccErr := s.Engine.CoreAPI.CoreService().RBAC().DeleteUserGroup(params.Context, params.Header, id); ccErr != nil {
+		blog.Errorf("DeleteRBACUserGroup failed, core service delete failed, id: %d, err: %v, rid: %s", id, ccErr, params.ReqID)
+		return nil, ccErr
+	}
+	return nil, nil
+}
+
+func (s *Service) ListRBACUserGroup(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ListRBACOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	groups, err := s.Engine.CoreAPI.CoreService().RBAC().ListUserGroup(params.Context, params.Header, option)
+	if err != nil {
+		blog.Errorf("ListRBACUserGroup failed, core service list failed, option: %+v, err: %v, rid: %s", option, err, params.ReqID)
+		return nil, err
+	}
+	return groups, nil
+}
+
+// GetRBACUserPolicy returns the roles bound to the user directly or by the user groups, the current user if not set
+func (s *Service) GetRBACUserPolicy(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.RBACUserPolicyOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		return nil, params.Err.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+	if len(option.User) == 0 {
+		option.User = params.User
+	}
+
+	policy, err := s.Engine.CoreAPI.CoreService().RBAC().GetUserPolicy(params.Context, params.Header, option.User)
+	if err != nil {
+		blog.Errorf("GetRBACUserPolicy failed, core service get failed, user: %s, err: %v, rid: %s", option.User, err, params.ReqID)
+		return nil, err
+	}
+	return policy, nil
+}
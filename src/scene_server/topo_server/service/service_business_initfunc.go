@@ -105,6 +105,7 @@ func (s *Service) initBusinessInst() {
 	s.addAction(http.MethodPost, "/create/instance/object/{bk_obj_id}", s.CreateInst, nil)
 	s.addAction(http.MethodDelete, "/delete/instance/object/{bk_obj_id}/inst/{inst_id}", s.DeleteInst, nil)
 	s.addAction(http.MethodDelete, "/deletemany/instance/object/{bk_obj_id}", s.DeleteInsts, nil)
+	s.addAction(http.MethodPost, "/deletemany/instance/object/{bk_obj_id}/preview", s.PreviewDeleteInsts, nil)
 	s.addAction(http.MethodPut, "/update/instance/object/{bk_obj_id}/inst/{inst_id}", s.UpdateInst, nil)
 	s.addAction(http.MethodPut, "/updatemany/instance/object/{bk_obj_id}", s.UpdateInsts, nil)
 	s.addAction(http.MethodPost, "/find/instance/object/{bk_obj_id}", s.SearchInstAndAssociationDetail, nil)
@@ -62,7 +62,12 @@ func (s *Service) initAssociation() {
 func (s *Service) initAuditLog() {
 
 	s.addAction(http.MethodPost, "/audit/search", s.AuditQuery, nil)
+	s.addAction(http.MethodPost, "/find/audit/chain/verify", s.VerifyAuditChain, nil)
 	s.addAction(http.MethodPost, "/object/{bk_obj_id}/audit/search", s.InstanceAuditQuery, nil)
+	s.addAction(http.MethodPost, "/find/audit/history/object/{bk_obj_id}/inst/{inst_id}", s.InstanceHistory, nil)
+	s.addAction(http.MethodPost, "/find/audit/history/diff/object/{bk_obj_id}/inst/{inst_id}", s.InstanceHistoryDiff, nil)
+	s.addAction(http.MethodPost, "/find/audit/history/topo/biz/{bk_biz_id}", s.BizTopoHistory, nil)
+	s.addAction(http.MethodPost, "/update/audit/{id}/revert", s.RevertAuditLog, nil)
 }
 
 func (s *Service) initBusiness() {
@@ -202,4 +207,6 @@ func (s *Service) initService() {
 	s.initFind()
 	s.initSetTemplate()
 	s.initInternalTask()
+	s.initRBAC()
+	s.initAPIToken()
 }
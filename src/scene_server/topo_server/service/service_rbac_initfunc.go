@@ -0,0 +1,33 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+)
+
+func (s *Service) initRBAC() {
+	s.addAction(http.MethodPost, "/create/topo/auth/role", s.CreateRBACRole, nil)
+	s.addAction(http.MethodPut, "/update/topo/auth/role/{id}", s.UpdateRBACRole, nil)
+	s.addAction(http.MethodDelete, "/delete/topo/auth/role/{id}", s.DeleteRBACRole, nil)
+	s.addAction(http.MethodPost, "/findmany/topo/auth/role", s.ListRBACRole, nil)
+	s.addAction(http.MethodPost, "/create/topo/auth/role_binding", s.CreateRBACRoleBinding, nil)
+	s.addAction(http.MethodPut, "/update/topo/auth/role_binding/{id}", s.UpdateRBACRoleBinding, nil)
+	s.addAction(http.MethodDelete, "/delete/topo/auth/role_binding/{id}", s.DeleteRBACRoleBinding, nil)
+	s.addAction(http.MethodPost, "/findmany/topo/auth/role_binding", s.ListRBACRoleBinding, nil)
+	s.addAction(http.MethodPost, "/create/topo/auth/user_group", s.CreateRBACUserGroup, nil)
+	s.addAction(http.MethodPut, "/update/topo/auth/user_group/{id}", s.UpdateRBACUserGroup, nil)
+	s.addAction(http.MethodDelete, "/delete/topo/auth/user_group/{id}", s.DeleteRBACUserGroup, nil)
+	s.addAction(http.MethodPost, "/findmany/topo/auth/user_group", s.ListRBACUserGroup, nil)
+	s.addAction(http.MethodPost, "/find/topo/auth/user_policy", s.GetRBACUserPolicy, nil)
+}
@@ -0,0 +1,236 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+type apiToken struct {
+	dbProxy dal.RDB
+}
+
+// New create a new api token operation instance, which keeps the personal api tokens
+// the api server authenticates the requests with
+func New(dbProxy dal.RDB) core.APITokenOperation {
+	return &apiToken{
+		dbProxy: dbProxy,
+	}
+}
+
+const (
+	// tokenPrefix marks the cmdb api tokens, so that they are easy to find when leaked
+	tokenPrefix = "cmdb_"
+	// tokenPrefixLength is the length of the beginning of the token kept to tell the tokens apart
+	tokenPrefixLength = 12
+
+	fieldName         = "name"
+	fieldUser         = "bk_username"
+	fieldTokenHash    = "token_hash"
+	fieldLastUsedTime = "last_used_time"
+	fieldLastUsedIP   = "last_used_ip"
+	fieldUseCount     = "use_count"
+)
+
+// hashToken returns the hash of the token kept in db
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CreateAPIToken creates a token of the current user, the token is returned only this time
+func (a *apiToken) CreateAPIToken(ctx core.ContextParams, option metadata.APITokenOption) (metadata.CreateAPITokenResult, errors.CCErrorCoder) {
+	result := metadata.CreateAPITokenResult{}
+	if key, err := option.Validate(); err != nil {
+		blog.Errorf("CreateAPIToken failed, parameter invalid, key: %s, err: %v, rid: %s", key, err, ctx.ReqID)
+		return result, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+	if option.ExpireDays == 0 {
+		option.ExpireDays = metadata.APITokenDefaultExpireDays
+	}
+
+	filter := map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		fieldUser:             ctx.User,
+		fieldName:             option.Name,
+	}
+	count, err := a.dbProxy.Table(common.BKTableNameAPIToken).Find(filter).Count(ctx.Context)
+	if err != nil {
+		blog.Errorf("CreateAPIToken failed, db count failed, filter: %+v, err: %v, rid: %s", filter, err, ctx.ReqID)
+		return result, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count > 0 {
+		return result, ctx.Error.CCErrorf(common.CCErrCommDuplicateItem, fieldName)
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		blog.Errorf("CreateAPIToken failed, generate token failed, err: %v, rid: %s", err, ctx.ReqID)
+		return result, ctx.Error.CCError(common.CCErrCommInternalServerError)
+	}
+	id, err := a.dbProxy.NextSequence(ctx, common.BKTableNameAPIToken)
+	if err != nil {
+		blog.Errorf("CreateAPIToken failed, generate id failed, err: %v, rid: %s", err, ctx.ReqID)
+		return result, ctx.Error.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := time.Now()
+	result.APIToken = metadata.APIToken{
+		ID:              int64(id),
+		Name:            option.Name,
+		User:            ctx.User,
+		TokenHash:       hashToken(token),
+		TokenPrefix:     token[:tokenPrefixLength],
+		BizIDs:          option.BizIDs,
+		Scope:           option.Scope,
+		ExpiredAt:       now.Add(time.Duration(option.ExpireDays) * 24 * time.Hour),
+		CreateTime:      now,
+		SupplierAccount: ctx.SupplierAccount,
+	}
+	if result.BizIDs == nil {
+		result.BizIDs = make([]int64, 0)
+	}
+	if err := a.dbProxy.Table(common.BKTableNameAPIToken).Insert(ctx.Context, result.APIToken); err != nil {
+		if a.dbProxy.IsDuplicatedError(err) {
+			return result, ctx.Error.CCErrorf(common.CCErrCommDuplicateItem, fieldName)
+		}
+		blog.Errorf("CreateAPIToken failed, db insert failed, name: %s, err: %v, rid: %s", option.Name, err, ctx.ReqID)
+		return result, ctx.Error.CCError(common.CCErrCommDBInsertFailed)
+	}
+	result.Token = token
+	return result, nil
+}
+
+// ListAPIToken lists the tokens of the current user
+func (a *apiToken) ListAPIToken(ctx core.ContextParams, option metadata.ListAPITokenOption) (metadata.MultipleAPIToken, errors.CCErrorCoder) {
+	result := metadata.MultipleAPIToken{Info: make([]metadata.APIToken, 0)}
+	if option.Page.Limit > common.BKMaxPageSize && option.Page.Limit != common.BKNoLimit {
+		return result, ctx.Error.CCError(common.CCErrCommPageLimitIsExceeded)
+	}
+
+	filter := map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		fieldUser:             ctx.User,
+	}
+	if len(option.IDs) > 0 {
+		filter[common.BKFieldID] = map[string]interface{}{common.BKDBIN: option.IDs}
+	}
+
+	query := a.dbProxy.Table(common.BKTableNameAPIToken).Find(filter)
+	count, err := query.Count(ctx.Context)
+	if err != nil {
+		blog.Errorf("ListAPIToken failed, db count failed, filter: %+v, err: %v, rid: %s", filter, err, ctx.ReqID)
+		return result, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(option.Page.Sort) > 0 {
+		query = query.Sort(option.Page.Sort)
+	} else {
+		query = query.Sort(common.BKFieldID)
+	}
+	if option.Page.Limit > 0 {
+		query = query.Limit(uint64(option.Page.Limit))
+	}
+	if option.Page.Start > 0 {
+		query = query.Start(uint64(option.Page.Start))
+	}
+	if err := query.All(ctx.Context, &result.Info); err != nil {
+		blog.Errorf("ListAPIToken failed, db select failed, filter: %+v, err: %v, rid: %s", filter, err, ctx.ReqID)
+		return result, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	result.Count = int64(count)
+	return result, nil
+}
+
+// DeleteAPIToken revokes a token of the current user
+func (a *apiToken) DeleteAPIToken(ctx core.ContextParams, id int64) errors.CCErrorCoder {
+	filter := map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		fieldUser:             ctx.User,
+		common.BKFieldID:      id,
+	}
+	count, err := a.dbProxy.Table(common.BKTableNameAPIToken).Find(filter).Count(ctx.Context)
+	if err != nil {
+		blog.Errorf("DeleteAPIToken failed, db count failed, filter: %+v, err: %v, rid: %s", filter, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		return ctx.Error.CCError(common.CCErrCommNotFound)
+	}
+
+	if err := a.dbProxy.Table(common.BKTableNameAPIToken).Delete(ctx.Context, filter); err != nil {
+		blog.Errorf("DeleteAPIToken failed, db delete failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+// ValidateAPIToken finds the unexpired token and records its usage, the token is
+// looked up in all the supplier accounts as the caller does not know the owner yet.
+func (a *apiToken) ValidateAPIToken(ctx core.ContextParams, option metadata.ValidateAPITokenOption) (metadata.APIToken, errors.CCErrorCoder) {
+	token := metadata.APIToken{}
+	if len(option.Token) == 0 {
+		return token, ctx.Error.CCError(common.CCErrAPITokenInvalid)
+	}
+
+	filter := map[string]interface{}{fieldTokenHash: hashToken(option.Token)}
+	if err := a.dbProxy.Table(common.BKTableNameAPIToken).Find(filter).One(ctx.Context, &token); err != nil {
+		if a.dbProxy.IsNotFoundError(err) {
+			blog.Errorf("ValidateAPIToken failed, token not found, rid: %s", ctx.ReqID)
+			return token, ctx.Error.CCError(common.CCErrAPITokenInvalid)
+		}
+		blog.Errorf("ValidateAPIToken failed, db select failed, err: %v, rid: %s", err, ctx.ReqID)
+		return token, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	now := time.Now()
+	if !now.Before(token.ExpiredAt) {
+		blog.Errorf("ValidateAPIToken failed, token %d of user %s expired at %s, rid: %s", token.ID, token.User, token.ExpiredAt, ctx.ReqID)
+		return token, ctx.Error.CCError(common.CCErrAPITokenInvalid)
+	}
+
+	usageFilter := map[string]interface{}{
+		common.BKOwnerIDField: token.SupplierAccount,
+		common.BKFieldID:      token.ID,
+	}
+	err := a.dbProxy.Table(common.BKTableNameAPIToken).UpdateMultiModel(ctx.Context, usageFilter,
+		dal.ModeUpdate{Op: dal.UpdateOpSet, Doc: map[string]interface{}{fieldLastUsedTime: now, fieldLastUsedIP: option.ClientIP}},
+		dal.ModeUpdate{Op: dal.UpdateOpInc, Doc: map[string]interface{}{fieldUseCount: 1}})
+	if err != nil {
+		// the failure of recording the usage does not fail the request
+		blog.Errorf("ValidateAPIToken, record usage of token %d failed, err: %v, rid: %s", token.ID, err, ctx.ReqID)
+	} else {
+		token.LastUsedTime = &now
+		token.LastUsedIP = option.ClientIP
+		token.UseCount++
+	}
+	return token, nil
+}
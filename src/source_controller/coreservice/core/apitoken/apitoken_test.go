@@ -0,0 +1,105 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apitoken
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal/mongo/local"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestContext(t *testing.T, user string) core.ContextParams {
+	errFactory, err := errors.NewFactory("../../../../../resources/errors/")
+	require.NoError(t, err)
+	return core.ContextParams{
+		Context:         context.Background(),
+		ReqID:           "test_req_id",
+		SupplierAccount: "0",
+		User:            user,
+		Error:           errFactory.CreateDefaultCCErrorIf("en"),
+	}
+}
+
+func TestAPIToken(t *testing.T) {
+	ctx := newTestContext(t, "tom")
+	db := local.NewMemory()
+	a := New(db)
+
+	_, ccErr := a.CreateAPIToken(ctx, metadata.APITokenOption{Name: "ci", Scope: "admin"})
+	require.Error(t, ccErr)
+	_, ccErr = a.CreateAPIToken(ctx, metadata.APITokenOption{Name: "ci", Scope: metadata.APITokenScopeRead, ExpireDays: 400})
+	require.Error(t, ccErr)
+
+	created, ccErr := a.CreateAPIToken(ctx, metadata.APITokenOption{Name: "ci", Scope: metadata.APITokenScopeRead, BizIDs: []int64{2}})
+	require.NoError(t, ccErr)
+	require.True(t, strings.HasPrefix(created.Token, tokenPrefix))
+	require.Equal(t, created.Token[:tokenPrefixLength], created.TokenPrefix)
+	require.WithinDuration(t, time.Now().Add(30*24*time.Hour), created.ExpiredAt, time.Minute)
+	_, ccErr = a.CreateAPIToken(ctx, metadata.APITokenOption{Name: "ci", Scope: metadata.APITokenScopeWrite})
+	require.Error(t, ccErr)
+
+	// only the hash of the token is kept
+	stored := make([]map[string]interface{}, 0)
+	require.NoError(t, db.Table(common.BKTableNameAPIToken).Find(nil).All(ctx.Context, &stored))
+	require.Len(t, stored, 1)
+	require.Equal(t, hashToken(created.Token), stored[0][fieldTokenHash])
+	for _, value := range stored[0] {
+		require.NotEqual(t, created.Token, value)
+	}
+
+	// the token is validated without knowing the user
+	token, ccErr := a.ValidateAPIToken(newTestContext(t, common.CCSystemOperatorUserName), metadata.ValidateAPITokenOption{Token: created.Token, ClientIP: "10.0.0.1"})
+	require.NoError(t, ccErr)
+	require.Equal(t, "tom", token.User)
+	require.Equal(t, []int64{2}, token.BizIDs)
+	_, ccErr = a.ValidateAPIToken(ctx, metadata.ValidateAPITokenOption{Token: created.Token, ClientIP: "10.0.0.2"})
+	require.NoError(t, ccErr)
+
+	list, ccErr := a.ListAPIToken(ctx, metadata.ListAPITokenOption{})
+	require.NoError(t, ccErr)
+	require.EqualValues(t, 1, list.Count)
+	require.EqualValues(t, 2, list.Info[0].UseCount)
+	require.Equal(t, "10.0.0.2", list.Info[0].LastUsedIP)
+	require.NotNil(t, list.Info[0].LastUsedTime)
+
+	_, ccErr = a.ValidateAPIToken(ctx, metadata.ValidateAPITokenOption{Token: created.Token + "x"})
+	require.Equal(t, common.CCErrAPITokenInvalid, ccErr.GetCode())
+
+	// the tokens of the other users are invisible
+	jerry := newTestContext(t, "jerry")
+	list, ccErr = a.ListAPIToken(jerry, metadata.ListAPITokenOption{})
+	require.NoError(t, ccErr)
+	require.EqualValues(t, 0, list.Count)
+	require.Error(t, a.DeleteAPIToken(jerry, created.ID))
+
+	// the expired token is invalid
+	require.NoError(t, db.Table(common.BKTableNameAPIToken).Update(ctx.Context,
+		map[string]interface{}{common.BKFieldID: created.ID},
+		map[string]interface{}{"expired_at": time.Now().Add(-time.Hour)}))
+	_, ccErr = a.ValidateAPIToken(ctx, metadata.ValidateAPITokenOption{Token: created.Token})
+	require.Equal(t, common.CCErrAPITokenInvalid, ccErr.GetCode())
+
+	require.NoError(t, a.DeleteAPIToken(ctx, created.ID))
+	list, ccErr = a.ListAPIToken(ctx, metadata.ListAPITokenOption{})
+	require.NoError(t, ccErr)
+	require.EqualValues(t, 0, list.Count)
+}
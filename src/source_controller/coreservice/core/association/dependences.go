@@ -23,4 +23,7 @@ type OperationDependences interface {
 
 	// IsInstanceExist used to check if the  instances exist
 	IsInstanceExist(ctx core.ContextParams, objID string, instID uint64) (exists bool, err error)
+
+	// ValidAssociationUnique used to check the unique rules with the association keys when the association changes
+	ValidAssociationUnique(ctx core.ContextParams, objID string, instID uint64, objAsstID string, asstInstID int64) error
 }
@@ -134,6 +134,10 @@ func (m *associationInstance) CreateOneInstanceAssociation(ctx core.ContextParam
 		blog.Errorf("asst inst is not exist objid(%#v), instid(%#v), rid: %s", inputParam.Data.ObjectID, inputParam.Data.InstID, ctx.ReqID)
 		return nil, ctx.Error.Error(common.CCErrorInstToAsstIsNotExist)
 	}
+	if err := m.validUnique(ctx, inputParam.Data, false); err != nil {
+		blog.Errorf("association instance (%#v) violates the unique rules, err: %v, rid: %s", inputParam.Data, err, ctx.ReqID)
+		return nil, err
+	}
 	id, err := m.save(ctx, inputParam.Data)
 	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, err
 }
@@ -221,6 +225,16 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 			})
 			continue
 		}
+		//check the unique rules with the association keys
+		if err := m.validUnique(ctx, item, false); err != nil {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        int64(err.(errors.CCErrorCoder).GetCode()),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
 		//save asst inst
 		id, err := m.save(ctx, item)
 		if nil != err {
@@ -272,6 +286,18 @@ func (m *associationInstance) DeleteInstanceAssociation(ctx core.ContextParams,
 		return &metadata.DeletedCount{}, err
 	}
 
+	instAssts := make([]metadata.InstAsst, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(inputParam.Condition).All(ctx, &instAssts); err != nil {
+		blog.Errorf("delete inst association, get inst associations [%#v] err [%#v], rid: %s", inputParam.Condition, err, ctx.ReqID)
+		return &metadata.DeletedCount{}, err
+	}
+	for _, instAsst := range instAssts {
+		if err := m.validUnique(ctx, instAsst, true); err != nil {
+			blog.Errorf("delete inst association (%#v) violates the unique rules, err: %v, rid: %s", instAsst, err, ctx.ReqID)
+			return &metadata.DeletedCount{}, err
+		}
+	}
+
 	err = m.dbProxy.Table(common.BKTableNameInstAsst).Delete(ctx, inputParam.Condition)
 	if nil != err {
 		blog.Errorf("delete inst association [%#v] err [%#v], rid: %s", inputParam.Condition, err, ctx.ReqID)
@@ -279,3 +305,16 @@ func (m *associationInstance) DeleteInstanceAssociation(ctx core.ContextParams,
 	}
 	return &metadata.DeletedCount{Count: cnt}, nil
 }
+
+// validUnique checks the unique rules with the association keys of both the instances, which are changed by
+// creating the association, or deleting it when deleting is true.
+func (m *associationInstance) validUnique(ctx core.ContextParams, instAsst metadata.InstAsst, deleting bool) error {
+	instID, asstInstID := instAsst.InstID, instAsst.AsstInstID
+	if deleting {
+		instID, asstInstID = 0, 0
+	}
+	if err := m.dependent.ValidAssociationUnique(ctx, instAsst.ObjectID, uint64(instAsst.InstID), instAsst.ObjectAsstID, asstInstID); err != nil {
+		return err
+	}
+	return m.dependent.ValidAssociationUnique(ctx, instAsst.AsstObjectID, uint64(instAsst.AsstInstID), instAsst.ObjectAsstID, instID)
+}
@@ -178,6 +178,10 @@ func (m *associationModel) DeleteModelAssociation(ctx core.ContextParams, inputP
 		associationIDS = append(associationIDS, assocaitionItem.AssociationName)
 	}
 
+	if err := m.checkUsedByUnique(ctx, needDeleteAssocaitionItems); nil != err {
+		return &metadata.DeletedCount{}, err
+	}
+
 	exists, err := m.usedInSomeInstanceAssociation(ctx, associationIDS)
 	if nil != err {
 		blog.Errorf("request(%s): it is failed to check if the instances (%#v) is in used, error info is %s", ctx.ReqID, associationIDS, err.Error())
@@ -218,6 +222,10 @@ func (m *associationModel) CascadeDeleteModelAssociation(ctx core.ContextParams,
 		associationIDS = append(associationIDS, assocaitionItem.AssociationName)
 	}
 
+	if err := m.checkUsedByUnique(ctx, needDeleteAssocaitionItems); nil != err {
+		return &metadata.DeletedCount{}, err
+	}
+
 	// cascade deletion operation
 	if err := m.cascadeInstanceAssociation(ctx, associationIDS); nil != err {
 		blog.Errorf("request(%s): it is failed to cascade delete the assocaitions of the instances (%#v), error info is %s ", ctx.ReqID, associationIDS, err.Error())
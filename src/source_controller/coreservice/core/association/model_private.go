@@ -16,7 +16,9 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql"
 	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
 )
 
@@ -80,6 +82,37 @@ func (m *associationModel) usedInSomeInstanceAssociation(ctx core.ContextParams,
 	return false, nil
 }
 
+// checkUsedByUnique forbids deleting the model associations which are used as the keys of some model unique rules
+func (m *associationModel) checkUsedByUnique(ctx core.ContextParams, associations []metadata.Association) error {
+	if len(associations) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(associations))
+	for _, association := range associations {
+		ids = append(ids, association.ID)
+	}
+
+	cond := map[string]interface{}{
+		"keys": map[string]interface{}{
+			universalsql.ELEMMATCH: map[string]interface{}{
+				"key_kind": metadata.UniqueKeyKindAssociation,
+				"key_id":   map[string]interface{}{common.BKDBIN: ids},
+			},
+		},
+	}
+	cond = util.SetModOwner(cond, ctx.SupplierAccount)
+	uniques := make([]metadata.ObjectUnique, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjUnique).Find(cond).All(ctx, &uniques); err != nil {
+		blog.Errorf("request(%s): it is failed to search the unique rules by the condition (%#v), error info is %s", ctx.ReqID, cond, err.Error())
+		return ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	if len(uniques) > 0 {
+		blog.Warnf("request(%s): it is forbbiden to delete the model associations (%#v) used by the unique rules of model (%s)", ctx.ReqID, ids, uniques[0].ObjID)
+		return ctx.Error.Errorf(common.CCErrTopoAssociationUsedByUnique, uniques[0].ObjID)
+	}
+	return nil
+}
+
 func (m *associationModel) cascadeInstanceAssociation(ctx core.ContextParams, associationIDS []string) error {
 	// TODO: need to implement
 	return nil
@@ -15,40 +15,75 @@ package auditlog
 import (
 	"configcenter/src/common/util"
 	"context"
+	"strconv"
 	"strings"
 	"time"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/lock"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/storage/dal"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"gopkg.in/redis.v5"
 )
 
 var _ core.AuditOperation = (*auditManager)(nil)
 
 type auditManager struct {
 	dbProxy dal.RDB
+	cache   *redis.Client
 }
 
 // New create a new instance manager instance
-func New(dbProxy dal.RDB) core.AuditOperation {
+func New(dbProxy dal.RDB, cache *redis.Client) core.AuditOperation {
 	return &auditManager{
 		dbProxy: dbProxy,
+		cache:   cache,
 	}
 }
 
 func (m *auditManager) CreateAuditLog(ctx core.ContextParams, logs ...metadata.SaveAuditLogParams) error {
 
+	// the logs recorded by a revert operation are linked to the reverted one
+	var revertOf int64
+	if revertID := ctx.Header.Get(common.BKHTTPCCRevertAuditID); len(revertID) != 0 {
+		id, err := strconv.ParseInt(revertID, 10, 64)
+		if err != nil {
+			blog.Errorf("create audit log, but got invalid reverted audit log id %s, err: %v, rid: %s", revertID, err, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsInvalid, common.BKHTTPCCRevertAuditID)
+		}
+		// the header is set by topo server when reverting an audit log of the same supplier account,
+		// it is removed from the outside requests by api server.
+		cond := util.SetQueryOwner(mapstr.MapStr{common.BKFieldID: id}, ctx.SupplierAccount)
+		cnt, err := m.dbProxy.Table(common.BKTableNameOperationLog).Find(cond).Count(ctx)
+		if err != nil {
+			blog.Errorf("create audit log, but find the reverted audit log %d failed, err: %v, rid: %s", id, err, ctx.ReqID)
+			return err
+		}
+		if cnt == 0 {
+			blog.Errorf("create audit log, but the reverted audit log %d not exist, rid: %s", id, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsInvalid, common.BKHTTPCCRevertAuditID)
+		}
+		revertOf = id
+	}
+
 	var logRows []interface{}
 	for _, content := range logs {
 		if instNotChange(ctx, content.Content, content.Model) {
 			continue
 		}
+		id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameOperationLog)
+		if err != nil {
+			blog.Errorf("create audit log, but generate id failed, err: %v, rid: %s", err, ctx.ReqID)
+			return err
+		}
 		row := &metadata.OperationLog{
+			ID:            int64(id),
 			OwnerID:       ctx.SupplierAccount,
 			ApplicationID: content.BizID,
 			OpType:        int(content.OpType),
@@ -59,6 +94,7 @@ func (m *auditManager) CreateAuditLog(ctx core.ContextParams, logs ...metadata.S
 			Content:       content.Content,
 			CreateTime:    time.Now(),
 			InstID:        content.ID,
+			RevertOf:      revertOf,
 		}
 		logRows = append(logRows, row)
 
@@ -93,6 +129,39 @@ func (m *auditManager) SearchAuditLog(ctx core.ContextParams, param metadata.Que
 	return rows, cnt, nil
 }
 
+// SealAuditChain chains the audit logs to the hash chain of their supplier account
+func (m *auditManager) SealAuditChain(ctx core.ContextParams) (int64, error) {
+	// the sealings of all the core services are serialized, or they fork the chain
+	locker := lock.NewLocker(m.cache)
+	locked, err := locker.Lock(lock.SealAuditChainFormat, 2*AuditChainSealTimeout)
+	defer locker.Unlock()
+	if err != nil {
+		blog.Errorf("seal audit log chain failed, get the sealing lock failed, err: %v, rid: %s", err, ctx.ReqID)
+		return 0, ctx.Error.CCErrorf(common.CCErrCommRedisOPErr)
+	}
+	if !locked {
+		blog.Errorf("seal audit log chain failed, another sealing is in progress, rid: %s", ctx.ReqID)
+		return 0, ctx.Error.CCErrorf(common.CCErrCommOPInProgressErr, "seal audit log chain")
+	}
+
+	sealed, err := SealAuditChain(ctx, m.dbProxy, time.Now().Add(-AuditChainSealDelay))
+	if err != nil {
+		blog.Errorf("seal audit log chain failed, sealed %d logs, err: %v, rid: %s", sealed, err, ctx.ReqID)
+		return sealed, ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return sealed, nil
+}
+
+// VerifyAuditChain verify the audit log hash chain of the supplier account
+func (m *auditManager) VerifyAuditChain(ctx core.ContextParams) (*metadata.AuditChainVerifyResult, error) {
+	result, err := VerifyAuditChain(ctx, m.dbProxy, ctx.SupplierAccount)
+	if err != nil {
+		blog.Errorf("verify audit log chain of %s failed, err: %v, rid: %s", ctx.SupplierAccount, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return result, nil
+}
+
 // instNotChange Determine whether the data is consistent before and after the change
 // notice: getIgnoreOptions用来设置不参与对比变化的字段，这些字段发生变化，在instNotChange不在返回数据发生变化
 func instNotChange(ctx context.Context, content interface{}, objID string) bool {
@@ -0,0 +1,417 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	auditHashField     = "hash"
+	auditPrevHashField = "prev_hash"
+
+	// AuditChainSealDelay the audit logs are sealed after this delay, so that the logs whose id is
+	// generated before but inserted after a sealed one are still sealed in the order of id.
+	AuditChainSealDelay = time.Minute
+
+	// AuditChainSealTimeout a sealing stops after this time, the rest logs are sealed by the next one,
+	// so that it ends in time before the sealing lock expires.
+	AuditChainSealTimeout = 5 * time.Minute
+
+	// maxChainProblems the max problems returned by a verification
+	maxChainProblems = common.BKMaxPageSize
+)
+
+// auditChainRecord is the canonical form of an audit log used to compute its hash
+type auditChainRecord struct {
+	ID            int64       `json:"id"`
+	OwnerID       string      `json:"bk_supplier_account"`
+	ApplicationID int64       `json:"bk_biz_id"`
+	ExtKey        string      `json:"ext_key"`
+	OpDesc        string      `json:"op_desc"`
+	OpType        int         `json:"op_type"`
+	OpTarget      string      `json:"op_target"`
+	Content       interface{} `json:"content"`
+	User          string      `json:"operator"`
+	OpFrom        string      `json:"op_from"`
+	ExtInfo       string      `json:"ext_info"`
+	OpTime        string      `json:"op_time"`
+	InstID        int64       `json:"inst_id"`
+	RevertOf      int64       `json:"revert_of"`
+}
+
+// AuditLogHash returns the hash of the audit log chained to the previous hash
+func AuditLogHash(prevHash string, log *metadata.OperationLog) (string, error) {
+	record := auditChainRecord{
+		ID:            log.ID,
+		OwnerID:       log.OwnerID,
+		ApplicationID: log.ApplicationID,
+		ExtKey:        log.ExtKey,
+		OpDesc:        log.OpDesc,
+		OpType:        log.OpType,
+		OpTarget:      log.OpTarget,
+		Content:       canonicalAuditValue(log.Content),
+		User:          log.User,
+		OpFrom:        log.OpFrom,
+		ExtInfo:       log.ExtInfo,
+		OpTime:        canonicalAuditTime(log.CreateTime),
+		InstID:        log.InstID,
+		RevertOf:      log.RevertOf,
+	}
+	js, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(prevHash))
+	hash.Write([]byte("\n"))
+	hash.Write(js)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// canonicalAuditTime mongodb keeps the time in milliseconds, the time zone depends on the reader
+func canonicalAuditTime(t time.Time) string {
+	return t.UTC().Truncate(time.Millisecond).Format("2006-01-02T15:04:05.000Z")
+}
+
+// canonicalAuditValue convert the values read from db to the types that are the same wherever it's read
+func canonicalAuditValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.M:
+		return canonicalAuditValue(map[string]interface{}(v))
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = canonicalAuditValue(item)
+		}
+		return result
+	case bson.D:
+		return canonicalAuditValue(v.Map())
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for idx, item := range v {
+			result[idx] = canonicalAuditValue(item)
+		}
+		return result
+	case time.Time:
+		return canonicalAuditTime(v)
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+// SealAuditChain chains the audit logs recorded before the time to the end of the hash chain of
+// their supplier account in the order of id, returns the count of the sealed logs.
+// the caller should make sure that only one sealing is running at the same time.
+func SealAuditChain(ctx context.Context, db dal.RDB, before time.Time) (int64, error) {
+	heads := make(map[string]*metadata.AuditChainHead)
+	var cursor, sealed int64
+	start := time.Now()
+	for {
+		if time.Since(start) > AuditChainSealTimeout {
+			blog.Warnf("seal audit log chain timeout, sealed %d logs to %d, the rest are sealed next time", sealed, cursor)
+			return sealed, nil
+		}
+
+		cond := map[string]interface{}{
+			common.BKFieldID:     map[string]interface{}{common.BKDBGT: cursor},
+			auditHashField:       map[string]interface{}{common.BKDBExists: false},
+			common.BKOpTimeField: map[string]interface{}{common.BKDBLT: before},
+		}
+		logs := make([]metadata.OperationLog, 0)
+		err := db.Table(common.BKTableNameOperationLog).Find(cond).Sort(common.BKFieldID).
+			Limit(common.BKMaxPageSize).All(ctx, &logs)
+		if err != nil {
+			return sealed, fmt.Errorf("find unsealed audit logs failed, err: %v", err)
+		}
+		if len(logs) == 0 {
+			return sealed, nil
+		}
+
+		changed := make(map[string]bool)
+		for idx := range logs {
+			log := &logs[idx]
+			cursor = log.ID
+
+			head, err := getAuditChainHead(ctx, db, heads, log.OwnerID)
+			if err != nil {
+				return sealed, err
+			}
+			if log.ID <= head.LastID {
+				blog.Warnf("audit log %d of %s is recorded after the chain is sealed to %d, skip it", log.ID, log.OwnerID, head.LastID)
+				continue
+			}
+
+			hash, err := AuditLogHash(head.LastHash, log)
+			if err != nil {
+				return sealed, fmt.Errorf("compute the hash of audit log %d failed, err: %v", log.ID, err)
+			}
+			filter := map[string]interface{}{
+				common.BKFieldID: log.ID,
+				auditHashField:   map[string]interface{}{common.BKDBExists: false},
+			}
+			doc := map[string]interface{}{
+				auditPrevHashField: head.LastHash,
+				auditHashField:     hash,
+			}
+			if err := db.Table(common.BKTableNameOperationLog).Update(ctx, filter, doc); err != nil {
+				return sealed, fmt.Errorf("seal audit log %d failed, err: %v", log.ID, err)
+			}
+
+			// the update matches nothing if the log is sealed by others in the meantime,
+			// the chain always continues from the hash that is stored.
+			stored := make([]metadata.OperationLog, 0)
+			err = db.Table(common.BKTableNameOperationLog).Find(map[string]interface{}{common.BKFieldID: log.ID}).
+				Fields(common.BKFieldID, auditPrevHashField, auditHashField).All(ctx, &stored)
+			if err != nil {
+				return sealed, fmt.Errorf("find the sealed audit log %d failed, err: %v", log.ID, err)
+			}
+			if len(stored) == 0 || len(stored[0].Hash) == 0 {
+				return sealed, fmt.Errorf("audit log %d is not sealed, it may be removed", log.ID)
+			}
+			if stored[0].Hash != hash {
+				blog.Errorf("audit log %d of %s is sealed by others, prev hash: %s, expected prev hash: %s", log.ID,
+					log.OwnerID, stored[0].PrevHash, head.LastHash)
+				hash = stored[0].Hash
+			} else {
+				sealed++
+			}
+
+			head.LastID = log.ID
+			head.LastHash = hash
+			head.LastTime = log.CreateTime
+			changed[log.OwnerID] = true
+		}
+
+		for ownerID := range changed {
+			filter := map[string]interface{}{common.BKOwnerIDField: ownerID}
+			if err := db.Table(common.BKTableNameAuditChain).Upsert(ctx, filter, heads[ownerID]); err != nil {
+				return sealed, fmt.Errorf("save the audit chain head of %s failed, err: %v", ownerID, err)
+			}
+		}
+	}
+}
+
+// getAuditChainHead returns the end of the chain of the supplier account. the head is saved after the logs
+// are sealed, if the last sealing is interrupted before that, the head is recovered from the sealed logs.
+func getAuditChainHead(ctx context.Context, db dal.RDB, heads map[string]*metadata.AuditChainHead,
+	ownerID string) (*metadata.AuditChainHead, error) {
+
+	if head, exist := heads[ownerID]; exist {
+		return head, nil
+	}
+
+	head, err := findAuditChainHead(ctx, db, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	cond := map[string]interface{}{
+		common.BKOwnerIDField: ownerID,
+		common.BKFieldID:      map[string]interface{}{common.BKDBGT: head.LastID},
+		auditHashField:        map[string]interface{}{common.BKDBExists: true},
+	}
+	last := make([]metadata.OperationLog, 0)
+	err = db.Table(common.BKTableNameOperationLog).Find(cond).Sort("-"+common.BKFieldID).Limit(1).All(ctx, &last)
+	if err != nil {
+		return nil, fmt.Errorf("find the last sealed audit log of %s failed, err: %v", ownerID, err)
+	}
+	if len(last) != 0 {
+		head.LastID = last[0].ID
+		head.LastHash = last[0].Hash
+		head.LastTime = last[0].CreateTime
+	}
+
+	heads[ownerID] = head
+	return head, nil
+}
+
+func findAuditChainHead(ctx context.Context, db dal.RDB, ownerID string) (*metadata.AuditChainHead, error) {
+	heads := make([]metadata.AuditChainHead, 0)
+	filter := map[string]interface{}{common.BKOwnerIDField: ownerID}
+	if err := db.Table(common.BKTableNameAuditChain).Find(filter).All(ctx, &heads); err != nil {
+		return nil, fmt.Errorf("find the audit chain head of %s failed, err: %v", ownerID, err)
+	}
+	if len(heads) == 0 {
+		return &metadata.AuditChainHead{OwnerID: ownerID}, nil
+	}
+	return &heads[0], nil
+}
+
+// VerifyAuditChain walk through the audit logs of the supplier account, recompute their hashes to find
+// the logs which are modified, deleted or inserted after they were sealed.
+func VerifyAuditChain(ctx context.Context, db dal.RDB, ownerID string) (*metadata.AuditChainVerifyResult, error) {
+	head, err := findAuditChainHead(ctx, db, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &metadata.AuditChainVerifyResult{
+		OwnerID:  ownerID,
+		Problems: make([]metadata.AuditChainProblem, 0),
+	}
+	addProblem := func(id int64, reason string) {
+		if len(result.Problems) < maxChainProblems {
+			result.Problems = append(result.Problems, metadata.AuditChainProblem{ID: id, Reason: reason})
+		}
+	}
+
+	prevHash := head.ArchivedHash
+	headFound := head.LastID <= head.ArchivedID
+	cursor := head.ArchivedID
+	for {
+		cond := map[string]interface{}{
+			common.BKOwnerIDField: ownerID,
+			common.BKFieldID:      map[string]interface{}{common.BKDBGT: cursor},
+		}
+		logs := make([]metadata.OperationLog, 0)
+		err := db.Table(common.BKTableNameOperationLog).Find(cond).Sort(common.BKFieldID).
+			Limit(common.BKMaxPageSize).All(ctx, &logs)
+		if err != nil {
+			return nil, fmt.Errorf("find audit logs of %s failed, err: %v", ownerID, err)
+		}
+		if len(logs) == 0 {
+			break
+		}
+
+		for idx := range logs {
+			log := &logs[idx]
+			cursor = log.ID
+
+			if len(log.Hash) == 0 {
+				if log.ID <= head.LastID {
+					addProblem(log.ID, metadata.AuditChainInserted)
+				} else {
+					result.Unsealed++
+				}
+				continue
+			}
+
+			result.Checked++
+			if log.PrevHash != prevHash {
+				addProblem(log.ID, metadata.AuditChainPreviousMissing)
+			}
+			hash, err := AuditLogHash(log.PrevHash, log)
+			if err != nil || hash != log.Hash {
+				addProblem(log.ID, metadata.AuditChainModified)
+			}
+			if log.ID == head.LastID && log.Hash == head.LastHash {
+				headFound = true
+			}
+			prevHash = log.Hash
+			result.LastID = log.ID
+		}
+	}
+
+	if !headFound {
+		addProblem(head.LastID, metadata.AuditChainTailMissing)
+	}
+	result.Valid = len(result.Problems) == 0
+	return result, nil
+}
+
+// ArchiveAuditLogs export the sealed audit logs of the supplier account recorded before the time to
+// the writer as gzip compressed NDJSON. the logs are exported in the order of id, and stop at the first
+// unsealed one, so the archive is always a continuous part at the start of the chain.
+func ArchiveAuditLogs(ctx context.Context, db dal.RDB, ownerID string, before time.Time, w io.Writer) (*metadata.AuditArchiveResult, error) {
+	head, err := findAuditChainHead(ctx, db, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &metadata.AuditArchiveResult{OwnerID: ownerID, LastID: head.ArchivedID, LastHash: head.ArchivedHash}
+	zw := gzip.NewWriter(w)
+	encoder := json.NewEncoder(zw)
+	cursor := head.ArchivedID
+	for {
+		cond := map[string]interface{}{
+			common.BKOwnerIDField: ownerID,
+			common.BKFieldID:      map[string]interface{}{common.BKDBGT: cursor, common.BKDBLTE: head.LastID},
+			common.BKOpTimeField:  map[string]interface{}{common.BKDBLT: before},
+		}
+		logs := make([]metadata.OperationLog, 0)
+		err := db.Table(common.BKTableNameOperationLog).Find(cond).Sort(common.BKFieldID).
+			Limit(common.BKMaxPageSize).All(ctx, &logs)
+		if err != nil {
+			return nil, fmt.Errorf("find audit logs of %s failed, err: %v", ownerID, err)
+		}
+		if len(logs) == 0 {
+			break
+		}
+
+		for idx := range logs {
+			log := &logs[idx]
+			if len(log.Hash) == 0 {
+				return result, zw.Close()
+			}
+			if err := encoder.Encode(log); err != nil {
+				return nil, fmt.Errorf("write audit log %d to archive failed, err: %v", log.ID, err)
+			}
+			if result.Count == 0 {
+				result.FirstID = log.ID
+			}
+			result.Count++
+			result.LastID = log.ID
+			result.LastHash = log.Hash
+			cursor = log.ID
+		}
+	}
+	return result, zw.Close()
+}
+
+// PurgeArchivedAuditLogs delete the audit logs exported to the archive, the chain starts after them.
+func PurgeArchivedAuditLogs(ctx context.Context, db dal.RDB, archive *metadata.AuditArchiveResult) error {
+	if archive.Count == 0 {
+		return nil
+	}
+
+	// move the start of the chain first, so the left logs are ignored by the verification if the purge fails
+	filter := map[string]interface{}{common.BKOwnerIDField: archive.OwnerID}
+	doc := map[string]interface{}{
+		"archived_id":   archive.LastID,
+		"archived_hash": archive.LastHash,
+	}
+	if err := db.Table(common.BKTableNameAuditChain).Update(ctx, filter, doc); err != nil {
+		return fmt.Errorf("update the audit chain head of %s failed, err: %v", archive.OwnerID, err)
+	}
+
+	cond := map[string]interface{}{
+		common.BKOwnerIDField: archive.OwnerID,
+		common.BKFieldID:      map[string]interface{}{common.BKDBLTE: archive.LastID},
+	}
+	if err := db.Table(common.BKTableNameOperationLog).Delete(ctx, cond); err != nil {
+		return fmt.Errorf("delete the archived audit logs of %s failed, err: %v", archive.OwnerID, err)
+	}
+	return nil
+}
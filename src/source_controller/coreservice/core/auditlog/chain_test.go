@@ -0,0 +1,174 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/mongo/local"
+
+	"github.com/stretchr/testify/require"
+)
+
+func prepareAuditLogs(t *testing.T, db *local.Memory, start time.Time) {
+	logs := make([]interface{}, 0)
+	for id := int64(1); id <= 6; id++ {
+		owner := "0"
+		if id%3 == 0 {
+			owner = "1"
+		}
+		logs = append(logs, metadata.OperationLog{
+			ID:         id,
+			OwnerID:    owner,
+			OpType:     2,
+			OpTarget:   "host",
+			InstID:     id,
+			User:       "admin",
+			CreateTime: start.Add(time.Duration(id) * time.Minute),
+			Content: map[string]interface{}{
+				"pre_data": map[string]interface{}{"bk_host_name": "a", "bk_cpu": 1},
+				"cur_data": map[string]interface{}{"bk_host_name": "b", "bk_cpu": 2},
+			},
+		})
+	}
+	require.NoError(t, db.Table(common.BKTableNameOperationLog).Insert(context.Background(), logs))
+}
+
+func TestSealAndVerifyAuditChain(t *testing.T) {
+	ctx := context.Background()
+	db := local.NewMemory()
+	start := time.Date(2020, 5, 1, 0, 0, 0, 0, time.Local)
+	prepareAuditLogs(t, db, start)
+
+	// the logs after the time are not sealed
+	sealed, err := SealAuditChain(ctx, db, start.Add(5*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), sealed)
+
+	result, err := VerifyAuditChain(ctx, db, "0")
+	require.NoError(t, err)
+	require.True(t, result.Valid)
+	require.Equal(t, int64(3), result.Checked)
+	require.Equal(t, int64(1), result.Unsealed)
+
+	sealed, err = SealAuditChain(ctx, db, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), sealed)
+
+	for _, owner := range []string{"0", "1"} {
+		result, err = VerifyAuditChain(ctx, db, owner)
+		require.NoError(t, err)
+		require.True(t, result.Valid, owner)
+		require.Zero(t, result.Unsealed)
+	}
+	require.Equal(t, int64(6), result.LastID)
+
+	// the logs inserted into the sealed part are not sealed
+	require.NoError(t, db.Table(common.BKTableNameOperationLog).Insert(ctx, metadata.OperationLog{ID: 3, OwnerID: "0", CreateTime: start}))
+	sealed, err = SealAuditChain(ctx, db, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, sealed)
+
+	result, err = VerifyAuditChain(ctx, db, "0")
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+	require.Equal(t, []metadata.AuditChainProblem{{ID: 3, Reason: metadata.AuditChainInserted}}, result.Problems)
+}
+
+func TestVerifyTamperedAuditChain(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2020, 5, 1, 0, 0, 0, 0, time.Local)
+	tamper := []struct {
+		name     string
+		tamper   func(db *local.Memory)
+		problems []metadata.AuditChainProblem
+	}{
+		{
+			name: "modified",
+			tamper: func(db *local.Memory) {
+				cond := map[string]interface{}{common.BKFieldID: 2}
+				require.NoError(t, db.Table(common.BKTableNameOperationLog).Update(ctx, cond, map[string]interface{}{"operator": "someone"}))
+			},
+			problems: []metadata.AuditChainProblem{{ID: 2, Reason: metadata.AuditChainModified}},
+		},
+		{
+			name: "deleted",
+			tamper: func(db *local.Memory) {
+				require.NoError(t, db.Table(common.BKTableNameOperationLog).Delete(ctx, map[string]interface{}{common.BKFieldID: 2}))
+			},
+			problems: []metadata.AuditChainProblem{{ID: 4, Reason: metadata.AuditChainPreviousMissing}},
+		},
+		{
+			name: "tail deleted",
+			tamper: func(db *local.Memory) {
+				require.NoError(t, db.Table(common.BKTableNameOperationLog).Delete(ctx, map[string]interface{}{common.BKFieldID: 5}))
+			},
+			problems: []metadata.AuditChainProblem{{ID: 5, Reason: metadata.AuditChainTailMissing}},
+		},
+	}
+
+	for _, tc := range tamper {
+		db := local.NewMemory()
+		prepareAuditLogs(t, db, start)
+		_, err := SealAuditChain(ctx, db, start.Add(time.Hour))
+		require.NoError(t, err)
+
+		tc.tamper(db)
+		result, err := VerifyAuditChain(ctx, db, "0")
+		require.NoError(t, err, tc.name)
+		require.False(t, result.Valid, tc.name)
+		require.Equal(t, tc.problems, result.Problems, tc.name)
+	}
+}
+
+func TestArchiveAuditLogs(t *testing.T) {
+	ctx := context.Background()
+	db := local.NewMemory()
+	start := time.Date(2020, 5, 1, 0, 0, 0, 0, time.Local)
+	prepareAuditLogs(t, db, start)
+	_, err := SealAuditChain(ctx, db, start.Add(time.Hour))
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	result, err := ArchiveAuditLogs(ctx, db, "0", start.Add(3*time.Minute), buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Count)
+	require.Equal(t, int64(1), result.FirstID)
+	require.Equal(t, int64(2), result.LastID)
+
+	zr, err := gzip.NewReader(buf)
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(zr)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[1], `"id":2`)
+
+	require.NoError(t, PurgeArchivedAuditLogs(ctx, db, result))
+	count, err := db.Table(common.BKTableNameOperationLog).Find(map[string]interface{}{common.BKOwnerIDField: "0"}).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+
+	verify, err := VerifyAuditChain(ctx, db, "0")
+	require.NoError(t, err)
+	require.True(t, verify.Valid)
+	require.Equal(t, int64(2), verify.Checked)
+}
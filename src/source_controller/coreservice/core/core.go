@@ -89,6 +89,9 @@ type InstanceOperation interface {
 	SearchModelInstance(ctx ContextParams, objID string, inputParam metadata.QueryCondition) (*metadata.QueryResult, error)
 	DeleteModelInstance(ctx ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
 	CascadeDeleteModelInstance(ctx ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
+	// ValidAssociationUnique checks the unique rules of the instance whose keys have the association, asstInstID is
+	// the instance associated with it through the association after the association changes, 0 if it is removed.
+	ValidAssociationUnique(ctx ContextParams, objID string, instID uint64, objAsstID string, asstInstID int64) error
 }
 
 // AssociationKind association kind methods
@@ -161,6 +164,10 @@ type HostOperation interface {
 
 	// host search
 	ListHosts(ctx ContextParams, input metadata.ListHosts) (*metadata.ListHostResult, error)
+
+	// host drift history recorded from the snapshots
+	SearchHostDrift(ctx ContextParams, input metadata.SearchHostDriftOption) (*metadata.MultipleHostDrift, errors.CCErrorCoder)
+	ListDriftedHosts(ctx ContextParams, input metadata.ListDriftedHostsOption) (*metadata.MultipleDriftedHost, errors.CCErrorCoder)
 }
 
 // AssociationOperation association methods
@@ -173,6 +180,8 @@ type AssociationOperation interface {
 type AuditOperation interface {
 	CreateAuditLog(ctx ContextParams, logs ...metadata.SaveAuditLogParams) error
 	SearchAuditLog(ctx ContextParams, param metadata.QueryInput) ([]metadata.OperationLog, uint64, error)
+	SealAuditChain(ctx ContextParams) (int64, error)
+	VerifyAuditChain(ctx ContextParams) (*metadata.AuditChainVerifyResult, error)
 }
 
 type StatisticOperation interface {
@@ -202,6 +211,9 @@ type Core interface {
 	SetTemplateOperation() SetTemplateOperation
 	HostApplyRuleOperation() HostApplyRuleOperation
 	SystemOperation() SystemOperation
+	FullTextOperation() FullTextOperation
+	RBACOperation() RBACOperation
+	APITokenOperation() APITokenOperation
 }
 
 // ProcessOperation methods
@@ -287,6 +299,37 @@ type SystemOperation interface {
 	GetSystemUserConfig(ctx ContextParams) (map[string]interface{}, errors.CCErrorCoder)
 }
 
+// FullTextOperation searches the instances by the text indexes of db when elasticsearch is not deployed
+type FullTextOperation interface {
+	Search(ctx ContextParams, option metadata.FullTextSearchOption) (*metadata.FullTextSearchResult, errors.CCErrorCoder)
+	SyncTextIndexes(ctx ContextParams) error
+}
+
+// RBACOperation keeps the roles, role bindings and user groups of the local rbac authorizer
+type RBACOperation interface {
+	CreateRole(ctx ContextParams, option metadata.RBACRoleOption) (metadata.RBACRole, errors.CCErrorCoder)
+	UpdateRole(ctx ContextParams, id int64, option metadata.RBACRoleOption) (metadata.RBACRole, errors.CCErrorCoder)
+	DeleteRole(ctx ContextParams, id int64) errors.CCErrorCoder
+	ListRole(ctx ContextParams, option metadata.ListRBACOption) (metadata.MultipleRBACRole, errors.CCErrorCoder)
+	CreateRoleBinding(ctx ContextParams, option metadata.RBACRoleBindingOption) (metadata.RBACRoleBinding, errors.CCErrorCoder)
+	UpdateRoleBinding(ctx ContextParams, id int64, option metadata.RBACRoleBindingOption) (metadata.RBACRoleBinding, errors.CCErrorCoder)
+	DeleteRoleBinding(ctx ContextParams, id int64) errors.CCErrorCoder
+	ListRoleBinding(ctx ContextParams, option metadata.ListRBACOption) (metadata.MultipleRBACRoleBinding, errors.CCErrorCoder)
+	CreateUserGroup(ctx ContextParams, option metadata.RBACUserGroupOption) (metadata.RBACUserGroup, errors.CCErrorCoder)
+	UpdateUserGroup(ctx ContextParams, id int64, option metadata.RBACUserGroupOption) (metadata.RBACUserGroup, errors.CCErrorCoder)
+	DeleteUserGroup(ctx ContextParams, id int64) errors.CCErrorCoder
+	ListUserGroup(ctx ContextParams, option metadata.ListRBACOption) (metadata.MultipleRBACUserGroup, errors.CCErrorCoder)
+	GetUserPolicy(ctx ContextParams, user string) (metadata.RBACUserPolicy, errors.CCErrorCoder)
+}
+
+// APITokenOperation keeps the personal api tokens of the users
+type APITokenOperation interface {
+	CreateAPIToken(ctx ContextParams, option metadata.APITokenOption) (metadata.CreateAPITokenResult, errors.CCErrorCoder)
+	ListAPIToken(ctx ContextParams, option metadata.ListAPITokenOption) (metadata.MultipleAPIToken, errors.CCErrorCoder)
+	DeleteAPIToken(ctx ContextParams, id int64) errors.CCErrorCoder
+	ValidateAPIToken(ctx ContextParams, option metadata.ValidateAPITokenOption) (metadata.APIToken, errors.CCErrorCoder)
+}
+
 type core struct {
 	model           ModelOperation
 	instance        InstanceOperation
@@ -301,6 +344,9 @@ type core struct {
 	sys             SystemOperation
 	setTemplate     SetTemplateOperation
 	hostApplyRule   HostApplyRuleOperation
+	fullText        FullTextOperation
+	rbac            RBACOperation
+	apiToken        APITokenOperation
 }
 
 // New create core
@@ -317,6 +363,9 @@ func New(
 	operation StatisticOperation,
 	hostApplyRule HostApplyRuleOperation,
     sys SystemOperation,
+	fullText FullTextOperation,
+	rbac RBACOperation,
+	apiToken APITokenOperation,
 ) Core {
 	return &core{
 		model:           model,
@@ -332,6 +381,9 @@ func New(
 		sys:             sys,
 		setTemplate:     setTemplate,
 		hostApplyRule:   hostApplyRule,
+		fullText:        fullText,
+		rbac:            rbac,
+		apiToken:        apiToken,
 	}
 }
 
@@ -386,3 +438,15 @@ func (m *core) SystemOperation() SystemOperation {
 func (m *core) HostApplyRuleOperation() HostApplyRuleOperation {
 	return m.hostApplyRule
 }
+
+func (m *core) FullTextOperation() FullTextOperation {
+	return m.fullText
+}
+
+func (m *core) RBACOperation() RBACOperation {
+	return m.rbac
+}
+
+func (m *core) APITokenOperation() APITokenOperation {
+	return m.apiToken
+}
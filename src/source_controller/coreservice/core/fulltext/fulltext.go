@@ -0,0 +1,440 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fulltext
+
+import (
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	// textIndexPrefix is the name prefix of the text indexes created for the full text search
+	textIndexPrefix = "bk_fulltext_"
+	// textIndexKeyPrefix is the prefix of the text index keys
+	textIndexKeyPrefix = "$text:"
+	// scoreField is the field the text score is added to the documents as
+	scoreField = "_score"
+	// defaultLimit is the default page size, which is the same with elasticsearch
+	defaultLimit = 10
+)
+
+// textIndexCheckInterval is how long the text index of a table is not checked again by the search
+var textIndexCheckInterval = time.Minute
+
+// searchTables are the tables can be searched, the same with the types of the elasticsearch index
+var searchTables = []string{
+	common.BKTableNameBaseInst,
+	common.BKTableNameBaseHost,
+	common.BKTableNameObjDes,
+	common.BKTableNameBaseApp,
+}
+
+// modelSearchFields are the fields of the models searched
+var modelSearchFields = []string{common.BKObjIDField, common.BKObjNameField}
+
+type textIndex struct {
+	fields    []string
+	checkedAt time.Time
+}
+
+type fullTextOperation struct {
+	dbProxy dal.RDB
+
+	lock    sync.RWMutex
+	indexes map[string]textIndex
+}
+
+// New create a full text search operation, which searches the instances by the text indexes of db
+func New(dbProxy dal.RDB) core.FullTextOperation {
+	return &fullTextOperation{
+		dbProxy: dbProxy,
+		indexes: make(map[string]textIndex),
+	}
+}
+
+func (f *fullTextOperation) Search(ctx core.ContextParams, option metadata.FullTextSearchOption) (*metadata.FullTextSearchResult, errors.CCErrorCoder) {
+	result := &metadata.FullTextSearchResult{
+		Aggregations: make([]metadata.FullTextSearchAggregation, 0),
+		Hits:         make([]metadata.FullTextSearchHit, 0),
+	}
+	words := strings.Fields(strings.Replace(option.QueryString, "*", " ", -1))
+	if len(words) == 0 {
+		return result, nil
+	}
+	if option.Start < 0 {
+		option.Start = 0
+	}
+	if option.Limit <= 0 {
+		option.Limit = defaultLimit
+	}
+
+	objCounts := make(map[string]int64)
+	for _, table := range option.Tables {
+		if !util.InStrArr(searchTables, table) {
+			blog.Errorf("table %s can not be searched, rid: %s", table, ctx.ReqID)
+			return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, "tables")
+		}
+
+		fields, err := f.findTextIndex(ctx, table)
+		if err != nil {
+			return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		filter := searchFilter(table, words, option)
+		filter = util.SetQueryOwner(filter, ctx.SupplierAccount)
+		count, err := f.dbProxy.Table(table).Find(filter).Count(ctx.Context)
+		if err != nil {
+			blog.ErrorJSON("count full text search hits failed, table: %s, filter: %s, err: %s, rid: %s", table, filter, err, ctx.ReqID)
+			return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+		}
+		if count == 0 {
+			continue
+		}
+		result.Total += int64(count)
+
+		switch table {
+		case common.BKTableNameBaseHost:
+			result.Aggregations = append(result.Aggregations, metadata.FullTextSearchAggregation{Key: common.TypeHost, Count: int64(count)})
+		case common.BKTableNameBaseApp:
+			result.Aggregations = append(result.Aggregations, metadata.FullTextSearchAggregation{Key: common.TypeApplication, Count: int64(count)})
+		default:
+			if err := f.countByObject(ctx, table, filter, objCounts); err != nil {
+				return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+			}
+		}
+
+		hits, err := f.findHits(ctx, table, filter, fields, words, option.Start+option.Limit)
+		if err != nil {
+			return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+		}
+		result.Hits = append(result.Hits, hits...)
+	}
+
+	objIDs := make([]string, 0, len(objCounts))
+	for objID := range objCounts {
+		objIDs = append(objIDs, objID)
+	}
+	sort.Strings(objIDs)
+	for _, objID := range objIDs {
+		result.Aggregations = append(result.Aggregations, metadata.FullTextSearchAggregation{Key: objID, Count: objCounts[objID]})
+	}
+
+	result.Hits = pageHits(result.Hits, option.Start, option.Limit)
+	return result, nil
+}
+
+// searchFilter returns the filter of the documents matched, the documents of other businesses and
+// the resource pool are not searched, which is the same with the elasticsearch query.
+func searchFilter(table string, words []string, option metadata.FullTextSearchOption) map[string]interface{} {
+	filter := map[string]interface{}{
+		"$text": map[string]interface{}{"$search": strings.Join(words, " ")},
+	}
+
+	noBiz := map[string]interface{}{common.BkBizMetaKey: map[string]interface{}{common.BKDBExists: false}}
+	if option.BizID != "" {
+		filter[common.BKDBOR] = []map[string]interface{}{noBiz, {common.BkBizMetaKey: option.BizID}}
+	} else {
+		filter[common.BkBizMetaKey] = noBiz[common.BkBizMetaKey]
+	}
+
+	if table == common.BKTableNameBaseApp {
+		filter[common.BKAppNameField] = map[string]interface{}{common.BKDBNE: common.DefaultAppName}
+	}
+	if option.ObjectID != "" {
+		filter[common.BKObjIDField] = option.ObjectID
+	}
+	return filter
+}
+
+// countByObject counts the documents matched of each object
+func (f *fullTextOperation) countByObject(ctx core.ContextParams, table string, filter map[string]interface{}, counts map[string]int64) error {
+	pipeline := []map[string]interface{}{
+		{common.BKDBMatch: filter},
+		{"$group": map[string]interface{}{"_id": "$" + common.BKObjIDField, "count": map[string]interface{}{"$sum": 1}}},
+	}
+	groups := make([]struct {
+		ObjectID string `bson:"_id"`
+		Count    int64  `bson:"count"`
+	}, 0)
+	if err := f.dbProxy.Table(table).AggregateAll(ctx.Context, pipeline, &groups); err != nil {
+		blog.ErrorJSON("count full text search hits by object failed, table: %s, pipeline: %s, err: %s, rid: %s", table, pipeline, err, ctx.ReqID)
+		return err
+	}
+	for _, group := range groups {
+		counts[group.ObjectID] += group.Count
+	}
+	return nil
+}
+
+// findHits finds the best matched documents of the table, ordered by the text score
+func (f *fullTextOperation) findHits(ctx core.ContextParams, table string, filter map[string]interface{}, fields, words []string,
+	limit int) ([]metadata.FullTextSearchHit, error) {
+
+	pipeline := []map[string]interface{}{
+		{common.BKDBMatch: filter},
+		{"$sort": map[string]interface{}{scoreField: map[string]interface{}{"$meta": "textScore"}}},
+		{"$limit": limit},
+		{"$addFields": map[string]interface{}{scoreField: map[string]interface{}{"$meta": "textScore"}}},
+	}
+	docs := make([]map[string]interface{}, 0)
+	if err := f.dbProxy.Table(table).AggregateAll(ctx.Context, pipeline, &docs); err != nil {
+		blog.ErrorJSON("find full text search hits failed, table: %s, pipeline: %s, err: %s, rid: %s", table, pipeline, err, ctx.ReqID)
+		return nil, err
+	}
+
+	hits := make([]metadata.FullTextSearchHit, 0, len(docs))
+	for _, doc := range docs {
+		score, _ := util.GetFloat64ByInterface(doc[scoreField])
+		delete(doc, scoreField)
+		delete(doc, "_id")
+		hits = append(hits, metadata.FullTextSearchHit{
+			Source:    doc,
+			Highlight: highlightDoc(doc, fields, words),
+			Table:     table,
+			Score:     score,
+		})
+	}
+	return hits, nil
+}
+
+// pageHits returns a page of the hits of all the tables ordered by the score
+func pageHits(hits []metadata.FullTextSearchHit, start, limit int) []metadata.FullTextSearchHit {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if start >= len(hits) {
+		return make([]metadata.FullTextSearchHit, 0)
+	}
+	end := start + limit
+	if end > len(hits) {
+		end = len(hits)
+	}
+	return hits[start:end]
+}
+
+// highlightDoc returns the highlight fragments of the searched fields, the words matched are
+// wrapped with <em></em>, which is the same with the elasticsearch highlight.
+func highlightDoc(doc map[string]interface{}, fields, words []string) map[string][]string {
+	highlight := make(map[string][]string)
+	for _, field := range fields {
+		value, ok := doc[field].(string)
+		if !ok {
+			continue
+		}
+		if fragment, matched := highlightText(value, words); matched {
+			highlight[field] = []string{fragment}
+		}
+	}
+	return highlight
+}
+
+func highlightText(value string, words []string) (string, bool) {
+	lower := strings.ToLower(value)
+	if len(lower) != len(value) {
+		// the positions of the lower case are not the same with the value, match the words as they are
+		lower = value
+	}
+
+	// mark the bytes matched by any of the words
+	matched := make([]bool, len(value))
+	found := false
+	for _, word := range words {
+		word = strings.ToLower(word)
+		if word == "" {
+			continue
+		}
+		for offset := 0; offset < len(lower); {
+			index := strings.Index(lower[offset:], word)
+			if index < 0 {
+				break
+			}
+			for i := offset + index; i < offset+index+len(word); i++ {
+				matched[i] = true
+			}
+			found = true
+			offset += index + len(word)
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if matched[i] && (i == 0 || !matched[i-1]) {
+			builder.WriteString("<em>")
+		}
+		builder.WriteByte(value[i])
+		if matched[i] && (i == len(value)-1 || !matched[i+1]) {
+			builder.WriteString("</em>")
+		}
+	}
+	return builder.String(), true
+}
+
+// findTextIndex returns the searchable fields of the table if it has a text index, the search only reads
+// the index, which is maintained by SyncTextIndexes. the text index may be outdated for a while after the
+// searchable attributes are changed, the fields are still searched with the index then.
+func (f *fullTextOperation) findTextIndex(ctx core.ContextParams, table string) ([]string, error) {
+	f.lock.RLock()
+	index, ok := f.indexes[table]
+	f.lock.RUnlock()
+	if ok && time.Since(index.checkedAt) < textIndexCheckInterval {
+		return index.fields, nil
+	}
+
+	fields, err := f.searchFields(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := f.dbProxy.Table(table).Indexes(ctx.Context)
+	if err != nil {
+		blog.Errorf("get indexes of table %s failed, err: %v, rid: %s", table, err, ctx.ReqID)
+		return nil, err
+	}
+
+	index = textIndex{checkedAt: time.Now()}
+	for _, idx := range indexes {
+		if strings.HasPrefix(idx.Name, textIndexPrefix) {
+			index.fields = fields
+			break
+		}
+	}
+
+	f.lock.Lock()
+	f.indexes[table] = index
+	f.lock.Unlock()
+	return index.fields, nil
+}
+
+// SyncTextIndexes makes sure the text index of each table is built on the searchable fields, which are
+// the single char and long char attributes of the models in the table. it's called by the cron job.
+func (f *fullTextOperation) SyncTextIndexes(ctx core.ContextParams) error {
+	for _, table := range searchTables {
+		if err := f.syncTextIndex(ctx, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fullTextOperation) syncTextIndex(ctx core.ContextParams, table string) error {
+	fields, err := f.searchFields(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	name := textIndexName(fields)
+	indexes, err := f.dbProxy.Table(table).Indexes(ctx.Context)
+	if err != nil {
+		blog.Errorf("get indexes of table %s failed, err: %v, rid: %s", table, err, ctx.ReqID)
+		return err
+	}
+
+	exist := false
+	for _, index := range indexes {
+		if index.Name == name && len(fields) != 0 {
+			exist = true
+			continue
+		}
+		if strings.HasPrefix(index.Name, textIndexPrefix) {
+			// the searchable fields are changed, a collection can only have one text index
+			blog.Infof("drop the outdated text index %s of table %s, rid: %s", index.Name, table, ctx.ReqID)
+			if err := f.dbProxy.Table(table).DropIndex(ctx.Context, index.Name); err != nil {
+				blog.Errorf("drop text index %s of table %s failed, err: %v, rid: %s", index.Name, table, err, ctx.ReqID)
+				return err
+			}
+		}
+	}
+
+	if exist || len(fields) == 0 {
+		return nil
+	}
+	keys := make(map[string]int32, len(fields))
+	for _, field := range fields {
+		keys[textIndexKeyPrefix+field] = 1
+	}
+	index := dal.Index{
+		Name:       name,
+		Keys:       keys,
+		Background: true,
+		// the words are not stemmed, so that they are matched as they are
+		DefaultLanguage: "none",
+	}
+	blog.Infof("create text index %s of table %s on fields %v, rid: %s", name, table, fields, ctx.ReqID)
+	if err := f.dbProxy.Table(table).CreateIndex(ctx.Context, index); err != nil {
+		blog.Errorf("create text index %s of table %s failed, err: %v, rid: %s", name, table, err, ctx.ReqID)
+		return err
+	}
+	return nil
+}
+
+// searchFields returns the sorted single char and long char attributes of the models in the table
+func (f *fullTextOperation) searchFields(ctx core.ContextParams, table string) ([]string, error) {
+	if table == common.BKTableNameObjDes {
+		return modelSearchFields, nil
+	}
+
+	filter := map[string]interface{}{
+		common.BKPropertyTypeField: map[string]interface{}{
+			common.BKDBIN: []string{common.FieldTypeSingleChar, common.FieldTypeLongChar},
+		},
+	}
+	attrs := make([]metadata.Attribute, 0)
+	err := f.dbProxy.Table(common.BKTableNameObjAttDes).Find(filter).
+		Fields(common.BKObjIDField, common.BKPropertyIDField).All(ctx.Context, &attrs)
+	if err != nil {
+		blog.ErrorJSON("find searchable attributes failed, filter: %s, err: %s, rid: %s", filter, err, ctx.ReqID)
+		return nil, err
+	}
+	return tableFields(table, attrs), nil
+}
+
+// tableFields returns the sorted fields of the attributes of the models in the table
+func tableFields(table string, attrs []metadata.Attribute) []string {
+	fieldMap := make(map[string]bool)
+	for _, attr := range attrs {
+		if common.GetInstTableName(attr.ObjectID) != table {
+			continue
+		}
+		fieldMap[attr.PropertyID] = true
+	}
+
+	fields := make([]string, 0, len(fieldMap))
+	for field := range fieldMap {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// textIndexName returns the name of the text index of the fields, which is changed with the fields
+func textIndexName(fields []string) string {
+	sum := md5.Sum([]byte(strings.Join(fields, ",")))
+	return fmt.Sprintf("%s%x", textIndexPrefix, sum[:4])
+}
@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fulltext
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighlightText(t *testing.T) {
+	fragment, matched := highlightText("Web-Server web01", []string{"web"})
+	require.True(t, matched)
+	require.Equal(t, "<em>Web</em>-Server <em>web</em>01", fragment)
+
+	// overlapped and adjacent matches are wrapped once
+	fragment, matched = highlightText("database", []string{"data", "tab", "base"})
+	require.True(t, matched)
+	require.Equal(t, "<em>database</em>", fragment)
+
+	fragment, matched = highlightText("蓝鲸配置平台", []string{"配置"})
+	require.True(t, matched)
+	require.Equal(t, "蓝鲸<em>配置</em>平台", fragment)
+
+	_, matched = highlightText("switch", []string{"router"})
+	require.False(t, matched)
+
+	highlight := highlightDoc(map[string]interface{}{
+		common.BKInstNameField: "core-switch",
+		"bk_sn":                "sw-001",
+		"bk_port":              int64(48),
+	}, []string{common.BKInstNameField, "bk_sn", "bk_port"}, []string{"switch"})
+	require.Equal(t, map[string][]string{common.BKInstNameField: {"core-<em>switch</em>"}}, highlight)
+}
+
+func TestTableFields(t *testing.T) {
+	attrs := []metadata.Attribute{
+		{ObjectID: common.BKInnerObjIDHost, PropertyID: common.BKHostNameField},
+		{ObjectID: common.BKInnerObjIDHost, PropertyID: common.BKHostInnerIPField},
+		{ObjectID: common.BKInnerObjIDSet, PropertyID: common.BKSetNameField},
+		{ObjectID: "switch", PropertyID: common.BKInstNameField},
+		{ObjectID: "router", PropertyID: common.BKInstNameField},
+		{ObjectID: "router", PropertyID: "bk_sn"},
+	}
+	require.Equal(t, []string{common.BKHostInnerIPField, common.BKHostNameField}, tableFields(common.BKTableNameBaseHost, attrs))
+	require.Equal(t, []string{common.BKInstNameField, "bk_sn"}, tableFields(common.BKTableNameBaseInst, attrs))
+	require.Empty(t, tableFields(common.BKTableNameBaseApp, attrs))
+
+	// the index name changes with the fields only
+	name := textIndexName([]string{"bk_sn", common.BKInstNameField})
+	require.Equal(t, name, textIndexName([]string{"bk_sn", common.BKInstNameField}))
+	require.NotEqual(t, name, textIndexName([]string{common.BKInstNameField}))
+}
+
+func TestSearchFilter(t *testing.T) {
+	filter := searchFilter(common.BKTableNameBaseApp, []string{"demo", "web"}, metadata.FullTextSearchOption{})
+	require.Equal(t, map[string]interface{}{"$search": "demo web"}, filter["$text"])
+	require.Equal(t, map[string]interface{}{common.BKDBExists: false}, filter[common.BkBizMetaKey])
+	require.Equal(t, map[string]interface{}{common.BKDBNE: common.DefaultAppName}, filter[common.BKAppNameField])
+	require.NotContains(t, filter, common.BKObjIDField)
+
+	filter = searchFilter(common.BKTableNameBaseInst, []string{"demo"}, metadata.FullTextSearchOption{ObjectID: "switch", BizID: "2"})
+	require.Equal(t, "switch", filter[common.BKObjIDField])
+	require.NotContains(t, filter, common.BkBizMetaKey)
+	require.Len(t, filter[common.BKDBOR], 2)
+	require.NotContains(t, filter, common.BKAppNameField)
+}
+
+func TestPageHits(t *testing.T) {
+	hits := []metadata.FullTextSearchHit{
+		{Table: common.BKTableNameBaseHost, Score: 1},
+		{Table: common.BKTableNameBaseHost, Score: 0.5},
+		{Table: common.BKTableNameBaseInst, Score: 2},
+		{Table: common.BKTableNameBaseApp, Score: 1.5},
+	}
+	page := pageHits(hits, 1, 2)
+	require.Len(t, page, 2)
+	require.Equal(t, 1.5, page[0].Score)
+	require.Equal(t, float64(1), page[1].Score)
+
+	require.Len(t, pageHits(hits, 3, 10), 1)
+	require.Empty(t, pageHits(hits, 4, 10))
+}
@@ -113,6 +113,10 @@ func (hm *hostManager) UpdateHostCloudAreaField(ctx core.ContextParams, input me
 	}
 	if err := hm.DbProxy.Table(common.BKTableNameBaseHost).Update(context, updateFilter, updateDoc); err != nil {
 		blog.ErrorJSON("UpdateHostCloudAreaField failed, db update failed, table: %s, filter: %s, doc: %s, err: %s, rid: %s", common.BKTableNameBaseHost, updateFilter, updateDoc, err.Error(), rid)
+		// the hosts are added to the cloud area concurrently, the unique index finds the duplication
+		if hm.DbProxy.IsDuplicatedError(err) {
+			return ctx.Error.CCErrorf(common.CCErrCommDuplicateItem, common.BKHostInnerIPField)
+		}
 		return ctx.Error.CCError(common.CCErrCommDBUpdateFailed)
 	}
 	return nil
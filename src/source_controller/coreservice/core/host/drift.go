@@ -0,0 +1,132 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// SearchHostDrift returns the drift timeline of a host, the latest first
+func (hm *hostManager) SearchHostDrift(ctx core.ContextParams, input metadata.SearchHostDriftOption) (*metadata.MultipleHostDrift, errors.CCErrorCoder) {
+	filter := map[string]interface{}{
+		common.BKHostIDField: input.HostID,
+	}
+	if timeCond := input.HostDriftTimeRange.Condition(); len(timeCond) > 0 {
+		filter[common.CreateTimeField] = timeCond
+	}
+	if len(input.Fields) > 0 {
+		filter["changes.bk_property_id"] = map[string]interface{}{common.BKDBIN: input.Fields}
+	}
+	filter = util.SetQueryOwner(filter, ctx.SupplierAccount)
+
+	table := hm.DbProxy.Table(common.BKTableNameHostDrift)
+	count, err := table.Find(filter).Count(ctx.Context)
+	if err != nil {
+		blog.ErrorJSON("SearchHostDrift failed, count drift failed, filter: %s, err: %s, rid: %s", filter, err.Error(), ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	drifts := make([]metadata.HostDrift, 0)
+	err = table.Find(filter).Sort("-"+common.CreateTimeField).Start(uint64(input.Page.Start)).
+		Limit(uint64(input.Page.Limit)).All(ctx.Context, &drifts)
+	if err != nil {
+		blog.ErrorJSON("SearchHostDrift failed, find drift failed, filter: %s, err: %s, rid: %s", filter, err.Error(), ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	// only the changes of the required fields are returned
+	if len(input.Fields) > 0 {
+		fields := make(map[string]bool)
+		for _, field := range input.Fields {
+			fields[field] = true
+		}
+		for idx := range drifts {
+			changes := make([]metadata.HostDriftChange, 0)
+			for _, change := range drifts[idx].Changes {
+				if fields[change.PropertyID] {
+					changes = append(changes, change)
+				}
+			}
+			drifts[idx].Changes = changes
+		}
+	}
+
+	return &metadata.MultipleHostDrift{Count: count, Info: drifts}, nil
+}
+
+// ListDriftedHosts returns the hosts whose fields changed in the time range, the latest changed first
+func (hm *hostManager) ListDriftedHosts(ctx core.ContextParams, input metadata.ListDriftedHostsOption) (*metadata.MultipleDriftedHost, errors.CCErrorCoder) {
+	filter := map[string]interface{}{}
+	if timeCond := input.HostDriftTimeRange.Condition(); len(timeCond) > 0 {
+		filter[common.CreateTimeField] = timeCond
+	}
+	if len(input.HostIDs) > 0 {
+		filter[common.BKHostIDField] = map[string]interface{}{common.BKDBIN: input.HostIDs}
+	}
+	filter = util.SetQueryOwner(filter, ctx.SupplierAccount)
+
+	pipeline := []map[string]interface{}{
+		{common.BKDBMatch: filter},
+		{"$unwind": "$changes"},
+	}
+	if len(input.Fields) > 0 {
+		pipeline = append(pipeline, map[string]interface{}{
+			common.BKDBMatch: map[string]interface{}{
+				"changes.bk_property_id": map[string]interface{}{common.BKDBIN: input.Fields},
+			},
+		})
+	}
+	pipeline = append(pipeline, map[string]interface{}{common.BKDBGroup: map[string]interface{}{
+		"_id":          "$" + common.BKHostIDField,
+		"change_count": map[string]interface{}{common.BKDBSum: 1},
+		"last_time":    map[string]interface{}{"$max": "$" + common.CreateTimeField},
+		"fields":       map[string]interface{}{common.BKDBAddToSet: "$changes.bk_property_id"},
+	}})
+
+	countPipeline := append(pipeline[:len(pipeline):len(pipeline)], map[string]interface{}{common.BKDBCount: "count"})
+	countResult := struct {
+		Count uint64 `bson:"count"`
+	}{}
+	if err := hm.DbProxy.Table(common.BKTableNameHostDrift).AggregateOne(ctx.Context, countPipeline, &countResult); err != nil {
+		if !hm.DbProxy.IsNotFoundError(err) {
+			blog.ErrorJSON("ListDriftedHosts failed, count drifted hosts failed, pipeline: %s, err: %s, rid: %s", countPipeline, err.Error(), ctx.ReqID)
+			return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+		}
+	}
+
+	hosts := make([]metadata.DriftedHost, 0)
+	if countResult.Count == 0 || uint64(input.Page.Start) >= countResult.Count {
+		return &metadata.MultipleDriftedHost{Count: countResult.Count, Info: hosts}, nil
+	}
+
+	pipeline = append(pipeline,
+		map[string]interface{}{"$sort": map[string]interface{}{"last_time": -1, "_id": 1}},
+		map[string]interface{}{"$skip": input.Page.Start},
+	)
+	if input.Page.Limit > 0 {
+		pipeline = append(pipeline, map[string]interface{}{"$limit": input.Page.Limit})
+	}
+	if err := hm.DbProxy.Table(common.BKTableNameHostDrift).AggregateAll(ctx.Context, pipeline, &hosts); err != nil {
+		if !hm.DbProxy.IsNotFoundError(err) {
+			blog.ErrorJSON("ListDriftedHosts failed, aggregate drift failed, pipeline: %s, err: %s, rid: %s", pipeline, err.Error(), ctx.ReqID)
+			return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+		}
+	}
+
+	return &metadata.MultipleDriftedHost{Count: countResult.Count, Info: hosts}, nil
+}
@@ -392,6 +392,9 @@ func (manager *TransferManager) setDefaultPrivateField(ctx core.ContextParams, a
 	_, err := manager.dependence.UpdateModelInstance(ctx, common.BKInnerObjIDHost, updateOption)
 	if err != nil {
 		blog.ErrorJSON("setDefaultPrivateField failed. UpdateModelInstance failed, option: %s, err: %s, rid:%s", common.BKTableNameBaseHost, updateOption, err.Error(), ctx.ReqID)
+		if ccErr, ok := err.(errors.CCErrorCoder); ok {
+			return ccErr
+		}
 		return ctx.Error.CCErrorf(common.CCErrCommDBUpdateFailed)
 	}
 	return nil
@@ -41,6 +41,10 @@ func (m *instanceManager) save(ctx core.ContextParams, objID string, inputParam
 	inputParam.Set(common.CreateTimeField, ts)
 	inputParam.Set(common.LastTimeField, ts)
 	err = m.dbProxy.Table(tableName).Insert(ctx, inputParam)
+	if err != nil && m.dbProxy.IsDuplicatedError(err) {
+		bizID, _ := FetchBizIDFromInstance(objID, inputParam)
+		return id, m.convertDuplicateError(ctx, objID, bizID, err)
+	}
 	return id, err
 }
 
@@ -57,6 +61,10 @@ func (m *instanceManager) update(ctx core.ContextParams, objID string, data maps
 	data.Set(common.LastTimeField, ts)
 	data.Remove(common.BKObjIDField)
 	err = m.dbProxy.Table(tableName).Update(ctx, cond, data)
+	if err != nil && m.dbProxy.IsDuplicatedError(err) {
+		bizID, _ := FetchBizIDFromInstance(objID, data)
+		return cnt, m.convertDuplicateError(ctx, objID, bizID, err)
+	}
 	return cnt, err
 }
 
@@ -38,6 +38,14 @@ func (valid *validator) validCreateUnique(ctx core.ContextParams, instanceData m
 	}
 
 	for _, unique := range uniqueAttr {
+		if hasAssociationKey(unique) {
+			// the new instance is not associated with any instance yet
+			if err := valid.validUniqueWithAssociation(ctx, unique, instanceData, instMedataData, 0, nil, instanceManager); err != nil {
+				return err
+			}
+			continue
+		}
+
 		// retrieve unique value
 		uniqueKeys := make([]string, 0)
 		for _, key := range unique.Keys {
@@ -91,12 +99,7 @@ func (valid *validator) validCreateUnique(ctx core.ContextParams, instanceData m
 
 		if 0 < result {
 			blog.Errorf("[validCreateUnique] duplicate data condition: %#v, unique keys: %#v, objID %s, rid: %s", cond.ToMapStr(), uniqueKeys, valid.objID, ctx.ReqID)
-			propertyNames := make([]string, 0)
-			for _, key := range uniqueKeys {
-				propertyNames = append(propertyNames, util.FirstNotEmptyString(ctx.Lang.Language(valid.objID+"_property_"+key), valid.propertys[key].PropertyName, key))
-			}
-
-			return valid.errif.Errorf(common.CCErrCommDuplicateItem, strings.Join(propertyNames, ","))
+			return valid.errif.Errorf(common.CCErrCommDuplicateItem, valid.propertyNames(ctx, uniqueKeys))
 		}
 
 	}
@@ -118,6 +121,13 @@ func (valid *validator) validUpdateUnique(ctx core.ContextParams, updateData map
 	}
 
 	for _, unique := range uniqueAttr {
+		if hasAssociationKey(unique) {
+			if err := valid.validUniqueWithAssociation(ctx, unique, updateData, instMedataData, instID, nil, instanceManager); err != nil {
+				return err
+			}
+			continue
+		}
+
 		// retrieve unique value
 		uniqueKeys := make([]string, 0)
 		for _, key := range unique.Keys {
@@ -170,13 +180,52 @@ func (valid *validator) validUpdateUnique(ctx core.ContextParams, updateData map
 
 		if 0 < result {
 			blog.Errorf("[validUpdateUnique] duplicate data condition: %#v, unique keys: %#v, objID %s, rid: %s", cond.ToMapStr(), uniqueKeys, valid.objID, ctx.ReqID)
-			propertyNames := make([]string, 0)
-			for _, key := range uniqueKeys {
-				propertyNames = append(propertyNames, util.FirstNotEmptyString(ctx.Lang.Language(valid.objID+"_property_"+key), valid.propertys[key].PropertyName, key))
-			}
-
-			return valid.errif.Errorf(common.CCErrCommDuplicateItem, strings.Join(propertyNames, ","))
+			return valid.errif.Errorf(common.CCErrCommDuplicateItem, valid.propertyNames(ctx, uniqueKeys))
 		}
 	}
 	return nil
 }
+
+// propertyNames returns the names of the properties in the language of the request
+func (valid *validator) propertyNames(ctx core.ContextParams, propertyIDs []string) string {
+	names := make([]string, 0, len(propertyIDs))
+	for _, key := range propertyIDs {
+		names = append(names, util.FirstNotEmptyString(ctx.Lang.Language(valid.objID+"_property_"+key), valid.propertys[key].PropertyName, key))
+	}
+	return strings.Join(names, ",")
+}
+
+// convertDuplicateError converts the duplicate key error of the db index backing a unique rule to the error
+// the application check returns, so that the users get the same error whichever finds the duplication first.
+func (m *instanceManager) convertDuplicateError(ctx core.ContextParams, objID string, bizID int64, err error) error {
+	uniqueID, ok := metadata.ParseUniqueIDFromDupError(err)
+	if !ok {
+		return ctx.Error.Errorf(common.CCErrCommDuplicateItem, "instance")
+	}
+
+	valid, validErr := NewValidator(ctx, m.dependent, objID, bizID)
+	if validErr != nil {
+		blog.Errorf("[convertDuplicateError] init validator for %s failed, err: %v, rid: %s", objID, validErr, ctx.ReqID)
+		return ctx.Error.Errorf(common.CCErrCommDuplicateItem, "instance")
+	}
+	uniques, searchErr := m.dependent.SearchUnique(ctx, objID)
+	if searchErr != nil {
+		blog.Errorf("[convertDuplicateError] search unique of %s failed, err: %v, rid: %s", objID, searchErr, ctx.ReqID)
+		return ctx.Error.Errorf(common.CCErrCommDuplicateItem, "instance")
+	}
+
+	for _, unique := range uniques {
+		if unique.ID != uniqueID {
+			continue
+		}
+		uniqueKeys := make([]string, 0, len(unique.Keys))
+		for _, key := range unique.Keys {
+			if property, ok := valid.idToProperty[int64(key.ID)]; ok {
+				uniqueKeys = append(uniqueKeys, property.PropertyID)
+			}
+		}
+		blog.Errorf("[convertDuplicateError] duplicate data of unique %d, keys: %v, objID %s, rid: %s", uniqueID, uniqueKeys, objID, ctx.ReqID)
+		return valid.errif.Errorf(common.CCErrCommDuplicateItem, valid.propertyNames(ctx, uniqueKeys))
+	}
+	return ctx.Error.Errorf(common.CCErrCommDuplicateItem, "instance")
+}
@@ -0,0 +1,268 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// hasAssociationKey returns whether the unique rule has association keys
+func hasAssociationKey(unique metadata.ObjectUnique) bool {
+	for _, key := range unique.Keys {
+		if key.Kind == metadata.UniqueKeyKindAssociation {
+			return true
+		}
+	}
+	return false
+}
+
+// searchUniqueAssociations returns the model associations of the association keys of the unique rule by the key id
+func (m *instanceManager) searchUniqueAssociations(ctx core.ContextParams, unique metadata.ObjectUnique) (map[uint64]metadata.Association, error) {
+	asstIDs := make([]uint64, 0)
+	for _, key := range unique.Keys {
+		if key.Kind == metadata.UniqueKeyKindAssociation {
+			asstIDs = append(asstIDs, key.ID)
+		}
+	}
+
+	cond := util.SetQueryOwner(mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: asstIDs}}, ctx.SupplierAccount)
+	associations := make([]metadata.Association, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(cond).All(ctx, &associations); err != nil {
+		blog.Errorf("[searchUniqueAssociations] search associations of unique %d failed, err: %v, rid: %s", unique.ID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	result := make(map[uint64]metadata.Association, len(associations))
+	for _, association := range associations {
+		result[uint64(association.ID)] = association
+	}
+	for _, asstID := range asstIDs {
+		if _, ok := result[asstID]; !ok {
+			blog.Errorf("[searchUniqueAssociations] association %d of unique %d not found, rid: %s", asstID, unique.ID, ctx.ReqID)
+			return nil, ctx.Error.Errorf(common.CCErrTopoObjectUniqueAssociationInvalid, asstID)
+		}
+	}
+	return result, nil
+}
+
+// associatedInstIDs returns the ids of the instances associated with the instances of the model through the
+// association by the instance id, there is at most one for each as the association is a unique key of the model.
+func (m *instanceManager) associatedInstIDs(ctx core.ContextParams, objID string, association metadata.Association, instIDs []int64) (map[int64]int64, error) {
+	cond := mapstr.MapStr{common.AssociationObjAsstIDField: association.AssociationName}
+	if objID == association.ObjectID {
+		cond.Set(common.BKObjIDField, objID)
+		cond.Set(common.BKInstIDField, mapstr.MapStr{common.BKDBIN: instIDs})
+	} else {
+		cond.Set(common.BKAsstObjIDField, objID)
+		cond.Set(common.BKAsstInstIDField, mapstr.MapStr{common.BKDBIN: instIDs})
+	}
+	cond = util.SetQueryOwner(cond, ctx.SupplierAccount)
+
+	instAssts := make([]metadata.InstAsst, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(cond).All(ctx, &instAssts); err != nil {
+		blog.Errorf("[associatedInstIDs] search instance associations failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	result := make(map[int64]int64, len(instAssts))
+	for _, instAsst := range instAssts {
+		if objID == association.ObjectID {
+			result[instAsst.InstID] = instAsst.AsstInstID
+		} else {
+			result[instAsst.AsstInstID] = instAsst.InstID
+		}
+	}
+	return result, nil
+}
+
+// validUniqueWithAssociation checks the unique rule with association keys, the value of an association key is the
+// id of the instance associated with the instance through the association, which is checked the same as the property
+// values. asstInstIDs overrides the associated instances by bk_obj_asst_id when the associations are changing.
+func (valid *validator) validUniqueWithAssociation(ctx core.ContextParams, unique metadata.ObjectUnique, instanceData mapstr.MapStr,
+	instMedataData metadata.Metadata, instID uint64, asstInstIDs map[string]int64, instanceManager *instanceManager) error {
+
+	associations, err := instanceManager.searchUniqueAssociations(ctx, unique)
+	if err != nil {
+		return err
+	}
+
+	cond := mongo.NewCondition()
+	anyEmpty := false
+	uniqueKeys := make([]string, 0)
+	asstValues := make(map[uint64]int64)
+	for _, key := range unique.Keys {
+		switch key.Kind {
+		case metadata.UniqueKeyKindProperty:
+			property, ok := valid.idToProperty[int64(key.ID)]
+			if !ok {
+				blog.Errorf("[validUniqueWithAssociation] find [%s] property [%d] failed, rid: %s", valid.objID, key.ID, ctx.ReqID)
+				return valid.errif.Errorf(common.CCErrTopoObjectPropertyNotFound, key.ID)
+			}
+			uniqueKeys = append(uniqueKeys, property.PropertyID)
+			val, ok := instanceData[property.PropertyID]
+			if !ok || isEmpty(val) {
+				anyEmpty = true
+			}
+			cond.Element(&mongo.Eq{Key: property.PropertyID, Val: val})
+		case metadata.UniqueKeyKindAssociation:
+			association := associations[key.ID]
+			uniqueKeys = append(uniqueKeys, association.AssociationName)
+			asstInstID, ok := asstInstIDs[association.AssociationName]
+			if !ok && instID != 0 {
+				associated, err := instanceManager.associatedInstIDs(ctx, valid.objID, association, []int64{int64(instID)})
+				if err != nil {
+					return err
+				}
+				asstInstID = associated[int64(instID)]
+			}
+			if asstInstID == 0 {
+				anyEmpty = true
+			}
+			asstValues[key.ID] = asstInstID
+		default:
+			blog.Errorf("[validUniqueWithAssociation] find [%s] key [%d] unique kind invalid [%s], rid: %s", valid.objID, key.ID, key.Kind, ctx.ReqID)
+			return valid.errif.Errorf(common.CCErrTopoObjectUniqueKeyKindInvalid, key.Kind)
+		}
+	}
+
+	if anyEmpty && !unique.MustCheck {
+		return nil
+	}
+
+	// only search data not in disable status
+	cond.Element(&mongo.Neq{Key: common.BKDataStatusField, Val: common.DataStatusDisabled})
+	if common.GetObjByType(valid.objID) == common.BKInnerObjIDObject {
+		cond.Element(&mongo.Eq{Key: common.BKObjIDField, Val: valid.objID})
+	}
+	instIDField := common.GetInstIDField(valid.objID)
+	if instID != 0 {
+		cond.Element(&mongo.Neq{Key: instIDField, Val: instID})
+	}
+	isExist, bizID := instMedataData.Label.Get(common.BKAppIDField)
+	if isExist {
+		_, metaCond := cond.Embed(metadata.BKMetadata)
+		_, labelCond := metaCond.Embed(metadata.BKLabel)
+		labelCond.Element(&mongo.Eq{Key: common.BKAppIDField, Val: bizID})
+	}
+
+	// the instances with the same property values are the candidates, then they are
+	// filtered by the instances associated with them through the associations
+	condMap := util.SetQueryOwner(cond.ToMapStr(), ctx.SupplierAccount)
+	candidates := make([]mapstr.MapStr, 0)
+	err = instanceManager.dbProxy.Table(common.GetInstTableName(valid.objID)).Find(condMap).Fields(instIDField).All(ctx, &candidates)
+	if err != nil {
+		blog.Errorf("[validUniqueWithAssociation] search [%s] inst failed, condition: %#v, err: %v, rid: %s", valid.objID, condMap, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	candidateIDs := make([]int64, 0, len(candidates))
+	for _, candidate := range candidates {
+		id, err := util.GetInt64ByInterface(candidate[instIDField])
+		if err != nil {
+			blog.Errorf("[validUniqueWithAssociation] parse [%s] inst id %v failed, err: %v, rid: %s", valid.objID, candidate[instIDField], err, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsInvalid, instIDField)
+		}
+		candidateIDs = append(candidateIDs, id)
+	}
+
+	for keyID, association := range associations {
+		if len(candidateIDs) == 0 {
+			return nil
+		}
+		associated, err := instanceManager.associatedInstIDs(ctx, valid.objID, association, candidateIDs)
+		if err != nil {
+			return err
+		}
+		remains := make([]int64, 0, len(candidateIDs))
+		for _, id := range candidateIDs {
+			if associated[id] == asstValues[keyID] {
+				remains = append(remains, id)
+			}
+		}
+		candidateIDs = remains
+	}
+
+	if len(candidateIDs) > 0 {
+		blog.Errorf("[validUniqueWithAssociation] duplicate data of unique %d with %v, objID %s, duplicated: %v, rid: %s", unique.ID, uniqueKeys, valid.objID, candidateIDs, ctx.ReqID)
+		return valid.errif.Errorf(common.CCErrCommDuplicateItem, valid.propertyNames(ctx, uniqueKeys))
+	}
+	return nil
+}
+
+// ValidAssociationUnique checks the unique rules of the instance whose keys have the association, asstInstID is
+// the instance associated with it through the association after the association changes, 0 if it is removed.
+func (m *instanceManager) ValidAssociationUnique(ctx core.ContextParams, objID string, instID uint64, objAsstID string, asstInstID int64) error {
+	uniques, err := m.dependent.SearchUnique(ctx, objID)
+	if err != nil {
+		blog.Errorf("[ValidAssociationUnique] search [%s] unique failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return err
+	}
+
+	var origin mapstr.MapStr
+	var valid *validator
+	instMedataData := metadata.Metadata{Label: make(metadata.Label)}
+	for _, unique := range uniques {
+		if !hasAssociationKey(unique) {
+			continue
+		}
+		associations, err := m.searchUniqueAssociations(ctx, unique)
+		if err != nil {
+			return err
+		}
+		used := false
+		for _, association := range associations {
+			if association.AssociationName == objAsstID {
+				used = true
+			}
+		}
+		if !used {
+			continue
+		}
+
+		if valid == nil {
+			origin, err = m.getInstDataByID(ctx, objID, instID, m)
+			if err != nil {
+				if m.dbProxy.IsNotFoundError(err) {
+					// the instance is being deleted
+					return nil
+				}
+				blog.Errorf("[ValidAssociationUnique] get [%s] inst %d failed, err: %v, rid: %s", objID, instID, err, ctx.ReqID)
+				return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+			}
+			bizID, err := FetchBizIDFromInstance(objID, origin)
+			if err != nil {
+				blog.Errorf("[ValidAssociationUnique] fetch biz id of [%s] inst %d failed, err: %v, rid: %s", objID, instID, err, ctx.ReqID)
+				return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField)
+			}
+			valid, err = NewValidator(ctx, m.dependent, objID, bizID)
+			if err != nil {
+				blog.Errorf("[ValidAssociationUnique] init validator failed, err: %v, rid: %s", err, ctx.ReqID)
+				return err
+			}
+			if bizID := metadata.GetBusinessIDFromMeta(origin[metadata.BKMetadata]); bizID != "" {
+				instMedataData.Label.Set(metadata.LabelBusinessID, bizID)
+			}
+		}
+
+		asstInstIDs := map[string]int64{objAsstID: asstInstID}
+		if err := valid.validUniqueWithAssociation(ctx, unique, origin, instMedataData, instID, asstInstIDs, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
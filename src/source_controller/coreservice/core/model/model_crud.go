@@ -202,7 +202,17 @@ func (m *modelManager) cascadeDelete(ctx core.ContextParams, cond universalsql.C
 		return 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
 	}
 
-	// delete model unique
+	// delete model unique with the indexes backing them
+	uniques := make([]metadata.ObjectUnique, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjUnique).Find(delCondMap).All(ctx, &uniques); err != nil {
+		blog.ErrorJSON("find model unique error. err:%s, cond:%s, rid:%s", err.Error(), delCondMap, ctx.ReqID)
+		return 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	for _, unique := range uniques {
+		if err := dropUniqueIndex(ctx, m.dbProxy, unique.ObjID, unique.ID); err != nil {
+			return 0, err
+		}
+	}
 	if err := m.dbProxy.Table(common.BKTableNameObjUnique).Delete(ctx, delCondMap); err != nil {
 		blog.ErrorJSON("delete model unique error. err:%s, cond:%s, rid:%s", err.Error(), delCondMap, ctx.ReqID)
 		return 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
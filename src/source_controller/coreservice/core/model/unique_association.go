@@ -0,0 +1,178 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+// validUniqueAssociationKey checks the association key of the unique rule, the association must be one of the
+// model, and each instance of the model can be associated with at most one instance through it.
+func (m *modelAttrUnique) validUniqueAssociationKey(ctx core.ContextParams, objID string, key metadata.UniqueKey) (metadata.Association, error) {
+	cond := util.SetQueryOwner(mapstr.MapStr{common.BKFieldID: key.ID}, ctx.SupplierAccount)
+	associations := make([]metadata.Association, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(cond).All(ctx, &associations); err != nil {
+		blog.Errorf("[ObjectUnique] search association %d failed, err: %v, rid: %s", key.ID, err, ctx.ReqID)
+		return metadata.Association{}, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	if len(associations) == 0 {
+		blog.Errorf("[ObjectUnique] association %d of %s not found, rid: %s", key.ID, objID, ctx.ReqID)
+		return metadata.Association{}, ctx.Error.Errorf(common.CCErrTopoObjectUniqueAssociationInvalid, key.ID)
+	}
+
+	association := associations[0]
+	if association.ObjectID != objID && association.AsstObjID != objID {
+		blog.Errorf("[ObjectUnique] association %s is not one of %s, rid: %s", association.AssociationName, objID, ctx.ReqID)
+		return metadata.Association{}, ctx.Error.Errorf(common.CCErrTopoObjectUniqueAssociationInvalid, association.AssociationName)
+	}
+	if !association.IsSingleSide(objID) {
+		blog.Errorf("[ObjectUnique] %s can be associated with multiple instances through association %s, rid: %s", objID, association.AssociationName, ctx.ReqID)
+		return metadata.Association{}, ctx.Error.Errorf(common.CCErrTopoObjectUniqueAssociationInvalid, association.AssociationName)
+	}
+	return association, nil
+}
+
+// recheckUniqueWithAssociation checks the exists instances for the unique rule with association keys, which can
+// not be done by the aggregation, so the instances and their associated instances are compared one by one.
+func (m *modelAttrUnique) recheckUniqueWithAssociation(ctx core.ContextParams, objID string, properties []metadata.Attribute,
+	associations []metadata.Association, mustCheck bool, meta metadata.Metadata) error {
+
+	instCond := mapstr.MapStr{}
+	if len(meta.Label) > 0 {
+		instCond.Merge(metadata.PublicAndBizCondition(meta))
+		instCond.Remove(metadata.BKMetadata)
+	} else {
+		instCond.Merge(metadata.BizLabelNotExist)
+	}
+	if common.GetObjByType(objID) == common.BKInnerObjIDObject {
+		instCond.Set(common.BKObjIDField, objID)
+	}
+
+	instIDField := common.GetInstIDField(objID)
+	fields := []string{instIDField}
+	zeros := make(map[string]interface{}, len(properties))
+	for _, property := range properties {
+		basic, err := getBasicDataType(property.PropertyType)
+		if err != nil {
+			return err
+		}
+		zeros[property.PropertyID] = basic
+		fields = append(fields, property.PropertyID)
+	}
+
+	instances := make([]mapstr.MapStr, 0)
+	err := m.dbProxy.Table(common.GetInstTableName(objID)).Find(instCond).Fields(fields...).All(ctx, &instances)
+	if err != nil {
+		blog.ErrorJSON("[ObjectUnique] recheckUniqueWithAssociation find instances failed %s, cond: %s, rid: %s", err, instCond, ctx.ReqID)
+		return err
+	}
+
+	associated := make([]map[int64]int64, 0, len(associations))
+	for _, association := range associations {
+		asstInstIDs, err := m.searchAssociatedInstIDs(ctx, objID, association)
+		if err != nil {
+			return err
+		}
+		associated = append(associated, asstInstIDs)
+	}
+
+	exists := make(map[string]bool, len(instances))
+	for _, inst := range instances {
+		instID, err := util.GetInt64ByInterface(inst[instIDField])
+		if err != nil {
+			blog.Errorf("[ObjectUnique] recheckUniqueWithAssociation parse %s inst id %v failed, err: %v, rid: %s", objID, inst[instIDField], err, ctx.ReqID)
+			return err
+		}
+
+		anyEmpty := false
+		values := make([]interface{}, 0, len(properties)+len(associations))
+		for _, property := range properties {
+			val := inst[property.PropertyID]
+			if isEmptyUniqueValue(val, zeros[property.PropertyID]) {
+				anyEmpty = true
+			}
+			values = append(values, val)
+		}
+		for _, asstInstIDs := range associated {
+			if asstInstIDs[instID] == 0 {
+				anyEmpty = true
+			}
+			values = append(values, asstInstIDs[instID])
+		}
+		if anyEmpty && !mustCheck {
+			continue
+		}
+
+		js, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		if exists[string(js)] {
+			blog.Errorf("[ObjectUnique] recheckUniqueWithAssociation %s inst %d duplicated with %s, rid: %s", objID, instID, js, ctx.ReqID)
+			return dal.ErrDuplicated
+		}
+		exists[string(js)] = true
+	}
+	return nil
+}
+
+// searchAssociatedInstIDs returns the ids of the instances associated with the instances of the model through the
+// association by the instance id
+func (m *modelAttrUnique) searchAssociatedInstIDs(ctx core.ContextParams, objID string, association metadata.Association) (map[int64]int64, error) {
+	cond := mapstr.MapStr{common.AssociationObjAsstIDField: association.AssociationName}
+	if objID == association.ObjectID {
+		cond.Set(common.BKObjIDField, objID)
+	} else {
+		cond.Set(common.BKAsstObjIDField, objID)
+	}
+	cond = util.SetQueryOwner(cond, ctx.SupplierAccount)
+
+	instAssts := make([]metadata.InstAsst, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(cond).All(ctx, &instAssts); err != nil {
+		blog.ErrorJSON("[ObjectUnique] search instance associations failed %s, cond: %s, rid: %s", err, cond, ctx.ReqID)
+		return nil, err
+	}
+
+	result := make(map[int64]int64, len(instAssts))
+	for _, instAsst := range instAssts {
+		if objID == association.ObjectID {
+			result[instAsst.InstID] = instAsst.AsstInstID
+		} else {
+			result[instAsst.AsstInstID] = instAsst.InstID
+		}
+	}
+	return result, nil
+}
+
+// isEmptyUniqueValue returns whether the property value is null or the "ZERO" value of its basic data type
+func isEmptyUniqueValue(val interface{}, zero interface{}) bool {
+	if val == nil {
+		return true
+	}
+	switch zero.(type) {
+	case string:
+		return val == ""
+	case bool:
+		return val == false
+	default:
+		num, err := util.GetFloat64ByInterface(val)
+		return err == nil && num == 0
+	}
+}
@@ -49,9 +49,16 @@ func (m *modelAttrUnique) countModelAttrUnique(ctx core.ContextParams, cond maps
 }
 
 func (m *modelAttrUnique) createModelAttrUnique(ctx core.ContextParams, objID string, inputParam metadata.CreateModelAttrUnique) (uint64, error) {
+	associations := make([]metadata.Association, 0)
 	for _, key := range inputParam.Data.Keys {
 		switch key.Kind {
 		case metadata.UniqueKeyKindProperty:
+		case metadata.UniqueKeyKindAssociation:
+			association, err := m.validUniqueAssociationKey(ctx, objID, key)
+			if err != nil {
+				return 0, err
+			}
+			associations = append(associations, association)
 		default:
 			blog.Errorf("[CreateObjectUnique] invalid key kind: %s, rid: %s", key.Kind, ctx.ReqID)
 			return 0, ctx.Error.Errorf(common.CCErrTopoObjectUniqueKeyKindInvalid, key.Kind)
@@ -79,7 +86,11 @@ func (m *modelAttrUnique) createModelAttrUnique(ctx core.ContextParams, objID st
 		return 0, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "keys")
 	}
 
-	err = m.recheckUniqueForExistsInstances(ctx, objID, properties, inputParam.Data.MustCheck, inputParam.Data.Metadata)
+	if len(associations) > 0 {
+		err = m.recheckUniqueWithAssociation(ctx, objID, properties, associations, inputParam.Data.MustCheck, inputParam.Data.Metadata)
+	} else {
+		err = m.recheckUniqueForExistsInstances(ctx, objID, properties, inputParam.Data.MustCheck, inputParam.Data.Metadata)
+	}
 	if nil != err {
 		blog.Errorf("[CreateObjectUnique] recheckUniqueForExistsInsts for %s with %#v err: %#v, rid: %s", objID, inputParam, err, ctx.ReqID)
 		return 0, ctx.Error.Errorf(common.CCErrCommDuplicateItem, "instance")
@@ -110,6 +121,14 @@ func (m *modelAttrUnique) createModelAttrUnique(ctx core.ContextParams, objID st
 		return 0, ctx.Error.Error(common.CCErrObjectDBOpErrno)
 	}
 
+	if err := createUniqueIndex(ctx, m.dbProxy, unique, properties); err != nil {
+		delCond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: id}, ctx.SupplierAccount)
+		if delErr := m.dbProxy.Table(common.BKTableNameObjUnique).Delete(ctx, delCond); delErr != nil {
+			blog.Errorf("[CreateObjectUnique] delete unique %d without index failed, err: %v, rid: %s", id, delErr, ctx.ReqID)
+		}
+		return 0, err
+	}
+
 	return id, nil
 }
 
@@ -118,9 +137,16 @@ func (m *modelAttrUnique) updateModelAttrUnique(ctx core.ContextParams, objID st
 	unique := data.Data
 	unique.LastTime = metadata.Now()
 
+	associations := make([]metadata.Association, 0)
 	for _, key := range unique.Keys {
 		switch key.Kind {
 		case metadata.UniqueKeyKindProperty:
+		case metadata.UniqueKeyKindAssociation:
+			association, err := m.validUniqueAssociationKey(ctx, objID, key)
+			if err != nil {
+				return err
+			}
+			associations = append(associations, association)
 		default:
 			blog.Errorf("[UpdateObjectUnique] invalid key kind: %s, rid: %s", key.Kind, ctx.ReqID)
 			return ctx.Error.Errorf(common.CCErrTopoObjectUniqueKeyKindInvalid, key.Kind)
@@ -149,7 +175,11 @@ func (m *modelAttrUnique) updateModelAttrUnique(ctx core.ContextParams, objID st
 		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "keys")
 	}
 
-	err = m.recheckUniqueForExistsInstances(ctx, objID, properties, unique.MustCheck, unique.Metadata)
+	if len(associations) > 0 {
+		err = m.recheckUniqueWithAssociation(ctx, objID, properties, associations, unique.MustCheck, unique.Metadata)
+	} else {
+		err = m.recheckUniqueForExistsInstances(ctx, objID, properties, unique.MustCheck, unique.Metadata)
+	}
 	if nil != err {
 		blog.Errorf("[UpdateObjectUnique] recheckUniqueForExistsInsts for %s with %#v error: %#v, rid: %s", objID, unique, err, ctx.ReqID)
 		return ctx.Error.Errorf(common.CCErrCommDuplicateItem, "instance")
@@ -175,14 +205,45 @@ func (m *modelAttrUnique) updateModelAttrUnique(ctx core.ContextParams, objID st
 		return ctx.Error.Error(common.CCErrTopoObjectUniquePresetCouldNotDelOrEdit)
 	}
 
+	// the old index is dropped first, so that the rule is never backed by an index of other keys
+	if err := dropUniqueIndex(ctx, m.dbProxy, objID, id); err != nil {
+		return err
+	}
+
 	err = m.dbProxy.Table(common.BKTableNameObjUnique).Update(ctx, cond.ToMapStr(), &unique)
 	if nil != err {
 		blog.Errorf("[UpdateObjectUnique] Update error: %s, raw: %#v, rid: %s", err, &unique, ctx.ReqID)
+		m.restoreUniqueIndex(ctx, oldUnique)
 		return ctx.Error.Error(common.CCErrObjectDBOpErrno)
 	}
+
+	newUnique := oldUnique
+	newUnique.MustCheck = unique.MustCheck
+	newUnique.Keys = unique.Keys
+	if err := createUniqueIndex(ctx, m.dbProxy, newUnique, properties); err != nil {
+		if restoreErr := m.dbProxy.Table(common.BKTableNameObjUnique).Update(ctx, cond.ToMapStr(), &oldUnique); restoreErr != nil {
+			blog.Errorf("[UpdateObjectUnique] restore unique %d failed, err: %v, rid: %s", id, restoreErr, ctx.ReqID)
+			return err
+		}
+		m.restoreUniqueIndex(ctx, oldUnique)
+		return err
+	}
 	return nil
 }
 
+// restoreUniqueIndex recreates the index of the unique rule whose update failed, the failure is only
+// logged as the rule is still checked by the application.
+func (m *modelAttrUnique) restoreUniqueIndex(ctx core.ContextParams, unique metadata.ObjectUnique) {
+	properties, err := m.getUniqueProperties(ctx, unique.ObjID, unique.Keys, unique.MustCheck, unique.Metadata)
+	if err != nil {
+		blog.Errorf("[UpdateObjectUnique] get properties of unique %d to restore index failed, err: %v, rid: %s", unique.ID, err, ctx.ReqID)
+		return
+	}
+	if err := createUniqueIndex(ctx, m.dbProxy, unique, properties); err != nil {
+		blog.Errorf("[UpdateObjectUnique] restore index of unique %d failed, err: %v, rid: %s", unique.ID, err, ctx.ReqID)
+	}
+}
+
 func (m *modelAttrUnique) deleteModelAttrUnique(ctx core.ContextParams, objID string, id uint64, meta metadata.DeleteModelAttrUnique) error {
 	cond := condition.CreateCondition()
 	cond.Field(common.BKFieldID).Eq(id)
@@ -211,6 +272,10 @@ func (m *modelAttrUnique) deleteModelAttrUnique(ctx core.ContextParams, objID st
 		return ctx.Error.CCError(common.CCErrTopoObjectUniqueShouldHaveMoreThanOne)
 	}
 
+	if err := dropUniqueIndex(ctx, m.dbProxy, objID, id); err != nil {
+		return err
+	}
+
 	fCond := cond.ToMapStr()
 	if len(meta.Label) > 0 {
 		fCond.Merge(metadata.PublicAndBizCondition(meta.Metadata))
@@ -232,6 +297,9 @@ func (m *modelAttrUnique) deleteModelAttrUnique(ctx core.ContextParams, objID st
 func (m *modelAttrUnique) getUniqueProperties(ctx core.ContextParams, objID string, keys []metadata.UniqueKey, mustCheck bool, meta metadata.Metadata) ([]metadata.Attribute, error) {
 	propertyIDs := make([]int64, 0)
 	for _, key := range keys {
+		if key.Kind != metadata.UniqueKeyKindProperty {
+			continue
+		}
 		propertyIDs = append(propertyIDs, int64(key.ID))
 	}
 	propertyIDs = util.IntArrayUnique(propertyIDs)
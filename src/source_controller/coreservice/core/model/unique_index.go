@@ -0,0 +1,83 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+// createUniqueIndex creates the db unique index on the instance table which backs the unique rule,
+// so that the concurrent creations can not produce duplicate instances. the rule which can not be
+// backed by an index, or whose table has too many indexes, is only checked by the application.
+func createUniqueIndex(ctx core.ContextParams, db dal.RDB, unique metadata.ObjectUnique, properties []metadata.Attribute) error {
+	keys, filter, err := unique.IndexKeysAndFilter(properties)
+	if err != nil {
+		blog.Warnf("[createUniqueIndex] unique %d of %s is not backed by index, reason: %v, rid: %s", unique.ID, unique.ObjID, err, ctx.ReqID)
+		return nil
+	}
+
+	index := dal.Index{
+		Name:                    metadata.UniqueIndexName(unique.ID),
+		Keys:                    keys,
+		Unique:                  true,
+		Background:              true,
+		PartialFilterExpression: filter,
+	}
+	tableName := common.GetInstTableName(unique.ObjID)
+	indexes, err := db.Table(tableName).Indexes(ctx)
+	if err != nil {
+		blog.Errorf("[createUniqueIndex] get indexes of %s failed, err: %v, rid: %s", tableName, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	if len(indexes) >= metadata.UniqueIndexTableLimit {
+		blog.Warnf("[createUniqueIndex] unique %d of %s is not backed by index, table %s has %d indexes, rid: %s",
+			unique.ID, unique.ObjID, tableName, len(indexes), ctx.ReqID)
+		return nil
+	}
+
+	if err := db.Table(tableName).CreateIndex(ctx, index); err != nil {
+		blog.Errorf("[createUniqueIndex] create index %+v on %s failed, err: %v, rid: %s", index, tableName, err, ctx.ReqID)
+		if db.IsDuplicatedError(err) {
+			return ctx.Error.Errorf(common.CCErrCommDuplicateItem, "instance")
+		}
+		return ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	return nil
+}
+
+// dropUniqueIndex drops the db unique index which backs the unique rule if it exists
+func dropUniqueIndex(ctx core.ContextParams, db dal.RDB, objID string, id uint64) error {
+	tableName := common.GetInstTableName(objID)
+	indexes, err := db.Table(tableName).Indexes(ctx)
+	if err != nil {
+		blog.Errorf("[dropUniqueIndex] get indexes of %s failed, err: %v, rid: %s", tableName, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	name := metadata.UniqueIndexName(id)
+	for _, index := range indexes {
+		if index.Name != name {
+			continue
+		}
+		if err := db.Table(tableName).DropIndex(ctx, name); err != nil {
+			blog.Errorf("[dropUniqueIndex] drop index %s of %s failed, err: %v, rid: %s", name, tableName, err, ctx.ReqID)
+			return ctx.Error.Error(common.CCErrObjectDBOpErrno)
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,594 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+type rbac struct {
+	dbProxy dal.RDB
+}
+
+// New create a new rbac operation instance, which keeps the roles, role bindings
+// and user groups used by the local rbac authorizer
+func New(dbProxy dal.RDB) core.RBACOperation {
+	return &rbac{
+		dbProxy: dbProxy,
+	}
+}
+
+const (
+	fieldName        = "name"
+	fieldMembers     = "members"
+	fieldRoleID      = "role_id"
+	fieldSubjectType = "subject_type"
+	fieldSubject     = "subject"
+)
+
+func (r *rbac) CreateRole(ctx core.ContextParams, option metadata.RBACRoleOption) (metadata.RBACRole, errors.CCErrorCoder) {
+	now := time.Now()
+	role := metadata.RBACRole{
+		Name:            option.Name,
+		Description:     option.Description,
+		Grants:          option.Grants,
+		Creator:         ctx.User,
+		Modifier:        ctx.User,
+		CreateTime:      now,
+		LastTime:        now,
+		SupplierAccount: ctx.SupplierAccount,
+	}
+	if key, err := option.Validate(); err != nil {
+		blog.Errorf("CreateRole failed, parameter invalid, key: %s, err: %v, rid: %s", key, err, ctx.ReqID)
+		return role, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+	if role.Grants == nil {
+		role.Grants = make([]metadata.RBACGrant, 0)
+	}
+
+	if err := r.checkNameUnique(ctx, common.BKTableNameAuthRole, role.Name, 0); err != nil {
+		return role, err
+	}
+
+	id, err := r.dbProxy.NextSequence(ctx, common.BKTableNameAuthRole)
+	if err != nil {
+		blog.Errorf("CreateRole failed, generate id failed, err: %v, rid: %s", err, ctx.ReqID)
+		return role, ctx.Error.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+	role.ID = int64(id)
+
+	if err := r.dbProxy.Table(common.BKTableNameAuthRole).Insert(ctx.Context, role); err != nil {
+		if r.dbProxy.IsDuplicatedError(err) {
+			return role, ctx.Error.CCErrorf(common.CCErrCommDuplicateItem, fieldName)
+		}
+		blog.Errorf("CreateRole failed, db insert failed, doc: %+v, err: %v, rid: %s", role, err, ctx.ReqID)
+		return role, ctx.Error.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return role, nil
+}
+
+func (r *rbac) UpdateRole(ctx core.ContextParams, id int64, option metadata.RBACRoleOption) (metadata.RBACRole, errors.CCErrorCoder) {
+	role := metadata.RBACRole{}
+	if key, err := option.Validate(); err != nil {
+		blog.Errorf("UpdateRole failed, parameter invalid, key: %s, err: %v, rid: %s", key, err, ctx.ReqID)
+		return role, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+
+	if err := r.getOne(ctx, common.BKTableNameAuthRole, id, &role); err != nil {
+		return role, err
+	}
+	if err := r.checkNameUnique(ctx, common.BKTableNameAuthRole, option.Name, id); err != nil {
+		return role, err
+	}
+
+	role.Name = option.Name
+	role.Description = option.Description
+	role.Grants = option.Grants
+	if role.Grants == nil {
+		role.Grants = make([]metadata.RBACGrant, 0)
+	}
+	role.Modifier = ctx.User
+	role.LastTime = time.Now()
+
+	if err := r.dbProxy.Table(common.BKTableNameAuthRole).Update(ctx.Context, r.idFilter(ctx, id), role); err != nil {
+		blog.Errorf("UpdateRole failed, db update failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return role, ctx.Error.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return role, nil
+}
+
+// DeleteRole deletes the role together with all of its bindings
+func (r *rbac) DeleteRole(ctx core.ContextParams, id int64) errors.CCErrorCoder {
+	role := metadata.RBACRole{}
+	if err := r.getOne(ctx, common.BKTableNameAuthRole, id, &role); err != nil {
+		return err
+	}
+
+	bindingFilter := map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		fieldRoleID:           id,
+	}
+	if err := r.dbProxy.Table(common.BKTableNameAuthRoleBinding).Delete(ctx.Context, bindingFilter); err != nil {
+		blog.Errorf("DeleteRole failed, delete role bindings failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
+	if err := r.dbProxy.Table(common.BKTableNameAuthRole).Delete(ctx.Context, r.idFilter(ctx, id)); err != nil {
+		blog.Errorf("DeleteRole failed, db delete failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+func (r *rbac) ListRole(ctx core.ContextParams, option metadata.ListRBACOption) (metadata.MultipleRBACRole, errors.CCErrorCoder) {
+	result := metadata.MultipleRBACRole{Info: make([]metadata.RBACRole, 0)}
+	filter := r.listFilter(ctx, option)
+	if len(option.Names) > 0 {
+		filter[fieldName] = map[string]interface{}{common.BKDBIN: option.Names}
+	}
+
+	count, err := r.list(ctx, common.BKTableNameAuthRole, filter, option.Page, &result.Info)
+	if err != nil {
+		return result, err
+	}
+	result.Count = count
+	return result, nil
+}
+
+func (r *rbac) CreateRoleBinding(ctx core.ContextParams, option metadata.RBACRoleBindingOption) (metadata.RBACRoleBinding, errors.CCErrorCoder) {
+	now := time.Now()
+	binding := metadata.RBACRoleBinding{
+		RoleID:          option.RoleID,
+		SubjectType:     option.SubjectType,
+		Subject:         option.Subject,
+		BizID:           option.BizID,
+		Creator:         ctx.User,
+		Modifier:        ctx.User,
+		CreateTime:      now,
+		LastTime:        now,
+		SupplierAccount: ctx.SupplierAccount,
+	}
+	if err := r.validateRoleBinding(ctx, option, 0); err != nil {
+		return binding, err
+	}
+
+	id, err := r.dbProxy.NextSequence(ctx, common.BKTableNameAuthRoleBinding)
+	if err != nil {
+		blog.Errorf("CreateRoleBinding failed, generate id failed, err: %v, rid: %s", err, ctx.ReqID)
+		return binding, ctx.Error.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+	binding.ID = int64(id)
+
+	if err := r.dbProxy.Table(common.BKTableNameAuthRoleBinding).Insert(ctx.Context, binding); err != nil {
+		if r.dbProxy.IsDuplicatedError(err) {
+			return binding, ctx.Error.CCErrorf(common.CCErrCommDuplicateItem, fieldSubject)
+		}
+		blog.Errorf("CreateRoleBinding failed, db insert failed, doc: %+v, err: %v, rid: %s", binding, err, ctx.ReqID)
+		return binding, ctx.Error.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return binding, nil
+}
+
+func (r *rbac) UpdateRoleBinding(ctx core.ContextParams, id int64, option metadata.RBACRoleBindingOption) (metadata.RBACRoleBinding, errors.CCErrorCoder) {
+	binding := metadata.RBACRoleBinding{}
+	if err := r.getOne(ctx, common.BKTableNameAuthRoleBinding, id, &binding); err != nil {
+		return binding, err
+	}
+	if err := r.validateRoleBinding(ctx, option, id); err != nil {
+		return binding, err
+	}
+
+	binding.RoleID = option.RoleID
+	binding.SubjectType = option.SubjectType
+	binding.Subject = option.Subject
+	binding.BizID = option.BizID
+	binding.Modifier = ctx.User
+	binding.LastTime = time.Now()
+
+	if err := r.dbProxy.Table(common.BKTableNameAuthRoleBinding).Update(ctx.Context, r.idFilter(ctx, id), binding); err != nil {
+		blog.Errorf("UpdateRoleBinding failed, db update failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return binding, ctx.Error.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return binding, nil
+}
+
+func (r *rbac) DeleteRoleBinding(ctx core.ContextParams, id int64) errors.CCErrorCoder {
+	binding := metadata.RBACRoleBinding{}
+	if err := r.getOne(ctx, common.BKTableNameAuthRoleBinding, id, &binding); err != nil {
+		return err
+	}
+
+	if err := r.dbProxy.Table(common.BKTableNameAuthRoleBinding).Delete(ctx.Context, r.idFilter(ctx, id)); err != nil {
+		blog.Errorf("DeleteRoleBinding failed, db delete failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+func (r *rbac) ListRoleBinding(ctx core.ContextParams, option metadata.ListRBACOption) (metadata.MultipleRBACRoleBinding, errors.CCErrorCoder) {
+	result := metadata.MultipleRBACRoleBinding{Info: make([]metadata.RBACRoleBinding, 0)}
+	filter := r.listFilter(ctx, option)
+	if len(option.RoleIDs) > 0 {
+		filter[fieldRoleID] = map[string]interface{}{common.BKDBIN: option.RoleIDs}
+	}
+	if len(option.SubjectType) > 0 {
+		filter[fieldSubjectType] = option.SubjectType
+	}
+	if len(option.Subjects) > 0 {
+		filter[fieldSubject] = map[string]interface{}{common.BKDBIN: option.Subjects}
+	}
+	if len(option.BizIDs) > 0 {
+		filter[common.BKAppIDField] = map[string]interface{}{common.BKDBIN: option.BizIDs}
+	}
+
+	count, err := r.list(ctx, common.BKTableNameAuthRoleBinding, filter, option.Page, &result.Info)
+	if err != nil {
+		return result, err
+	}
+	result.Count = count
+	return result, nil
+}
+
+func (r *rbac) CreateUserGroup(ctx core.ContextParams, option metadata.RBACUserGroupOption) (metadata.RBACUserGroup, errors.CCErrorCoder) {
+	now := time.Now()
+	group := metadata.RBACUserGroup{
+		Name:            option.Name,
+		Description:     option.Description,
+		Members:         util.StrArrayUnique(option.Members),
+		Creator:         ctx.User,
+		Modifier:        ctx.User,
+		CreateTime:      now,
+		LastTime:        now,
+		SupplierAccount: ctx.SupplierAccount,
+	}
+	if key, err := option.Validate(); err != nil {
+		blog.Errorf("CreateUserGroup failed, parameter invalid, key: %s, err: %v, rid: %s", key, err, ctx.ReqID)
+		return group, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+
+	if err := r.checkNameUnique(ctx, common.BKTableNameAuthUserGroup, group.Name, 0); err != nil {
+		return group, err
+	}
+
+	id, err := r.dbProxy.NextSequence(ctx, common.BKTableNameAuthUserGroup)
+	if err != nil {
+		blog.Errorf("CreateUserGroup failed, generate id failed, err: %v, rid: %s", err, ctx.ReqID)
+		return group, ctx.Error.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+	group.ID = int64(id)
+
+	if err := r.dbProxy.Table(common.BKTableNameAuthUserGroup).Insert(ctx.Context, group); err != nil {
+		if r.dbProxy.IsDuplicatedError(err) {
+			return group, ctx.Error.CCErrorf(common.CCErrCommDuplicateItem, fieldName)
+		}
+		blog.Errorf("CreateUserGroup failed, db insert failed, doc: %+v, err: %v, rid: %s", group, err, ctx.ReqID)
+		return group, ctx.Error.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return group, nil
+}
+
+// UpdateUserGroup updates the user group, the bindings of the group follow its new name
+func (r *rbac) UpdateUserGroup(ctx core.ContextParams, id int64, option metadata.RBACUserGroupOption) (metadata.RBACUserGroup, errors.CCErrorCoder) {
+	group := metadata.RBACUserGroup{}
+	if key, err := option.Validate(); err != nil {
+		blog.Errorf("UpdateUserGroup failed, parameter invalid, key: %s, err: %v, rid: %s", key, err, ctx.ReqID)
+		return group, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+
+	if err := r.getOne(ctx, common.BKTableNameAuthUserGroup, id, &group); err != nil {
+		return group, err
+	}
+	if err := r.checkNameUnique(ctx, common.BKTableNameAuthUserGroup, option.Name, id); err != nil {
+		return group, err
+	}
+
+	if group.Name != option.Name {
+		filter := r.groupBindingFilter(ctx, group.Name)
+		doc := map[string]interface{}{fieldSubject: option.Name}
+		if err := r.dbProxy.Table(common.BKTableNameAuthRoleBinding).Update(ctx.Context, filter, doc); err != nil {
+			blog.Errorf("UpdateUserGroup failed, rename the group of role bindings failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+			return group, ctx.Error.CCError(common.CCErrCommDBUpdateFailed)
+		}
+	}
+
+	group.Name = option.Name
+	group.Description = option.Description
+	group.Members = util.StrArrayUnique(option.Members)
+	group.Modifier = ctx.User
+	group.LastTime = time.Now()
+
+	if err := r.dbProxy.Table(common.BKTableNameAuthUserGroup).Update(ctx.Context, r.idFilter(ctx, id), group); err != nil {
+		blog.Errorf("UpdateUserGroup failed, db update failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return group, ctx.Error.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return group, nil
+}
+
+// DeleteUserGroup deletes the user group together with the role bindings of the group
+func (r *rbac) DeleteUserGroup(ctx core.ContextParams, id int64) errors.CCErrorCoder {
+	group := metadata.RBACUserGroup{}
+	if err := r.getOne(ctx, common.BKTableNameAuthUserGroup, id, &group); err != nil {
+		return err
+	}
+
+	if err := r.dbProxy.Table(common.BKTableNameAuthRoleBinding).Delete(ctx.Context, r.groupBindingFilter(ctx, group.Name)); err != nil {
+		blog.Errorf("DeleteUserGroup failed, delete role bindings failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
+	if err := r.dbProxy.Table(common.BKTableNameAuthUserGroup).Delete(ctx.Context, r.idFilter(ctx, id)); err != nil {
+		blog.Errorf("DeleteUserGroup failed, db delete failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+func (r *rbac) ListUserGroup(ctx core.ContextParams, option metadata.ListRBACOption) (metadata.MultipleRBACUserGroup, errors.CCErrorCoder) {
+	result := metadata.MultipleRBACUserGroup{Info: make([]metadata.RBACUserGroup, 0)}
+	filter := r.listFilter(ctx, option)
+	if len(option.Names) > 0 {
+		filter[fieldName] = map[string]interface{}{common.BKDBIN: option.Names}
+	}
+
+	count, err := r.list(ctx, common.BKTableNameAuthUserGroup, filter, option.Page, &result.Info)
+	if err != nil {
+		return result, err
+	}
+	result.Count = count
+	return result, nil
+}
+
+// GetUserPolicy collects the grants of the roles bound to the user directly or through his groups
+func (r *rbac) GetUserPolicy(ctx core.ContextParams, user string) (metadata.RBACUserPolicy, errors.CCErrorCoder) {
+	policy := metadata.RBACUserPolicy{
+		User:   user,
+		Groups: make([]string, 0),
+		Items:  make([]metadata.RBACPolicyItem, 0),
+	}
+	if len(user) == 0 {
+		return policy, ctx.Error.CCErrorf(common.CCErrCommParamsNeedSet, "bk_username")
+	}
+
+	groups := make([]metadata.RBACUserGroup, 0)
+	groupFilter := map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		fieldMembers:          user,
+	}
+	if err := r.dbProxy.Table(common.BKTableNameAuthUserGroup).Find(groupFilter).Fields(fieldName).All(ctx.Context, &groups); err != nil {
+		blog.Errorf("GetUserPolicy failed, find user groups failed, filter: %+v, err: %v, rid: %s", groupFilter, err, ctx.ReqID)
+		return policy, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	for _, group := range groups {
+		policy.Groups = append(policy.Groups, group.Name)
+	}
+
+	subjects := []map[string]interface{}{
+		{fieldSubjectType: metadata.RBACSubjectUser, fieldSubject: user},
+	}
+	if len(policy.Groups) > 0 {
+		subjects = append(subjects, map[string]interface{}{
+			fieldSubjectType: metadata.RBACSubjectGroup,
+			fieldSubject:     map[string]interface{}{common.BKDBIN: policy.Groups},
+		})
+	}
+	bindingFilter := map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		common.BKDBOR:         subjects,
+	}
+	bindings := make([]metadata.RBACRoleBinding, 0)
+	if err := r.dbProxy.Table(common.BKTableNameAuthRoleBinding).Find(bindingFilter).All(ctx.Context, &bindings); err != nil {
+		blog.Errorf("GetUserPolicy failed, find role bindings failed, filter: %+v, err: %v, rid: %s", bindingFilter, err, ctx.ReqID)
+		return policy, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(bindings) == 0 {
+		return policy, nil
+	}
+
+	roleIDs := make([]int64, 0)
+	for _, binding := range bindings {
+		roleIDs = append(roleIDs, binding.RoleID)
+	}
+	roleFilter := map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		common.BKFieldID:      map[string]interface{}{common.BKDBIN: util.IntArrayUnique(roleIDs)},
+	}
+	roles := make([]metadata.RBACRole, 0)
+	if err := r.dbProxy.Table(common.BKTableNameAuthRole).Find(roleFilter).All(ctx.Context, &roles); err != nil {
+		blog.Errorf("GetUserPolicy failed, find roles failed, filter: %+v, err: %v, rid: %s", roleFilter, err, ctx.ReqID)
+		return policy, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	roleMap := make(map[int64]metadata.RBACRole)
+	for _, role := range roles {
+		roleMap[role.ID] = role
+	}
+
+	// the same role may be bound in a business through several subjects, keep one of them
+	type itemKey struct {
+		bizID  int64
+		roleID int64
+	}
+	added := make(map[itemKey]bool)
+	for _, binding := range bindings {
+		role, exist := roleMap[binding.RoleID]
+		if !exist {
+			continue
+		}
+		key := itemKey{bizID: binding.BizID, roleID: binding.RoleID}
+		if added[key] {
+			continue
+		}
+		added[key] = true
+		policy.Items = append(policy.Items, metadata.RBACPolicyItem{
+			BizID:  binding.BizID,
+			RoleID: role.ID,
+			Grants: role.Grants,
+		})
+	}
+	return policy, nil
+}
+
+func (r *rbac) validateRoleBinding(ctx core.ContextParams, option metadata.RBACRoleBindingOption, id int64) errors.CCErrorCoder {
+	if key, err := option.Validate(); err != nil {
+		blog.Errorf("validate role binding failed, parameter invalid, key: %s, err: %v, rid: %s", key, err, ctx.ReqID)
+		return ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+
+	role := metadata.RBACRole{}
+	if err := r.getOne(ctx, common.BKTableNameAuthRole, option.RoleID, &role); err != nil {
+		if err.GetCode() == common.CCErrCommNotFound {
+			return ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, fieldRoleID)
+		}
+		return err
+	}
+
+	if option.SubjectType == metadata.RBACSubjectGroup {
+		groupFilter := map[string]interface{}{
+			common.BKOwnerIDField: ctx.SupplierAccount,
+			fieldName:             option.Subject,
+		}
+		count, err := r.dbProxy.Table(common.BKTableNameAuthUserGroup).Find(groupFilter).Count(ctx.Context)
+		if err != nil {
+			blog.Errorf("validate role binding failed, count user group failed, filter: %+v, err: %v, rid: %s", groupFilter, err, ctx.ReqID)
+			return ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+		}
+		if count == 0 {
+			return ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, fieldSubject)
+		}
+	}
+
+	if option.BizID > 0 {
+		bizFilter := map[string]interface{}{
+			common.BKOwnerIDField: ctx.SupplierAccount,
+			common.BKAppIDField:   option.BizID,
+		}
+		count, err := r.dbProxy.Table(common.BKTableNameBaseApp).Find(bizFilter).Count(ctx.Context)
+		if err != nil {
+			blog.Errorf("validate role binding failed, count business failed, filter: %+v, err: %v, rid: %s", bizFilter, err, ctx.ReqID)
+			return ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+		}
+		if count == 0 {
+			return ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+		}
+	}
+
+	dupFilter := map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		fieldRoleID:           option.RoleID,
+		fieldSubjectType:      option.SubjectType,
+		fieldSubject:          option.Subject,
+		common.BKAppIDField:   option.BizID,
+		common.BKFieldID:      map[string]interface{}{common.BKDBNE: id},
+	}
+	count, err := r.dbProxy.Table(common.BKTableNameAuthRoleBinding).Find(dupFilter).Count(ctx.Context)
+	if err != nil {
+		blog.Errorf("validate role binding failed, count role bindings failed, filter: %+v, err: %v, rid: %s", dupFilter, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count > 0 {
+		return ctx.Error.CCErrorf(common.CCErrCommDuplicateItem, fieldSubject)
+	}
+	return nil
+}
+
+// checkNameUnique checks there is no other role or user group than the one with the id using the name
+func (r *rbac) checkNameUnique(ctx core.ContextParams, table, name string, id int64) errors.CCErrorCoder {
+	filter := map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		fieldName:             name,
+		common.BKFieldID:      map[string]interface{}{common.BKDBNE: id},
+	}
+	count, err := r.dbProxy.Table(table).Find(filter).Count(ctx.Context)
+	if err != nil {
+		blog.Errorf("check name unique failed, table: %s, filter: %+v, err: %v, rid: %s", table, filter, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count > 0 {
+		return ctx.Error.CCErrorf(common.CCErrCommDuplicateItem, fieldName)
+	}
+	return nil
+}
+
+func (r *rbac) getOne(ctx core.ContextParams, table string, id int64, result interface{}) errors.CCErrorCoder {
+	if err := r.dbProxy.Table(table).Find(r.idFilter(ctx, id)).One(ctx.Context, result); err != nil {
+		if r.dbProxy.IsNotFoundError(err) {
+			blog.Errorf("get %s failed, not found, id: %d, rid: %s", table, id, ctx.ReqID)
+			return ctx.Error.CCError(common.CCErrCommNotFound)
+		}
+		blog.Errorf("get %s failed, db select failed, id: %d, err: %v, rid: %s", table, id, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return nil
+}
+
+func (r *rbac) list(ctx core.ContextParams, table string, filter map[string]interface{}, page metadata.BasePage, result interface{}) (int64, errors.CCErrorCoder) {
+	if page.Limit > common.BKMaxPageSize && page.Limit != common.BKNoLimit {
+		return 0, ctx.Error.CCError(common.CCErrCommPageLimitIsExceeded)
+	}
+
+	query := r.dbProxy.Table(table).Find(filter)
+	total, err := query.Count(ctx.Context)
+	if err != nil {
+		blog.ErrorJSON("list %s failed, db count failed, filter: %s, err: %s, rid: %s", table, filter, err.Error(), ctx.ReqID)
+		return 0, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(page.Sort) > 0 {
+		query = query.Sort(page.Sort)
+	} else {
+		query = query.Sort(common.BKFieldID)
+	}
+	if page.Limit > 0 {
+		query = query.Limit(uint64(page.Limit))
+	}
+	if page.Start > 0 {
+		query = query.Start(uint64(page.Start))
+	}
+	if err := query.All(ctx.Context, result); err != nil {
+		blog.ErrorJSON("list %s failed, db select failed, filter: %s, err: %s, rid: %s", table, filter, err.Error(), ctx.ReqID)
+		return 0, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return int64(total), nil
+}
+
+func (r *rbac) listFilter(ctx core.ContextParams, option metadata.ListRBACOption) map[string]interface{} {
+	filter := map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+	}
+	if len(option.IDs) > 0 {
+		filter[common.BKFieldID] = map[string]interface{}{common.BKDBIN: option.IDs}
+	}
+	return filter
+}
+
+func (r *rbac) idFilter(ctx core.ContextParams, id int64) map[string]interface{} {
+	return map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		common.BKFieldID:      id,
+	}
+}
+
+func (r *rbac) groupBindingFilter(ctx core.ContextParams, group string) map[string]interface{} {
+	return map[string]interface{}{
+		common.BKOwnerIDField: ctx.SupplierAccount,
+		fieldSubjectType:      metadata.RBACSubjectGroup,
+		fieldSubject:          group,
+	}
+}
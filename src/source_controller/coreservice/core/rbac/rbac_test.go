@@ -0,0 +1,112 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal/mongo/local"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestContext(t *testing.T) core.ContextParams {
+	errFactory, err := errors.NewFactory("../../../../../resources/errors/")
+	require.NoError(t, err)
+	return core.ContextParams{
+		Context:         context.Background(),
+		ReqID:           "test_req_id",
+		SupplierAccount: "0",
+		User:            "admin",
+		Error:           errFactory.CreateDefaultCCErrorIf("en"),
+	}
+}
+
+func TestUserPolicy(t *testing.T) {
+	ctx := newTestContext(t)
+	db := local.NewMemory()
+	require.NoError(t, db.Table(common.BKTableNameBaseApp).Insert(ctx.Context, map[string]interface{}{
+		common.BKAppIDField:   int64(2),
+		common.BKOwnerIDField: "0",
+	}))
+	r := New(db)
+
+	viewer, ccErr := r.CreateRole(ctx, metadata.RBACRoleOption{
+		Name:   "viewer",
+		Grants: []metadata.RBACGrant{{ResourceType: "hostInstance", Actions: []string{"find"}}},
+	})
+	require.NoError(t, ccErr)
+	operator, ccErr := r.CreateRole(ctx, metadata.RBACRoleOption{
+		Name:   "operator",
+		Grants: []metadata.RBACGrant{{ResourceType: "*", Actions: []string{"update"}}},
+	})
+	require.NoError(t, ccErr)
+	_, ccErr = r.CreateRole(ctx, metadata.RBACRoleOption{Name: "viewer"})
+	require.Error(t, ccErr)
+
+	_, ccErr = r.CreateUserGroup(ctx, metadata.RBACUserGroupOption{Name: "ops", Members: []string{"tom", "jerry", "tom"}})
+	require.NoError(t, ccErr)
+
+	_, ccErr = r.CreateRoleBinding(ctx, metadata.RBACRoleBindingOption{
+		RoleID: viewer.ID, SubjectType: metadata.RBACSubjectUser, Subject: "tom",
+	})
+	require.NoError(t, ccErr)
+	_, ccErr = r.CreateRoleBinding(ctx, metadata.RBACRoleBindingOption{
+		RoleID: operator.ID, SubjectType: metadata.RBACSubjectGroup, Subject: "ops", BizID: 2,
+	})
+	require.NoError(t, ccErr)
+
+	// the binding already exists
+	_, ccErr = r.CreateRoleBinding(ctx, metadata.RBACRoleBindingOption{
+		RoleID: viewer.ID, SubjectType: metadata.RBACSubjectUser, Subject: "tom",
+	})
+	require.Error(t, ccErr)
+	// the business does not exist
+	_, ccErr = r.CreateRoleBinding(ctx, metadata.RBACRoleBindingOption{
+		RoleID: viewer.ID, SubjectType: metadata.RBACSubjectUser, Subject: "tom", BizID: 3,
+	})
+	require.Error(t, ccErr)
+	// the group does not exist
+	_, ccErr = r.CreateRoleBinding(ctx, metadata.RBACRoleBindingOption{
+		RoleID: viewer.ID, SubjectType: metadata.RBACSubjectGroup, Subject: "dev",
+	})
+	require.Error(t, ccErr)
+
+	policy, ccErr := r.GetUserPolicy(ctx, "tom")
+	require.NoError(t, ccErr)
+	require.Equal(t, []string{"ops"}, policy.Groups)
+	require.Len(t, policy.Items, 2)
+
+	policy, ccErr = r.GetUserPolicy(ctx, "jerry")
+	require.NoError(t, ccErr)
+	require.Len(t, policy.Items, 1)
+	require.Equal(t, int64(2), policy.Items[0].BizID)
+	require.Equal(t, operator.ID, policy.Items[0].RoleID)
+
+	// the bindings of the group are deleted with it
+	require.NoError(t, r.DeleteUserGroup(ctx, 1))
+	policy, ccErr = r.GetUserPolicy(ctx, "jerry")
+	require.NoError(t, ccErr)
+	require.Empty(t, policy.Items)
+
+	// the bindings of the role are deleted with it
+	require.NoError(t, r.DeleteRole(ctx, viewer.ID))
+	bindings, ccErr := r.ListRoleBinding(ctx, metadata.ListRBACOption{Subjects: []string{"tom"}})
+	require.NoError(t, ccErr)
+	require.Equal(t, int64(0), bindings.Count)
+}
@@ -0,0 +1,80 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func (s *coreService) CreateAPIToken(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.APITokenOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("CreateAPIToken failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.APITokenOperation().CreateAPIToken(ctx, option)
+	if err != nil {
+		blog.Errorf("CreateAPIToken failed, option: %+v, err: %v, rid: %s", option, err, ctx.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) ListAPIToken(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ListAPITokenOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("ListAPIToken failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.APITokenOperation().ListAPIToken(ctx, option)
+	if err != nil {
+		blog.Errorf("ListAPIToken failed, option: %+v, err: %v, rid: %s", option, err, ctx.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) DeleteAPIToken(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+	}
+
+	if err := s.core.APITokenOperation().DeleteAPIToken(ctx, id); err != nil {
+		blog.Errorf("DeleteAPIToken failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *coreService) ValidateAPIToken(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ValidateAPITokenOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("ValidateAPIToken failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.APITokenOperation().ValidateAPIToken(ctx, option)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -35,3 +35,8 @@ func (s *coreService) IsInstanceExist(ctx core.ContextParams, objID string, inst
 	}
 	return true, nil
 }
+
+// ValidAssociationUnique used to check the unique rules with the association keys when the association changes
+func (s *coreService) ValidAssociationUnique(ctx core.ContextParams, objID string, instID uint64, objAsstID string, asstInstID int64) error {
+	return s.core.InstanceOperation().ValidAssociationUnique(ctx, objID, instID, objAsstID, asstInstID)
+}
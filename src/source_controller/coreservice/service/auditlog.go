@@ -42,3 +42,16 @@ func (s *coreService) SearchAuditLog(ctx core.ContextParams, pathParams, queryPa
 		Info:  auditlogs,
 	}, err
 }
+
+// SealAuditChain chains the new audit logs to the hash chain, it's called by the cron job periodically
+func (s *coreService) SealAuditChain(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	sealed, err := s.core.AuditOperation().SealAuditChain(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mapstr.MapStr{"sealed": sealed}, nil
+}
+
+func (s *coreService) VerifyAuditChain(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	return s.core.AuditOperation().VerifyAuditChain(ctx)
+}
@@ -0,0 +1,45 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func (s *coreService) FullTextSearch(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.FullTextSearchOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("FullTextSearch failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.FullTextOperation().Search(ctx, option)
+	if err != nil {
+		blog.Errorf("FullTextSearch failed, option: %+v, err: %v, rid: %s", option, err, ctx.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+// SyncTextIndexes rebuilds the text indexes on the searchable attributes, it's called by the cron job periodically
+func (s *coreService) SyncTextIndexes(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	if err := s.core.FullTextOperation().SyncTextIndexes(ctx); err != nil {
+		blog.Errorf("SyncTextIndexes failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil, nil
+}
@@ -0,0 +1,59 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func (s *coreService) SearchHostDrift(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	hostID, err := strconv.ParseInt(pathParams(common.BKHostIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("SearchHostDrift failed, parse host id failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKHostIDField)
+	}
+	option := meta.SearchHostDriftOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("SearchHostDrift failed, decode body failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+	option.HostID = hostID
+
+	drifts, ccErr := s.core.HostOperation().SearchHostDrift(params, option)
+	if ccErr != nil {
+		blog.Errorf("SearchHostDrift failed, call host operation failed, err: %s, rid: %s", ccErr.Error(), params.ReqID)
+		return nil, ccErr
+	}
+	return drifts, nil
+}
+
+func (s *coreService) ListDriftedHosts(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := meta.ListDriftedHostsOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("ListDriftedHosts failed, decode body failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	hosts, ccErr := s.core.HostOperation().ListDriftedHosts(params, option)
+	if ccErr != nil {
+		blog.Errorf("ListDriftedHosts failed, call host operation failed, err: %s, rid: %s", ccErr.Error(), params.ReqID)
+		return nil, ccErr
+	}
+	return hosts, nil
+}
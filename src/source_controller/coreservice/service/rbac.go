@@ -0,0 +1,235 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func parseRBACID(ctx core.ContextParams, pathParams ParamsGetter) (int64, error) {
+	id, err := strconv.ParseInt(pathParams(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+	}
+	return id, nil
+}
+
+func (s *coreService) CreateRBACRole(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.RBACRoleOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("CreateRBACRole failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.RBACOperation().CreateRole(ctx, option)
+	if err != nil {
+		blog.Errorf("CreateRBACRole failed, option: %+v, err: %v, rid: %s", option, err, ctx.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) UpdateRBACRole(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(ctx, pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	option := metadata.RBACRoleOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("UpdateRBACRole failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, ccErr := s.core.RBACOperation().UpdateRole(ctx, id, option)
+	if ccErr != nil {
+		blog.Errorf("UpdateRBACRole failed, id: %d, option: %+v, err: %v, rid: %s", id, option, ccErr, ctx.ReqID)
+		return nil, ccErr
+	}
+	return result, nil
+}
+
+func (s *coreService) DeleteRBACRole(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(ctx, pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.core.RBACOperation().DeleteRole(ctx, id); err != nil {
+		blog.Errorf("DeleteRBACRole failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *coreService) ListRBACRole(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ListRBACOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("ListRBACRole failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.RBACOperation().ListRole(ctx, option)
+	if err != nil {
+		blog.Errorf("ListRBACRole failed, option: %+v, err: %v, rid: %s", option, err, ctx.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) CreateRBACRoleBinding(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.RBACRoleBindingOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("CreateRBACRoleBinding failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.RBACOperation().CreateRoleBinding(ctx, option)
+	if err != nil {
+		blog.Errorf("CreateRBACRoleBinding failed, option: %+v, err: %v, rid: %s", option, err, ctx.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) UpdateRBACRoleBinding(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(ctx, pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	option := metadata.RBACRoleBindingOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("UpdateRBACRoleBinding failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, ccErr := s.core.RBACOperation().UpdateRoleBinding(ctx, id, option)
+	if ccErr != nil {
+		blog.Errorf("UpdateRBACRoleBinding failed, id: %d, option: %+v, err: %v, rid: %s", id, option, ccErr, ctx.ReqID)
+		return nil, ccErr
+	}
+	return result, nil
+}
+
+func (s *coreService) DeleteRBACRoleBinding(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(ctx, pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.core.RBACOperation().DeleteRoleBinding(ctx, id); err != nil {
+		blog.Errorf("DeleteRBACRoleBinding failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *coreService) ListRBACRoleBinding(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ListRBACOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("ListRBACRoleBinding failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.RBACOperation().ListRoleBinding(ctx, option)
+	if err != nil {
+		blog.Errorf("ListRBACRoleBinding failed, option: %+v, err: %v, rid: %s", option, err, ctx.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) CreateRBACUserGroup(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.RBACUserGroupOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("CreateRBACUserGroup failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.RBACOperation().CreateUserGroup(ctx, option)
+	if err != nil {
+		blog.Errorf("CreateRBACUserGroup failed, option: %+v, err: %v, rid: %s", option, err, ctx.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) UpdateRBACUserGroup(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(ctx, pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	option := metadata.RBACUserGroupOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("UpdateRBACUserGroup failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, ccErr := s.core.RBACOperation().UpdateUserGroup(ctx, id, option)
+	if ccErr != nil {
+		blog.Errorf("UpdateRBACUserGroup failed, id: %d, option: %+v, err: %v, rid: %s", id, option, ccErr, ctx.ReqID)
+		return nil, ccErr
+	}
+	return result, nil
+}
+
+func (s *coreService) DeleteRBACUserGroup(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := parseRBACID(ctx, pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.core.RBACOperation().DeleteUserGroup(ctx, id); err != nil {
+		blog.Errorf("DeleteRBACUserGroup failed, id: %d, err: %v, rid: %s", id, err, ctx.ReqID)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *coreService) ListRBACUserGroup(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ListRBACOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("ListRBACUserGroup failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.RBACOperation().ListUserGroup(ctx, option)
+	if err != nil {
+		blog.Errorf("ListRBACUserGroup failed, option: %+v, err: %v, rid: %s", option, err, ctx.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) GetRBACUserPolicy(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.RBACUserPolicyOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("GetRBACUserPolicy failed, decode body failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.RBACOperation().GetUserPolicy(ctx, option.User)
+	if err != nil {
+		blog.Errorf("GetRBACUserPolicy failed, user: %s, err: %v, rid: %s", option.User, err, ctx.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
@@ -33,9 +33,11 @@ import (
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/app/options"
 	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/apitoken"
 	"configcenter/src/source_controller/coreservice/core/association"
 	"configcenter/src/source_controller/coreservice/core/auditlog"
 	"configcenter/src/source_controller/coreservice/core/datasynchronize"
+	"configcenter/src/source_controller/coreservice/core/fulltext"
 	"configcenter/src/source_controller/coreservice/core/host"
 	"configcenter/src/source_controller/coreservice/core/hostapplyrule"
 	"configcenter/src/source_controller/coreservice/core/instances"
@@ -44,6 +46,7 @@ import (
 	"configcenter/src/source_controller/coreservice/core/model"
 	"configcenter/src/source_controller/coreservice/core/operation"
 	"configcenter/src/source_controller/coreservice/core/process"
+	"configcenter/src/source_controller/coreservice/core/rbac"
 	"configcenter/src/source_controller/coreservice/core/settemplate"
 	dbSystem "configcenter/src/source_controller/coreservice/core/system"
 	"configcenter/src/storage/dal"
@@ -122,13 +125,16 @@ func (s *coreService) SetConfig(cfg options.Config, engin *backbone.Engine, err
 		datasynchronize.New(db, s),
 		mainline.New(db, s.language),
 		host.New(db, cache, s, hostApplyRuleCore),
-		auditlog.New(db),
+		auditlog.New(db, cache),
 		process.New(db, s, cache),
 		label.New(db),
 		settemplate.New(db),
 		operation.New(db),
 		hostApplyRuleCore,
 		dbSystem.New(db),
+		fulltext.New(db),
+		rbac.New(db),
+		apitoken.New(db),
 	)
 	return nil
 }
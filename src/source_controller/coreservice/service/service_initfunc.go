@@ -149,6 +149,9 @@ func (s *coreService) host() {
 
 	s.addAction(http.MethodPost, "/findmany/hosts/list_hosts", s.ListHosts, nil)
 	s.addAction(http.MethodPut, "/updatemany/hosts/cloudarea_field", s.UpdateHostCloudAreaField, nil)
+
+	s.addAction(http.MethodPost, "/findmany/host/drift/{bk_host_id}", s.SearchHostDrift, nil)
+	s.addAction(http.MethodPost, "/findmany/hosts/drift/list_hosts", s.ListDriftedHosts, nil)
 }
 
 func (s *coreService) initCloudSync() {
@@ -169,6 +172,8 @@ func (s *coreService) initCloudSync() {
 func (s *coreService) audit() {
 	s.addAction(http.MethodPost, "/create/auditlog", s.CreateAuditLog, nil)
 	s.addAction(http.MethodPost, "/read/auditlog", s.SearchAuditLog, nil)
+	s.addAction(http.MethodPost, "/update/auditlog/chain/seal", s.SealAuditChain, nil)
+	s.addAction(http.MethodPost, "/read/auditlog/chain/verify", s.VerifyAuditChain, nil)
 }
 
 func (s *coreService) initOperation() {
@@ -195,6 +200,34 @@ func (s *coreService) topographics() {
 	s.addAction(http.MethodPost, "/topographics/update", s.UpdateTopoGraphics, nil)
 }
 
+func (s *coreService) fullText() {
+	s.addAction(http.MethodPost, "/find/fulltext", s.FullTextSearch, nil)
+	s.addAction(http.MethodPost, "/update/fulltext/index", s.SyncTextIndexes, nil)
+}
+
+func (s *coreService) rbac() {
+	s.addAction(http.MethodPost, "/create/auth/role", s.CreateRBACRole, nil)
+	s.addAction(http.MethodPut, "/update/auth/role/{id}", s.UpdateRBACRole, nil)
+	s.addAction(http.MethodDelete, "/delete/auth/role/{id}", s.DeleteRBACRole, nil)
+	s.addAction(http.MethodPost, "/findmany/auth/role", s.ListRBACRole, nil)
+	s.addAction(http.MethodPost, "/create/auth/role_binding", s.CreateRBACRoleBinding, nil)
+	s.addAction(http.MethodPut, "/update/auth/role_binding/{id}", s.UpdateRBACRoleBinding, nil)
+	s.addAction(http.MethodDelete, "/delete/auth/role_binding/{id}", s.DeleteRBACRoleBinding, nil)
+	s.addAction(http.MethodPost, "/findmany/auth/role_binding", s.ListRBACRoleBinding, nil)
+	s.addAction(http.MethodPost, "/create/auth/user_group", s.CreateRBACUserGroup, nil)
+	s.addAction(http.MethodPut, "/update/auth/user_group/{id}", s.UpdateRBACUserGroup, nil)
+	s.addAction(http.MethodDelete, "/delete/auth/user_group/{id}", s.DeleteRBACUserGroup, nil)
+	s.addAction(http.MethodPost, "/findmany/auth/user_group", s.ListRBACUserGroup, nil)
+	s.addAction(http.MethodPost, "/find/auth/user_policy", s.GetRBACUserPolicy, nil)
+}
+
+func (s *coreService) apiToken() {
+	s.addAction(http.MethodPost, "/create/auth/api_token", s.CreateAPIToken, nil)
+	s.addAction(http.MethodPost, "/findmany/auth/api_token", s.ListAPIToken, nil)
+	s.addAction(http.MethodDelete, "/delete/auth/api_token/{id}", s.DeleteAPIToken, nil)
+	s.addAction(http.MethodPost, "/find/auth/api_token/validate", s.ValidateAPIToken, nil)
+}
+
 func (s *coreService) ccSystem() {
 	s.addAction(http.MethodPost, "/find/system/user_config", s.GetSystemUserConfig, nil)
 }
@@ -217,6 +250,9 @@ func (s *coreService) initService() {
 	s.label()
 	s.topographics()
 	s.ccSystem()
+	s.fullText()
+	s.rbac()
+	s.apiToken()
 	s.initSetTemplate()
 	s.initHostApplyRule()
 }
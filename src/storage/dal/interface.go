@@ -32,6 +32,8 @@ var (
 
 	UpdateOpAddToSet = "addToSet"
 	UpdateOpPull     = "pull"
+	UpdateOpSet      = "set"
+	UpdateOpInc      = "inc"
 )
 
 // RDB rename the RDB into DB
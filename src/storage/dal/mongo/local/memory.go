@@ -0,0 +1,762 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/types"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Memory implement dal.DB interface with an in-memory document store,
+// the filters and update operators are evaluated the way mongodb does,
+// which makes it suitable for unit tests of the logics built on dal.
+type Memory struct {
+	store *memoryStore
+}
+
+var _ dal.DB = new(Memory)
+var _ dal.Transcation = new(Memory)
+
+// memoryStore is shared by all the clones of a Memory db
+type memoryStore struct {
+	lock      sync.RWMutex
+	tables    map[string]*memoryTable
+	sequences map[string]uint64
+	// snapshot is the store data when the transaction started, nil if not in transaction
+	snapshot *memorySnapshot
+	txn      *types.Transaction
+}
+
+type memorySnapshot struct {
+	tables    map[string]*memoryTable
+	sequences map[string]uint64
+}
+
+type memoryTable struct {
+	docs    []bson.M
+	indexes []dal.Index
+}
+
+// NewMemory returns a new empty in-memory DB
+func NewMemory() *Memory {
+	return &Memory{
+		store: &memoryStore{
+			tables:    map[string]*memoryTable{},
+			sequences: map[string]uint64{},
+		},
+	}
+}
+
+// Close replica client
+func (c *Memory) Close() error {
+	return nil
+}
+
+// Ping replica client
+func (c *Memory) Ping() error {
+	return nil
+}
+
+// Clone return the new client, the clones share the same data
+func (c *Memory) Clone() dal.DB {
+	return &Memory{store: c.store}
+}
+
+// IsDuplicatedError check duplicated error
+func (c *Memory) IsDuplicatedError(err error) bool {
+	return err == dal.ErrDuplicated || mgo.IsDup(err)
+}
+
+// IsNotFoundError check the not found error
+func (c *Memory) IsNotFoundError(err error) bool {
+	return err == dal.ErrDocumentNotFound
+}
+
+// Table collection operation
+func (c *Memory) Table(collName string) dal.Table {
+	return &MemoryCollection{collName: collName, Memory: c}
+}
+
+// NextSequence 获取新序列号(非事务)
+func (c *Memory) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	c.store.sequences[sequenceName]++
+	return c.store.sequences[sequenceName], nil
+}
+
+// HasTable 判断是否存在集合
+func (c *Memory) HasTable(collName string) (bool, error) {
+	c.store.lock.RLock()
+	defer c.store.lock.RUnlock()
+	_, ok := c.store.tables[collName]
+	return ok, nil
+}
+
+// DropTable 移除集合
+func (c *Memory) DropTable(collName string) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	delete(c.store.tables, collName)
+	return nil
+}
+
+// CreateTable 创建集合
+func (c *Memory) CreateTable(collName string) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	if _, ok := c.store.tables[collName]; ok {
+		return fmt.Errorf("collection %s already exists", collName)
+	}
+	c.store.table(collName)
+	return nil
+}
+
+// Start 开启新事务, the whole store is snapshotted and restored on abort
+func (c *Memory) Start(ctx context.Context) (dal.Transcation, error) {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	if c.store.snapshot != nil {
+		return nil, dal.ErrTransactionStated
+	}
+
+	snapshot := &memorySnapshot{
+		tables:    make(map[string]*memoryTable, len(c.store.tables)),
+		sequences: make(map[string]uint64, len(c.store.sequences)),
+	}
+	for name, table := range c.store.tables {
+		snapshot.tables[name] = table.clone()
+	}
+	for name, seq := range c.store.sequences {
+		snapshot.sequences[name] = seq
+	}
+	c.store.snapshot = snapshot
+	now := time.Now()
+	c.store.txn = &types.Transaction{
+		TxnID:      bson.NewObjectId().Hex(),
+		RequestID:  util.GetStrByInterface(ctx.Value(common.ContextRequestIDField)),
+		Status:     types.TxStatusOnProgress,
+		CreateTime: now,
+		LastTime:   now,
+	}
+	return c, nil
+}
+
+// Commit 提交事务
+func (c *Memory) Commit(ctx context.Context) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	if c.store.snapshot == nil {
+		return dal.ErrTransactionNotFound
+	}
+	c.store.snapshot = nil
+	c.store.txn = nil
+	return nil
+}
+
+// Abort 取消事务
+func (c *Memory) Abort(ctx context.Context) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	if c.store.snapshot == nil {
+		return dal.ErrTransactionNotFound
+	}
+	c.store.tables = c.store.snapshot.tables
+	c.store.sequences = c.store.snapshot.sequences
+	c.store.snapshot = nil
+	c.store.txn = nil
+	return nil
+}
+
+// TxnInfo 当前事务信息，用于事务发起者往下传递
+func (c *Memory) TxnInfo() *types.Transaction {
+	c.store.lock.RLock()
+	defer c.store.lock.RUnlock()
+	if c.store.txn == nil {
+		return &types.Transaction{}
+	}
+	txn := *c.store.txn
+	return &txn
+}
+
+// AutoRun run f in a transaction, abort it if f returns error, otherwise commit it
+func (c *Memory) AutoRun(ctx context.Context, opt dal.TxnWrapperOption, f func(header http.Header) error) error {
+	txn, err := c.Start(ctx)
+	if err != nil {
+		return err
+	}
+	if err := f(txn.TxnInfo().IntoHeader(opt.Header)); err != nil {
+		if abortErr := txn.Abort(ctx); abortErr != nil {
+			blog.Errorf("abort memory transaction failed, err: %v", abortErr)
+		}
+		return err
+	}
+	return txn.Commit(ctx)
+}
+
+// table returns the table, create it if not exists. the caller must hold the write lock
+func (s *memoryStore) table(collName string) *memoryTable {
+	table, ok := s.tables[collName]
+	if !ok {
+		table = &memoryTable{
+			indexes: []dal.Index{{Keys: map[string]int32{"_id": 1}, Name: "_id_", Unique: true}},
+		}
+		s.tables[collName] = table
+	}
+	return table
+}
+
+func (t *memoryTable) clone() *memoryTable {
+	nt := &memoryTable{
+		docs:    make([]bson.M, len(t.docs)),
+		indexes: make([]dal.Index, len(t.indexes)),
+	}
+	for idx, doc := range t.docs {
+		nt.docs[idx] = deepCopyValue(doc).(bson.M)
+	}
+	copy(nt.indexes, t.indexes)
+	return nt
+}
+
+// checkUnique checks the docs against all the unique indexes of the table,
+// the error names the violated index the same way mongodb does
+func (t *memoryTable) checkUnique(docs []bson.M) error {
+	for _, index := range t.indexes {
+		if !index.Unique {
+			continue
+		}
+		keys := make([]string, 0, len(index.Keys))
+		for key := range index.Keys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		partial, err := normalizeDocument(index.PartialFilterExpression)
+		if err != nil {
+			return err
+		}
+
+		exists := make(map[string]bool, len(docs))
+		for _, doc := range docs {
+			if len(partial) > 0 {
+				matched, err := matchDocument(doc, partial)
+				if err != nil {
+					return err
+				}
+				if !matched {
+					continue
+				}
+			}
+			values := make([]string, len(keys))
+			for idx, key := range keys {
+				value, _ := getPathValue(doc, key)
+				values[idx] = uniqueKey(value)
+			}
+			value := strings.Join(values, "\x00")
+			if exists[value] {
+				return &mgo.LastError{Code: 11000, Err: fmt.Sprintf("E11000 duplicate key error index: %s", index.Name)}
+			}
+			exists[value] = true
+		}
+	}
+	return nil
+}
+
+// MemoryCollection implement dal.Table interface
+type MemoryCollection struct {
+	collName string // 集合名
+	*Memory
+}
+
+// Find 查询多个并反序列化到 Result
+func (c *MemoryCollection) Find(filter dal.Filter) dal.Find {
+	return &MemoryFind{MemoryCollection: c, filter: filter, projection: types.Document{"_id": false}}
+}
+
+// match returns the index of the docs in the table which match the filter. the caller must hold the lock
+func (c *MemoryCollection) match(filter dal.Filter) ([]int, error) {
+	cond, err := normalizeDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	table, ok := c.store.tables[c.collName]
+	if !ok {
+		return nil, nil
+	}
+
+	matched := make([]int, 0)
+	for idx, doc := range table.docs {
+		ok, err := matchDocument(doc, cond)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, idx)
+		}
+	}
+	return matched, nil
+}
+
+// Insert 插入数据, docs 可以为 单个数据 或者 多个数据
+func (c *MemoryCollection) Insert(ctx context.Context, docs interface{}) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+
+	newDocs := make([]bson.M, 0)
+	for _, item := range util.ConverToInterfaceSlice(docs) {
+		doc, err := normalizeDocument(item)
+		if err != nil {
+			return err
+		}
+		if _, ok := doc["_id"]; !ok {
+			doc["_id"] = bson.NewObjectId()
+		}
+		newDocs = append(newDocs, doc)
+	}
+
+	table := c.store.table(c.collName)
+	all := append(append(make([]bson.M, 0, len(table.docs)+len(newDocs)), table.docs...), newDocs...)
+	if err := table.checkUnique(all); err != nil {
+		return err
+	}
+	table.docs = all
+	return nil
+}
+
+// Update 更新数据
+func (c *MemoryCollection) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	return c.update(filter, bson.M{"$set": doc}, false)
+}
+
+// Upsert 更新数据, insert a new one if nothing matched
+func (c *MemoryCollection) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	return c.update(filter, bson.M{"$set": doc}, true)
+}
+
+// UpdateMultiModel 根据不同的操作符去更新数据
+func (c *MemoryCollection) UpdateMultiModel(ctx context.Context, filter dal.Filter, updateModel ...dal.ModeUpdate) error {
+	data := bson.M{}
+	for _, item := range updateModel {
+		if _, ok := data["$"+item.Op]; ok {
+			return errors.New(item.Op + " appear multiple times")
+		}
+		data["$"+item.Op] = item.Doc
+	}
+	return c.update(filter, data, false)
+}
+
+func (c *MemoryCollection) update(filter dal.Filter, data bson.M, upsert bool) error {
+	update, err := normalizeDocument(data)
+	if err != nil {
+		return err
+	}
+
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+
+	matched, err := c.match(filter)
+	if err != nil {
+		return err
+	}
+	if len(matched) == 0 && !upsert {
+		return nil
+	}
+
+	table := c.store.table(c.collName)
+	all := make([]bson.M, len(table.docs))
+	copy(all, table.docs)
+	for _, idx := range matched {
+		doc := deepCopyValue(all[idx]).(bson.M)
+		if err := applyUpdate(doc, update, false); err != nil {
+			return err
+		}
+		all[idx] = doc
+	}
+
+	if len(matched) == 0 && upsert {
+		cond, err := normalizeDocument(filter)
+		if err != nil {
+			return err
+		}
+		doc := upsertDocument(cond)
+		if err := applyUpdate(doc, update, true); err != nil {
+			return err
+		}
+		if _, ok := doc["_id"]; !ok {
+			doc["_id"] = bson.NewObjectId()
+		}
+		all = append(all, doc)
+	}
+
+	if err := table.checkUnique(all); err != nil {
+		return err
+	}
+	table.docs = all
+	return nil
+}
+
+// Delete 删除数据
+func (c *MemoryCollection) Delete(ctx context.Context, filter dal.Filter) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+
+	matched, err := c.match(filter)
+	if err != nil {
+		return err
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	table := c.store.tables[c.collName]
+	docs := make([]bson.M, 0, len(table.docs)-len(matched))
+	next := 0
+	for idx, doc := range table.docs {
+		if next < len(matched) && matched[next] == idx {
+			next++
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	table.docs = docs
+	return nil
+}
+
+// CreateIndex 创建索引
+func (c *MemoryCollection) CreateIndex(ctx context.Context, index dal.Index) error {
+	if len(index.Keys) == 0 {
+		return errors.New("index keys can not be empty")
+	}
+	if index.Name == "" {
+		keys := make([]string, 0, len(index.Keys))
+		for key, order := range index.Keys {
+			keys = append(keys, fmt.Sprintf("%s_%d", key, order))
+		}
+		sort.Strings(keys)
+		index.Name = strings.Join(keys, "_")
+	}
+
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+
+	table := c.store.table(c.collName)
+	for _, exist := range table.indexes {
+		if exist.Name != index.Name {
+			continue
+		}
+		if exist.Unique == index.Unique && reflect.DeepEqual(exist.Keys, index.Keys) {
+			return nil
+		}
+		return fmt.Errorf("There's already an index with name: %s", index.Name)
+	}
+
+	indexes := append(append(make([]dal.Index, 0, len(table.indexes)+1), table.indexes...), index)
+	check := &memoryTable{indexes: []dal.Index{index}}
+	if err := check.checkUnique(table.docs); err != nil {
+		return err
+	}
+	table.indexes = indexes
+	return nil
+}
+
+// DropIndex remove index by name
+func (c *MemoryCollection) DropIndex(ctx context.Context, indexName string) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+
+	table, ok := c.store.tables[c.collName]
+	if !ok {
+		return fmt.Errorf("ns not found: %s", c.collName)
+	}
+	for idx, index := range table.indexes {
+		if index.Name == indexName && indexName != "_id_" {
+			table.indexes = append(table.indexes[:idx:idx], table.indexes[idx+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("index not found with name [%s]", indexName)
+}
+
+// Indexes get all indexes for the collection
+func (c *MemoryCollection) Indexes(ctx context.Context) ([]dal.Index, error) {
+	c.store.lock.RLock()
+	defer c.store.lock.RUnlock()
+
+	table, ok := c.store.tables[c.collName]
+	if !ok {
+		return []dal.Index{}, nil
+	}
+	indexes := make([]dal.Index, len(table.indexes))
+	copy(indexes, table.indexes)
+	return indexes, nil
+}
+
+// AddColumn add a new column for the collection
+func (c *MemoryCollection) AddColumn(ctx context.Context, column string, value interface{}) error {
+	return c.update(types.Document{column: types.Document{"$exists": false}}, bson.M{"$set": bson.M{column: value}}, false)
+}
+
+// RenameColumn rename a column for the collection
+func (c *MemoryCollection) RenameColumn(ctx context.Context, oldName, newColumn string) error {
+	return c.update(types.Document{}, bson.M{"$rename": bson.M{oldName: newColumn}}, false)
+}
+
+// DropColumn remove a column by the name
+func (c *MemoryCollection) DropColumn(ctx context.Context, field string) error {
+	return c.update(types.Document{}, bson.M{"$unset": bson.M{field: ""}}, false)
+}
+
+// DropColumns remove the columns of the docs matched the filter
+func (c *MemoryCollection) DropColumns(ctx context.Context, filter dal.Filter, fields []string) error {
+	unsetFields := bson.M{}
+	for _, field := range fields {
+		unsetFields[field] = ""
+	}
+	return c.update(filter, bson.M{"$unset": unsetFields}, false)
+}
+
+// AggregateAll aggregate all operation, not supported by the memory db
+func (c *MemoryCollection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
+	return dal.ErrNotImplemented
+}
+
+// AggregateOne aggregate one operation, not supported by the memory db
+func (c *MemoryCollection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+	return dal.ErrNotImplemented
+}
+
+// MemoryFind define a find operation
+type MemoryFind struct {
+	*MemoryCollection
+	projection types.Document
+	filter     dal.Filter
+	start      uint64
+	limit      uint64
+	sort       []string
+}
+
+// Fields 查询字段
+func (f *MemoryFind) Fields(fields ...string) dal.Find {
+	for _, field := range fields {
+		if len(field) <= 0 {
+			continue
+		}
+		f.projection[field] = true
+	}
+	return f
+}
+
+// Sort 查询排序
+func (f *MemoryFind) Sort(sort string) dal.Find {
+	if sort != "" {
+		f.sort = strings.Split(sort, ",")
+	}
+	return f
+}
+
+// Start 查询上标
+func (f *MemoryFind) Start(start uint64) dal.Find {
+	f.start = start
+	return f
+}
+
+// Limit 查询限制
+func (f *MemoryFind) Limit(limit uint64) dal.Find {
+	f.limit = limit
+	return f
+}
+
+// find returns the matched docs with sort, paging and projection applied
+func (f *MemoryFind) find() ([]bson.M, error) {
+	f.store.lock.RLock()
+	defer f.store.lock.RUnlock()
+
+	matched, err := f.match(f.filter)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]bson.M, len(matched))
+	for idx, docIdx := range matched {
+		docs[idx] = f.store.tables[f.collName].docs[docIdx]
+	}
+
+	if len(f.sort) > 0 {
+		sort.SliceStable(docs, func(i, j int) bool {
+			for _, field := range f.sort {
+				field = strings.TrimSpace(field)
+				desc := false
+				if strings.HasPrefix(field, "-") {
+					desc = true
+					field = field[1:]
+				} else if strings.HasPrefix(field, "+") {
+					field = field[1:]
+				}
+				vi, _ := getPathValue(docs[i], field)
+				vj, _ := getPathValue(docs[j], field)
+				cmp := compareOrder(vi, vj)
+				if cmp == 0 {
+					continue
+				}
+				if desc {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+			return false
+		})
+	}
+
+	if f.start >= uint64(len(docs)) {
+		return []bson.M{}, nil
+	}
+	docs = docs[f.start:]
+	if f.limit > 0 && f.limit < uint64(len(docs)) {
+		docs = docs[:f.limit]
+	}
+
+	result := make([]bson.M, len(docs))
+	for idx, doc := range docs {
+		result[idx] = projectDocument(doc, f.projection)
+	}
+	return result, nil
+}
+
+// All 查询多个
+func (f *MemoryFind) All(ctx context.Context, result interface{}) error {
+	docs, err := f.find()
+	if err != nil {
+		return err
+	}
+
+	resultv := reflect.ValueOf(result)
+	if resultv.Kind() != reflect.Ptr || resultv.Elem().Kind() != reflect.Slice {
+		return errors.New("result argument must be a slice address")
+	}
+	slicev := resultv.Elem()
+	slicev = slicev.Slice(0, 0)
+	elemt := slicev.Type().Elem()
+	for _, doc := range docs {
+		if elemt.Kind() == reflect.Ptr {
+			elemp := reflect.New(elemt.Elem())
+			if err := decodeDocument(doc, elemp.Interface()); err != nil {
+				return err
+			}
+			slicev = reflect.Append(slicev, elemp)
+			continue
+		}
+		elemp := reflect.New(elemt)
+		if err := decodeDocument(doc, elemp.Interface()); err != nil {
+			return err
+		}
+		slicev = reflect.Append(slicev, elemp.Elem())
+	}
+	resultv.Elem().Set(slicev)
+	return nil
+}
+
+// One 查询一个
+func (f *MemoryFind) One(ctx context.Context, result interface{}) error {
+	limit := f.limit
+	f.limit = 1
+	docs, err := f.find()
+	f.limit = limit
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return dal.ErrDocumentNotFound
+	}
+	return decodeDocument(docs[0], result)
+}
+
+// Count 统计数量(非事务)
+func (f *MemoryFind) Count(ctx context.Context) (uint64, error) {
+	f.store.lock.RLock()
+	defer f.store.lock.RUnlock()
+
+	matched, err := f.match(f.filter)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(matched)), nil
+}
+
+// projectDocument copy the doc with the projection, the fields set to true are
+// returned only if there is any, and the fields set to false are always excluded
+func projectDocument(doc bson.M, projection types.Document) bson.M {
+	include := make([]string, 0)
+	for field, value := range projection {
+		if show, ok := value.(bool); ok && show {
+			include = append(include, field)
+		}
+	}
+
+	var result bson.M
+	if len(include) == 0 {
+		result = deepCopyValue(doc).(bson.M)
+	} else {
+		result = bson.M{}
+		for _, field := range include {
+			if value, ok := getPathValue(doc, field); ok {
+				_ = setPathValue(result, field, deepCopyValue(value))
+			}
+		}
+	}
+
+	for field, value := range projection {
+		if show, ok := value.(bool); ok && !show {
+			unsetPathValue(result, field)
+		}
+	}
+	return result
+}
+
+// normalizeDocument converts any bson marshalable value to bson.M, the nested
+// documents are converted to bson.M and arrays to []interface{}
+func normalizeDocument(value interface{}) (bson.M, error) {
+	if value == nil {
+		return bson.M{}, nil
+	}
+	out, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.M{}
+	if err := bson.Unmarshal(out, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func decodeDocument(doc bson.M, result interface{}) error {
+	out, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(out, result)
+}
@@ -0,0 +1,841 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// matchDocument returns whether the doc matches the mongodb filter
+func matchDocument(doc bson.M, filter bson.M) (bool, error) {
+	for key, cond := range filter {
+		var matched bool
+		var err error
+		switch key {
+		case "$and", "$or", "$nor":
+			matched, err = matchLogical(doc, key, cond)
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("unsupported filter operator %s", key)
+			}
+			matched, err = matchField(lookupPath(doc, strings.Split(key, ".")), cond)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogical(doc bson.M, op string, cond interface{}) (bool, error) {
+	items, ok := cond.([]interface{})
+	if !ok || len(items) == 0 {
+		return false, fmt.Errorf("%s must be a nonempty array", op)
+	}
+	for _, item := range items {
+		sub, ok := item.(bson.M)
+		if !ok {
+			return false, fmt.Errorf("%s entries must be objects", op)
+		}
+		matched, err := matchDocument(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case op == "$and" && !matched:
+			return false, nil
+		case op == "$or" && matched:
+			return true, nil
+		case op == "$nor" && matched:
+			return false, nil
+		}
+	}
+	return op != "$or", nil
+}
+
+// matchField returns whether the values found by the field path match the condition
+func matchField(values []interface{}, cond interface{}) (bool, error) {
+	ops, ok := cond.(bson.M)
+	if !ok || !isOperatorDocument(ops) {
+		if regex, ok := cond.(bson.RegEx); ok {
+			return matchRegex(values, regex)
+		}
+		return matchEqual(values, cond), nil
+	}
+
+	for op, arg := range ops {
+		var matched bool
+		var err error
+		switch op {
+		case "$eq":
+			matched = matchEqual(values, arg)
+		case "$ne":
+			matched = !matchEqual(values, arg)
+		case "$gt", "$gte", "$lt", "$lte":
+			matched = matchCompare(values, op, arg)
+		case "$in":
+			matched, err = matchIn(values, arg)
+		case "$nin":
+			matched, err = matchIn(values, arg)
+			matched = !matched
+		case "$exists":
+			matched = (len(values) > 0) == isTrue(arg)
+		case "$regex":
+			regex := bson.RegEx{Options: optionString(ops["$options"])}
+			switch pattern := arg.(type) {
+			case string:
+				regex.Pattern = pattern
+			case bson.RegEx:
+				regex.Pattern = pattern.Pattern
+				if regex.Options == "" {
+					regex.Options = pattern.Options
+				}
+			default:
+				return false, fmt.Errorf("$regex has to be a string")
+			}
+			matched, err = matchRegex(values, regex)
+		case "$options":
+			if _, ok := ops["$regex"]; !ok {
+				return false, fmt.Errorf("$options needs a $regex")
+			}
+			matched = true
+		case "$not":
+			matched, err = matchField(values, arg)
+			matched = !matched
+		case "$elemMatch":
+			matched, err = matchElem(values, arg)
+		case "$size":
+			matched = matchSize(values, arg)
+		case "$all":
+			matched, err = matchAll(values, arg)
+		case "$type":
+			matched, err = matchType(values, arg)
+		default:
+			return false, fmt.Errorf("unsupported filter operator %s", op)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// candidates returns the values and the elements of the array values, which
+// is how mongodb matches the scalar conditions with the array fields
+func candidates(values []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+		if arr, ok := value.([]interface{}); ok {
+			result = append(result, arr...)
+		}
+	}
+	return result
+}
+
+func matchEqual(values []interface{}, target interface{}) bool {
+	if target == nil && len(values) == 0 {
+		return true
+	}
+	for _, value := range candidates(values) {
+		if equalValue(value, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchCompare(values []interface{}, op string, target interface{}) bool {
+	for _, value := range candidates(values) {
+		cmp, ok := compareValue(value, target)
+		if !ok {
+			continue
+		}
+		switch op {
+		case "$gt":
+			if cmp > 0 {
+				return true
+			}
+		case "$gte":
+			if cmp >= 0 {
+				return true
+			}
+		case "$lt":
+			if cmp < 0 {
+				return true
+			}
+		case "$lte":
+			if cmp <= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchIn(values []interface{}, arg interface{}) (bool, error) {
+	items, ok := arg.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("$in needs an array")
+	}
+	for _, item := range items {
+		if regex, ok := item.(bson.RegEx); ok {
+			matched, err := matchRegex(values, regex)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+			continue
+		}
+		if matchEqual(values, item) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchRegex(values []interface{}, regex bson.RegEx) (bool, error) {
+	flags := ""
+	for _, option := range regex.Options {
+		switch option {
+		case 'i', 'm', 's':
+			flags += string(option)
+		}
+	}
+	pattern := regex.Pattern
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	for _, value := range candidates(values) {
+		if str, ok := value.(string); ok && re.MatchString(str) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchElem(values []interface{}, arg interface{}) (bool, error) {
+	cond, ok := arg.(bson.M)
+	if !ok {
+		return false, fmt.Errorf("$elemMatch needs an object")
+	}
+	for _, value := range values {
+		arr, ok := value.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, elem := range arr {
+			matched, err := matchElement(elem, cond)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// matchElement matches an array element with the condition of $elemMatch or $pull,
+// the operator condition applies to the element itself and the others to its fields
+func matchElement(elem interface{}, cond bson.M) (bool, error) {
+	if isOperatorDocument(cond) {
+		return matchField([]interface{}{elem}, cond)
+	}
+	doc, ok := elem.(bson.M)
+	if !ok {
+		return false, nil
+	}
+	return matchDocument(doc, cond)
+}
+
+func matchSize(values []interface{}, arg interface{}) bool {
+	size, ok := toInt64(arg)
+	if !ok {
+		return false
+	}
+	for _, value := range values {
+		if arr, ok := value.([]interface{}); ok && int64(len(arr)) == size {
+			return true
+		}
+	}
+	return false
+}
+
+// typeAliases are the $type aliases of the values decoded from bson
+var typeAliases = map[string]func(value interface{}) bool{
+	"double": func(value interface{}) bool { _, ok := value.(float64); return ok },
+	"string": func(value interface{}) bool { _, ok := value.(string); return ok },
+	"object": func(value interface{}) bool { _, ok := value.(bson.M); return ok },
+	"array":  func(value interface{}) bool { _, ok := value.([]interface{}); return ok },
+	"bool":   func(value interface{}) bool { _, ok := value.(bool); return ok },
+	"date":   func(value interface{}) bool { _, ok := value.(time.Time); return ok },
+	"null":   func(value interface{}) bool { return value == nil },
+	"int":    func(value interface{}) bool { _, ok := value.(int); return ok },
+	"long":   func(value interface{}) bool { _, ok := value.(int64); return ok },
+	"number": func(value interface{}) bool {
+		switch value.(type) {
+		case int, int64, float64:
+			return true
+		}
+		return false
+	},
+}
+
+func matchType(values []interface{}, arg interface{}) (bool, error) {
+	aliases, ok := arg.([]interface{})
+	if !ok {
+		aliases = []interface{}{arg}
+	}
+	for _, alias := range aliases {
+		name, ok := alias.(string)
+		if !ok {
+			return false, fmt.Errorf("$type only supports the type aliases, got %v", alias)
+		}
+		isType, ok := typeAliases[name]
+		if !ok {
+			return false, fmt.Errorf("unsupported $type alias %s", name)
+		}
+		for _, value := range values {
+			if isType(value) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func matchAll(values []interface{}, arg interface{}) (bool, error) {
+	items, ok := arg.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("$all needs an array")
+	}
+	if len(items) == 0 {
+		return false, nil
+	}
+	for _, item := range items {
+		if !matchEqual(values, item) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// isOperatorDocument returns whether all the keys of the doc are operators
+func isOperatorDocument(doc bson.M) bool {
+	if len(doc) == 0 {
+		return false
+	}
+	for key := range doc {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupPath returns all the values at the dotted path, the arrays on the way
+// are expanded unless the path part is an index of the array
+func lookupPath(value interface{}, parts []string) []interface{} {
+	if len(parts) == 0 {
+		return []interface{}{value}
+	}
+	switch v := value.(type) {
+	case bson.M:
+		child, ok := v[parts[0]]
+		if !ok {
+			return nil
+		}
+		return lookupPath(child, parts[1:])
+	case []interface{}:
+		if idx, err := strconv.Atoi(parts[0]); err == nil {
+			if idx < 0 || idx >= len(v) {
+				return nil
+			}
+			return lookupPath(v[idx], parts[1:])
+		}
+		result := make([]interface{}, 0)
+		for _, elem := range v {
+			if doc, ok := elem.(bson.M); ok {
+				result = append(result, lookupPath(doc, parts)...)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// getPathValue returns the single value at the dotted path without expanding arrays
+func getPathValue(doc bson.M, path string) (interface{}, bool) {
+	var value interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case bson.M:
+			child, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			value = child
+		case []interface{}:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			value = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// setPathValue sets the value at the dotted path, the missing parent documents are created
+func setPathValue(doc bson.M, path string, value interface{}) error {
+	parts := strings.Split(path, ".")
+	var parent interface{} = doc
+	for idx, part := range parts {
+		last := idx == len(parts)-1
+		switch p := parent.(type) {
+		case bson.M:
+			if last {
+				p[part] = value
+				return nil
+			}
+			child, ok := p[part]
+			if !ok || child == nil {
+				child = bson.M{}
+				p[part] = child
+			}
+			parent = child
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(p) {
+				return fmt.Errorf("cannot create field %s in element %v", part, p)
+			}
+			if last {
+				p[i] = value
+				return nil
+			}
+			if p[i] == nil {
+				p[i] = bson.M{}
+			}
+			parent = p[i]
+		default:
+			return fmt.Errorf("cannot create field %s in element %v", part, parent)
+		}
+	}
+	return nil
+}
+
+func unsetPathValue(doc bson.M, path string) {
+	idx := strings.LastIndex(path, ".")
+	if idx < 0 {
+		delete(doc, path)
+		return
+	}
+	parent, ok := getPathValue(doc, path[:idx])
+	if !ok {
+		return
+	}
+	if p, ok := parent.(bson.M); ok {
+		delete(p, path[idx+1:])
+	}
+}
+
+// upsertDocument builds the document to insert from the equality conditions of the filter
+func upsertDocument(filter bson.M) bson.M {
+	doc := bson.M{}
+	for key, cond := range filter {
+		if key == "$and" {
+			items, _ := cond.([]interface{})
+			for _, item := range items {
+				if sub, ok := item.(bson.M); ok {
+					for k, v := range upsertDocument(sub) {
+						_ = setPathValue(doc, k, v)
+					}
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(key, "$") {
+			continue
+		}
+		if ops, ok := cond.(bson.M); ok && isOperatorDocument(ops) {
+			if eq, ok := ops["$eq"]; ok {
+				_ = setPathValue(doc, key, deepCopyValue(eq))
+			}
+			continue
+		}
+		if _, ok := cond.(bson.RegEx); ok {
+			continue
+		}
+		_ = setPathValue(doc, key, deepCopyValue(cond))
+	}
+	return doc
+}
+
+// applyUpdate applies the mongodb update operators to the doc
+func applyUpdate(doc bson.M, update bson.M, inserting bool) error {
+	for op, arg := range update {
+		fields, ok := arg.(bson.M)
+		if !ok {
+			return fmt.Errorf("modifier %s's argument must be an object", op)
+		}
+		for field, value := range fields {
+			if err := applyFieldUpdate(doc, op, field, value, inserting); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func applyFieldUpdate(doc bson.M, op, field string, value interface{}, inserting bool) error {
+	switch op {
+	case "$set":
+		return setPathValue(doc, field, deepCopyValue(value))
+	case "$setOnInsert":
+		if inserting {
+			return setPathValue(doc, field, deepCopyValue(value))
+		}
+		return nil
+	case "$unset":
+		unsetPathValue(doc, field)
+		return nil
+	case "$rename":
+		newField, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("$rename target must be a string")
+		}
+		current, exists := getPathValue(doc, field)
+		if !exists {
+			return nil
+		}
+		unsetPathValue(doc, field)
+		return setPathValue(doc, newField, current)
+	case "$inc":
+		current, exists := getPathValue(doc, field)
+		if !exists {
+			current = 0
+		}
+		sum, err := addNumber(current, value)
+		if err != nil {
+			return fmt.Errorf("cannot apply $inc to field %s, %v", field, err)
+		}
+		return setPathValue(doc, field, sum)
+	case "$addToSet", "$push":
+		arr, err := arrayField(doc, field, op)
+		if err != nil {
+			return err
+		}
+		items := []interface{}{value}
+		if each, ok := value.(bson.M); ok {
+			if list, ok := each["$each"].([]interface{}); ok {
+				items = list
+			}
+		}
+		for _, item := range items {
+			if op == "$addToSet" && containsValue(arr, item) {
+				continue
+			}
+			arr = append(arr, deepCopyValue(item))
+		}
+		return setPathValue(doc, field, arr)
+	case "$pull":
+		current, exists := getPathValue(doc, field)
+		if !exists {
+			return nil
+		}
+		arr, ok := current.([]interface{})
+		if !ok {
+			return fmt.Errorf("cannot apply $pull to a non-array value of field %s", field)
+		}
+		result := make([]interface{}, 0, len(arr))
+		for _, elem := range arr {
+			var matched bool
+			if cond, ok := value.(bson.M); ok {
+				var err error
+				if matched, err = matchElement(elem, cond); err != nil {
+					return err
+				}
+			} else {
+				matched = equalValue(elem, value)
+			}
+			if !matched {
+				result = append(result, elem)
+			}
+		}
+		return setPathValue(doc, field, result)
+	default:
+		return fmt.Errorf("unsupported update operator %s", op)
+	}
+}
+
+func arrayField(doc bson.M, field, op string) ([]interface{}, error) {
+	current, exists := getPathValue(doc, field)
+	if !exists || current == nil {
+		return make([]interface{}, 0), nil
+	}
+	arr, ok := current.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot apply %s to a non-array value of field %s", op, field)
+	}
+	return append(make([]interface{}, 0, len(arr)+1), arr...), nil
+}
+
+func containsValue(arr []interface{}, value interface{}) bool {
+	for _, elem := range arr {
+		if equalValue(elem, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func deepCopyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.M:
+		result := make(bson.M, len(v))
+		for key, item := range v {
+			result[key] = deepCopyValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for idx, item := range v {
+			result[idx] = deepCopyValue(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	if v, ok := toInt64(value); ok {
+		return float64(v), true
+	}
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func addNumber(a, b interface{}) (interface{}, error) {
+	ai, aok := toInt64(a)
+	bi, bok := toInt64(b)
+	if aok && bok {
+		return ai + bi, nil
+	}
+	af, aok := toFloat64(a)
+	bf, bok := toFloat64(b)
+	if !aok || !bok {
+		return nil, fmt.Errorf("%v or %v is not a number", a, b)
+	}
+	return af + bf, nil
+}
+
+// compareValue compares the values of the same kind, returns false if they are not comparable
+func compareValue(a, b interface{}) (int, bool) {
+	if ai, ok := toInt64(a); ok {
+		if bi, ok := toInt64(b); ok {
+			return compareInt(ai, bi), true
+		}
+	}
+	if af, ok := toFloat64(a); ok {
+		if bf, ok := toFloat64(b); ok {
+			switch {
+			case af < bf:
+				return -1, true
+			case af > bf:
+				return 1, true
+			}
+			return 0, true
+		}
+		return 0, false
+	}
+
+	switch av := a.(type) {
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), true
+		}
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			switch {
+			case av.Before(bv):
+				return -1, true
+			case av.After(bv):
+				return 1, true
+			}
+			return 0, true
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			switch {
+			case av == bv:
+				return 0, true
+			case !av:
+				return -1, true
+			}
+			return 1, true
+		}
+	case bson.ObjectId:
+		if bv, ok := b.(bson.ObjectId); ok {
+			return strings.Compare(string(av), string(bv)), true
+		}
+	}
+	return 0, false
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareOrder compares any two values with the mongodb sort order of the types
+func compareOrder(a, b interface{}) int {
+	ra, rb := typeOrder(a), typeOrder(b)
+	if ra != rb {
+		return compareInt(int64(ra), int64(rb))
+	}
+	if cmp, ok := compareValue(a, b); ok {
+		return cmp
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func typeOrder(value interface{}) int {
+	if _, ok := toFloat64(value); ok {
+		return 2
+	}
+	switch value.(type) {
+	case nil:
+		return 1
+	case string:
+		return 3
+	case bson.M:
+		return 4
+	case []interface{}:
+		return 5
+	case bson.ObjectId:
+		return 6
+	case bool:
+		return 7
+	case time.Time:
+		return 8
+	}
+	return 9
+}
+
+func equalValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if cmp, ok := compareValue(a, b); ok {
+		return cmp == 0
+	}
+	switch av := a.(type) {
+	case bson.M:
+		bv, ok := b.(bson.M)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, item := range av {
+			other, ok := bv[key]
+			if !ok || !equalValue(item, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for idx := range av {
+			if !equalValue(av[idx], bv[idx]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// uniqueKey returns the key of the value in unique index, the equal values have the same key
+func uniqueKey(value interface{}) string {
+	if f, ok := toFloat64(value); ok {
+		return "n:" + strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case time.Time:
+		return "t:" + strconv.FormatInt(v.UnixNano(), 10)
+	}
+	return fmt.Sprintf("%T:%v", value, value)
+}
+
+func isTrue(value interface{}) bool {
+	if b, ok := value.(bool); ok {
+		return b
+	}
+	if f, ok := toFloat64(value); ok {
+		return f != 0
+	}
+	return value != nil
+}
+
+func optionString(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	return ""
+}
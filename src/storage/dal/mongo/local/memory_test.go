@@ -0,0 +1,195 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/storage/dal"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type memoryHost struct {
+	ID     int64    `bson:"bk_host_id"`
+	IP     string   `bson:"bk_host_innerip"`
+	OS     string   `bson:"bk_os_type"`
+	Labels []string `bson:"labels"`
+}
+
+func newMemoryHosts(t *testing.T) *Memory {
+	db := NewMemory()
+	hosts := []memoryHost{
+		{ID: 1, IP: "127.0.0.1", OS: "linux", Labels: []string{"a", "b"}},
+		{ID: 2, IP: "127.0.0.2", OS: "windows", Labels: []string{"b"}},
+		{ID: 3, IP: "10.0.0.3", OS: "linux"},
+	}
+	require.NoError(t, db.Table("host").Insert(context.Background(), hosts))
+	return db
+}
+
+func findHostIDs(t *testing.T, db dal.DB, filter interface{}) []int64 {
+	hosts := make([]memoryHost, 0)
+	require.NoError(t, db.Table("host").Find(filter).Sort("bk_host_id").All(context.Background(), &hosts))
+	ids := make([]int64, 0)
+	for _, host := range hosts {
+		ids = append(ids, host.ID)
+	}
+	return ids
+}
+
+func TestMemoryFilter(t *testing.T) {
+	db := newMemoryHosts(t)
+
+	require.Equal(t, []int64{1, 3}, findHostIDs(t, db, mapstr.MapStr{"bk_os_type": "linux"}))
+	require.Equal(t, []int64{2, 3}, findHostIDs(t, db, mapstr.MapStr{"bk_host_id": mapstr.MapStr{"$in": []int{2, 3}}}))
+	require.Equal(t, []int64{1}, findHostIDs(t, db, mapstr.MapStr{"$and": []mapstr.MapStr{
+		{"bk_os_type": "linux"}, {"bk_host_id": mapstr.MapStr{"$lt": 2}}}}))
+	require.Equal(t, []int64{2, 3}, findHostIDs(t, db, mapstr.MapStr{"$or": []mapstr.MapStr{
+		{"bk_os_type": "windows"}, {"bk_host_innerip": mapstr.MapStr{"$regex": "^10\\."}}}}))
+	require.Equal(t, []int64{1, 2}, findHostIDs(t, db, mapstr.MapStr{"bk_host_innerip": bson.RegEx{Pattern: "127"}}))
+	require.Equal(t, []int64{3}, findHostIDs(t, db, mapstr.MapStr{"labels": mapstr.MapStr{"$size": 0}}))
+	require.Empty(t, findHostIDs(t, db, mapstr.MapStr{"bk_cloud_id": mapstr.MapStr{"$exists": true}}))
+	require.Equal(t, []int64{1, 2}, findHostIDs(t, db, mapstr.MapStr{"labels": "b"}))
+	require.Equal(t, []int64{1}, findHostIDs(t, db, mapstr.MapStr{"labels": mapstr.MapStr{"$elemMatch": mapstr.MapStr{"$eq": "a"}}}))
+	require.Equal(t, []int64{2}, findHostIDs(t, db, mapstr.MapStr{"bk_os_type": mapstr.MapStr{"$ne": "linux"}}))
+
+	_, err := db.Table("host").Find(mapstr.MapStr{"$where": "true"}).Count(context.Background())
+	require.Error(t, err)
+}
+
+func TestMemoryFind(t *testing.T) {
+	db := newMemoryHosts(t)
+	ctx := context.Background()
+
+	hosts := make([]mapstr.MapStr, 0)
+	err := db.Table("host").Find(nil).Fields("bk_host_id").Sort("-bk_host_id").Start(1).Limit(1).All(ctx, &hosts)
+	require.NoError(t, err)
+	require.Len(t, hosts, 1)
+	require.Equal(t, mapstr.MapStr{"bk_host_id": int64(2)}, hosts[0])
+
+	host := memoryHost{}
+	require.NoError(t, db.Table("host").Find(mapstr.MapStr{"bk_os_type": "linux"}).Sort("-bk_host_id").One(ctx, &host))
+	require.Equal(t, int64(3), host.ID)
+	err = db.Table("host").Find(mapstr.MapStr{"bk_host_id": 4}).One(ctx, &host)
+	require.True(t, db.IsNotFoundError(err))
+
+	count, err := db.Table("host").Find(mapstr.MapStr{"bk_os_type": "linux"}).Limit(1).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+}
+
+func TestMemoryUpdate(t *testing.T) {
+	db := newMemoryHosts(t)
+	ctx := context.Background()
+
+	require.NoError(t, db.Table("host").Update(ctx, mapstr.MapStr{"bk_os_type": "linux"}, mapstr.MapStr{"bk_os_type": "aix"}))
+	require.Equal(t, []int64{1, 3}, findHostIDs(t, db, mapstr.MapStr{"bk_os_type": "aix"}))
+
+	err := db.Table("host").UpdateMultiModel(ctx, mapstr.MapStr{"bk_host_id": 3},
+		dal.ModeUpdate{Op: dal.UpdateOpAddToSet, Doc: mapstr.MapStr{"labels": "c"}})
+	require.NoError(t, err)
+	err = db.Table("host").UpdateMultiModel(ctx, mapstr.MapStr{},
+		dal.ModeUpdate{Op: dal.UpdateOpAddToSet, Doc: mapstr.MapStr{"labels": "c"}})
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2, 3}, findHostIDs(t, db, mapstr.MapStr{"labels": "c"}))
+	err = db.Table("host").UpdateMultiModel(ctx, mapstr.MapStr{}, dal.ModeUpdate{Op: dal.UpdateOpPull, Doc: mapstr.MapStr{"labels": "b"}})
+	require.NoError(t, err)
+	require.Empty(t, findHostIDs(t, db, mapstr.MapStr{"labels": "b"}))
+
+	host := memoryHost{}
+	require.NoError(t, db.Table("host").Find(mapstr.MapStr{"bk_host_id": 3}).One(ctx, &host))
+	require.Equal(t, []string{"c"}, host.Labels)
+
+	require.NoError(t, db.Table("host").Upsert(ctx, mapstr.MapStr{"bk_host_id": 4}, mapstr.MapStr{"bk_os_type": "linux"}))
+	require.NoError(t, db.Table("host").Find(mapstr.MapStr{"bk_host_id": 4}).One(ctx, &host))
+	require.Equal(t, "linux", host.OS)
+
+	require.NoError(t, db.Table("host").Delete(ctx, mapstr.MapStr{"bk_os_type": "aix"}))
+	require.Equal(t, []int64{2, 4}, findHostIDs(t, db, nil))
+}
+
+func TestMemoryUniqueIndex(t *testing.T) {
+	db := newMemoryHosts(t)
+	ctx := context.Background()
+
+	index := dal.Index{Keys: map[string]int32{"bk_os_type": 1}, Name: "os", Unique: true}
+	require.True(t, db.IsDuplicatedError(db.Table("host").CreateIndex(ctx, index)))
+
+	index = dal.Index{Keys: map[string]int32{"bk_host_innerip": 1}, Name: "ip", Unique: true}
+	require.NoError(t, db.Table("host").CreateIndex(ctx, index))
+	err := db.Table("host").Insert(ctx, memoryHost{ID: 4, IP: "127.0.0.1"})
+	require.True(t, db.IsDuplicatedError(err))
+	err = db.Table("host").Update(ctx, mapstr.MapStr{"bk_host_id": 2}, mapstr.MapStr{"bk_host_innerip": "10.0.0.3"})
+	require.True(t, db.IsDuplicatedError(err))
+	require.Equal(t, []int64{2}, findHostIDs(t, db, mapstr.MapStr{"bk_host_innerip": "127.0.0.2"}))
+
+	indexes, err := db.Table("host").Indexes(ctx)
+	require.NoError(t, err)
+	require.Len(t, indexes, 2)
+	require.NoError(t, db.Table("host").DropIndex(ctx, "ip"))
+	require.NoError(t, db.Table("host").Insert(ctx, memoryHost{ID: 4, IP: "127.0.0.1"}))
+
+	// the partial index only covers the hosts with a non empty os type
+	index = dal.Index{
+		Keys:                    map[string]int32{"bk_os_type": 1},
+		Name:                    "os_partial",
+		Unique:                  true,
+		PartialFilterExpression: map[string]interface{}{"bk_os_type": map[string]interface{}{"$type": "string", "$gt": ""}},
+	}
+	require.NoError(t, db.Table("host").Delete(ctx, mapstr.MapStr{"bk_host_id": 4}))
+	require.NoError(t, db.Table("host").Update(ctx, mapstr.MapStr{}, mapstr.MapStr{"bk_os_type": ""}))
+	require.NoError(t, db.Table("host").CreateIndex(ctx, index))
+	require.NoError(t, db.Table("host").Insert(ctx, memoryHost{ID: 5, OS: "linux"}))
+	err = db.Table("host").Insert(ctx, memoryHost{ID: 6, OS: "linux"})
+	require.True(t, db.IsDuplicatedError(err))
+	require.Contains(t, err.Error(), "index: os_partial")
+}
+
+func TestMemoryTransaction(t *testing.T) {
+	db := newMemoryHosts(t)
+	ctx := context.Background()
+
+	id, err := db.NextSequence(ctx, "host")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+
+	err = db.AutoRun(ctx, dal.TxnWrapperOption{Header: http.Header{}}, func(header http.Header) error {
+		if _, err := db.NextSequence(ctx, "host"); err != nil {
+			return err
+		}
+		if err := db.Table("host").Delete(ctx, mapstr.MapStr{}); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	require.EqualError(t, err, "rollback")
+	require.Equal(t, []int64{1, 2, 3}, findHostIDs(t, db, nil))
+	id, err = db.NextSequence(ctx, "host")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), id)
+
+	txn, err := db.Start(ctx)
+	require.NoError(t, err)
+	_, err = db.Start(ctx)
+	require.Equal(t, dal.ErrTransactionStated, err)
+	require.NoError(t, db.Clone().Table("host").Delete(ctx, mapstr.MapStr{"bk_host_id": 1}))
+	require.NoError(t, txn.Commit(ctx))
+	require.Equal(t, []int64{2, 3}, findHostIDs(t, db, nil))
+	require.Equal(t, dal.ErrTransactionNotFound, db.Abort(ctx))
+}
@@ -15,6 +15,7 @@ package local
 import (
 	"context"
 	"errors"
+	"sort"
 	"strings"
 	"time"
 
@@ -365,16 +366,46 @@ func (c *Collection) CreateIndex(ctx context.Context, index dal.Index) error {
 	}
 
 	i := mgo.Index{
-		Key:        keys,
-		Name:       index.Name,
-		Unique:     index.Unique,
-		Background: index.Background,
+		Key:             keys,
+		Name:            index.Name,
+		Unique:          index.Unique,
+		Background:      index.Background,
+		DefaultLanguage: index.DefaultLanguage,
 	}
 	sess := c.dbc.Clone()
 	defer sess.Close()
+	if len(index.PartialFilterExpression) > 0 {
+		return c.createPartialIndex(sess, index)
+	}
 	return sess.DB(c.dbname).C(c.collName).EnsureIndex(i)
 }
 
+// createPartialIndex creates the index with the createIndexes command, as mgo.Index has no partial filter
+func (c *Collection) createPartialIndex(sess *mgo.Session, index dal.Index) error {
+	keys := make([]string, 0, len(index.Keys))
+	for key := range index.Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	keyDoc := bson.D{}
+	for _, key := range keys {
+		keyDoc = append(keyDoc, bson.DocElem{Name: key, Value: index.Keys[key]})
+	}
+
+	spec := bson.M{
+		"key":                     keyDoc,
+		"name":                    index.Name,
+		"unique":                  index.Unique,
+		"background":              index.Background,
+		"partialFilterExpression": index.PartialFilterExpression,
+	}
+	cmd := bson.D{
+		{Name: "createIndexes", Value: c.collName},
+		{Name: "indexes", Value: []bson.M{spec}},
+	}
+	return sess.DB(c.dbname).Run(cmd, nil)
+}
+
 // DropIndex remove index by name
 func (c *Collection) DropIndex(ctx context.Context, indexName string) error {
 	sess := c.dbc.Clone()
@@ -407,6 +438,7 @@ func (c *Collection) Indexes(ctx context.Context) ([]dal.Index, error) {
 		index.Name = dbindex.Name
 		index.Unique = dbindex.Unique
 		index.Background = dbindex.Background
+		index.DefaultLanguage = dbindex.DefaultLanguage
 		index.Keys = keys
 		indexs = append(indexs, index)
 	}
@@ -202,7 +202,7 @@ func (c *Collection) CreateIndex(ctx context.Context, index dal.Index) error {
 	msg := types.OPDDLOperation{
 		Command:    types.OPDDLCreateIndexCommand,
 		Collection: c.collection,
-		Index:      mongodb.Index{Name: index.Name, Keys: index.Keys, Unique: index.Unique, Background: index.Background},
+		Index:      mongodb.Index(index),
 		MsgHeader:  types.MsgHeader{OPCode: types.OPDDLCode},
 	}
 
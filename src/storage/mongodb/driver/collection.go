@@ -40,6 +40,9 @@ type collection struct {
 	err             error
 }
 
+// textIndexKeyPrefix is the prefix of the text index keys, e.g: $text:bk_inst_name
+const textIndexKeyPrefix = "$text:"
+
 func newCollection(db *mongo.Database, collectionName string) mongodb.CollectionInterface {
 
 	return &collection{
@@ -76,6 +79,10 @@ func (c *collection) CreateIndex(index mongodb.Index) error {
 
 	keys := bsonx.Doc{}
 	for key, val := range index.Keys {
+		if strings.HasPrefix(key, textIndexKeyPrefix) {
+			keys = keys.Append(strings.TrimPrefix(key, textIndexKeyPrefix), bsonx.String("text"))
+			continue
+		}
 		keys = keys.Append(key, bsonx.Int32(val))
 	}
 
@@ -84,6 +91,12 @@ func (c *collection) CreateIndex(index mongodb.Index) error {
 		Background: &index.Background,
 		Unique:     &index.Unique,
 	}
+	if index.DefaultLanguage != "" {
+		indexOpts.DefaultLanguage = &index.DefaultLanguage
+	}
+	if len(index.PartialFilterExpression) > 0 {
+		indexOpts.PartialFilterExpression = index.PartialFilterExpression
+	}
 
 	// in a session
 	if nil != c.innerSession {
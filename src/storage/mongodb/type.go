@@ -34,4 +34,9 @@ type Index struct {
 	Name       string           `json:"name"`
 	Unique     bool             `json:"unique"`
 	Background bool             `json:"background"`
+	// DefaultLanguage is the language of the text index, the keys of a text index are prefixed with "$text:"
+	DefaultLanguage string `json:"default_language,omitempty"`
+	// PartialFilterExpression limits the index to the documents matching the filter,
+	// only the equality, $exists: true, $gt/$gte/$lt/$lte, $type and top level $and are allowed
+	PartialFilterExpression map[string]interface{} `json:"partial_filter_expression,omitempty"`
 }
@@ -0,0 +1,154 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/source_controller/coreservice/core/auditlog"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewAuditCommand())
+}
+
+type auditConf struct {
+	supplierAccount string
+	before          string
+	dir             string
+	purge           bool
+}
+
+func NewAuditCommand() *cobra.Command {
+	conf := new(auditConf)
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "audit log hash chain operations",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "verify the audit log hash chain to find the modified, deleted or inserted audit logs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuditVerifyCmd(conf)
+		},
+	}
+
+	archiveCmd := &cobra.Command{
+		Use:   "archive",
+		Short: "export the sealed audit logs before the date to a gzip compressed ndjson file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuditArchiveCmd(conf)
+		},
+	}
+	archiveCmd.Flags().StringVar(&conf.before, "before", "", "export the audit logs recorded before the date, e.g: 2020-01-01")
+	archiveCmd.Flags().StringVar(&conf.dir, "dir", ".", "the directory to save the archive file")
+	archiveCmd.Flags().BoolVar(&conf.purge, "purge", false, "delete the exported audit logs from db after the archive file is saved")
+
+	cmd.AddCommand(verifyCmd, archiveCmd)
+	cmd.PersistentFlags().StringVar(&conf.supplierAccount, "supplier-account", common.BKDefaultOwnerID, "the supplier account of the audit logs")
+
+	return cmd
+}
+
+func runAuditVerifyCmd(c *auditConf) error {
+	srv, err := config.NewMongoService(config.Conf.MongoURI)
+	if err != nil {
+		return err
+	}
+
+	result, err := auditlog.VerifyAuditChain(context.Background(), srv.DbProxy, c.supplierAccount)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("checked %d sealed audit logs to id %d, %d audit logs not sealed yet\n", result.Checked, result.LastID, result.Unsealed)
+	if result.Valid {
+		fmt.Print(WithGreenColor("the audit log hash chain is valid"))
+		return nil
+	}
+	for _, problem := range result.Problems {
+		fmt.Print(WithRedColor(fmt.Sprintf("audit log %d: %s", problem.ID, problem.Reason)))
+	}
+	return fmt.Errorf("the audit log hash chain of %s is broken", c.supplierAccount)
+}
+
+func runAuditArchiveCmd(c *auditConf) error {
+	if c.before == "" {
+		return errors.New("before must be set")
+	}
+	before, err := time.ParseInLocation("2006-01-02", c.before, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid before date %s, err: %v", c.before, err)
+	}
+
+	srv, err := config.NewMongoService(config.Conf.MongoURI)
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(c.dir, "audit_archive_")
+	if err != nil {
+		return fmt.Errorf("create archive file failed, err: %v", err)
+	}
+	tmpName := file.Name()
+	defer os.Remove(tmpName)
+
+	ctx := context.Background()
+	result, err := auditlog.ArchiveAuditLogs(ctx, srv.DbProxy, c.supplierAccount, before, file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("save archive file failed, err: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("save archive file failed, err: %v", err)
+	}
+
+	if result.Count == 0 {
+		fmt.Println("no sealed audit logs to archive")
+		return nil
+	}
+
+	name := filepath.Join(c.dir, fmt.Sprintf("%s_%s_%d-%d.ndjson.gz", common.BKTableNameOperationLog, c.supplierAccount, result.FirstID, result.LastID))
+	if err := os.Rename(tmpName, name); err != nil {
+		return fmt.Errorf("rename archive file to %s failed, err: %v", name, err)
+	}
+	fmt.Printf("archived %d audit logs from id %d to %d to %s\n", result.Count, result.FirstID, result.LastID, name)
+
+	if !c.purge {
+		return nil
+	}
+	if err := auditlog.PurgeArchivedAuditLogs(ctx, srv.DbProxy, result); err != nil {
+		return err
+	}
+	fmt.Printf("purged %d archived audit logs\n", result.Count)
+	return nil
+}
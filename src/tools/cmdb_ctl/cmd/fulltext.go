@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"configcenter/src/scene_server/event_server/indexer"
+	"configcenter/src/thirdpartyclient/elasticsearch"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewFullTextCommand())
+}
+
+type fullTextConf struct {
+	esURL      string
+	esUser     string
+	esPassword string
+	force      bool
+}
+
+func NewFullTextCommand() *cobra.Command {
+	conf := new(fullTextConf)
+
+	cmd := &cobra.Command{
+		Use:   "fulltext",
+		Short: "full text search index operations",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	rebuildCmd := &cobra.Command{
+		Use:   "rebuild",
+		Short: "rebuild the full text search index from db, the index is switched after it is built",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFullTextRebuildCmd(conf)
+		},
+	}
+	rebuildCmd.Flags().BoolVar(&conf.force, "force", false, "delete the index left by the interrupted rebuilding before rebuilding")
+
+	cmd.AddCommand(rebuildCmd)
+	cmd.PersistentFlags().StringVar(&conf.esURL, "es-url", os.Getenv("ES_URL"), "the elasticsearch url, e.g: http://127.0.0.1:9200, corresponding environment variable is ES_URL")
+	cmd.PersistentFlags().StringVar(&conf.esUser, "es-user", os.Getenv("ES_USER"), "the elasticsearch user, corresponding environment variable is ES_USER")
+	cmd.PersistentFlags().StringVar(&conf.esPassword, "es-password", os.Getenv("ES_PASSWORD"), "the elasticsearch password, corresponding environment variable is ES_PASSWORD")
+
+	return cmd
+}
+
+func runFullTextRebuildCmd(c *fullTextConf) error {
+	if c.esURL == "" {
+		return fmt.Errorf("es-url must set via flag or environment variable")
+	}
+
+	srv, err := config.NewMongoService(config.Conf.MongoURI)
+	if err != nil {
+		return err
+	}
+
+	esClient, err := elasticsearch.NewEsClient(elasticsearch.EsConfig{EsUrl: c.esURL, EsUser: c.esUser, EsPassword: c.esPassword})
+	if err != nil {
+		return err
+	}
+	esIndexer := indexer.New(esClient, srv.DbProxy)
+	defer esIndexer.Close()
+
+	ctx := context.Background()
+	if c.force {
+		cleared, err := esIndexer.ClearRebuild(ctx)
+		if err != nil {
+			return err
+		}
+		if len(cleared) > 0 {
+			fmt.Printf("deleted the index left by the interrupted rebuilding: %v\n", cleared)
+		}
+	}
+
+	result, err := esIndexer.Rebuild(ctx)
+	if err != nil {
+		return err
+	}
+	for typ, count := range result.Counts {
+		fmt.Printf("%s: %d documents\n", typ, count)
+	}
+	if result.Failed > 0 {
+		fmt.Print(WithRedColor(fmt.Sprintf("%d documents failed to index, see the log for details", result.Failed)))
+	}
+	fmt.Print(WithGreenColor(fmt.Sprintf("full text search index %s is built, replaced index: %v", result.Index, result.Replaced)))
+	return nil
+}
@@ -29,6 +29,7 @@ import (
 	"configcenter/src/storage/dal/redis"
 	"configcenter/src/web_server/app/options"
 	"configcenter/src/web_server/logics"
+	"configcenter/src/web_server/middleware/user/plugins"
 	websvc "configcenter/src/web_server/service"
 
 	"github.com/holmeswang/contrib/sessions"
@@ -111,7 +112,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	service.Logics = &logics.Logics{Engine: engine}
 	service.Config = &webSvr.Config
 
-	if webSvr.Config.LoginVersion != common.BKDefaultLoginUserPluginVersion && webSvr.Config.LoginVersion != "" {
+	if webSvr.Config.LoginVersion != "" && !plugins.IsRegistered(webSvr.Config.LoginVersion) {
 		service.VersionPlg, err = plugin.Open("login.so")
 		if nil != err {
 			service.VersionPlg = nil
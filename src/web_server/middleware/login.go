@@ -50,6 +50,10 @@ func ValidLogin(config options.Config, disc discovery.DiscoveryInterface) gin.Ha
 		case "healthz", "metrics":
 			c.Next()
 			return
+		case strings.TrimPrefix(common.WebLoginPagePath, "/"):
+			// the login page logs the user in by itself
+			c.Next()
+			return
 		}
 
 		if isAuthed(c, config) {
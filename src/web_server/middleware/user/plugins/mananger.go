@@ -41,3 +41,13 @@ func CurrentPlugin(c *gin.Context, version string) metadata.LoginUserPluginInerf
 
 	return nil
 }
+
+// IsRegistered returns whether the login plugin of the version is built in
+func IsRegistered(version string) bool {
+	for _, plugin := range manager.LoginPluginInfo {
+		if plugin.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// the classes of the ber encoded elements
+const (
+	classUniversal   byte = 0x00
+	classApplication byte = 0x40
+	classContext     byte = 0x80
+)
+
+// the universal tags used by ldap
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x10
+	tagSet         = 0x11
+)
+
+// maxPacketSize limits the size of a message read from the server
+const maxPacketSize = 16 << 20
+
+// packet is a ber encoded element, the content of a constructed element is its children
+type packet struct {
+	class       byte
+	constructed bool
+	tag         byte
+	value       []byte
+	children    []*packet
+}
+
+func newConstructed(class, tag byte, children ...*packet) *packet {
+	return &packet{class: class, constructed: true, tag: tag, children: children}
+}
+
+func newSequence(children ...*packet) *packet {
+	return newConstructed(classUniversal, tagSequence, children...)
+}
+
+func newString(class, tag byte, value string) *packet {
+	return &packet{class: class, tag: tag, value: []byte(value)}
+}
+
+func newInteger(class, tag byte, value int64) *packet {
+	// the minimal two's complement encoding
+	content := []byte{byte(value)}
+	for v := value >> 8; !(v == 0 && content[0]&0x80 == 0) && !(v == -1 && content[0]&0x80 != 0); v >>= 8 {
+		content = append([]byte{byte(v)}, content...)
+	}
+	return &packet{class: class, tag: tag, value: content}
+}
+
+func newBoolean(value bool) *packet {
+	if value {
+		return &packet{class: classUniversal, tag: tagBoolean, value: []byte{0xff}}
+	}
+	return &packet{class: classUniversal, tag: tagBoolean, value: []byte{0x00}}
+}
+
+func (p *packet) append(children ...*packet) *packet {
+	p.children = append(p.children, children...)
+	return p
+}
+
+// is checks the class and tag of the packet
+func (p *packet) is(class, tag byte) bool {
+	return p.class == class && p.tag == tag
+}
+
+func (p *packet) int() (int64, error) {
+	if len(p.value) == 0 || len(p.value) > 8 {
+		return 0, fmt.Errorf("invalid integer length %d", len(p.value))
+	}
+	value := int64(int8(p.value[0]))
+	for _, b := range p.value[1:] {
+		value = value<<8 | int64(b)
+	}
+	return value, nil
+}
+
+func (p *packet) string() string {
+	return string(p.value)
+}
+
+// child returns the child of the index, nil if the packet does not have it
+func (p *packet) child(index int) *packet {
+	if index < 0 || index >= len(p.children) {
+		return nil
+	}
+	return p.children[index]
+}
+
+func (p *packet) bytes() []byte {
+	content := p.value
+	if p.constructed {
+		content = nil
+		for _, child := range p.children {
+			content = append(content, child.bytes()...)
+		}
+	}
+
+	header := p.class | p.tag
+	if p.constructed {
+		header |= 0x20
+	}
+	data := append([]byte{header}, encodeLength(len(content))...)
+	return append(data, content...)
+}
+
+func encodeLength(length int) []byte {
+	if length < 0x80 {
+		return []byte{byte(length)}
+	}
+	var data []byte
+	for ; length > 0; length >>= 8 {
+		data = append([]byte{byte(length)}, data...)
+	}
+	return append([]byte{0x80 | byte(len(data))}, data...)
+}
+
+// readPacket reads a whole ber encoded element from the reader
+func readPacket(r io.Reader) (*packet, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	data := header
+	length := int(header[1])
+	if header[1]&0x80 != 0 {
+		lengthBytes := int(header[1] & 0x7f)
+		if lengthBytes == 0 || lengthBytes > 4 {
+			return nil, fmt.Errorf("unsupported ber length of %d bytes", lengthBytes)
+		}
+		buf := make([]byte, lengthBytes)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		data = append(data, buf...)
+		length = 0
+		for _, b := range buf {
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxPacketSize {
+		return nil, fmt.Errorf("ber element of %d bytes is too large", length)
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+
+	p, rest, err := decodePacket(append(data, content...))
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("unexpected data after ber element")
+	}
+	return p, nil
+}
+
+// decodePacket decodes the first ber encoded element of the data and returns the remaining data
+func decodePacket(data []byte) (*packet, []byte, error) {
+	if len(data) < 2 {
+		return nil, nil, errors.New("ber element is truncated")
+	}
+	header := data[0]
+	if header&0x1f == 0x1f {
+		return nil, nil, errors.New("unsupported ber high tag number")
+	}
+	p := &packet{
+		class:       header & 0xc0,
+		constructed: header&0x20 != 0,
+		tag:         header & 0x1f,
+	}
+
+	length, offset := int(data[1]), 2
+	if data[1]&0x80 != 0 {
+		lengthBytes := int(data[1] & 0x7f)
+		if lengthBytes == 0 || lengthBytes > 4 || len(data) < 2+lengthBytes {
+			return nil, nil, errors.New("invalid ber length")
+		}
+		length = 0
+		for _, b := range data[2 : 2+lengthBytes] {
+			length = length<<8 | int(b)
+		}
+		offset += lengthBytes
+	}
+	if length < 0 || len(data)-offset < length {
+		return nil, nil, errors.New("ber element is truncated")
+	}
+	content := data[offset : offset+length]
+
+	if !p.constructed {
+		p.value = content
+		return p, data[offset+length:], nil
+	}
+	for len(content) > 0 {
+		child, rest, err := decodePacket(content)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.children = append(p.children, child)
+		content = rest
+	}
+	return p, data[offset+length:], nil
+}
@@ -0,0 +1,283 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// the application tags of the protocol operations, rfc4511 section 4.2
+const (
+	opBindRequest         = 0
+	opBindResponse        = 1
+	opUnbindRequest       = 2
+	opSearchRequest       = 3
+	opSearchResultEntry   = 4
+	opSearchResultDone    = 5
+	opSearchResultRef     = 19
+	opExtendedRequest     = 23
+	opExtendedResponse    = 24
+	startTLSOID           = "1.3.6.1.4.1.1466.20037"
+	scopeWholeSubtree     = 2
+	derefAliasesNever     = 0
+	resultSuccess         = 0
+	resultSizeLimitExceed = 4
+)
+
+// resultError is the error result returned by the ldap server
+type resultError struct {
+	Code    int64
+	Message string
+}
+
+func (e *resultError) Error() string {
+	return fmt.Sprintf("ldap result code %d: %s", e.Code, e.Message)
+}
+
+// entry is an entry of the search result, the attribute names are lower cased
+type entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// get returns the first value of the attribute
+func (e *entry) get(attr string) string {
+	values := e.Attributes[strings.ToLower(attr)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// conn is a connection to the ldap server, the operations are sent one by one
+type conn struct {
+	conn      net.Conn
+	timeout   time.Duration
+	messageID int64
+}
+
+// dial connects to the ldap server of the ldap:// or ldaps:// url
+func dial(conf *config) (*conn, error) {
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ldap url %s, err: %v", conf.URL, err)
+	}
+	tlsConf, err := conf.tlsConfig(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: conf.Timeout}
+	var c net.Conn
+	switch u.Scheme {
+	case "ldap":
+		c, err = dialer.Dial("tcp", hostPort(u, "389"))
+	case "ldaps":
+		c, err = tls.DialWithDialer(dialer, "tcp", hostPort(u, "636"), tlsConf)
+	default:
+		return nil, fmt.Errorf("invalid ldap url %s, unsupported scheme", conf.URL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect to ldap server %s failed, err: %v", conf.URL, err)
+	}
+
+	l := &conn{conn: c, timeout: conf.Timeout}
+	if u.Scheme == "ldap" && conf.StartTLS {
+		if err := l.startTLS(tlsConf); err != nil {
+			l.close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func hostPort(u *url.URL, defaultPort string) string {
+	if len(u.Port()) > 0 {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), defaultPort)
+}
+
+func (l *conn) startTLS(tlsConf *tls.Config) error {
+	request := newConstructed(classApplication, opExtendedRequest, newString(classContext, 0, startTLSOID))
+	response, err := l.request(request, opExtendedResponse)
+	if err != nil {
+		return fmt.Errorf("ldap start tls failed, err: %v", err)
+	}
+	if err := checkResult(response[0]); err != nil {
+		return fmt.Errorf("ldap start tls failed, err: %v", err)
+	}
+
+	tlsConn := tls.Client(l.conn, tlsConf)
+	tlsConn.SetDeadline(time.Now().Add(l.timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		return fmt.Errorf("ldap start tls handshake failed, err: %v", err)
+	}
+	l.conn = tlsConn
+	return nil
+}
+
+// bind authenticates with the simple authentication, an empty password is rejected
+// as the server may take it as an unauthenticated bind.
+func (l *conn) bind(dn, password string) error {
+	if len(password) == 0 {
+		return errors.New("ldap bind password is empty")
+	}
+	request := newConstructed(classApplication, opBindRequest,
+		newInteger(classUniversal, tagInteger, 3),
+		newString(classUniversal, tagOctetString, dn),
+		newString(classContext, 0, password))
+	response, err := l.request(request, opBindResponse)
+	if err != nil {
+		return err
+	}
+	return checkResult(response[0])
+}
+
+// search searches the subtree of the base dn, the size limit exceeded result
+// is not an error, the entries returned are truncated.
+func (l *conn) search(baseDN, filter string, attributes []string, sizeLimit int64) ([]*entry, error) {
+	compiled, err := compileFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	attrs := newSequence()
+	for _, attr := range attributes {
+		attrs.append(newString(classUniversal, tagOctetString, attr))
+	}
+	request := newConstructed(classApplication, opSearchRequest,
+		newString(classUniversal, tagOctetString, baseDN),
+		newInteger(classUniversal, tagEnumerated, scopeWholeSubtree),
+		newInteger(classUniversal, tagEnumerated, derefAliasesNever),
+		newInteger(classUniversal, tagInteger, sizeLimit),
+		newInteger(classUniversal, tagInteger, int64(l.timeout/time.Second)),
+		newBoolean(false),
+		compiled,
+		attrs)
+
+	responses, err := l.request(request, opSearchResultDone)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResult(responses[len(responses)-1]); err != nil {
+		if resErr, ok := err.(*resultError); !ok || resErr.Code != resultSizeLimitExceed {
+			return nil, err
+		}
+	}
+
+	entries := make([]*entry, 0)
+	for _, response := range responses[:len(responses)-1] {
+		if !response.is(classApplication, opSearchResultEntry) {
+			// search result references are not followed
+			continue
+		}
+		e, err := parseEntry(response)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func parseEntry(p *packet) (*entry, error) {
+	if len(p.children) != 2 {
+		return nil, errors.New("invalid ldap search result entry")
+	}
+	e := &entry{DN: p.children[0].string(), Attributes: make(map[string][]string)}
+	for _, attr := range p.children[1].children {
+		if len(attr.children) != 2 {
+			return nil, errors.New("invalid ldap search result attribute")
+		}
+		name := strings.ToLower(attr.children[0].string())
+		for _, value := range attr.children[1].children {
+			e.Attributes[name] = append(e.Attributes[name], value.string())
+		}
+	}
+	return e, nil
+}
+
+// request sends the operation and reads the responses until the one of the done tag,
+// the protocol operations of the responses are returned.
+func (l *conn) request(op *packet, doneTag byte) ([]*packet, error) {
+	l.messageID++
+	message := newSequence(newInteger(classUniversal, tagInteger, l.messageID), op)
+
+	l.conn.SetDeadline(time.Now().Add(l.timeout))
+	if _, err := l.conn.Write(message.bytes()); err != nil {
+		return nil, fmt.Errorf("send ldap request failed, err: %v", err)
+	}
+
+	responses := make([]*packet, 0)
+	for {
+		response, err := readPacket(l.conn)
+		if err != nil {
+			return nil, fmt.Errorf("read ldap response failed, err: %v", err)
+		}
+		if len(response.children) < 2 {
+			return nil, errors.New("invalid ldap message")
+		}
+		id, err := response.children[0].int()
+		if err != nil {
+			return nil, fmt.Errorf("invalid ldap message id, err: %v", err)
+		}
+		if id != l.messageID {
+			// the unsolicited notification, like the notice of disconnection
+			if id == 0 {
+				if err := checkResult(response.children[1]); err != nil {
+					return nil, err
+				}
+				return nil, errors.New("ldap server closed the connection")
+			}
+			continue
+		}
+
+		op := response.children[1]
+		if op.class != classApplication {
+			return nil, errors.New("invalid ldap protocol operation")
+		}
+		responses = append(responses, op)
+		if op.tag == doneTag {
+			return responses, nil
+		}
+	}
+}
+
+// checkResult checks the ldap result of the response
+func checkResult(p *packet) error {
+	if len(p.children) < 3 {
+		return errors.New("invalid ldap result")
+	}
+	code, err := p.children[0].int()
+	if err != nil {
+		return fmt.Errorf("invalid ldap result code, err: %v", err)
+	}
+	if code != resultSuccess {
+		return &resultError{Code: code, Message: p.children[2].string()}
+	}
+	return nil
+}
+
+func (l *conn) close() {
+	message := newSequence(newInteger(classUniversal, tagInteger, l.messageID+1),
+		&packet{class: classApplication, tag: opUnbindRequest})
+	l.conn.SetDeadline(time.Now().Add(l.timeout))
+	l.conn.Write(message.bytes())
+	l.conn.Close()
+}
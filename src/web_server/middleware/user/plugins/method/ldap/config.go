@@ -0,0 +1,190 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// config is the [ldap] section of the web server configuration
+type config struct {
+	// URL is the ldap server url, ldap://host:389 or ldaps://host:636
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	CAFile             string
+	Timeout            time.Duration
+
+	// BindDN and BindPassword is the service account to search the users and groups,
+	// the search is anonymous if not set.
+	BindDN       string
+	BindPassword string
+
+	UserBaseDN string
+	// UserFilter selects the entries of the users, the login name is added to it to find the user
+	UserFilter string
+	UserAttr   string
+	ChNameAttr string
+	EmailAttr  string
+	PhoneAttr  string
+	// UserListLimit is the max number of the users returned by the user list
+	UserListLimit int64
+
+	GroupBaseDN string
+	// GroupFilter finds the groups of the user, {dn} and {user} are replaced by the
+	// user's dn and login name
+	GroupFilter   string
+	GroupNameAttr string
+	// OwnerGroups maps the group names to the supplier accounts, the user must be in one of the
+	// groups if it is set, otherwise the default supplier account is used.
+	OwnerGroups []ownerGroup
+}
+
+type ownerGroup struct {
+	Group   string
+	OwnerID string
+}
+
+func parseConfig(configMap map[string]string) (*config, error) {
+	conf := &config{
+		URL:           strings.TrimSpace(configMap["ldap.url"]),
+		CAFile:        configMap["ldap.ca_file"],
+		BindDN:        configMap["ldap.bind_dn"],
+		BindPassword:  configMap["ldap.bind_password"],
+		UserBaseDN:    configMap["ldap.user_base_dn"],
+		UserFilter:    getOrDefault(configMap, "ldap.user_filter", "(objectClass=person)"),
+		UserAttr:      getOrDefault(configMap, "ldap.user_attr", "uid"),
+		ChNameAttr:    getOrDefault(configMap, "ldap.chname_attr", "cn"),
+		EmailAttr:     getOrDefault(configMap, "ldap.email_attr", "mail"),
+		PhoneAttr:     getOrDefault(configMap, "ldap.phone_attr", "telephoneNumber"),
+		GroupBaseDN:   configMap["ldap.group_base_dn"],
+		GroupFilter:   getOrDefault(configMap, "ldap.group_filter", "(|(member={dn})(uniqueMember={dn})(memberUid={user}))"),
+		GroupNameAttr: getOrDefault(configMap, "ldap.group_name_attr", "cn"),
+		Timeout:       10 * time.Second,
+		UserListLimit: 1000,
+	}
+	if len(conf.URL) == 0 {
+		return nil, errors.New("ldap.url is not set")
+	}
+	if len(conf.UserBaseDN) == 0 {
+		return nil, errors.New("ldap.user_base_dn is not set")
+	}
+	if len(conf.BindDN) > 0 && len(conf.BindPassword) == 0 {
+		return nil, errors.New("ldap.bind_password is not set")
+	}
+
+	var err error
+	if conf.StartTLS, err = parseBool(configMap, "ldap.start_tls"); err != nil {
+		return nil, err
+	}
+	if conf.InsecureSkipVerify, err = parseBool(configMap, "ldap.insecure_skip_verify"); err != nil {
+		return nil, err
+	}
+	if timeout := strings.TrimSpace(configMap["ldap.timeout"]); len(timeout) > 0 {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("invalid ldap.timeout %s", timeout)
+		}
+		conf.Timeout = time.Duration(seconds) * time.Second
+	}
+	if limit := strings.TrimSpace(configMap["ldap.user_list_limit"]); len(limit) > 0 {
+		conf.UserListLimit, err = strconv.ParseInt(limit, 10, 64)
+		if err != nil || conf.UserListLimit < 0 {
+			return nil, fmt.Errorf("invalid ldap.user_list_limit %s", limit)
+		}
+	}
+
+	// owner_groups is like "ops:0,game:1", a group may be mapped to several supplier accounts
+	for _, item := range strings.Split(configMap["ldap.owner_groups"], ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		sep := strings.LastIndex(item, ":")
+		if sep <= 0 || sep == len(item)-1 {
+			return nil, fmt.Errorf("invalid ldap.owner_groups item %s, group:supplier_account expected", item)
+		}
+		conf.OwnerGroups = append(conf.OwnerGroups, ownerGroup{
+			Group:   strings.TrimSpace(item[:sep]),
+			OwnerID: strings.TrimSpace(item[sep+1:]),
+		})
+	}
+	if len(conf.OwnerGroups) > 0 && len(conf.GroupBaseDN) == 0 {
+		return nil, errors.New("ldap.group_base_dn is not set")
+	}
+	return conf, nil
+}
+
+func (c *config) tlsConfig(serverName string) (*tls.Config, error) {
+	tlsConf := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if len(c.CAFile) == 0 {
+		return tlsConf, nil
+	}
+	ca, err := ioutil.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read ldap.ca_file failed, err: %v", err)
+	}
+	tlsConf.RootCAs = x509.NewCertPool()
+	if !tlsConf.RootCAs.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in ldap.ca_file %s", c.CAFile)
+	}
+	return tlsConf, nil
+}
+
+// userFilter returns the filter to find the user of the login name
+func (c *config) userFilter(userName string) string {
+	return fmt.Sprintf("(&%s(%s=%s))", wrapFilter(c.UserFilter), c.UserAttr, escapeFilter(userName))
+}
+
+// groupFilter returns the filter to find the groups of the user
+func (c *config) groupFilter(userDN, userName string) string {
+	replacer := strings.NewReplacer("{dn}", escapeFilter(userDN), "{user}", escapeFilter(userName))
+	return replacer.Replace(wrapFilter(c.GroupFilter))
+}
+
+func wrapFilter(filter string) string {
+	filter = strings.TrimSpace(filter)
+	if strings.HasPrefix(filter, "(") {
+		return filter
+	}
+	return "(" + filter + ")"
+}
+
+func parseBool(configMap map[string]string, key string) (bool, error) {
+	value := strings.TrimSpace(configMap[key])
+	if len(value) == 0 {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %s", key, value)
+	}
+	return b, nil
+}
+
+func getOrDefault(configMap map[string]string, key, defaultValue string) string {
+	if value := strings.TrimSpace(configMap[key]); len(value) > 0 {
+		return value
+	}
+	return defaultValue
+}
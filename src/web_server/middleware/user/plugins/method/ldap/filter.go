@@ -0,0 +1,206 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// the context tags of the filter choices, rfc4511 section 4.5.1
+const (
+	filterAnd            = 0
+	filterOr             = 1
+	filterNot            = 2
+	filterEqualityMatch  = 3
+	filterSubstrings     = 4
+	filterGreaterOrEqual = 5
+	filterLessOrEqual    = 6
+	filterPresent        = 7
+	filterApproxMatch    = 8
+)
+
+// the context tags of the substrings
+const (
+	substringInitial = 0
+	substringAny     = 1
+	substringFinal   = 2
+)
+
+// escapeFilter escapes the value used in a filter, rfc4515 section 3
+func escapeFilter(value string) string {
+	var buf strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '*', '(', ')', 0:
+			fmt.Fprintf(&buf, "\\%02x", c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// compileFilter compiles the string representation of a filter to its ber encoding
+func compileFilter(filter string) (*packet, error) {
+	filter = strings.TrimSpace(filter)
+	if !strings.HasPrefix(filter, "(") {
+		// the outermost parentheses may be omitted
+		filter = "(" + filter + ")"
+	}
+	p, pos, err := parseFilter(filter, 0)
+	if err != nil {
+		return nil, err
+	}
+	if pos != len(filter) {
+		return nil, fmt.Errorf("invalid filter %s, unexpected data at %d", filter, pos)
+	}
+	return p, nil
+}
+
+// parseFilter parses the filter starting at the pos, returns the filter and the position after it
+func parseFilter(filter string, pos int) (*packet, int, error) {
+	if pos >= len(filter) || filter[pos] != '(' {
+		return nil, pos, fmt.Errorf("invalid filter %s, '(' expected at %d", filter, pos)
+	}
+	pos++
+	if pos >= len(filter) {
+		return nil, pos, fmt.Errorf("invalid filter %s, unexpected end", filter)
+	}
+
+	switch filter[pos] {
+	case '&', '|':
+		tag := byte(filterAnd)
+		if filter[pos] == '|' {
+			tag = filterOr
+		}
+		p := newConstructed(classContext, tag)
+		pos++
+		for pos < len(filter) && filter[pos] == '(' {
+			child, next, err := parseFilter(filter, pos)
+			if err != nil {
+				return nil, next, err
+			}
+			p.append(child)
+			pos = next
+		}
+		if len(p.children) == 0 {
+			return nil, pos, fmt.Errorf("invalid filter %s, empty filter set at %d", filter, pos)
+		}
+		return closeFilter(filter, pos, p)
+	case '!':
+		child, next, err := parseFilter(filter, pos+1)
+		if err != nil {
+			return nil, next, err
+		}
+		return closeFilter(filter, next, newConstructed(classContext, filterNot, child))
+	}
+
+	end := strings.IndexByte(filter[pos:], ')')
+	if end < 0 {
+		return nil, pos, fmt.Errorf("invalid filter %s, ')' expected", filter)
+	}
+	p, err := parseItem(filter[pos : pos+end])
+	if err != nil {
+		return nil, pos, fmt.Errorf("invalid filter %s, %v", filter, err)
+	}
+	return p, pos + end + 1, nil
+}
+
+func closeFilter(filter string, pos int, p *packet) (*packet, int, error) {
+	if pos >= len(filter) || filter[pos] != ')' {
+		return nil, pos, fmt.Errorf("invalid filter %s, ')' expected at %d", filter, pos)
+	}
+	return p, pos + 1, nil
+}
+
+// parseItem parses the simple, present or substring item like "cn=admin"
+func parseItem(item string) (*packet, error) {
+	eq := strings.IndexByte(item, '=')
+	if eq <= 0 {
+		return nil, fmt.Errorf("'=' expected in %s", item)
+	}
+	attr, value := item[:eq], item[eq+1:]
+
+	tag := byte(filterEqualityMatch)
+	switch attr[len(attr)-1] {
+	case '>':
+		tag, attr = filterGreaterOrEqual, attr[:len(attr)-1]
+	case '<':
+		tag, attr = filterLessOrEqual, attr[:len(attr)-1]
+	case '~':
+		tag, attr = filterApproxMatch, attr[:len(attr)-1]
+	}
+	if len(attr) == 0 {
+		return nil, fmt.Errorf("attribute expected in %s", item)
+	}
+
+	if tag == filterEqualityMatch && value == "*" {
+		return newString(classContext, filterPresent, attr), nil
+	}
+	if tag == filterEqualityMatch && strings.Contains(value, "*") {
+		parts := strings.Split(value, "*")
+		substrings := newSequence()
+		for i, part := range parts {
+			if len(part) == 0 {
+				continue
+			}
+			unescaped, err := unescapeFilter(part)
+			if err != nil {
+				return nil, err
+			}
+			partTag := byte(substringAny)
+			switch i {
+			case 0:
+				partTag = substringInitial
+			case len(parts) - 1:
+				partTag = substringFinal
+			}
+			substrings.append(newString(classContext, partTag, unescaped))
+		}
+		return newConstructed(classContext, filterSubstrings, newString(classUniversal, tagOctetString, attr), substrings), nil
+	}
+
+	unescaped, err := unescapeFilter(value)
+	if err != nil {
+		return nil, err
+	}
+	return newConstructed(classContext, tag,
+		newString(classUniversal, tagOctetString, attr),
+		newString(classUniversal, tagOctetString, unescaped)), nil
+}
+
+// unescapeFilter decodes the \XX escaped bytes of the filter value
+func unescapeFilter(value string) (string, error) {
+	if !strings.Contains(value, "\\") {
+		return value, nil
+	}
+	var buf strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			buf.WriteByte(value[i])
+			continue
+		}
+		if i+2 >= len(value) {
+			return "", fmt.Errorf("invalid escape in %s", value)
+		}
+		b, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %s", value)
+		}
+		buf.WriteByte(b[0])
+		i += 2
+	}
+	return buf.String(), nil
+}
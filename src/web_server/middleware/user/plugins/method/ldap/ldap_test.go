@@ -0,0 +1,362 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+// stubServer is an in-process ldap server serving the bind and search operations of the entries
+type stubServer struct {
+	listener  net.Listener
+	entries   []*entry
+	passwords map[string]string
+}
+
+func newStubServer(t *testing.T) *stubServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &stubServer{
+		listener: listener,
+		passwords: map[string]string{
+			"cn=admin,dc=example,dc=com":           "admin",
+			"uid=alice,ou=users,dc=example,dc=com": "alice",
+			"uid=bob,ou=users,dc=example,dc=com":   "bob",
+			"uid=carol,ou=users,dc=example,dc=com": "carol",
+		},
+		entries: []*entry{
+			{DN: "uid=alice,ou=users,dc=example,dc=com", Attributes: map[string][]string{
+				"objectclass": {"person"}, "uid": {"alice"}, "cn": {"Alice"}, "mail": {"alice@example.com"}}},
+			{DN: "uid=bob,ou=users,dc=example,dc=com", Attributes: map[string][]string{
+				"objectclass": {"person"}, "uid": {"bob"}, "cn": {"Bob"}}},
+			{DN: "uid=carol,ou=users,dc=example,dc=com", Attributes: map[string][]string{
+				"objectclass": {"person"}, "uid": {"carol"}, "cn": {"Carol"}}},
+			{DN: "cn=ops,ou=groups,dc=example,dc=com", Attributes: map[string][]string{
+				"objectclass": {"groupOfNames"}, "cn": {"ops"},
+				"member": {"uid=alice,ou=users,dc=example,dc=com", "uid=bob,ou=users,dc=example,dc=com"}}},
+			{DN: "cn=game,ou=groups,dc=example,dc=com", Attributes: map[string][]string{
+				"objectclass": {"posixGroup"}, "cn": {"game"}, "memberuid": {"alice"}}},
+		},
+	}
+	go s.serve()
+	return s
+}
+
+func (s *stubServer) url() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *stubServer) serve() {
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(c)
+	}
+}
+
+func (s *stubServer) handle(c net.Conn) {
+	defer c.Close()
+	for {
+		message, err := readPacket(c)
+		if err != nil || len(message.children) < 2 {
+			return
+		}
+		id, op := message.children[0], message.children[1]
+		reply := func(tag byte, children ...*packet) {
+			c.Write(newSequence(id, newConstructed(classApplication, tag, children...)).bytes())
+		}
+		result := func(tag byte, code int64) {
+			reply(tag, newInteger(classUniversal, tagEnumerated, code),
+				newString(classUniversal, tagOctetString, ""),
+				newString(classUniversal, tagOctetString, ""))
+		}
+
+		switch op.tag {
+		case opBindRequest:
+			dn, password := op.children[1].string(), op.children[2].string()
+			if expect, ok := s.passwords[dn]; ok && password == expect {
+				result(opBindResponse, resultSuccess)
+			} else {
+				// invalid credentials
+				result(opBindResponse, 49)
+			}
+		case opSearchRequest:
+			baseDN := strings.ToLower(op.children[0].string())
+			sizeLimit, _ := op.children[3].int()
+			attrs := make([]string, 0)
+			for _, attr := range op.children[7].children {
+				attrs = append(attrs, strings.ToLower(attr.string()))
+			}
+			count := int64(0)
+			exceeded := false
+			for _, e := range s.entries {
+				if !strings.HasSuffix(strings.ToLower(e.DN), baseDN) || !matchFilter(op.children[6], e) {
+					continue
+				}
+				if sizeLimit > 0 && count == sizeLimit {
+					exceeded = true
+					break
+				}
+				count++
+				attributes := newSequence()
+				for _, attr := range attrs {
+					values := newConstructed(classUniversal, tagSet)
+					for _, value := range e.Attributes[attr] {
+						values.append(newString(classUniversal, tagOctetString, value))
+					}
+					attributes.append(newSequence(newString(classUniversal, tagOctetString, attr), values))
+				}
+				reply(opSearchResultEntry, newString(classUniversal, tagOctetString, e.DN), attributes)
+			}
+			if exceeded {
+				result(opSearchResultDone, resultSizeLimitExceed)
+			} else {
+				result(opSearchResultDone, resultSuccess)
+			}
+		case opUnbindRequest:
+			return
+		default:
+			// protocol error
+			result(opExtendedResponse, 2)
+		}
+	}
+}
+
+func matchFilter(filter *packet, e *entry) bool {
+	switch filter.tag {
+	case filterAnd:
+		for _, child := range filter.children {
+			if !matchFilter(child, e) {
+				return false
+			}
+		}
+		return true
+	case filterOr:
+		for _, child := range filter.children {
+			if matchFilter(child, e) {
+				return true
+			}
+		}
+		return false
+	case filterNot:
+		return !matchFilter(filter.children[0], e)
+	case filterPresent:
+		return len(e.Attributes[strings.ToLower(filter.string())]) > 0
+	case filterEqualityMatch:
+		for _, value := range e.Attributes[strings.ToLower(filter.children[0].string())] {
+			if strings.EqualFold(value, filter.children[1].string()) {
+				return true
+			}
+		}
+		return false
+	case filterSubstrings:
+		for _, value := range e.Attributes[strings.ToLower(filter.children[0].string())] {
+			value = strings.ToLower(value)
+			matched := true
+			for _, sub := range filter.children[1].children {
+				part := strings.ToLower(sub.string())
+				switch sub.tag {
+				case substringInitial:
+					matched = matched && strings.HasPrefix(value, part)
+				case substringFinal:
+					matched = matched && strings.HasSuffix(value, part)
+				default:
+					matched = matched && strings.Contains(value, part)
+				}
+			}
+			if matched {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func testConfig(t *testing.T, s *stubServer, extra map[string]string) *config {
+	configMap := map[string]string{
+		"ldap.url":           s.url(),
+		"ldap.bind_dn":       "cn=admin,dc=example,dc=com",
+		"ldap.bind_password": "admin",
+		"ldap.user_base_dn":  "ou=users,dc=example,dc=com",
+	}
+	for key, value := range extra {
+		configMap[key] = value
+	}
+	conf, err := parseConfig(configMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
+
+func TestCompileFilter(t *testing.T) {
+	p, err := compileFilter("(&(objectClass=person)(|(uid=al*ce)(!(cn=*)))(mail=a\\2ab))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.is(classContext, filterAnd) || len(p.children) != 3 {
+		t.Fatalf("unexpected filter: %+v", p)
+	}
+	or := p.children[1]
+	if !or.is(classContext, filterOr) || !or.children[0].is(classContext, filterSubstrings) ||
+		!or.children[1].children[0].is(classContext, filterPresent) {
+		t.Errorf("unexpected or filter: %+v", or)
+	}
+	if value := p.children[2].children[1].string(); value != "a*b" {
+		t.Errorf("unexpected unescaped value: %s", value)
+	}
+
+	decoded, rest, err := decodePacket(p.bytes())
+	if err != nil || len(rest) != 0 || len(decoded.children) != 3 {
+		t.Errorf("decode filter failed, err: %v", err)
+	}
+
+	for _, invalid := range []string{"(uid=alice", "(&)", "(=alice)", "(uid=a\\zz)", "(uid=alice))"} {
+		if _, err := compileFilter(invalid); err == nil {
+			t.Errorf("filter %s should be invalid", invalid)
+		}
+	}
+
+	if escaped := escapeFilter("*)(uid=*"); escaped != "\\2a\\29\\28uid=\\2a" {
+		t.Errorf("unexpected escaped value: %s", escaped)
+	}
+}
+
+func TestInteger(t *testing.T) {
+	for _, value := range []int64{0, 1, 127, 128, 255, 256, 65535, -1, -128, -129, 1 << 40} {
+		p := newInteger(classUniversal, tagInteger, value)
+		decoded, _, err := decodePacket(p.bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := decoded.int(); got != value {
+			t.Errorf("integer %d decoded as %d", value, got)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	if _, err := parseConfig(map[string]string{"ldap.user_base_dn": "dc=example,dc=com"}); err == nil {
+		t.Error("config without url should be invalid")
+	}
+	if _, err := parseConfig(map[string]string{"ldap.url": "ldap://localhost",
+		"ldap.user_base_dn": "dc=example,dc=com", "ldap.owner_groups": "ops:0"}); err == nil {
+		t.Error("config with owner groups but without group base dn should be invalid")
+	}
+	conf, err := parseConfig(map[string]string{
+		"ldap.url":           "ldap://localhost",
+		"ldap.user_base_dn":  "dc=example,dc=com",
+		"ldap.group_base_dn": "ou=groups,dc=example,dc=com",
+		"ldap.owner_groups":  "ops:0, game:1",
+		"ldap.timeout":       "3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.OwnerGroups) != 2 || conf.OwnerGroups[1].Group != "game" || conf.OwnerGroups[1].OwnerID != "1" {
+		t.Errorf("unexpected owner groups: %+v", conf.OwnerGroups)
+	}
+	if conf.UserAttr != "uid" || conf.Timeout.Seconds() != 3 {
+		t.Errorf("unexpected config: %+v", conf)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := newStubServer(t)
+	defer s.listener.Close()
+	conf := testConfig(t, s, nil)
+
+	user, err := authenticate(conf, "alice", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UserName != "alice" || user.ChName != "Alice" || user.Email != "alice@example.com" ||
+		user.OnwerUin != "0" || user.MultiSupplier {
+		t.Errorf("unexpected user: %+v", user)
+	}
+
+	if _, err := authenticate(conf, "alice", "wrong"); err != errInvalidCredentials {
+		t.Errorf("wrong password should be invalid credentials, err: %v", err)
+	}
+	if _, err := authenticate(conf, "nobody", "alice"); err != errInvalidCredentials {
+		t.Errorf("unknown user should be invalid credentials, err: %v", err)
+	}
+	if _, err := authenticate(conf, "*", "alice"); err != errInvalidCredentials {
+		t.Errorf("wildcard user name should not match any user, err: %v", err)
+	}
+	if _, err := authenticate(conf, "alice", ""); err == nil {
+		t.Error("empty password should be rejected")
+	}
+
+	badAccount := testConfig(t, s, map[string]string{"ldap.bind_password": "wrong"})
+	if _, err := authenticate(badAccount, "alice", "alice"); err == nil || err == errInvalidCredentials {
+		t.Errorf("wrong service account should fail, err: %v", err)
+	}
+}
+
+func TestOwnerGroups(t *testing.T) {
+	s := newStubServer(t)
+	defer s.listener.Close()
+	conf := testConfig(t, s, map[string]string{
+		"ldap.group_base_dn": "ou=groups,dc=example,dc=com",
+		"ldap.owner_groups":  "game:1,ops:0,admin:2",
+	})
+
+	user, err := authenticate(conf, "alice", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.OnwerUin != "1" || !user.MultiSupplier || len(user.OwnerUinArr) != 2 || user.OwnerUinArr[1].OwnerID != "0" {
+		t.Errorf("unexpected owners of alice: %+v", user)
+	}
+
+	user, err = authenticate(conf, "bob", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.OnwerUin != "0" || user.MultiSupplier || len(user.OwnerUinArr) != 1 {
+		t.Errorf("unexpected owners of bob: %+v", user)
+	}
+
+	if _, err := authenticate(conf, "carol", "carol"); err == nil {
+		t.Error("user without any group of the supplier accounts should not login")
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	s := newStubServer(t)
+	defer s.listener.Close()
+
+	users, err := listUsers(testConfig(t, s, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 3 || users[0].EnName != "alice" || users[0].CnName != "Alice" {
+		t.Errorf("unexpected users: %+v", users)
+	}
+
+	users, err = listUsers(testConfig(t, s, map[string]string{"ldap.user_list_limit": "2"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Errorf("user list should be limited, users: %+v", users)
+	}
+}
@@ -0,0 +1,251 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	commonutil "configcenter/src/common/util"
+	"configcenter/src/web_server/middleware/user/plugins/manager"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	plugin := &metadata.LoginPluginInfo{
+		Name:       "ldap login",
+		Version:    common.BKLDAPLoginUserPluginVersion,
+		HandleFunc: &user{},
+	}
+	manager.RegisterPlugin(plugin)
+}
+
+// errInvalidCredentials is returned when the user does not exist or the password is wrong,
+// they are not told apart to the user.
+var errInvalidCredentials = errors.New("invalid user name or password")
+
+type user struct {
+}
+
+// LoginUser logs the user in with the user name and password posted by the login page
+func (m *user) LoginUser(c *gin.Context, config map[string]string, isMultiOwner bool) (user *metadata.LoginUserInfo, loginSucc bool) {
+	rid := commonutil.GetHTTPCCRequestID(c.Request.Header)
+
+	if c.Request.Method != http.MethodPost {
+		return nil, false
+	}
+	userName, password := strings.TrimSpace(c.PostForm("username")), c.PostForm("password")
+	if len(userName) == 0 || len(password) == 0 {
+		return nil, false
+	}
+
+	conf, err := parseConfig(config)
+	if err != nil {
+		blog.Errorf("ldap login failed, invalid config, err: %v, rid: %s", err, rid)
+		return nil, false
+	}
+	user, err = authenticate(conf, userName, password)
+	if err != nil {
+		blog.Errorf("ldap login failed, user: %s, err: %v, rid: %s", userName, err, rid)
+		return nil, false
+	}
+
+	// the session is bound to the login by a token cookie like the blueking login system does
+	user.BkToken, err = randomToken()
+	if err != nil {
+		blog.Errorf("ldap login failed, generate token failed, err: %v, rid: %s", err, rid)
+		return nil, false
+	}
+	c.SetCookie(common.HTTPCookieBKToken, user.BkToken, 0, "/", "", false, true)
+
+	blog.Infof("ldap login success, user: %s, owner: %s, rid: %s", user.UserName, user.OnwerUin, rid)
+	return user, true
+}
+
+// authenticate finds the user with the service account and binds as the user to check the password
+func authenticate(conf *config, userName, password string) (*metadata.LoginUserInfo, error) {
+	l, err := dial(conf)
+	if err != nil {
+		return nil, err
+	}
+	defer l.close()
+
+	if err := l.bindServiceAccount(conf); err != nil {
+		return nil, err
+	}
+	entries, err := l.search(conf.UserBaseDN, conf.userFilter(userName),
+		[]string{conf.UserAttr, conf.ChNameAttr, conf.EmailAttr, conf.PhoneAttr}, 2)
+	if err != nil {
+		return nil, fmt.Errorf("search user failed, err: %v", err)
+	}
+	switch len(entries) {
+	case 0:
+		return nil, errInvalidCredentials
+	case 1:
+	default:
+		return nil, fmt.Errorf("more than one entry found for the user")
+	}
+	userEntry := entries[0]
+
+	if err := l.bind(userEntry.DN, password); err != nil {
+		if _, ok := err.(*resultError); ok {
+			return nil, errInvalidCredentials
+		}
+		return nil, err
+	}
+
+	user := &metadata.LoginUserInfo{
+		// use the name in the directory, the login name may differ in case
+		UserName: userEntry.get(conf.UserAttr),
+		ChName:   userEntry.get(conf.ChNameAttr),
+		Email:    userEntry.get(conf.EmailAttr),
+		Phone:    userEntry.get(conf.PhoneAttr),
+		OnwerUin: common.BKDefaultOwnerID,
+	}
+	if len(user.UserName) == 0 {
+		user.UserName = userName
+	}
+	if len(user.ChName) == 0 {
+		user.ChName = user.UserName
+	}
+	if len(conf.OwnerGroups) == 0 {
+		return user, nil
+	}
+
+	// search the groups as the service account, the user may not be allowed to read them
+	if err := l.bindServiceAccount(conf); err != nil {
+		return nil, err
+	}
+	groups, err := l.search(conf.GroupBaseDN, conf.groupFilter(userEntry.DN, user.UserName), []string{conf.GroupNameAttr}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("search groups of the user failed, err: %v", err)
+	}
+	owners := mapOwners(conf, groups)
+	if len(owners) == 0 {
+		return nil, fmt.Errorf("user is not in any group of the supplier accounts")
+	}
+	user.OnwerUin = owners[0]
+	user.MultiSupplier = len(owners) > 1
+	for _, owner := range owners {
+		user.OwnerUinArr = append(user.OwnerUinArr, metadata.LoginUserInfoOwnerUinList{
+			OwnerID:   owner,
+			OwnerName: owner,
+		})
+	}
+	return user, nil
+}
+
+// mapOwners maps the groups to the supplier accounts in the order of the owner_groups config
+func mapOwners(conf *config, groups []*entry) []string {
+	names := make(map[string]bool)
+	for _, group := range groups {
+		for _, name := range group.Attributes[strings.ToLower(conf.GroupNameAttr)] {
+			names[strings.ToLower(name)] = true
+		}
+	}
+	owners := make([]string, 0)
+	exists := make(map[string]bool)
+	for _, mapping := range conf.OwnerGroups {
+		if names[strings.ToLower(mapping.Group)] && !exists[mapping.OwnerID] {
+			owners = append(owners, mapping.OwnerID)
+			exists[mapping.OwnerID] = true
+		}
+	}
+	return owners
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// bindServiceAccount binds as the service account, the connection stays anonymous if it is not set
+func (l *conn) bindServiceAccount(conf *config) error {
+	if len(conf.BindDN) == 0 {
+		return nil
+	}
+	if err := l.bind(conf.BindDN, conf.BindPassword); err != nil {
+		return fmt.Errorf("bind as %s failed, err: %v", conf.BindDN, err)
+	}
+	return nil
+}
+
+// GetUserList searches the directory for the users
+func (m *user) GetUserList(c *gin.Context, config map[string]string, params map[string]string) ([]*metadata.LoginSystemUserInfo, error) {
+	rid := commonutil.GetHTTPCCRequestID(c.Request.Header)
+
+	conf, err := parseConfig(config)
+	if err != nil {
+		blog.Errorf("get ldap user list failed, invalid config, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+	users, err := listUsers(conf)
+	if err != nil {
+		blog.Errorf("get ldap user list failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+	return users, nil
+}
+
+func listUsers(conf *config) ([]*metadata.LoginSystemUserInfo, error) {
+	l, err := dial(conf)
+	if err != nil {
+		return nil, err
+	}
+	defer l.close()
+
+	if err := l.bindServiceAccount(conf); err != nil {
+		return nil, err
+	}
+	entries, err := l.search(conf.UserBaseDN, wrapFilter(conf.UserFilter), []string{conf.UserAttr, conf.ChNameAttr}, conf.UserListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("search users failed, err: %v", err)
+	}
+
+	users := make([]*metadata.LoginSystemUserInfo, 0)
+	for _, e := range entries {
+		name := e.get(conf.UserAttr)
+		if len(name) == 0 {
+			continue
+		}
+		users = append(users, &metadata.LoginSystemUserInfo{
+			CnName: e.get(conf.ChNameAttr),
+			EnName: name,
+		})
+	}
+	return users, nil
+}
+
+// GetLoginUrl returns the login page of the web server, the user is redirected back after login
+func (m *user) GetLoginUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	loginURL := strings.TrimSuffix(config["site.domain_url"], "/") + common.WebLoginPagePath
+	return loginURL + "?" + url.Values{common.WebLoginRedirectKey: []string{c.Request.URL.RequestURI()}}.Encode()
+}
+
+// GetLogoutUrl clears the token cookie, the user logs in again at the login page
+func (m *user) GetLogoutUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	c.SetCookie(common.HTTPCookieBKToken, "", -1, "/", "", false, true)
+	return strings.TrimSuffix(config["site.domain_url"], "/") + common.WebLoginPagePath
+}
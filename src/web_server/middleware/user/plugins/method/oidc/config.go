@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"errors"
+	"strings"
+)
+
+// config is the [oidc] section of the web server configuration
+type config struct {
+	// Issuer is the issuer url of the provider, the endpoints are discovered from
+	// {issuer}/.well-known/openid-configuration
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the url of cmdb the provider redirects to with the authorization code,
+	// any page of cmdb can be used, the site domain url by default.
+	RedirectURL string
+	// PostLogoutRedirectURL is the url the provider redirects to after logout, the site domain url by default.
+	PostLogoutRedirectURL string
+	Scopes                []string
+	// CAFile is used to verify the certificate of the provider, the system ones are used if not set
+	CAFile string
+
+	// the claims of the id token mapped to the user info
+	UserClaim     string
+	ChNameClaim   string
+	EmailClaim    string
+	PhoneClaim    string
+	LanguageClaim string
+	// OwnerClaim is the claim of the supplier accounts of the user, a string or a string array,
+	// the default supplier account is used if not set.
+	OwnerClaim string
+}
+
+func parseConfig(configMap map[string]string) (*config, error) {
+	conf := &config{
+		Issuer:                strings.TrimSuffix(configMap["oidc.issuer"], "/"),
+		ClientID:              configMap["oidc.client_id"],
+		ClientSecret:          configMap["oidc.client_secret"],
+		RedirectURL:           configMap["oidc.redirect_url"],
+		PostLogoutRedirectURL: configMap["oidc.post_logout_redirect_url"],
+		CAFile:                configMap["oidc.ca_file"],
+		UserClaim:             getOrDefault(configMap, "oidc.user_claim", "preferred_username"),
+		ChNameClaim:           getOrDefault(configMap, "oidc.chname_claim", "name"),
+		EmailClaim:            getOrDefault(configMap, "oidc.email_claim", "email"),
+		PhoneClaim:            getOrDefault(configMap, "oidc.phone_claim", "phone_number"),
+		LanguageClaim:         getOrDefault(configMap, "oidc.language_claim", "locale"),
+		OwnerClaim:            configMap["oidc.owner_claim"],
+	}
+	if len(conf.Issuer) == 0 {
+		return nil, errors.New("oidc.issuer is not set")
+	}
+	if len(conf.ClientID) == 0 {
+		return nil, errors.New("oidc.client_id is not set")
+	}
+	if len(conf.RedirectURL) == 0 {
+		conf.RedirectURL = configMap["site.domain_url"]
+	}
+	if len(conf.RedirectURL) == 0 {
+		return nil, errors.New("oidc.redirect_url is not set")
+	}
+	if len(conf.PostLogoutRedirectURL) == 0 {
+		conf.PostLogoutRedirectURL = configMap["site.domain_url"]
+	}
+
+	conf.Scopes = strings.Fields(strings.Replace(getOrDefault(configMap, "oidc.scopes", "openid profile email"), ",", " ", -1))
+	hasOpenID := false
+	for _, scope := range conf.Scopes {
+		if scope == "openid" {
+			hasOpenID = true
+		}
+	}
+	if !hasOpenID {
+		conf.Scopes = append([]string{"openid"}, conf.Scopes...)
+	}
+	return conf, nil
+}
+
+func getOrDefault(configMap map[string]string, key, defaultValue string) string {
+	if value := strings.TrimSpace(configMap[key]); len(value) > 0 {
+		return value
+	}
+	return defaultValue
+}
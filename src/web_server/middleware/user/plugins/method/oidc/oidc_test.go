@@ -0,0 +1,283 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+)
+
+type testProvider struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	claims   map[string]interface{}
+	verifier string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp := &testProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 tp.server.URL,
+			"authorization_endpoint": tp.server.URL + "/authorize",
+			"token_endpoint":         tp.server.URL + "/token",
+			"jwks_uri":               tp.server.URL + "/jwks",
+			"end_session_endpoint":   tp.server.URL + "/logout",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "cmdb" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		r.ParseForm()
+		if r.Form.Get("code") != "code" || codeChallenge(r.Form.Get("code_verifier")) != codeChallenge(tp.verifier) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access",
+			"id_token":     tp.sign(t, "RS256", tp.claims),
+		})
+	})
+	tp.server = httptest.NewServer(mux)
+	return tp
+}
+
+func (tp *testProvider) sign(t *testing.T, alg string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, tp.key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (tp *testProvider) validClaims() map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":                tp.server.URL,
+		"sub":                "1",
+		"aud":                "cmdb",
+		"exp":                now.Add(time.Hour).Unix(),
+		"iat":                now.Unix(),
+		"nonce":              "nonce",
+		"preferred_username": "alice",
+		"name":               "Alice",
+		"locale":             "zh-CN",
+		"tenants":            []string{"0", "1"},
+	}
+}
+
+func (tp *testProvider) config(t *testing.T) *config {
+	conf, err := parseConfig(map[string]string{
+		"oidc.issuer":        tp.server.URL,
+		"oidc.client_id":     "cmdb",
+		"oidc.client_secret": "secret",
+		"oidc.owner_claim":   "tenants",
+		"site.domain_url":    "http://cmdb.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
+
+func TestParseConfig(t *testing.T) {
+	if _, err := parseConfig(map[string]string{"oidc.client_id": "cmdb", "site.domain_url": "http://cmdb"}); err == nil {
+		t.Error("config without issuer should be invalid")
+	}
+	conf, err := parseConfig(map[string]string{
+		"oidc.issuer":     "https://sso.example.com/",
+		"oidc.client_id":  "cmdb",
+		"oidc.scopes":     "profile,email",
+		"site.domain_url": "http://cmdb",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Issuer != "https://sso.example.com" || conf.RedirectURL != "http://cmdb" || conf.PostLogoutRedirectURL != "http://cmdb" {
+		t.Errorf("unexpected config: %+v", conf)
+	}
+	if strings.Join(conf.Scopes, " ") != "openid profile email" {
+		t.Errorf("unexpected scopes: %v", conf.Scopes)
+	}
+	if conf.UserClaim != "preferred_username" || conf.OwnerClaim != "" {
+		t.Errorf("unexpected claims: %+v", conf)
+	}
+}
+
+func TestLoginFlow(t *testing.T) {
+	tp := newTestProvider(t)
+	defer tp.server.Close()
+	conf := tp.config(t)
+	p, err := getProvider(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tp.verifier, _ = randomString(32)
+	authURL, err := p.authURL("state", "nonce", tp.verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	query := u.Query()
+	if u.Path != "/authorize" || query.Get("code_challenge") != codeChallenge(tp.verifier) ||
+		query.Get("code_challenge_method") != "S256" || query.Get("state") != "state" ||
+		query.Get("redirect_uri") != "http://cmdb.example.com" {
+		t.Errorf("unexpected auth url: %s", authURL)
+	}
+
+	tp.claims = tp.validClaims()
+	if _, err := p.exchange("code", "wrong verifier"); err == nil {
+		t.Error("exchange with wrong code verifier should fail")
+	}
+	token, err := p.exchange("code", tp.verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := p.verify(token.IDToken, "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := claimsToUser(conf, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UserName != "alice" || user.ChName != "Alice" || user.Language != "zh-cn" ||
+		user.OnwerUin != "0" || !user.MultiSupplier || len(user.OwnerUinArr) != 2 {
+		t.Errorf("unexpected user: %+v", user)
+	}
+
+	logoutURL, err := p.logoutURL(token.IDToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ = url.Parse(logoutURL)
+	if u.Path != "/logout" || u.Query().Get("id_token_hint") != token.IDToken {
+		t.Errorf("unexpected logout url: %s", logoutURL)
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	tp := newTestProvider(t)
+	defer tp.server.Close()
+	p, err := getProvider(tp.config(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.verify(tp.sign(t, "RS256", tp.validClaims()), "nonce"); err != nil {
+		t.Errorf("valid id token verify failed, err: %v", err)
+	}
+
+	invalid := map[string]func(claims map[string]interface{}){
+		"wrong audience": func(claims map[string]interface{}) { claims["aud"] = "other" },
+		"wrong issuer":   func(claims map[string]interface{}) { claims["iss"] = "https://other" },
+		"expired":        func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiry":      func(claims map[string]interface{}) { delete(claims, "exp") },
+		"wrong nonce":    func(claims map[string]interface{}) { claims["nonce"] = "other" },
+	}
+	for name, modify := range invalid {
+		claims := tp.validClaims()
+		modify(claims)
+		if _, err := p.verify(tp.sign(t, "RS256", claims), "nonce"); err == nil {
+			t.Errorf("%s id token should be invalid", name)
+		}
+	}
+
+	raw := tp.sign(t, "RS256", tp.validClaims())
+	parts := strings.Split(raw, ".")
+	claims := tp.validClaims()
+	claims["preferred_username"] = "admin"
+	payload, _ := json.Marshal(claims)
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+	if _, err := p.verify(tampered, "nonce"); err == nil {
+		t.Error("tampered id token should be invalid")
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "none", "kid": "test"})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + parts[1] + "."
+	if _, err := p.verify(unsigned, "nonce"); err == nil {
+		t.Error("unsigned id token should be invalid")
+	}
+}
+
+func TestClaimsToUser(t *testing.T) {
+	conf, _ := parseConfig(map[string]string{
+		"oidc.issuer":     "https://sso.example.com",
+		"oidc.client_id":  "cmdb",
+		"site.domain_url": "http://cmdb",
+	})
+
+	user, err := claimsToUser(conf, map[string]interface{}{"preferred_username": "bob", "locale": "en-US"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ChName != "bob" || user.Language != "en" || user.OnwerUin != common.BKDefaultOwnerID || user.MultiSupplier {
+		t.Errorf("unexpected user: %+v", user)
+	}
+
+	if _, err := claimsToUser(conf, map[string]interface{}{"name": "Bob"}); err == nil {
+		t.Error("claims without user name should be invalid")
+	}
+
+	conf.OwnerClaim = "tenant"
+	user, err = claimsToUser(conf, map[string]interface{}{"preferred_username": "bob", "tenant": "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.OnwerUin != "2" || user.MultiSupplier || len(user.OwnerUinArr) != 1 {
+		t.Errorf("unexpected user: %+v", user)
+	}
+	if _, err := claimsToUser(conf, map[string]interface{}{"preferred_username": "bob"}); err == nil {
+		t.Error("claims without owner should be invalid when the owner claim is set")
+	}
+}
@@ -0,0 +1,284 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/http/httpclient"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+	// discoveryTTL is how long the discovery document is cached
+	discoveryTTL = time.Hour
+	// jwksRefreshInterval limits how often the keys are fetched again for an unknown key id,
+	// the keys are rotated by the provider and a token signed with a new key may come first.
+	jwksRefreshInterval = time.Minute
+)
+
+// discovery is the provider metadata of openid connect discovery
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// provider talks to an openid connect provider, the discovery document and the keys are cached
+type provider struct {
+	conf   *config
+	client *httpclient.HttpClient
+
+	lock        sync.Mutex
+	discovery   *discovery
+	discoveryAt time.Time
+	keys        map[string]jsonWebKey
+	keysAt      time.Time
+}
+
+var (
+	providerLock sync.Mutex
+	providers    = make(map[string]*provider)
+)
+
+// getProvider returns the cached provider of the configuration, a new one is created when
+// the configuration changes.
+func getProvider(conf *config) (*provider, error) {
+	key := fmt.Sprintf("%+v", *conf)
+	providerLock.Lock()
+	defer providerLock.Unlock()
+	if p, exist := providers[key]; exist {
+		return p, nil
+	}
+
+	client := httpclient.NewHttpClient()
+	client.SetTimeOut(httpTimeout)
+	if len(conf.CAFile) > 0 {
+		if err := client.SetTlsVerityServer(conf.CAFile); err != nil {
+			return nil, fmt.Errorf("load oidc ca file %s failed, err: %v", conf.CAFile, err)
+		}
+	}
+	p := &provider{conf: conf, client: client}
+	// the configuration is changed, the old providers are not used any more
+	providers = map[string]*provider{key: p}
+	return p, nil
+}
+
+func (p *provider) getDiscovery() (*discovery, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.discovery != nil && time.Since(p.discoveryAt) < discoveryTTL {
+		return p.discovery, nil
+	}
+
+	doc := new(discovery)
+	if err := p.getJSON(p.conf.Issuer+"/.well-known/openid-configuration", doc); err != nil {
+		return nil, fmt.Errorf("get oidc discovery document failed, err: %v", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != p.conf.Issuer {
+		return nil, fmt.Errorf("oidc discovery issuer %s does not match the configured %s", doc.Issuer, p.conf.Issuer)
+	}
+	if len(doc.AuthorizationEndpoint) == 0 || len(doc.TokenEndpoint) == 0 || len(doc.JWKSURI) == 0 {
+		return nil, errors.New("oidc discovery document lacks authorization, token or jwks endpoint")
+	}
+	p.discovery = doc
+	p.discoveryAt = time.Now()
+	return doc, nil
+}
+
+// authURL returns the url of the authorization code request with pkce
+func (p *provider) authURL(state, nonce, verifier string) (string, error) {
+	doc, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.conf.ClientID)
+	query.Set("redirect_uri", p.conf.RedirectURL)
+	query.Set("scope", strings.Join(p.conf.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	return appendQuery(doc.AuthorizationEndpoint, query), nil
+}
+
+// logoutURL returns the rp-initiated logout url, or empty if the provider does not support it
+func (p *provider) logoutURL(idToken string) (string, error) {
+	doc, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+	if len(doc.EndSessionEndpoint) == 0 {
+		return "", nil
+	}
+	query := url.Values{}
+	query.Set("client_id", p.conf.ClientID)
+	if len(idToken) > 0 {
+		query.Set("id_token_hint", idToken)
+	}
+	if len(p.conf.PostLogoutRedirectURL) > 0 {
+		query.Set("post_logout_redirect_uri", p.conf.PostLogoutRedirectURL)
+	}
+	return appendQuery(doc.EndSessionEndpoint, query), nil
+}
+
+// exchange exchanges the authorization code for the tokens with the pkce code verifier
+func (p *provider) exchange(code, verifier string) (*tokenResponse, error) {
+	doc, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.conf.RedirectURL)
+	form.Set("client_id", p.conf.ClientID)
+	form.Set("code_verifier", verifier)
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-www-form-urlencoded")
+	header.Set("Accept", "application/json")
+	if len(p.conf.ClientSecret) > 0 {
+		// client_secret_basic, the default client authentication method
+		credential := url.QueryEscape(p.conf.ClientID) + ":" + url.QueryEscape(p.conf.ClientSecret)
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credential)))
+	}
+
+	status, body, err := p.client.POSTEx(doc.TokenEndpoint, header, []byte(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("request oidc token endpoint failed, err: %v", err)
+	}
+	token := new(tokenResponse)
+	if err := json.Unmarshal(body, token); err != nil {
+		return nil, fmt.Errorf("unmarshal oidc token response failed, status: %d, body: %s, err: %v", status, body, err)
+	}
+	if status != http.StatusOK || len(token.Error) > 0 {
+		return nil, fmt.Errorf("oidc token request failed, status: %d, error: %s, description: %s", status, token.Error, token.ErrorDescription)
+	}
+	if len(token.IDToken) == 0 {
+		return nil, errors.New("oidc token response has no id token")
+	}
+	return token, nil
+}
+
+// verify verifies the id token with the provider's keys and returns its claims
+func (p *provider) verify(rawIDToken, nonce string) (map[string]interface{}, error) {
+	token, err := parseIDToken(rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := p.getKeys(token.header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	verified := false
+	for _, key := range keys {
+		pub, err := key.publicKey()
+		if err != nil {
+			continue
+		}
+		if err := token.verifySignature(pub); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("id token signature verification failed")
+	}
+
+	if err := token.verifyClaims(p.conf.Issuer, p.conf.ClientID, nonce, time.Now()); err != nil {
+		return nil, err
+	}
+	return token.claims, nil
+}
+
+// getKeys returns the signing keys of the key id, all the signing keys if the key id is empty
+func (p *provider) getKeys(kid string) ([]jsonWebKey, error) {
+	doc, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	_, known := p.keys[kid]
+	if p.keys == nil || (len(kid) > 0 && !known && time.Since(p.keysAt) > jwksRefreshInterval) {
+		set := new(jsonWebKeySet)
+		if err := p.getJSON(doc.JWKSURI, set); err != nil {
+			return nil, fmt.Errorf("get oidc jwks failed, err: %v", err)
+		}
+		keys := make(map[string]jsonWebKey)
+		for _, key := range set.Keys {
+			if key.Use == "enc" {
+				continue
+			}
+			keys[key.Kid] = key
+		}
+		p.keys = keys
+		p.keysAt = time.Now()
+	}
+
+	if len(kid) > 0 {
+		key, exist := p.keys[kid]
+		if !exist {
+			return nil, fmt.Errorf("oidc signing key %s not found", kid)
+		}
+		return []jsonWebKey{key}, nil
+	}
+	keys := make([]jsonWebKey, 0, len(p.keys))
+	for _, key := range p.keys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (p *provider) getJSON(address string, result interface{}) error {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	status, body, err := p.client.GETEx(address, header, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("http status %d, body: %s", status, body)
+	}
+	return json.Unmarshal(body, result)
+}
+
+func appendQuery(address string, query url.Values) string {
+	if strings.Contains(address, "?") {
+		return address + "&" + query.Encode()
+	}
+	return address + "?" + query.Encode()
+}
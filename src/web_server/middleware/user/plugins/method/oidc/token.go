@@ -0,0 +1,295 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// clockSkew is the tolerance of the time claims of the id token
+const clockSkew = time.Minute
+
+// randomString returns a url safe random string of the bytes length
+func randomString(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge returns the S256 code challenge of the pkce code verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// jsonWebKey is a public key of the provider's jwks
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// rsa keys
+	N string `json:"n"`
+	E string `json:"e"`
+	// ec keys
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// publicKey returns the rsa or ecdsa public key of the json web key
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rsa modulus, err: %v", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rsa exponent, err: %v", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("rsa exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ec x coordinate, err: %v", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ec y coordinate, err: %v", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("ec point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// idToken is a parsed but not yet verified id token
+type idToken struct {
+	header    tokenHeader
+	claims    map[string]interface{}
+	signed    []byte
+	signature []byte
+}
+
+func parseIDToken(raw string) (*idToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("id token is not a compact jws")
+	}
+	token := &idToken{signed: []byte(parts[0] + "." + parts[1])}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("decode id token header failed, err: %v", err)
+	}
+	if err := json.Unmarshal(headerBytes, &token.header); err != nil {
+		return nil, fmt.Errorf("unmarshal id token header failed, err: %v", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("decode id token payload failed, err: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&token.claims); err != nil {
+		return nil, fmt.Errorf("unmarshal id token claims failed, err: %v", err)
+	}
+
+	token.signature, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode id token signature failed, err: %v", err)
+	}
+	return token, nil
+}
+
+// verifySignature verifies the signature with the key, only the asymmetric algorithms are accepted,
+// so that a token signed with "none" or the client secret can not be forged.
+func (t *idToken) verifySignature(key crypto.PublicKey) error {
+	var hash crypto.Hash
+	switch t.header.Alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported id token algorithm %s", t.header.Alg)
+	}
+	hasher := hash.New()
+	hasher.Write(t.signed)
+	digest := hasher.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(t.header.Alg, "RS") {
+			return fmt.Errorf("algorithm %s does not match the rsa key", t.header.Alg)
+		}
+		return rsa.VerifyPKCS1v15(pub, hash, digest, t.signature)
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(t.header.Alg, "ES") {
+			return fmt.Errorf("algorithm %s does not match the ec key", t.header.Alg)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(t.signature) != 2*size {
+			return errors.New("invalid ecdsa signature length")
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("ecdsa signature verification failed")
+		}
+		return nil
+	}
+	return errors.New("unsupported public key")
+}
+
+// verifyClaims verifies the standard claims of the id token as the openid connect core spec requires
+func (t *idToken) verifyClaims(issuer, clientID, nonce string, now time.Time) error {
+	if iss, _ := t.claims["iss"].(string); iss != issuer {
+		return fmt.Errorf("id token issuer %s does not match %s", iss, issuer)
+	}
+
+	audiences := claimStrings(t.claims, "aud")
+	found := false
+	for _, aud := range audiences {
+		if aud == clientID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("id token audience %v does not contain the client id", audiences)
+	}
+	if len(audiences) > 1 {
+		if azp, _ := t.claims["azp"].(string); azp != clientID {
+			return fmt.Errorf("id token authorized party %s is not the client id", azp)
+		}
+	}
+
+	exp, ok := claimTime(t.claims, "exp")
+	if !ok {
+		return errors.New("id token has no expiration time")
+	}
+	if now.After(exp.Add(clockSkew)) {
+		return fmt.Errorf("id token expired at %s", exp)
+	}
+	if iat, ok := claimTime(t.claims, "iat"); ok && iat.After(now.Add(clockSkew)) {
+		return fmt.Errorf("id token issued in the future at %s", iat)
+	}
+
+	if tokenNonce, _ := t.claims["nonce"].(string); tokenNonce != nonce {
+		return errors.New("id token nonce does not match")
+	}
+	return nil
+}
+
+// claimStrings returns the claim as a string array, a single string is taken as an array with one element
+func claimStrings(claims map[string]interface{}, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		if len(value) == 0 {
+			return nil
+		}
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok && len(s) > 0 {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func claimString(claims map[string]interface{}, name string) string {
+	if len(name) == 0 {
+		return ""
+	}
+	switch value := claims[name].(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	}
+	return ""
+}
+
+func claimTime(claims map[string]interface{}, name string) (time.Time, bool) {
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
@@ -0,0 +1,250 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	commonutil "configcenter/src/common/util"
+	"configcenter/src/web_server/middleware/user/plugins/manager"
+
+	"github.com/gin-gonic/gin"
+	"github.com/holmeswang/contrib/sessions"
+)
+
+func init() {
+	plugin := &metadata.LoginPluginInfo{
+		Name:       "openid connect login",
+		Version:    common.BKOIDCLoginUserPluginVersion,
+		HandleFunc: &user{},
+	}
+	manager.RegisterPlugin(plugin)
+}
+
+// the session keys of the login in progress and the logged in user
+const (
+	sessionStateKey        = "oidc_state"
+	sessionNonceKey        = "oidc_nonce"
+	sessionCodeVerifierKey = "oidc_code_verifier"
+	sessionIDTokenKey      = "oidc_id_token"
+)
+
+type user struct {
+}
+
+// LoginUser logs the user in with the authorization code the provider redirects back with,
+// the requests without it are redirected to the provider by the login url.
+func (m *user) LoginUser(c *gin.Context, config map[string]string, isMultiOwner bool) (user *metadata.LoginUserInfo, loginSucc bool) {
+	rid := commonutil.GetHTTPCCRequestID(c.Request.Header)
+
+	if errCode := c.Query("error"); len(errCode) > 0 {
+		blog.Errorf("oidc login failed, provider returns error: %s, description: %s, rid: %s", errCode, c.Query("error_description"), rid)
+		return nil, false
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if len(code) == 0 || len(state) == 0 {
+		return nil, false
+	}
+
+	session := sessions.Default(c)
+	expectState, _ := session.Get(sessionStateKey).(string)
+	nonce, _ := session.Get(sessionNonceKey).(string)
+	verifier, _ := session.Get(sessionCodeVerifierKey).(string)
+	if len(expectState) == 0 || state != expectState {
+		blog.Errorf("oidc login failed, state %s does not match the session, rid: %s", state, rid)
+		return nil, false
+	}
+	// the state can be used only once
+	session.Delete(sessionStateKey)
+	session.Delete(sessionNonceKey)
+	session.Delete(sessionCodeVerifierKey)
+
+	conf, err := parseConfig(config)
+	if err != nil {
+		blog.Errorf("oidc login failed, invalid config, err: %v, rid: %s", err, rid)
+		return nil, false
+	}
+	p, err := getProvider(conf)
+	if err != nil {
+		blog.Errorf("oidc login failed, get provider failed, err: %v, rid: %s", err, rid)
+		return nil, false
+	}
+
+	token, err := p.exchange(code, verifier)
+	if err != nil {
+		blog.Errorf("oidc login failed, exchange code failed, err: %v, rid: %s", err, rid)
+		return nil, false
+	}
+	claims, err := p.verify(token.IDToken, nonce)
+	if err != nil {
+		blog.Errorf("oidc login failed, verify id token failed, err: %v, rid: %s", err, rid)
+		return nil, false
+	}
+
+	user, err = claimsToUser(conf, claims)
+	if err != nil {
+		blog.Errorf("oidc login failed, err: %v, rid: %s", err, rid)
+		return nil, false
+	}
+
+	// the session is bound to the login by a token cookie like the blueking login system does
+	user.BkToken, err = randomString(32)
+	if err != nil {
+		blog.Errorf("oidc login failed, generate token failed, err: %v, rid: %s", err, rid)
+		return nil, false
+	}
+	c.SetCookie(common.HTTPCookieBKToken, user.BkToken, 0, "/", "", false, true)
+	session.Set(sessionIDTokenKey, token.IDToken)
+
+	blog.Infof("oidc login success, user: %s, owner: %s, rid: %s", user.UserName, user.OnwerUin, rid)
+	return user, true
+}
+
+// claimsToUser maps the id token claims to the login user
+func claimsToUser(conf *config, claims map[string]interface{}) (*metadata.LoginUserInfo, error) {
+	user := &metadata.LoginUserInfo{
+		UserName: claimString(claims, conf.UserClaim),
+		ChName:   claimString(claims, conf.ChNameClaim),
+		Email:    claimString(claims, conf.EmailClaim),
+		Phone:    claimString(claims, conf.PhoneClaim),
+		Language: normalizeLanguage(claimString(claims, conf.LanguageClaim)),
+		OnwerUin: common.BKDefaultOwnerID,
+	}
+	if len(user.UserName) == 0 {
+		return nil, fmt.Errorf("id token has no user claim %s", conf.UserClaim)
+	}
+	if len(user.ChName) == 0 {
+		user.ChName = user.UserName
+	}
+
+	if len(conf.OwnerClaim) == 0 {
+		return user, nil
+	}
+	owners := claimStrings(claims, conf.OwnerClaim)
+	if len(owners) == 0 {
+		return nil, fmt.Errorf("id token has no owner claim %s", conf.OwnerClaim)
+	}
+	user.OnwerUin = owners[0]
+	user.MultiSupplier = len(owners) > 1
+	for _, owner := range owners {
+		user.OwnerUinArr = append(user.OwnerUinArr, metadata.LoginUserInfoOwnerUinList{
+			OwnerID:   owner,
+			OwnerName: owner,
+		})
+	}
+	return user, nil
+}
+
+// normalizeLanguage maps the bcp47 locale to the languages cmdb supports
+func normalizeLanguage(locale string) string {
+	locale = strings.ToLower(locale)
+	switch {
+	case strings.HasPrefix(locale, "zh"):
+		return "zh-cn"
+	case strings.HasPrefix(locale, "en"):
+		return "en"
+	}
+	return ""
+}
+
+// GetUserList returns the current user, openid connect has no api to list the users
+func (m *user) GetUserList(c *gin.Context, config map[string]string, params map[string]string) ([]*metadata.LoginSystemUserInfo, error) {
+	session := sessions.Default(c)
+	userName, _ := session.Get(common.WEBSessionUinKey).(string)
+	if len(userName) == 0 {
+		return nil, fmt.Errorf("user not logged in")
+	}
+	chName, _ := session.Get(common.WEBSessionChineseNameKey).(string)
+	return []*metadata.LoginSystemUserInfo{
+		{
+			CnName: chName,
+			EnName: userName,
+		},
+	}, nil
+}
+
+// GetLoginUrl starts an authorization code flow with pkce, the state, nonce and code verifier
+// are kept in the session to check the code the provider redirects back with.
+func (m *user) GetLoginUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	rid := commonutil.GetHTTPCCRequestID(c.Request.Header)
+
+	conf, err := parseConfig(config)
+	if err != nil {
+		blog.Errorf("get oidc login url failed, invalid config, err: %v, rid: %s", err, rid)
+		return ""
+	}
+	p, err := getProvider(conf)
+	if err != nil {
+		blog.Errorf("get oidc login url failed, get provider failed, err: %v, rid: %s", err, rid)
+		return ""
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		if values[i], err = randomString(32); err != nil {
+			blog.Errorf("get oidc login url failed, generate random string failed, err: %v, rid: %s", err, rid)
+			return ""
+		}
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	loginURL, err := p.authURL(state, nonce, verifier)
+	if err != nil {
+		blog.Errorf("get oidc login url failed, err: %v, rid: %s", err, rid)
+		return ""
+	}
+
+	session := sessions.Default(c)
+	session.Set(sessionStateKey, state)
+	session.Set(sessionNonceKey, nonce)
+	session.Set(sessionCodeVerifierKey, verifier)
+	if err := session.Save(); err != nil {
+		blog.Errorf("get oidc login url failed, save session failed, err: %v, rid: %s", err, rid)
+		return ""
+	}
+	return loginURL
+}
+
+// GetLogoutUrl returns the provider's end session url, so that the user is logged out of the provider too.
+func (m *user) GetLogoutUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	rid := commonutil.GetHTTPCCRequestID(c.Request.Header)
+
+	c.SetCookie(common.HTTPCookieBKToken, "", -1, "/", "", false, true)
+
+	conf, err := parseConfig(config)
+	if err != nil {
+		blog.Errorf("get oidc logout url failed, invalid config, err: %v, rid: %s", err, rid)
+		return ""
+	}
+	p, err := getProvider(conf)
+	if err != nil {
+		blog.Errorf("get oidc logout url failed, get provider failed, err: %v, rid: %s", err, rid)
+		return conf.PostLogoutRedirectURL
+	}
+
+	idToken, _ := sessions.Default(c).Get(sessionIDTokenKey).(string)
+	logoutURL, err := p.logoutURL(idToken)
+	if err != nil {
+		blog.Errorf("get oidc logout url failed, err: %v, rid: %s", err, rid)
+		return conf.PostLogoutRedirectURL
+	}
+	if len(logoutURL) == 0 {
+		// the provider does not support logout, go back to cmdb and login again
+		return conf.PostLogoutRedirectURL
+	}
+	return logoutURL
+}
@@ -147,8 +147,23 @@ func (m *publicUser) GetUserList(c *gin.Context) (int, interface{}) {
 }
 
 func (m *publicUser) GetLoginUrl(c *gin.Context) string {
-	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	return m.getLoginUrl(c, m.getLogoutRequestParams(c))
+}
 
+// GetLogoutUrl returns the url to log out of the login system, the login url is used
+// when the login plugin does not log out by itself.
+func (m *publicUser) GetLogoutUrl(c *gin.Context) string {
+	params := m.getLogoutRequestParams(c)
+	if nil == m.loginPlg {
+		user := plugins.CurrentPlugin(c, m.config.LoginVersion)
+		if logout, ok := user.(metadata.LoginUserPluginLogout); ok {
+			return logout.GetLogoutUrl(c, m.config.ConfigMap, params)
+		}
+	}
+	return m.getLoginUrl(c, params)
+}
+
+func (m *publicUser) getLogoutRequestParams(c *gin.Context) *metadata.LogoutRequestParams {
 	params := new(metadata.LogoutRequestParams)
 	err := json.NewDecoder(c.Request.Body).Decode(params)
 	if nil != err || (common.LogoutHTTPSchemeHTTP != params.HTTPScheme && common.LogoutHTTPSchemeHTTPS != params.HTTPScheme) {
@@ -157,6 +172,11 @@ func (m *publicUser) GetLoginUrl(c *gin.Context) string {
 			params.HTTPScheme = common.LogoutHTTPSchemeHTTP
 		}
 	}
+	return params
+}
+
+func (m *publicUser) getLoginUrl(c *gin.Context, params *metadata.LogoutRequestParams) string {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
 
 	if nil == m.loginPlg {
 		user := plugins.CurrentPlugin(c, m.config.LoginVersion)
@@ -26,6 +26,7 @@ type User interface {
 	LoginUser(c *gin.Context) (isLogin bool)
 	GetUserList(c *gin.Context) (int, interface{})
 	GetLoginUrl(c *gin.Context) string
+	GetLogoutUrl(c *gin.Context) string
 }
 
 // NewUser return user instance by type
@@ -13,7 +13,14 @@
 package service
 
 import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 	"configcenter/src/web_server/middleware/user"
 
 	"github.com/gin-gonic/gin"
@@ -22,14 +29,81 @@ import (
 
 // LogOutUser log out user
 func (s *Service) LogOutUser(c *gin.Context) {
-	session := sessions.Default(c)
-	session.Clear()
 	c.Request.URL.Path = ""
 	userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli, s.VersionPlg)
-	loginURL := userManger.GetLoginUrl(c)
+	// the logout url may depend on the session of the login, so get it before the session is cleared
+	logoutURL := userManger.GetLogoutUrl(c)
+	session := sessions.Default(c)
+	session.Clear()
 	ret := metadata.LogoutResult{}
 	ret.BaseResp.Result = true
-	ret.Data.LogoutURL = loginURL
+	ret.Data.LogoutURL = logoutURL
 	c.JSON(200, ret)
 	return
 }
+
+var loginPageTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>蓝鲸配置平台</title>
+<style>
+body { font-family: sans-serif; background: #f5f6fa; }
+form { width: 320px; margin: 120px auto; padding: 32px; background: #fff; box-shadow: 0 2px 6px rgba(0,0,0,.1); }
+input { display: block; width: 100%; box-sizing: border-box; margin: 8px 0 16px; padding: 8px; }
+button { width: 100%; padding: 8px; background: #3a84ff; color: #fff; border: none; cursor: pointer; }
+.error { color: #ea3636; }
+</style>
+</head>
+<body>
+<form method="post" action="{{.Action}}">
+<h3>蓝鲸配置平台</h3>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<label>用户名<input name="username" value="{{.UserName}}" autofocus></label>
+<label>密码<input name="password" type="password"></label>
+<input type="hidden" name="{{.RedirectKey}}" value="{{.Redirect}}">
+<button type="submit">登录</button>
+</form>
+</body>
+</html>`))
+
+// LoginPage shows the login page of the login plugins which authenticate with the user name and password
+func (s *Service) LoginPage(c *gin.Context) {
+	s.renderLoginPage(c, http.StatusOK, "", c.Query(common.WebLoginRedirectKey))
+}
+
+// Login logs the user in with the user name and password posted by the login page,
+// and redirects to the page the user visited before login.
+func (s *Service) Login(c *gin.Context) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	redirect := c.PostForm(common.WebLoginRedirectKey)
+
+	userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli, s.VersionPlg)
+	if !userManger.LoginUser(c) {
+		blog.Infof("login with password failed, user: %s, rid: %s", c.PostForm("username"), rid)
+		s.renderLoginPage(c, http.StatusUnauthorized, "用户名或密码错误", redirect)
+		return
+	}
+
+	// only the pages of cmdb can be redirected to
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") ||
+		strings.HasPrefix(redirect, common.WebLoginPagePath) {
+		redirect = "/"
+	}
+	c.Redirect(http.StatusFound, strings.TrimSuffix(s.Config.Site.DomainUrl, "/")+redirect)
+}
+
+func (s *Service) renderLoginPage(c *gin.Context, status int, errMsg, redirect string) {
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	err := loginPageTemplate.Execute(c.Writer, map[string]string{
+		"Action":      strings.TrimSuffix(s.Config.Site.DomainUrl, "/") + common.WebLoginPagePath,
+		"Error":       errMsg,
+		"UserName":    c.PostForm("username"),
+		"RedirectKey": common.WebLoginRedirectKey,
+		"Redirect":    redirect,
+	})
+	if err != nil {
+		blog.Errorf("render login page failed, err: %v, rid: %s", err, util.GetHTTPCCRequestID(c.Request.Header))
+	}
+}